@@ -2,7 +2,9 @@ package app
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/utils/breaker"
 	"github.com/go-ozzo/ozzo-validation"
 	"github.com/spf13/viper"
 )
@@ -25,8 +27,13 @@ type appConfig struct {
 	TakeFee float64 `mapstructure:"take_fee"`
 	// the Rabbitmq is the URI of rabbitmq to use
 	Rabbitmq string `mapstructure:"rabbitmq"`
-
+	// the Ethereum is a comma separated list of ethereum rpc endpoints, in order of preference
 	Ethereum string `mapstructure:"ethereum"`
+	// the EthereumHealthCheck is the interval in seconds between the health checks of the
+	// ethereum endpoints. The calls fail over to the next healthy endpoint.
+	EthereumHealthCheck int `mapstructure:"ethereum_health_check"`
+	// the EthereumRoundRobin spreads the read calls over the healthy ethereum endpoints
+	EthereumRoundRobin bool `mapstructure:"ethereum_round_robin"`
 
 	WETH string `mapstructure:"weth"`
 	// the redis is the URI of redis to use
@@ -41,8 +48,397 @@ type appConfig struct {
 	TickDuration map[string][]int64 `mapstructure:"tick_duration"`
 	// ExchangeAddress is the address of the exchange smart-contract
 	ExchangeAddress string `mapstructure:"exchange"`
+	// ZeroExExchangeAddress is the address of the 0x exchange smart-contract. The signed 0x
+	// orders are rejected unless they are for this exchange.
+	ZeroExExchangeAddress string `mapstructure:"zeroex_exchange"`
 	// Decimal is the number of decimal places used in matching engine
 	Decimal int `mapstructure:"decimal"`
+	// EngineMode selects how the matching engine is run. "embedded" runs the matcher in the
+	// API process, "matcher" only runs the matcher and "api" only runs the API and forwards
+	// orders to a separate matcher process. Defaults to "embedded"
+	EngineMode string `mapstructure:"engine_mode"`
+	// ReadOnly runs the API as a read replica serving market data only (tokens, pairs,
+	// orderbook, trades and OHLCV). Order entry, accounts and admin endpoints are disabled
+	// and the process neither consumes engine messages nor launches listings. Defaults to false
+	ReadOnly bool `mapstructure:"read_only"`
+	// EngineShard is the shard of pairs matched by this process in matcher mode.
+	// Defaults to the default shard which matches all unassigned pairs
+	EngineShard string `mapstructure:"engine_shard"`
+	// EngineShards assigns pair names to shards in api mode (eg. {"zrx": ["ZRX/WETH"]}).
+	// Assignments can also be stored in the "engine::shards" redis hash.
+	EngineShards map[string][]string `mapstructure:"engine_shards"`
+	// WalletKeyID is the id of the key used to encrypt wallet private keys at rest
+	WalletKeyID string `mapstructure:"wallet_key_id"`
+	// WalletKeys maps key ids to the passphrases used to derive wallet encryption keys.
+	// Previous keys should be kept until all wallet records have been rotated.
+	WalletKeys map[string]string `mapstructure:"wallet_keys"`
+	// AutoPairs is the policy used to create pairs automatically when tokens are listed
+	AutoPairs AutoPairsConfig `mapstructure:"auto_pairs"`
+	// Breakers configures the circuit breakers of the outbound dependencies ("mongodb",
+	// "redis" and "ethereum"). The dependencies that are not configured use the defaults.
+	Breakers map[string]BreakerConfig `mapstructure:"breakers"`
+	// RequestTimeout is the number of seconds after which a request is answered with a
+	// 503 error. Defaults to 30
+	RequestTimeout int `mapstructure:"request_timeout"`
+	// RouteTimeouts overrides the request timeout, in seconds, of the routes starting with
+	// a path prefix (eg. {"/orders": 5}). The longest matching prefix is used.
+	RouteTimeouts map[string]int `mapstructure:"route_timeouts"`
+	// OperatorBalance configures the monitoring of the ether balance of the operator wallet
+	OperatorBalance OperatorBalanceConfig `mapstructure:"operator_balance"`
+	// NonceCheckInterval is the number of seconds between two checks of the nonces of the
+	// operator accounts against the node. Defaults to 30
+	NonceCheckInterval int `mapstructure:"nonce_check_interval"`
+	// SelfTradePrevention is applied by the engine when an order would match an order
+	// of the same maker: "cancel-newest", "cancel-oldest" or "cancel-both". Self-trades
+	// are allowed if it is empty. Defaults to "cancel-newest"
+	SelfTradePrevention string `mapstructure:"self_trade_prevention"`
+	// QuoteThrottles are the minimum resting times and the update frequency caps enforced
+	// by the engine on the makers of each pair. The throttle without pair applies to the
+	// pairs without throttle.
+	QuoteThrottles []QuoteThrottleConfig `mapstructure:"quote_throttles"`
+	// OrderBookLimits cap the number of orders of the orderbook of each pair. The limit
+	// without pair applies to the pairs without limit.
+	OrderBookLimits []OrderBookLimitConfig `mapstructure:"orderbook_limits"`
+	// ChainLag configures the monitoring of the age of the latest ethereum block
+	ChainLag ChainLagConfig `mapstructure:"chain_lag"`
+	// Deposits configures the crediting of the tokens transferred to the exchange contract
+	Deposits DepositsConfig `mapstructure:"deposits"`
+	// Settlement configures the sending of the settlement transactions of the trades and
+	// when they are final
+	Settlement SettlementConfig `mapstructure:"settlement"`
+	// SettlementWebhook configures the posting of the settled trades to an external system
+	SettlementWebhook SettlementWebhookConfig `mapstructure:"settlement_webhook"`
+	// Withdraws configures the execution of the withdrawal requests of the traders
+	Withdraws WithdrawsConfig `mapstructure:"withdraws"`
+	// GasPrice configures the gas prices of the transactions sent by the operator
+	GasPrice GasPriceConfig `mapstructure:"gas_price"`
+	// FiatPrices configures the feed of the token prices in fiat currencies, used to value
+	// the portfolios of the accounts
+	FiatPrices FiatPricesConfig `mapstructure:"fiat_prices"`
+	// ChainID is the id of the ethereum chain, used in the EIP712 domain of the orders
+	// signed with eth_signTypedData. Defaults to 1 (main network)
+	ChainID int64 `mapstructure:"chain_id"`
+	// AccountTiers are the default fees and order limits of the accounts, selected by the
+	// account tags. The first tier whose tag is set on an account applies, or the tier
+	// without tag. The accounts can have a limit override taking precedence over their tier.
+	AccountTiers []AccountTierConfig `mapstructure:"account_tiers"`
+	// Admins maps the names of the administrators to their api keys, sent in the
+	// X-Admin-Key header. The destructive admin actions need the approval of two of them.
+	Admins map[string]string `mapstructure:"admins"`
+	// ApprovalTTL is the number of hours after which a pending admin action expires.
+	// Defaults to 24
+	ApprovalTTL int `mapstructure:"approval_ttl"`
+	// JournalRetention is the number of days the order journal (the decisions of the
+	// order service and the engine about each order) is kept. Defaults to 30
+	JournalRetention int `mapstructure:"journal_retention"`
+	// MaxClockSkew is the maximum difference in seconds between the timestamp of a signed
+	// request (eg. a user or balances channel subscription) and the server time. Defaults to 300
+	MaxClockSkew int `mapstructure:"max_clock_skew"`
+	// WSMaxMessageSize is the maximum size in bytes of a websocket message sent by a
+	// client, the connection is closed when it is exceeded. Defaults to 262144
+	WSMaxMessageSize int `mapstructure:"ws_max_message_size"`
+	// WSPingInterval is the number of seconds between the pings sent to the websocket
+	// connections and WSPongDeadline the number of seconds a client has to answer a ping
+	// before its connection is closed. Default to 30 and 10
+	WSPingInterval int `mapstructure:"ws_ping_interval"`
+	WSPongDeadline int `mapstructure:"ws_pong_deadline"`
+	// WSAuthRequired rejects the orders and cancels of the websocket connections that are
+	// not authenticated on the auth channel. Defaults to false
+	WSAuthRequired bool `mapstructure:"ws_auth_required"`
+	// RateLimit sets the request quotas of the REST API
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	// WSRateLimit limits the rate of the messages sent by the websocket clients
+	WSRateLimit WSRateLimitConfig `mapstructure:"ws_rate_limit"`
+	// LatencyBreakdown adds the latency in milliseconds between the stages of the order
+	// pipeline to the ORDER_ADDED messages. Meant for debugging, defaults to false
+	LatencyBreakdown bool `mapstructure:"latency_breakdown"`
+	// Consistency configures the periodic comparison of the orderbooks and of the locked
+	// balances with the orders stored in the database
+	Consistency ConsistencyConfig `mapstructure:"consistency"`
+	// RFQQuoteTTL is the number of seconds the maker quantity of a firm quote is reserved
+	// for the taker before it is released. Defaults to 10
+	RFQQuoteTTL int `mapstructure:"rfq_quote_ttl"`
+	// MetadataCacheTTL is the number of seconds the tokens and pairs read by the order
+	// validation are kept in memory. Defaults to 60, 0 disables the cache
+	MetadataCacheTTL int `mapstructure:"metadata_cache_ttl"`
+	// TickerInterval is the number of seconds between the updates of the 24 hour tickers
+	// sent on the tickers channel. Defaults to 10, 0 disables the updates
+	TickerInterval int `mapstructure:"ticker_interval"`
+	// Engine configures the storage of the orderbooks matched by the engine
+	Engine EngineConfig `mapstructure:"engine"`
+	// SkipSelfCheck disables the verification of the versions and features of mongodb,
+	// redis and rabbitmq and of the exchange contract on startup. Defaults to false
+	SkipSelfCheck bool `mapstructure:"skip_self_check"`
+}
+
+// AutoPairsConfig is the policy used to create pairs automatically when a token is
+// listed. A pair is created between the listed token, as base token, and each quote token.
+type AutoPairsConfig struct {
+	// Enabled turns on automatic pair creation. Defaults to false
+	Enabled bool `mapstructure:"enabled"`
+	// QuoteTokens are the contract addresses of the quote tokens (eg. WETH, stablecoins).
+	// Defaults to all the tokens flagged as quote tokens.
+	QuoteTokens []string `mapstructure:"quote_tokens"`
+	// Active sets whether the created pairs are active. Defaults to true
+	Active bool `mapstructure:"active"`
+	// MakeFee and TakeFee are the fees of the created pairs. Defaults to 0
+	MakeFee int64 `mapstructure:"make_fee"`
+	TakeFee int64 `mapstructure:"take_fee"`
+	// PricePrecision and AmountPrecision are the display precisions of the created pairs
+	PricePrecision  int `mapstructure:"price_precision"`
+	AmountPrecision int `mapstructure:"amount_precision"`
+}
+
+// AccountTierConfig sets the fees and the order limits of the accounts with a tag
+type AccountTierConfig struct {
+	// Tag is the account tag selecting the tier, the tier without tag is the default tier
+	Tag string `mapstructure:"tag"`
+	// MakeFee and TakeFee replace the fees of the pairs when they are set
+	MakeFee *int64 `mapstructure:"make_fee"`
+	TakeFee *int64 `mapstructure:"take_fee"`
+	// OrdersPerMinute is the number of orders an account can place per minute. Not
+	// limited if 0
+	OrdersPerMinute int `mapstructure:"orders_per_minute"`
+	// MaxOpenOrders is the number of orders of an account that can rest in the orderbooks.
+	// Not limited if 0
+	MaxOpenOrders int `mapstructure:"max_open_orders"`
+}
+
+// QuoteThrottleConfig limits the quote updates of the makers of a pair
+type QuoteThrottleConfig struct {
+	// Pair is the name of the pair (eg. "ZRX/WETH"), the throttle without pair is the default
+	Pair string `mapstructure:"pair"`
+	// MinRestingTime is the number of milliseconds an order must rest in the orderbook
+	// before it can be cancelled. Not limited if 0
+	MinRestingTime int `mapstructure:"min_resting_time"`
+	// MaxUpdates is the number of orders and cancels a maker can send on the pair per
+	// second. Not limited if 0
+	MaxUpdates int `mapstructure:"max_updates"`
+}
+
+// OrderBookLimitConfig caps the number of orders of the orderbook of a pair to protect
+// the redis memory
+type OrderBookLimitConfig struct {
+	// Pair is the name of the pair (eg. "ZRX/WETH"), the limit without pair is the default
+	Pair string `mapstructure:"pair"`
+	// MaxOrders is the number of orders (bids and asks) of the orderbook. Not limited if 0
+	MaxOrders int `mapstructure:"max_orders"`
+	// Policy is applied to the orders that would rest in a full orderbook: "reject"
+	// rejects them, "evict" cancels the order furthest from the touch on their side when
+	// they have a better price. Defaults to "reject"
+	Policy string `mapstructure:"policy"`
+}
+
+// OperatorBalanceConfig sets the thresholds of the ether balance of the operator wallet
+// below which alerts are sent, and where the alerts are sent. Settlement is paused below
+// the critical threshold so that transactions do not fail for lack of gas.
+type OperatorBalanceConfig struct {
+	// CheckInterval is the number of seconds between two balance checks. Defaults to 15 (about a block)
+	CheckInterval int `mapstructure:"check_interval"`
+	// WarningThreshold is the balance in ether below which alerts are sent. Defaults to 1
+	WarningThreshold float64 `mapstructure:"warning_threshold"`
+	// CriticalThreshold is the balance in ether below which settlement is paused. Defaults to 0.2
+	CriticalThreshold float64 `mapstructure:"critical_threshold"`
+	// WebhookURL receives the alerts as json POST requests
+	WebhookURL string `mapstructure:"webhook_url"`
+	// Email sends the alerts by email when an smtp server is set
+	Email AlertEmailConfig `mapstructure:"email"`
+}
+
+// ChainLagConfig sets when the ethereum node is considered stale. Settlement is paused and
+// the balances are flagged as stale while the latest block is older than MaxLag.
+type ChainLagConfig struct {
+	// CheckInterval is the number of seconds between two checks of the latest block. Defaults to 15
+	CheckInterval int `mapstructure:"check_interval"`
+	// MaxLag is the age in seconds of the latest block above which the chain is stale. Defaults to 120
+	MaxLag int `mapstructure:"max_lag"`
+}
+
+// DepositsConfig sets when the tokens transferred to the exchange contract are credited to
+// the balances of the accounts
+type DepositsConfig struct {
+	// Enabled turns on the deposit watcher. Defaults to false
+	Enabled bool `mapstructure:"enabled"`
+	// Confirmations is the number of blocks, including the block of a deposit, after which
+	// the deposit is credited. Defaults to 12
+	Confirmations int `mapstructure:"confirmations"`
+	// CheckInterval is the number of seconds between two checks of the confirmations of
+	// the pending deposits. Defaults to 15
+	CheckInterval int `mapstructure:"check_interval"`
+}
+
+// SettlementConfig sets when the trades are settled. A trade is settled once the block of
+// its settlement transaction has enough confirmations, until then the transaction can be
+// removed by a chain reorganization.
+type SettlementConfig struct {
+	// Enabled runs the operator in the API server: the trades signed by the takers are
+	// sent to the exchange contract with the default admin wallet, which must be an
+	// operator of the contract. Defaults to false
+	Enabled bool `mapstructure:"enabled"`
+	// Confirmations is the number of blocks, including the block of a settlement
+	// transaction, after which the trade is settled. Defaults to 12
+	Confirmations int `mapstructure:"confirmations"`
+	// CheckInterval is the number of seconds between two checks of the settlement
+	// transactions. Defaults to 15
+	CheckInterval int `mapstructure:"check_interval"`
+	// GasBumpTimeout is the number of seconds after which a transaction of the operator
+	// that is not mined is sent again with a higher gas price. 0 never re-prices the
+	// transactions. Defaults to 180
+	GasBumpTimeout int `mapstructure:"gas_bump_timeout"`
+	// GasBumpPercent is the gas price increase of a re-priced transaction, the nodes
+	// reject replacements below 10%. Defaults to 12
+	GasBumpPercent int64 `mapstructure:"gas_bump_percent"`
+	// MaxGasPrice is the gas price (in gwei) above which the transactions are not
+	// re-priced. 0 is unlimited. Defaults to 0
+	MaxGasPrice int64 `mapstructure:"max_gas_price"`
+}
+
+// SettlementWebhookConfig sets where the settled trades are posted. The settlement events
+// are posted by the servers running the operator until the webhook accepts them.
+type SettlementWebhookConfig struct {
+	// URL is the url of the webhook. The settled trades are not posted if it is empty
+	URL string `mapstructure:"url"`
+	// Secret is the key of the HMAC-SHA256 signature of the events
+	Secret string `mapstructure:"secret"`
+	// CheckInterval is the number of seconds between two checks of the events due for
+	// delivery. Defaults to 10
+	CheckInterval int `mapstructure:"check_interval"`
+}
+
+// GasPriceConfig sets where the gas prices come from and the gas price tier (fast,
+// standard or slow) of each use of the operator transactions
+type GasPriceConfig struct {
+	// Oracle is the url of an external gas price oracle returning the prices in the
+	// ethgasstation format. The gas prices come from eth_gasPrice if it is empty or fails
+	Oracle string `mapstructure:"oracle"`
+	// CheckInterval is the number of seconds between two checks of the gas prices. Defaults to 15
+	CheckInterval int `mapstructure:"check_interval"`
+	// FastPercent and SlowPercent scale the gas price of the node into the fast and slow
+	// tiers when there is no oracle. Default to 125 and 80
+	FastPercent int64 `mapstructure:"fast_percent"`
+	SlowPercent int64 `mapstructure:"slow_percent"`
+	// SettlementTier is the tier of the settlement transactions. Defaults to "fast"
+	SettlementTier string `mapstructure:"settlement_tier"`
+	// WithdrawTier is the tier of the withdrawal transactions. Defaults to "standard"
+	WithdrawTier string `mapstructure:"withdraw_tier"`
+}
+
+// RateLimitConfig sets the request quotas of the REST API, counted in redis and shared by
+// the api servers. The requests over a quota are rejected with a 429 RATE_LIMIT_EXCEEDED error.
+type RateLimitConfig struct {
+	// Window is the number of seconds of the quota windows. Defaults to 60
+	Window int `mapstructure:"window"`
+	// IPLimit is the number of requests of a client ip per window. Defaults to 600, 0 is
+	// not limited
+	IPLimit int `mapstructure:"ip_limit"`
+	// AddressLimit is the number of requests about an address (eg. GET /orders/<addr>)
+	// per window. Defaults to 300, 0 is not limited
+	AddressLimit int `mapstructure:"address_limit"`
+	// ForwardedFor takes the client ip from the X-Forwarded-For header, for the servers
+	// behind a load balancer. Defaults to false
+	ForwardedFor bool `mapstructure:"forwarded_for"`
+}
+
+// WSRateLimitConfig sets the token buckets of the messages sent by the websocket clients.
+// The messages over the limits are rejected with a RATE_LIMIT_EXCEEDED error.
+type WSRateLimitConfig struct {
+	// ConnectionRate is the number of messages per second of a connection and
+	// ConnectionBurst the number of messages it can send at once. Default to 20 and 40,
+	// a rate of 0 is not limited
+	ConnectionRate  float64 `mapstructure:"connection_rate"`
+	ConnectionBurst int     `mapstructure:"connection_burst"`
+	// AddressRate and AddressBurst are shared by the connections authenticated as an
+	// address. Default to 50 and 100, a rate of 0 is not limited
+	AddressRate  float64 `mapstructure:"address_rate"`
+	AddressBurst int     `mapstructure:"address_burst"`
+	// MaxViolations is the number of consecutive rejected messages after which a
+	// connection is closed. Defaults to 20, 0 never closes the connections
+	MaxViolations int `mapstructure:"max_violations"`
+}
+
+// FiatPricesConfig sets where the fiat prices of the tokens come from
+type FiatPricesConfig struct {
+	// Feed is the url of the price feed returning the prices of the tokens by symbol in the
+	// cryptocompare format (eg. {"WETH": {"USD": 200.5, "EUR": 175.2}}). The portfolios are
+	// only valued with the last trade prices if it is empty
+	Feed string `mapstructure:"feed"`
+	// CheckInterval is the number of seconds between two fetches of the prices. Defaults to 60
+	CheckInterval int `mapstructure:"check_interval"`
+}
+
+// WithdrawsConfig sets how the withdrawal requests are executed. The withdrawal
+// transactions are sent with the default admin wallet, which must be an operator of the
+// exchange contract.
+type WithdrawsConfig struct {
+	// Enabled accepts the withdrawal requests. Defaults to false
+	Enabled bool `mapstructure:"enabled"`
+	// CheckInterval is the number of seconds between two checks of the transactions of
+	// the submitted withdrawals. Defaults to 15
+	CheckInterval int `mapstructure:"check_interval"`
+}
+
+// ConsistencyConfig sets how often the orderbooks and the locked balances of the accounts
+// are compared with the orders stored in the database, and whether the divergences are
+// repaired
+type ConsistencyConfig struct {
+	// CheckInterval is the number of seconds between two checks. The checks are disabled
+	// if 0. Defaults to 300
+	CheckInterval int `mapstructure:"check_interval"`
+	// AutoFix repairs the divergences found by the checks. It is ignored when the engine
+	// runs in a separate process (engine_mode "api"). Defaults to false
+	AutoFix bool `mapstructure:"auto_fix"`
+}
+
+// EngineConfig selects the backend storing the orderbooks matched by the engine
+type EngineConfig struct {
+	// Backend is "redis" to match the orders on the orderbooks stored in redis, or
+	// "memory" to match them on in-memory orderbooks written to redis every snapshot
+	// interval. Defaults to "redis"
+	Backend string `mapstructure:"backend"`
+	// SnapshotInterval is the number of milliseconds between two writes of the in-memory
+	// orderbooks to redis. Defaults to 100
+	SnapshotInterval int `mapstructure:"snapshot_interval"`
+	// PairWorkers matches each pair in a goroutine of its own, so that the pairs are
+	// matched in parallel. Defaults to true
+	PairWorkers bool `mapstructure:"pair_workers"`
+}
+
+// BreakerConfig sets when the circuit breaker of a dependency opens. The breaker opens
+// after Threshold consecutive failed or timed out calls and rejects the calls for
+// Cooldown seconds. Timeout is the maximum duration of a call in milliseconds.
+type BreakerConfig struct {
+	Threshold int `mapstructure:"threshold"`
+	Cooldown  int `mapstructure:"cooldown"`
+	Timeout   int `mapstructure:"timeout"`
+}
+
+// Settings returns the breaker settings of the configuration, the unset values are
+// taken from the default settings
+func (c BreakerConfig) Settings() breaker.Settings {
+	s := breaker.DefaultSettings
+	if c.Threshold > 0 {
+		s.Threshold = c.Threshold
+	}
+
+	if c.Cooldown > 0 {
+		s.Cooldown = time.Duration(c.Cooldown) * time.Second
+	}
+
+	if c.Timeout > 0 {
+		s.Timeout = time.Duration(c.Timeout) * time.Millisecond
+	}
+
+	return s
+}
+
+// AlertEmailConfig is the smtp configuration used to send alerts by email
+type AlertEmailConfig struct {
+	// SMTPServer is the address (host:port) of the smtp server
+	SMTPServer string   `mapstructure:"smtp_server"`
+	Username   string   `mapstructure:"username"`
+	Password   string   `mapstructure:"password"`
+	From       string   `mapstructure:"from"`
+	To         []string `mapstructure:"to"`
 }
 
 func (config appConfig) Validate() error {
@@ -50,6 +446,7 @@ func (config appConfig) Validate() error {
 		validation.Field(&config.DSN, validation.Required),
 		validation.Field(&config.JWTSigningKey, validation.Required),
 		validation.Field(&config.JWTVerificationKey, validation.Required),
+		validation.Field(&config.EngineMode, validation.In("embedded", "matcher", "api")),
 	)
 }
 
@@ -65,6 +462,51 @@ func LoadConfig(configPaths ...string) error {
 	v.SetDefault("error_file", "config/errors.yaml")
 	v.SetDefault("server_port", 8081)
 	v.SetDefault("jwt_signing_method", "HS256")
+	v.SetDefault("engine_mode", "embedded")
+	v.SetDefault("auto_pairs.active", true)
+	v.SetDefault("request_timeout", 30)
+	v.SetDefault("ethereum_health_check", 10)
+	v.SetDefault("operator_balance.check_interval", 15)
+	v.SetDefault("operator_balance.warning_threshold", 1)
+	v.SetDefault("operator_balance.critical_threshold", 0.2)
+	v.SetDefault("chain_lag.check_interval", 15)
+	v.SetDefault("nonce_check_interval", 30)
+	v.SetDefault("self_trade_prevention", "cancel-newest")
+	v.SetDefault("chain_lag.max_lag", 120)
+	v.SetDefault("approval_ttl", 24)
+	v.SetDefault("journal_retention", 30)
+	v.SetDefault("max_clock_skew", 300)
+	v.SetDefault("ws_max_message_size", 262144)
+	v.SetDefault("rate_limit.window", 60)
+	v.SetDefault("rate_limit.ip_limit", 600)
+	v.SetDefault("rate_limit.address_limit", 300)
+	v.SetDefault("ws_rate_limit.connection_rate", 20)
+	v.SetDefault("ws_rate_limit.connection_burst", 40)
+	v.SetDefault("ws_rate_limit.address_rate", 50)
+	v.SetDefault("ws_rate_limit.address_burst", 100)
+	v.SetDefault("ws_rate_limit.max_violations", 20)
+	v.SetDefault("rfq_quote_ttl", 10)
+	v.SetDefault("metadata_cache_ttl", 60)
+	v.SetDefault("ticker_interval", 10)
+	v.SetDefault("engine.backend", "redis")
+	v.SetDefault("engine.snapshot_interval", 100)
+	v.SetDefault("engine.pair_workers", true)
+	v.SetDefault("consistency.check_interval", 300)
+	v.SetDefault("deposits.confirmations", 12)
+	v.SetDefault("deposits.check_interval", 15)
+	v.SetDefault("settlement.confirmations", 12)
+	v.SetDefault("settlement.check_interval", 15)
+	v.SetDefault("settlement.gas_bump_timeout", 180)
+	v.SetDefault("settlement.gas_bump_percent", 12)
+	v.SetDefault("settlement_webhook.check_interval", 10)
+	v.SetDefault("withdraws.check_interval", 15)
+	v.SetDefault("gas_price.check_interval", 15)
+	v.SetDefault("gas_price.fast_percent", 125)
+	v.SetDefault("gas_price.slow_percent", 80)
+	v.SetDefault("gas_price.settlement_tier", "fast")
+	v.SetDefault("gas_price.withdraw_tier", "standard")
+	v.SetDefault("fiat_prices.check_interval", 60)
+	v.SetDefault("chain_id", 1)
 	for _, path := range configPaths {
 		v.AddConfigPath(path)
 	}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/utils/breaker"
 	"github.com/Sirupsen/logrus"
 	"github.com/go-ozzo/ozzo-routing"
 	"github.com/go-ozzo/ozzo-routing/access"
@@ -52,6 +53,9 @@ func convertError(c *routing.Context, err error) error {
 	if err == sql.ErrNoRows {
 		return errors.NotFound("the requested resource")
 	}
+	if err == breaker.ErrOpen || err == breaker.ErrTimeout {
+		return errors.ServiceUnavailable.New(errors.Params{"error": err.Error()})
+	}
 	switch err.(type) {
 	case *errors.APIError:
 		return err
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-ozzo/ozzo-routing"
+	"github.com/gomodule/redigo/redis"
+)
+
+// rateLimitScript increments the request counter of a quota and starts its window with the
+// first request. It returns the counter and the milliseconds left in the window.
+var rateLimitScript = redis.NewScript(1, `
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return {count, redis.call("PTTL", KEYS[1])}
+`)
+
+// RateLimiter enforces the request quotas of the REST API. The requests of a client ip
+// and the requests about an address (the address or addr route parameter, eg. GET
+// /orders/<addr>) are counted in redis over fixed windows, so that the quotas are shared
+// by the api servers.
+type RateLimiter struct {
+	conn         redis.Conn
+	mutex        sync.Mutex
+	window       time.Duration
+	ipLimit      int
+	addressLimit int
+	forwardedFor bool
+}
+
+// rateLimitUsage is the state of a quota after a request
+type rateLimitUsage struct {
+	scope     string
+	limit     int
+	remaining int
+	reset     time.Duration
+}
+
+// NewRateLimiter returns a rate limiter counting the requests with a redis connection of
+// its own. A limit of 0 is not enforced. The client ip is the first address of the
+// X-Forwarded-For header when forwardedFor is set (eg. behind a load balancer).
+func NewRateLimiter(conn redis.Conn, window time.Duration, ipLimit, addressLimit int, forwardedFor bool) *RateLimiter {
+	return &RateLimiter{
+		conn:         conn,
+		window:       window,
+		ipLimit:      ipLimit,
+		addressLimit: addressLimit,
+		forwardedFor: forwardedFor,
+	}
+}
+
+// Handler returns a middleware answering the requests over a quota with a 429
+// RATE_LIMIT_EXCEEDED error and a Retry-After header. The usage of the most used quota is
+// returned in the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (seconds)
+// headers. The requests are not limited while redis is unreachable.
+func (l *RateLimiter) Handler() routing.Handler {
+	return func(c *routing.Context) error {
+		usages := []*rateLimitUsage{}
+
+		if l.ipLimit > 0 {
+			if ip := l.clientIP(c.Request); ip != "" {
+				usages = append(usages, l.count("ip", ip, l.ipLimit))
+			}
+		}
+
+		if l.addressLimit > 0 {
+			if addr := requestAddress(c); addr != "" {
+				usages = append(usages, l.count("address", addr, l.addressLimit))
+			}
+		}
+
+		var usage *rateLimitUsage
+		for _, u := range usages {
+			if u != nil && (usage == nil || u.remaining < usage.remaining) {
+				usage = u
+			}
+		}
+
+		if usage == nil {
+			return nil
+		}
+
+		reset := strconv.Itoa(int((usage.reset + time.Second - 1) / time.Second))
+		h := c.Response.Header()
+		h.Set("X-RateLimit-Limit", strconv.Itoa(usage.limit))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(usage.remaining))
+		h.Set("X-RateLimit-Reset", reset)
+
+		if usage.remaining < 0 {
+			h.Set("X-RateLimit-Remaining", "0")
+			h.Set("Retry-After", reset)
+
+			return errors.RateLimitExceeded.New(errors.Params{
+				"scope": usage.scope,
+				"limit": usage.limit,
+			})
+		}
+
+		return nil
+	}
+}
+
+// count counts a request in the quota of a client, it returns nil if redis fails
+func (l *RateLimiter) count(scope, client string, limit int) *rateLimitUsage {
+	key := fmt.Sprintf("ratelimit::%s::%s", scope, client)
+
+	l.mutex.Lock()
+	reply, err := redis.Int64s(rateLimitScript.Do(l.conn, key, int64(l.window/time.Millisecond)))
+	l.mutex.Unlock()
+
+	if err != nil || len(reply) != 2 {
+		log.Printf("Could not count the request of %s %s: %v", scope, client, err)
+		return nil
+	}
+
+	reset := time.Duration(reply[1]) * time.Millisecond
+	if reset < 0 {
+		reset = l.window
+	}
+
+	return &rateLimitUsage{
+		scope:     scope,
+		limit:     limit,
+		remaining: limit - int(reply[0]),
+		reset:     reset,
+	}
+}
+
+// clientIP returns the ip of the client of a request
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.forwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// requestAddress returns the address a request is about, from its address or addr route
+// parameter
+func requestAddress(c *routing.Context) string {
+	for _, name := range []string{"address", "addr"} {
+		if a := c.Param(name); common.IsHexAddress(a) {
+			return common.HexToAddress(a).Hex()
+		}
+	}
+
+	return ""
+}
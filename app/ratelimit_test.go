@@ -0,0 +1,114 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-ozzo/ozzo-routing"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	rateLimitedAddress = "0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"
+	otherAddress       = "0xe8e84ee367bc63ddb38d3d01bccef106c194dc47"
+)
+
+func newTestRateLimiter(t *testing.T, ipLimit, addressLimit int) (*routing.Router, *miniredis.Miniredis) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := redis.Dial("tcp", s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewRateLimiter(conn, time.Minute, ipLimit, addressLimit, false)
+	ok := func(c *routing.Context) error {
+		return c.Write("ok")
+	}
+
+	router := routing.New()
+	router.Get("/orders/<address>", l.Handler(), ok)
+	router.Get("/pairs", l.Handler(), ok)
+	return router, s
+}
+
+func rateLimitedRequest(router *routing.Router, path, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	req.RemoteAddr = ip + ":43210"
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	return res
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	router, s := newTestRateLimiter(t, 3, 0)
+	defer s.Close()
+
+	for _, remaining := range []string{"2", "1", "0"} {
+		res := rateLimitedRequest(router, "/pairs", "10.0.0.1")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "3", res.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, remaining, res.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "60", res.Header().Get("X-RateLimit-Reset"))
+	}
+
+	// the quota of the window is exhausted
+	res := rateLimitedRequest(router, "/pairs", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+	assert.Equal(t, "0", res.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "60", res.Header().Get("Retry-After"))
+	assert.True(t, strings.Contains(res.Body.String(), "RATE_LIMIT_EXCEEDED"))
+
+	// the quota is available again in the next window
+	s.FastForward(time.Minute)
+
+	res = rateLimitedRequest(router, "/pairs", "10.0.0.1")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "2", res.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestRateLimiterScopes(t *testing.T) {
+	router, s := newTestRateLimiter(t, 3, 2)
+	defer s.Close()
+
+	// the requests about an address are counted whatever the client ip and the case of
+	// the address
+	res := rateLimitedRequest(router, "/orders/"+rateLimitedAddress, "10.0.0.1")
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = rateLimitedRequest(router, "/orders/0x"+strings.ToUpper(rateLimitedAddress[2:]), "10.0.0.2")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "2", res.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", res.Header().Get("X-RateLimit-Remaining"))
+
+	res = rateLimitedRequest(router, "/orders/"+rateLimitedAddress, "10.0.0.3")
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+
+	// the other addresses have their own quota
+	res = rateLimitedRequest(router, "/orders/"+otherAddress, "10.0.0.3")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "1", res.Header().Get("X-RateLimit-Remaining"))
+
+	// the requests of a client ip are counted whatever the address, the other ips have
+	// their own quota
+	res = rateLimitedRequest(router, "/pairs", "10.0.0.3")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "3", res.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", res.Header().Get("X-RateLimit-Remaining"))
+
+	res = rateLimitedRequest(router, "/pairs", "10.0.0.3")
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+
+	res = rateLimitedRequest(router, "/pairs", "10.0.0.4")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "2", res.Header().Get("X-RateLimit-Remaining"))
+}
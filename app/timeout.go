@@ -0,0 +1,52 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/errors"
+)
+
+// routeTimeout is the timeout handler of the routes starting with a path prefix
+type routeTimeout struct {
+	prefix  string
+	handler http.Handler
+}
+
+// TimeoutHandler returns a handler answering the requests that are not served in time
+// with a 503 REQUEST_TIMEOUT error, so that a hung dependency does not pile up pending
+// requests. Routes maps path prefixes to their timeout, the longest matching prefix is
+// used and the other requests are bounded by the default timeout. A timeout of 0
+// disables the timeout.
+func TimeoutHandler(h http.Handler, timeout time.Duration, routes map[string]time.Duration) http.Handler {
+	body, _ := json.Marshal(errors.RequestTimeout.New(nil))
+	withTimeout := func(d time.Duration) http.Handler {
+		if d <= 0 {
+			return h
+		}
+
+		return http.TimeoutHandler(h, d, string(body))
+	}
+
+	timeouts := []routeTimeout{}
+	for prefix, d := range routes {
+		timeouts = append(timeouts, routeTimeout{prefix, withTimeout(d)})
+	}
+
+	sort.Slice(timeouts, func(i, j int) bool { return len(timeouts[i].prefix) > len(timeouts[j].prefix) })
+	def := withTimeout(timeout)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, t := range timeouts {
+			if strings.HasPrefix(r.URL.Path, t.prefix) {
+				t.handler.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		def.ServeHTTP(w, r)
+	})
+}
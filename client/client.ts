@@ -0,0 +1,46 @@
+// Minimal browser client of the matching engine websocket. The message types are
+// generated from the Go payload types (see cmd/wsschema), do not edit messages.ts.
+import { Channel, ClientMessage, ServerMessage } from './messages'
+
+type Handler<C extends Channel> = (message: Extract<ServerMessage, { channel: C }>) => void
+
+export class AmpSocket {
+  private socket: WebSocket
+  private handlers: { [C in Channel]?: Handler<C>[] } = {}
+  private queue: ClientMessage[] = []
+
+  constructor(url: string) {
+    this.socket = new WebSocket(url)
+    this.socket.onopen = () => {
+      this.queue.forEach(message => this.socket.send(JSON.stringify(message)))
+      this.queue = []
+    }
+
+    this.socket.onmessage = (event: MessageEvent) => {
+      const message: ServerMessage = JSON.parse(event.data)
+      const handlers = (this.handlers[message.channel] || []) as Handler<Channel>[]
+      handlers.forEach(handler => handler(message))
+    }
+  }
+
+  // on registers a handler for the messages of a channel
+  on<C extends Channel>(channel: C, handler: Handler<C>) {
+    const handlers = (this.handlers[channel] || []) as Handler<C>[]
+    handlers.push(handler)
+    this.handlers[channel] = handlers as any
+  }
+
+  // send sends a message, the messages sent before the connection is open are queued
+  send(message: ClientMessage) {
+    if (this.socket.readyState !== WebSocket.OPEN) {
+      this.queue.push(message)
+      return
+    }
+
+    this.socket.send(JSON.stringify(message))
+  }
+
+  close() {
+    this.socket.close()
+  }
+}
@@ -0,0 +1,57 @@
+// Command wsschema generates the json schema and the typescript types of the websocket
+// messages from the payload types registered in the types package. The generated files
+// are used by the browser client and must be regenerated after a payload change:
+//
+//	go run ./cmd/wsschema -out client
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/schema"
+)
+
+// Names of the generated files
+const (
+	schemaFile     = "schema.json"
+	typeScriptFile = "messages.ts"
+)
+
+func main() {
+	out := flag.String("out", "client", "output directory")
+	flag.Parse()
+
+	files, err := generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for name, content := range files {
+		err := ioutil.WriteFile(filepath.Join(*out, name), content, 0644)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// generate returns the content of the generated files by name
+func generate() (map[string][]byte, error) {
+	t := types.WebSocketSchemaTypes()
+	m := types.WebSocketSchemaMessages()
+
+	js, err := schema.JSONSchema(t, m)
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := schema.TypeScript(t, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{schemaFile: js, typeScriptFile: ts}, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// The generated files in the client directory must match the payload types. Run the
+// test with -update to regenerate them after an intended change.
+var update = flag.Bool("update", false, "update the generated files")
+
+func TestGeneratedFilesAreUpToDate(t *testing.T) {
+	files, err := generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		path := filepath.Join("..", "..", "client", name)
+		if *update {
+			err := ioutil.WriteFile(path, content, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		expected, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(expected) != string(content) {
+			t.Errorf("%s is out of date, run go run ./cmd/wsschema -out client", path)
+		}
+	}
+}
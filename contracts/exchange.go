@@ -1,13 +1,19 @@
 package contracts
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"log"
+	"math/big"
+	"strings"
+	"sync"
 
 	"github.com/Proofsuite/amp-matching-engine/contracts/interfaces"
 	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	eth "github.com/ethereum/go-ethereum/core/types"
@@ -21,9 +27,26 @@ import (
 // CallOptions are options for making read calls to the connected backend
 // TxOptions are options for making write txs to the connected backend
 type Exchange struct {
+	Address       common.Address
 	WalletService *services.WalletService
 	TxService     *services.TxService
 	Interface     *interfaces.Exchange
+
+	backend    bind.ContractBackend
+	transactor *bind.TransactOpts
+}
+
+var exchangeABI abi.ABI
+var exchangeABIErr error
+var exchangeABIOnce sync.Once
+
+// getExchangeABI returns the parsed ABI of the exchange contract bindings
+func getExchangeABI() (abi.ABI, error) {
+	exchangeABIOnce.Do(func() {
+		exchangeABI, exchangeABIErr = abi.JSON(strings.NewReader(interfaces.ExchangeABI))
+	})
+
+	return exchangeABI, exchangeABIErr
 }
 
 // Returns a new exchange interface for a given wallet, contract address and connected backend.
@@ -36,21 +59,34 @@ func NewExchange(w *services.WalletService, tx *services.TxService, contractAddr
 	}
 
 	return &Exchange{
+		Address:       contractAddress,
 		WalletService: w,
 		TxService:     tx,
 		Interface:     instance,
+		backend:       backend,
 	}, nil
 }
 
+// SetTransactor sets the options used to send transactions instead of the default
+// admin wallet (eg. in tests or when the operator key is not stored in the database)
+func (e *Exchange) SetTransactor(opts *bind.TransactOpts) {
+	e.transactor = opts
+}
+
 func (e *Exchange) GetTxCallOptions() *bind.CallOpts {
 	return e.TxService.GetTxCallOptions()
 }
 
 func (e *Exchange) GetTxSendOptions() (*bind.TransactOpts, error) {
+	if e.transactor != nil {
+		opts := *e.transactor
+		return &opts, nil
+	}
+
 	return e.TxService.GetTxSendOptions()
 }
 
-func (e *Exchange) GetCustomTxSendOptions(w *types.Wallet) *bind.TransactOpts {
+func (e *Exchange) GetCustomTxSendOptions(w *types.OperatorWallet) *bind.TransactOpts {
 	return e.TxService.GetCustomTxSendOptions(w)
 }
 
@@ -107,35 +143,149 @@ func (e *Exchange) Operator(a common.Address) (bool, error) {
 // by the Maker and the Taker of the trade. Only the operator account can send a Trade function to the
 // Exchange smart contract.
 func (e *Exchange) Trade(o *types.Order, t *types.Trade) (*eth.Transaction, error) {
-	// txSendOptions, _ := e.GetTxSendOptions()
+	txSendOptions, err := e.GetTxSendOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	e.TxService.SetGasPrice(txSendOptions, services.SettlementTx)
+	orderValues, orderAddresses, vValues, rsValues := tradeArgs(o, t)
+	tx, err := e.TxService.Send(txSendOptions, func(opts *bind.TransactOpts) (*eth.Transaction, error) {
+		return e.Interface.ExecuteTrade(opts, orderValues, orderAddresses, vValues, rsValues)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// BatchTrade executes the settlement transactions of several trades. The exchange contract
+// settles one trade per transaction, the transactions are sent with consecutive nonces
+// without waiting for the previous ones to be mined. The transactions sent before an
+// error are returned along with the error.
+func (e *Exchange) BatchTrade(orders []*types.Order, trades []*types.Trade) ([]*eth.Transaction, error) {
+	if len(orders) != len(trades) {
+		return nil, fmt.Errorf("Got %d orders for %d trades", len(orders), len(trades))
+	}
+
+	txSendOptions, err := e.GetTxSendOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	e.TxService.SetGasPrice(txSendOptions, services.SettlementTx)
+
+	// the nonces are assigned by the nonce manager of the tx service when there is one
+	var nonce uint64
+	if e.TxService.Nonces == nil {
+		nonce, err = e.backend.PendingNonceAt(context.Background(), txSendOptions.From)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	// orderValues := [8]*big.Int{o.AmountBuy, o.AmountSell, o.Expires, o.Nonce, o.FeeMake, o.FeeTake, t.Amount, t.TradeNonce}
-	// orderAddresses := [4]Address{o.TokenBuy, o.TokenSell, o.Maker, t.Taker}
-	// vValues := [2]uint8{o.Signature.V, t.Signature.V}
-	// rsValues := [4][32]byte{o.Signature.R, o.Signature.S, t.Signature.R, t.Signature.S}
+	txs := []*eth.Transaction{}
+	for i, t := range trades {
+		orderValues, orderAddresses, vValues, rsValues := tradeArgs(orders[i], t)
+		executeTrade := func(opts *bind.TransactOpts) (*eth.Transaction, error) {
+			return e.Interface.ExecuteTrade(opts, orderValues, orderAddresses, vValues, rsValues)
+		}
+
+		var tx *eth.Transaction
+		if e.TxService.Nonces != nil {
+			tx, err = e.TxService.Send(txSendOptions, executeTrade)
+		} else {
+			txSendOptions.Nonce = new(big.Int).SetUint64(nonce + uint64(i))
+			tx, err = executeTrade(txSendOptions)
+		}
+
+		if err != nil {
+			return txs, err
+		}
+
+		txs = append(txs, tx)
+	}
+
+	return txs, nil
+}
+
+// CancelOrder cancels an order on-chain. The exchange contract only accepts the
+// cancellations sent by the maker of the order, the transaction is sent with the given
+// options of the maker account.
+func (e *Exchange) CancelOrder(opts *bind.TransactOpts, o *types.Order) (*eth.Transaction, error) {
+	orderValues := [6]*big.Int{o.BuyAmount, o.SellAmount, o.Expires, o.Nonce, o.MakeFee, o.TakeFee}
+	orderAddresses := [3]common.Address{o.BuyToken, o.SellToken, o.UserAddress}
+
+	tx, err := e.Interface.CancelOrder(opts, orderValues, orderAddresses, o.Signature.V, o.Signature.R, o.Signature.S)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// Filled returns the amount of an order that has been filled on-chain
+func (e *Exchange) Filled(orderHash common.Hash) (*big.Int, error) {
+	return e.Interface.Filled(e.GetTxCallOptions(), orderHash)
+}
+
+// Traded returns true if a trade has been settled on-chain
+func (e *Exchange) Traded(tradeHash common.Hash) (bool, error) {
+	return e.Interface.Traded(e.GetTxCallOptions(), tradeHash)
+}
+
+// TradeCallMsg returns the settlement transaction of a trade as a call message sent by the
+// operator account, so that it can be simulated before being broadcasted
+func (e *Exchange) TradeCallMsg(o *types.Order, t *types.Trade) (ethereum.CallMsg, error) {
+	txSendOptions, err := e.GetTxSendOptions()
+	if err != nil {
+		return ethereum.CallMsg{}, err
+	}
+
+	parsed, err := getExchangeABI()
+	if err != nil {
+		return ethereum.CallMsg{}, err
+	}
+
+	orderValues, orderAddresses, vValues, rsValues := tradeArgs(o, t)
+	data, err := parsed.Pack("executeTrade", orderValues, orderAddresses, vValues, rsValues)
+	if err != nil {
+		return ethereum.CallMsg{}, err
+	}
+
+	return ethereum.CallMsg{From: txSendOptions.From, To: &e.Address, Data: data}, nil
+}
+
+// TradeSucceeded decodes the value returned by a simulated settlement transaction. The
+// exchange contract returns false and emits an error log when a trade can not be settled.
+func (e *Exchange) TradeSucceeded(output []byte) (bool, error) {
+	parsed, err := getExchangeABI()
+	if err != nil {
+		return false, err
+	}
+
+	var success bool
+	err = parsed.Unpack(&success, "executeTrade", output)
+	if err != nil {
+		return false, err
+	}
+
+	return success, nil
+}
 
-	// tx, err := e.Interface.ExecuteTrade(txSendOptions, orderValues, orderAddresses, vValues, rsValues)
-	// if err != nil {
-	// 	return nil, err
-	// }
+// tradeArgs returns the arguments of the executeTrade function of the exchange contract
+func tradeArgs(o *types.Order, t *types.Trade) ([8]*big.Int, [4]common.Address, [2]uint8, [4][32]byte) {
+	orderValues := [8]*big.Int{o.BuyAmount, o.SellAmount, o.Expires, o.Nonce, o.MakeFee, o.TakeFee, t.Amount, t.TradeNonce}
+	orderAddresses := [4]common.Address{o.BuyToken, o.SellToken, o.UserAddress, t.Taker}
+	vValues := [2]uint8{o.Signature.V, t.Signature.V}
+	rsValues := [4][32]byte{o.Signature.R, o.Signature.S, t.Signature.R, t.Signature.S}
 
-	// return tx, nil
-	return nil, nil
+	return orderValues, orderAddresses, vValues, rsValues
 }
 
 // ListenToErrorEvents returns a channel that receives errors logs (events) from the exchange smart contract.
-// The error IDs correspond to the following codes:
-// 1. MAKER_INSUFFICIENT_BALANCE,
-// 2. TAKER_INSUFFICIENT_BALANCE,
-// 3. WITHDRAW_INSUFFICIENT_BALANCE,
-// 4. WITHDRAW_FEE_TO_HIGH,
-// 5. ORDER_EXPIRED,
-// 6. WITHDRAW_ALREADY_COMPLETED,
-// 7. TRADE_ALREADY_COMPLETED,
-// 8. TRADE_AMOUNT_TOO_BIG,
-// 9. SIGNATURE_INVALID,
-// 10. MAKER_SIGNATURE_INVALID,
-// 11. TAKER_SIGNATURE_INVALID
+// The error IDs are described by the exchange error registry (see errors.GetExchangeError).
 func (e *Exchange) ListenToErrors() (chan *interfaces.ExchangeLogError, error) {
 	events := make(chan *interfaces.ExchangeLogError)
 	opts := &bind.WatchOpts{nil, nil}
@@ -0,0 +1,197 @@
+package contracts
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/contracts/interfaces"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	eth "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSimulatedExchange deploys the exchange contract on a simulated backend. The
+// deployer is the owner of the contract and sends the settlement transactions.
+func newSimulatedExchange(t *testing.T) (*Exchange, *backends.SimulatedBackend, *bind.TransactOpts) {
+	key, _ := crypto.GenerateKey()
+	opts := bind.NewKeyedTransactor(key)
+
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		opts.From: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))},
+	})
+
+	address, _, _, err := interfaces.DeployExchange(opts, backend, common.Address{}, opts.From)
+	if err != nil {
+		t.Fatalf("Could not deploy exchange: %v", err)
+	}
+
+	backend.Commit()
+
+	exchange, err := NewExchange(nil, &services.TxService{}, address, backend)
+	if err != nil {
+		t.Fatalf("Could not create exchange: %v", err)
+	}
+
+	exchange.SetTransactor(opts)
+	return exchange, backend, opts
+}
+
+// newUnsignedTrade returns a trade of an order that is not signed by its maker. The
+// exchange contract rejects it with an error log without reverting.
+func newUnsignedTrade(exchange *Exchange, nonce int64) (*types.Order, *types.Trade) {
+	maker := types.NewWallet()
+	taker := types.NewWallet()
+
+	o := &types.Order{
+		UserAddress:     maker.Address,
+		ExchangeAddress: exchange.Address,
+		BuyToken:        common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		SellToken:       common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BuyAmount:       big.NewInt(1000),
+		SellAmount:      big.NewInt(100),
+		Expires:         big.NewInt(1e10),
+		Nonce:           big.NewInt(nonce),
+		MakeFee:         big.NewInt(0),
+		TakeFee:         big.NewInt(0),
+	}
+
+	o.Sign(taker)
+
+	tr := types.NewTrade(o, big.NewInt(100), big.NewInt(10), taker.Address)
+	tr.Hash = tr.ComputeHash()
+	tr.Signature, _ = taker.SignHash(tr.Hash)
+	return o, tr
+}
+
+func TestExchangeTrade(t *testing.T) {
+	exchange, backend, _ := newSimulatedExchange(t)
+	o, tr := newUnsignedTrade(exchange, 1)
+
+	msg, err := exchange.TradeCallMsg(o, tr)
+	if err != nil {
+		t.Fatalf("Could not create trade call: %v", err)
+	}
+
+	output, err := backend.CallContract(context.Background(), msg, nil)
+	if err != nil {
+		t.Fatalf("Could not simulate trade: %v", err)
+	}
+
+	success, err := exchange.TradeSucceeded(output)
+	if err != nil {
+		t.Fatalf("Could not decode trade result: %v", err)
+	}
+
+	assert.False(t, success)
+
+	tx, err := exchange.Trade(o, tr)
+	if err != nil {
+		t.Fatalf("Could not execute trade: %v", err)
+	}
+
+	backend.Commit()
+
+	receipt, err := backend.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatalf("Could not get receipt: %v", err)
+	}
+
+	// the trade is rejected with an error log
+	assert.Equal(t, eth.ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, 1, len(receipt.Logs))
+
+	traded, err := exchange.Traded(tr.Hash)
+	if err != nil {
+		t.Fatalf("Could not get trade status: %v", err)
+	}
+
+	assert.False(t, traded)
+}
+
+func TestExchangeTradeFromNonOperator(t *testing.T) {
+	exchange, backend, _ := newSimulatedExchange(t)
+	o, tr := newUnsignedTrade(exchange, 1)
+
+	key, _ := crypto.GenerateKey()
+	exchange.SetTransactor(bind.NewKeyedTransactor(key))
+
+	msg, err := exchange.TradeCallMsg(o, tr)
+	if err != nil {
+		t.Fatalf("Could not create trade call: %v", err)
+	}
+
+	// the call reverts, nothing is returned
+	output, _ := backend.CallContract(context.Background(), msg, nil)
+	_, err = exchange.TradeSucceeded(output)
+	assert.Error(t, err)
+}
+
+func TestExchangeBatchTrade(t *testing.T) {
+	exchange, backend, opts := newSimulatedExchange(t)
+
+	orders := []*types.Order{}
+	trades := []*types.Trade{}
+	for i := int64(1); i <= 3; i++ {
+		o, tr := newUnsignedTrade(exchange, i)
+		orders = append(orders, o)
+		trades = append(trades, tr)
+	}
+
+	nonce, _ := backend.PendingNonceAt(context.Background(), opts.From)
+
+	txs, err := exchange.BatchTrade(orders, trades)
+	if err != nil {
+		t.Fatalf("Could not execute trades: %v", err)
+	}
+
+	backend.Commit()
+
+	assert.Equal(t, 3, len(txs))
+	for i, tx := range txs {
+		assert.Equal(t, nonce+uint64(i), tx.Nonce())
+
+		receipt, err := backend.TransactionReceipt(context.Background(), tx.Hash())
+		if err != nil {
+			t.Fatalf("Could not get receipt: %v", err)
+		}
+
+		assert.Equal(t, eth.ReceiptStatusSuccessful, receipt.Status)
+	}
+
+	_, err = exchange.BatchTrade(orders, trades[:1])
+	assert.Error(t, err)
+}
+
+func TestExchangeCancelOrder(t *testing.T) {
+	exchange, backend, opts := newSimulatedExchange(t)
+	o, _ := newUnsignedTrade(exchange, 1)
+
+	tx, err := exchange.CancelOrder(opts, o)
+	if err != nil {
+		t.Fatalf("Could not cancel order: %v", err)
+	}
+
+	backend.Commit()
+
+	receipt, err := backend.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatalf("Could not get receipt: %v", err)
+	}
+
+	// the cancellation is not sent by the maker, it is rejected with an error log
+	assert.Equal(t, eth.ReceiptStatusSuccessful, receipt.Status)
+
+	filled, err := exchange.Filled(o.Hash)
+	if err != nil {
+		t.Fatalf("Could not get filled amount: %v", err)
+	}
+
+	assert.Equal(t, int64(0), filled.Int64())
+}
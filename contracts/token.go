@@ -40,7 +40,7 @@ func (t *Token) GetTxSendOptions() (*bind.TransactOpts, error) {
 	return t.TxService.GetTxSendOptions()
 }
 
-func (t *Token) GetCustomTxSendOptions(w *types.Wallet) *bind.TransactOpts {
+func (t *Token) GetCustomTxSendOptions(w *types.OperatorWallet) *bind.TransactOpts {
 	return t.TxService.GetCustomTxSendOptions(w)
 }
 
@@ -77,7 +77,7 @@ func (t *Token) Transfer(receiver common.Address, amount *big.Int) (*eth.Transac
 	return tx, nil
 }
 
-func (t *Token) TransferFromCustomWallet(w *types.Wallet, receiver common.Address, amount *big.Int) (*eth.Transaction, error) {
+func (t *Token) TransferFromCustomWallet(w *types.OperatorWallet, receiver common.Address, amount *big.Int) (*eth.Transaction, error) {
 	txSendOptions := t.GetCustomTxSendOptions(w)
 
 	tx, err := t.Interface.Transfer(txSendOptions, receiver, amount)
@@ -122,7 +122,7 @@ func (t *Token) Approve(spender common.Address, amount *big.Int) (*eth.Transacti
 	return tx, nil
 }
 
-func (t *Token) ApproveFrom(w *types.Wallet, spender common.Address, amount *big.Int) (*eth.Transaction, error) {
+func (t *Token) ApproveFrom(w *types.OperatorWallet, spender common.Address, amount *big.Int) (*eth.Transaction, error) {
 	txSendOptions := t.GetCustomTxSendOptions(w)
 
 	tx, err := t.Interface.Approve(txSendOptions, spender, amount)
@@ -0,0 +1,46 @@
+package crons
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/robfig/cron"
+)
+
+// consistencyCron takes instance of cron.Cron and adds the cron comparing the orderbooks
+// and the locked balances with the orders stored in the database
+func (s *CronService) consistencyCron(c *cron.Cron) {
+	interval := app.Config.Consistency.CheckInterval
+	if interval <= 0 {
+		return
+	}
+
+	c.AddFunc(fmt.Sprintf("@every %ds", interval), s.checkConsistency)
+}
+
+// checkConsistency logs the divergences found by the consistency check. They are only
+// repaired when the engine runs in this process, the orderbooks of a separate matcher
+// must not be written while it matches orders.
+func (s *CronService) checkConsistency() {
+	fix := app.Config.Consistency.AutoFix && app.Config.EngineMode != "api"
+	report, err := s.orderService.CheckConsistency(fix)
+	if err != nil {
+		log.Printf("%s", err)
+		return
+	}
+
+	for _, r := range report.OrderBooks {
+		log.Printf(
+			"Orderbook of %s diverges from the database: %d orders missing, %d different, %d not open, %d price levels (fixed: %v)",
+			r.Pair, len(r.Restored), len(r.Updated), len(r.Removed), r.Levels, fix,
+		)
+	}
+
+	for _, b := range report.Balances {
+		log.Printf(
+			"Locked balance of %s for token %s is %s instead of %s (fixed: %v)",
+			b.Address.Hex(), b.Token.Hex(), b.Locked.String(), b.Expected.String(), fix,
+		)
+	}
+}
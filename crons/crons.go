@@ -1,23 +1,40 @@
 package crons
 
 import (
+	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/robfig/cron"
 )
 
 // CronService contains the services required to initialize crons
 type CronService struct {
-	ohlcvService *services.OHLCVService
+	ohlcvService  *services.OHLCVService
+	pairService   *services.PairService
+	orderService  *services.OrderService
+	tickerService *services.TickerService
 }
 
 // NewCronService returns a new instance of CronService
-func NewCronService(ohlcvService *services.OHLCVService) *CronService {
-	return &CronService{ohlcvService}
+func NewCronService(
+	ohlcvService *services.OHLCVService,
+	pairService *services.PairService,
+	orderService *services.OrderService,
+	tickerService *services.TickerService,
+) *CronService {
+	return &CronService{ohlcvService, pairService, orderService, tickerService}
 }
 
 // InitCrons is responsible for initializing all the crons in the system
 func (s *CronService) InitCrons() {
 	c := cron.New()
 	s.tickStreamingCron(c)
+	s.tickerCron(c)
+
+	// read replicas do not write to the orderbook
+	if !app.Config.ReadOnly {
+		s.listingCron(c)
+		s.consistencyCron(c)
+	}
+
 	c.Start()
 }
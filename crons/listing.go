@@ -0,0 +1,26 @@
+package crons
+
+import (
+	"log"
+
+	"github.com/robfig/cron"
+)
+
+// listingCron takes instance of cron.Cron and adds the cron launching the
+// scheduled listings whose go-live time has passed
+func (s *CronService) listingCron(c *cron.Cron) {
+	c.AddFunc("@every 10s", s.launchListings)
+}
+
+// launchListings launches the due scheduled listings
+func (s *CronService) launchListings() {
+	pairs, err := s.pairService.LaunchDueListings()
+	if err != nil {
+		log.Printf("%s", err)
+		return
+	}
+
+	for _, p := range pairs {
+		log.Printf("Launched listing of pair %s", p.Symbol)
+	}
+}
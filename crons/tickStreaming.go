@@ -35,7 +35,9 @@ func (s *CronService) tickStream(unit string, duration int64) func() {
 			return
 		}
 
+		interval := types.OHLCVInterval{Duration: duration, Units: unit}.Key()
 		for _, tick := range ticks {
+			tick.Interval = interval
 			baseTokenAddress := common.HexToAddress(tick.ID.BaseToken)
 			quoteTokenAddress := common.HexToAddress(tick.ID.QuoteToken)
 
@@ -0,0 +1,19 @@
+package crons
+
+import (
+	"fmt"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/robfig/cron"
+)
+
+// tickerCron takes instance of cron.Cron and adds the cron sending the 24 hour tickers to
+// the connections subscribed to the tickers channel
+func (s *CronService) tickerCron(c *cron.Cron) {
+	interval := app.Config.TickerInterval
+	if interval <= 0 {
+		return
+	}
+
+	c.AddFunc(fmt.Sprintf("@every %ds", interval), s.tickerService.PublishTickers)
+}
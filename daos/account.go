@@ -1,6 +1,7 @@
 package daos
 
 import (
+	"errors"
 	"math/big"
 	"time"
 
@@ -9,9 +10,18 @@ import (
 	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
+// ErrInsufficientBalance is returned when a balance update would make the available or
+// the locked balance of a token negative
+var ErrInsufficientBalance = errors.New("Insufficient Balance")
+
+// maxBalanceUpdateRetries is the number of times a balance update is retried when the
+// balance was modified concurrently
+const maxBalanceUpdateRetries = 10
+
 // BalanceDao contains:
 // collectionName: MongoDB collection name
 // dbName: name of mongodb to interact with
@@ -35,8 +45,111 @@ func (dao *AccountDao) Create(account *types.Account) (err error) {
 	return
 }
 
+// Delete marks the account corresponding to the given address as deleted.
+// The document is kept so that historical orders and trades still resolve.
+func (dao *AccountDao) Delete(owner common.Address) (err error) {
+	q := notDeleted(bson.M{"address": owner.Hex()})
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"deletedAt": now, "updatedAt": now}}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// SetBlocked blocks or unblocks the account corresponding to the given address
+func (dao *AccountDao) SetBlocked(owner common.Address, blocked bool) (err error) {
+	q := notDeleted(bson.M{"address": owner.Hex()})
+	update := bson.M{"$set": bson.M{"isBlocked": blocked, "updatedAt": time.Now()}}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// AddTags tags the account corresponding to the given address. Tags already set are ignored.
+func (dao *AccountDao) AddTags(owner common.Address, tags []string) (err error) {
+	q := notDeleted(bson.M{"address": owner.Hex()})
+	update := bson.M{
+		"$addToSet": bson.M{"tags": bson.M{"$each": tags}},
+		"$set":      bson.M{"updatedAt": time.Now()},
+	}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// RemoveTag removes a tag from the account corresponding to the given address
+func (dao *AccountDao) RemoveTag(owner common.Address, tag string) (err error) {
+	q := notDeleted(bson.M{"address": owner.Hex()})
+	update := bson.M{
+		"$pull": bson.M{"tags": tag},
+		"$set":  bson.M{"updatedAt": time.Now()},
+	}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// AddNote appends a note to the account corresponding to the given address
+func (dao *AccountDao) AddNote(owner common.Address, note *types.AccountNote) (err error) {
+	note.CreatedAt = time.Now()
+
+	q := notDeleted(bson.M{"address": owner.Hex()})
+	update := bson.M{
+		"$push": bson.M{"notes": note},
+		"$set":  bson.M{"updatedAt": note.CreatedAt},
+	}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// UpdateLimitOverride sets the limit override of the account corresponding to the given
+// address, or removes it if override is nil
+func (dao *AccountDao) UpdateLimitOverride(owner common.Address, override *types.AccountLimitOverride) (err error) {
+	q := notDeleted(bson.M{"address": owner.Hex()})
+	update := bson.M{
+		"$set":   bson.M{"updatedAt": time.Now()},
+		"$unset": bson.M{"limitOverride": ""},
+	}
+
+	if override != nil {
+		update = bson.M{"$set": bson.M{"limitOverride": override, "updatedAt": time.Now()}}
+	}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// Anonymize erases the personal metadata of the account corresponding to the given
+// address: its tags, its notes and the reason of its limit override. The balances and
+// the rest of the limit override are kept. Deleted accounts are anonymized as well.
+// mgo.ErrNotFound is returned if there is no such account.
+func (dao *AccountDao) Anonymize(owner common.Address) (err error) {
+	now := time.Now()
+	q := bson.M{"address": owner.Hex()}
+	update := bson.M{
+		"$set":   bson.M{"anonymizedAt": now, "updatedAt": now},
+		"$unset": bson.M{"tags": "", "notes": "", "limitOverride.reason": ""},
+	}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// GetByTags returns the accounts tagged with all the given tags, most recent first.
+// All the accounts are returned if no tag is given.
+func (dao *AccountDao) GetByTags(tags []string, offset, limit int) (res []types.Account, err error) {
+	q := bson.M{}
+	if len(tags) > 0 {
+		q["tags"] = bson.M{"$all": tags}
+	}
+
+	err = db.GetWithSort(dao.dbName, dao.collectionName, notDeleted(q), []string{"-createdAt"}, offset, limit, &res)
+	return
+}
+
 func (dao *AccountDao) GetAll() (res []types.Account, err error) {
-	err = db.Get(dao.dbName, dao.collectionName, bson.M{}, 0, 0, &res)
+	err = db.Get(dao.dbName, dao.collectionName, notDeleted(bson.M{}), 0, 0, &res)
 	return
 }
 
@@ -54,7 +167,7 @@ func (dao *AccountDao) GetByID(id bson.ObjectId) (*types.Account, error) {
 
 func (dao *AccountDao) GetByAddress(owner common.Address) (response *types.Account, err error) {
 	var res []*types.Account
-	q := bson.M{"address": owner.Hex()}
+	q := notDeleted(bson.M{"address": owner.Hex()})
 	err = db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
 
 	if err != nil {
@@ -68,7 +181,7 @@ func (dao *AccountDao) GetByAddress(owner common.Address) (response *types.Accou
 }
 
 func (dao *AccountDao) GetTokenBalances(owner common.Address) (map[common.Address]*types.TokenBalance, error) {
-	q := bson.M{"address": owner.Hex()}
+	q := notDeleted(bson.M{"address": owner.Hex()})
 	response := []types.Account{}
 	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &response)
 	if err != nil {
@@ -93,9 +206,9 @@ func (dao *AccountDao) GetWethTokenBalance(owner common.Address) (*types.TokenBa
 func (dao *AccountDao) GetTokenBalance(owner common.Address, token common.Address) (*types.TokenBalance, error) {
 	q := []bson.M{
 		bson.M{
-			"$match": bson.M{
+			"$match": notDeleted(bson.M{
 				"address": owner.Hex(),
-			},
+			}),
 		},
 		bson.M{
 			"$project": bson.M{
@@ -156,6 +269,73 @@ func (dao *AccountDao) UpdateTokenBalance(owner common.Address, token common.Add
 
 }
 
+// LockBalance moves amount from the available balance of a token to its locked balance
+// (eg. the sold amount of an order). It fails with ErrInsufficientBalance if the
+// available balance is lower than amount.
+func (dao *AccountDao) LockBalance(owner, token common.Address, amount *big.Int) error {
+	return dao.AdjustTokenBalance(owner, token, new(big.Int).Neg(amount), amount)
+}
+
+// UnlockBalance moves amount from the locked balance of a token back to its available
+// balance (eg. the remaining amount of a cancelled order)
+func (dao *AccountDao) UnlockBalance(owner, token common.Address, amount *big.Int) error {
+	return dao.AdjustTokenBalance(owner, token, amount, new(big.Int).Neg(amount))
+}
+
+// SpendLockedBalance removes amount from the locked balance of a token (eg. the sold
+// amount of a filled order)
+func (dao *AccountDao) SpendLockedBalance(owner, token common.Address, amount *big.Int) error {
+	return dao.AdjustTokenBalance(owner, token, big.NewInt(0), new(big.Int).Neg(amount))
+}
+
+// AdjustTokenBalance adds balanceDelta to the available balance and lockedDelta to the
+// locked balance of a token. Balances are stored as strings and can not be incremented,
+// so the new balances are only written if the balances were not modified since they
+// were read, and the update is retried otherwise. This keeps concurrent orders from
+// locking the same funds twice. It fails with ErrInsufficientBalance if one of the
+// balances would become negative.
+func (dao *AccountDao) AdjustTokenBalance(owner, token common.Address, balanceDelta, lockedDelta *big.Int) error {
+	prefix := "tokenBalances." + token.Hex() + "."
+
+	for i := 0; i < maxBalanceUpdateRetries; i++ {
+		tokenBalances, err := dao.GetTokenBalances(owner)
+		if err != nil {
+			return err
+		}
+
+		tb := tokenBalances[token]
+		if tb == nil {
+			return fmt.Errorf("NO_TOKEN_BALANCE_FOUND")
+		}
+
+		balance := new(big.Int).Add(tb.Balance, balanceDelta)
+		locked := new(big.Int).Add(tb.LockedBalance, lockedDelta)
+		if balance.Sign() < 0 || locked.Sign() < 0 {
+			return ErrInsufficientBalance
+		}
+
+		q := notDeleted(bson.M{
+			"address":                owner.Hex(),
+			prefix + "balance":       tb.Balance.String(),
+			prefix + "lockedBalance": tb.LockedBalance.String(),
+		})
+
+		update := bson.M{
+			"$set": bson.M{
+				prefix + "balance":       balance.String(),
+				prefix + "lockedBalance": locked.String(),
+			},
+		}
+
+		err = db.Update(dao.dbName, dao.collectionName, q, update)
+		if err != mgo.ErrNotFound {
+			return err
+		}
+	}
+
+	return fmt.Errorf("Could not update the %s balance of %s: too many concurrent updates", token.Hex(), owner.Hex())
+}
+
 func (dao *AccountDao) UpdateBalance(owner common.Address, token common.Address, balance *big.Int) (err error) {
 	q := bson.M{
 		"address": owner.Hex(),
@@ -8,6 +8,7 @@ import (
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -226,6 +227,105 @@ func TestUpdateAccountBalance(t *testing.T) {
 	assert.Equal(t, balance.Balance, big.NewInt(20000))
 }
 
+func TestAccountTags(t *testing.T) {
+	address := common.HexToAddress("0x3b89e78363d872c80c78c254bf1bb9ff4ee0bd2d")
+	account := &types.Account{
+		Address:       address,
+		TokenBalances: map[common.Address]*types.TokenBalance{},
+	}
+
+	dao := NewAccountDao()
+
+	err := dao.Create(account)
+	if err != nil {
+		t.Errorf("Could not create account: %v", err)
+	}
+
+	err = dao.AddTags(address, []string{types.TagVIP, types.TagMarketMaker})
+	if err != nil {
+		t.Errorf("Could not tag account: %v", err)
+	}
+
+	err = dao.AddTags(address, []string{types.TagVIP})
+	if err != nil {
+		t.Errorf("Could not tag account: %v", err)
+	}
+
+	err = dao.AddNote(address, &types.AccountNote{Text: "Signed the market maker agreement", Admin: "alice"})
+	if err != nil {
+		t.Errorf("Could not add note: %v", err)
+	}
+
+	res, err := dao.GetByAddress(address)
+	if err != nil {
+		t.Errorf("Could not get account: %v", err)
+	}
+
+	assert.Equal(t, []string{types.TagVIP, types.TagMarketMaker}, res.Tags)
+	assert.Equal(t, 1, len(res.Notes))
+	assert.Equal(t, "alice", res.Notes[0].Admin)
+
+	tagged, err := dao.GetByTags([]string{types.TagMarketMaker}, 0, 0)
+	if err != nil {
+		t.Errorf("Could not get accounts by tag: %v", err)
+	}
+
+	assert.Equal(t, 1, len(tagged))
+	assert.Equal(t, address, tagged[0].Address)
+
+	err = dao.RemoveTag(address, types.TagMarketMaker)
+	if err != nil {
+		t.Errorf("Could not remove tag: %v", err)
+	}
+
+	tagged, err = dao.GetByTags([]string{types.TagMarketMaker}, 0, 0)
+	if err != nil {
+		t.Errorf("Could not get accounts by tag: %v", err)
+	}
+
+	assert.Equal(t, 0, len(tagged))
+}
+
+func TestAccountAnonymize(t *testing.T) {
+	address := common.HexToAddress("0x5e7e4cbc1ec0c1cc2d1f0b4ca9b8dc04c38a8a6a")
+	account := &types.Account{
+		Address:       address,
+		TokenBalances: map[common.Address]*types.TokenBalance{},
+	}
+
+	dao := NewAccountDao()
+
+	err := dao.Create(account)
+	if err != nil {
+		t.Errorf("Could not create account: %v", err)
+	}
+
+	dao.AddTags(address, []string{types.TagVIP})
+	dao.AddNote(address, &types.AccountNote{Text: "Prefers to be reached by phone", Admin: "alice"})
+
+	maxOpenOrders := 1000
+	dao.UpdateLimitOverride(address, &types.AccountLimitOverride{MaxOpenOrders: &maxOpenOrders, Reason: "agreement with Acme Trading"})
+
+	err = dao.Anonymize(address)
+	if err != nil {
+		t.Errorf("Could not anonymize account: %v", err)
+	}
+
+	res, err := dao.GetByAddress(address)
+	if err != nil {
+		t.Errorf("Could not get account: %v", err)
+	}
+
+	assert.NotNil(t, res.AnonymizedAt)
+	assert.Equal(t, 0, len(res.Tags))
+	assert.Equal(t, 0, len(res.Notes))
+	assert.Equal(t, "", res.LimitOverride.Reason)
+	assert.Equal(t, 1000, *res.LimitOverride.MaxOpenOrders)
+
+	err = dao.Anonymize(common.HexToAddress("0x1"))
+	assert.Equal(t, mgo.ErrNotFound, err)
+}
+
 // func TestUpdateAccountBalance(t *testing.T) {
 // 	address := common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47")
 // 	tokenAddress1 := common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5")
@@ -276,3 +376,72 @@ func TestUpdateAccountBalance(t *testing.T) {
 // 	assert.Equal(t, tokenBalance1, balance)
 
 // }
+
+func TestAccountLockBalance(t *testing.T) {
+	address := common.HexToAddress("0x7d3d6a3e0a5c5b2c0a3a8e8c2e0e4e4b3b3f1f5a")
+	token := common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5")
+
+	account := &types.Account{
+		Address: address,
+		TokenBalances: map[common.Address]*types.TokenBalance{
+			token: &types.TokenBalance{
+				ID:            bson.NewObjectId(),
+				Address:       token,
+				Symbol:        "EOS",
+				Balance:       big.NewInt(10000),
+				Allowance:     big.NewInt(10000),
+				LockedBalance: big.NewInt(0),
+			},
+		},
+	}
+
+	dao := NewAccountDao()
+	if err := dao.Create(account); err != nil {
+		t.Fatalf("Could not create account: %v", err)
+	}
+
+	// concurrent orders can not lock more than the available balance
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		go func() {
+			errs <- dao.LockBalance(address, token, big.NewInt(1500))
+		}()
+	}
+
+	locked := 0
+	for i := 0; i < 8; i++ {
+		err := <-errs
+		if err == nil {
+			locked++
+		} else {
+			assert.Equal(t, ErrInsufficientBalance, err)
+		}
+	}
+
+	assert.Equal(t, 6, locked)
+
+	balance, err := dao.GetTokenBalance(address, token)
+	if err != nil {
+		t.Errorf("Could not get token balance: %v", err)
+	}
+
+	assert.Equal(t, big.NewInt(1000), balance.Balance)
+	assert.Equal(t, big.NewInt(9000), balance.LockedBalance)
+
+	err = dao.UnlockBalance(address, token, big.NewInt(1500))
+	assert.Nil(t, err)
+
+	err = dao.SpendLockedBalance(address, token, big.NewInt(3000))
+	assert.Nil(t, err)
+
+	err = dao.SpendLockedBalance(address, token, big.NewInt(5000))
+	assert.Equal(t, ErrInsufficientBalance, err)
+
+	balance, err = dao.GetTokenBalance(address, token)
+	if err != nil {
+		t.Errorf("Could not get token balance: %v", err)
+	}
+
+	assert.Equal(t, big.NewInt(2500), balance.Balance)
+	assert.Equal(t, big.NewInt(4500), balance.LockedBalance)
+}
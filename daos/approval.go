@@ -0,0 +1,66 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ApprovalDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type ApprovalDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewApprovalDao returns a new instance of ApprovalDao
+func NewApprovalDao() *ApprovalDao {
+	return &ApprovalDao{"approvals", app.Config.DBName}
+}
+
+// Create inserts a new approval request
+func (dao *ApprovalDao) Create(a *types.Approval) error {
+	a.ID = bson.NewObjectId()
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = a.CreatedAt
+
+	return db.Create(dao.dbName, dao.collectionName, a)
+}
+
+// GetByID fetches an approval request, it returns nil if there is none
+func (dao *ApprovalDao) GetByID(id bson.ObjectId) (*types.Approval, error) {
+	res := []*types.Approval{}
+	err := db.Get(dao.dbName, dao.collectionName, bson.M{"_id": id}, 0, 1, &res)
+	if err != nil || len(res) == 0 {
+		return nil, err
+	}
+
+	return res[0], nil
+}
+
+// GetByStatus fetches the approval requests with the given status, most recent first
+func (dao *ApprovalDao) GetByStatus(status string) (response []*types.Approval, err error) {
+	q := bson.M{"status": status}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"-createdAt"}, 0, 0, &response)
+	return
+}
+
+// Review moves a pending approval request to another status. It fails with
+// mgo.ErrNotFound if the request is no longer pending, so that a request is only
+// executed once when it is approved concurrently.
+func (dao *ApprovalDao) Review(a *types.Approval, status string) error {
+	a.Status = status
+	a.UpdatedAt = time.Now()
+
+	q := bson.M{"_id": a.ID, "status": types.ApprovalPending}
+	return db.Update(dao.dbName, dao.collectionName, q, a)
+}
+
+// Update replaces a reviewed approval request (eg. to record the result of its execution)
+func (dao *ApprovalDao) Update(a *types.Approval) error {
+	a.UpdatedAt = time.Now()
+	return db.Update(dao.dbName, dao.collectionName, bson.M{"_id": a.ID}, a)
+}
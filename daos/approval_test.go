@@ -0,0 +1,53 @@
+package daos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
+)
+
+func TestApprovalDao(t *testing.T) {
+	dao := NewApprovalDao()
+
+	a := &types.Approval{
+		Action:      types.ActionUnblockAccount,
+		Target:      "0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa",
+		Status:      types.ApprovalPending,
+		RequestedBy: "alice",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	err := dao.Create(a)
+	if err != nil {
+		t.Errorf("Could not create approval: %v", err)
+	}
+
+	pending, err := dao.GetByStatus(types.ApprovalPending)
+	if err != nil {
+		t.Errorf("Could not get approvals: %v", err)
+	}
+
+	assert.Equal(t, 1, len(pending))
+	assert.Equal(t, "alice", pending[0].RequestedBy)
+
+	a.ReviewedBy = "bob"
+	err = dao.Review(a, types.ApprovalExecuted)
+	if err != nil {
+		t.Errorf("Could not review approval: %v", err)
+	}
+
+	// a request is only reviewed once
+	err = dao.Review(a, types.ApprovalRejected)
+	assert.Equal(t, mgo.ErrNotFound, err)
+
+	stored, err := dao.GetByID(a.ID)
+	if err != nil {
+		t.Errorf("Could not get approval: %v", err)
+	}
+
+	assert.Equal(t, types.ApprovalExecuted, stored.Status)
+	assert.Equal(t, "bob", stored.ReviewedBy)
+}
@@ -0,0 +1,37 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AuditDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type AuditDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewAuditDao returns a new instance of AuditDao
+func NewAuditDao() *AuditDao {
+	return &AuditDao{"audit_logs", app.Config.DBName}
+}
+
+// Create inserts a new entry in the audit log
+func (dao *AuditDao) Create(entry *types.AuditLog) error {
+	entry.ID = bson.NewObjectId()
+	entry.CreatedAt = time.Now()
+
+	return db.Create(dao.dbName, dao.collectionName, entry)
+}
+
+// GetByTarget fetches all the audit log entries of a document, most recent first
+func (dao *AuditDao) GetByTarget(target string) (response []*types.AuditLog, err error) {
+	q := bson.M{"target": target}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"-createdAt"}, 0, 0, &response)
+	return
+}
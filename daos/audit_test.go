@@ -0,0 +1,31 @@
+package daos
+
+import (
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditDao(t *testing.T) {
+	dao := NewAuditDao()
+	target := "0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"
+
+	err := dao.Create(&types.AuditLog{
+		Action:  "SETTLEMENT_SKIP",
+		Target:  target,
+		Details: map[string]interface{}{"status": "SKIPPED"},
+	})
+	if err != nil {
+		t.Errorf("Could not create audit log: %v", err)
+	}
+
+	logs, err := dao.GetByTarget(target)
+	if err != nil {
+		t.Errorf("Could not get audit logs: %v", err)
+	}
+
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, "SETTLEMENT_SKIP", logs[0].Action)
+	assert.Equal(t, "SKIPPED", logs[0].Details["status"])
+}
@@ -0,0 +1,113 @@
+package daos
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// minMongoVersion is the oldest mongodb version supporting the aggregation operators used
+// by the OHLCV queries ($dateFromParts) and the trade aggregations ($convert)
+var minMongoVersion = []int{4, 0}
+
+// uniqueIndexes are the unique indexes the daos rely on to not record a document twice,
+// by collection. They are created by the daos but cannot be if an index with the same key
+// and without the unique option already exists.
+var uniqueIndexes = map[string][][]string{
+	"orders":            {{"hash"}},
+	archiveCollection:   {{"hash"}},
+	"withdraws":         {{"hash"}},
+	"deposits":          {{"txHash", "logIndex"}},
+	"pending_txs":       {{"from", "nonce"}},
+	"settlement_events": {{"tradeHash"}},
+}
+
+// CheckDatabase verifies that the mongodb server supports the features used by the daos:
+// its version, the aggregation operators and the unique indexes
+func CheckDatabase() error {
+	sc := db.session.Copy()
+	defer sc.Close()
+
+	info, err := sc.BuildInfo()
+	if err != nil {
+		return fmt.Errorf("could not read the mongodb version: %v", err)
+	}
+
+	if !info.VersionAtLeast(minMongoVersion...) {
+		return fmt.Errorf("mongodb %v is not supported, upgrade to mongodb %v or later", info.Version, joinVersion(minMongoVersion))
+	}
+
+	// the pipeline is parsed even if the collection is empty, an unknown operator fails
+	query := []bson.M{
+		{"$limit": 1},
+		{"$project": bson.M{
+			"t": bson.M{"$dateFromParts": bson.M{"year": 2018}},
+			"d": toDecimal("$amount"),
+		}},
+	}
+
+	var res []interface{}
+	err = sc.DB(app.Config.DBName).C("trades").Pipe(query).All(&res)
+	if err != nil {
+		return fmt.Errorf("mongodb does not support the $dateFromParts and $convert aggregation operators used by the OHLCV queries and the trade aggregations: %v", err)
+	}
+
+	return checkIndexes(sc)
+}
+
+// checkIndexes returns an error if an index conflicts with a unique index of the daos
+func checkIndexes(sc *mgo.Session) error {
+	for collection, keys := range uniqueIndexes {
+		indexes, err := sc.DB(app.Config.DBName).C(collection).Indexes()
+		if isNamespaceNotFound(err) {
+			// the collection and its indexes are created by the dao
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not list the indexes of the %v collection: %v", collection, err)
+		}
+
+		for _, key := range keys {
+			for _, index := range indexes {
+				if sameKey(index.Key, key) && !index.Unique {
+					return fmt.Errorf("the index %v of the %v collection must be unique, drop it so that it is recreated on startup", index.Name, collection)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// isNamespaceNotFound returns true if the error is returned for a missing collection
+func isNamespaceNotFound(err error) bool {
+	qe, ok := err.(*mgo.QueryError)
+	return ok && (qe.Code == 26 || strings.Contains(qe.Message, "ns not found"))
+}
+
+func sameKey(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func joinVersion(v []int) string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = fmt.Sprint(n)
+	}
+
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,37 @@
+package daos
+
+import (
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
+)
+
+func TestCheckIndexes(t *testing.T) {
+	defer func(indexes map[string][][]string) { uniqueIndexes = indexes }(uniqueIndexes)
+	uniqueIndexes = map[string][][]string{"check_indexes": {{"hash"}}}
+
+	// the missing collections are created by the daos
+	assert.Nil(t, checkIndexes(db.session))
+
+	c := db.session.DB(app.Config.DBName).C("check_indexes")
+	err := c.EnsureIndex(mgo.Index{Key: []string{"hash"}})
+	if err != nil {
+		t.Fatalf("Could not create the index: %v", err)
+	}
+
+	assert.NotNil(t, checkIndexes(db.session))
+
+	err = c.DropIndex("hash")
+	if err != nil {
+		t.Fatalf("Could not drop the index: %v", err)
+	}
+
+	err = c.EnsureIndex(mgo.Index{Key: []string{"hash"}, Unique: true})
+	if err != nil {
+		t.Fatalf("Could not create the index: %v", err)
+	}
+
+	assert.Nil(t, checkIndexes(db.session))
+}
@@ -0,0 +1,101 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// DepositDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type DepositDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewDepositDao returns a new instance of DepositDao
+func NewDepositDao() *DepositDao {
+	dbName := app.Config.DBName
+	collection := "deposits"
+	indexes := []mgo.Index{
+		// a deposit log is recorded once, even if it is delivered again (eg. after a failover)
+		{Key: []string{"txHash", "logIndex"}, Unique: true},
+		{Key: []string{"status"}},
+		{Key: []string{"owner", "-createdAt"}},
+	}
+
+	for _, index := range indexes {
+		err := db.session.DB(dbName).C(collection).EnsureIndex(index)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return &DepositDao{collection, dbName}
+}
+
+// Create inserts a new deposit. It returns false without error if the deposit was
+// already recorded.
+func (dao *DepositDao) Create(d *types.Deposit) (bool, error) {
+	d.ID = bson.NewObjectId()
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = d.CreatedAt
+
+	err := db.Create(dao.dbName, dao.collectionName, d)
+	if mgo.IsDup(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// GetByStatus fetches the deposits with the given status, oldest first
+func (dao *DepositDao) GetByStatus(status string) (response []*types.Deposit, err error) {
+	q := bson.M{"status": status}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"blockNumber"}, 0, 0, &response)
+	return
+}
+
+// GetByOwner fetches the deposits of an account, most recent first
+func (dao *DepositDao) GetByOwner(owner common.Address) (response []*types.Deposit, err error) {
+	q := bson.M{"owner": owner.Hex()}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"-createdAt"}, 0, 0, &response)
+	return
+}
+
+// GetLatestBlock returns the number of the latest block with a recorded deposit, or 0
+func (dao *DepositDao) GetLatestBlock() (uint64, error) {
+	res := []*types.Deposit{}
+	err := db.GetWithSort(dao.dbName, dao.collectionName, bson.M{}, []string{"-blockNumber"}, 0, 1, &res)
+	if err != nil || len(res) == 0 {
+		return 0, err
+	}
+
+	return res[0].BlockNumber, nil
+}
+
+// UpdateStatus moves a deposit from a status to another. It fails with mgo.ErrNotFound
+// if the deposit is not in the from status anymore, so that a deposit is only credited
+// once when several watchers confirm it concurrently.
+func (dao *DepositDao) UpdateStatus(d *types.Deposit, from, to string) error {
+	d.Status = to
+	d.UpdatedAt = time.Now()
+
+	q := bson.M{"_id": d.ID, "status": from}
+	update := bson.M{"$set": bson.M{"status": to, "updatedAt": d.UpdatedAt}}
+	return db.Update(dao.dbName, dao.collectionName, q, update)
+}
+
+// UpdateStatusByLog moves the deposit of a log from a status to another, eg. when the
+// log is removed by a chain reorganization. It fails with mgo.ErrNotFound if there is no
+// such deposit in the from status.
+func (dao *DepositDao) UpdateStatusByLog(txHash common.Hash, logIndex uint, from, to string) error {
+	q := bson.M{"txHash": txHash.Hex(), "logIndex": int64(logIndex), "status": from}
+	update := bson.M{"$set": bson.M{"status": to, "updatedAt": time.Now()}}
+	return db.Update(dao.dbName, dao.collectionName, q, update)
+}
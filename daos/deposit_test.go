@@ -0,0 +1,77 @@
+package daos
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
+)
+
+func TestDepositDao(t *testing.T) {
+	dao := NewDepositDao()
+
+	d := &types.Deposit{
+		TxHash:      common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		LogIndex:    3,
+		BlockNumber: 100,
+		Token:       common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		Owner:       common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Amount:      big.NewInt(1000),
+		Status:      types.DepositPending,
+	}
+
+	created, err := dao.Create(d)
+	if err != nil {
+		t.Errorf("Could not create deposit: %v", err)
+	}
+
+	assert.True(t, created)
+
+	// the same log is only recorded once
+	duplicate := *d
+	created, err = dao.Create(&duplicate)
+	if err != nil {
+		t.Errorf("Could not create deposit: %v", err)
+	}
+
+	assert.False(t, created)
+
+	pending, err := dao.GetByStatus(types.DepositPending)
+	if err != nil {
+		t.Errorf("Could not get deposits: %v", err)
+	}
+
+	assert.Equal(t, 1, len(pending))
+	assert.Equal(t, d.Owner, pending[0].Owner)
+	assert.Equal(t, d.Amount, pending[0].Amount)
+
+	latest, err := dao.GetLatestBlock()
+	if err != nil {
+		t.Errorf("Could not get latest block: %v", err)
+	}
+
+	assert.Equal(t, uint64(100), latest)
+
+	err = dao.UpdateStatus(pending[0], types.DepositPending, types.DepositConfirmed)
+	if err != nil {
+		t.Errorf("Could not confirm deposit: %v", err)
+	}
+
+	// a deposit is only confirmed once
+	err = dao.UpdateStatus(pending[0], types.DepositPending, types.DepositConfirmed)
+	assert.Equal(t, mgo.ErrNotFound, err)
+
+	err = dao.UpdateStatusByLog(d.TxHash, d.LogIndex, types.DepositPending, types.DepositRemoved)
+	assert.Equal(t, mgo.ErrNotFound, err)
+
+	deposits, err := dao.GetByOwner(d.Owner)
+	if err != nil {
+		t.Errorf("Could not get deposits: %v", err)
+	}
+
+	assert.Equal(t, 1, len(deposits))
+	assert.Equal(t, types.DepositConfirmed, deposits[0].Status)
+}
@@ -0,0 +1,65 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// JournalDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type JournalDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewJournalDao returns a new instance of JournalDao. The entries are removed by mongodb
+// after the retention period of the journal.
+func NewJournalDao() *JournalDao {
+	dbName := app.Config.DBName
+	collection := "order_journal"
+	indexes := []mgo.Index{
+		{Key: []string{"orderHash", "createdAt"}},
+		{Key: []string{"createdAt"}, ExpireAfter: time.Duration(app.Config.JournalRetention) * 24 * time.Hour},
+	}
+
+	for _, index := range indexes {
+		err := db.session.DB(dbName).C(collection).EnsureIndex(index)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return &JournalDao{collection, dbName}
+}
+
+// Create inserts journal entries
+func (dao *JournalDao) Create(entries ...*types.JournalEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(entries))
+	for i, e := range entries {
+		e.ID = bson.NewObjectId()
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = time.Now()
+		}
+
+		docs[i] = e
+	}
+
+	return db.Create(dao.dbName, dao.collectionName, docs...)
+}
+
+// GetByOrderHash fetches the journal entries of an order, oldest first
+func (dao *JournalDao) GetByOrderHash(hash common.Hash) (response []*types.JournalEntry, err error) {
+	q := bson.M{"orderHash": hash.Hex()}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"createdAt"}, 0, 0, &response)
+	return
+}
@@ -0,0 +1,36 @@
+package daos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalDao(t *testing.T) {
+	dao := NewJournalDao()
+
+	hash := common.HexToHash("0x9b4ec2ca7ae0be3d7d4fd2f3a4bd9c5c26e93ed2ed4c4ad7a4d0ce5e5a74c9ab")
+	now := time.Now()
+
+	err := dao.Create(
+		&types.JournalEntry{OrderHash: hash.Hex(), Event: types.JournalMatched, CreatedAt: now.Add(time.Second)},
+		&types.JournalEntry{OrderHash: hash.Hex(), Event: types.JournalAdded, Details: map[string]interface{}{"amount": "1000"}, CreatedAt: now},
+		&types.JournalEntry{OrderHash: common.HexToHash("0x1").Hex(), Event: types.JournalCancelled},
+	)
+	if err != nil {
+		t.Errorf("Could not create journal entries: %v", err)
+	}
+
+	entries, err := dao.GetByOrderHash(hash)
+	if err != nil {
+		t.Errorf("Could not get journal: %v", err)
+	}
+
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, types.JournalAdded, entries[0].Event)
+	assert.Equal(t, "1000", entries[0].Details["amount"])
+	assert.Equal(t, types.JournalMatched, entries[1].Event)
+}
@@ -19,19 +19,39 @@ type OrderDao struct {
 	dbName         string
 }
 
+// currentOrderStatuses are the statuses of the orders resting in the orderbook and
+// historyOrderStatuses the statuses of the orders that left it
+var (
+	currentOrderStatuses = []string{"NEW", "OPEN", "PARTIAL_FILLED"}
+	historyOrderStatuses = []string{"FILLED", "CANCELLED", "EXPIRED"}
+)
+
+// archiveCollection is the collection where the old orders that left the orderbook are
+// moved out of the orders collection
+const archiveCollection = "orders_archive"
+
 // NewOrderDao returns a new instance of OrderDao
 func NewOrderDao() *OrderDao {
 	dbName := app.Config.DBName
 	collection := "orders"
-	index := mgo.Index{
-		Key:    []string{"hash"},
-		Unique: true,
+	indexes := []mgo.Index{
+		{Key: []string{"hash"}, Unique: true},
+		// order history of an account, most recent first
+		{Key: []string{"userAddress", "-createdAt"}},
+	}
+
+	for _, index := range indexes {
+		err := db.session.DB(dbName).C(collection).EnsureIndex(index)
+		if err != nil {
+			panic(err)
+		}
 	}
 
-	err := db.session.DB(dbName).C(collection).EnsureIndex(index)
+	err := db.session.DB(dbName).C(archiveCollection).EnsureIndex(mgo.Index{Key: []string{"hash"}, Unique: true})
 	if err != nil {
 		panic(err)
 	}
+
 	return &OrderDao{collection, dbName}
 }
 
@@ -82,6 +102,19 @@ func (dao *OrderDao) GetByID(id bson.ObjectId) (response *types.Order, err error
 	return
 }
 
+// GetByIDs fetches the orders with the given mongoDB IDs in a single query. The missing
+// orders are skipped.
+func (dao *OrderDao) GetByIDs(ids ...bson.ObjectId) ([]*types.Order, error) {
+	q := bson.M{"_id": bson.M{"$in": ids}}
+	response := []*types.Order{}
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
 // GetByHash function fetches a single document from order collection based on mongoDB ID.
 // Returns Order type struct
 func (dao *OrderDao) GetByHash(hash common.Hash) (response *types.Order, err error) {
@@ -94,6 +127,20 @@ func (dao *OrderDao) GetByHash(hash common.Hash) (response *types.Order, err err
 	return &resp[0], nil
 }
 
+// GetArchivedByHash fetches an order from the archive collection. It returns nil if the
+// order was not archived.
+func (dao *OrderDao) GetArchivedByHash(hash common.Hash) (*types.Order, error) {
+	q := bson.M{"hash": hash.Hex()}
+	var resp []types.Order
+	err := db.Get(dao.dbName, archiveCollection, q, 0, 1, &resp)
+	if err != nil || len(resp) == 0 {
+		return nil, err
+	}
+
+	resp[0].Archived = true
+	return &resp[0], nil
+}
+
 // GetByUserAddress function fetches list of orders from order collection based on user address.
 // Returns array of Order type struct
 func (dao *OrderDao) GetByUserAddress(addr common.Address) (response []*types.Order, err error) {
@@ -101,3 +148,124 @@ func (dao *OrderDao) GetByUserAddress(addr common.Address) (response []*types.Or
 	err = db.Get(dao.dbName, dao.collectionName, q, 0, 0, &response)
 	return
 }
+
+// GetCurrentByUserAddress fetches the orders of an account that are still resting in the
+// orderbook (NEW, OPEN or PARTIAL_FILLED), most recent first
+func (dao *OrderDao) GetCurrentByUserAddress(addr common.Address) ([]*types.Order, error) {
+	q := bson.M{
+		"userAddress": addr.Hex(),
+		"status":      bson.M{"$in": currentOrderStatuses},
+	}
+
+	response := []*types.Order{}
+	err := db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"-createdAt", "-_id"}, 0, 0, &response)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetHistoryByUserAddress fetches the orders of an account that are no longer in the
+// orderbook (FILLED, CANCELLED or EXPIRED), most recent first. It also returns the total
+// number of such orders.
+func (dao *OrderDao) GetHistoryByUserAddress(addr common.Address, offset, limit int) ([]*types.Order, int, error) {
+	return dao.Query(&types.OrderQuery{
+		UserAddress: addr,
+		Statuses:    historyOrderStatuses,
+		Offset:      offset,
+		Limit:       limit,
+	})
+}
+
+// Query fetches the orders of an account matching the filters of the query, most recent
+// first. It also returns the total number of matching orders.
+func (dao *OrderDao) Query(oq *types.OrderQuery) ([]*types.Order, int, error) {
+	q := bson.M{"userAddress": oq.UserAddress.Hex()}
+
+	if len(oq.Statuses) > 0 {
+		q["status"] = bson.M{"$in": oq.Statuses}
+	}
+
+	if oq.BaseToken != (common.Address{}) {
+		q["baseToken"] = oq.BaseToken.Hex()
+	}
+
+	if oq.QuoteToken != (common.Address{}) {
+		q["quoteToken"] = oq.QuoteToken.Hex()
+	}
+
+	createdAt := bson.M{}
+	if !oq.From.IsZero() {
+		createdAt["$gte"] = oq.From
+	}
+
+	if !oq.To.IsZero() {
+		createdAt["$lte"] = oq.To
+	}
+
+	if len(createdAt) > 0 {
+		q["createdAt"] = createdAt
+	}
+
+	total, err := db.Count(dao.dbName, dao.collectionName, q)
+	if err != nil {
+		log.Print(err)
+		return nil, 0, err
+	}
+
+	response := []*types.Order{}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"-createdAt", "-_id"}, oq.Offset, oq.Limit, &response)
+	if err != nil {
+		log.Print(err)
+		return nil, 0, err
+	}
+
+	return response, total, nil
+}
+
+// GetOpenByPair fetches the orders of a pair resting in the orderbook once processed by
+// the engine (OPEN or PARTIAL_FILLED), in the order they entered the engine
+func (dao *OrderDao) GetOpenByPair(baseToken, quoteToken common.Address) ([]*types.Order, error) {
+	q := bson.M{
+		"baseToken":  baseToken.Hex(),
+		"quoteToken": quoteToken.Hex(),
+		"status":     bson.M{"$in": []string{"OPEN", "PARTIAL_FILLED"}},
+	}
+
+	response := []*types.Order{}
+	err := db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"sequence", "createdAt"}, 0, 0, &response)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetCurrent fetches the orders of all the accounts that are still resting in the
+// orderbook (NEW, OPEN or PARTIAL_FILLED)
+func (dao *OrderDao) GetCurrent() ([]*types.Order, error) {
+	q := bson.M{"status": bson.M{"$in": currentOrderStatuses}}
+
+	response := []*types.Order{}
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &response)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CountOpenOrdersByAddress returns the number of orders placed by the passed user address
+// that are still resting in the orderbook (NEW, OPEN or PARTIAL_FILLED)
+func (dao *OrderDao) CountOpenOrdersByAddress(addr common.Address) (int, error) {
+	q := bson.M{
+		"userAddress": addr.Hex(),
+		"status":      bson.M{"$in": currentOrderStatuses},
+	}
+
+	return db.Count(dao.dbName, dao.collectionName, q)
+}
@@ -264,3 +264,222 @@ func TestOrderDao(t *testing.T) {
 
 	CompareOrder(t, o, o2[0])
 }
+
+func TestCountOpenOrdersByAddress(t *testing.T) {
+	user := common.HexToAddress("0x1a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+
+	o := &types.Order{
+		UserAddress:     user,
+		ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		BuyToken:        common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		SellToken:       common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BaseToken:       common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken:      common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BuyAmount:       big.NewInt(1000),
+		SellAmount:      big.NewInt(100),
+		Price:           big.NewInt(1000),
+		PricePoint:      big.NewInt(1000),
+		Amount:          big.NewInt(1000),
+		FilledAmount:    big.NewInt(0),
+		Side:            "BUY",
+		PairName:        "ZRX/WETH",
+		Expires:         big.NewInt(10000),
+		MakeFee:         big.NewInt(50),
+		Nonce:           big.NewInt(1000),
+		TakeFee:         big.NewInt(50),
+		Hash:            common.HexToHash("0xc9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+	}
+
+	dao := NewOrderDao()
+
+	err := dao.Create(o)
+	if err != nil {
+		t.Errorf("Could not create order object")
+	}
+
+	count, err := dao.CountOpenOrdersByAddress(user)
+	if err != nil {
+		t.Errorf("Could not count open orders: %v", err)
+	}
+
+	assert.Equal(t, 1, count)
+
+	o.Status = "FILLED"
+	err = dao.Update(o.ID, o)
+	if err != nil {
+		t.Errorf("Could not update order: %v", err)
+	}
+
+	count, err = dao.CountOpenOrdersByAddress(user)
+	if err != nil {
+		t.Errorf("Could not count open orders: %v", err)
+	}
+
+	assert.Equal(t, 0, count)
+}
+
+func TestOrderDaoQuery(t *testing.T) {
+	user := common.HexToAddress("0x2a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	zrx := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	dai := common.HexToAddress("0xb7a4f3e9097c08da09517b5ab877f7a917224ede")
+	weth := common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093")
+	now := time.Now().Round(time.Second)
+
+	dao := NewOrderDao()
+
+	statuses := []string{"OPEN", "FILLED", "CANCELLED", "OPEN"}
+	for i, status := range statuses {
+		base := zrx
+		if i == 3 {
+			base = dai
+		}
+
+		o := &types.Order{
+			ID:          bson.NewObjectId(),
+			UserAddress: user,
+			BaseToken:   base,
+			QuoteToken:  weth,
+			Status:      status,
+			Hash:        common.BigToHash(big.NewInt(int64(100 + i))),
+			CreatedAt:   now.Add(time.Duration(i) * time.Minute),
+		}
+
+		err := db.Create(dao.dbName, dao.collectionName, o)
+		if err != nil {
+			t.Fatalf("Could not create order object: %v", err)
+		}
+	}
+
+	orders, total, err := dao.Query(&types.OrderQuery{UserAddress: user})
+	if err != nil {
+		t.Fatalf("Could not query orders: %v", err)
+	}
+
+	// most recent first
+	assert.Equal(t, 4, total)
+	assert.Equal(t, 4, len(orders))
+	assert.Equal(t, dai, orders[0].BaseToken)
+
+	orders, total, err = dao.Query(&types.OrderQuery{UserAddress: user, Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatalf("Could not query orders: %v", err)
+	}
+
+	assert.Equal(t, 4, total)
+	assert.Equal(t, 2, len(orders))
+	assert.Equal(t, "CANCELLED", orders[0].Status)
+	assert.Equal(t, "FILLED", orders[1].Status)
+
+	orders, total, err = dao.Query(&types.OrderQuery{
+		UserAddress: user,
+		Statuses:    []string{"OPEN"},
+		BaseToken:   zrx,
+		QuoteToken:  weth,
+	})
+	if err != nil {
+		t.Fatalf("Could not query orders: %v", err)
+	}
+
+	assert.Equal(t, 1, total)
+	assert.Equal(t, common.BigToHash(big.NewInt(100)), orders[0].Hash)
+
+	orders, total, err = dao.Query(&types.OrderQuery{
+		UserAddress: user,
+		From:        now.Add(time.Minute),
+		To:          now.Add(2 * time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("Could not query orders: %v", err)
+	}
+
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "CANCELLED", orders[0].Status)
+	assert.Equal(t, "FILLED", orders[1].Status)
+}
+
+func TestOrderDaoCurrentAndHistory(t *testing.T) {
+	user := common.HexToAddress("0x3a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	now := time.Now().Round(time.Second)
+
+	dao := NewOrderDao()
+
+	statuses := []string{"NEW", "OPEN", "PARTIAL_FILLED", "FILLED", "CANCELLED", "EXPIRED", "INVALID"}
+	for i, status := range statuses {
+		o := &types.Order{
+			ID:          bson.NewObjectId(),
+			UserAddress: user,
+			Status:      status,
+			Hash:        common.BigToHash(big.NewInt(int64(200 + i))),
+			CreatedAt:   now.Add(time.Duration(i) * time.Minute),
+		}
+
+		err := db.Create(dao.dbName, dao.collectionName, o)
+		if err != nil {
+			t.Fatalf("Could not create order object: %v", err)
+		}
+	}
+
+	current, err := dao.GetCurrentByUserAddress(user)
+	if err != nil {
+		t.Fatalf("Could not get current orders: %v", err)
+	}
+
+	assert.Equal(t, 3, len(current))
+	assert.Equal(t, "PARTIAL_FILLED", current[0].Status)
+	assert.Equal(t, "NEW", current[2].Status)
+
+	history, total, err := dao.GetHistoryByUserAddress(user, 0, 2)
+	if err != nil {
+		t.Fatalf("Could not get order history: %v", err)
+	}
+
+	assert.Equal(t, 3, total)
+	assert.Equal(t, 2, len(history))
+	assert.Equal(t, "EXPIRED", history[0].Status)
+	assert.Equal(t, "CANCELLED", history[1].Status)
+}
+
+func TestOrderDaoGetByIDs(t *testing.T) {
+	newOrder := func(hash string) *types.Order {
+		return &types.Order{
+			UserAddress:     common.HexToAddress("0x2a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+			ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+			BuyToken:        common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+			SellToken:       common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+			BaseToken:       common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+			QuoteToken:      common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+			BuyAmount:       big.NewInt(1000),
+			SellAmount:      big.NewInt(100),
+			Price:           big.NewInt(1000),
+			PricePoint:      big.NewInt(1000),
+			Amount:          big.NewInt(1000),
+			FilledAmount:    big.NewInt(0),
+			Side:            "BUY",
+			PairName:        "ZRX/WETH",
+			Expires:         big.NewInt(10000),
+			MakeFee:         big.NewInt(50),
+			Nonce:           big.NewInt(1000),
+			TakeFee:         big.NewInt(50),
+			Hash:            common.HexToHash(hash),
+		}
+	}
+
+	o1 := newOrder("0xd9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a")
+	o2 := newOrder("0xd9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880b")
+
+	dao := NewOrderDao()
+	for _, o := range []*types.Order{o1, o2} {
+		err := dao.Create(o)
+		if err != nil {
+			t.Errorf("Could not create order object")
+		}
+	}
+
+	// the missing orders are skipped
+	orders, err := dao.GetByIDs(o1.ID, o2.ID, bson.NewObjectId())
+	if err != nil {
+		t.Errorf("Could not get orders: %v", err)
+	}
+
+	assert.Equal(t, 2, len(orders))
+}
@@ -2,6 +2,7 @@ package daos
 
 import (
 	"errors"
+	"regexp"
 	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/app"
@@ -33,13 +34,29 @@ func (dao *PairDao) Create(pair *types.Pair) (err error) {
 	return
 }
 
+// Delete marks the pair corresponding to the given base and quote token as deleted.
+// The document is kept so that historical orders and trades still resolve.
+func (dao *PairDao) Delete(baseToken, quoteToken common.Address) (err error) {
+	q := notDeleted(bson.M{
+		"baseTokenAddress":  baseToken.Hex(),
+		"quoteTokenAddress": quoteToken.Hex(),
+	})
+
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"deletedAt": now, "updatedAt": now}}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
 // GetAll function fetches all the pairs in the pair collection of mongodb.
 func (dao *PairDao) GetAll() (response []types.Pair, err error) {
-	err = db.Get(dao.dbName, dao.collectionName, bson.M{}, 0, 0, &response)
+	err = db.Get(dao.dbName, dao.collectionName, notDeleted(bson.M{}), 0, 0, &response)
 	return
 }
 
 // GetByID function fetches details of a pair using pair's mongo ID.
+// Deleted pairs are returned as well so that references keep resolving.
 func (dao *PairDao) GetByID(id bson.ObjectId) (response *types.Pair, err error) {
 	err = db.GetByID(dao.dbName, dao.collectionName, id, &response)
 	return
@@ -49,10 +66,10 @@ func (dao *PairDao) GetByID(id bson.ObjectId) (response *types.Pair, err error)
 // It makes CASE INSENSITIVE search query one pair's name
 func (dao *PairDao) GetByName(name string) (*types.Pair, error) {
 	var res []*types.Pair
-	q := bson.M{"name": bson.RegEx{
+	q := notDeleted(bson.M{"name": bson.RegEx{
 		Pattern: name,
 		Options: "i",
-	}}
+	}})
 
 	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
 	if err != nil {
@@ -69,10 +86,10 @@ func (dao *PairDao) GetByName(name string) (*types.Pair, error) {
 func (dao *PairDao) GetByTokenSymbols(baseTokenSymbol, quoteTokenSymbol string) (*types.Pair, error) {
 	var res []*types.Pair
 
-	q := bson.M{
+	q := notDeleted(bson.M{
 		"baseTokenSymbol":  baseTokenSymbol,
 		"quoteTokenSymbol": quoteTokenSymbol,
-	}
+	})
 
 	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
 	if err != nil {
@@ -86,15 +103,151 @@ func (dao *PairDao) GetByTokenSymbols(baseTokenSymbol, quoteTokenSymbol string)
 	return res[0], nil
 }
 
+// GetBySymbol fetches the pair registered with the given display symbol (case insensitive).
+// It returns nil if no pair uses the symbol.
+func (dao *PairDao) GetBySymbol(symbol string) (*types.Pair, error) {
+	var res []*types.Pair
+	q := notDeleted(bson.M{"symbol": bson.RegEx{
+		Pattern: "^" + regexp.QuoteMeta(symbol) + "$",
+		Options: "i",
+	}})
+
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return res[0], nil
+}
+
+// UpdateSymbol changes the display symbol of the pair corresponding to the given base
+// and quote token. Pairs are referenced by token addresses so renaming a pair does not
+// affect existing orders and trades.
+func (dao *PairDao) UpdateSymbol(baseToken, quoteToken common.Address, symbol string) (err error) {
+	q := notDeleted(bson.M{
+		"baseTokenAddress":  baseToken.Hex(),
+		"quoteTokenAddress": quoteToken.Hex(),
+	})
+
+	update := bson.M{"$set": bson.M{"symbol": symbol, "updatedAt": time.Now()}}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// UpdateFeeOverride sets the fee override of the pair corresponding to the given base
+// and quote token. The override is removed when it is nil.
+func (dao *PairDao) UpdateFeeOverride(baseToken, quoteToken common.Address, override *types.PairFeeOverride) (err error) {
+	q := notDeleted(bson.M{
+		"baseTokenAddress":  baseToken.Hex(),
+		"quoteTokenAddress": quoteToken.Hex(),
+	})
+
+	update := bson.M{
+		"$set":   bson.M{"updatedAt": time.Now()},
+		"$unset": bson.M{"feeOverride": ""},
+	}
+
+	if override != nil {
+		update = bson.M{"$set": bson.M{"feeOverride": override, "updatedAt": time.Now()}}
+	}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// UpdateListingTime sets the go-live time of the pair corresponding to the given base
+// and quote token
+func (dao *PairDao) UpdateListingTime(baseToken, quoteToken common.Address, t time.Time) (err error) {
+	q := notDeleted(bson.M{
+		"baseTokenAddress":  baseToken.Hex(),
+		"quoteTokenAddress": quoteToken.Hex(),
+	})
+
+	update := bson.M{"$set": bson.M{"listingTime": t, "updatedAt": time.Now()}}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// SetLaunched records the launch time of the scheduled listing of the pair
+// corresponding to the given base and quote token
+func (dao *PairDao) SetLaunched(baseToken, quoteToken common.Address, t time.Time) (err error) {
+	q := notDeleted(bson.M{
+		"baseTokenAddress":  baseToken.Hex(),
+		"quoteTokenAddress": quoteToken.Hex(),
+	})
+
+	update := bson.M{"$set": bson.M{"launchedAt": t, "updatedAt": time.Now()}}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// GetDueListings returns the pairs whose scheduled listing is due at the given time
+// and has not been launched yet
+func (dao *PairDao) GetDueListings(t time.Time) ([]types.Pair, error) {
+	var res []types.Pair
+
+	q := notDeleted(bson.M{
+		"listingTime": bson.M{"$lte": t},
+		"launchedAt":  bson.M{"$exists": false},
+	})
+
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetAllByTokenSymbols returns all the pairs whose base and quote token symbols match
+// the given symbols (case insensitive). Token symbols are not unique so several pairs can
+// share the same symbols.
+func (dao *PairDao) GetAllByTokenSymbols(baseTokenSymbol, quoteTokenSymbol string) ([]types.Pair, error) {
+	var res []types.Pair
+
+	q := notDeleted(bson.M{
+		"baseTokenSymbol":  bson.RegEx{Pattern: "^" + regexp.QuoteMeta(baseTokenSymbol) + "$", Options: "i"},
+		"quoteTokenSymbol": bson.RegEx{Pattern: "^" + regexp.QuoteMeta(quoteTokenSymbol) + "$", Options: "i"},
+	})
+
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetByQuoteTokenAddress returns all the pairs quoted in the token corresponding
+// to the given contract address
+func (dao *PairDao) GetByQuoteTokenAddress(quoteToken common.Address) ([]types.Pair, error) {
+	var res []types.Pair
+
+	q := notDeleted(bson.M{"quoteTokenAddress": quoteToken.Hex()})
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // GetByTokenAddress function fetches pair based on
 // CONTRACT ADDRESS of base token and quote token
 func (dao *PairDao) GetByTokenAddress(baseToken, quoteToken common.Address) (*types.Pair, error) {
 	var res []*types.Pair
 
-	q := bson.M{
+	q := notDeleted(bson.M{
 		"baseTokenAddress":  baseToken.Hex(),
 		"quoteTokenAddress": quoteToken.Hex(),
-	}
+	})
 
 	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
 	if err != nil {
@@ -112,7 +265,7 @@ func (dao *PairDao) GetByTokenAddress(baseToken, quoteToken common.Address) (*ty
 // CONTRACT ADDRESS of buy token and sell token
 func (dao *PairDao) GetByBuySellTokenAddress(buyToken, sellToken common.Address) (*types.Pair, error) {
 	var res []*types.Pair
-	q := bson.M{
+	q := notDeleted(bson.M{
 		"$or": []bson.M{
 			bson.M{
 				"baseTokenAddress":  buyToken.Hex(),
@@ -123,7 +276,7 @@ func (dao *PairDao) GetByBuySellTokenAddress(buyToken, sellToken common.Address)
 				"quoteTokenAddress": buyToken.Hex(),
 			},
 		},
-	}
+	})
 
 	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
 	if err != nil {
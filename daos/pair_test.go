@@ -76,3 +76,90 @@ func TestPairDao(t *testing.T) {
 
 	ComparePair(t, pair, byAddress)
 }
+
+func TestPairDaoGetAllByTokenSymbols(t *testing.T) {
+	dao := NewPairDao()
+
+	for _, addr := range []string{"0x1d3b6f13bd9d6eb5b2f7ac3a3b3c5c2a0b0f4a01", "0x1d3b6f13bd9d6eb5b2f7ac3a3b3c5c2a0b0f4a02"} {
+		pair := &types.Pair{
+			Name:              "ZRX/WETH",
+			BaseTokenID:       bson.NewObjectId(),
+			BaseTokenSymbol:   "ZRX",
+			BaseTokenAddress:  common.HexToAddress(addr),
+			QuoteTokenID:      bson.NewObjectId(),
+			QuoteTokenSymbol:  "WETH",
+			QuoteTokenAddress: common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+			Active:            true,
+			MakeFee:           big.NewInt(10000),
+			TakeFee:           big.NewInt(10000),
+		}
+
+		err := dao.Create(pair)
+		if err != nil {
+			t.Errorf("Could not create pair object: %+v", err)
+		}
+	}
+
+	pairs, err := dao.GetAllByTokenSymbols("zrx", "weth")
+	if err != nil {
+		t.Errorf("Could not get pairs by symbols: %v", err)
+	}
+
+	assert.Equal(t, 2, len(pairs))
+
+	pairs, err = dao.GetAllByTokenSymbols("ZR", "WETH")
+	if err != nil {
+		t.Errorf("Could not get pairs by symbols: %v", err)
+	}
+
+	assert.Equal(t, 0, len(pairs))
+}
+
+func TestPairDaoSymbol(t *testing.T) {
+	dao := NewPairDao()
+
+	pair := &types.Pair{
+		Name:              "AMP/WETH",
+		Symbol:            "AMP-WETH",
+		BaseTokenID:       bson.NewObjectId(),
+		BaseTokenSymbol:   "AMP",
+		BaseTokenAddress:  common.HexToAddress("0x2d3b6f13bd9d6eb5b2f7ac3a3b3c5c2a0b0f4a01"),
+		QuoteTokenID:      bson.NewObjectId(),
+		QuoteTokenSymbol:  "WETH",
+		QuoteTokenAddress: common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Active:            true,
+		MakeFee:           big.NewInt(10000),
+		TakeFee:           big.NewInt(10000),
+	}
+
+	err := dao.Create(pair)
+	if err != nil {
+		t.Errorf("Could not create pair object: %+v", err)
+	}
+
+	bySymbol, err := dao.GetBySymbol("amp-weth")
+	if err != nil {
+		t.Errorf("Could not get pair by symbol: %v", err)
+	}
+
+	ComparePair(t, pair, bySymbol)
+
+	err = dao.UpdateSymbol(pair.BaseTokenAddress, pair.QuoteTokenAddress, "AMPL-WETH")
+	if err != nil {
+		t.Errorf("Could not update pair symbol: %v", err)
+	}
+
+	bySymbol, err = dao.GetBySymbol("AMP-WETH")
+	if err != nil {
+		t.Errorf("Could not get pair by symbol: %v", err)
+	}
+
+	assert.Nil(t, bySymbol)
+
+	byAddress, err := dao.GetByTokenAddress(pair.BaseTokenAddress, pair.QuoteTokenAddress)
+	if err != nil {
+		t.Errorf("Could not get pair by address: %v", err)
+	}
+
+	assert.Equal(t, "AMPL-WETH", byAddress.Symbol)
+}
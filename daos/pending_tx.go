@@ -0,0 +1,57 @@
+package daos
+
+import (
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// PendingTxDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type PendingTxDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewPendingTxDao returns a new instance of PendingTxDao
+func NewPendingTxDao() *PendingTxDao {
+	dbName := app.Config.DBName
+	collection := "pending_txs"
+
+	// an account has a single pending transaction per nonce
+	index := mgo.Index{
+		Key:    []string{"from", "nonce"},
+		Unique: true,
+	}
+
+	err := db.session.DB(dbName).C(collection).EnsureIndex(index)
+	if err != nil {
+		panic(err)
+	}
+
+	return &PendingTxDao{collection, dbName}
+}
+
+// Save inserts a pending transaction or replaces the transaction pending with the same
+// account and nonce
+func (dao *PendingTxDao) Save(p *types.PendingTx) error {
+	q := bson.M{"from": p.From.Hex(), "nonce": int64(p.Nonce)}
+	return db.Upsert(dao.dbName, dao.collectionName, q, bson.M{"$set": p})
+}
+
+// GetByAccount fetches the pending transactions of an account ordered by nonce
+func (dao *PendingTxDao) GetByAccount(from common.Address) (response []*types.PendingTx, err error) {
+	q := bson.M{"from": from.Hex()}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"nonce"}, 0, 0, &response)
+	return
+}
+
+// DeleteBelow removes the pending transactions of an account with a nonce lower than
+// nonce, ie. the transactions that were mined
+func (dao *PendingTxDao) DeleteBelow(from common.Address, nonce uint64) error {
+	q := bson.M{"from": from.Hex(), "nonce": bson.M{"$lt": int64(nonce)}}
+	return db.RemoveAll(dao.dbName, dao.collectionName, q)
+}
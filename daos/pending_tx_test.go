@@ -0,0 +1,63 @@
+package daos
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	eth "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPendingTxDao(t *testing.T) {
+	dao := NewPendingTxDao()
+	from := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	to := common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47")
+
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		p := &types.PendingTx{
+			From:      from,
+			Nonce:     nonce,
+			Tx:        eth.NewTransaction(nonce, to, big.NewInt(0), 21000, big.NewInt(1e9), nil),
+			SentAt:    time.Now(),
+			CreatedAt: time.Now(),
+		}
+
+		err := dao.Save(p)
+		if err != nil {
+			t.Errorf("Could not save pending tx: %v", err)
+		}
+	}
+
+	// replacing the transaction of a nonce does not add a pending transaction
+	bumped := eth.NewTransaction(1, to, big.NewInt(0), 21000, big.NewInt(2e9), nil)
+	err := dao.Save(&types.PendingTx{From: from, Nonce: 1, Tx: bumped, Bumps: 1})
+	if err != nil {
+		t.Errorf("Could not save pending tx: %v", err)
+	}
+
+	pending, err := dao.GetByAccount(from)
+	if err != nil {
+		t.Errorf("Could not get pending txs: %v", err)
+	}
+
+	assert.Equal(t, 3, len(pending))
+	assert.Equal(t, uint64(0), pending[0].Nonce)
+	assert.Equal(t, bumped.Hash(), pending[1].Tx.Hash())
+	assert.Equal(t, 1, pending[1].Bumps)
+
+	err = dao.DeleteBelow(from, 2)
+	if err != nil {
+		t.Errorf("Could not delete pending txs: %v", err)
+	}
+
+	pending, err = dao.GetByAccount(from)
+	if err != nil {
+		t.Errorf("Could not get pending txs: %v", err)
+	}
+
+	assert.Equal(t, 1, len(pending))
+	assert.Equal(t, uint64(2), pending[0].Nonce)
+}
@@ -1,7 +1,10 @@
 package daos
 
 import (
+	"context"
+
 	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/utils/breaker"
 	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -22,19 +25,58 @@ func InitSession() (*mgo.Session, error) {
 		if err != nil {
 			return nil, err
 		}
+		// bound the socket operations so that the calls timed out by the breaker
+		// do not hang forever
+		if timeout := breaker.Get("mongodb").Settings().Timeout; timeout > 0 {
+			db1.SetSocketTimeout(timeout)
+		}
+
 		db = &Database{db1}
 	}
 	return db.session, nil
 }
 
+func init() {
+	breaker.Get("mongodb").SetFailureFilter(isConnectionError)
+}
+
+// call runs fn on a copy of the session, which is returned to the connection pool once
+// fn returns. The calls go through the mongodb circuit breaker, so that requests fail
+// fast while the database is unreachable.
+func (d *Database) call(fn func(sc *mgo.Session) error) error {
+	return breaker.Get("mongodb").Call(func(ctx context.Context) error {
+		sc := d.session.Copy()
+		defer sc.Close()
+
+		return fn(sc)
+	})
+}
+
+// isConnectionError returns false for the errors returned by the database server (eg.
+// not found or duplicate key), which must not open the circuit breaker
+func isConnectionError(err error) bool {
+	switch err.(type) {
+	case *mgo.QueryError, *mgo.LastError, *mgo.BulkError:
+		return false
+	}
+
+	return err != mgo.ErrNotFound && err != mgo.ErrCursor
+}
+
+// notDeleted adds the tombstone filter to a query so that documents removed via
+// admin actions (i.e. with a deletedAt field) are not returned
+func notDeleted(q bson.M) bson.M {
+	q["deletedAt"] = bson.M{"$exists": false}
+	return q
+}
+
 // Create is a wrapper for mgo.Insert function.
 // It creates a copy of session initialized, sends query over this session
 // and returns the session to connection pool
 func (d *Database) Create(dbName, collection string, data ...interface{}) (err error) {
-	sc := d.session.Copy()
-	defer sc.Close()
-
-	err = sc.DB(dbName).C(collection).Insert(data...)
+	err = d.call(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Insert(data...)
+	})
 	return
 }
 
@@ -42,10 +84,9 @@ func (d *Database) Create(dbName, collection string, data ...interface{}) (err e
 // It creates a copy of session initialized, sends query over this session
 // and returns the session to connection pool
 func (d *Database) GetByID(dbName, collection string, id bson.ObjectId, response interface{}) (err error) {
-	sc := d.session.Copy()
-	defer sc.Close()
-
-	err = sc.DB(dbName).C(collection).FindId(id).One(response)
+	err = d.call(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).FindId(id).One(response)
+	})
 	return
 }
 
@@ -53,18 +94,16 @@ func (d *Database) GetByID(dbName, collection string, id bson.ObjectId, response
 // It creates a copy of session initialized, sends query over this session
 // and returns the session to connection pool
 func (d *Database) Get(dbName, collection string, query interface{}, offset, limit int, response interface{}) (err error) {
-	sc := d.session.Copy()
-	defer sc.Close()
-
-	err = sc.DB(dbName).C(collection).Find(query).Skip(offset).Limit(limit).All(response)
+	err = d.call(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Find(query).Skip(offset).Limit(limit).All(response)
+	})
 	return
 }
 
 func (d *Database) Query(dbName, collection string, query interface{}, selector interface{}, offset, limit int, response interface{}) (err error) {
-	sc := d.session.Copy()
-	defer sc.Close()
-
-	err = sc.DB(dbName).C(collection).Find(query).Skip(offset).Limit(limit).Select(selector).All(response)
+	err = d.call(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Find(query).Skip(offset).Limit(limit).Select(selector).All(response)
+	})
 	return
 }
 
@@ -72,10 +111,20 @@ func (d *Database) Query(dbName, collection string, query interface{}, selector
 // It creates a copy of session initialized, sends query over this session
 // and returns the session to connection pool
 func (d *Database) GetWithSort(dbName, collection string, query interface{}, sort []string, offset, limit int, response interface{}) (err error) {
-	sc := d.session.Copy()
-	defer sc.Close()
+	err = d.call(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Find(query).Sort(sort...).Skip(offset).Limit(limit).All(response)
+	})
+	return
+}
 
-	err = sc.DB(dbName).C(collection).Find(query).Sort(sort...).Skip(offset).Limit(limit).All(response)
+// Count is a wrapper for mgo.Count function.
+// It creates a copy of session initialized, sends query over this session
+// and returns the session to connection pool
+func (d *Database) Count(dbName, collection string, query interface{}) (count int, err error) {
+	err = d.call(func(sc *mgo.Session) (err error) {
+		count, err = sc.DB(dbName).C(collection).Find(query).Count()
+		return
+	})
 	return
 }
 
@@ -83,10 +132,31 @@ func (d *Database) GetWithSort(dbName, collection string, query interface{}, sor
 // It creates a copy of session initialized, sends query over this session
 // and returns the session to connection pool
 func (d *Database) Update(dbName, collection string, query interface{}, update interface{}) (err error) {
-	sc := d.session.Copy()
-	defer sc.Close()
+	err = d.call(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Update(query, update)
+	})
+	return
+}
 
-	err = sc.DB(dbName).C(collection).Update(query, update)
+// Upsert is a wrapper for mgo.Upsert function.
+// It creates a copy of session initialized, sends query over this session
+// and returns the session to connection pool
+func (d *Database) Upsert(dbName, collection string, query interface{}, update interface{}) (err error) {
+	err = d.call(func(sc *mgo.Session) error {
+		_, err := sc.DB(dbName).C(collection).Upsert(query, update)
+		return err
+	})
+	return
+}
+
+// RemoveAll is a wrapper for mgo.RemoveAll function.
+// It creates a copy of session initialized, sends query over this session
+// and returns the session to connection pool
+func (d *Database) RemoveAll(dbName, collection string, query interface{}) (err error) {
+	err = d.call(func(sc *mgo.Session) error {
+		_, err := sc.DB(dbName).C(collection).RemoveAll(query)
+		return err
+	})
 	return
 }
 
@@ -95,8 +165,8 @@ func (d *Database) Update(dbName, collection string, query interface{}, update i
 // It creates a copy of session initialized, sends query over this session
 // and returns the session to connection pool
 func (d *Database) Aggregate(dbName, collection string, query []bson.M) (response []interface{}, err error) {
-	sc := d.session.Copy()
-	defer sc.Close()
-	err = sc.DB(dbName).C(collection).Pipe(query).All(&response)
+	err = d.call(func(sc *mgo.Session) error {
+		return sc.DB(dbName).C(collection).Pipe(query).All(&response)
+	})
 	return
 }
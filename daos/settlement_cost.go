@@ -0,0 +1,51 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SettlementCostDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type SettlementCostDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewSettlementCostDao returns a new instance of SettlementCostDao
+func NewSettlementCostDao() *SettlementCostDao {
+	dbName := app.Config.DBName
+	collection := "settlement_costs"
+
+	// the costs are aggregated over a date range
+	index := mgo.Index{
+		Key: []string{"createdAt"},
+	}
+
+	err := db.session.DB(dbName).C(collection).EnsureIndex(index)
+	if err != nil {
+		panic(err)
+	}
+
+	return &SettlementCostDao{collection, dbName}
+}
+
+// Create inserts the cost of a settlement transaction
+func (dao *SettlementCostDao) Create(c *types.SettlementCost) error {
+	c.ID = bson.NewObjectId()
+	c.CreatedAt = time.Now()
+
+	return db.Create(dao.dbName, dao.collectionName, c)
+}
+
+// GetByDateRange fetches the settlement costs recorded between from (included) and to (excluded)
+func (dao *SettlementCostDao) GetByDateRange(from, to time.Time) (response []*types.SettlementCost, err error) {
+	q := bson.M{"createdAt": bson.M{"$gte": from, "$lt": to}}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"createdAt"}, 0, 0, &response)
+	return
+}
@@ -0,0 +1,50 @@
+package daos
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettlementCostDao(t *testing.T) {
+	dao := NewSettlementCostDao()
+	// the dates are stored with a millisecond precision
+	start := time.Now().Add(-time.Second)
+
+	c := &types.SettlementCost{
+		TradeHash: common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		PairName:  "ZRX/WETH",
+		Status:    "SUCCESS",
+		GasUsed:   120000,
+		GasPrice:  big.NewInt(1e9),
+		Cost:      big.NewInt(120000 * 1e9),
+		Fees:      big.NewInt(1e15),
+	}
+
+	err := dao.Create(c)
+	if err != nil {
+		t.Errorf("Could not create settlement cost: %v", err)
+	}
+
+	costs, err := dao.GetByDateRange(start, time.Now().Add(time.Second))
+	if err != nil {
+		t.Errorf("Could not get settlement costs: %v", err)
+	}
+
+	assert.Equal(t, 1, len(costs))
+	assert.Equal(t, c.TradeHash, costs[0].TradeHash)
+	assert.Equal(t, uint64(120000), costs[0].GasUsed)
+	assert.Equal(t, c.Cost, costs[0].Cost)
+	assert.Equal(t, c.Fees, costs[0].Fees)
+
+	costs, err = dao.GetByDateRange(start.Add(-time.Hour), start.Add(-time.Minute))
+	if err != nil {
+		t.Errorf("Could not get settlement costs: %v", err)
+	}
+
+	assert.Equal(t, 0, len(costs))
+}
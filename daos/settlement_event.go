@@ -0,0 +1,101 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SettlementEventDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type SettlementEventDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewSettlementEventDao returns a new instance of SettlementEventDao
+func NewSettlementEventDao() *SettlementEventDao {
+	dbName := app.Config.DBName
+	collection := "settlement_events"
+
+	// a trade is settled once
+	indexes := []mgo.Index{
+		{Key: []string{"tradeHash"}, Unique: true},
+		{Key: []string{"status", "nextAttemptAt"}},
+	}
+
+	for _, index := range indexes {
+		err := db.session.DB(dbName).C(collection).EnsureIndex(index)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return &SettlementEventDao{collection, dbName}
+}
+
+// Create inserts a pending settlement event. It returns false without error if an event
+// was already recorded for the trade.
+func (dao *SettlementEventDao) Create(e *types.SettlementEvent) (bool, error) {
+	e.ID = bson.NewObjectId()
+	e.Status = types.SettlementEventPending
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+	e.NextAttemptAt = e.CreatedAt
+
+	err := db.Create(dao.dbName, dao.collectionName, e)
+	if mgo.IsDup(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// GetDue fetches at most limit pending events whose next delivery attempt is due at t,
+// oldest first
+func (dao *SettlementEventDao) GetDue(t time.Time, limit int) (response []*types.SettlementEvent, err error) {
+	q := bson.M{"status": types.SettlementEventPending, "nextAttemptAt": bson.M{"$lte": t}}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"nextAttemptAt"}, 0, limit, &response)
+	return
+}
+
+// MarkDelivered records the delivery of a pending event
+func (dao *SettlementEventDao) MarkDelivered(e *types.SettlementEvent) error {
+	e.Attempts++
+	e.Status = types.SettlementEventDelivered
+	e.LastError = ""
+	e.UpdatedAt = time.Now()
+
+	q := bson.M{"_id": e.ID, "status": types.SettlementEventPending}
+	update := bson.M{"$set": bson.M{
+		"status":    e.Status,
+		"attempts":  e.Attempts,
+		"lastError": e.LastError,
+		"updatedAt": e.UpdatedAt,
+	}}
+
+	return db.Update(dao.dbName, dao.collectionName, q, update)
+}
+
+// MarkFailed records a failed delivery attempt of a pending event and the time of its
+// next attempt
+func (dao *SettlementEventDao) MarkFailed(e *types.SettlementEvent, reason string, next time.Time) error {
+	e.Attempts++
+	e.LastError = reason
+	e.NextAttemptAt = next
+	e.UpdatedAt = time.Now()
+
+	q := bson.M{"_id": e.ID, "status": types.SettlementEventPending}
+	update := bson.M{"$set": bson.M{
+		"attempts":      e.Attempts,
+		"lastError":     e.LastError,
+		"nextAttemptAt": e.NextAttemptAt,
+		"updatedAt":     e.UpdatedAt,
+	}}
+
+	return db.Update(dao.dbName, dao.collectionName, q, update)
+}
@@ -0,0 +1,90 @@
+package daos
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettlementEventDao(t *testing.T) {
+	dao := NewSettlementEventDao()
+
+	e := &types.SettlementEvent{
+		TradeHash:   common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		TxHash:      common.HexToHash("0x3f1c0e6a1b2d4c5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6"),
+		PairName:    "ZRX/WETH",
+		Amount:      big.NewInt(1e18),
+		PricePoint:  big.NewInt(2e6),
+		BlockNumber: 100,
+		GasUsed:     120000,
+		GasPrice:    big.NewInt(1e9),
+		GasCost:     big.NewInt(120000 * 1e9),
+		Fees:        big.NewInt(1e15),
+		SettledAt:   time.Now(),
+	}
+
+	created, err := dao.Create(e)
+	if err != nil {
+		t.Errorf("Could not create settlement event: %v", err)
+	}
+
+	assert.True(t, created)
+
+	// a trade is only posted once
+	duplicate := *e
+	created, err = dao.Create(&duplicate)
+	if err != nil {
+		t.Errorf("Could not create settlement event: %v", err)
+	}
+
+	assert.False(t, created)
+
+	due, err := dao.GetDue(time.Now().Add(time.Second), 10)
+	if err != nil {
+		t.Errorf("Could not get settlement events: %v", err)
+	}
+
+	assert.Equal(t, 1, len(due))
+	assert.Equal(t, e.TradeHash, due[0].TradeHash)
+	assert.Equal(t, uint64(100), due[0].BlockNumber)
+	assert.Equal(t, e.GasCost, due[0].GasCost)
+	assert.Equal(t, e.Fees, due[0].Fees)
+
+	// a failed event is due again at its next attempt
+	err = dao.MarkFailed(due[0], "Webhook returned status 500", time.Now().Add(time.Minute))
+	if err != nil {
+		t.Errorf("Could not record failed attempt: %v", err)
+	}
+
+	due, err = dao.GetDue(time.Now().Add(time.Second), 10)
+	if err != nil {
+		t.Errorf("Could not get settlement events: %v", err)
+	}
+
+	assert.Equal(t, 0, len(due))
+
+	due, err = dao.GetDue(time.Now().Add(2*time.Minute), 10)
+	if err != nil {
+		t.Errorf("Could not get settlement events: %v", err)
+	}
+
+	assert.Equal(t, 1, len(due))
+	assert.Equal(t, 1, due[0].Attempts)
+	assert.Equal(t, "Webhook returned status 500", due[0].LastError)
+
+	err = dao.MarkDelivered(due[0])
+	if err != nil {
+		t.Errorf("Could not record delivery: %v", err)
+	}
+
+	due, err = dao.GetDue(time.Now().Add(2*time.Minute), 10)
+	if err != nil {
+		t.Errorf("Could not get settlement events: %v", err)
+	}
+
+	assert.Equal(t, 0, len(due))
+}
@@ -36,13 +36,64 @@ func (dao *TokenDao) Create(token *types.Token) (err error) {
 	return
 }
 
+// CreateMany inserts several tokens in a single insert operation. All the tokens are
+// validated before anything is written so that an invalid token does not result in a
+// partial listing.
+func (dao *TokenDao) CreateMany(tokens []*types.Token) (err error) {
+	for _, token := range tokens {
+		if err := token.Validate(); err != nil {
+			return err
+		}
+	}
+
+	docs := []interface{}{}
+	now := time.Now()
+	for _, token := range tokens {
+		token.ID = bson.NewObjectId()
+		token.CreatedAt = now
+		token.UpdatedAt = now
+		docs = append(docs, token)
+	}
+
+	err = db.Create(dao.dbName, dao.collectionName, docs...)
+	return
+}
+
+// UpdateQuote sets whether the token corresponding to the given contract address
+// can be used as the quote token of a pair
+func (dao *TokenDao) UpdateQuote(addr common.Address, quote bool) (err error) {
+	q := notDeleted(bson.M{"contractAddress": addr.Hex()})
+	update := bson.M{"$set": bson.M{"quote": quote, "updatedAt": time.Now()}}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
+// Delete marks the token corresponding to the given contract address as deleted.
+// The document is kept so that historical pairs, orders and trades still resolve.
+func (dao *TokenDao) Delete(addr common.Address) (err error) {
+	q := notDeleted(bson.M{"contractAddress": addr.Hex()})
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"deletedAt": now, "updatedAt": now}}
+
+	err = db.Update(dao.dbName, dao.collectionName, q, update)
+	return
+}
+
 // GetAll function fetches all the tokens in the token collection of mongodb.
 func (dao *TokenDao) GetAll() (response []types.Token, err error) {
-	err = db.Get(dao.dbName, dao.collectionName, bson.M{}, 0, 0, &response)
+	err = db.Get(dao.dbName, dao.collectionName, notDeleted(bson.M{}), 0, 0, &response)
+	return
+}
+
+// GetQuoteTokens fetches all the tokens that can be used as quote tokens
+func (dao *TokenDao) GetQuoteTokens() (response []types.Token, err error) {
+	err = db.Get(dao.dbName, dao.collectionName, notDeleted(bson.M{"quote": true}), 0, 0, &response)
 	return
 }
 
 // GetByID function fetches details of a token based on its mongo id
+// Deleted tokens are returned as well so that references keep resolving.
 func (dao *TokenDao) GetByID(id bson.ObjectId) (response *types.Token, err error) {
 	err = db.GetByID(dao.dbName, dao.collectionName, id, &response)
 	return
@@ -50,7 +101,7 @@ func (dao *TokenDao) GetByID(id bson.ObjectId) (response *types.Token, err error
 
 // GetByAddress function fetches details of a token based on its contract address
 func (dao *TokenDao) GetByAddress(addr common.Address) (*types.Token, error) {
-	q := bson.M{"contractAddress": addr.Hex()}
+	q := notDeleted(bson.M{"contractAddress": addr.Hex()})
 	var resp []types.Token
 	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &resp)
 
@@ -68,3 +68,98 @@ func TestTokenDao(t *testing.T) {
 
 	Compare(t, token, byAddress)
 }
+
+func TestTokenDaoDelete(t *testing.T) {
+	dao := NewTokenDao()
+	address := common.HexToAddress("0x1e9a406696617ec5105f9382d33ba3360fcfabcc")
+
+	token := &types.Token{
+		Name:            "DEL",
+		Symbol:          "DEL",
+		ContractAddress: address,
+		Decimal:         18,
+		Active:          true,
+	}
+
+	err := dao.Create(token)
+	if err != nil {
+		t.Errorf("Could not create token object: %+v", err)
+	}
+
+	err = dao.Delete(address)
+	if err != nil {
+		t.Errorf("Could not delete token: %+v", err)
+	}
+
+	byAddress, err := dao.GetByAddress(address)
+	if err != nil {
+		t.Errorf("Could not get token by address: %+v", err)
+	}
+
+	assert.Nil(t, byAddress)
+
+	byId, err := dao.GetByID(token.ID)
+	if err != nil {
+		t.Errorf("Could not get token by ID: %+v", err)
+	}
+
+	Compare(t, token, byId)
+	assert.NotNil(t, byId.DeletedAt)
+}
+
+func TestTokenDaoCreateManyAndQuote(t *testing.T) {
+	dao := NewTokenDao()
+
+	quote := &types.Token{
+		Name:            "QTE",
+		Symbol:          "QTE",
+		ContractAddress: common.HexToAddress("0x2e9a406696617ec5105f9382d33ba3360fcfabcc"),
+		Decimal:         18,
+		Active:          true,
+	}
+
+	base := &types.Token{
+		Name:            "BSE",
+		Symbol:          "BSE",
+		ContractAddress: common.HexToAddress("0x3e9a406696617ec5105f9382d33ba3360fcfabcc"),
+		Decimal:         18,
+		Active:          true,
+	}
+
+	err := dao.CreateMany([]*types.Token{quote, base})
+	if err != nil {
+		t.Errorf("Could not create tokens: %+v", err)
+	}
+
+	byAddress, err := dao.GetByAddress(base.ContractAddress)
+	if err != nil {
+		t.Errorf("Could not get token by address: %+v", err)
+	}
+
+	Compare(t, base, byAddress)
+
+	err = dao.UpdateQuote(quote.ContractAddress, true)
+	if err != nil {
+		t.Errorf("Could not update quote token: %+v", err)
+	}
+
+	quotes, err := dao.GetQuoteTokens()
+	if err != nil {
+		t.Errorf("Could not get quote tokens: %+v", err)
+	}
+
+	found := false
+	for _, q := range quotes {
+		assert.True(t, q.Quote)
+		assert.NotEqual(t, base.ContractAddress, q.ContractAddress)
+		if q.ContractAddress == quote.ContractAddress {
+			found = true
+		}
+	}
+
+	assert.True(t, found)
+
+	invalid := &types.Token{Name: "INV"}
+	err = dao.CreateMany([]*types.Token{invalid})
+	assert.NotNil(t, err)
+}
@@ -1,11 +1,14 @@
 package daos
 
 import (
+	"math/big"
 	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -19,7 +22,25 @@ type TradeDao struct {
 
 // NewTradeDao returns a new instance of TradeDao.
 func NewTradeDao() *TradeDao {
-	return &TradeDao{"trades", app.Config.DBName}
+	dbName := app.Config.DBName
+	collection := "trades"
+
+	// the trade history of pairs and accounts is queried from the latest trade
+	indexes := []mgo.Index{
+		{Key: []string{"baseToken", "quoteToken", "-createdAt"}},
+		{Key: []string{"baseToken", "quoteToken", "-_id"}},
+		{Key: []string{"maker", "-_id"}},
+		{Key: []string{"taker", "-_id"}},
+	}
+
+	for _, index := range indexes {
+		err := db.session.DB(dbName).C(collection).EnsureIndex(index)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return &TradeDao{collection, dbName}
 }
 
 // Create function performs the DB insertion task for trade collection
@@ -49,6 +70,14 @@ func (dao *TradeDao) Update(trade *types.Trade) (err error) {
 	return
 }
 
+// UpdateIfStatus replaces a trade if its status in db is still status. It fails with
+// mgo.ErrNotFound if the status was changed concurrently (eg. by the operator).
+func (dao *TradeDao) UpdateIfStatus(trade *types.Trade, status string) error {
+	trade.UpdatedAt = time.Now()
+	q := bson.M{"_id": trade.ID, "status": status}
+	return db.Update(dao.dbName, dao.collectionName, q, trade)
+}
+
 // GetAll function fetches all the trades in mongodb
 func (dao *TradeDao) GetAll() (response []types.Trade, err error) {
 	err = db.Get(dao.dbName, dao.collectionName, bson.M{}, 0, 0, &response)
@@ -79,13 +108,40 @@ func (dao *TradeDao) GetByHash(hash common.Hash) (*types.Trade, error) {
 
 	response := []*types.Trade{}
 	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &response)
-	if err != nil {
+	if err != nil || len(response) == 0 {
+		return nil, err
+	}
+
+	return response[0], nil
+}
+
+// GetByTxHash fetches the trade settled by a transaction
+func (dao *TradeDao) GetByTxHash(hash common.Hash) (*types.Trade, error) {
+	q := bson.M{"txHash": hash.Hex()}
+
+	response := []*types.Trade{}
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &response)
+	if err != nil || len(response) == 0 {
 		return nil, err
 	}
 
 	return response[0], nil
 }
 
+// GetByStatus fetches all the trades with one of the given settlement statuses,
+// oldest first
+func (dao *TradeDao) GetByStatus(statuses ...string) (response []*types.Trade, err error) {
+	q := bson.M{"status": bson.M{"$in": statuses}}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"createdAt"}, 0, 0, &response)
+	return
+}
+
+// CountByStatus returns the number of trades with one of the given settlement statuses
+func (dao *TradeDao) CountByStatus(statuses ...string) (int, error) {
+	q := bson.M{"status": bson.M{"$in": statuses}}
+	return db.Count(dao.dbName, dao.collectionName, q)
+}
+
 func (dao *TradeDao) GetByOrderHash(hash common.Hash) ([]*types.Trade, error) {
 	q := bson.M{"orderHash": hash.Hex()}
 
@@ -98,6 +154,22 @@ func (dao *TradeDao) GetByOrderHash(hash common.Hash) ([]*types.Trade, error) {
 	return response, nil
 }
 
+// GetByOrder fetches the trades filling an order, oldest first. The order is either the
+// maker order of the trades (orderHash) or their taker order.
+func (dao *TradeDao) GetByOrder(hash common.Hash, id bson.ObjectId) ([]*types.Trade, error) {
+	q := bson.M{"$or": []bson.M{
+		{"orderHash": hash.Hex()}, {"makerOrderId": id}, {"takerOrderId": id},
+	}}
+
+	response := []*types.Trade{}
+	err := db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"createdAt", "_id"}, 0, 0, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
 // GetByPairAddress fetches all the trades corresponding to a particular pair token address.
 func (dao *TradeDao) GetByPairAddress(baseToken, quoteToken common.Address) (response []*types.Trade, err error) {
 	q := bson.M{"baseToken": baseToken.Hex(), "quoteToken": quoteToken.Hex()}
@@ -108,6 +180,18 @@ func (dao *TradeDao) GetByPairAddress(baseToken, quoteToken common.Address) (res
 	return
 }
 
+// GetLatestByPairAddress returns the latest trades of a pair, from the most recent one.
+// Only the trades created at or after from are returned when from is not zero.
+func (dao *TradeDao) GetLatestByPairAddress(baseToken, quoteToken common.Address, from time.Time, limit int) (response []*types.Trade, err error) {
+	q := bson.M{"baseToken": baseToken.Hex(), "quoteToken": quoteToken.Hex()}
+	if !from.IsZero() {
+		q["createdAt"] = bson.M{"$gte": from}
+	}
+
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"-createdAt"}, 0, limit, &response)
+	return
+}
+
 // GetByUserAddress fetches all the trades corresponding to a particular user address.
 func (dao *TradeDao) GetByUserAddress(addr common.Address) (response []*types.Trade, err error) {
 	q := bson.M{"$or": []bson.M{
@@ -119,3 +203,269 @@ func (dao *TradeDao) GetByUserAddress(addr common.Address) (response []*types.Tr
 	}
 	return
 }
+
+// Query fetches the trades matching the filters of the query, most recent first. The ids
+// of the trades are generated in their creation order, the trades are paginated on their
+// ids so that the pages are not shifted by the new trades.
+func (dao *TradeDao) Query(tq *types.TradeQuery) ([]*types.Trade, error) {
+	q := bson.M{}
+
+	if tq.BaseToken != (common.Address{}) {
+		q["baseToken"] = tq.BaseToken.Hex()
+	}
+
+	if tq.QuoteToken != (common.Address{}) {
+		q["quoteToken"] = tq.QuoteToken.Hex()
+	}
+
+	if tq.UserAddress != (common.Address{}) {
+		maker := bson.M{"maker": tq.UserAddress.Hex()}
+		taker := bson.M{"taker": tq.UserAddress.Hex()}
+
+		// the side of the trades is the side of the taker, the maker is on the other side
+		if tq.Side != "" {
+			taker["side"] = tq.Side
+			maker["side"] = "BUY"
+			if tq.Side == "BUY" {
+				maker["side"] = "SELL"
+			}
+		}
+
+		q["$or"] = []bson.M{maker, taker}
+	} else if tq.Side != "" {
+		q["side"] = tq.Side
+	}
+
+	createdAt := bson.M{}
+	if !tq.From.IsZero() {
+		createdAt["$gte"] = tq.From
+	}
+
+	if !tq.To.IsZero() {
+		createdAt["$lte"] = tq.To
+	}
+
+	if len(createdAt) > 0 {
+		q["createdAt"] = createdAt
+	}
+
+	id := bson.M{}
+	if tq.Before != "" {
+		id["$lt"] = tq.Before
+	}
+
+	if tq.After != "" {
+		id["$gt"] = tq.After
+	}
+
+	if len(id) > 0 {
+		q["_id"] = id
+	}
+
+	// the page following the after cursor is made of the oldest trades more recent than
+	// the cursor
+	sort := []string{"-_id"}
+	if tq.After != "" && tq.Before == "" {
+		sort = []string{"_id"}
+	}
+
+	response := []*types.Trade{}
+	err := db.GetWithSort(dao.dbName, dao.collectionName, q, sort, 0, tq.Limit, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if sort[0] == "_id" {
+		for i, j := 0, len(response)-1; i < j; i, j = i+1, j-1 {
+			response[i], response[j] = response[j], response[i]
+		}
+	}
+
+	return response, nil
+}
+
+// VolumeByPairSince returns the sum of the amounts of all the trades of a pair created after
+// the given time, busted trades excluded. The amounts are stored as strings and are summed
+// as decimals by the aggregation.
+func (dao *TradeDao) VolumeByPairSince(baseToken, quoteToken common.Address, since time.Time) (*big.Int, error) {
+	q := []bson.M{
+		{"$match": bson.M{
+			"baseToken":  baseToken.Hex(),
+			"quoteToken": quoteToken.Hex(),
+			"createdAt":  bson.M{"$gte": since},
+			"status":     bson.M{"$ne": "BUSTED"},
+		}},
+		{"$group": bson.M{
+			"_id":    nil,
+			"volume": bson.M{"$sum": toDecimal("$amount")},
+		}},
+	}
+
+	res, err := db.Aggregate(dao.dbName, dao.collectionName, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return big.NewInt(0), nil
+	}
+
+	var group struct {
+		Volume bson.Decimal128 `bson:"volume"`
+	}
+
+	bytes, _ := bson.Marshal(res[0])
+	if err := bson.Unmarshal(bytes, &group); err != nil {
+		return nil, err
+	}
+
+	return decimalToBigInt(group.Volume), nil
+}
+
+// GetStatsSince returns the statistics of the trades of each pair created after the given
+// time, busted trades excluded. The pricepoints and amounts are stored as strings, they
+// are converted to decimals by the aggregation to be compared and summed.
+func (dao *TradeDao) GetStatsSince(since time.Time) ([]*types.TradeStats, error) {
+	q := []bson.M{
+		{"$match": bson.M{
+			"createdAt": bson.M{"$gte": since},
+			"status":    bson.M{"$ne": "BUSTED"},
+		}},
+		{"$sort": bson.M{"createdAt": 1}},
+		{"$group": bson.M{
+			"_id":    bson.M{"baseToken": "$baseToken", "quoteToken": "$quoteToken"},
+			"open":   bson.M{"$first": "$pricepoint"},
+			"last":   bson.M{"$last": "$pricepoint"},
+			"high":   bson.M{"$max": toDecimal("$pricepoint")},
+			"low":    bson.M{"$min": toDecimal("$pricepoint")},
+			"volume": bson.M{"$sum": toDecimal("$amount")},
+			"count":  bson.M{"$sum": 1},
+		}},
+	}
+
+	res, err := db.Aggregate(dao.dbName, dao.collectionName, q)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := []*types.TradeStats{}
+	for _, r := range res {
+		var group struct {
+			ID struct {
+				BaseToken  string `bson:"baseToken"`
+				QuoteToken string `bson:"quoteToken"`
+			} `bson:"_id"`
+			Open   string           `bson:"open"`
+			Last   string           `bson:"last"`
+			High   *bson.Decimal128 `bson:"high"`
+			Low    *bson.Decimal128 `bson:"low"`
+			Volume bson.Decimal128  `bson:"volume"`
+			Count  int              `bson:"count"`
+		}
+
+		bytes, _ := bson.Marshal(r)
+		if err := bson.Unmarshal(bytes, &group); err != nil {
+			return nil, err
+		}
+
+		s := &types.TradeStats{
+			BaseToken:  common.HexToAddress(group.ID.BaseToken),
+			QuoteToken: common.HexToAddress(group.ID.QuoteToken),
+			Open:       math.ToBigInt(group.Open),
+			Last:       math.ToBigInt(group.Last),
+			High:       math.ToBigInt(group.Last),
+			Low:        math.ToBigInt(group.Last),
+			Volume:     decimalToBigInt(group.Volume),
+			Count:      group.Count,
+		}
+
+		if group.High != nil && group.Low != nil {
+			s.High, s.Low = decimalToBigInt(*group.High), decimalToBigInt(*group.Low)
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
+
+// GetLatestByPairs returns the latest trade of each of the given pairs, busted trades
+// excluded. The pairs without trades are left out.
+func (dao *TradeDao) GetLatestByPairs(pairs []*types.Pair) ([]*types.Trade, error) {
+	if len(pairs) == 0 {
+		return []*types.Trade{}, nil
+	}
+
+	or := []bson.M{}
+	for _, p := range pairs {
+		or = append(or, bson.M{"baseToken": p.BaseTokenAddress.Hex(), "quoteToken": p.QuoteTokenAddress.Hex()})
+	}
+
+	q := []bson.M{
+		{"$match": bson.M{
+			"$or":    or,
+			"status": bson.M{"$ne": "BUSTED"},
+		}},
+		{"$sort": bson.M{"createdAt": -1}},
+		{"$group": bson.M{
+			"_id":   bson.M{"baseToken": "$baseToken", "quoteToken": "$quoteToken"},
+			"trade": bson.M{"$first": "$$ROOT"},
+		}},
+		{"$replaceRoot": bson.M{"newRoot": "$trade"}},
+	}
+
+	res, err := db.Aggregate(dao.dbName, dao.collectionName, q)
+	if err != nil {
+		return nil, err
+	}
+
+	trades := []*types.Trade{}
+	for _, r := range res {
+		t := &types.Trade{}
+		bytes, _ := bson.Marshal(r)
+		if err := bson.Unmarshal(bytes, t); err != nil {
+			return nil, err
+		}
+
+		trades = append(trades, t)
+	}
+
+	return trades, nil
+}
+
+// TradeCountByAccountSince returns the number of trades in which the given address was
+// either maker or taker since the given time, busted trades excluded.
+func (dao *TradeDao) TradeCountByAccountSince(addr common.Address, since time.Time) (int, error) {
+	q := bson.M{
+		"$or": []bson.M{
+			{"maker": addr.Hex()}, {"taker": addr.Hex()},
+		},
+		"createdAt": bson.M{"$gte": since},
+		"status":    bson.M{"$ne": "BUSTED"},
+	}
+
+	return db.Count(dao.dbName, dao.collectionName, q)
+}
+
+// toDecimal converts a numeric string field to a decimal in an aggregation. Empty and
+// invalid values are converted to null, which are ignored by the accumulators. Decimals
+// hold 34 significant digits, enough for the token amounts and their sums.
+func toDecimal(field string) bson.M {
+	return bson.M{"$convert": bson.M{
+		"input":   field,
+		"to":      "decimal",
+		"onError": nil,
+		"onNull":  nil,
+	}}
+}
+
+// decimalToBigInt converts a decimal computed by an aggregation to a big.Int, the
+// fractional part is dropped
+func decimalToBigInt(d bson.Decimal128) *big.Int {
+	r, ok := new(big.Rat).SetString(d.String())
+	if !ok {
+		return big.NewInt(0)
+	}
+
+	return new(big.Int).Quo(r.Num(), r.Denom())
+}
@@ -4,10 +4,12 @@ import (
 	"io/ioutil"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -213,3 +215,429 @@ func TestUpdateTrade(t *testing.T) {
 
 	CompareTrade(t, queried, updated)
 }
+
+func TestTradeAggregations(t *testing.T) {
+	ZRXAddress := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	MKRAddress := common.HexToAddress("0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2")
+	maker := common.HexToAddress("0x3a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	taker := common.HexToAddress("0x4e55690d4b079460e6ac28aaa58c9ec7b73a7485")
+
+	newTrade := func(amount int64) *types.Trade {
+		return &types.Trade{
+			Maker:      maker,
+			Taker:      taker,
+			BaseToken:  ZRXAddress,
+			QuoteToken: MKRAddress,
+			PairName:   "ZRX/MKR",
+			TradeNonce: big.NewInt(0),
+			Signature:  &types.Signature{},
+			Price:      big.NewInt(100),
+			PricePoint: big.NewInt(100),
+			Side:       "BUY",
+			Amount:     big.NewInt(amount),
+		}
+	}
+
+	dao := NewTradeDao()
+
+	since := time.Now().Add(-time.Minute)
+	busted := newTrade(1000)
+	err := dao.Create(newTrade(100), newTrade(250), busted)
+	if err != nil {
+		t.Errorf("Could not create trade objects")
+	}
+
+	// the busted trades are neither in the volume nor in the count
+	busted.Status = "BUSTED"
+	err = dao.Update(busted)
+	if err != nil {
+		t.Errorf("Could not update trade object")
+	}
+
+	volume, err := dao.VolumeByPairSince(ZRXAddress, MKRAddress, since)
+	if err != nil {
+		t.Errorf("Could not compute pair volume: %v", err)
+	}
+
+	assert.Equal(t, big.NewInt(350), volume)
+
+	count, err := dao.TradeCountByAccountSince(maker, since)
+	if err != nil {
+		t.Errorf("Could not count trades: %v", err)
+	}
+
+	assert.Equal(t, 2, count)
+
+	count, err = dao.TradeCountByAccountSince(taker, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Errorf("Could not count trades: %v", err)
+	}
+
+	assert.Equal(t, 0, count)
+}
+
+func TestTradeDaoGetLatestByPairAddress(t *testing.T) {
+	ZRXAddress := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	BATAddress := common.HexToAddress("0x0d8775f648430679a709e98d2b0cb6250d2887ef")
+
+	dao := NewTradeDao()
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	trs := []*types.Trade{}
+	for i := 0; i < 3; i++ {
+		tr := &types.Trade{
+			BaseToken:  ZRXAddress,
+			QuoteToken: BATAddress,
+			PairName:   "ZRX/BAT",
+			TradeNonce: big.NewInt(int64(i)),
+			Signature:  &types.Signature{},
+			Price:      big.NewInt(100),
+			PricePoint: big.NewInt(100),
+			Side:       "BUY",
+			Amount:     big.NewInt(100),
+		}
+
+		err := dao.Create(tr)
+		if err != nil {
+			t.Errorf("Could not create trade object")
+		}
+
+		tr.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		err = dao.Update(tr)
+		if err != nil {
+			t.Errorf("Could not update trade object")
+		}
+
+		trs = append(trs, tr)
+	}
+
+	latest, err := dao.GetLatestByPairAddress(ZRXAddress, BATAddress, time.Time{}, 2)
+	if err != nil {
+		t.Errorf("Could not get latest trades: %v", err)
+	}
+
+	assert.Equal(t, 2, len(latest))
+	assert.Equal(t, trs[2].ID, latest[0].ID)
+	assert.Equal(t, trs[1].ID, latest[1].ID)
+
+	latest, err = dao.GetLatestByPairAddress(ZRXAddress, BATAddress, start.Add(30*time.Second), 10)
+	if err != nil {
+		t.Errorf("Could not get latest trades: %v", err)
+	}
+
+	assert.Equal(t, 2, len(latest))
+	assert.Equal(t, trs[2].ID, latest[0].ID)
+}
+
+func TestTradeDaoGetLatestByPairs(t *testing.T) {
+	KNCAddress := common.HexToAddress("0xdd974d5c2e2928dea5f71b9825b8b646686bd200")
+	DAIAddress := common.HexToAddress("0x89d24a6b4ccb1b6faa2625fe562bdd9a23260359")
+	LINKAddress := common.HexToAddress("0x514910771af9ca656af840dff83e8264ecf986ca")
+
+	dao := NewTradeDao()
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	// the latest trade is busted
+	trs := []*types.Trade{}
+	for i, pp := range []int64{100, 200, 300} {
+		tr := &types.Trade{
+			BaseToken:  KNCAddress,
+			QuoteToken: DAIAddress,
+			PairName:   "KNC/DAI",
+			TradeNonce: big.NewInt(int64(i)),
+			Signature:  &types.Signature{},
+			Price:      big.NewInt(pp),
+			PricePoint: big.NewInt(pp),
+			Side:       "BUY",
+			Amount:     big.NewInt(100),
+		}
+
+		err := dao.Create(tr)
+		if err != nil {
+			t.Errorf("Could not create trade object")
+		}
+
+		tr.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		if i == 2 {
+			tr.Status = "BUSTED"
+		}
+
+		err = dao.Update(tr)
+		if err != nil {
+			t.Errorf("Could not update trade object")
+		}
+
+		trs = append(trs, tr)
+	}
+
+	pairs := []*types.Pair{
+		{BaseTokenAddress: KNCAddress, QuoteTokenAddress: DAIAddress},
+		{BaseTokenAddress: LINKAddress, QuoteTokenAddress: DAIAddress},
+	}
+
+	latest, err := dao.GetLatestByPairs(pairs)
+	if err != nil {
+		t.Errorf("Could not get latest trades: %v", err)
+	}
+
+	assert.Equal(t, 1, len(latest))
+	assert.Equal(t, trs[1].ID, latest[0].ID)
+	assert.Equal(t, big.NewInt(200), latest[0].PricePoint)
+
+	latest, err = dao.GetLatestByPairs(nil)
+	if err != nil {
+		t.Errorf("Could not get latest trades: %v", err)
+	}
+
+	assert.Equal(t, 0, len(latest))
+}
+
+func TestTradeDaoQuery(t *testing.T) {
+	ZRXAddress := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	MKRAddress := common.HexToAddress("0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2")
+	maker := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	taker := common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485")
+
+	dao := NewTradeDao()
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	trs := []*types.Trade{}
+	for i, side := range []string{"BUY", "SELL", "BUY", "SELL"} {
+		tr := &types.Trade{
+			Maker:      maker,
+			Taker:      taker,
+			BaseToken:  ZRXAddress,
+			QuoteToken: MKRAddress,
+			PairName:   "ZRX/MKR",
+			TradeNonce: big.NewInt(int64(i)),
+			Signature:  &types.Signature{},
+			Price:      big.NewInt(100),
+			PricePoint: big.NewInt(100),
+			Side:       side,
+			Amount:     big.NewInt(100),
+		}
+
+		err := dao.Create(tr)
+		if err != nil {
+			t.Errorf("Could not create trade object")
+		}
+
+		tr.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		err = dao.Update(tr)
+		if err != nil {
+			t.Errorf("Could not update trade object")
+		}
+
+		trs = append(trs, tr)
+	}
+
+	res, err := dao.Query(&types.TradeQuery{BaseToken: ZRXAddress, QuoteToken: MKRAddress, Limit: 2})
+	if err != nil {
+		t.Errorf("Could not query trades: %v", err)
+	}
+
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, trs[3].ID, res[0].ID)
+	assert.Equal(t, trs[2].ID, res[1].ID)
+
+	res, err = dao.Query(&types.TradeQuery{BaseToken: ZRXAddress, QuoteToken: MKRAddress, Before: res[1].ID, Limit: 2})
+	if err != nil {
+		t.Errorf("Could not query trades: %v", err)
+	}
+
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, trs[1].ID, res[0].ID)
+	assert.Equal(t, trs[0].ID, res[1].ID)
+
+	res, err = dao.Query(&types.TradeQuery{BaseToken: ZRXAddress, QuoteToken: MKRAddress, After: trs[0].ID, Limit: 2})
+	if err != nil {
+		t.Errorf("Could not query trades: %v", err)
+	}
+
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, trs[2].ID, res[0].ID)
+	assert.Equal(t, trs[1].ID, res[1].ID)
+
+	res, err = dao.Query(&types.TradeQuery{
+		BaseToken:  ZRXAddress,
+		QuoteToken: MKRAddress,
+		Side:       "SELL",
+		From:       start.Add(30 * time.Second),
+		To:         start.Add(2 * time.Minute),
+	})
+	if err != nil {
+		t.Errorf("Could not query trades: %v", err)
+	}
+
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, trs[1].ID, res[0].ID)
+
+	// the maker sold in the trades where the taker bought
+	res, err = dao.Query(&types.TradeQuery{QuoteToken: MKRAddress, UserAddress: maker, Side: "SELL"})
+	if err != nil {
+		t.Errorf("Could not query trades: %v", err)
+	}
+
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, trs[2].ID, res[0].ID)
+	assert.Equal(t, trs[0].ID, res[1].ID)
+}
+
+func TestTradeDaoGetStatsSince(t *testing.T) {
+	OMGAddress := common.HexToAddress("0xd26114cd6ee289accf82350c8d8487fedb8a0c07")
+	REPAddress := common.HexToAddress("0x1985365e9f78359a9b6ad760e32412f4a445e862")
+
+	dao := NewTradeDao()
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	// the first trade is older than the period and the last one is busted
+	pricepoints := []int64{500, 100, 300, 200, 1000}
+	for i, pp := range pricepoints {
+		tr := &types.Trade{
+			BaseToken:  OMGAddress,
+			QuoteToken: REPAddress,
+			PairName:   "OMG/REP",
+			TradeNonce: big.NewInt(int64(i)),
+			Signature:  &types.Signature{},
+			Price:      big.NewInt(pp),
+			PricePoint: big.NewInt(pp),
+			Side:       "BUY",
+			Amount:     big.NewInt(10),
+		}
+
+		err := dao.Create(tr)
+		if err != nil {
+			t.Errorf("Could not create trade object")
+		}
+
+		tr.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		if i == len(pricepoints)-1 {
+			tr.Status = "BUSTED"
+		}
+
+		err = dao.Update(tr)
+		if err != nil {
+			t.Errorf("Could not update trade object")
+		}
+	}
+
+	stats, err := dao.GetStatsSince(start.Add(30 * time.Second))
+	if err != nil {
+		t.Errorf("Could not get trade stats: %v", err)
+	}
+
+	var s *types.TradeStats
+	for _, st := range stats {
+		if st.BaseToken == OMGAddress && st.QuoteToken == REPAddress {
+			s = st
+		}
+	}
+
+	if s == nil {
+		t.Fatal("No stats for the pair")
+	}
+
+	assert.Equal(t, 3, s.Count)
+	assert.Equal(t, big.NewInt(100), s.Open)
+	assert.Equal(t, big.NewInt(200), s.Last)
+	assert.Equal(t, big.NewInt(300), s.High)
+	assert.Equal(t, big.NewInt(100), s.Low)
+	assert.Equal(t, big.NewInt(30), s.Volume)
+}
+
+func TestTradeDaoGetByOrder(t *testing.T) {
+	dao := NewTradeDao()
+	orderHash := common.HexToHash("0x1a2b3c")
+	orderID := bson.NewObjectId()
+
+	trs := []*types.Trade{
+		// the order is the maker order
+		{OrderHash: orderHash, MakerOrderID: orderID, TakerOrderID: bson.NewObjectId()},
+		// the order is the taker order
+		{OrderHash: common.HexToHash("0x4d5e6f"), MakerOrderID: bson.NewObjectId(), TakerOrderID: orderID},
+		// unrelated trade
+		{OrderHash: common.HexToHash("0x4d5e6f"), MakerOrderID: bson.NewObjectId(), TakerOrderID: bson.NewObjectId()},
+	}
+
+	for i, tr := range trs {
+		tr.PairName = "ZRX/WETH"
+		tr.TradeNonce = big.NewInt(int64(i))
+		tr.Signature = &types.Signature{}
+		tr.Price = big.NewInt(100)
+		tr.PricePoint = big.NewInt(100)
+		tr.Side = "BUY"
+		tr.Amount = big.NewInt(100)
+
+		err := dao.Create(tr)
+		if err != nil {
+			t.Errorf("Could not create trade object")
+		}
+	}
+
+	fills, err := dao.GetByOrder(orderHash, orderID)
+	if err != nil {
+		t.Errorf("Could not get the trades of the order: %v", err)
+	}
+
+	if assert.Equal(t, 2, len(fills)) {
+		assert.Equal(t, trs[0].ID, fills[0].ID)
+		assert.Equal(t, trs[1].ID, fills[1].ID)
+	}
+}
+
+func TestTradeDaoUpdateIfStatus(t *testing.T) {
+	dao := NewTradeDao()
+
+	tr := &types.Trade{
+		ID:         bson.NewObjectId(),
+		Maker:      common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Taker:      common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		Hash:       common.HexToHash("0x31e8d0d5ab5ba6d2bd4f3e4c4d7e1e2d2f60e9b1a9bde5a3f0e43c9b7a0b8c11"),
+		OrderHash:  common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		PairName:   "ZRX/WETH",
+		TradeNonce: big.NewInt(100),
+		Signature:  &types.Signature{},
+		Price:      big.NewInt(100),
+		PricePoint: big.NewInt(100),
+		Amount:     big.NewInt(100),
+		Status:     "AWAITING_BROADCAST",
+	}
+
+	err := dao.Create(tr)
+	if err != nil {
+		t.Errorf("Could not create trade: %v", err)
+	}
+
+	tr.Status = "BUSTED"
+	tr.BustReason = "Erroneous price"
+	err = dao.UpdateIfStatus(tr, "AWAITING_BROADCAST")
+	if err != nil {
+		t.Errorf("Could not update trade: %v", err)
+	}
+
+	// the status was changed
+	err = dao.UpdateIfStatus(tr, "AWAITING_BROADCAST")
+	assert.Equal(t, mgo.ErrNotFound, err)
+
+	queried, err := dao.GetByHash(tr.Hash)
+	if err != nil {
+		t.Errorf("Could not get trade: %v", err)
+	}
+
+	assert.Equal(t, "BUSTED", queried.Status)
+	assert.Equal(t, "Erroneous price", queried.BustReason)
+}
+
+func TestDecimalToBigInt(t *testing.T) {
+	amount, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	d, err := bson.ParseDecimal128(amount.String())
+	if err != nil {
+		t.Fatalf("Could not parse the decimal: %v", err)
+	}
+
+	assert.Equal(t, amount, decimalToBigInt(d))
+
+	d, _ = bson.ParseDecimal128("1.5E+3")
+	assert.Equal(t, big.NewInt(1500), decimalToBigInt(d))
+}
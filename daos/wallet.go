@@ -1,8 +1,11 @@
 package daos
 
 import (
+	"errors"
+
 	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/encryption"
 	"github.com/ethereum/go-ethereum/common"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -16,17 +19,17 @@ type WalletDao struct {
 }
 
 type WalletDaoInterface interface {
-	GetAll() ([]types.Wallet, error)
-	GetByID(bson.ObjectId) (*types.Wallet, error)
-	GetByAddress(string) (*types.Wallet, error)
-	GetDefaultAdminWallet() (*types.Wallet, error)
+	GetAll() ([]types.OperatorWallet, error)
+	GetByID(bson.ObjectId) (*types.OperatorWallet, error)
+	GetByAddress(string) (*types.OperatorWallet, error)
+	GetDefaultAdminWallet() (*types.OperatorWallet, error)
 }
 
 func NewWalletDao() *WalletDao {
 	return &WalletDao{"wallet", app.Config.DBName}
 }
 
-func (dao *WalletDao) Create(wallet *types.Wallet) (err error) {
+func (dao *WalletDao) Create(wallet *types.OperatorWallet) (err error) {
 	err = wallet.Validate()
 	if err != nil {
 		return err
@@ -41,21 +44,21 @@ func (dao *WalletDao) Create(wallet *types.Wallet) (err error) {
 	return
 }
 
-func (dao *WalletDao) GetAll() (response []types.Wallet, err error) {
+func (dao *WalletDao) GetAll() (response []types.OperatorWallet, err error) {
 	err = db.Get(dao.dbName, dao.collectionName, bson.M{}, 0, 0, &response)
 	return
 }
 
 // GetByID function fetches details of a token based on its mongo id
-func (dao *WalletDao) GetByID(id bson.ObjectId) (response *types.Wallet, err error) {
+func (dao *WalletDao) GetByID(id bson.ObjectId) (response *types.OperatorWallet, err error) {
 	err = db.GetByID(dao.dbName, dao.collectionName, id, &response)
 	return
 }
 
 // GetByAddress function fetches details of a token based on its contract address
-func (dao *WalletDao) GetByAddress(a common.Address) (response *types.Wallet, err error) {
+func (dao *WalletDao) GetByAddress(a common.Address) (response *types.OperatorWallet, err error) {
 	q := bson.M{"address": a.Hex()}
-	var resp []types.Wallet
+	var resp []types.OperatorWallet
 	err = db.Get(dao.dbName, dao.collectionName, q, 0, 1, &resp)
 	if err != nil || len(resp) == 0 {
 		return
@@ -63,9 +66,9 @@ func (dao *WalletDao) GetByAddress(a common.Address) (response *types.Wallet, er
 	return &resp[0], nil
 }
 
-func (dao *WalletDao) GetDefaultAdminWallet() (response *types.Wallet, err error) {
+func (dao *WalletDao) GetDefaultAdminWallet() (response *types.OperatorWallet, err error) {
 	q := bson.M{"admin": true}
-	var resp []types.Wallet
+	var resp []types.OperatorWallet
 	err = db.Get(dao.dbName, dao.collectionName, q, 0, 1, &resp)
 	if err != nil || len(resp) == 0 {
 		return
@@ -73,3 +76,28 @@ func (dao *WalletDao) GetDefaultAdminWallet() (response *types.Wallet, err error
 
 	return &resp[0], nil
 }
+
+// EncryptPrivateKeys re-saves every wallet record. Records that still hold a plaintext
+// private key are encrypted, and records sealed with a previous key are re-encrypted with
+// the current key of the key ring. It should be run after a key ring is configured or rotated.
+func (dao *WalletDao) EncryptPrivateKeys() (count int, err error) {
+	if encryption.GetKeyRing() == nil {
+		return 0, errors.New("No key ring configured")
+	}
+
+	wallets, err := dao.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range wallets {
+		err = db.Update(dao.dbName, dao.collectionName, bson.M{"_id": wallets[i].ID}, &wallets[i])
+		if err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
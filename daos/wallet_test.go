@@ -21,7 +21,7 @@ func init() {
 
 func TestWalletDao(t *testing.T) {
 	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
-	w := types.NewWalletFromPrivateKey(key)
+	w, _ := types.NewOperatorWalletFromPrivateKey(key)
 	dao := NewWalletDao()
 
 	err := dao.Create(w)
@@ -59,7 +59,7 @@ func TestWalletDao(t *testing.T) {
 
 func TestDefaultAdminWallet(t *testing.T) {
 	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
-	w := types.NewWalletFromPrivateKey(key)
+	w, _ := types.NewOperatorWalletFromPrivateKey(key)
 	w.Admin = true
 	dao := NewWalletDao()
 
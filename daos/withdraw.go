@@ -0,0 +1,110 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// WithdrawDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type WithdrawDao struct {
+	collectionName string
+	dbName         string
+}
+
+// NewWithdrawDao returns a new instance of WithdrawDao
+func NewWithdrawDao() *WithdrawDao {
+	dbName := app.Config.DBName
+	collection := "withdraws"
+	indexes := []mgo.Index{
+		// a signed withdrawal is only executed once
+		{Key: []string{"hash"}, Unique: true},
+		{Key: []string{"status"}},
+		{Key: []string{"trader", "-createdAt"}},
+	}
+
+	for _, index := range indexes {
+		err := db.session.DB(dbName).C(collection).EnsureIndex(index)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return &WithdrawDao{collection, dbName}
+}
+
+// Create inserts a new withdrawal. It returns false without error if a withdrawal with
+// the same hash was already recorded.
+func (dao *WithdrawDao) Create(w *types.Withdraw) (bool, error) {
+	w.ID = bson.NewObjectId()
+	w.CreatedAt = time.Now()
+	w.UpdatedAt = w.CreatedAt
+
+	err := db.Create(dao.dbName, dao.collectionName, w)
+	if mgo.IsDup(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// GetByHash fetches a withdrawal by its hash. It returns nil if there is no such withdrawal.
+func (dao *WithdrawDao) GetByHash(hash common.Hash) (*types.Withdraw, error) {
+	res := []*types.Withdraw{}
+	err := db.Get(dao.dbName, dao.collectionName, bson.M{"hash": hash.Hex()}, 0, 1, &res)
+	if err != nil || len(res) == 0 {
+		return nil, err
+	}
+
+	return res[0], nil
+}
+
+// GetByTrader fetches the withdrawals of an account, most recent first
+func (dao *WithdrawDao) GetByTrader(trader common.Address) (response []*types.Withdraw, err error) {
+	q := bson.M{"trader": trader.Hex()}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"-createdAt"}, 0, 0, &response)
+	return
+}
+
+// GetByStatus fetches the withdrawals with the given status, oldest first
+func (dao *WithdrawDao) GetByStatus(status string) (response []*types.Withdraw, err error) {
+	q := bson.M{"status": status}
+	err = db.GetWithSort(dao.dbName, dao.collectionName, q, []string{"createdAt"}, 0, 0, &response)
+	return
+}
+
+// SetTxHash records the hash of the transaction of a pending withdrawal before the
+// transaction is sent. It fails with mgo.ErrNotFound if the withdrawal is not pending.
+func (dao *WithdrawDao) SetTxHash(w *types.Withdraw) error {
+	w.UpdatedAt = time.Now()
+
+	q := bson.M{"_id": w.ID, "status": types.WithdrawPending}
+	set := bson.M{"txHash": w.TxHash.Hex(), "updatedAt": w.UpdatedAt}
+	return db.Update(dao.dbName, dao.collectionName, q, bson.M{"$set": set})
+}
+
+// UpdateStatus moves a withdrawal from a status to another along with its transaction
+// hash and error. It fails with mgo.ErrNotFound if the withdrawal is not in the from
+// status anymore, so that the balance of a withdrawal is only settled once.
+func (dao *WithdrawDao) UpdateStatus(w *types.Withdraw, from, to string) error {
+	w.Status = to
+	w.UpdatedAt = time.Now()
+
+	set := bson.M{"status": to, "updatedAt": w.UpdatedAt}
+	if w.TxHash != (common.Hash{}) {
+		set["txHash"] = w.TxHash.Hex()
+	}
+
+	if w.Error != "" {
+		set["error"] = w.Error
+	}
+
+	q := bson.M{"_id": w.ID, "status": from}
+	return db.Update(dao.dbName, dao.collectionName, q, bson.M{"$set": set})
+}
@@ -0,0 +1,94 @@
+package daos
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
+)
+
+func TestWithdrawDao(t *testing.T) {
+	dao := NewWithdrawDao()
+
+	w := &types.Withdraw{
+		Hash:            common.HexToHash("0x2c3b7c4a2f5d1b0e7e0f9f6b5e0d63c0a7d8d8b2e6b4f0b1d9e62b0f6f3c9a11"),
+		ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		Token:           common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		Amount:          big.NewInt(1000),
+		Trader:          common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Receiver:        common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Nonce:           big.NewInt(1),
+		Signature:       &types.Signature{V: 28},
+		Status:          types.WithdrawPending,
+	}
+
+	created, err := dao.Create(w)
+	if err != nil {
+		t.Errorf("Could not create withdrawal: %v", err)
+	}
+
+	assert.True(t, created)
+
+	// the same signed withdrawal is only recorded once
+	duplicate := *w
+	created, err = dao.Create(&duplicate)
+	if err != nil {
+		t.Errorf("Could not create withdrawal: %v", err)
+	}
+
+	assert.False(t, created)
+
+	w.TxHash = common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a")
+	err = dao.SetTxHash(w)
+	if err != nil {
+		t.Errorf("Could not set withdrawal transaction: %v", err)
+	}
+
+	pending, err := dao.GetByStatus(types.WithdrawPending)
+	if err != nil {
+		t.Errorf("Could not get withdrawals: %v", err)
+	}
+
+	assert.Equal(t, 1, len(pending))
+	assert.Equal(t, w.TxHash, pending[0].TxHash)
+
+	err = dao.UpdateStatus(w, types.WithdrawPending, types.WithdrawSubmitted)
+	if err != nil {
+		t.Errorf("Could not update withdrawal: %v", err)
+	}
+
+	// a withdrawal is only submitted once
+	err = dao.UpdateStatus(w, types.WithdrawPending, types.WithdrawSubmitted)
+	assert.Equal(t, mgo.ErrNotFound, err)
+
+	// the transaction of a submitted withdrawal is not replaced
+	err = dao.SetTxHash(w)
+	assert.Equal(t, mgo.ErrNotFound, err)
+
+	submitted, err := dao.GetByStatus(types.WithdrawSubmitted)
+	if err != nil {
+		t.Errorf("Could not get withdrawals: %v", err)
+	}
+
+	assert.Equal(t, 1, len(submitted))
+	assert.Equal(t, w.TxHash, submitted[0].TxHash)
+	assert.Equal(t, w.Amount, submitted[0].Amount)
+
+	stored, err := dao.GetByHash(w.Hash)
+	if err != nil {
+		t.Errorf("Could not get withdrawal: %v", err)
+	}
+
+	assert.Equal(t, types.WithdrawSubmitted, stored.Status)
+	assert.Equal(t, byte(28), stored.Signature.V)
+
+	withdraws, err := dao.GetByTrader(w.Trader)
+	if err != nil {
+		t.Errorf("Could not get withdrawals: %v", err)
+	}
+
+	assert.Equal(t, 1, len(withdraws))
+}
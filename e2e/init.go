@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 
@@ -97,6 +98,16 @@ func NewRouter() *routing.Router {
 		return c.Write("OK " + app.Version)
 	})
 
+	// the request quotas are counted with a redis connection of their own
+	rateLimit := app.Config.RateLimit
+	rateLimiter := app.NewRateLimiter(
+		redis.InitConnection(app.Config.Redis),
+		time.Duration(rateLimit.Window)*time.Second,
+		rateLimit.IPLimit,
+		rateLimit.AddressLimit,
+		rateLimit.ForwardedFor,
+	)
+
 	router.Use(
 		app.Init(logger),
 		content.TypeNegotiator(content.JSON),
@@ -105,6 +116,7 @@ func NewRouter() *routing.Router {
 			AllowHeaders: "*",
 			AllowMethods: "*",
 		}),
+		rateLimiter.Handler(),
 	)
 
 	rg := router.Group("")
@@ -115,6 +127,9 @@ func NewRouter() *routing.Router {
 	tokenDao := daos.NewTokenDao()
 	pairDao := daos.NewPairDao()
 	tradeDao := daos.NewTradeDao()
+	journalDao := daos.NewJournalDao()
+	auditDao := daos.NewAuditDao()
+	approvalDao := daos.NewApprovalDao()
 
 	redisClient := redis.InitConnection(app.Config.Redis)
 	engineResource, err := engine.InitEngine(redisClient)
@@ -123,23 +138,27 @@ func NewRouter() *routing.Router {
 	}
 
 	// setup services
-	accountService := services.NewAccountService(accountDao, tokenDao)
-	ohlcvService := services.NewOHLCVService(tradeDao)
-	tokenService := services.NewTokenService(tokenDao)
-	tradeService := services.NewTradeService(tradeDao)
+	accountService := services.NewAccountService(accountDao, tokenDao, auditDao)
+	ohlcvService := services.NewOHLCVService(tradeDao, pairDao)
+	tradeService := services.NewTradeService(tradeDao, pairDao)
 	pairService := services.NewPairService(pairDao, tokenDao, engineResource, tradeService)
-	orderService := services.NewOrderService(orderDao, pairDao, accountDao, tradeDao, engineResource)
+	tokenService := services.NewTokenService(tokenDao, pairDao, pairService)
+	orderService := services.NewOrderService(orderDao, pairDao, accountDao, tradeDao, journalDao, engineResource)
 	orderBookService := services.NewOrderBookService(pairDao, tokenDao, engineResource)
-	cronService := crons.NewCronService(ohlcvService)
+	tickerService := services.NewTickerService(tradeDao, pairDao, engineResource)
+	cronService := crons.NewCronService(ohlcvService, pairService, orderService, tickerService)
+	approvalService := services.NewApprovalService(approvalDao, auditDao, time.Duration(app.Config.ApprovalTTL)*time.Hour)
+	portfolioService := services.NewPortfolioService(accountDao, tokenDao, pairDao, tradeDao, daos.NewDepositDao(), nil)
 
 	// setup endpoints
-	endpoints.ServeAccountResource(rg, accountService)
+	endpoints.ServeAccountResource(rg, accountService, approvalService, portfolioService)
 	endpoints.ServeTokenResource(rg, tokenService)
-	endpoints.ServePairResource(rg, pairService)
-	endpoints.ServeOrderBookResource(rg, orderBookService)
-	endpoints.ServeOHLCVResource(rg, ohlcvService)
-	endpoints.ServeTradeResource(rg, tradeService)
-	endpoints.ServeOrderResource(rg, orderService, engineResource)
+	endpoints.ServePairResource(rg, pairService, approvalService)
+	endpoints.ServeOrderBookResource(rg, orderBookService, pairService)
+	endpoints.ServeOHLCVResource(rg, ohlcvService, pairService)
+	endpoints.ServeTradeResource(rg, tradeService, pairService)
+	endpoints.ServeTickerResource(rg, tickerService)
+	endpoints.ServeOrderResource(rg, orderService, engineResource, approvalService)
 
 	cronService.InitCrons()
 	return router
@@ -55,8 +55,16 @@ func SetupTest() (*types.Wallet, *types.Wallet, *mocks.Client, *mocks.Client, *m
 	NewRouter()
 
 	//setup mock client
-	client1 := mocks.NewClient(wallet1, http.HandlerFunc(ws.ConnectionEndpoint))
-	client2 := mocks.NewClient(wallet2, http.HandlerFunc(ws.ConnectionEndpoint))
+	client1, err := mocks.NewClient(wallet1, http.HandlerFunc(ws.ConnectionEndpoint))
+	if err != nil {
+		panic(err)
+	}
+
+	client2, err := mocks.NewClient(wallet2, http.HandlerFunc(ws.ConnectionEndpoint))
+	if err != nil {
+		panic(err)
+	}
+
 	client1.Start()
 	client2.Start()
 
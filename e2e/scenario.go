@@ -0,0 +1,524 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/engine"
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/mocks"
+	"github.com/Proofsuite/amp-matching-engine/redis"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Scenario describes a trading flow between several actors as a list of steps
+// (eg. a maker places an order, a taker crosses it, the taker signs the trades,
+// the trades are settled and the balances of both actors are checked).
+// Orders are referred to by the label given when they are placed.
+type Scenario struct {
+	Name    string
+	Pair    *types.Pair
+	Actors  []*Actor
+	Steps   []Step
+	Settler Settler
+	Timeout time.Duration
+
+	orders   map[string]*types.Order
+	trades   map[string][]*types.Trade
+	balances map[common.Address]map[common.Address]*big.Int
+}
+
+// Actor is a trader taking part in a scenario. pending holds the client logs that
+// were read while waiting for another message.
+type Actor struct {
+	Name    string
+	Wallet  *types.Wallet
+	Client  *mocks.Client
+	Factory *mocks.OrderFactory
+
+	pending []*mocks.ClientLogMessage
+}
+
+// Step is a single action or assertion of a scenario
+type Step struct {
+	Description string
+	Run         func(s *Scenario) error
+}
+
+// Settler settles or fails matched trades. The simulated settlement is used by default.
+type Settler interface {
+	Settle(hash common.Hash) error
+	Fail(hash common.Hash, errID aerrors.ExchangeErrorID) error
+}
+
+// NewActor returns an actor trading with the given wallet, client and order factory
+func NewActor(name string, w *types.Wallet, c *mocks.Client, f *mocks.OrderFactory) *Actor {
+	return &Actor{
+		Name:    name,
+		Wallet:  w,
+		Client:  c,
+		Factory: f,
+	}
+}
+
+// NewScenario returns an empty scenario on the given pair
+func NewScenario(name string, p *types.Pair, actors ...*Actor) *Scenario {
+	return &Scenario{
+		Name:    name,
+		Pair:    p,
+		Actors:  actors,
+		Timeout: 5 * time.Second,
+	}
+}
+
+// Add appends steps to the scenario
+func (s *Scenario) Add(steps ...Step) *Scenario {
+	s.Steps = append(s.Steps, steps...)
+	return s
+}
+
+// Run executes the steps of the scenario in order and stops at the first failing step.
+// The balances of the actors are recorded before the first step so that steps can
+// assert balance changes.
+func (s *Scenario) Run(t *testing.T) {
+	s.orders = make(map[string]*types.Order)
+	s.trades = make(map[string][]*types.Trade)
+	s.balances = make(map[common.Address]map[common.Address]*big.Int)
+
+	if s.Settler == nil {
+		s.Settler = NewSimulatedSettlement()
+	}
+
+	for _, a := range s.Actors {
+		balances, err := s.tokenBalances(a)
+		if err != nil {
+			t.Fatalf("%s: could not retrieve balances of %s: %v", s.Name, a.Name, err)
+		}
+
+		s.balances[a.Wallet.Address] = balances
+	}
+
+	for i, step := range s.Steps {
+		err := step.Run(s)
+		if err != nil {
+			t.Fatalf("%s: step %d (%s) failed: %v", s.Name, i+1, step.Description, err)
+		}
+	}
+}
+
+// Order returns the order placed under the given label
+func (s *Scenario) Order(label string) *types.Order {
+	return s.orders[label]
+}
+
+// Trades returns the trades signed for the order placed under the given label
+func (s *Scenario) Trades(label string) []*types.Trade {
+	return s.trades[label]
+}
+
+// Place sends a new order and waits until it is added to the orderbook
+func Place(a *Actor, label string, buyToken common.Address, buyAmount int64, sellToken common.Address, sellAmount int64) Step {
+	return Step{
+		Description: fmt.Sprintf("%s places %s", a.Name, label),
+		Run: func(s *Scenario) error {
+			o, err := s.send(a, label, buyToken, buyAmount, sellToken, sellAmount)
+			if err != nil {
+				return err
+			}
+
+			_, err = a.expect("ORDER_ADDED", o.Hash, s.Timeout)
+			return err
+		},
+	}
+}
+
+// Cross sends a new order that matches resting orders and waits for the signature request
+func Cross(a *Actor, label string, buyToken common.Address, buyAmount int64, sellToken common.Address, sellAmount int64) Step {
+	return Step{
+		Description: fmt.Sprintf("%s crosses with %s", a.Name, label),
+		Run: func(s *Scenario) error {
+			o, err := s.send(a, label, buyToken, buyAmount, sellToken, sellAmount)
+			if err != nil {
+				return err
+			}
+
+			_, err = a.expect("REQUEST_SIGNATURE", o.Hash, s.Timeout)
+			return err
+		},
+	}
+}
+
+// Cancel cancels an order and waits for the cancellation
+func Cancel(a *Actor, label string) Step {
+	return Step{
+		Description: fmt.Sprintf("%s cancels %s", a.Name, label),
+		Run: func(s *Scenario) error {
+			o, err := s.order(label)
+			if err != nil {
+				return err
+			}
+
+			m, _, err := a.Factory.NewCancelOrderMessage(o)
+			if err != nil {
+				return err
+			}
+
+			a.Client.Requests <- m
+			_, err = a.expect("ORDER_CANCELLED", o.Hash, s.Timeout)
+			return err
+		},
+	}
+}
+
+// Sign signs the trades of the signature request received for an order and sends
+// them back to the server
+func Sign(a *Actor, label string) Step {
+	return Step{
+		Description: fmt.Sprintf("%s signs the trades of %s", a.Name, label),
+		Run: func(s *Scenario) error {
+			o, err := s.order(label)
+			if err != nil {
+				return err
+			}
+
+			res, err := a.signatureRequest(o.Hash)
+			if err != nil {
+				return err
+			}
+
+			for _, tr := range res.Trades {
+				err := a.Wallet.SignTrade(tr)
+				if err != nil {
+					return err
+				}
+			}
+
+			a.Client.Requests <- types.NewSubmitSignatureWebsocketMessage(o.Hash, res)
+			s.trades[label] = res.Trades
+			return nil
+		},
+	}
+}
+
+// Settle settles the signed trades of an order
+func Settle(label string) Step {
+	return Step{
+		Description: fmt.Sprintf("settle %s", label),
+		Run: func(s *Scenario) error {
+			return s.eachTrade(label, func(tr *types.Trade) error {
+				return s.Settler.Settle(tr.Hash)
+			})
+		},
+	}
+}
+
+// FailSettlement fails the settlement of the signed trades of an order with the given error
+func FailSettlement(label string, errID aerrors.ExchangeErrorID) Step {
+	return Step{
+		Description: fmt.Sprintf("fail settlement of %s", label),
+		Run: func(s *Scenario) error {
+			return s.eachTrade(label, func(tr *types.Trade) error {
+				return s.Settler.Fail(tr.Hash, errID)
+			})
+		},
+	}
+}
+
+// ExpectMessage waits until an actor receives a message of the given type for an order
+func ExpectMessage(a *Actor, msgType string, label string) Step {
+	return Step{
+		Description: fmt.Sprintf("%s receives %s for %s", a.Name, msgType, label),
+		Run: func(s *Scenario) error {
+			o, err := s.order(label)
+			if err != nil {
+				return err
+			}
+
+			_, err = a.expect(msgType, o.Hash, s.Timeout)
+			return err
+		},
+	}
+}
+
+// ExpectTradeStatus waits until the signed trades of an order have the given status
+func ExpectTradeStatus(label string, status string) Step {
+	tradeDao := daos.NewTradeDao()
+
+	return Step{
+		Description: fmt.Sprintf("trades of %s are %s", label, status),
+		Run: func(s *Scenario) error {
+			return s.eachTrade(label, func(tr *types.Trade) error {
+				return s.waitFor(func() (bool, error) {
+					stored, err := tradeDao.GetByHash(tr.Hash)
+					if err != nil || stored == nil {
+						return false, err
+					}
+
+					return stored.Status == status, nil
+				}, fmt.Sprintf("trade %s is not %s", tr.Hash.Hex(), status))
+			})
+		},
+	}
+}
+
+// ExpectBalance waits until the balance of an actor for a token is equal to the expected amount
+func ExpectBalance(a *Actor, token common.Address, expected *big.Int) Step {
+	return Step{
+		Description: fmt.Sprintf("%s balance of %s is %s", a.Name, token.Hex(), expected),
+		Run: func(s *Scenario) error {
+			return s.expectBalance(a, token, expected)
+		},
+	}
+}
+
+// ExpectBalanceChange waits until the balance of an actor for a token has changed
+// by delta since the start of the scenario
+func ExpectBalanceChange(a *Actor, token common.Address, delta *big.Int) Step {
+	return Step{
+		Description: fmt.Sprintf("%s balance of %s changed by %s", a.Name, token.Hex(), delta),
+		Run: func(s *Scenario) error {
+			initial := s.balances[a.Wallet.Address][token]
+			if initial == nil {
+				return fmt.Errorf("No initial balance for %s", token.Hex())
+			}
+
+			expected := new(big.Int).Add(initial, delta)
+			return s.expectBalance(a, token, expected)
+		},
+	}
+}
+
+// send creates, records and sends a new order
+func (s *Scenario) send(a *Actor, label string, buyToken common.Address, buyAmount int64, sellToken common.Address, sellAmount int64) (*types.Order, error) {
+	if s.orders[label] != nil {
+		return nil, fmt.Errorf("Order label %s is already used", label)
+	}
+
+	m, o, err := a.Factory.NewOrderMessage(buyToken, buyAmount, sellToken, sellAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	s.orders[label] = o
+	a.Client.Requests <- m
+	return o, nil
+}
+
+func (s *Scenario) order(label string) (*types.Order, error) {
+	o := s.orders[label]
+	if o == nil {
+		return nil, fmt.Errorf("Unknown order %s", label)
+	}
+
+	return o, nil
+}
+
+// eachTrade calls fn with each signed trade of an order
+func (s *Scenario) eachTrade(label string, fn func(tr *types.Trade) error) error {
+	trades := s.trades[label]
+	if len(trades) == 0 {
+		return fmt.Errorf("No signed trades for %s", label)
+	}
+
+	for _, tr := range trades {
+		err := fn(tr)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tokenBalances returns the balances of an actor for the tokens of the scenario pair
+func (s *Scenario) tokenBalances(a *Actor) (map[common.Address]*big.Int, error) {
+	accountDao := daos.NewAccountDao()
+	res := make(map[common.Address]*big.Int)
+
+	tokenBalances, err := accountDao.GetTokenBalances(a.Wallet.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, token := range []common.Address{s.Pair.BaseTokenAddress, s.Pair.QuoteTokenAddress} {
+		if tb := tokenBalances[token]; tb != nil {
+			res[token] = tb.Balance
+		}
+	}
+
+	return res, nil
+}
+
+func (s *Scenario) expectBalance(a *Actor, token common.Address, expected *big.Int) error {
+	accountDao := daos.NewAccountDao()
+
+	return s.waitFor(func() (bool, error) {
+		tokenBalances, err := accountDao.GetTokenBalances(a.Wallet.Address)
+		if err != nil {
+			return false, err
+		}
+
+		tb := tokenBalances[token]
+		return tb != nil && tb.Balance.Cmp(expected) == 0, nil
+	}, fmt.Sprintf("%s balance of %s is not %s", a.Name, token.Hex(), expected))
+}
+
+// waitFor polls cond until it returns true or until the scenario timeout expires
+func (s *Scenario) waitFor(cond func() (bool, error), msg string) error {
+	deadline := time.Now().Add(s.Timeout)
+
+	for {
+		ok, err := cond()
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out: %s", msg)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// expect waits for a client log of the given type concerning an order. Logs of
+// other types or orders are kept for later calls.
+func (a *Actor) expect(msgType string, hash common.Hash, timeout time.Duration) (*mocks.ClientLogMessage, error) {
+	for i, l := range a.pending {
+		if matches(l, msgType, hash) {
+			a.pending = append(a.pending[:i], a.pending[i+1:]...)
+			return l, nil
+		}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case l := <-a.Client.Logs:
+			if l.MessageType == "ERROR" {
+				return nil, fmt.Errorf("%s received an error", a.Name)
+			}
+
+			if matches(l, msgType, hash) {
+				return l, nil
+			}
+
+			a.pending = append(a.pending, l)
+		case <-timer.C:
+			return nil, fmt.Errorf("%s did not receive %s for %s", a.Name, msgType, hash.Hex())
+		}
+	}
+}
+
+// signatureRequest returns the matching engine response sent with the signature request of an order
+func (a *Actor) signatureRequest(hash common.Hash) (*engine.Response, error) {
+	for _, msg := range a.Client.ResponseLogs() {
+		if msg.Channel != types.OrderChannel || msg.Payload.Type != "REQUEST_SIGNATURE" || msg.Payload.Hash != hash.Hex() {
+			continue
+		}
+
+		bytes, err := json.Marshal(msg.Payload.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		res := &engine.Response{}
+		err = json.Unmarshal(bytes, res)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+
+	return nil, fmt.Errorf("No signature request for %s", hash.Hex())
+}
+
+func matches(l *mocks.ClientLogMessage, msgType string, hash common.Hash) bool {
+	return l.MessageType == msgType && l.Order != nil && l.Order.Hash == hash
+}
+
+// SimulatedSettlement settles trades without broadcasting them. It updates the trades
+// and notifies the maker and the taker the same way the operator does once the
+// settlement transaction is confirmed.
+type SimulatedSettlement struct {
+	tradeService *services.TradeService
+	orderService *services.OrderService
+}
+
+// NewSimulatedSettlement returns a settler using the matching engine singleton
+func NewSimulatedSettlement() *SimulatedSettlement {
+	orderDao := daos.NewOrderDao()
+	pairDao := daos.NewPairDao()
+	tradeDao := daos.NewTradeDao()
+	accountDao := daos.NewAccountDao()
+	journalDao := daos.NewJournalDao()
+
+	e, err := engine.InitEngine(redis.InitConnection(app.Config.Redis))
+	if err != nil {
+		panic(err)
+	}
+
+	return &SimulatedSettlement{
+		tradeService: services.NewTradeService(tradeDao, pairDao),
+		orderService: services.NewOrderService(orderDao, pairDao, accountDao, tradeDao, journalDao, e),
+	}
+}
+
+// Settle marks a trade as successfully settled
+func (s *SimulatedSettlement) Settle(hash common.Hash) error {
+	tr, err := s.getTrade(hash)
+	if err != nil {
+		return err
+	}
+
+	err = s.tradeService.UpdateTradeStatus(tr, "SUCCESS")
+	if err != nil {
+		return err
+	}
+
+	return s.orderService.HandleSettlementSuccess(tr)
+}
+
+// Fail records a settlement failure and reverts the trade
+func (s *SimulatedSettlement) Fail(hash common.Hash, errID aerrors.ExchangeErrorID) error {
+	tr, err := s.getTrade(hash)
+	if err != nil {
+		return err
+	}
+
+	err = s.tradeService.RecordFailure(tr, errID, aerrors.GetExchangeError(errID).Cause)
+	if err != nil {
+		return err
+	}
+
+	return s.orderService.HandleSettlementFailure(tr)
+}
+
+// getTrade retrieves a trade. Trades are saved after the signature request is sent
+// so the trade might not be saved yet.
+func (s *SimulatedSettlement) getTrade(hash common.Hash) (*types.Trade, error) {
+	for i := 0; i < 50; i++ {
+		tr, err := s.tradeService.GetByHash(hash)
+		if err == nil && tr != nil {
+			return tr, nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return nil, fmt.Errorf("Trade %s not found", hash.Hex())
+}
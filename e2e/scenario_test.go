@@ -0,0 +1,33 @@
+package e2e
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestScenarioMatchAndSettle(t *testing.T) {
+	wallet1, wallet2, client1, client2, factory1, factory2, pair, ZRX, WETH := SetupTest()
+	maker := NewActor("maker", wallet1, client1, factory1)
+	taker := NewActor("taker", wallet2, client2, factory2)
+
+	NewScenario("match and settle", pair, maker, taker).Add(
+		Place(maker, "bid", ZRX, 1e18, WETH, 1e18),
+		Cross(taker, "ask", WETH, 1e18, ZRX, 1e18),
+		Sign(taker, "ask"),
+		Settle("ask"),
+		ExpectMessage(maker, "TRADE_TX_SUCCESS", "bid"),
+		ExpectMessage(taker, "TRADE_TX_SUCCESS", "ask"),
+		ExpectTradeStatus("ask", "SUCCESS"),
+		ExpectBalanceChange(maker, ZRX, big.NewInt(1e18)),
+	).Run(t)
+}
+
+func TestScenarioPlaceAndCancel(t *testing.T) {
+	wallet1, _, client1, _, factory1, _, pair, ZRX, WETH := SetupTest()
+	maker := NewActor("maker", wallet1, client1, factory1)
+
+	NewScenario("place and cancel", pair, maker).Add(
+		Place(maker, "bid", ZRX, 1, WETH, 1),
+		Cancel(maker, "bid"),
+	).Run(t)
+}
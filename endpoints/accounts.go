@@ -1,30 +1,60 @@
 package endpoints
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
+	"strings"
 
 	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/ethereum"
 	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/ws"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-ozzo/ozzo-routing"
+	"github.com/gorilla/websocket"
 )
 
 type accountEndpoint struct {
-	accountService *services.AccountService
+	accountService   *services.AccountService
+	approvalService  *services.ApprovalService
+	portfolioService *services.PortfolioService
 }
 
-func ServeAccountResource(rg *routing.RouteGroup, accountService *services.AccountService) {
-	e := &accountEndpoint{accountService}
+func ServeAccountResource(
+	rg *routing.RouteGroup,
+	accountService *services.AccountService,
+	approvalService *services.ApprovalService,
+	portfolioService *services.PortfolioService,
+) {
+	e := &accountEndpoint{accountService, approvalService, portfolioService}
+	approvalService.Register(types.ActionUnblockAccount, e.unblockAccount)
+	approvalService.Register(types.ActionAnonymizeAccount, e.anonymizeAccount)
+
 	rg.Post("/account", e.create)
 	rg.Get("/account/<address>", e.get)
+	rg.Delete("/account/<address>", e.delete)
+	rg.Get("/account/<address>/portfolio", e.portfolio)
+	rg.Post("/admin/accounts/<address>/unblock", e.unblock)
+	rg.Post("/admin/accounts/<address>/anonymize", e.anonymize)
+	rg.Get("/admin/accounts", e.query)
+	rg.Post("/admin/accounts/<address>/tags", e.addTags)
+	rg.Delete("/admin/accounts/<address>/tags/<tag>", e.removeTag)
+	rg.Post("/admin/accounts/<address>/notes", e.addNote)
+	rg.Get("/admin/accounts/<address>/limits", e.limits)
+	rg.Put("/admin/accounts/<address>/limits", e.setLimitOverride)
+	rg.Delete("/admin/accounts/<address>/limits", e.removeLimitOverride)
+
+	ws.RegisterChannel(ws.BalanceChannel, e.balanceWebSocket)
+	ws.RegisterChannel(ws.AuthChannel, authWebSocket)
 }
 
 func (e *accountEndpoint) create(c *routing.Context) error {
 
 	account := &types.Account{}
 	if err := c.Read(&account); err != nil {
-		return errors.NewAPIError(400, "INVALID_DATA", map[string]interface{}{
+		return errors.InvalidRequestData.New(map[string]interface{}{
 			"details": err.Error(),
 		})
 	}
@@ -34,9 +64,7 @@ func (e *accountEndpoint) create(c *routing.Context) error {
 
 	if err := e.accountService.Create(account); err != nil {
 		fmt.Println(err)
-		return errors.NewAPIError(400, "CREATE_ACCOUNT_FAIL", map[string]interface{}{
-			"details": err.Error(),
-		})
+		return errors.CreateAccountFailed.Wrap(err)
 	}
 
 	return c.Write(account)
@@ -45,28 +73,74 @@ func (e *accountEndpoint) create(c *routing.Context) error {
 func (e *accountEndpoint) get(c *routing.Context) error {
 	a := c.Param("address")
 	if !common.IsHexAddress(a) {
-		return errors.NewAPIError(400, "INVALID_ADDRESS", nil)
+		return errors.InvalidAddress.New(nil)
 	}
 
 	address := common.HexToAddress(a)
 
 	account, err := e.accountService.GetByAddress(address)
 	if err != nil {
-		return errors.NewAPIError(400, "ACCOUNT_ERROR", nil)
+		return errors.AccountError.New(nil)
 	}
 
+	account.BalancesStale = ethereum.ChainStale()
 	return c.Write(account)
 }
 
+// portfolio values the token balances of an account in the quote currency of the quote
+// query parameter: a quote token symbol or address, or a fiat currency (eg. USD)
+func (e *accountEndpoint) portfolio(c *routing.Context) error {
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	quote := c.Query("quote")
+	if quote == "" {
+		return errors.InvalidQuote.New(nil)
+	}
+
+	portfolio, err := e.portfolioService.GetPortfolio(common.HexToAddress(a), quote)
+	if err == services.ErrInvalidQuote {
+		return errors.InvalidQuote.New(map[string]interface{}{"quote": quote})
+	}
+
+	if err != nil {
+		log.Print(err)
+		return errors.AccountError.New(nil)
+	}
+
+	return c.Write(portfolio)
+}
+
+func (e *accountEndpoint) delete(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	address := common.HexToAddress(a)
+	err := e.accountService.Delete(address)
+	if err != nil {
+		return errors.DeleteAccountFailed.Wrap(err)
+	}
+
+	return c.Write(map[string]string{"status": "DELETED"})
+}
+
 func (e *accountEndpoint) getBalance(c *routing.Context) error {
 	a := c.Param("address")
 	if !common.IsHexAddress(a) {
-		return errors.NewAPIError(400, "INVALID_ADDRESS", nil)
+		return errors.InvalidAddress.New(nil)
 	}
 
 	t := c.Param("token")
 	if !common.IsHexAddress(a) {
-		return errors.NewAPIError(400, "INVALID_TOKEN_ADDRESS", nil)
+		return errors.InvalidTokenAddress.New(nil)
 	}
 
 	addr := common.HexToAddress(a)
@@ -74,8 +148,249 @@ func (e *accountEndpoint) getBalance(c *routing.Context) error {
 
 	balance, err := e.accountService.GetTokenBalance(addr, tokenAddr)
 	if err != nil {
-		return errors.NewAPIError(400, "ERROR_GETBALANCE", nil)
+		return errors.GetBalanceFailed.New(nil)
 	}
 
 	return c.Write(balance)
 }
+
+// balanceWebSocket handles the subscriptions to the token balances of an account. The
+// subscription message must be signed by the account.
+func (e *accountEndpoint) balanceWebSocket(input interface{}, conn *websocket.Conn) {
+	mab, _ := json.Marshal(input)
+	var msg *types.UserSubscription
+	if err := json.Unmarshal(mab, &msg); err != nil || msg == nil {
+		ws.SendBalanceErrorMessage(conn, errors.NewWSError(errors.InvalidSubscription.New(nil)))
+		return
+	}
+
+	if (msg.Address == common.Address{}) {
+		ws.SendBalanceErrorMessage(conn, errors.NewWSError(errors.InvalidAddress.New(nil)))
+		return
+	}
+
+	if msg.Event == types.SUBSCRIBE {
+		e.accountService.SubscribeBalances(conn, msg)
+	}
+
+	if msg.Event == types.UNSUBSCRIBE {
+		e.accountService.UnsubscribeBalances(conn, msg.Address)
+	}
+}
+
+// unblock requests the unblocking of an account, which needs the approval of a second
+// administrator
+func (e *accountEndpoint) unblock(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	address := common.HexToAddress(a)
+	account, err := e.accountService.GetByAddress(address)
+	if err != nil {
+		return errors.AccountError.New(nil)
+	}
+
+	if !account.IsBlocked {
+		return errors.AccountNotBlocked.New(nil)
+	}
+
+	return requestApproval(c, e.approvalService, types.ActionUnblockAccount, address.Hex(), nil)
+}
+
+// unblockAccount unblocks an account once its unblocking is approved
+func (e *accountEndpoint) unblockAccount(a *types.Approval) error {
+	_, err := e.accountService.Unblock(common.HexToAddress(a.Target))
+	return err
+}
+
+// anonymize requests the anonymization of an account, which needs the approval of a
+// second administrator
+func (e *accountEndpoint) anonymize(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	return requestApproval(c, e.approvalService, types.ActionAnonymizeAccount, common.HexToAddress(a).Hex(), nil)
+}
+
+// anonymizeAccount anonymizes an account once its anonymization is approved
+func (e *accountEndpoint) anonymizeAccount(a *types.Approval) error {
+	return e.accountService.Anonymize(common.HexToAddress(a.Target), a.RequestedBy, a.ReviewedBy)
+}
+
+// query returns the accounts, most recent first. The accounts can be filtered with the
+// tags query param (comma separated, the accounts must have all the tags) and are
+// paginated with limit and offset.
+func (e *accountEndpoint) query(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	tags := []string{}
+	if t := c.Query("tags"); t != "" {
+		tags = strings.Split(t, ",")
+	}
+
+	offset, limit, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+
+	res, err := e.accountService.GetByTags(tags, offset, limit)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	return c.Write(res)
+}
+
+// addTags tags an account. The request body is {"tags": ["vip", "market-maker"]}
+func (e *accountEndpoint) addTags(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+
+	if err := c.Read(&req); err != nil {
+		return errors.InvalidRequestData.New(nil)
+	}
+
+	res, err := e.accountService.AddTags(common.HexToAddress(a), req.Tags)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
+func (e *accountEndpoint) removeTag(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	res, err := e.accountService.RemoveTag(common.HexToAddress(a), c.Param("tag"))
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
+// addNote writes a note on an account. The request body is {"text": "..."}
+func (e *accountEndpoint) addNote(c *routing.Context) error {
+	admin, err := adminIdentity(c)
+	if err != nil {
+		return err
+	}
+
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	var req struct {
+		Text string `json:"text"`
+	}
+
+	if err := c.Read(&req); err != nil {
+		return errors.InvalidRequestData.New(nil)
+	}
+
+	res, err := e.accountService.AddNote(common.HexToAddress(a), req.Text, admin)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
+// limits returns the fees and order limits currently applied to an account
+func (e *accountEndpoint) limits(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	res, err := e.accountService.GetLimits(common.HexToAddress(a))
+	if err != nil {
+		return errors.AccountError.New(nil)
+	}
+
+	return c.Write(res)
+}
+
+// setLimitOverride replaces the tier limits of an account. The request body is
+// {"makeFee": 0, "takeFee": 0, "ordersPerMinute": 600, "maxOpenOrders": 1000,
+// "reason": "market maker agreement", "expiresAt": "2018-10-01T00:00:00Z"}
+func (e *accountEndpoint) setLimitOverride(c *routing.Context) error {
+	admin, err := adminIdentity(c)
+	if err != nil {
+		return err
+	}
+
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	var override types.AccountLimitOverride
+	if err := c.Read(&override); err != nil {
+		return errors.InvalidRequestData.New(nil)
+	}
+
+	res, err := e.accountService.SetLimitOverride(common.HexToAddress(a), &override, admin)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
+// removeLimitOverride restores the tier limits of an account
+func (e *accountEndpoint) removeLimitOverride(c *routing.Context) error {
+	admin, err := adminIdentity(c)
+	if err != nil {
+		return err
+	}
+
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	res, err := e.accountService.RemoveLimitOverride(common.HexToAddress(a), admin)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
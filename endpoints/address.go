@@ -22,7 +22,7 @@ package endpoints
 // func (r *addressEndpoint) create(c *routing.Context) error {
 // 	var model types.UserAddress
 // 	if err := c.Read(&model); err != nil {
-// 		return errors.NewAPIError(400, "INVALID_DATA", map[string]interface{}{
+// 		return errors.InvalidRequestData.New(map[string]interface{}{
 // 			"details": err.Error(),
 // 		})
 // 	}
@@ -42,7 +42,7 @@ package endpoints
 // func (r *addressEndpoint) getNonce(c *routing.Context) error {
 // 	addr := c.Param("addr")
 // 	if !common.IsHexAddress(addr) {
-// 		return errors.NewAPIError(400, "INVALID_ADDRESS", nil)
+// 		return errors.InvalidAddress.New(nil)
 // 	}
 
 // 	nonce, err := r.addressService.GetNonce(addr)
@@ -0,0 +1,117 @@
+package endpoints
+
+import (
+	"log"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/go-ozzo/ozzo-routing"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type approvalEndpoint struct {
+	approvalService *services.ApprovalService
+}
+
+// ServeApprovalResource sets up the routing of the endpoints reviewing the destructive admin
+// actions and the corresponding handlers.
+func ServeApprovalResource(rg *routing.RouteGroup, approvalService *services.ApprovalService) {
+	e := &approvalEndpoint{approvalService}
+	rg.Get("/admin/approvals", e.pending)
+	rg.Get("/admin/approvals/<id>", e.get)
+	rg.Post("/admin/approvals/<id>/approve", e.approve)
+	rg.Post("/admin/approvals/<id>/reject", e.reject)
+}
+
+func (e *approvalEndpoint) pending(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	res, err := e.approvalService.GetPending()
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	return c.Write(res)
+}
+
+func (e *approvalEndpoint) get(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	id := c.Param("id")
+	if !bson.IsObjectIdHex(id) {
+		return errors.InvalidID.New(nil)
+	}
+
+	res, err := e.approvalService.GetByID(bson.ObjectIdHex(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
+func (e *approvalEndpoint) approve(c *routing.Context) error {
+	return e.review(c, e.approvalService.Approve)
+}
+
+func (e *approvalEndpoint) reject(c *routing.Context) error {
+	return e.review(c, e.approvalService.Reject)
+}
+
+func (e *approvalEndpoint) review(c *routing.Context, fn func(bson.ObjectId, string) (*types.Approval, error)) error {
+	admin, err := adminIdentity(c)
+	if err != nil {
+		return err
+	}
+
+	id := c.Param("id")
+	if !bson.IsObjectIdHex(id) {
+		return errors.InvalidID.New(nil)
+	}
+
+	res, err := fn(bson.ObjectIdHex(id), admin)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
+// requestApproval creates a pending request for a destructive admin action on behalf of
+// the calling administrator and answers with 202 Accepted
+func requestApproval(c *routing.Context, approvalService *services.ApprovalService, action, target string, params map[string]interface{}) error {
+	admin, err := adminIdentity(c)
+	if err != nil {
+		return err
+	}
+
+	res, err := approvalService.Request(action, target, params, admin)
+	if err != nil {
+		return err
+	}
+
+	c.Response.WriteHeader(202)
+	return c.Write(res)
+}
+
+// adminIdentity returns the name of the administrator whose api key is sent in the
+// X-Admin-Key header
+func adminIdentity(c *routing.Context) (string, error) {
+	key := c.Request.Header.Get("X-Admin-Key")
+	if key != "" {
+		for name, k := range app.Config.Admins {
+			if k == key {
+				return name, nil
+			}
+		}
+	}
+
+	return "", errors.AdminRequired.New(nil)
+}
@@ -0,0 +1,69 @@
+package endpoints
+
+import (
+	"encoding/json"
+
+	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/ws"
+	"github.com/gorilla/websocket"
+)
+
+// authWebSocket handles the authentication of a connection. A CHALLENGE message is
+// answered with a random challenge, which the client signs with the key of its account
+// and sends back in an AUTHENTICATE message. The connection is then authenticated as the
+// account: its orders and cancels are only accepted for orders of the account.
+func authWebSocket(input interface{}, conn *websocket.Conn) {
+	msg := &types.WebSocketPayload{}
+
+	bytes, _ := json.Marshal(input)
+	if err := json.Unmarshal(bytes, &msg); err != nil {
+		ws.SendAuthErrorMessage(conn, errors.NewWSError(errors.InvalidMessage.New(nil)))
+		return
+	}
+
+	switch msg.Type {
+	case "CHALLENGE":
+		challenge, err := ws.NewChallenge(conn)
+		if err != nil {
+			ws.SendAuthErrorMessage(conn, errors.NewWSError(err))
+			return
+		}
+
+		ws.SendAuthMessage(conn, "CHALLENGE", map[string]interface{}{"challenge": challenge})
+	case "AUTHENTICATE":
+		authenticate(msg, conn)
+	default:
+		ws.SendAuthErrorMessage(conn, errors.NewWSError(errors.InvalidMessage.New(nil)))
+	}
+}
+
+// authenticate verifies the signed challenge of an AUTHENTICATE message
+func authenticate(msg *types.WebSocketPayload, conn *websocket.Conn) {
+	auth := &types.WebSocketAuth{}
+
+	bytes, err := json.Marshal(msg.Data)
+	if err == nil {
+		err = json.Unmarshal(bytes, auth)
+	}
+
+	if err != nil {
+		ws.SendAuthErrorMessage(conn, errors.NewWSError(errors.InvalidMessage.New(nil)))
+		return
+	}
+
+	// the challenge is consumed even if the signature is invalid
+	challenge, ok := ws.TakeChallenge(conn)
+	if !ok {
+		ws.SendAuthErrorMessage(conn, &ws.AuthError{Code: errors.NoChallenge.Code, Message: "Request a challenge before authenticating"})
+		return
+	}
+
+	if err := auth.VerifySignature(challenge); err != nil {
+		ws.SendAuthErrorMessage(conn, &ws.AuthError{Code: errors.InvalidSignature.Code, Message: err.Error()})
+		return
+	}
+
+	ws.Authenticate(conn, auth.Address)
+	ws.SendAuthMessage(conn, "AUTHENTICATED", map[string]interface{}{"address": auth.Address})
+}
@@ -22,7 +22,7 @@ package endpoints
 // func (r *balanceEndpoint) get(c *routing.Context) error {
 // 	addr := c.Param("addr")
 // 	if !common.IsHexAddress(addr) {
-// 		return errors.NewAPIError(400, "INVALID_ADDRESS", nil)
+// 		return errors.InvalidAddress.New(nil)
 // 	}
 // 	nonZero := c.Query("nonZero", "false")
 // 	nonZeroBool, err := strconv.ParseBool(nonZero)
@@ -0,0 +1,150 @@
+package endpoints
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/ws"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-ozzo/ozzo-routing"
+)
+
+// Duration of the payload captures: defaultCaptureDuration when the duration query
+// parameter is not set, maxCaptureDuration at most so that a forgotten capture does
+// not fill the logs
+const (
+	defaultCaptureDuration = time.Hour
+	maxCaptureDuration     = 24 * time.Hour
+)
+
+// ServeCaptureResource sets up the routing of the endpoints toggling the logging of the
+// full websocket payloads of an address or a pair
+func ServeCaptureResource(rg *routing.RouteGroup) {
+	rg.Get("/admin/captures", getCaptures)
+	rg.Put("/admin/captures/addresses/<address>", captureAddress)
+	rg.Delete("/admin/captures/addresses/<address>", stopAddressCapture)
+	rg.Put("/admin/captures/pairs/<baseToken>/<quoteToken>", capturePair)
+	rg.Delete("/admin/captures/pairs/<baseToken>/<quoteToken>", stopPairCapture)
+}
+
+// getCaptures returns the addresses and the pairs whose payloads are logged
+func getCaptures(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	return c.Write(ws.GetCaptures())
+}
+
+// captureAddress logs the payloads of an address for the duration (in seconds) set by the
+// duration query parameter
+func captureAddress(c *routing.Context) error {
+	admin, err := adminIdentity(c)
+	if err != nil {
+		return err
+	}
+
+	addr := c.Param("address")
+	if !common.IsHexAddress(addr) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	d, err := captureDuration(c)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Payload capture of %s started by %s for %s", addr, admin, d)
+	return c.Write(ws.CaptureAddress(common.HexToAddress(addr), d))
+}
+
+func stopAddressCapture(c *routing.Context) error {
+	admin, err := adminIdentity(c)
+	if err != nil {
+		return err
+	}
+
+	addr := c.Param("address")
+	if !common.IsHexAddress(addr) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	if !ws.StopAddressCapture(common.HexToAddress(addr)) {
+		return errors.CaptureNotFound.New(nil)
+	}
+
+	log.Printf("Payload capture of %s stopped by %s", addr, admin)
+	return c.Write(map[string]bool{"stopped": true})
+}
+
+// capturePair logs the payloads of a pair for the duration (in seconds) set by the
+// duration query parameter
+func capturePair(c *routing.Context) error {
+	admin, err := adminIdentity(c)
+	if err != nil {
+		return err
+	}
+
+	baseToken, quoteToken, err := capturePairParams(c)
+	if err != nil {
+		return err
+	}
+
+	d, err := captureDuration(c)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Payload capture of %s/%s started by %s for %s", baseToken.Hex(), quoteToken.Hex(), admin, d)
+	return c.Write(ws.CapturePair(baseToken, quoteToken, d))
+}
+
+func stopPairCapture(c *routing.Context) error {
+	admin, err := adminIdentity(c)
+	if err != nil {
+		return err
+	}
+
+	baseToken, quoteToken, err := capturePairParams(c)
+	if err != nil {
+		return err
+	}
+
+	if !ws.StopPairCapture(baseToken, quoteToken) {
+		return errors.CaptureNotFound.New(nil)
+	}
+
+	log.Printf("Payload capture of %s/%s stopped by %s", baseToken.Hex(), quoteToken.Hex(), admin)
+	return c.Write(map[string]bool{"stopped": true})
+}
+
+func capturePairParams(c *routing.Context) (baseToken, quoteToken common.Address, err error) {
+	base := c.Param("baseToken")
+	if !common.IsHexAddress(base) {
+		return baseToken, quoteToken, errors.InvalidHexAddress.New(nil)
+	}
+
+	quote := c.Param("quoteToken")
+	if !common.IsHexAddress(quote) {
+		return baseToken, quoteToken, errors.InvalidHexAddress.New(nil)
+	}
+
+	return common.HexToAddress(base), common.HexToAddress(quote), nil
+}
+
+// captureDuration returns the duration of a capture request
+func captureDuration(c *routing.Context) (time.Duration, error) {
+	s := c.Query("duration")
+	if s == "" {
+		return defaultCaptureDuration, nil
+	}
+
+	seconds, err := strconv.Atoi(s)
+	if err != nil || seconds <= 0 || time.Duration(seconds)*time.Second > maxCaptureDuration {
+		return 0, errors.InvalidDuration.New(nil)
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
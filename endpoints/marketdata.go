@@ -0,0 +1,48 @@
+package endpoints
+
+import (
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/ws"
+	"github.com/go-ozzo/ozzo-routing"
+)
+
+// ServeMarketDataResource sets up the routing of the market data endpoints and channels
+// only: tokens, pairs, orderbook, public trades, OHLCV and tickers. It is used by the read-only
+// API profile, the order entry, account and admin endpoints are served by the primary.
+func ServeMarketDataResource(
+	rg *routing.RouteGroup,
+	tokenService *services.TokenService,
+	pairService *services.PairService,
+	orderBookService *services.OrderBookService,
+	ohlcvService *services.OHLCVService,
+	tradeService *services.TradeService,
+	tickerService *services.TickerService,
+) {
+	tokens := &tokenEndpoint{tokenService}
+	rg.Get("/tokens/<address>", tokens.get)
+	rg.Get("/tokens", tokens.query)
+
+	pairs := &pairEndpoint{pairService}
+	rg.Get("/pairs/<baseToken>/<quoteToken>", pairs.get)
+	rg.Get("/pairs", pairs.query)
+	rg.Get("/pairs/<baseToken>/<quoteToken>/fees", pairs.fees)
+
+	orderBook := &OrderBookEndpoint{orderBookService, pairService}
+	rg.Get("/orderbook/<baseToken>/<quoteToken>", orderBook.orderBookEndpoint)
+	rg.Get("/orderbook/<pair>/depth-chart", orderBook.depthChart)
+	ws.RegisterChannel(ws.OrderBookChannel, orderBook.orderBookWebSocket)
+	ws.RegisterChannel(ws.RawOrderBookChannel, orderBook.rawOrderBookWebSocket)
+
+	trades := &tradeEndpoint{tradeService, pairService}
+	rg.Get("/trades/pair/<bt>/<qt>", trades.history)
+	rg.Get("/trades/history/<bt>/<qt>", trades.history)
+	rg.Get("/trades/history/<pair>", trades.historyBySymbol)
+	ws.RegisterChannel(ws.TradeChannel, trades.tradeWebSocket)
+
+	ohlcv := &OHLCVEndpoint{ohlcvService, pairService}
+	rg.Post("/ohlcv", ohlcv.ohlcv)
+	rg.Get("/ohlcv/<pair>", ohlcv.ohlcvBySymbol)
+	ws.RegisterChannel(ws.OHLCVChannel, ohlcv.ohlcvWebSocket)
+
+	ServeTickerResource(rg, tickerService)
+}
@@ -0,0 +1,216 @@
+package endpoints
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/Proofsuite/amp-matching-engine/ethereum"
+	"github.com/Proofsuite/amp-matching-engine/operator"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/utils/breaker"
+	"github.com/Proofsuite/amp-matching-engine/ws"
+	"github.com/go-ozzo/ozzo-routing"
+)
+
+// defaultTopPairs is the number of pairs returned by the stats endpoint by default
+const defaultTopPairs = 10
+
+// balanceLevels are the values of the operator balance level metric
+var balanceLevels = map[string]int{types.BalanceOK: 0, types.BalanceWarning: 1, types.BalanceCritical: 2}
+
+// breakerStates are the values of the circuit breaker state metric
+var breakerStates = map[string]int{breaker.Closed: 0, breaker.HalfOpen: 1, breaker.Open: 2}
+
+type metricsEndpoint struct {
+	pairService *services.PairService
+}
+
+// ServeMetricsResource sets up the routing of the websocket metrics endpoints and the corresponding handlers.
+func ServeMetricsResource(rg *routing.RouteGroup, pairService *services.PairService) {
+	e := &metricsEndpoint{pairService}
+	rg.Get("/metrics", e.metrics)
+	rg.Get("/admin/stats", e.stats)
+}
+
+// metrics writes the websocket metrics in the prometheus text format
+func (e *metricsEndpoint) metrics(c *routing.Context) error {
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintln(buf, "# HELP ws_channel_messages_total Messages sent on a websocket channel.")
+	fmt.Fprintln(buf, "# TYPE ws_channel_messages_total counter")
+	channels := ws.GetChannelStats()
+	for _, s := range channels {
+		fmt.Fprintf(buf, "ws_channel_messages_total{channel=%q} %d\n", s.Channel, s.Messages)
+	}
+
+	fmt.Fprintln(buf, "# HELP ws_channel_subscribers Subscribers of a websocket channel.")
+	fmt.Fprintln(buf, "# TYPE ws_channel_subscribers gauge")
+	for _, s := range channels {
+		fmt.Fprintf(buf, "ws_channel_subscribers{channel=%q} %d\n", s.Channel, s.Subscribers)
+	}
+
+	streams := ws.GetStreamStats()
+	fmt.Fprintln(buf, "# HELP ws_stream_subscribers Subscribers of a websocket channel id.")
+	fmt.Fprintln(buf, "# TYPE ws_stream_subscribers gauge")
+	for _, s := range streams {
+		fmt.Fprintf(buf, "ws_stream_subscribers{channel=%q,id=%q} %d\n", s.Channel, s.ID, s.Subscribers)
+	}
+
+	fmt.Fprintln(buf, "# HELP ws_stream_broadcasts_total Messages broadcasted to the subscribers of a websocket channel id.")
+	fmt.Fprintln(buf, "# TYPE ws_stream_broadcasts_total counter")
+	for _, s := range streams {
+		fmt.Fprintf(buf, "ws_stream_broadcasts_total{channel=%q,id=%q} %d\n", s.Channel, s.ID, s.Messages)
+	}
+
+	fmt.Fprintln(buf, "# HELP circuit_breaker_state State of the circuit breaker of a dependency (0: closed, 1: half-open, 2: open).")
+	fmt.Fprintln(buf, "# TYPE circuit_breaker_state gauge")
+	for _, b := range breaker.All() {
+		fmt.Fprintf(buf, "circuit_breaker_state{dependency=%q} %d\n", b.Name(), breakerStates[b.State()])
+	}
+
+	if evictions, err := e.pairService.GetOrderBookEvictions(); err == nil && len(evictions) > 0 {
+		fmt.Fprintln(buf, "# HELP orderbook_evictions_total Orders evicted from the orderbook of a pair holding its maximum number of orders.")
+		fmt.Fprintln(buf, "# TYPE orderbook_evictions_total counter")
+		for pair, n := range evictions {
+			fmt.Fprintf(buf, "orderbook_evictions_total{pair=%q} %d\n", pair, n)
+		}
+	}
+
+	cache := services.GetMetadataCacheStats()
+	fmt.Fprintln(buf, "# HELP metadata_cache_hits_total Pair and token lookups served by the in-memory cache.")
+	fmt.Fprintln(buf, "# TYPE metadata_cache_hits_total counter")
+	fmt.Fprintf(buf, "metadata_cache_hits_total %d\n", cache.Hits)
+
+	fmt.Fprintln(buf, "# HELP metadata_cache_misses_total Pair and token lookups read from the database.")
+	fmt.Fprintln(buf, "# TYPE metadata_cache_misses_total counter")
+	fmt.Fprintf(buf, "metadata_cache_misses_total %d\n", cache.Misses)
+
+	if s := ethereum.GetChainStatus(); s != nil {
+		fmt.Fprintln(buf, "# HELP ethereum_block_number Number of the latest block returned by the ethereum node.")
+		fmt.Fprintln(buf, "# TYPE ethereum_block_number gauge")
+		fmt.Fprintf(buf, "ethereum_block_number %d\n", s.BlockNumber)
+
+		fmt.Fprintln(buf, "# HELP ethereum_block_lag_seconds Age of the latest block returned by the ethereum node.")
+		fmt.Fprintln(buf, "# TYPE ethereum_block_lag_seconds gauge")
+		fmt.Fprintf(buf, "ethereum_block_lag_seconds %d\n", int64(s.Lag.Seconds()))
+
+		fmt.Fprintln(buf, "# HELP ethereum_chain_stale Whether the ethereum node is stale and settlement paused (0: in sync, 1: stale).")
+		fmt.Fprintln(buf, "# TYPE ethereum_chain_stale gauge")
+		stale := 0
+		if s.Stale {
+			stale = 1
+		}
+
+		fmt.Fprintf(buf, "ethereum_chain_stale %d\n", stale)
+	}
+
+	// the balance of the operator wallet is only known by the process running the operator
+	if b := operator.GetBalanceStatus(); b != nil && b.Balance != nil {
+		fmt.Fprintln(buf, "# HELP operator_balance_wei Ether balance of the operator wallet.")
+		fmt.Fprintln(buf, "# TYPE operator_balance_wei gauge")
+		fmt.Fprintf(buf, "operator_balance_wei{address=%q} %s\n", b.Address.Hex(), b.Balance.String())
+
+		fmt.Fprintln(buf, "# HELP operator_balance_level Balance level of the operator wallet (0: ok, 1: warning, 2: critical, settlement paused).")
+		fmt.Fprintln(buf, "# TYPE operator_balance_level gauge")
+		fmt.Fprintf(buf, "operator_balance_level{address=%q} %d\n", b.Address.Hex(), balanceLevels[b.Level])
+	}
+
+	// the nonces are only managed by the process running the operator
+	if nonces := services.GetNonceStatuses(); len(nonces) > 0 {
+		fmt.Fprintln(buf, "# HELP operator_nonce_next Next nonce assigned locally to an operator account.")
+		fmt.Fprintln(buf, "# TYPE operator_nonce_next gauge")
+		for _, n := range nonces {
+			fmt.Fprintf(buf, "operator_nonce_next{address=%q} %d\n", n.Address.Hex(), n.Next)
+		}
+
+		fmt.Fprintln(buf, "# HELP operator_nonce_pending Pending transaction count of an operator account returned by the node.")
+		fmt.Fprintln(buf, "# TYPE operator_nonce_pending gauge")
+		for _, n := range nonces {
+			fmt.Fprintf(buf, "operator_nonce_pending{address=%q} %d\n", n.Address.Hex(), n.Pending)
+		}
+
+		fmt.Fprintln(buf, "# HELP operator_nonce_gaps Nonces of an operator account unknown to the node at the last check.")
+		fmt.Fprintln(buf, "# TYPE operator_nonce_gaps gauge")
+		for _, n := range nonces {
+			fmt.Fprintf(buf, "operator_nonce_gaps{address=%q} %d\n", n.Address.Hex(), len(n.Gaps))
+		}
+
+		fmt.Fprintln(buf, "# HELP operator_nonce_repairs_total Nonce gaps and duplicates repaired for an operator account.")
+		fmt.Fprintln(buf, "# TYPE operator_nonce_repairs_total counter")
+		for _, n := range nonces {
+			fmt.Fprintf(buf, "operator_nonce_repairs_total{address=%q} %d\n", n.Address.Hex(), n.Repairs)
+		}
+
+		fmt.Fprintln(buf, "# HELP operator_nonce_bumps_total Stuck transactions of an operator account replaced with a higher gas price.")
+		fmt.Fprintln(buf, "# TYPE operator_nonce_bumps_total counter")
+		for _, n := range nonces {
+			fmt.Fprintf(buf, "operator_nonce_bumps_total{address=%q} %d\n", n.Address.Hex(), n.Bumps)
+		}
+	}
+
+	fmt.Fprintln(buf, "# HELP order_stage_latency_seconds Latency between two stages of the order pipeline.")
+	fmt.Fprintln(buf, "# TYPE order_stage_latency_seconds histogram")
+	for _, h := range services.GetPipelineLatencies() {
+		for i, bound := range types.LatencyBuckets {
+			fmt.Fprintf(buf, "order_stage_latency_seconds_bucket{from=%q,to=%q,le=\"%g\"} %d\n", h.From, h.To, bound, h.Buckets[i])
+		}
+		fmt.Fprintf(buf, "order_stage_latency_seconds_bucket{from=%q,to=%q,le=\"+Inf\"} %d\n", h.From, h.To, h.Count)
+		fmt.Fprintf(buf, "order_stage_latency_seconds_sum{from=%q,to=%q} %g\n", h.From, h.To, h.Sum)
+		fmt.Fprintf(buf, "order_stage_latency_seconds_count{from=%q,to=%q} %d\n", h.From, h.To, h.Count)
+	}
+
+	c.Response.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, err := c.Response.Write(buf.Bytes())
+	return err
+}
+
+// stats returns the websocket metrics per channel and the pairs with the highest
+// message rate. The number of pairs is set by the limit query parameter.
+func (e *metricsEndpoint) stats(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	limit := defaultTopPairs
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	top := ws.GetTopPairs(limit)
+
+	pairs, err := e.pairService.GetAll()
+	if err != nil {
+		return err
+	}
+
+	names := map[string]string{}
+	for _, p := range pairs {
+		names[utils.GetPairKey(p.BaseTokenAddress, p.QuoteTokenAddress)] = p.Name
+	}
+
+	for _, p := range top {
+		p.Name = names[p.Pair]
+	}
+
+	stats := map[string]interface{}{
+		"channels": ws.GetChannelStats(),
+		"topPairs": top,
+	}
+
+	if nonces := services.GetNonceStatuses(); nonces != nil {
+		stats["nonces"] = nonces
+	}
+
+	if latencies := services.GetPipelineLatencies(); len(latencies) > 0 {
+		stats["pipelineLatencies"] = latencies
+	}
+
+	if prices := ethereum.GetGasPrices(); prices != nil {
+		stats["gasPrices"] = prices
+	}
+
+	return c.Write(stats)
+}
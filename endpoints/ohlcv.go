@@ -3,8 +3,10 @@ package endpoints
 import (
 	"encoding/json"
 	"log"
+	"strconv"
 	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/ws"
@@ -15,11 +17,13 @@ import (
 
 type OHLCVEndpoint struct {
 	ohlcvService *services.OHLCVService
+	pairService  *services.PairService
 }
 
-func ServeOHLCVResource(rg *routing.RouteGroup, ohlcvService *services.OHLCVService) {
-	e := &OHLCVEndpoint{ohlcvService}
+func ServeOHLCVResource(rg *routing.RouteGroup, ohlcvService *services.OHLCVService, pairService *services.PairService) {
+	e := &OHLCVEndpoint{ohlcvService, pairService}
 	rg.Post("/ohlcv", e.ohlcv)
+	rg.Get("/ohlcv/<pair>", e.ohlcvBySymbol)
 	ws.RegisterChannel(ws.OHLCVChannel, e.ohlcvWebSocket)
 }
 
@@ -51,9 +55,78 @@ func (e *OHLCVEndpoint) ohlcv(c *routing.Context) error {
 		return err
 	}
 
+	if model.Formatted {
+		e.ohlcvService.SetFormatted(res)
+	}
+
+	return c.Write(res)
+}
+
+// ohlcvBySymbol returns the ticks of a pair given by its symbol (eg. /ohlcv/AMP-WETH).
+// The tick parameters are passed in the query string (from, to, duration and units)
+func (e *OHLCVEndpoint) ohlcvBySymbol(c *routing.Context) error {
+	p, err := e.pairService.GetBySymbol(c.Param("pair"))
+	if err != nil {
+		return err
+	}
+
+	model := types.TickRequest{
+		Pair:      []types.PairSubDoc{{Name: p.Name, BaseToken: p.BaseTokenAddress, QuoteToken: p.QuoteTokenAddress}},
+		Units:     c.Query("units", "hour"),
+		Formatted: formatted(c),
+		From:      time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC).Unix(),
+		To:        time.Now().Unix(),
+	}
+
+	params := map[string]*int64{"duration": &model.Duration, "from": &model.From, "to": &model.To}
+	for key, value := range params {
+		if q := c.Query(key); q != "" {
+			*value, err = strconv.ParseInt(q, 10, 64)
+			if err != nil {
+				return errors.InvalidQueryParam(key)
+			}
+		}
+	}
+
+	if model.Duration == 0 {
+		model.Duration = 24
+	}
+
+	res, err := e.ohlcvService.GetOHLCV(model.Pair, model.Duration, model.Units, model.From, model.To)
+	if err != nil {
+		return err
+	}
+
+	if model.Formatted {
+		e.ohlcvService.SetFormatted(res)
+	}
+
 	return c.Write(res)
 }
 
+// maxOHLCVIntervals is the maximum number of intervals of a bundled ohlcv subscription
+const maxOHLCVIntervals = 10
+
+// bundleIntervals returns the distinct intervals of a bundled ohlcv subscription, or false
+// if there are too many or one of them is invalid
+func bundleIntervals(intervals []types.OHLCVInterval) ([]types.OHLCVInterval, bool) {
+	distinct := []types.OHLCVInterval{}
+	seen := map[types.OHLCVInterval]bool{}
+
+	for _, i := range intervals {
+		if i.Duration <= 0 || i.Units == "" {
+			return nil, false
+		}
+
+		if !seen[i] {
+			seen[i] = true
+			distinct = append(distinct, i)
+		}
+	}
+
+	return distinct, len(distinct) <= maxOHLCVIntervals
+}
+
 func (e *OHLCVEndpoint) ohlcvWebSocket(input interface{}, conn *websocket.Conn) {
 	startTs := time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
 
@@ -64,20 +137,12 @@ func (e *OHLCVEndpoint) ohlcvWebSocket(input interface{}, conn *websocket.Conn)
 	}
 
 	if (msg.Pair.BaseToken == common.Address{}) {
-		message := map[string]string{
-			"Code":    "Invalid_Pair_BaseToken",
-			"Message": "Invalid Pair BaseToken passed in Params",
-		}
-		ws.SendOHLCVErrorMessage(conn, message)
+		ws.SendOHLCVErrorMessage(conn, errors.NewWSError(errors.InvalidPairBaseToken.New(nil)))
 		return
 	}
 
 	if (msg.Pair.QuoteToken == common.Address{}) {
-		message := map[string]string{
-			"Code":    "Invalid_Pair_BaseToken",
-			"Message": "Invalid Pair BaseToken passed in Params",
-		}
-		ws.SendOHLCVErrorMessage(conn, message)
+		ws.SendOHLCVErrorMessage(conn, errors.NewWSError(errors.InvalidPairQuoteToken.New(nil)))
 		return
 	}
 
@@ -97,6 +162,14 @@ func (e *OHLCVEndpoint) ohlcvWebSocket(input interface{}, conn *websocket.Conn)
 		msg.Params.Units = "hour"
 	}
 
+	intervals, ok := bundleIntervals(msg.Params.Intervals)
+	if !ok {
+		ws.SendOHLCVErrorMessage(conn, errors.NewWSError(errors.InvalidIntervals.New(errors.Params{"max": maxOHLCVIntervals})))
+		return
+	}
+
+	msg.Params.Intervals = intervals
+
 	if msg.Event == types.SUBSCRIBE {
 		e.ohlcvService.Subscribe(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken, &msg.Params)
 	}
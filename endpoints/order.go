@@ -2,47 +2,653 @@ package endpoints
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/Proofsuite/amp-matching-engine/engine"
 	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/Proofsuite/amp-matching-engine/ws"
 	"github.com/go-ozzo/ozzo-routing"
 	"github.com/gorilla/websocket"
+	"gopkg.in/mgo.v2/bson"
 )
 
 type orderEndpoint struct {
-	orderService *services.OrderService
-	engine       *engine.Resource
+	orderService    *services.OrderService
+	engine          engine.Engine
+	approvalService *services.ApprovalService
 }
 
 // ServeOrderResource sets up the routing of order endpoints and the corresponding handlers.
-func ServeOrderResource(rg *routing.RouteGroup, orderService *services.OrderService, engine *engine.Resource) {
-	e := &orderEndpoint{orderService, engine}
+func ServeOrderResource(rg *routing.RouteGroup, orderService *services.OrderService, engine engine.Engine, approvalService *services.ApprovalService) {
+	e := &orderEndpoint{orderService, engine, approvalService}
+	approvalService.Register(types.ActionRestoreSnapshot, e.restoreOrderBooks)
+
 	rg.Get("/orders/<address>", e.get)
+	rg.Get("/orders/<address>/current", e.getCurrent)
+	rg.Get("/orders/<address>/history", e.getHistory)
+	rg.Get("/orders/hash/<hash>", e.getByHash)
+	rg.Get("/admin/orders/<hash>/journal", e.getJournal)
+	rg.Post("/admin/orderbooks/restore", e.restore)
+	rg.Get("/admin/consistency", e.getConsistency)
+	rg.Post("/orders/0x", e.createZeroEx)
+	rg.Post("/orders/dry-run", e.dryRun)
+	rg.Delete("/orders/<hash>", e.cancel)
+	rg.Post("/orders/bulk", e.createBulk)
+	rg.Post("/orders/bulk/cancel", e.cancelBulk)
+	rg.Post("/rfq/quotes", e.requestQuote)
+	rg.Post("/rfq/quotes/<id>/commit", e.commitQuote)
 	ws.RegisterChannel(ws.OrderChannel, e.ws)
-	engine.SubscribeEngineResponse(e.orderService.HandleEngineResponse)
+	engine.SubscribeResponses(e.orderService.HandleEngineResponse)
 }
 
+// Pagination of the order history. Limit is the default number of orders returned,
+// maxOrderLimit is the maximum number of orders a request can return.
+const (
+	defaultOrderLimit = 100
+	maxOrderLimit     = 1000
+)
+
+// get returns the orders of an account, most recent first. The orders can be filtered
+// with the status (comma separated), baseToken, quoteToken, from and to (unix timestamps)
+// query params and are paginated with limit and offset. The total number of matching
+// orders is returned in the X-Total-Count header.
 func (e *orderEndpoint) get(c *routing.Context) error {
 	addr := c.Param("address")
 	if !common.IsHexAddress(addr) {
-		return errors.NewAPIError(400, "Invalid Adrress", map[string]interface{}{})
+		return errors.InvalidAddress.New(nil)
+	}
+
+	q, err := parseOrderQuery(c)
+	if err != nil {
+		return err
+	}
+
+	q.UserAddress = common.HexToAddress(addr)
+	orders, total, err := e.orderService.Query(q)
+	if err != nil {
+		return errors.FetchError.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	if formatted(c) {
+		e.orderService.SetFormatted(orders)
+	}
+
+	c.Response.Header().Set("X-Total-Count", strconv.Itoa(total))
+	return c.Write(orders)
+}
+
+// parseOrderQuery returns the filters and the pagination of an order history request
+func parseOrderQuery(c *routing.Context) (*types.OrderQuery, error) {
+	q := &types.OrderQuery{}
+
+	if s := c.Query("status"); s != "" {
+		for _, status := range strings.Split(s, ",") {
+			q.Statuses = append(q.Statuses, strings.ToUpper(strings.TrimSpace(status)))
+		}
+	}
+
+	tokens := map[string]*common.Address{"baseToken": &q.BaseToken, "quoteToken": &q.QuoteToken}
+	for key, value := range tokens {
+		if t := c.Query(key); t != "" {
+			if !common.IsHexAddress(t) {
+				return nil, errors.InvalidQueryParam(key)
+			}
+
+			*value = common.HexToAddress(t)
+		}
+	}
+
+	times := map[string]*time.Time{"from": &q.From, "to": &q.To}
+	for key, value := range times {
+		if t := c.Query(key); t != "" {
+			ts, err := strconv.ParseInt(t, 10, 64)
+			if err != nil {
+				return nil, errors.InvalidQueryParam(key)
+			}
+
+			*value = time.Unix(ts, 0)
+		}
+	}
+
+	offset, limit, err := parsePagination(c)
+	if err != nil {
+		return nil, err
+	}
+
+	q.Offset, q.Limit = offset, limit
+	return q, nil
+}
+
+// parsePagination returns the offset and the limit query params of an order request
+func parsePagination(c *routing.Context) (offset, limit int, err error) {
+	limit = defaultOrderLimit
+	pagination := map[string]*int{"limit": &limit, "offset": &offset}
+	for key, value := range pagination {
+		if p := c.Query(key); p != "" {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 0 {
+				return 0, 0, errors.InvalidQueryParam(key)
+			}
+
+			*value = n
+		}
+	}
+
+	if limit == 0 || limit > maxOrderLimit {
+		limit = maxOrderLimit
+	}
+
+	return offset, limit, nil
+}
+
+// getCurrent returns the orders of an account resting in the orderbook (NEW, OPEN or
+// PARTIAL_FILLED), most recent first
+func (e *orderEndpoint) getCurrent(c *routing.Context) error {
+	addr := c.Param("address")
+	if !common.IsHexAddress(addr) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	orders, err := e.orderService.GetCurrentByUserAddress(common.HexToAddress(addr))
+	if err != nil {
+		return errors.FetchError.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	if formatted(c) {
+		e.orderService.SetFormatted(orders)
+	}
+
+	return c.Write(orders)
+}
+
+// getHistory returns the filled, cancelled and expired orders of an account, most recent
+// first. The orders are paginated with the limit and offset query params and the total
+// number of orders is returned in the X-Total-Count header.
+func (e *orderEndpoint) getHistory(c *routing.Context) error {
+	addr := c.Param("address")
+	if !common.IsHexAddress(addr) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	offset, limit, err := parsePagination(c)
+	if err != nil {
+		return err
 	}
 
-	address := common.HexToAddress(addr)
-	orders, err := e.orderService.GetByUserAddress(address)
+	orders, total, err := e.orderService.GetHistoryByUserAddress(common.HexToAddress(addr), offset, limit)
 	if err != nil {
-		return errors.NewAPIError(400, "Fetch Error", map[string]interface{}{})
+		return errors.FetchError.New(map[string]interface{}{"error": err.Error()})
 	}
 
+	if formatted(c) {
+		e.orderService.SetFormatted(orders)
+	}
+
+	c.Response.Header().Set("X-Total-Count", strconv.Itoa(total))
 	return c.Write(orders)
 }
 
+// getByHash returns an order and its fill history: the trades filling the order. The
+// orders moved to the archive are returned with the archived flag.
+func (e *orderEndpoint) getByHash(c *routing.Context) error {
+	h := c.Param("hash")
+	if !utils.IsHexHash(h) {
+		return errors.InvalidHash.New(map[string]interface{}{"hash": h})
+	}
+
+	hash := common.HexToHash(h)
+	o, err := e.orderService.LookupByHash(hash)
+	if err != nil {
+		return errors.FetchError.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	if o == nil {
+		return errors.OrderNotFound.New(map[string]interface{}{"hash": hash.Hex()})
+	}
+
+	trades, err := e.orderService.GetTrades(o)
+	if err != nil {
+		return errors.FetchError.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	if formatted(c) {
+		e.orderService.SetFormatted([]*types.Order{o})
+		e.orderService.SetFormattedTrades(trades)
+	}
+
+	return c.Write(map[string]interface{}{"order": o, "trades": trades})
+}
+
+// getJournal returns the journal of an order: why it was rejected, the makers it matched
+// and the self-trade prevention actions, oldest first. The journal is kept for the
+// journal retention period, including for the orders rejected before being stored.
+func (e *orderEndpoint) getJournal(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	h := c.Param("hash")
+	if !utils.IsHexHash(h) {
+		return errors.InvalidHash.New(map[string]interface{}{"hash": h})
+	}
+
+	entries, err := e.orderService.GetJournal(common.HexToHash(h))
+	if err != nil {
+		return errors.FetchError.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.Write(entries)
+}
+
+// createZeroEx creates an order from a signed 0x order. The order updates are not sent
+// to the client, the 0x clients that need them can send the order on the websocket
+// order channel instead (NEW_0X_ORDER message).
+func (e *orderEndpoint) createZeroEx(c *routing.Context) error {
+	z := &types.ZeroExOrder{}
+	if err := c.Read(z); err != nil {
+		return errors.InvalidZeroExOrder.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	o, err := zeroExOrder(z)
+	if err != nil {
+		return err
+	}
+
+	err = e.orderService.NewOrder(o)
+	if err != nil {
+		return errors.OrderRejected.Wrap(err)
+	}
+
+	return c.Write(map[string]interface{}{"order": o, "zeroEx": z})
+}
+
+// dryRun validates a signed order like a new order and returns the trades it would make
+// against the orderbook right now, without placing it. The order is rejected with the
+// error it would be rejected with as a new order.
+func (e *orderEndpoint) dryRun(c *routing.Context) error {
+	o := &types.Order{}
+	if err := c.Read(o); err != nil {
+		return errors.InvalidOrder.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	o.Hash = o.ComputeHash()
+	res, err := e.orderService.DryRunOrder(o)
+	if err != nil {
+		return errors.OrderRejected.Wrap(err)
+	}
+
+	if formatted(c) {
+		orders := []*types.Order{res.Order}
+		for _, m := range res.Matches {
+			orders = append(orders, m.Order)
+		}
+
+		e.orderService.SetFormatted(orders)
+		e.orderService.SetFormattedTrades(res.Trades)
+	}
+
+	return c.Write(res)
+}
+
+// requestQuote returns a firm quote for a signed order. The maker quantity filling the
+// whole order is reserved for the taker until the quote expires, the order is rejected
+// if the orderbook can not fill it.
+func (e *orderEndpoint) requestQuote(c *routing.Context) error {
+	o := &types.Order{}
+	if err := c.Read(o); err != nil {
+		return errors.InvalidOrder.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	o.Hash = o.ComputeHash()
+	q, err := e.orderService.RequestQuote(o)
+	if err != nil {
+		return errors.OrderRejected.Wrap(err)
+	}
+
+	return c.Write(q)
+}
+
+// commitQuote settles the trades of a firm quote with the trades signed by the taker
+func (e *orderEndpoint) commitQuote(c *routing.Context) error {
+	id := c.Param("id")
+	if !bson.IsObjectIdHex(id) {
+		return errors.InvalidID.New(map[string]interface{}{"id": id})
+	}
+
+	commit := &types.RFQCommit{}
+	if err := c.Read(commit); err != nil {
+		return errors.InvalidTrades.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	trades, err := e.orderService.CommitQuote(bson.ObjectIdHex(id), commit.Trades)
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			return apiErr
+		}
+
+		return errors.InternalServerError(err)
+	}
+
+	return c.Write(trades)
+}
+
+// cancel cancels an order with an order cancel message signed by the maker of the order.
+// The cancel hash must be the hash of the order hash and the signature must recover to
+// the maker address, otherwise the cancel is rejected before reaching the engine.
+func (e *orderEndpoint) cancel(c *routing.Context) error {
+	h := c.Param("hash")
+	if !utils.IsHexHash(h) {
+		return errors.InvalidHash.New(map[string]interface{}{"hash": h})
+	}
+
+	oc := &types.OrderCancel{}
+	if err := c.Read(oc); err != nil {
+		return errors.InvalidOrderCancel.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	if oc.OrderHash != common.HexToHash(h) {
+		return errors.InvalidOrderCancel.New(map[string]interface{}{"error": "orderHash does not match the order"})
+	}
+
+	if err := e.verifyCancel(oc); err != nil {
+		return err
+	}
+
+	err := e.orderService.CancelOrder(oc)
+	if err != nil {
+		return errors.CancelRejected.Wrap(err)
+	}
+
+	o, err := e.orderService.GetByHash(oc.OrderHash)
+	if err != nil {
+		return errors.FetchError.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.Write(o)
+}
+
+// verifyCancel checks that an order cancel is signed by the maker of the order
+func (e *orderEndpoint) verifyCancel(oc *types.OrderCancel) error {
+	o, err := e.orderService.GetByHash(oc.OrderHash)
+	if err != nil {
+		return errors.FetchError.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	if o == nil {
+		return errors.OrderNotFound.New(map[string]interface{}{"hash": oc.OrderHash.Hex()})
+	}
+
+	if oc.Hash != oc.ComputeHash() {
+		return errors.InvalidSignature.New(map[string]interface{}{"error": "Invalid cancel hash"})
+	}
+
+	ok, err := oc.VerifySignature(o)
+	if err != nil || !ok {
+		return errors.InvalidSignature.New(nil)
+	}
+
+	return nil
+}
+
+// maxBulkOrders is the maximum number of orders, or of cancels, of a bulk request
+const maxBulkOrders = 100
+
+// createBulk creates a batch of orders. No order is created if one of them is invalid,
+// the valid orders are then sent to the engine in sequence. The response contains the
+// result of each order, in the order of the batch.
+func (e *orderEndpoint) createBulk(c *routing.Context) error {
+	orders := []*types.Order{}
+	if err := c.Read(&orders); err != nil {
+		return errors.InvalidOrders.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	if len(orders) == 0 || len(orders) > maxBulkOrders {
+		return errors.InvalidBatchSize.New(map[string]interface{}{"max": maxBulkOrders})
+	}
+
+	return c.Write(e.submitOrders(orders, nil))
+}
+
+// cancelBulk cancels a batch of orders with order cancels signed by the order makers. No
+// order is cancelled if one of the cancels is invalid. The response contains the result
+// of each cancel, in the order of the batch.
+func (e *orderEndpoint) cancelBulk(c *routing.Context) error {
+	cancels := []*types.OrderCancel{}
+	if err := c.Read(&cancels); err != nil {
+		return errors.InvalidOrderCancel.New(map[string]interface{}{"error": err.Error()})
+	}
+
+	if len(cancels) == 0 || len(cancels) > maxBulkOrders {
+		return errors.InvalidBatchSize.New(map[string]interface{}{"max": maxBulkOrders})
+	}
+
+	return c.Write(e.cancelOrders(cancels, nil))
+}
+
+// submitOrders validates a batch of orders and sends them in sequence to the order
+// service if they are all valid. The orders are registered on the connection, if any,
+// so that their updates are sent to the client.
+func (e *orderEndpoint) submitOrders(orders []*types.Order, conn *websocket.Conn) []*types.OrderResult {
+	results, valid := e.validateOrders(orders)
+	if !valid {
+		return rejectBatch(results)
+	}
+
+	for i, o := range orders {
+		if conn != nil {
+			ch := make(chan *types.WebSocketPayload)
+			ws.RegisterOrderConnection(o.Hash, &ws.OrderConnection{Conn: conn, ReadChannel: ch})
+			ws.RegisterConnectionUnsubscribeHandler(conn, ws.OrderSocketUnsubscribeHandler(o.Hash))
+		}
+
+		if err := e.orderService.NewOrder(o); err != nil {
+			results[i].Reject(err)
+		}
+	}
+
+	return results
+}
+
+// validateOrders computes the hashes and checks the fields and the signatures of a batch
+// of orders. It returns the result of each order and whether all the orders are valid.
+func (e *orderEndpoint) validateOrders(orders []*types.Order) ([]*types.OrderResult, bool) {
+	results := make([]*types.OrderResult, len(orders))
+	valid := true
+	for i, o := range orders {
+		o.Hash = o.ComputeHash()
+		results[i] = &types.OrderResult{Hash: o.Hash}
+
+		if err := e.orderService.ValidateOrder(o); err != nil {
+			results[i].Reject(err)
+			valid = false
+		}
+	}
+
+	return results, valid
+}
+
+// cancelOrders verifies a batch of order cancels and cancels the orders in sequence if
+// they are all valid
+func (e *orderEndpoint) cancelOrders(cancels []*types.OrderCancel, conn *websocket.Conn) []*types.OrderResult {
+	results := make([]*types.OrderResult, len(cancels))
+	valid := true
+	for i, oc := range cancels {
+		results[i] = &types.OrderResult{Hash: oc.OrderHash}
+
+		if err := e.verifyCancel(oc); err != nil {
+			results[i].Reject(err)
+			valid = false
+		}
+	}
+
+	if !valid {
+		return rejectBatch(results)
+	}
+
+	for i, oc := range cancels {
+		if conn != nil {
+			ws.RegisterOrderConnection(oc.OrderHash, &ws.OrderConnection{Conn: conn, Active: true})
+			ws.RegisterConnectionUnsubscribeHandler(conn, ws.OrderSocketUnsubscribeHandler(oc.OrderHash))
+		}
+
+		if err := e.orderService.CancelOrder(oc); err != nil {
+			results[i].Reject(err)
+		}
+	}
+
+	return results
+}
+
+// rejectBatch sets the error of the valid orders of a batch containing invalid orders
+func rejectBatch(results []*types.OrderResult) []*types.OrderResult {
+	for _, r := range results {
+		if r.Code == "" {
+			r.Reject(errors.BatchRejected.New(nil))
+		}
+	}
+
+	return results
+}
+
+// Fields of the order payloads checked before the payloads are decoded, so that a
+// malformed field is rejected with the path of the field instead of being decoded as zero
+var (
+	orderNumericFields  = []string{"price", "pricepoint", "amount", "filledAmount", "buyAmount", "sellAmount", "expires", "nonce", "makeFee", "takeFee"}
+	orderAddressFields  = []string{"exchangeAddress", "userAddress", "buyToken", "sellToken", "baseToken", "quoteToken"}
+	orderStringFields   = []string{"id", "pairID", "pairName", "hash", "side", "status", "signatureScheme"}
+	zeroExNumericFields = []string{"makerAssetAmount", "takerAssetAmount", "makerFee", "takerFee", "expirationTimeSeconds", "salt"}
+	zeroExAddressFields = []string{"exchangeAddress", "makerAddress", "takerAddress", "feeRecipientAddress", "senderAddress"}
+	zeroExStringFields  = []string{"makerAssetData", "takerAssetData", "makerFeeAssetData", "takerFeeAssetData", "signature"}
+)
+
+// validateOrderInput checks the fields of an order payload. field is the path of the
+// order in the message payload.
+func validateOrderInput(data interface{}, field string) error {
+	obj, err := ws.CheckObject(data, field)
+	if err != nil {
+		return err
+	}
+
+	if err := ws.CheckNumericStrings(obj, field, orderNumericFields...); err != nil {
+		return err
+	}
+
+	if err := ws.CheckAddresses(obj, field, orderAddressFields...); err != nil {
+		return err
+	}
+
+	if err := ws.CheckStrings(obj, field, orderStringFields...); err != nil {
+		return err
+	}
+
+	return ws.CheckSignature(obj, field)
+}
+
+// validateOrdersInput checks a batch of order payloads
+func validateOrdersInput(data interface{}, field string) error {
+	items, err := ws.CheckArray(data, field, maxBulkOrders)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		if err := validateOrderInput(item, ws.ItemPath(field, i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateCancelInput checks the fields of an order cancel payload
+func validateCancelInput(data interface{}, field string) error {
+	obj, err := ws.CheckObject(data, field)
+	if err != nil {
+		return err
+	}
+
+	if err := ws.CheckStrings(obj, field, "orderHash", "hash"); err != nil {
+		return err
+	}
+
+	return ws.CheckSignature(obj, field)
+}
+
+// validateMassQuoteInput checks the pair and the quotes of a mass quote payload. The
+// bids and the asks are checked as batches of orders.
+func validateMassQuoteInput(data interface{}) error {
+	obj, err := ws.CheckObject(data, "")
+	if err != nil {
+		return err
+	}
+
+	if err := ws.CheckAddresses(obj, "", "baseToken", "quoteToken"); err != nil {
+		return err
+	}
+
+	for _, side := range []string{"bids", "asks"} {
+		if err := validateOrdersInput(obj[side], side); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateZeroExOrderInput checks the fields of a signed 0x order payload
+func validateZeroExOrderInput(data interface{}) error {
+	obj, err := ws.CheckObject(data, "")
+	if err != nil {
+		return err
+	}
+
+	if err := ws.CheckNumericStrings(obj, "", zeroExNumericFields...); err != nil {
+		return err
+	}
+
+	if err := ws.CheckAddresses(obj, "", zeroExAddressFields...); err != nil {
+		return err
+	}
+
+	return ws.CheckStrings(obj, "", zeroExStringFields...)
+}
+
+// zeroExOrder converts a signed 0x order to an order. The 0x orders are only accepted for
+// the configured 0x exchange contract, on which their trades are settled.
+func zeroExOrder(z *types.ZeroExOrder) (*types.Order, error) {
+	exchange := app.Config.ZeroExExchangeAddress
+	if exchange == "" || z.ExchangeAddress != common.HexToAddress(exchange) {
+		return nil, errors.InvalidZeroExOrder.New(errors.Params{"error": "Invalid exchange address"})
+	}
+
+	o, err := z.ToOrder()
+	if err != nil {
+		return nil, errors.InvalidZeroExOrder.New(errors.Params{"error": err.Error()})
+	}
+
+	return o, nil
+}
+
+// batchSizeError is the error of the empty batches of orders or cancels
+func batchSizeError(field string) *ws.InputError {
+	return &ws.InputError{
+		Code:    errors.InvalidBatchSize.Code,
+		Message: fmt.Sprintf("Invalid batch size, a batch contains 1 to %d items", maxBulkOrders),
+		Field:   field,
+		Max:     maxBulkOrders,
+	}
+}
+
 // ws function handles incoming websocket messages on the order channel
 func (e *orderEndpoint) ws(input interface{}, conn *websocket.Conn) {
 	msg := &types.WebSocketPayload{}
@@ -55,8 +661,16 @@ func (e *orderEndpoint) ws(input interface{}, conn *websocket.Conn) {
 	switch msg.Type {
 	case "NEW_ORDER":
 		e.handleNewOrder(msg, conn)
+	case "NEW_0X_ORDER":
+		e.handleNewZeroExOrder(msg, conn)
 	case "CANCEL_ORDER":
 		e.handleCancelOrder(msg, conn)
+	case "NEW_ORDERS":
+		e.handleNewOrders(msg, conn)
+	case "CANCEL_ORDERS":
+		e.handleCancelOrders(msg, conn)
+	case "MASS_QUOTE":
+		e.handleMassQuote(msg, conn)
 	case "NEW_TRADE":
 		e.handleNewTrade(msg, conn)
 	default:
@@ -77,23 +691,77 @@ func (e *orderEndpoint) handleNewTrade(msg *types.WebSocketPayload, conn *websoc
 
 // handleNewOrder handles NewOrder message. New order messages are transmitted to the order service after being unmarshalled
 func (e *orderEndpoint) handleNewOrder(msg *types.WebSocketPayload, conn *websocket.Conn) {
-	ch := make(chan *types.WebSocketPayload)
+	if err := validateOrderInput(msg.Data, ""); err != nil {
+		ws.SendOrderErrorMessage(conn, err)
+		return
+	}
+
 	o := &types.Order{}
 
 	bytes, err := json.Marshal(msg.Data)
 	if err != nil {
 		log.Print(err)
-		ws.SendOrderErrorMessage(conn, err.Error())
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(errors.InvalidMessage.New(nil)))
 		return
 	}
 	err = json.Unmarshal(bytes, &o)
 	if err != nil {
 		log.Print(err)
-		ws.SendOrderErrorMessage(conn, err.Error())
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(errors.InvalidMessage.New(nil)))
 		return
 	}
 
 	o.Hash = o.ComputeHash()
+	if err := ws.CheckMaker(conn, o.UserAddress); err != nil {
+		ws.SendOrderErrorMessage(conn, err, o.Hash)
+		return
+	}
+
+	o.Stamp(types.StageReceived, time.Now())
+	e.submitOrder(o, conn)
+}
+
+// handleNewZeroExOrder handles NewZeroExOrder messages. The signed 0x order is converted to
+// an order and submitted as a new order.
+func (e *orderEndpoint) handleNewZeroExOrder(msg *types.WebSocketPayload, conn *websocket.Conn) {
+	if err := validateZeroExOrderInput(msg.Data); err != nil {
+		ws.SendOrderErrorMessage(conn, err)
+		return
+	}
+
+	z := &types.ZeroExOrder{}
+
+	bytes, err := json.Marshal(msg.Data)
+	if err != nil {
+		log.Print(err)
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(errors.InvalidMessage.New(nil)))
+		return
+	}
+
+	err = json.Unmarshal(bytes, z)
+	if err != nil {
+		log.Print(err)
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(errors.InvalidMessage.New(nil)))
+		return
+	}
+
+	o, err := zeroExOrder(z)
+	if err != nil {
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(err), z.ComputeHash())
+		return
+	}
+
+	if err := ws.CheckMaker(conn, o.UserAddress); err != nil {
+		ws.SendOrderErrorMessage(conn, err, o.Hash)
+		return
+	}
+
+	e.submitOrder(o, conn)
+}
+
+// submitOrder registers the connection of the order and sends it to the order service
+func (e *orderEndpoint) submitOrder(o *types.Order, conn *websocket.Conn) {
+	ch := make(chan *types.WebSocketPayload)
 
 	// NOTE: I've put the connection registration here as i feel it would be preferable to
 	// validate orders but this might leads to race conditions, not exactly sure.
@@ -103,22 +771,213 @@ func (e *orderEndpoint) handleNewOrder(msg *types.WebSocketPayload, conn *websoc
 	ws.RegisterOrderConnection(o.Hash, &ws.OrderConnection{Conn: conn, ReadChannel: ch})
 	ws.RegisterConnectionUnsubscribeHandler(conn, ws.OrderSocketUnsubscribeHandler(o.Hash))
 
-	err = e.orderService.NewOrder(o)
+	err := e.orderService.NewOrder(o)
+	if err != nil {
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(err), o.Hash)
+		return
+	}
+}
+
+// handleNewOrders handles NewOrders messages. The result of each order of the batch is
+// sent in a NEW_ORDERS_RESULT message, the updates of the orders are then sent like for
+// NEW_ORDER messages.
+func (e *orderEndpoint) handleNewOrders(msg *types.WebSocketPayload, conn *websocket.Conn) {
+	if err := validateOrdersInput(msg.Data, ""); err != nil {
+		ws.SendOrderErrorMessage(conn, err)
+		return
+	}
+
+	orders := []*types.Order{}
+
+	bytes, err := json.Marshal(msg.Data)
+	if err == nil {
+		err = json.Unmarshal(bytes, &orders)
+	}
+
+	if err != nil {
+		log.Print(err)
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(errors.InvalidMessage.New(nil)))
+		return
+	}
+
+	if len(orders) == 0 {
+		ws.SendOrderErrorMessage(conn, batchSizeError(""))
+		return
+	}
+
+	if err := checkMakers(conn, orders); err != nil {
+		ws.SendOrderErrorMessage(conn, err)
+		return
+	}
+
+	ws.SendOrderMessage(conn, "NEW_ORDERS_RESULT", e.submitOrders(orders, conn))
+}
+
+// handleCancelOrders handles CancelOrders messages. The result of each cancel of the batch
+// is sent in a CANCEL_ORDERS_RESULT message.
+func (e *orderEndpoint) handleCancelOrders(msg *types.WebSocketPayload, conn *websocket.Conn) {
+	items, err := ws.CheckArray(msg.Data, "", maxBulkOrders)
+	if err != nil {
+		ws.SendOrderErrorMessage(conn, err)
+		return
+	}
+
+	for i, item := range items {
+		if err := validateCancelInput(item, ws.ItemPath("", i)); err != nil {
+			ws.SendOrderErrorMessage(conn, err)
+			return
+		}
+	}
+
+	cancels := []*types.OrderCancel{}
+
+	bytes, err := json.Marshal(msg.Data)
+	if err == nil {
+		err = json.Unmarshal(bytes, &cancels)
+	}
+
+	if err != nil {
+		log.Print(err)
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(errors.InvalidMessage.New(nil)))
+		return
+	}
+
+	if len(cancels) == 0 {
+		ws.SendOrderErrorMessage(conn, batchSizeError(""))
+		return
+	}
+
+	for _, oc := range cancels {
+		if err := e.checkCanceller(conn, oc.OrderHash); err != nil {
+			ws.SendOrderErrorMessage(conn, authErrorPayload(err))
+			return
+		}
+	}
+
+	ws.SendOrderMessage(conn, "CANCEL_ORDERS_RESULT", e.cancelOrders(cancels, conn))
+}
+
+// handleMassQuote handles MassQuote messages. The current orders of the maker on the pair
+// are replaced by the quotes of the message. The result of each quote (bids first) is
+// sent in a MASS_QUOTE_RESULT message, the updates of the quotes and of the replaced
+// orders are then sent like for NEW_ORDER messages.
+func (e *orderEndpoint) handleMassQuote(msg *types.WebSocketPayload, conn *websocket.Conn) {
+	if err := validateMassQuoteInput(msg.Data); err != nil {
+		ws.SendOrderErrorMessage(conn, err)
+		return
+	}
+
+	q := &types.MassQuote{}
+
+	bytes, err := json.Marshal(msg.Data)
+	if err == nil {
+		err = json.Unmarshal(bytes, q)
+	}
+
+	if err != nil {
+		log.Print(err)
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(errors.InvalidMessage.New(nil)))
+		return
+	}
+
+	orders := q.Orders()
+	if len(orders) == 0 || len(orders) > maxBulkOrders {
+		ws.SendOrderErrorMessage(conn, batchSizeError(""))
+		return
+	}
+
+	if err := checkMakers(conn, orders); err != nil {
+		ws.SendOrderErrorMessage(conn, err)
+		return
+	}
+
+	results, valid := e.validateOrders(orders)
+	if !valid {
+		ws.SendOrderMessage(conn, "MASS_QUOTE_RESULT", rejectBatch(results))
+		return
+	}
+
+	for _, o := range orders {
+		ch := make(chan *types.WebSocketPayload)
+		ws.RegisterOrderConnection(o.Hash, &ws.OrderConnection{Conn: conn, ReadChannel: ch})
+		ws.RegisterConnectionUnsubscribeHandler(conn, ws.OrderSocketUnsubscribeHandler(o.Hash))
+	}
+
+	results, err = e.orderService.MassQuote(q)
 	if err != nil {
-		ws.SendOrderErrorMessage(conn, err.Error(), o.Hash)
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(err))
 		return
 	}
+
+	ws.SendOrderMessage(conn, "MASS_QUOTE_RESULT", results)
+}
+
+// checkMakers returns an error if the connection can not send the orders of one of the
+// makers of a batch
+func checkMakers(conn *websocket.Conn, orders []*types.Order) error {
+	for _, o := range orders {
+		if err := ws.CheckMaker(conn, o.UserAddress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkCanceller returns an error if the connection can not cancel the orders of the
+// maker of an order. The unknown orders are rejected by the cancellation.
+func (e *orderEndpoint) checkCanceller(conn *websocket.Conn, hash common.Hash) error {
+	o, err := e.orderService.GetByHash(hash)
+	if err != nil {
+		return err
+	}
+
+	if o == nil {
+		return nil
+	}
+
+	return ws.CheckMaker(conn, o.UserAddress)
+}
+
+// authErrorPayload returns the payload of the error of a maker check, the other errors
+// (eg. of the database) are sent as catalogue errors
+func authErrorPayload(err error) interface{} {
+	if authErr, ok := err.(*ws.AuthError); ok {
+		return authErr
+	}
+
+	return errors.NewWSError(err)
 }
 
 // handleCancelOrder handles CancelOrder message.
 func (e *orderEndpoint) handleCancelOrder(p *types.WebSocketPayload, conn *websocket.Conn) {
+	if err := validateCancelInput(p.Data, ""); err != nil {
+		ws.SendOrderErrorMessage(conn, err)
+		return
+	}
+
 	bytes, err := json.Marshal(p.Data)
 	oc := &types.OrderCancel{}
 
 	err = oc.UnmarshalJSON(bytes)
 	if err != nil {
 		log.Print(err)
-		ws.SendOrderErrorMessage(conn, err.Error(), oc.Hash)
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(errors.InvalidMessage.New(nil)), oc.Hash)
+		return
+	}
+
+	if err := e.checkCanceller(conn, oc.OrderHash); err != nil {
+		ws.SendOrderErrorMessage(conn, authErrorPayload(err), oc.Hash)
+		return
+	}
+
+	// the cancels sent on a connection that is not authenticated must be signed by the
+	// maker of the order
+	if _, ok := ws.AuthenticatedAddress(conn); !ok {
+		if err := e.verifyCancel(oc); err != nil {
+			ws.SendOrderErrorMessage(conn, errors.NewWSError(err), oc.Hash)
+			return
+		}
 	}
 
 	ws.RegisterOrderConnection(oc.Hash, &ws.OrderConnection{Conn: conn, Active: true})
@@ -129,8 +988,7 @@ func (e *orderEndpoint) handleCancelOrder(p *types.WebSocketPayload, conn *webso
 
 	err = e.orderService.CancelOrder(oc)
 	if err != nil {
-		log.Print(err)
-		ws.SendOrderErrorMessage(conn, err.Error(), oc.Hash)
+		ws.SendOrderErrorMessage(conn, errors.NewWSError(err), oc.Hash)
 		return
 	}
 }
@@ -141,13 +999,13 @@ func (e *orderEndpoint) handleCancelOrder(p *types.WebSocketPayload, conn *webso
 // 	bytes, err := json.Marshal(msg.Data)
 // 	if err != nil {
 // 		log.Printf("Error while marshalling msg data: ", err)
-// 		ws.SendOrderErrorMessage(conn, err.Error())
+// 		ws.SendOrderErrorMessage(conn, errors.NewWSError(err))
 // 		return
 // 	}
 // 	err = json.Unmarshal(bytes, &p)
 // 	if err != nil {
 // 		log.Printf("Error while unmarshalling msg data bytes: ", err)
-// 		ws.SendOrderErrorMessage(conn, err.Error())
+// 		ws.SendOrderErrorMessage(conn, errors.NewWSError(err))
 // 		return
 // 	}
 
@@ -177,3 +1035,35 @@ func (e *orderEndpoint) handleCancelOrder(p *types.WebSocketPayload, conn *webso
 // 		ws.OrderSocketUnsubscribeHandler(p.Hash),
 // 	)
 // }
+
+// restore requests the approval of the restoration of the orderbooks from the open
+// orders stored in the database
+func (e *orderEndpoint) restore(c *routing.Context) error {
+	return requestApproval(c, e.approvalService, types.ActionRestoreSnapshot, "orderbooks", nil)
+}
+
+// restoreOrderBooks restores the orderbooks once their restoration is approved
+func (e *orderEndpoint) restoreOrderBooks(a *types.Approval) error {
+	_, err := e.orderService.RestoreOrderBooks()
+	return err
+}
+
+// getConsistency returns the report of the latest consistency check of the orderbooks
+// and of the locked balances. A check is run without repairing the divergences if no
+// check ran yet or if the refresh query param is true.
+func (e *orderEndpoint) getConsistency(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	report := services.GetConsistencyReport()
+	if report == nil || c.Query("refresh") == "true" {
+		var err error
+		report, err = e.orderService.CheckConsistency(false)
+		if err != nil {
+			return errors.InternalServerError(err)
+		}
+	}
+
+	return c.Write(report)
+}
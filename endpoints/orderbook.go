@@ -3,6 +3,8 @@ package endpoints
 import (
 	"encoding/json"
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/services"
@@ -15,30 +17,66 @@ import (
 
 type OrderBookEndpoint struct {
 	orderBookService *services.OrderBookService
+	pairService      *services.PairService
 }
 
+// Bounds of the depth chart buckets
+const (
+	defaultDepthStep    = 0.5
+	defaultDepthBuckets = 20
+	maxDepthBuckets     = 100
+)
+
+// Number of keys sampled per pair by the orderbook memory report
+const (
+	defaultMemorySamples = 100
+	maxMemorySamples     = 10000
+)
+
+// Number of buckets per side of the aggregated orderbook
+const (
+	defaultBookDepth = 50
+	maxBookDepth     = 500
+)
+
 // ServePairResource sets up the routing of pair endpoints and the corresponding handlers.
-func ServeOrderBookResource(rg *routing.RouteGroup, orderBookService *services.OrderBookService) {
-	e := &OrderBookEndpoint{orderBookService}
+func ServeOrderBookResource(rg *routing.RouteGroup, orderBookService *services.OrderBookService, pairService *services.PairService) {
+	e := &OrderBookEndpoint{orderBookService, pairService}
 
 	rg.Get("/orderbook/<baseToken>/<quoteToken>", e.orderBookEndpoint)
+	rg.Get("/orderbook/<pair>/depth-chart", e.depthChart)
+	rg.Get("/quote", e.quote)
+	rg.Get("/admin/orderbooks/memory", e.memory)
 	ws.RegisterChannel(ws.OrderBookChannel, e.orderBookWebSocket)
+	ws.RegisterChannel(ws.RawOrderBookChannel, e.rawOrderBookWebSocket)
 }
 
 func (e *OrderBookEndpoint) orderBookEndpoint(c *routing.Context) error {
 
 	bt := c.Param("baseToken")
 	if !common.IsHexAddress(bt) {
-		return errors.NewAPIError(400, "INVALID_HEX_ADDRESS", nil)
+		return errors.InvalidHexAddress.New(nil)
 	}
 
 	qt := c.Param("quoteToken")
 	if !common.IsHexAddress(qt) {
-		return errors.NewAPIError(400, "INVALID_HEX_ADDRESS", nil)
+		return errors.InvalidHexAddress.New(nil)
 	}
 
 	baseTokenAddress := common.HexToAddress(bt)
 	quoteTokenAddress := common.HexToAddress(qt)
+	switch c.Query("mode") {
+	case "":
+	case "full":
+		return e.rawOrderBook(c, baseTokenAddress, quoteTokenAddress)
+	default:
+		return errors.InvalidMode.New(nil)
+	}
+
+	if c.Query("depth") != "" || c.Query("precision") != "" {
+		return e.aggregatedOrderBook(c, baseTokenAddress, quoteTokenAddress)
+	}
+
 	ob, err := e.orderBookService.GetOrderBook(baseTokenAddress, quoteTokenAddress)
 	if err != nil {
 		return err
@@ -47,6 +85,132 @@ func (e *OrderBookEndpoint) orderBookEndpoint(c *routing.Context) error {
 	return c.Write(ob)
 }
 
+// aggregatedOrderBook returns the orderbook of a pair with its price levels grouped in
+// buckets of precision decimals (default 8, the precision of the pricepoints) and limited
+// to the depth (default 50) best buckets of each side. Each bucket has its price, the
+// total amount and the number of its orders.
+func (e *OrderBookEndpoint) aggregatedOrderBook(c *routing.Context, bt, qt common.Address) error {
+	p, err := e.pairService.GetByTokenAddress(bt, qt)
+	if err != nil {
+		return err
+	}
+
+	depth := defaultBookDepth
+	if d := c.Query("depth"); d != "" {
+		depth, err = strconv.Atoi(d)
+		if err != nil || depth <= 0 || depth > maxBookDepth {
+			return errors.InvalidDepth.New(nil)
+		}
+	}
+
+	precision := types.MaxBookPrecision
+	if pr := c.Query("precision"); pr != "" {
+		precision, err = strconv.Atoi(pr)
+		if err != nil || precision < 0 || precision > types.MaxBookPrecision {
+			return errors.InvalidPrecision.New(nil)
+		}
+	}
+
+	ob, err := e.orderBookService.GetAggregatedOrderBook(p, precision, depth)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(ob)
+}
+
+// rawOrderBook returns the resting orders of the orderbook of a pair (mode=full), best
+// price first and in time priority within a price level. Each order has its hash, maker,
+// price, remaining amount and sequence number.
+func (e *OrderBookEndpoint) rawOrderBook(c *routing.Context, bt, qt common.Address) error {
+	p, err := e.pairService.GetByTokenAddress(bt, qt)
+	if err != nil {
+		return err
+	}
+
+	ob, err := e.orderBookService.GetRawOrderBook(p)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(ob)
+}
+
+// depthChart returns the cumulative bid and ask curves of a pair given by its symbol (eg.
+// /orderbook/AMP-WETH/depth-chart). The curves are bucketed by step percent from the
+// mid-price, set by the step (default 0.5) and buckets (default 20) query parameters.
+func (e *OrderBookEndpoint) depthChart(c *routing.Context) error {
+	p, err := e.pairService.GetBySymbol(c.Param("pair"))
+	if err != nil {
+		return err
+	}
+
+	step := defaultDepthStep
+	if s := c.Query("step"); s != "" {
+		step, err = strconv.ParseFloat(s, 64)
+		if err != nil || step <= 0 || step > 100 {
+			return errors.InvalidStep.New(nil)
+		}
+	}
+
+	buckets := defaultDepthBuckets
+	if b := c.Query("buckets"); b != "" {
+		buckets, err = strconv.Atoi(b)
+		if err != nil || buckets <= 0 || buckets > maxDepthBuckets {
+			return errors.InvalidBuckets.New(nil)
+		}
+	}
+
+	return c.Write(e.orderBookService.GetDepthChart(p, step, buckets))
+}
+
+// quote returns the expected average and worst fill prices and the slippage of an order
+// against the current orderbook, given by the pair (symbol), side (BUY or SELL) and amount
+// query parameters (eg. /quote?pair=AMP-WETH&side=BUY&amount=10)
+func (e *OrderBookEndpoint) quote(c *routing.Context) error {
+	p, err := e.pairService.GetBySymbol(c.Query("pair"))
+	if err != nil {
+		return err
+	}
+
+	side := strings.ToUpper(c.Query("side"))
+	if side != "BUY" && side != "SELL" {
+		return errors.InvalidSide.New(nil)
+	}
+
+	amount, err := strconv.ParseFloat(c.Query("amount"), 64)
+	if err != nil || amount <= 0 {
+		return errors.InvalidAmount.New(nil)
+	}
+
+	return c.Write(e.orderBookService.GetQuote(p, side, amount))
+}
+
+// memory returns the estimated redis memory used by the orderbook of each pair, largest
+// first (admin only). The memory usage of at most samples keys (default 100) of each
+// orderbook is read and extrapolated to all its keys.
+func (e *OrderBookEndpoint) memory(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	samples := defaultMemorySamples
+	if s := c.Query("samples"); s != "" {
+		var err error
+		samples, err = strconv.Atoi(s)
+		if err != nil || samples < 0 || samples > maxMemorySamples {
+			return errors.InvalidSamples.New(nil)
+		}
+	}
+
+	usage, err := e.orderBookService.GetMemoryUsage(samples)
+	if err != nil {
+		return errors.InternalServerError(err)
+	}
+
+	return c.Write(usage)
+}
+
 func (e *OrderBookEndpoint) orderBookWebSocket(input interface{}, conn *websocket.Conn) {
 	mab, _ := json.Marshal(input)
 	var msg *types.WebSocketSubscription
@@ -55,22 +219,12 @@ func (e *OrderBookEndpoint) orderBookWebSocket(input interface{}, conn *websocke
 	}
 
 	if (msg.Pair.BaseToken == common.Address{}) {
-		message := map[string]string{
-			"Code":    "Invalid_Pair_BaseToken",
-			"Message": "Invalid Pair BaseToken passed in query Params",
-		}
-
-		ws.SendOrderBookErrorMessage(conn, message)
+		ws.SendOrderBookErrorMessage(conn, errors.NewWSError(errors.InvalidPairBaseToken.New(nil)))
 		return
 	}
 
 	if (msg.Pair.QuoteToken == common.Address{}) {
-		message := map[string]string{
-			"Code":    "Invalid_Pair_QuoteToken",
-			"Message": "Invalid Pair QuoteToken passed in query Params",
-		}
-
-		ws.SendOrderBookErrorMessage(conn, message)
+		ws.SendOrderBookErrorMessage(conn, errors.NewWSError(errors.InvalidPairQuoteToken.New(nil)))
 		return
 	}
 
@@ -82,3 +236,25 @@ func (e *OrderBookEndpoint) orderBookWebSocket(input interface{}, conn *websocke
 		e.orderBookService.Unsubscribe(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken)
 	}
 }
+
+// rawOrderBookWebSocket handles the subscriptions to the full orderbook channel
+func (e *OrderBookEndpoint) rawOrderBookWebSocket(input interface{}, conn *websocket.Conn) {
+	mab, _ := json.Marshal(input)
+	var msg *types.WebSocketSubscription
+	if err := json.Unmarshal(mab, &msg); err != nil {
+		log.Println("unmarshal to wsmsg <==>" + err.Error())
+	}
+
+	if (msg.Pair.BaseToken == common.Address{}) || (msg.Pair.QuoteToken == common.Address{}) {
+		ws.SendRawOrderBookErrorMessage(conn, errors.NewWSError(errors.InvalidPair.New(nil)))
+		return
+	}
+
+	if msg.Event == types.SUBSCRIBE {
+		e.orderBookService.SubscribeRawOrderBook(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken)
+	}
+
+	if msg.Event == types.UNSUBSCRIBE {
+		e.orderBookService.UnsubscribeRawOrderBook(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken)
+	}
+}
@@ -1,24 +1,40 @@
 package endpoints
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
 
 	"github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/ws"
 	"github.com/go-ozzo/ozzo-routing"
 )
 
 type pairEndpoint struct {
-	pairService *services.PairService
+	pairService     *services.PairService
+	approvalService *services.ApprovalService
 }
 
 // ServePairResource sets up the routing of pair endpoints and the corresponding handlers.
-func ServePairResource(rg *routing.RouteGroup, pairService *services.PairService) {
-	r := &pairEndpoint{pairService}
+func ServePairResource(rg *routing.RouteGroup, pairService *services.PairService, approvalService *services.ApprovalService) {
+	r := &pairEndpoint{pairService, approvalService}
+	approvalService.Register(types.ActionDelistPair, r.delistPair)
+
 	rg.Get("/pairs/<baseToken>/<quoteToken>", r.get)
 	rg.Get("/pairs", r.query)
 	rg.Post("/pairs", r.create)
+	rg.Delete("/pairs/<baseToken>/<quoteToken>", r.delete)
+	rg.Put("/pairs/<baseToken>/<quoteToken>/symbol", r.rename)
+	rg.Get("/pairs/<baseToken>/<quoteToken>/fees", r.fees)
+	rg.Put("/admin/pairs/<baseToken>/<quoteToken>/fees", r.setFeeOverride)
+	rg.Delete("/admin/pairs/<baseToken>/<quoteToken>/fees", r.removeFeeOverride)
+	rg.Put("/admin/pairs/<baseToken>/<quoteToken>/listing", r.scheduleListing)
+
+	ws.RegisterChannel(ws.MarketChannel, r.marketWebSocket)
 }
 
 func (r *pairEndpoint) create(c *routing.Context) error {
@@ -52,12 +68,12 @@ func (r *pairEndpoint) query(c *routing.Context) error {
 func (r *pairEndpoint) get(c *routing.Context) error {
 	baseToken := c.Param("baseToken")
 	if !common.IsHexAddress(baseToken) {
-		return errors.NewAPIError(400, "INVALID_HEX_ADDRESS", nil)
+		return errors.InvalidHexAddress.New(nil)
 	}
 
 	quoteToken := c.Param("quoteToken")
 	if !common.IsHexAddress(quoteToken) {
-		return errors.NewAPIError(400, "INVALID_HEX_ADDRESS", nil)
+		return errors.InvalidHexAddress.New(nil)
 	}
 
 	baseTokenAddress := common.HexToAddress(baseToken)
@@ -71,6 +87,208 @@ func (r *pairEndpoint) get(c *routing.Context) error {
 	return c.Write(res)
 }
 
+func (r *pairEndpoint) delete(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	baseToken := c.Param("baseToken")
+	if !common.IsHexAddress(baseToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	quoteToken := c.Param("quoteToken")
+	if !common.IsHexAddress(quoteToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	baseTokenAddress := common.HexToAddress(baseToken)
+	quoteTokenAddress := common.HexToAddress(quoteToken)
+
+	// delisting a pair with open orders needs the approval of a second administrator
+	open, err := r.pairService.HasOpenInterest(baseTokenAddress, quoteTokenAddress)
+	if err != nil {
+		return err
+	}
+
+	if open {
+		params := map[string]interface{}{"baseToken": baseTokenAddress.Hex(), "quoteToken": quoteTokenAddress.Hex()}
+		target := baseTokenAddress.Hex() + "/" + quoteTokenAddress.Hex()
+		return requestApproval(c, r.approvalService, types.ActionDelistPair, target, params)
+	}
+
+	err = r.pairService.Delete(baseTokenAddress, quoteTokenAddress)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(map[string]string{"status": "DELETED"})
+}
+
+// delistPair deletes a pair once its delisting is approved
+func (r *pairEndpoint) delistPair(a *types.Approval) error {
+	bt, _ := a.Params["baseToken"].(string)
+	qt, _ := a.Params["quoteToken"].(string)
+
+	return r.pairService.Delete(common.HexToAddress(bt), common.HexToAddress(qt))
+}
+
+// marketWebSocket handles the subscriptions to the catalog changes (token and pair
+// listings, rule changes and delistings)
+func (r *pairEndpoint) marketWebSocket(input interface{}, conn *websocket.Conn) {
+	mab, _ := json.Marshal(input)
+	var msg *types.WebSocketSubscription
+	if err := json.Unmarshal(mab, &msg); err != nil || msg == nil {
+		ws.SendMarketErrorMessage(conn, errors.NewWSError(errors.InvalidSubscription.New(nil)))
+		return
+	}
+
+	if msg.Event == types.SUBSCRIBE {
+		r.pairService.SubscribeMarkets(conn)
+	}
+
+	if msg.Event == types.UNSUBSCRIBE {
+		r.pairService.UnsubscribeMarkets(conn)
+	}
+}
+
+// rename changes the display symbol of a pair. The request body is {"symbol": "AMP-WETH"}
+func (r *pairEndpoint) rename(c *routing.Context) error {
+	baseToken := c.Param("baseToken")
+	if !common.IsHexAddress(baseToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	quoteToken := c.Param("quoteToken")
+	if !common.IsHexAddress(quoteToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	var req struct {
+		Symbol string `json:"symbol"`
+	}
+
+	if err := c.Read(&req); err != nil {
+		return errors.InvalidRequestData.New(nil)
+	}
+
+	baseTokenAddress := common.HexToAddress(baseToken)
+	quoteTokenAddress := common.HexToAddress(quoteToken)
+	res, err := r.pairService.Rename(baseTokenAddress, quoteTokenAddress, req.Symbol)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
+// fees returns the fees currently charged on a pair
+func (r *pairEndpoint) fees(c *routing.Context) error {
+	baseToken := c.Param("baseToken")
+	if !common.IsHexAddress(baseToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	quoteToken := c.Param("quoteToken")
+	if !common.IsHexAddress(quoteToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	res, err := r.pairService.GetFees(common.HexToAddress(baseToken), common.HexToAddress(quoteToken))
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
+// setFeeOverride replaces the fees of a pair. The request body is
+// {"makeFee": 0, "takeFee": 0, "reason": "promotion", "expiresAt": "2018-10-01T00:00:00Z"}
+func (r *pairEndpoint) setFeeOverride(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	baseToken := c.Param("baseToken")
+	if !common.IsHexAddress(baseToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	quoteToken := c.Param("quoteToken")
+	if !common.IsHexAddress(quoteToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	var override types.PairFeeOverride
+	if err := c.Read(&override); err != nil {
+		return errors.InvalidRequestData.New(nil)
+	}
+
+	res, err := r.pairService.SetFeeOverride(common.HexToAddress(baseToken), common.HexToAddress(quoteToken), &override)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
+// removeFeeOverride restores the default fees of a pair
+func (r *pairEndpoint) removeFeeOverride(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	baseToken := c.Param("baseToken")
+	if !common.IsHexAddress(baseToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	quoteToken := c.Param("quoteToken")
+	if !common.IsHexAddress(quoteToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	res, err := r.pairService.RemoveFeeOverride(common.HexToAddress(baseToken), common.HexToAddress(quoteToken))
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
+// scheduleListing changes the go-live time of a scheduled listing. The request body is
+// {"listingTime": "2018-10-01T00:00:00Z"}
+func (r *pairEndpoint) scheduleListing(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	baseToken := c.Param("baseToken")
+	if !common.IsHexAddress(baseToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	quoteToken := c.Param("quoteToken")
+	if !common.IsHexAddress(quoteToken) {
+		return errors.InvalidHexAddress.New(nil)
+	}
+
+	var req struct {
+		ListingTime *time.Time `json:"listingTime"`
+	}
+
+	if err := c.Read(&req); err != nil || req.ListingTime == nil {
+		return errors.InvalidRequestData.New(nil)
+	}
+
+	res, err := r.pairService.ScheduleListing(common.HexToAddress(baseToken), common.HexToAddress(quoteToken), *req.ListingTime)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
 // func (r *pairEndpoint) orderBook(input interface{}, conn *websocket.Conn) {
 // 	mab, _ := json.Marshal(input)
 // 	var msg *types.Subscription
@@ -113,12 +331,12 @@ func (r *pairEndpoint) get(c *routing.Context) error {
 
 // 	bt := c.Param("baseToken")
 // 	if !common.IsHexAddress(bt) {
-// 		return errors.NewAPIError(400, "INVALID_HEX_ADDRESS", nil)
+// 		return errors.InvalidHexAddress.New(nil)
 // 	}
 
 // 	qt := c.Param("quoteToken")
 // 	if !common.IsHexAddress(qt) {
-// 		return errors.NewAPIError(400, "INVALID_HEX_ADDRESS", nil)
+// 		return errors.InvalidHexAddress.New(nil)
 // 	}
 
 // 	baseTokenAddress := common.HexToAddress(bt)
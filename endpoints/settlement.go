@@ -0,0 +1,159 @@
+package endpoints
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-ozzo/ozzo-routing"
+)
+
+type settlementEndpoint struct {
+	settlementService *services.SettlementService
+	approvalService   *services.ApprovalService
+}
+
+// ServeSettlementResource sets up the routing of the settlement admin endpoints and the corresponding handlers.
+func ServeSettlementResource(rg *routing.RouteGroup, settlementService *services.SettlementService, approvalService *services.ApprovalService) {
+	e := &settlementEndpoint{settlementService, approvalService}
+	approvalService.Register(types.ActionBustTrade, e.bustTrade)
+
+	rg.Get("/admin/settlements", e.backlog)
+	rg.Post("/admin/settlements/<hash>/retry", e.retry)
+	rg.Post("/admin/settlements/<hash>/skip", e.skip)
+	rg.Post("/admin/settlements/<hash>/cancel", e.cancel)
+	rg.Get("/admin/stats/settlements", e.costs)
+	rg.Post("/admin/trades/<hash>/bust", e.bust)
+}
+
+func (e *settlementEndpoint) backlog(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	statuses := []string{}
+	if s := c.Query("status"); s != "" {
+		statuses = strings.Split(s, ",")
+	}
+
+	res, err := e.settlementService.GetBacklog(statuses...)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	return c.Write(res)
+}
+
+// costs returns the settlement costs per pair and per day. The range is given by the
+// from and to query params (unix timestamps) and defaults to the last 30 days.
+func (e *settlementEndpoint) costs(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	params := map[string]*time.Time{"from": &from, "to": &to}
+	for name, value := range params {
+		q := c.Query(name)
+		if q == "" {
+			continue
+		}
+
+		ts, err := strconv.ParseInt(q, 10, 64)
+		if err != nil {
+			return errors.InvalidQueryParam(name)
+		}
+
+		*value = time.Unix(ts, 0)
+	}
+
+	res, err := e.settlementService.GetCostStats(from, to)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	return c.Write(res)
+}
+
+func (e *settlementEndpoint) retry(c *routing.Context) error {
+	return e.intervene(c, e.settlementService.Retry)
+}
+
+func (e *settlementEndpoint) skip(c *routing.Context) error {
+	return e.intervene(c, e.settlementService.Skip)
+}
+
+func (e *settlementEndpoint) cancel(c *routing.Context) error {
+	return e.intervene(c, e.settlementService.Cancel)
+}
+
+func (e *settlementEndpoint) intervene(c *routing.Context, fn func(common.Hash, string) (*types.Trade, error)) error {
+	admin, err := adminIdentity(c)
+	if err != nil {
+		return err
+	}
+
+	h := c.Param("hash")
+	if !utils.IsHexHash(h) {
+		return errors.InvalidHash.New(nil)
+	}
+
+	res, err := fn(common.HexToHash(h), admin)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	return c.Write(res)
+}
+
+// bust requests the approval of the bust of an erroneous trade. The request body is
+// {"reason": "..."}, the reason is recorded on the trade and in the audit log.
+func (e *settlementEndpoint) bust(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	h := c.Param("hash")
+	if !utils.IsHexHash(h) {
+		return errors.InvalidHash.New(nil)
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+
+	if err := c.Read(&req); err != nil {
+		return errors.InvalidRequestData.New(nil)
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return errors.BustReasonRequired.New(nil)
+	}
+
+	t, err := e.settlementService.GetBustableTrade(common.HexToHash(h))
+	if err != nil {
+		return err
+	}
+
+	return requestApproval(c, e.approvalService, types.ActionBustTrade, t.Hash.Hex(), map[string]interface{}{"reason": reason})
+}
+
+// bustTrade busts a trade once its bust is approved
+func (e *settlementEndpoint) bustTrade(a *types.Approval) error {
+	reason, _ := a.Params["reason"].(string)
+
+	_, err := e.settlementService.Bust(common.HexToHash(a.Target), reason, a.RequestedBy, a.ReviewedBy)
+	return err
+}
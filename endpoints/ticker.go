@@ -0,0 +1,53 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/ws"
+	"github.com/go-ozzo/ozzo-routing"
+	"github.com/gorilla/websocket"
+)
+
+type tickerEndpoint struct {
+	tickerService *services.TickerService
+}
+
+// ServeTickerResource sets up the routing of the ticker endpoint and channel
+func ServeTickerResource(rg *routing.RouteGroup, tickerService *services.TickerService) {
+	e := &tickerEndpoint{tickerService}
+	rg.Get("/tickers", e.query)
+	ws.RegisterChannel(ws.TickerChannel, e.tickerWebSocket)
+}
+
+// query returns the 24 hour tickers of the active pairs
+func (e *tickerEndpoint) query(c *routing.Context) error {
+	res, err := e.tickerService.GetTickers(formatted(c))
+	if err != nil {
+		log.Print(err)
+		return errors.InternalServerError(err)
+	}
+
+	return c.Write(res)
+}
+
+// tickerWebSocket handles the subscriptions to the 24 hour tickers of all the pairs
+func (e *tickerEndpoint) tickerWebSocket(input interface{}, conn *websocket.Conn) {
+	mab, _ := json.Marshal(input)
+	var msg *types.WebSocketSubscription
+	if err := json.Unmarshal(mab, &msg); err != nil || msg == nil {
+		ws.SendTickerErrorMessage(conn, errors.NewWSError(errors.InvalidSubscription.New(nil)))
+		return
+	}
+
+	if msg.Event == types.SUBSCRIBE {
+		e.tickerService.Subscribe(conn)
+	}
+
+	if msg.Event == types.UNSUBSCRIBE {
+		e.tickerService.Unsubscribe(conn)
+	}
+}
@@ -0,0 +1,22 @@
+package endpoints
+
+import (
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing"
+)
+
+// ServeTimeResource sets up the routing of the server time endpoint
+func ServeTimeResource(rg *routing.RouteGroup) {
+	rg.Get("/time", serverTime)
+}
+
+// serverTime returns the server time, in unix seconds and milliseconds, so that clients
+// can sign their requests with timestamps within the allowed clock skew
+func serverTime(c *routing.Context) error {
+	now := time.Now()
+	return c.Write(map[string]int64{
+		"time":   now.Unix(),
+		"timeMs": now.UnixNano() / int64(time.Millisecond),
+	})
+}
@@ -20,6 +20,9 @@ func ServeTokenResource(rg *routing.RouteGroup, tokenService *services.TokenServ
 	rg.Get("/tokens/<address>", r.get)
 	rg.Get("/tokens", r.query)
 	rg.Post("/tokens", r.create)
+	rg.Post("/tokens/bulk", r.createMany)
+	rg.Put("/tokens/<address>/quote", r.setQuote)
+	rg.Delete("/tokens/<address>", r.delete)
 }
 
 func (r *tokenEndpoint) create(c *routing.Context) error {
@@ -38,8 +41,60 @@ func (r *tokenEndpoint) create(c *routing.Context) error {
 	return c.Write(model)
 }
 
+func (r *tokenEndpoint) createMany(c *routing.Context) error {
+	var model []*types.Token
+	if err := c.Read(&model); err != nil {
+		log.Print(err)
+		return err
+	}
+
+	err := r.tokenService.CreateMany(model)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	return c.Write(model)
+}
+
+func (r *tokenEndpoint) setQuote(c *routing.Context) error {
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidID.New(nil)
+	}
+
+	var model struct {
+		Quote *bool `json:"quote"`
+	}
+
+	if err := c.Read(&model); err != nil {
+		log.Print(err)
+		return err
+	}
+
+	if model.Quote == nil {
+		return errors.InvalidRequestData.New(nil)
+	}
+
+	response, err := r.tokenService.SetQuote(common.HexToAddress(a), *model.Quote)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	return c.Write(response)
+}
+
 func (r *tokenEndpoint) query(c *routing.Context) error {
-	response, err := r.tokenService.GetAll()
+	var response []types.Token
+	var err error
+
+	if c.Query("quote") == "true" {
+		response, err = r.tokenService.GetQuoteTokens()
+	} else {
+		response, err = r.tokenService.GetAll()
+	}
+
 	if err != nil {
 		log.Print(err)
 		return err
@@ -51,7 +106,7 @@ func (r *tokenEndpoint) query(c *routing.Context) error {
 func (r *tokenEndpoint) get(c *routing.Context) error {
 	a := c.Param("address")
 	if !common.IsHexAddress(a) {
-		return errors.NewAPIError(400, "INVALID_ID", nil)
+		return errors.InvalidID.New(nil)
 	}
 
 	tokenAddress := common.HexToAddress(a)
@@ -63,3 +118,23 @@ func (r *tokenEndpoint) get(c *routing.Context) error {
 
 	return c.Write(response)
 }
+
+func (r *tokenEndpoint) delete(c *routing.Context) error {
+	if _, err := adminIdentity(c); err != nil {
+		return err
+	}
+
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidID.New(nil)
+	}
+
+	tokenAddress := common.HexToAddress(a)
+	err := r.tokenService.Delete(tokenAddress)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	return c.Write(map[string]string{"status": "DELETED"})
+}
@@ -15,7 +15,7 @@ package endpoints
 
 // func TestToken(t *testing.T) {
 // 	router := newRouter()
-// 	ServeTokenResource(&router.RouteGroup, services.NewTokenService(daos.NewTokenDao()))
+// 	ServeTokenResource(&router.RouteGroup, services.NewTokenService(daos.NewTokenDao(), daos.NewPairDao(), nil))
 
 // 	// notFoundError := `{"error_code":"NOT_FOUND", "message":"NOT_FOUND"}`
 // 	// nameRequiredError := `{"error_code":"INVALID_DATA","message":"INVALID_DATA","details":[{"field":"name","error":"cannot be blank"}]}`
@@ -3,6 +3,9 @@ package endpoints
 import (
 	"encoding/json"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/services"
@@ -11,59 +14,153 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-ozzo/ozzo-routing"
 	"github.com/gorilla/websocket"
+	"gopkg.in/mgo.v2/bson"
 )
 
 type tradeEndpoint struct {
 	tradeService *services.TradeService
+	pairService  *services.PairService
 }
 
 // ServeTradeResource sets up the routing of trade endpoints and the corresponding handlers.
-func ServeTradeResource(rg *routing.RouteGroup, tradeService *services.TradeService) {
-	e := &tradeEndpoint{tradeService}
+func ServeTradeResource(rg *routing.RouteGroup, tradeService *services.TradeService, pairService *services.PairService) {
+	e := &tradeEndpoint{tradeService, pairService}
+	rg.Get("/trades/pair/<bt>/<qt>", e.history)
 	rg.Get("/trades/history/<bt>/<qt>", e.history)
+	rg.Get("/trades/history/<pair>", e.historyBySymbol)
 	rg.Get("/trades/<addr>", e.get)
 
 	ws.RegisterChannel(ws.TradeChannel, e.tradeWebSocket)
+	ws.RegisterChannel(ws.UserChannel, e.userWebSocket)
 }
 
-// history is reponsible for handling pair's trade history requests
+// history is reponsible for handling pair's trade history requests. The trades are
+// paginated and filtered with the query params of parseTradeQuery.
 func (r *tradeEndpoint) history(c *routing.Context) error {
 	bt := c.Param("bt")
 	if !common.IsHexAddress(bt) {
-		return errors.NewAPIError(400, "INVALID_HEX_ADDRESS", nil)
+		return errors.InvalidHexAddress.New(nil)
 	}
 
 	qt := c.Param("qt")
 	if !common.IsHexAddress(qt) {
-		return errors.NewAPIError(400, "INVALID_HEX_ADDRESS", nil)
+		return errors.InvalidHexAddress.New(nil)
 	}
 
-	baseToken := common.HexToAddress(bt)
-	quoteToken := common.HexToAddress(qt)
-	response, err := r.tradeService.GetByPairAddress(baseToken, quoteToken)
+	q, err := parseTradeQuery(c)
 	if err != nil {
 		return err
 	}
 
-	return c.Write(response)
+	q.BaseToken = common.HexToAddress(bt)
+	q.QuoteToken = common.HexToAddress(qt)
+	return r.writeTrades(c, q)
+}
+
+// historyBySymbol is reponsible for handling pair's trade history requests where
+// the pair is given by its symbol (eg. /trades/history/AMP-WETH)
+func (r *tradeEndpoint) historyBySymbol(c *routing.Context) error {
+	p, err := r.pairService.GetBySymbol(c.Param("pair"))
+	if err != nil {
+		return err
+	}
+
+	q, err := parseTradeQuery(c)
+	if err != nil {
+		return err
+	}
+
+	q.BaseToken, q.QuoteToken = p.BaseTokenAddress, p.QuoteTokenAddress
+	return r.writeTrades(c, q)
 }
 
-// get is reponsible for handling user's trade history requests
+// get is reponsible for handling user's trade history requests. The side filter is the
+// side of the account in the trades.
 func (r *tradeEndpoint) get(c *routing.Context) error {
 	addr := c.Param("addr")
 	if !common.IsHexAddress(addr) {
-		return errors.NewAPIError(400, "INVALID_ADDRESS", nil)
+		return errors.InvalidAddress.New(nil)
 	}
 
-	address := common.HexToAddress(addr)
-	response, err := r.tradeService.GetByUserAddress(address)
+	q, err := parseTradeQuery(c)
 	if err != nil {
 		return err
 	}
 
+	q.UserAddress = common.HexToAddress(addr)
+	return r.writeTrades(c, q)
+}
+
+// writeTrades writes the page of trades of a trade history request
+func (r *tradeEndpoint) writeTrades(c *routing.Context, q *types.TradeQuery) error {
+	response, err := r.tradeService.Query(q)
+	if err != nil {
+		log.Print(err)
+		return errors.InternalServerError(err)
+	}
+
+	if formatted(c) {
+		r.tradeService.SetFormatted(response)
+	}
+
 	return c.Write(response)
 }
 
+// parseTradeQuery returns the filters and the cursors of a trade history request: the
+// side (BUY or SELL), the from and to unix timestamps, the before and after trade ids
+// and the limit (100 by default, up to 1000)
+func parseTradeQuery(c *routing.Context) (*types.TradeQuery, error) {
+	q := &types.TradeQuery{}
+
+	if s := c.Query("side"); s != "" {
+		q.Side = strings.ToUpper(s)
+		if q.Side != "BUY" && q.Side != "SELL" {
+			return nil, errors.InvalidSide.New(nil)
+		}
+	}
+
+	times := map[string]*time.Time{"from": &q.From, "to": &q.To}
+	for key, value := range times {
+		if t := c.Query(key); t != "" {
+			ts, err := strconv.ParseInt(t, 10, 64)
+			if err != nil {
+				return nil, errors.InvalidQueryParam(key)
+			}
+
+			*value = time.Unix(ts, 0)
+		}
+	}
+
+	cursors := map[string]*bson.ObjectId{"before": &q.Before, "after": &q.After}
+	for key, value := range cursors {
+		if id := c.Query(key); id != "" {
+			if !bson.IsObjectIdHex(id) {
+				return nil, errors.InvalidQueryParam(key)
+			}
+
+			*value = bson.ObjectIdHex(id)
+		}
+	}
+
+	if l := c.Query("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			return nil, errors.InvalidQueryParam("limit")
+		}
+
+		q.Limit = limit
+	}
+
+	return q, nil
+}
+
+// formatted returns true if the client requested display formatted prices and
+// amounts with the formatted query parameter (eg. ?formatted=true)
+func formatted(c *routing.Context) bool {
+	f, _ := strconv.ParseBool(c.Query("formatted"))
+	return f
+}
+
 func (e *tradeEndpoint) tradeWebSocket(input interface{}, conn *websocket.Conn) {
 	mab, _ := json.Marshal(input)
 	var msg *types.WebSocketSubscription
@@ -72,28 +169,44 @@ func (e *tradeEndpoint) tradeWebSocket(input interface{}, conn *websocket.Conn)
 	}
 
 	if (msg.Pair.BaseToken == common.Address{}) {
-		message := map[string]string{
-			"Code":    "Invalid_Pair_BaseToken",
-			"Message": "Invalid Pair BaseToken passed in Params",
-		}
-		ws.SendTradeErrorMessage(conn, message)
+		ws.SendTradeErrorMessage(conn, errors.NewWSError(errors.InvalidPairBaseToken.New(nil)))
 		return
 	}
 
 	if (msg.Pair.QuoteToken == common.Address{}) {
-		message := map[string]string{
-			"Code":    "Invalid_Pair_BaseToken",
-			"Message": "Invalid Pair BaseToken passed in Params",
-		}
-		ws.SendTradeErrorMessage(conn, message)
+		ws.SendTradeErrorMessage(conn, errors.NewWSError(errors.InvalidPairQuoteToken.New(nil)))
 		return
 	}
 
 	if msg.Event == types.SUBSCRIBE {
-		e.tradeService.Subscribe(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken)
+		e.tradeService.Subscribe(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken, &msg.Params)
 	}
 
 	if msg.Event == types.UNSUBSCRIBE {
 		e.tradeService.Unsubscribe(conn, msg.Pair.BaseToken, msg.Pair.QuoteToken)
 	}
 }
+
+// userWebSocket handles the subscriptions to the fills of an account. The subscription
+// message must be signed by the account.
+func (e *tradeEndpoint) userWebSocket(input interface{}, conn *websocket.Conn) {
+	mab, _ := json.Marshal(input)
+	var msg *types.UserSubscription
+	if err := json.Unmarshal(mab, &msg); err != nil || msg == nil {
+		ws.SendUserErrorMessage(conn, errors.NewWSError(errors.InvalidSubscription.New(nil)))
+		return
+	}
+
+	if (msg.Address == common.Address{}) {
+		ws.SendUserErrorMessage(conn, errors.NewWSError(errors.InvalidAddress.New(nil)))
+		return
+	}
+
+	if msg.Event == types.SUBSCRIBE {
+		e.tradeService.SubscribeUser(conn, msg)
+	}
+
+	if msg.Event == types.UNSUBSCRIBE {
+		e.tradeService.UnsubscribeUser(conn, msg.Address)
+	}
+}
@@ -0,0 +1,71 @@
+package endpoints
+
+import (
+	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-ozzo/ozzo-routing"
+)
+
+type withdrawEndpoint struct {
+	withdrawService *services.WithdrawService
+}
+
+// ServeWithdrawResource sets up the routing of withdraw endpoints and the corresponding handlers.
+func ServeWithdrawResource(rg *routing.RouteGroup, withdrawService *services.WithdrawService) {
+	e := &withdrawEndpoint{withdrawService}
+	rg.Post("/withdraws", e.create)
+	rg.Get("/withdraws/<hash>", e.getByHash)
+	rg.Get("/withdraws/address/<address>", e.getByAddress)
+}
+
+// create executes a withdrawal request signed by the trader. The withdrawal is returned
+// with its status, its updates are sent on the balances websocket channel.
+func (e *withdrawEndpoint) create(c *routing.Context) error {
+	w := &types.Withdraw{}
+	if err := c.Read(w); err != nil {
+		return errors.InvalidWithdraw.New(errors.Params{"error": err.Error()})
+	}
+
+	res, err := e.withdrawService.Create(w)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(res)
+}
+
+func (e *withdrawEndpoint) getByHash(c *routing.Context) error {
+	h := c.Param("hash")
+	if !utils.IsHexHash(h) {
+		return errors.InvalidHash.New(errors.Params{"hash": h})
+	}
+
+	w, err := e.withdrawService.GetByHash(common.HexToHash(h))
+	if err != nil {
+		return errors.FetchError.New(errors.Params{"error": err.Error()})
+	}
+
+	if w == nil {
+		return errors.WithdrawNotFound.New(nil)
+	}
+
+	return c.Write(w)
+}
+
+// getByAddress returns the withdrawals of an account, most recent first
+func (e *withdrawEndpoint) getByAddress(c *routing.Context) error {
+	a := c.Param("address")
+	if !common.IsHexAddress(a) {
+		return errors.InvalidAddress.New(nil)
+	}
+
+	res, err := e.withdrawService.GetByTrader(common.HexToAddress(a))
+	if err != nil {
+		return errors.FetchError.New(errors.Params{"error": err.Error()})
+	}
+
+	return c.Write(res)
+}
@@ -0,0 +1,370 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// Backends storing the orderbooks matched by the engine:
+// - RedisBackend matches the orders directly on the orderbooks stored in redis, every
+// change is durable once the order is matched
+// - MemoryBackend matches the orders on in-memory orderbooks loaded from redis and writes
+// the changes back to redis every snapshot interval, the changes of the last interval
+// are lost if the matcher crashes
+const (
+	RedisBackend  = "redis"
+	MemoryBackend = "memory"
+)
+
+// OrderBook stores the orderbooks of the pairs matched by the engine. All the methods but
+// Depth, Levels and Orders are called with the engine lock held.
+type OrderBook interface {
+	// Match matches an order against the orderbook of its pair in price-time priority.
+	// The order is added to the orderbook if it does not match any order.
+	Match(order *types.Order) (*Response, error)
+	// Add adds an order to the orderbook of its pair without matching it
+	Add(order *types.Order) error
+	// Cancel removes an order from the orderbook of its pair
+	Cancel(order *types.Order) (*Response, error)
+	// Depth returns the volume of the price levels of both sides of the orderbook of a pair
+	Depth(pair *types.Pair) (sellBook, buyBook []*map[string]float64)
+	// Levels returns the price levels of both sides of the orderbook of a pair, best price
+	// first. It is called without the engine lock, as Depth.
+	Levels(pair *types.Pair) (asks, bids []*types.BookLevel, err error)
+	// Orders returns the resting orders of both sides of the orderbook of a pair, best
+	// price first and in time priority within a price level. It is called without the
+	// engine lock, as Depth.
+	Orders(pair *types.Pair) (asks, bids []*types.Order, err error)
+	// Sync runs fn on the orderbook of a pair stored in redis. It is used by the
+	// operations reading or writing the redis orderbook directly (eg. the self-trade
+	// prevention or the orderbook restore). prefix is the redis key prefix of the pair.
+	Sync(prefix string, fn func() error) error
+}
+
+// orderBook returns the orderbook backend of the engine, the redis orderbook if no
+// backend is set
+func (e *Resource) orderBook() OrderBook {
+	if e.book == nil {
+		return &redisOrderBook{e}
+	}
+
+	return e.book
+}
+
+// SetOrderBookBackend selects the backend storing the orderbooks. The memory backend
+// writes its changes to redis with writerConn every snapshotInterval.
+func (e *Resource) SetOrderBookBackend(backend string, writerConn redis.Conn, snapshotInterval time.Duration) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	switch backend {
+	case "", RedisBackend:
+		e.book = &redisOrderBook{e}
+	case MemoryBackend:
+		if snapshotInterval <= 0 {
+			return fmt.Errorf("Invalid orderbook snapshot interval %s", snapshotInterval)
+		}
+
+		book := newMemoryOrderBook(e, NewBookWriter(e.fence(writerConn)))
+		book.writer.Start(snapshotInterval, nil)
+		e.book = book
+	default:
+		return fmt.Errorf("Invalid orderbook backend %q", backend)
+	}
+
+	e.updatePairResources()
+	return nil
+}
+
+// redisOrderBook matches the orders on the orderbooks stored in redis
+type redisOrderBook struct {
+	e *Resource
+}
+
+func (b *redisOrderBook) Match(order *types.Order) (*Response, error) {
+	if order.Side == "SELL" {
+		return b.e.sellOrder(order)
+	}
+
+	return b.e.buyOrder(order)
+}
+
+func (b *redisOrderBook) Add(order *types.Order) error {
+	return b.e.addOrder(order)
+}
+
+func (b *redisOrderBook) Cancel(order *types.Order) (*Response, error) {
+	return b.e.cancelOrder(order)
+}
+
+func (b *redisOrderBook) Depth(pair *types.Pair) (sellBook, buyBook []*map[string]float64) {
+	return b.e.redisDepth(pair)
+}
+
+func (b *redisOrderBook) Levels(pair *types.Pair) (asks, bids []*types.BookLevel, err error) {
+	return b.e.redisLevels(pair)
+}
+
+func (b *redisOrderBook) Orders(pair *types.Pair) (asks, bids []*types.Order, err error) {
+	return b.e.redisOrders(pair)
+}
+
+func (b *redisOrderBook) Sync(prefix string, fn func() error) error {
+	return fn()
+}
+
+// memoryOrderBook matches the orders on in-memory orderbooks. The orderbook of a pair is
+// loaded from redis when it is first used and its changes are written back to redis by
+// the writer. The orders that would be self-trades and the operations on the redis
+// orderbook are run on redis after the pending changes are written, the orderbook of the
+// pair is then loaded again from redis.
+type memoryOrderBook struct {
+	e      *Resource
+	writer *BookWriter
+	// mutex guards books against the depth reads, which are made without the engine lock
+	mutex sync.Mutex
+	books map[string]*MemoryBook
+}
+
+func newMemoryOrderBook(e *Resource, writer *BookWriter) *memoryOrderBook {
+	return &memoryOrderBook{e: e, writer: writer, books: make(map[string]*MemoryBook)}
+}
+
+// book returns the orderbook of the pair of a redis key prefix, loaded from redis if it
+// is not in memory
+func (m *memoryOrderBook) book(prefix string) (*MemoryBook, error) {
+	if b, ok := m.books[prefix]; ok {
+		return b, nil
+	}
+
+	b, err := m.writer.Load(prefix)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	m.books[prefix] = b
+	return b, nil
+}
+
+func (m *memoryOrderBook) Match(order *types.Order) (*Response, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	b, err := m.book(order.GetKVPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	if m.e.selfTrade != "" && m.crossesMaker(b, order) {
+		var resp *Response
+		err := m.sync(order.GetKVPrefix(), func() (err error) {
+			resp, err = (&redisOrderBook{m.e}).Match(order)
+			return
+		})
+
+		return resp, err
+	}
+
+	return b.Match(order), nil
+}
+
+// crossesMaker returns true if an order would match an order of the orderbook for which
+// the self-trade prevention applies
+func (m *memoryOrderBook) crossesMaker(b *MemoryBook, order *types.Order) bool {
+	opposite := b.asks
+	if order.Side == "SELL" {
+		opposite = b.bids
+	}
+
+	found := false
+	opposite.each(func(l *priceLevel) bool {
+		if !crosses(order, l.price) {
+			return false
+		}
+
+		for el := l.orders.Front(); el != nil; el = el.Next() {
+			if m.e.isSelfTrade(order, el.Value.(*types.Order)) {
+				found = true
+				return false
+			}
+		}
+
+		return true
+	})
+
+	return found
+}
+
+func (m *memoryOrderBook) Add(order *types.Order) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	b, err := m.book(order.GetKVPrefix())
+	if err != nil {
+		return err
+	}
+
+	b.Add(order)
+	return nil
+}
+
+func (m *memoryOrderBook) Cancel(order *types.Order) (*Response, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	b, err := m.book(order.GetKVPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	stored := b.Remove(order.Hash)
+	if stored == nil {
+		return nil, errors.New("Order not found")
+	}
+
+	stored.Status = "CANCELLED"
+	engineResponse := &Response{
+		Order:          stored,
+		Trades:         make([]*types.Trade, 0),
+		RemainingOrder: &types.Order{},
+		FillStatus:     CANCELLED,
+		MatchingOrders: make([]*FillOrder, 0),
+	}
+
+	return engineResponse, nil
+}
+
+func (m *memoryOrderBook) Depth(pair *types.Pair) (sellBook, buyBook []*map[string]float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	b, err := m.book(pairPrefix(pair))
+	if err != nil {
+		return
+	}
+
+	pricepoints, volumes := b.Depth("SELL", 0)
+	for i := range pricepoints {
+		sellBook = append(sellBook, depthLevel(pricepoints[i], volumes[i].Int64()))
+	}
+
+	pricepoints, volumes = b.Depth("BUY", 0)
+	for i := range pricepoints {
+		buyBook = append(buyBook, depthLevel(pricepoints[i], volumes[i].Int64()))
+	}
+
+	return
+}
+
+func (m *memoryOrderBook) Levels(pair *types.Pair) (asks, bids []*types.BookLevel, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	b, err := m.book(pairPrefix(pair))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b.Levels("SELL"), b.Levels("BUY"), nil
+}
+
+func (m *memoryOrderBook) Orders(pair *types.Pair) (asks, bids []*types.Order, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	b, err := m.book(pairPrefix(pair))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b.Orders("SELL"), b.Orders("BUY"), nil
+}
+
+func (m *memoryOrderBook) Sync(prefix string, fn func() error) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.sync(prefix, fn)
+}
+
+// sync writes the pending changes to redis, runs fn and drops the orderbook of the pair
+// so that it is loaded again from redis. The lock of the orderbooks must be held by the
+// caller.
+func (m *memoryOrderBook) sync(prefix string, fn func() error) error {
+	err := m.writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	delete(m.books, prefix)
+	return fn()
+}
+
+// pairPrefix returns the redis key prefix of the orderbook of a pair
+func pairPrefix(pair *types.Pair) string {
+	return pair.BaseTokenAddress.Hex() + "::" + pair.QuoteTokenAddress.Hex()
+}
+
+// depthLevel returns the volume and the price of a price level of the orderbook depth
+func depthLevel(pricepoint, volume int64) *map[string]float64 {
+	return &map[string]float64{
+		"volume": float64(volume) / math.Pow10(8),
+		"price":  float64(pricepoint) / math.Pow10(8),
+	}
+}
+
+// Load reads the orderbook of the pair of a redis key prefix from redis. The orders of a
+// price level are loaded in the order of their redis list.
+func (w *BookWriter) Load(prefix string) (*MemoryBook, error) {
+	w.flushing.Lock()
+	defer w.flushing.Unlock()
+
+	b := NewMemoryBook(nil)
+	for _, side := range []string{"BUY", "SELL"} {
+		ssKey := prefix + "::" + side
+		pricePoints, err := redis.Strings(w.redisConn.Do("ZRANGE", ssKey, 0, -1))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pp := range pricePoints {
+			listKey := ssKey + "::" + pp
+			hashes, err := redis.Strings(w.redisConn.Do("ZRANGE", listKey, 0, -1))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, hash := range hashes {
+				bytes, err := redis.Bytes(w.redisConn.Do("GET", listKey+"::"+hash))
+				if err == redis.ErrNil {
+					continue
+				}
+
+				if err != nil {
+					return nil, err
+				}
+
+				o := &types.Order{}
+				err = json.Unmarshal(bytes, o)
+				if err != nil {
+					return nil, err
+				}
+
+				b.Add(o)
+			}
+		}
+	}
+
+	b.writer = w
+	return b, nil
+}
@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// bookBackends are the orderbook backends run through the conformance tests
+var bookBackends = map[string]func(e *Resource) OrderBook{
+	RedisBackend: func(e *Resource) OrderBook {
+		return &redisOrderBook{e}
+	},
+	MemoryBackend: func(e *Resource) OrderBook {
+		return newMemoryOrderBook(e, NewBookWriter(e.redisConn))
+	},
+}
+
+// testBackends runs a conformance test against each orderbook backend
+func testBackends(t *testing.T, test func(t *testing.T, e *Resource)) {
+	for name, newBook := range bookBackends {
+		t.Run(name, func(t *testing.T) {
+			e := getResource()
+			defer flushData(e.redisConn)
+
+			e.book = newBook(e)
+			test(t, e)
+		})
+	}
+}
+
+var conformancePair = &types.Pair{
+	Name:              "ZRX/WETH",
+	BaseTokenAddress:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+	QuoteTokenAddress: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+}
+
+// redisBook returns the depth of the orderbook of the conformance pair stored in redis
+func redisBook(t *testing.T, e *Resource) (sells, buys []*map[string]float64) {
+	err := e.orderBook().Sync(pairPrefix(conformancePair), func() error {
+		sells, buys = e.redisDepth(conformancePair)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestOrderBookMatch(t *testing.T) {
+	testBackends(t, func(t *testing.T, e *Resource) {
+		book := e.orderBook()
+		first := listingOrder("SELL", 229999999, "0x1")
+		second := listingOrder("SELL", 229999999, "0x2")
+		book.Add(first)
+		book.Add(second)
+
+		sells, buys := book.Depth(conformancePair)
+		assert.Equal(t, 1, len(sells))
+		assert.Equal(t, 0, len(buys))
+		assert.Equal(t, float64(120), (*sells[0])["volume"])
+
+		taker := listingOrder("BUY", 229999999, "0x3")
+		taker.UserAddress = common.HexToAddress("0x1")
+		taker.Amount = big.NewInt(9000000000)
+
+		res, err := book.Match(taker)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, FULL, res.FillStatus)
+		assert.Equal(t, "FILLED", taker.Status)
+		assert.Equal(t, 2, len(res.Trades))
+		assert.Equal(t, first.Hash, res.Trades[0].OrderHash)
+		assert.Equal(t, big.NewInt(6000000000), res.Trades[0].Amount)
+		assert.Equal(t, big.NewInt(3000000000), res.Trades[1].Amount)
+		assert.Equal(t, "FILLED", res.MatchingOrders[0].Order.Status)
+		assert.Equal(t, "PARTIAL_FILLED", res.MatchingOrders[1].Order.Status)
+
+		sells, _ = book.Depth(conformancePair)
+		assert.Equal(t, float64(30), (*sells[0])["volume"])
+
+		// the orderbook stored in redis is the same once the changes are written
+		sells, _ = redisBook(t, e)
+		assert.Equal(t, float64(30), (*sells[0])["volume"])
+
+		// an order that does not match rests in the orderbook
+		bid := listingOrder("BUY", 100000000, "0x4")
+		res, err = book.Match(bid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, NOMATCH, res.FillStatus)
+		assert.Equal(t, "OPEN", bid.Status)
+
+		_, buys = book.Depth(conformancePair)
+		assert.Equal(t, float64(60), (*buys[0])["volume"])
+		assert.Equal(t, float64(1), (*buys[0])["price"])
+	})
+}
+
+func TestOrderBookCancel(t *testing.T) {
+	testBackends(t, func(t *testing.T, e *Resource) {
+		book := e.orderBook()
+		o := listingOrder("BUY", 100000000, "0x1")
+		book.Add(o)
+
+		res, err := book.Cancel(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, CANCELLED, res.FillStatus)
+		assert.Equal(t, "CANCELLED", res.Order.Status)
+		assert.Equal(t, o.Hash, res.Order.Hash)
+
+		_, err = book.Cancel(o)
+		assert.NotNil(t, err)
+
+		_, buys := book.Depth(conformancePair)
+		assert.Equal(t, 0, len(buys))
+
+		_, buys = redisBook(t, e)
+		assert.Equal(t, 0, len(buys))
+		assert.False(t, inBook(e, o))
+	})
+}
+
+func TestOrderBookSelfTrade(t *testing.T) {
+	testBackends(t, func(t *testing.T, e *Resource) {
+		e.selfTrade = CancelNewest
+
+		book := e.orderBook()
+		maker := listingOrder("SELL", 229999999, "0x1")
+		book.Add(maker)
+
+		taker := listingOrder("BUY", 229999999, "0x2")
+		res, err := book.Match(taker)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, CANCELLED, res.FillStatus)
+		assert.Equal(t, "CANCELLED", taker.Status)
+		assert.Equal(t, maker.Hash, res.SelfTradeOrder.Hash)
+
+		sells, buys := book.Depth(conformancePair)
+		assert.Equal(t, float64(60), (*sells[0])["volume"])
+		assert.Equal(t, 0, len(buys))
+	})
+}
+
+// TestOrderBookSync checks that the operations made on the redis orderbook are seen by
+// the backend
+func TestOrderBookSync(t *testing.T) {
+	testBackends(t, func(t *testing.T, e *Resource) {
+		book := e.orderBook()
+		o := listingOrder("SELL", 229999999, "0x1")
+		book.Add(o)
+
+		res, err := e.ReduceOrder(o, big.NewInt(1000000000))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, PARTIAL, res.FillStatus)
+
+		sells, _ := book.Depth(conformancePair)
+		assert.Equal(t, float64(50), (*sells[0])["volume"])
+
+		taker := listingOrder("BUY", 229999999, "0x2")
+		taker.UserAddress = common.HexToAddress("0x1")
+		res, err = book.Match(taker)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, PARTIAL, res.FillStatus)
+		assert.Equal(t, big.NewInt(5000000000), res.Trades[0].Amount)
+
+		sells, _ = book.Depth(conformancePair)
+		assert.Equal(t, 0, len(sells))
+	})
+}
+
+func TestSetOrderBookBackend(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	assert.NotNil(t, e.SetOrderBookBackend("leveldb", nil, 0))
+	assert.NotNil(t, e.SetOrderBookBackend(MemoryBackend, e.redisConn, 0))
+	assert.Nil(t, e.SetOrderBookBackend(RedisBackend, nil, 0))
+	assert.IsType(t, &redisOrderBook{}, e.orderBook())
+}
+
+func TestOrderBookOrders(t *testing.T) {
+	testBackends(t, func(t *testing.T, e *Resource) {
+		book := e.orderBook()
+		orders := []*types.Order{
+			listingOrder("SELL", 230000000, "0x1"),
+			listingOrder("SELL", 229999999, "0x2"),
+			listingOrder("SELL", 229999999, "0x3"),
+			listingOrder("BUY", 200000000, "0x4"),
+			listingOrder("BUY", 210000000, "0x5"),
+		}
+
+		for i, o := range orders {
+			o.Sequence = uint64(i + 1)
+			book.Add(o)
+		}
+
+		asks, bids, err := book.Orders(conformancePair)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// best price first, then time priority within a price level
+		assert.Equal(t, 3, len(asks))
+		assert.Equal(t, orders[1].Hash, asks[0].Hash)
+		assert.Equal(t, orders[2].Hash, asks[1].Hash)
+		assert.Equal(t, orders[0].Hash, asks[2].Hash)
+		assert.Equal(t, 2, len(bids))
+		assert.Equal(t, orders[4].Hash, bids[0].Hash)
+		assert.Equal(t, orders[3].Hash, bids[1].Hash)
+	})
+}
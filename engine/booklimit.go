@@ -0,0 +1,218 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// Policies applied when an order would rest in the orderbook of a pair that holds its
+// maximum number of orders:
+// - RejectFarOrders rejects the order
+// - EvictFarOrders cancels the order furthest from the touch on the side of the order
+// EvictFarOrders also rejects the order if it is not closer to the touch than the
+// furthest order of its side.
+const (
+	RejectFarOrders = "reject"
+	EvictFarOrders  = "evict"
+)
+
+// evictionsKey is the redis hash counting the orders evicted from the orderbook of each
+// pair. It is shared by the API and the matchers.
+const evictionsKey = "engine::evictions"
+
+// OrderBookLimit caps the number of orders of the orderbook of a pair
+type OrderBookLimit struct {
+	// MaxOrders is the number of orders of the orderbook (bids and asks). Not limited if 0
+	MaxOrders int
+	Policy    string
+}
+
+// OrderBookFullError is returned when an order can not rest in the orderbook of its
+// pair because the orderbook holds its maximum number of orders
+type OrderBookFullError struct {
+	Pair      string
+	MaxOrders int
+}
+
+func (e *OrderBookFullError) Error() string {
+	return fmt.Sprintf("ORDERBOOK_FULL: the orderbook of %s holds the maximum of %d orders", e.Pair, e.MaxOrders)
+}
+
+// SetOrderBookLimits sets the maximum size of the orderbooks by pair name. The limit of
+// the "" pair applies to the pairs without limit.
+func (e *Resource) SetOrderBookLimits(limits map[string]OrderBookLimit) error {
+	for pair, l := range limits {
+		switch l.Policy {
+		case RejectFarOrders, EvictFarOrders:
+		default:
+			return fmt.Errorf("Invalid orderbook limit policy %q of pair %q", l.Policy, pair)
+		}
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.bookLimits = limits
+	e.updatePairResources()
+	return nil
+}
+
+// orderBookLimit returns the size limit of the orderbook of a pair
+func (e *Resource) orderBookLimit(pairName string) OrderBookLimit {
+	if l, ok := e.bookLimits[pairName]; ok {
+		return l
+	}
+
+	return e.bookLimits[""]
+}
+
+// GetEvictions returns the number of orders evicted from the orderbook of each pair
+func (e *Resource) GetEvictions() (map[string]int64, error) {
+	return redis.Int64Map(e.redisConn.Do("HGETALL", evictionsKey))
+}
+
+// limitOrderBook applies the size limit of the orderbook of the pair of an order before
+// the order is matched. Orders crossing the orderbook are always matched, the limit
+// applies to their remainder when it is put back in the orderbook (remainder set). The
+// orderbook orders evicted to make room for the order are returned, an
+// OrderBookFullError is returned if the order can not rest in the orderbook. The engine
+// lock must be held by the caller.
+func (e *Resource) limitOrderBook(order *types.Order, remainder bool) ([]*types.Order, error) {
+	limit := e.orderBookLimit(order.PairName)
+	if limit.MaxOrders <= 0 {
+		return nil, nil
+	}
+
+	var evicted []*types.Order
+	err := e.orderBook().Sync(order.GetKVPrefix(), func() error {
+		if !remainder {
+			crossing, err := e.crossesBook(order)
+			if err != nil || crossing {
+				return err
+			}
+		}
+
+		var err error
+		evicted, err = e.limitRedisOrderBook(order, limit)
+		return err
+	})
+
+	return evicted, err
+}
+
+// limitRedisOrderBook applies the size limit of the orderbook of the pair of an order on
+// the redis orderbook
+func (e *Resource) limitRedisOrderBook(order *types.Order, limit OrderBookLimit) ([]*types.Order, error) {
+	count, err := e.countOrders(order)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	if count < limit.MaxOrders {
+		return nil, nil
+	}
+
+	full := &OrderBookFullError{order.PairName, limit.MaxOrders}
+	if limit.Policy != EvictFarOrders {
+		return nil, full
+	}
+
+	furthest, err := e.furthestOrder(order)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	if furthest == nil || !closerToTouch(order, furthest) {
+		return nil, full
+	}
+
+	res, err := e.cancelOrder(furthest)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	_, err = e.redisConn.Do("HINCRBY", evictionsKey, order.PairName, 1)
+	if err != nil {
+		log.Print(err)
+	}
+
+	return []*types.Order{res.Order}, nil
+}
+
+// countOrders returns the number of orders of the orderbook of the pair of an order
+func (e *Resource) countOrders(order *types.Order) (int, error) {
+	ssKey, _ := order.GetOBKeys()
+
+	count := 0
+	for _, key := range []string{ssKey, order.GetOBMatchKey()} {
+		pricePoints, err := redis.Strings(e.redisConn.Do("ZRANGE", key, 0, -1))
+		if err != nil {
+			return 0, err
+		}
+
+		for _, pp := range pricePoints {
+			n, err := redis.Int(e.redisConn.Do("ZCARD", key+"::"+pp))
+			if err != nil {
+				return 0, err
+			}
+
+			count += n
+		}
+	}
+
+	return count, nil
+}
+
+// furthestOrder returns the order of the orderbook furthest from the touch on the side
+// of an order: the most recent order of the lowest bid or of the highest ask. It returns
+// nil if that side of the orderbook is empty.
+func (e *Resource) furthestOrder(order *types.Order) (*types.Order, error) {
+	ssKey, _ := order.GetOBKeys()
+
+	index := 0
+	if order.Side == "SELL" {
+		index = -1
+	}
+
+	pricePoints, err := redis.Strings(e.redisConn.Do("ZRANGE", ssKey, index, index))
+	if err != nil || len(pricePoints) == 0 {
+		return nil, err
+	}
+
+	listKey := ssKey + "::" + pricePoints[0]
+	hashes, err := redis.Strings(e.redisConn.Do("ZRANGE", listKey, -1, -1))
+	if err != nil || len(hashes) == 0 {
+		return nil, err
+	}
+
+	res, err := redis.Bytes(e.redisConn.Do("GET", listKey+"::"+hashes[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	furthest := &types.Order{}
+	err = json.Unmarshal(res, furthest)
+	if err != nil {
+		return nil, err
+	}
+
+	return furthest, nil
+}
+
+// closerToTouch returns true if an order has a better price than an order of the same
+// side of the orderbook
+func closerToTouch(order, other *types.Order) bool {
+	if order.Side == "BUY" {
+		return order.PricePoint.Cmp(other.PricePoint) > 0
+	}
+
+	return order.PricePoint.Cmp(other.PricePoint) < 0
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitOrderBook(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	far := listingOrder("BUY", 100000000, "0x1")
+	near := listingOrder("BUY", 200000000, "0x2")
+	ask := listingOrder("SELL", 300000000, "0x3")
+	e.addOrder(far)
+	e.addOrder(near)
+	e.addOrder(ask)
+
+	count, err := e.countOrders(near)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, count)
+
+	err = e.SetOrderBookLimits(map[string]OrderBookLimit{"": {MaxOrders: 3, Policy: "drop"}})
+	assert.NotNil(t, err)
+
+	err = e.SetOrderBookLimits(map[string]OrderBookLimit{"": {MaxOrders: 3, Policy: RejectFarOrders}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// orders crossing the orderbook are matched
+	evicted, err := e.limitOrderBook(listingOrder("BUY", 300000000, "0x4"), false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(evicted))
+
+	// the limit applies to their remainder
+	_, err = e.limitOrderBook(listingOrder("BUY", 300000000, "0x4"), true)
+	assert.IsType(t, &OrderBookFullError{}, err)
+
+	_, err = e.limitOrderBook(listingOrder("BUY", 150000000, "0x5"), false)
+	assert.IsType(t, &OrderBookFullError{}, err)
+
+	err = e.SetOrderBookLimits(map[string]OrderBookLimit{"ZRX/WETH": {MaxOrders: 3, Policy: EvictFarOrders}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the order is not closer to the touch than the furthest bid
+	_, err = e.limitOrderBook(listingOrder("BUY", 100000000, "0x6"), false)
+	assert.IsType(t, &OrderBookFullError{}, err)
+
+	evicted, err = e.limitOrderBook(listingOrder("BUY", 150000000, "0x7"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, len(evicted))
+	assert.Equal(t, far.Hash, evicted[0].Hash)
+	assert.Equal(t, "CANCELLED", evicted[0].Status)
+	assert.False(t, inBook(e, far))
+	assert.True(t, inBook(e, near))
+
+	evictions, err := e.GetEvictions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, int64(1), evictions["ZRX/WETH"])
+}
@@ -0,0 +1,271 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/streadway/amqp"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// replyTimeout is the time the client waits for the matcher to reply to a command
+const replyTimeout = 10 * time.Second
+
+// Client is an Engine implementation used when the matcher runs as a separate process.
+// Every command that mutates the orderbook is sent over rabbitmq and processed by the
+// matcher, the client only reads the orderbook from redis. This allows running several
+// API replicas against a single matcher.
+type Client struct {
+	*Resource
+	// origin identifies the replica in the orders it sends, see types.Order.Origin
+	origin     string
+	replyQueue string
+	pending    map[string]chan *Response
+	mutex      *sync.Mutex
+}
+
+var _ Engine = (*Client)(nil)
+
+// InitEngineClient returns an engine client that forwards orderbook commands to the
+// matcher processes. Commands are routed to the matcher of the shard of the order pair.
+func InitEngineClient(redisConn redis.Conn, shards *Shards) (*Client, error) {
+	c := &Client{
+		Resource: &Resource{redisConn: redisConn, mutex: &sync.Mutex{}, shards: shards},
+		origin:   bson.NewObjectId().Hex(),
+		pending:  make(map[string]chan *Response),
+		mutex:    &sync.Mutex{},
+	}
+
+	err := c.subscribeReplies()
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// AddOrder publishes a new order on the order queue of its shard, the engine responses
+// of the order are processed by this replica
+func (c *Client) AddOrder(o *types.Order) error {
+	o.Origin = c.origin
+	return c.Resource.AddOrder(o)
+}
+
+// MassQuote publishes the orders of a mass quote on the order queue of the shard of the
+// pair, the engine responses of the orders are processed by this replica
+func (c *Client) MassQuote(pairName string, cancels, orders []*types.Order) error {
+	for _, o := range cancels {
+		o.Origin = c.origin
+	}
+
+	for _, o := range orders {
+		o.Origin = c.origin
+	}
+
+	return c.Resource.MassQuote(pairName, cancels, orders)
+}
+
+// SubscribeResponses calls fn for each response published by the matchers. Every replica
+// receives all the responses so that they are relayed to all the websocket clients, the
+// responses of the orders sent by another replica are flagged as Foreign.
+func (c *Client) SubscribeResponses(fn func(*Response) error) error {
+	return subscribeResponses(func(res *Response) error {
+		res.Foreign = res.Order == nil || res.Order.Origin != c.origin
+		return fn(res)
+	})
+}
+
+// reduceOrderMessage is the data of the REDUCE_ORDER commands
+type reduceOrderMessage struct {
+	Order  *types.Order `json:"order"`
+	Amount string       `json:"amount"`
+}
+
+// CancelOrder sends a cancel command to the matcher and waits for its response
+func (c *Client) CancelOrder(order *types.Order) (*Response, error) {
+	bytes, err := json.Marshal(order)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.request(&Message{Type: "CANCEL_ORDER", Data: bytes}, c.shards.Get(order.PairName))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.FillStatus == REJECTED {
+		return nil, &RateLimitError{res.Error}
+	}
+
+	if res.FillStatus == ERROR {
+		return nil, errors.New("Could not cancel order")
+	}
+
+	return res, nil
+}
+
+// ReduceOrder sends a reduce command to the matcher and waits for its response
+func (c *Client) ReduceOrder(order *types.Order, amount *big.Int) (*Response, error) {
+	bytes, err := json.Marshal(&reduceOrderMessage{Order: order, Amount: amount.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.request(&Message{Type: "REDUCE_ORDER", Data: bytes}, c.shards.Get(order.PairName))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.FillStatus == ERROR {
+		return nil, errors.New("Could not reduce order")
+	}
+
+	return res, nil
+}
+
+// ReserveOrder sends a reserve command to the matcher and waits for its response
+func (c *Client) ReserveOrder(order *types.Order) (*Response, error) {
+	bytes, err := json.Marshal(order)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.request(&Message{Type: "RESERVE_ORDER", Data: bytes}, c.shards.Get(order.PairName))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.FillStatus == ERROR {
+		return nil, errors.New("Could not reserve order")
+	}
+
+	return res, nil
+}
+
+// request publishes a command to the matcher of a shard and waits for its response
+func (c *Client) request(msg *Message, shard string) (*Response, error) {
+	id := bson.NewObjectId().Hex()
+	replies := make(chan *Response, 1)
+
+	c.mutex.Lock()
+	c.pending[id] = replies
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, id)
+		c.mutex.Unlock()
+	}()
+
+	err := c.publishCommand(msg, shard, id)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case res := <-replies:
+		return res, nil
+	case <-time.After(replyTimeout):
+		return nil, errors.New("Timeout waiting for matcher response")
+	}
+}
+
+// RecoverOrders sends the orders to recover to the matcher. Orders are grouped by shard
+// since matching orders of a response can only belong to the same pair.
+func (c *Client) RecoverOrders(orders []*FillOrder) error {
+	shards := make(map[string][]*FillOrder)
+	for _, o := range orders {
+		shard := c.shards.Get(o.Order.PairName)
+		shards[shard] = append(shards[shard], o)
+	}
+
+	for shard, orders := range shards {
+		bytes, err := json.Marshal(orders)
+		if err != nil {
+			return err
+		}
+
+		err = c.publishMessage(&Message{Type: "RECOVER_ORDERS", Data: bytes}, shard)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publishCommand publishes a message on the order queue with the client reply queue
+// so that the matcher can send back the result of the command
+func (c *Client) publishCommand(msg *Message, shard, correlationID string) error {
+	ch := getChannel("orderPublish")
+	q := getQueue(ch, orderQueue(shard))
+
+	bytes, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return ch.Publish(
+		"",     // exchange
+		q.Name, // routing key
+		false,  // mandatory
+		false,  // immediate
+		amqp.Publishing{
+			ContentType:   "text/json",
+			CorrelationId: correlationID,
+			ReplyTo:       c.replyQueue,
+			Body:          bytes,
+		})
+}
+
+// subscribeReplies declares an exclusive reply queue for the client and dispatches
+// the replies of the matcher to the pending commands
+func (c *Client) subscribeReplies() error {
+	ch := getChannel("replySubscribe")
+	q, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return err
+	}
+
+	msgs, err := ch.Consume(
+		q.Name, // queue
+		"",     // consumer
+		true,   // auto-ack
+		true,   // exclusive
+		false,  // no-local
+		false,  // no-wait
+		nil,    // args
+	)
+	if err != nil {
+		return err
+	}
+
+	c.replyQueue = q.Name
+	go func() {
+		for d := range msgs {
+			var res *Response
+			err := json.Unmarshal(d.Body, &res)
+			if err != nil {
+				log.Printf("error: %s", err)
+				continue
+			}
+
+			c.mutex.Lock()
+			replies := c.pending[d.CorrelationId]
+			c.mutex.Unlock()
+
+			if replies != nil {
+				replies <- res
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+)
+
+// DryRunOrder matches an order against the resting orders of the orderbook of its pair
+// without changing the orderbook or the order. The response holds the trades and the
+// orders of the orderbook the order would be matched against right now, with the fill
+// status the engine would return. The orders of the same maker are handled with the
+// self-trade prevention mode of the engine. The orderbook size limits and the quote
+// throttling are not applied.
+func (e *Resource) DryRunOrder(order *types.Order) (*Response, error) {
+	if order.Side != "BUY" && order.Side != "SELL" {
+		return nil, errors.New("Invalid order side")
+	}
+
+	pair := &types.Pair{Name: order.PairName, BaseTokenAddress: order.BaseToken, QuoteTokenAddress: order.QuoteToken}
+	asks, bids, err := e.GetRawOrderBook(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	book := asks
+	if order.Side == "SELL" {
+		book = bids
+	}
+
+	e.mutex.Lock()
+	selfTrade := e.selfTrade
+	e.mutex.Unlock()
+
+	o := *order
+	if o.FilledAmount == nil {
+		o.FilledAmount = math.ToBigInt("0")
+	}
+
+	resp := &Response{
+		Order:          &o,
+		FillStatus:     NOMATCH,
+		Trades:         make([]*types.Trade, 0),
+		RemainingOrder: &types.Order{},
+		MatchingOrders: make([]*FillOrder, 0),
+	}
+
+	// the orders of a pair that is not launched are not matched, the orders crossing the
+	// orderbook are rejected
+	if e.isPreLaunch(o.PairName, time.Now()) {
+		if len(book) > 0 && crosses(&o, book[0]) {
+			o.Status = "REJECTED"
+			resp.FillStatus = ERROR
+		} else {
+			o.Status = "OPEN"
+		}
+
+		return resp, nil
+	}
+
+	for _, entry := range book {
+		if !crosses(&o, entry) {
+			break
+		}
+
+		if selfTrade != "" && entry.UserAddress == o.UserAddress {
+			if selfTrade == CancelOldest || selfTrade == CancelBoth {
+				cancelled := *entry
+				cancelled.Status = "CANCELLED"
+				resp.CancelledOrders = append(resp.CancelledOrders, &cancelled)
+			}
+
+			if selfTrade == CancelOldest {
+				continue
+			}
+
+			o.Status = "CANCELLED"
+			resp.SelfTradeOrder = entry
+			if len(resp.Trades) == 0 {
+				resp.FillStatus = CANCELLED
+			}
+
+			return resp, nil
+		}
+
+		maker := *entry
+		amount := math.Sub(maker.Amount, maker.FilledAmount)
+		if available := math.Sub(o.Amount, o.FilledAmount); math.IsGreaterThan(amount, available) {
+			amount = available
+			maker.Status = "PARTIAL_FILLED"
+		} else {
+			maker.Status = "FILLED"
+		}
+
+		maker.FilledAmount = math.Add(maker.FilledAmount, amount)
+		o.FilledAmount = math.Add(o.FilledAmount, amount)
+		resp.Trades = append(resp.Trades, newTrade(&o, &maker, amount))
+		resp.MatchingOrders = append(resp.MatchingOrders, &FillOrder{Amount: amount, Order: &maker})
+		resp.FillStatus = PARTIAL
+		o.Status = "PARTIAL_FILLED"
+
+		if math.IsEqual(o.FilledAmount, o.Amount) {
+			resp.FillStatus = FULL
+			o.Status = "FILLED"
+			return resp, nil
+		}
+	}
+
+	// the remaining amount of the order rests in the orderbook
+	if resp.FillStatus == NOMATCH {
+		o.Status = "OPEN"
+	} else {
+		remaining := *order
+		remaining.Amount = math.Sub(o.Amount, o.FilledAmount)
+		resp.RemainingOrder = &remaining
+	}
+
+	return resp, nil
+}
+
+// crosses returns true if an order can be matched against an order of the orderbook
+func crosses(order, entry *types.Order) bool {
+	if order.Side == "BUY" {
+		return entry.PricePoint.Cmp(order.PricePoint) <= 0
+	}
+
+	return entry.PricePoint.Cmp(order.PricePoint) >= 0
+}
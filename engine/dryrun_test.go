@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunOrder(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	pair := &types.Pair{
+		Name:              "ZRX/WETH",
+		BaseTokenAddress:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteTokenAddress: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+	}
+
+	e.addOrder(listingOrder("SELL", 229999999, "0x1"))
+	e.addOrder(listingOrder("SELL", 230000000, "0x2"))
+	e.addOrder(listingOrder("SELL", 240000000, "0x3"))
+
+	// the order is filled by the two best asks and the rest of the orderbook is untouched
+	taker := listingOrder("BUY", 230000000, "0x4")
+	taker.UserAddress = common.HexToAddress("0x1")
+	taker.Amount = big.NewInt(9000000000)
+
+	res, err := e.DryRunOrder(taker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, FULL, res.FillStatus)
+	assert.Equal(t, 2, len(res.Trades))
+	assert.Equal(t, common.HexToHash("0x1"), res.MatchingOrders[0].Order.Hash)
+	assert.Equal(t, "FILLED", res.MatchingOrders[0].Order.Status)
+	assert.Equal(t, big.NewInt(3000000000), res.MatchingOrders[1].Amount)
+	assert.Equal(t, "PARTIAL_FILLED", res.MatchingOrders[1].Order.Status)
+
+	// neither the order nor the orderbook are changed
+	assert.Equal(t, "NEW", taker.Status)
+	assert.Equal(t, big.NewInt(0), taker.FilledAmount)
+	assert.False(t, inBook(e, taker))
+
+	sells, _ := e.GetOrderBook(pair)
+	assert.Equal(t, 3, len(sells))
+	assert.Equal(t, float64(60), (*sells[0])["volume"])
+
+	// the remaining amount of an order that is not filled would rest in the orderbook
+	taker = listingOrder("BUY", 229999999, "0x5")
+	taker.UserAddress = common.HexToAddress("0x1")
+	taker.Amount = big.NewInt(8000000000)
+
+	res, err = e.DryRunOrder(taker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, PARTIAL, res.FillStatus)
+	assert.Equal(t, 1, len(res.Trades))
+	assert.Equal(t, big.NewInt(2000000000), res.RemainingOrder.Amount)
+
+	// an order of the same maker is not matched with the self-trade prevention
+	assert.Nil(t, e.SetSelfTradePrevention(CancelNewest))
+	taker = listingOrder("BUY", 240000000, "0x6")
+
+	res, err = e.DryRunOrder(taker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, CANCELLED, res.FillStatus)
+	assert.Equal(t, 0, len(res.Trades))
+	assert.True(t, inBook(e, listingOrder("SELL", 229999999, "0x1")))
+}
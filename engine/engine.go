@@ -4,19 +4,88 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"math/big"
 	"sync"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/streadway/amqp"
 
 	"github.com/Proofsuite/amp-matching-engine/rabbitmq"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 )
 
+// Engine is the interface implemented by matching backends. Services and endpoints only
+// depend on this interface so that alternative implementations (in-memory, remote
+// matching service, test fakes) can be swapped in place of the redis backed Resource.
+type Engine interface {
+	// AddOrder submits a new order to be matched against the orderbook
+	AddOrder(o *types.Order) error
+	// AddRemainingOrder puts the remaining part of a partially filled order back
+	// in the orderbook without matching it
+	AddRemainingOrder(o *types.Order) error
+	CancelOrder(o *types.Order) (*Response, error)
+	// ReduceOrder reduces the remaining amount of an order of the orderbook by an amount
+	// filled outside of the engine (eg. on-chain by a third party)
+	ReduceOrder(o *types.Order, amount *big.Int) (*Response, error)
+	RecoverOrders(orders []*FillOrder) error
+	// ReserveOrder fills an order completely against the orderbook without adding it
+	// to the orderbook, or rejects it. The matched quantity is held until it is
+	// released with RecoverOrders.
+	ReserveOrder(o *types.Order) (*Response, error)
+	// DryRunOrder reports the trades an order would make against the orderbook without
+	// changing the orderbook
+	DryRunOrder(o *types.Order) (*Response, error)
+	// MassQuote cancels orders of the orderbook of a pair and matches new orders of the
+	// pair in a single operation, without processing any other order in between
+	MassQuote(pairName string, cancels, orders []*types.Order) error
+	GetOrderBook(pair *types.Pair) (sellBook, buyBook []*map[string]float64)
+	// GetOrderBookLevels returns the price levels of both sides of the orderbook of a
+	// pair with the number of orders of each level, best price first
+	GetOrderBookLevels(pair *types.Pair) (asks, bids []*types.BookLevel, err error)
+	// GetRawOrderBook returns the resting orders of both sides of the orderbook of a
+	// pair, best price first and in time priority within a price level
+	GetRawOrderBook(pair *types.Pair) (asks, bids []*types.Order, err error)
+	// GetOrderBookMemory estimates the redis memory used by the orderbook of a pair
+	// from the memory usage of at most samples of its keys
+	GetOrderBookMemory(pair *types.Pair, samples int) (*OrderBookMemory, error)
+	// GetEvictions returns the number of orders evicted from the orderbook of each pair
+	// by the orderbook size limits
+	GetEvictions() (map[string]int64, error)
+	// RestoreOrderBook rebuilds the orderbook of a pair from its open orders stored in
+	// the database and reports the divergences that were repaired
+	RestoreOrderBook(pair *types.Pair, orders []*types.Order) (*RestoreReport, error)
+	// CheckOrderBook reports the divergences between the orderbook of a pair and its
+	// open orders stored in the database without repairing them
+	CheckOrderBook(pair *types.Pair, orders []*types.Order) (*RestoreReport, error)
+	// SubscribeResponses calls fn for each response emitted by the engine
+	SubscribeResponses(fn func(*Response) error) error
+	// ScheduleListing and LaunchListing set and remove the go-live time of a pair.
+	// Orders of a pair are added to the orderbook without being matched until then.
+	ScheduleListing(pairName string, goLive time.Time) error
+	LaunchListing(pairName string) error
+}
+
 // Resource contains daos and redis connection required for engine to work
 type Resource struct {
 	redisConn redis.Conn
 	mutex     *sync.Mutex
+	lease     *Lease
+	shard     string
+	shards    *Shards
+	selfTrade string
+
+	throttles    map[string]QuoteThrottle
+	quoteUpdates map[string][]time.Time
+	bookLimits   map[string]OrderBookLimit
+	book         OrderBook
+
+	// pairs are the workers matching each pair in a goroutine of its own, when enabled
+	pairs *pairWorkers
+	// publications receives the responses of the resource of a pair, nil if the
+	// resource publishes its responses itself
+	publications chan *publication
 }
 
 // Message is the structure of message that matching engine expects
@@ -25,26 +94,111 @@ type Message struct {
 	Data []byte `json:"data"`
 }
 
+// responseBuffer is the number of responses of a pair waiting to be handled
+const responseBuffer = 1000
+
+// the channels, queues and exchanges are shared by the goroutines of the engine and
+// guarded by amqpMutex
+var amqpMutex = &sync.RWMutex{}
 var channels = make(map[string]*amqp.Channel)
 var queues = make(map[string]*amqp.Queue)
+var exchanges = make(map[string]bool)
+
+// resource is singleton Resource instance
+var resource *Resource
 
-// Engine is singleton Resource instance
-var Engine *Resource
+var _ Engine = (*Resource)(nil)
 
 // InitEngine initializes the engine singleton instance
 func InitEngine(redisConn redis.Conn) (engine *Resource, err error) {
-	if Engine == nil {
-		Engine = &Resource{redisConn, &sync.Mutex{}}
-		Engine.subscribeMessage()
+	if resource == nil {
+		resource = &Resource{redisConn: redisConn, mutex: &sync.Mutex{}}
+		resource.subscribeMessage()
 	}
-	engine = Engine
+	engine = resource
 	return
 }
 
+// InitReadOnlyEngine initializes the engine singleton instance for the read-only API
+// profile. The engine only reads the orderbook from redis: it does not consume the order
+// queue, so read replicas never match orders.
+func InitReadOnlyEngine(redisConn redis.Conn) *Resource {
+	if resource == nil {
+		resource = &Resource{redisConn: redisConn, mutex: &sync.Mutex{}}
+	}
+
+	return resource
+}
+
+// InitStandbyEngine initializes the engine singleton instance as a standby matcher.
+// The orderbook is stored in redis and shared by all matchers, so a standby matcher
+// only needs to wait for the lease of the active matcher to expire before it starts
+// consuming orders. The process exits if the lease is lost, so that two matchers never
+// process orders for the same books.
+// Each shard has its own lease, so that there is one active matcher per shard.
+func InitStandbyEngine(redisConn, leaseConn redis.Conn, shard string) (engine *Resource, err error) {
+	if resource != nil {
+		return resource, nil
+	}
+
+	lease := NewLease(leaseConn, leaseKey(shard))
+	lease.WaitAndHold(func(err error) {
+		log.Fatalf("Matcher lease lost: %s", err)
+	})
+
+	log.Printf("Acquired matcher lease with fencing token %s", lease.Token())
+	resource = &Resource{redisConn: lease.Fence(redisConn), mutex: &sync.Mutex{}, lease: lease, shard: shard}
+	resource.subscribeMessage()
+	return resource, nil
+}
+
+// AddOrder publishes a new order on the order queue to be matched by the engine
+func (e *Resource) AddOrder(o *types.Order) error {
+	bytes, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	return e.publishMessage(&Message{Type: "NEW_ORDER", Data: bytes}, e.shards.Get(o.PairName))
+}
+
+// AddRemainingOrder publishes the remaining part of a partially filled order on the
+// order queue to be added back to the orderbook
+func (e *Resource) AddRemainingOrder(o *types.Order) error {
+	bytes, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	return e.publishMessage(&Message{Type: "ADD_ORDER", Data: bytes}, e.shards.Get(o.PairName))
+}
+
+// massQuoteMessage is the data of the MASS_QUOTE messages
+type massQuoteMessage struct {
+	Cancels []*types.Order `json:"cancels"`
+	Orders  []*types.Order `json:"orders"`
+}
+
+// MassQuote publishes the orders to cancel and the new orders of a mass quote on the
+// order queue in a single message
+func (e *Resource) MassQuote(pairName string, cancels, orders []*types.Order) error {
+	bytes, err := json.Marshal(&massQuoteMessage{Cancels: cancels, Orders: orders})
+	if err != nil {
+		return err
+	}
+
+	return e.publishMessage(&Message{Type: "MASS_QUOTE", Data: bytes}, e.shards.Get(pairName))
+}
+
 // PublishMessage is used to publish order message over the rabbitmq.
 func (e *Resource) PublishMessage(order *Message) error {
+	return e.publishMessage(order, "")
+}
+
+// publishMessage publishes a message on the order queue of the given shard
+func (e *Resource) publishMessage(order *Message, shard string) error {
 	ch := getChannel("orderPublish")
-	q := getQueue(ch, "order")
+	q := getQueue(ch, orderQueue(shard))
 
 	orderAsBytes, err := json.Marshal(order)
 	if err != nil {
@@ -73,20 +227,35 @@ func (e *Resource) PublishMessage(order *Message) error {
 // publishEngineResponse is used by matching engine to publish or send response of matching engine to
 // system for further processing
 func (e *Resource) publishEngineResponse(er *Response) error {
-	ch := getChannel("erPub")
-	q := getQueue(ch, "engineResponse")
-
 	erAsBytes, err := json.Marshal(er)
 	if err != nil {
 		log.Fatalf("Failed to marshal Engine Response: %s", err)
 		return errors.New("Failed to marshal Engine Response: " + err.Error())
 	}
 
-	err = ch.Publish(
-		"",     // exchange
-		q.Name, // routing key
-		false,  // mandatory
-		false,  // immediate
+	if e.publications != nil {
+		e.publications <- &publication{body: erAsBytes}
+		return nil
+	}
+
+	return publishResponseBytes(erAsBytes)
+}
+
+// responseExchange is the fanout exchange on which the engine responses are published.
+// Every process handling the responses consumes them from a queue of its own, so that
+// the websocket clients of all the API replicas receive the orderbook and trade updates.
+const responseExchange = "engineResponses"
+
+// publishResponseBytes publishes a marshalled engine response on the response exchange
+func publishResponseBytes(erAsBytes []byte) error {
+	ch := getChannel("erPub")
+	getExchange(ch, responseExchange)
+
+	err := ch.Publish(
+		responseExchange, // exchange
+		"",               // routing key
+		false,            // mandatory
+		false,            // immediate
 		amqp.Publishing{
 			ContentType: "text/json",
 			Body:        erAsBytes,
@@ -98,56 +267,92 @@ func (e *Resource) publishEngineResponse(er *Response) error {
 	return nil
 }
 
-// SubscribeEngineResponse subscribes to engineResponse queue and triggers the function
+// SubscribeResponses subscribes to the response exchange and triggers the function
 // passed as arguments for each message.
-func (e *Resource) SubscribeEngineResponse(fn func(*Response) error) error {
+func (e *Resource) SubscribeResponses(fn func(*Response) error) error {
+	return subscribeResponses(fn)
+}
+
+// subscribeResponses binds an exclusive queue to the response exchange and calls fn for
+// each response published by the matchers. The responses of a pair are handled one at a
+// time in the order they were published, the pairs are handled concurrently.
+func subscribeResponses(fn func(*Response) error) error {
 	ch := getChannel("erSub")
-	q := getQueue(ch, "engineResponse")
-	go func() {
-		msgs, err := ch.Consume(
-			q.Name, // queue
-			"",     // consumer
-			true,   // auto-ack
-			false,  // exclusive
-			false,  // no-local
-			false,  // no-wait
-			nil,    // args
-		)
-		if err != nil {
-			log.Fatalf("Failed to register a consumer: %s", err)
+	getExchange(ch, responseExchange)
 
-		}
+	q, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return err
+	}
 
-		forever := make(chan bool)
+	err = ch.QueueBind(q.Name, "", responseExchange, false, nil)
+	if err != nil {
+		return err
+	}
 
-		go func() {
-			for d := range msgs {
-				// log.Printf("Received a message: %s", d.Body)
-				var er *Response
-				err := json.Unmarshal(d.Body, &er)
-				if err != nil {
-					log.Printf("error: %s", err)
-					continue
-				}
-				go fn(er)
+	msgs, err := ch.Consume(
+		q.Name, // queue
+		"",     // consumer
+		true,   // auto-ack
+		true,   // exclusive
+		false,  // no-local
+		false,  // no-wait
+		nil,    // args
+	)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		pairs := make(map[string]chan *Response)
+		for d := range msgs {
+			var er *Response
+			err := json.Unmarshal(d.Body, &er)
+			if err != nil || er == nil || er.Order == nil {
+				log.Printf("error: invalid engine response: %v", err)
+				continue
 			}
-		}()
 
-		<-forever
+			responses := pairs[er.Order.GetKVPrefix()]
+			if responses == nil {
+				responses = make(chan *Response, responseBuffer)
+				pairs[er.Order.GetKVPrefix()] = responses
+				go handleResponses(responses, fn)
+			}
+
+			responses <- er
+		}
+
+		for _, responses := range pairs {
+			close(responses)
+		}
 	}()
+
 	return nil
 }
 
+// handleResponses calls fn for the responses of a pair in the order they are received
+func handleResponses(responses <-chan *Response, fn func(*Response) error) {
+	for er := range responses {
+		err := fn(er)
+		if err != nil {
+			log.Print(err)
+		}
+	}
+}
+
 // subscribeMessage is called by matching engine while initializing,
 // it subscribes to order message queue and triggers the fn according to message type.
+// The messages are acknowledged once handled and their responses published, so that the
+// messages not handled yet are delivered again to the next matcher if this one stops.
 func (e *Resource) subscribeMessage() error {
 	ch := getChannel("orderSubscribe")
-	q := getQueue(ch, "order")
+	q := getQueue(ch, orderQueue(e.shard))
 	go func() {
 		msgs, err := ch.Consume(
 			q.Name, // queue
 			"",     // consumer
-			true,   // auto-ack
+			false,  // auto-ack
 			false,  // exclusive
 			false,  // no-local
 			false,  // no-wait
@@ -166,21 +371,11 @@ func (e *Resource) subscribeMessage() error {
 				err := json.Unmarshal(d.Body, msg)
 				if err != nil {
 					log.Printf("Message Unmarshal error: %s", err)
+					ack(d)
 					continue
 				}
 
-				order := &types.Order{}
-				err = json.Unmarshal(msg.Data, order)
-				if err != nil {
-					log.Printf("Order Unmarshal error: %s", err)
-					continue
-				}
-
-				if msg.Type == "NEW_ORDER" {
-					e.newOrder(order)
-				} else if msg.Type == "ADD_ORDER" {
-					e.addOrder(order)
-				}
+				e.dispatchMessage(msg, d)
 			}
 		}()
 
@@ -189,7 +384,168 @@ func (e *Resource) subscribeMessage() error {
 	return nil
 }
 
+// ack acknowledges a message of the order queue
+func ack(d amqp.Delivery) {
+	if err := d.Ack(false); err != nil {
+		log.Print(err)
+	}
+}
+
+// handleMessage triggers the engine function corresponding to the message type. Cancel
+// messages are sent by remote clients (see Client) and are replied to on the reply queue
+// of the delivery.
+func (e *Resource) handleMessage(msg *Message, d amqp.Delivery) {
+	if e.lease != nil && !e.lease.Held() {
+		log.Fatalf("Matcher lease lost, dropping %s message", msg.Type)
+	}
+
+	switch msg.Type {
+	case "NEW_ORDER", "ADD_ORDER":
+		order := &types.Order{}
+		err := json.Unmarshal(msg.Data, order)
+		if err != nil {
+			log.Printf("Order Unmarshal error: %s", err)
+			return
+		}
+
+		if msg.Type == "NEW_ORDER" {
+			e.newOrder(order)
+		} else {
+			e.addRemainingOrder(order)
+		}
+
+	case "CANCEL_ORDER":
+		order := &types.Order{}
+		err := json.Unmarshal(msg.Data, order)
+		if err != nil {
+			log.Printf("Order Unmarshal error: %s", err)
+			return
+		}
+
+		res, err := e.CancelOrder(order)
+		if IsRateLimited(err) {
+			res = &Response{Order: order, FillStatus: REJECTED, Error: err.(*RateLimitError).Reason}
+		} else if err != nil {
+			log.Print(err)
+			res = &Response{Order: order, FillStatus: ERROR}
+		}
+
+		err = e.publishReply(d.ReplyTo, d.CorrelationId, res)
+		if err != nil {
+			log.Print(err)
+		}
+
+	case "MASS_QUOTE":
+		m := &massQuoteMessage{}
+		err := json.Unmarshal(msg.Data, m)
+		if err != nil {
+			log.Printf("Mass quote Unmarshal error: %s", err)
+			return
+		}
+
+		e.massQuote(m.Cancels, m.Orders)
+
+	case "REDUCE_ORDER":
+		m := &reduceOrderMessage{}
+		err := json.Unmarshal(msg.Data, m)
+		if err != nil {
+			log.Printf("Order Unmarshal error: %s", err)
+			return
+		}
+
+		res, err := e.ReduceOrder(m.Order, math.ToBigInt(m.Amount))
+		if err != nil {
+			log.Print(err)
+			res = &Response{Order: m.Order, FillStatus: ERROR}
+		}
+
+		err = e.publishReply(d.ReplyTo, d.CorrelationId, res)
+		if err != nil {
+			log.Print(err)
+		}
+
+	case "RESERVE_ORDER":
+		order := &types.Order{}
+		err := json.Unmarshal(msg.Data, order)
+		if err != nil {
+			log.Printf("Order Unmarshal error: %s", err)
+			return
+		}
+
+		res, err := e.ReserveOrder(order)
+		if err != nil {
+			log.Print(err)
+			res = &Response{Order: order, FillStatus: ERROR}
+		}
+
+		err = e.publishReply(d.ReplyTo, d.CorrelationId, res)
+		if err != nil {
+			log.Print(err)
+		}
+
+	case "RECOVER_ORDERS":
+		orders := []*FillOrder{}
+		err := json.Unmarshal(msg.Data, &orders)
+		if err != nil {
+			log.Printf("Orders Unmarshal error: %s", err)
+			return
+		}
+
+		err = e.RecoverOrders(orders)
+		if err != nil {
+			log.Print(err)
+		}
+
+	default:
+		log.Printf("Unknown message type: %s", msg.Type)
+	}
+}
+
+// publishReply sends the response of a command to the reply queue of a remote client
+func (e *Resource) publishReply(replyTo, correlationID string, res *Response) error {
+	if replyTo == "" {
+		return errors.New("No reply queue for message " + correlationID)
+	}
+
+	bytes, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	if e.publications != nil {
+		e.publications <- &publication{body: bytes, replyTo: replyTo, correlationID: correlationID}
+		return nil
+	}
+
+	return publishReplyBytes(replyTo, correlationID, bytes)
+}
+
+// publishReplyBytes sends a marshalled response to the reply queue of a remote client
+func publishReplyBytes(replyTo, correlationID string, bytes []byte) error {
+	ch := getChannel("replyPublish")
+	return ch.Publish(
+		"",      // exchange
+		replyTo, // routing key
+		false,   // mandatory
+		false,   // immediate
+		amqp.Publishing{
+			ContentType:   "text/json",
+			CorrelationId: correlationID,
+			Body:          bytes,
+		})
+}
+
 func getQueue(ch *amqp.Channel, queue string) *amqp.Queue {
+	amqpMutex.RLock()
+	q := queues[queue]
+	amqpMutex.RUnlock()
+	if q != nil {
+		return q
+	}
+
+	amqpMutex.Lock()
+	defer amqpMutex.Unlock()
+
 	if queues[queue] == nil {
 		q, err := ch.QueueDeclare(queue, false, false, false, false, nil)
 		if err != nil {
@@ -200,7 +556,38 @@ func getQueue(ch *amqp.Channel, queue string) *amqp.Queue {
 	return queues[queue]
 }
 
+// getExchange declares a fanout exchange once
+func getExchange(ch *amqp.Channel, exchange string) {
+	amqpMutex.RLock()
+	declared := exchanges[exchange]
+	amqpMutex.RUnlock()
+	if declared {
+		return
+	}
+
+	amqpMutex.Lock()
+	defer amqpMutex.Unlock()
+
+	if !exchanges[exchange] {
+		err := ch.ExchangeDeclare(exchange, "fanout", false, false, false, false, nil)
+		if err != nil {
+			log.Fatalf("Failed to declare an exchange: %s", err)
+		}
+		exchanges[exchange] = true
+	}
+}
+
 func getChannel(id string) *amqp.Channel {
+	amqpMutex.RLock()
+	ch := channels[id]
+	amqpMutex.RUnlock()
+	if ch != nil {
+		return ch
+	}
+
+	amqpMutex.Lock()
+	defer amqpMutex.Unlock()
+
 	if channels[id] == nil {
 		ch, err := rabbitmq.Conn.Channel()
 		if err != nil {
@@ -26,7 +26,7 @@ func getResource() *Resource {
 		}
 		// Clear redis before starting tests
 		flushData(c)
-		return &Resource{c, &sync.Mutex{}}
+		return &Resource{redisConn: c, mutex: &sync.Mutex{}}
 	}
 
 	s, err := miniredis.Run()
@@ -39,7 +39,7 @@ func getResource() *Resource {
 		panic(err)
 	}
 
-	return &Resource{c, &sync.Mutex{}}
+	return &Resource{redisConn: c, mutex: &sync.Mutex{}}
 }
 
 func getSortedSet(c redis.Conn, key string) (map[string]float64, error) {
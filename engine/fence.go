@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"github.com/gomodule/redigo/redis"
+)
+
+// fencedCommands are the redis commands used by the engine to write the orderbooks
+var fencedCommands = map[string]bool{
+	"SET":     true,
+	"DEL":     true,
+	"INCR":    true,
+	"INCRBY":  true,
+	"HSET":    true,
+	"HDEL":    true,
+	"HINCRBY": true,
+	"ZADD":    true,
+	"ZREM":    true,
+}
+
+// fenceScript runs a write command only if the lease is still held with the given
+// fencing token. The command and its arguments follow the token.
+var fenceScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return redis.error_reply("FENCED matcher lease lost")
+end
+return redis.call(unpack(ARGV, 2))
+`)
+
+// fencedConn is a redis connection whose writes are checked against the fencing token of
+// a lease by redis itself. A matcher that lost its lease (eg. after a long pause) can
+// thus not write to the orderbooks once another matcher acquired it.
+type fencedConn struct {
+	redis.Conn
+	lease *Lease
+}
+
+// Fence returns a connection whose writes fail once the lease is not held with its
+// current fencing token anymore
+func (l *Lease) Fence(conn redis.Conn) redis.Conn {
+	return &fencedConn{conn, l}
+}
+
+func (c *fencedConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	if !fencedCommands[cmd] {
+		return c.Conn.Do(cmd, args...)
+	}
+
+	return fenceScript.Do(c.Conn, c.fenceArgs(cmd, args)...)
+}
+
+func (c *fencedConn) Send(cmd string, args ...interface{}) error {
+	if !fencedCommands[cmd] {
+		return c.Conn.Send(cmd, args...)
+	}
+
+	return fenceScript.Send(c.Conn, c.fenceArgs(cmd, args)...)
+}
+
+// fenceArgs returns the keys and arguments of the fence script running a command
+func (c *fencedConn) fenceArgs(cmd string, args []interface{}) []interface{} {
+	return append([]interface{}{c.lease.key, c.lease.Token(), cmd}, args...)
+}
+
+// fence returns the connection fenced by the lease of the engine, if any
+func (e *Resource) fence(conn redis.Conn) redis.Conn {
+	if e.lease == nil || conn == nil {
+		return conn
+	}
+
+	return e.lease.Fence(conn)
+}
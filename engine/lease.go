@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// leaseTTL is the time after which a matcher that stopped renewing its lease is
+// considered dead and a standby matcher is promoted
+const leaseTTL = 5 * time.Second
+
+// renewScript extends the lease only if it is still held by the given token
+var renewScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Lease is a redis based lock that guarantees that only one matcher is active for a
+// set of pairs. Each acquisition gets a new fencing token so that a matcher that lost
+// its lease (eg. after a long pause) can detect it and stop processing orders, and its
+// writes to the orderbooks are refused by redis (see Fence).
+type Lease struct {
+	conn  redis.Conn
+	key   string
+	token string
+	held  bool
+	// renewed is the time the last successful renewal was sent at, the lease is not
+	// held anymore leaseTTL after it
+	renewed time.Time
+	mutex   *sync.Mutex
+}
+
+// NewLease returns a lease on the given key. The lease uses its own redis connection
+// as it is renewed concurrently with the engine operations.
+func NewLease(conn redis.Conn, key string) *Lease {
+	return &Lease{conn: conn, key: key, mutex: &sync.Mutex{}}
+}
+
+// Acquire tries to acquire the lease and returns true if it succeeded
+func (l *Lease) Acquire() (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	fence, err := redis.Int64(l.conn.Do("INCR", l.key+"::fence"))
+	if err != nil {
+		return false, err
+	}
+
+	token := strconv.FormatInt(fence, 10)
+	sent := time.Now()
+	res, err := l.conn.Do("SET", l.key, token, "NX", "PX", int64(leaseTTL/time.Millisecond))
+	if err != nil {
+		return false, err
+	}
+
+	if res == nil {
+		return false, nil
+	}
+
+	l.token = token
+	l.held = true
+	l.renewed = sent
+	return true, nil
+}
+
+// Renew extends the lease. It returns an error if the lease has been acquired by
+// another matcher in the meantime.
+func (l *Lease) Renew() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	sent := time.Now()
+	ok, err := redis.Int(renewScript.Do(l.conn, l.key, l.token, int64(leaseTTL/time.Millisecond)))
+	if err != nil {
+		return err
+	}
+
+	if ok == 0 {
+		l.held = false
+		return errors.New("Lease " + l.key + " lost")
+	}
+
+	l.renewed = sent
+	return nil
+}
+
+// Held returns true if the lease was held at the time of the last renewal and has not
+// expired since, eg. because redis could not be reached to renew it
+func (l *Lease) Held() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.held && time.Since(l.renewed) >= leaseTTL {
+		l.held = false
+	}
+
+	return l.held
+}
+
+// Token returns the fencing token of the current lease
+func (l *Lease) Token() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.token
+}
+
+// WaitAndHold blocks until the lease is acquired and then renews it in the background.
+// onLost is called if the lease can not be renewed anymore.
+func (l *Lease) WaitAndHold(onLost func(error)) {
+	for {
+		ok, err := l.Acquire()
+		if err != nil {
+			log.Print(err)
+		}
+
+		if ok {
+			break
+		}
+
+		time.Sleep(leaseTTL / 5)
+	}
+
+	go func() {
+		for range time.Tick(leaseTTL / 3) {
+			err := l.Renew()
+			if err != nil {
+				l.mutex.Lock()
+				l.held = false
+				l.mutex.Unlock()
+
+				onLost(err)
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLease(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	c1, _ := redis.Dial("tcp", s.Addr())
+	c2, _ := redis.Dial("tcp", s.Addr())
+
+	active := NewLease(c1, "engine::lease")
+	standby := NewLease(c2, "engine::lease")
+
+	ok, err := active.Acquire()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.True(t, ok)
+	assert.True(t, active.Held())
+
+	ok, err = standby.Acquire()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.False(t, ok)
+	assert.Nil(t, active.Renew())
+
+	// simulate the expiry of the active lease
+	s.Del("engine::lease")
+
+	ok, err = standby.Acquire()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.True(t, ok)
+	assert.NotEqual(t, active.Token(), standby.Token())
+	assert.NotNil(t, active.Renew())
+	assert.False(t, active.Held())
+}
+
+func TestLeaseExpiresWithoutRenewal(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	c, _ := redis.Dial("tcp", s.Addr())
+	l := NewLease(c, "engine::lease")
+
+	ok, err := l.Acquire()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.True(t, ok)
+	assert.True(t, l.Held())
+
+	// simulate renewals failing since longer than the ttl (eg. redis unreachable)
+	l.renewed = time.Now().Add(-leaseTTL)
+	assert.False(t, l.Held())
+}
+
+func TestFencedConn(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	c1, _ := redis.Dial("tcp", s.Addr())
+	c2, _ := redis.Dial("tcp", s.Addr())
+
+	active := NewLease(c1, "engine::lease")
+	ok, err := active.Acquire()
+	if err != nil || !ok {
+		t.Fatalf("Could not acquire the lease: %v", err)
+	}
+
+	conn, _ := redis.Dial("tcp", s.Addr())
+	fenced := active.Fence(conn)
+
+	_, err = fenced.Do("SET", "book::key", "1")
+	assert.Nil(t, err)
+
+	v, err := redis.String(fenced.Do("GET", "book::key"))
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+
+	// another matcher acquires the lease after it expired
+	s.Del("engine::lease")
+	standby := NewLease(c2, "engine::lease")
+	ok, err = standby.Acquire()
+	if err != nil || !ok {
+		t.Fatalf("Could not acquire the lease: %v", err)
+	}
+
+	_, err = fenced.Do("SET", "book::key", "2")
+	assert.NotNil(t, err)
+
+	v, _ = redis.String(conn.Do("GET", "book::key"))
+	assert.Equal(t, "1", v)
+}
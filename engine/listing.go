@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"log"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+)
+
+// listingsKey is the redis hash that maps the names of the pairs that are not launched
+// yet to their go-live time (unix timestamp). It is shared by the API and the matchers.
+const listingsKey = "engine::listings"
+
+// ScheduleListing sets the go-live time of a pair. Until then, the orders of the pair
+// are added to the orderbook without being matched so that the book can be seeded
+// before the market opens.
+func (e *Resource) ScheduleListing(pairName string, goLive time.Time) error {
+	_, err := e.redisConn.Do("HSET", listingsKey, pairName, goLive.Unix())
+	return err
+}
+
+// LaunchListing removes the go-live time of a pair, the orders of the pair are matched
+// from then on
+func (e *Resource) LaunchListing(pairName string) error {
+	_, err := e.redisConn.Do("HDEL", listingsKey, pairName)
+	return err
+}
+
+// isPreLaunch returns true if the pair has a go-live time later than now. The matcher
+// does not depend on the launch cron to start matching orders on time.
+func (e *Resource) isPreLaunch(pairName string, now time.Time) bool {
+	goLive, err := redis.Int64(e.redisConn.Do("HGET", listingsKey, pairName))
+	if err != nil {
+		if err != redis.ErrNil {
+			log.Print(err)
+		}
+
+		return false
+	}
+
+	return now.Unix() < goLive
+}
+
+// preLaunchOrder adds an order to the orderbook of a pair that is not launched yet, or
+// a 0x order to the orderbook of the 0x orders. These orders are not matched, so orders
+// that would cross the book are rejected to keep the book consistent.
+func (e *Resource) preLaunchOrder(order *types.Order) (*Response, error) {
+	resp := &Response{
+		Order:          order,
+		FillStatus:     NOMATCH,
+		Trades:         make([]*types.Trade, 0),
+		RemainingOrder: &types.Order{},
+		MatchingOrders: make([]*FillOrder, 0),
+	}
+
+	crossing, err := e.crossesBook(order)
+	if err != nil {
+		return nil, err
+	}
+
+	if crossing {
+		log.Printf("Rejected order %s crossing the unmatched book of %s", order.Hash.Hex(), order.PairName)
+		order.Status = "REJECTED"
+		resp.FillStatus = ERROR
+		return resp, nil
+	}
+
+	err = e.addOrder(order)
+	if err != nil {
+		return nil, err
+	}
+
+	order.Status = "OPEN"
+	return resp, nil
+}
+
+// crossesBook returns true if the order could be matched against the orderbook
+func (e *Resource) crossesBook(order *types.Order) (bool, error) {
+	key := order.GetOBMatchKey()
+	pp := "[" + utils.UintToPaddedString(order.PricePoint.Int64())
+
+	var res []interface{}
+	var err error
+	if order.Side == "BUY" {
+		res, err = redis.Values(e.redisConn.Do("ZRANGEBYLEX", key, "-", pp, "LIMIT", 0, 1))
+	} else {
+		res, err = redis.Values(e.redisConn.Do("ZREVRANGEBYLEX", key, "+", pp, "LIMIT", 0, 1))
+	}
+
+	if err != nil {
+		log.Print(err)
+		return false, err
+	}
+
+	return len(res) > 0, nil
+}
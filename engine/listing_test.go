@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func listingOrder(side string, pricepoint int64, hash string) *types.Order {
+	return &types.Order{
+		ID:           bson.NewObjectId(),
+		UserAddress:  common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		BaseToken:    common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken:   common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		PricePoint:   big.NewInt(pricepoint),
+		Amount:       big.NewInt(6000000000),
+		FilledAmount: big.NewInt(0),
+		Status:       "NEW",
+		Side:         side,
+		PairName:     "ZRX/WETH",
+		Hash:         common.HexToHash(hash),
+		CreatedAt:    time.Unix(1405544146, 0),
+	}
+}
+
+func TestPreLaunchOrders(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	now := time.Now()
+	assert.False(t, e.isPreLaunch("ZRX/WETH", now))
+
+	err := e.ScheduleListing("ZRX/WETH", now.Add(time.Hour))
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.True(t, e.isPreLaunch("ZRX/WETH", now))
+	assert.False(t, e.isPreLaunch("ZRX/WETH", now.Add(2*time.Hour)))
+	assert.False(t, e.isPreLaunch("MKR/WETH", now))
+
+	sell := listingOrder("SELL", 229999999, "0x1")
+	res, err := e.preLaunchOrder(sell)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, NOMATCH, res.FillStatus)
+	assert.Equal(t, "OPEN", sell.Status)
+
+	_, listKey := sell.GetOBKeys()
+	assert.True(t, exists(e.redisConn, listKey+"::"+sell.Hash.Hex()))
+
+	// a buy order below the best ask rests in the book
+	buy := listingOrder("BUY", 219999999, "0x2")
+	res, err = e.preLaunchOrder(buy)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, NOMATCH, res.FillStatus)
+
+	// a buy order crossing the best ask is rejected
+	crossing := listingOrder("BUY", 239999999, "0x3")
+	res, err = e.preLaunchOrder(crossing)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, ERROR, res.FillStatus)
+	assert.Equal(t, "REJECTED", crossing.Status)
+
+	_, listKey = crossing.GetOBKeys()
+	assert.False(t, exists(e.redisConn, listKey+"::"+crossing.Hash.Hex()))
+
+	err = e.LaunchListing("ZRX/WETH")
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.False(t, e.isPreLaunch("ZRX/WETH", now))
+}
+
+func TestZeroExOrdersNotMatched(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	sell := listingOrder("SELL", 229999999, "0x1")
+	sell.ZeroEx = &types.ZeroExFields{}
+	res, err := e.preLaunchOrder(sell)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, NOMATCH, res.FillStatus)
+
+	// the 0x orders are not in the orderbook of the other orders of the pair
+	buy := listingOrder("BUY", 239999999, "0x2")
+	assert.NotEqual(t, buy.GetKVPrefix(), sell.GetKVPrefix())
+
+	res, err = e.orderBook().Match(buy)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, NOMATCH, res.FillStatus)
+	assert.Equal(t, 0, len(res.Trades))
+
+	_, listKey := sell.GetOBKeys()
+	assert.True(t, exists(e.redisConn, listKey+"::"+sell.Hash.Hex()))
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// scanCount is the number of keys requested from redis by each SCAN call
+const scanCount = 1000
+
+// OrderBookMemory is the redis memory used by the orderbook of a pair. The memory is
+// estimated from a sample of the keys of the orderbook: Bytes is the average memory
+// usage of the sampled keys multiplied by the number of keys.
+type OrderBookMemory struct {
+	Pair      string `json:"pair"`
+	Keys      int    `json:"keys"`
+	Orders    int    `json:"orders"`
+	Levels    int    `json:"levels"`
+	Sampled   int    `json:"sampled"`
+	Bytes     int64  `json:"bytes"`
+	MaxOrders int    `json:"maxOrders,omitempty"`
+}
+
+// GetOrderBookMemory estimates the redis memory used by the orderbook of a pair with the
+// MEMORY USAGE of at most samples of its keys, spread over the keys of the orderbook.
+// The keys are listed with SCAN so that redis is not blocked by large orderbooks.
+func (e *Resource) GetOrderBookMemory(pair *types.Pair, samples int) (*OrderBookMemory, error) {
+	keys, err := e.scanKeys(pairPrefix(pair) + "::*")
+	if err != nil {
+		return nil, err
+	}
+
+	e.mutex.Lock()
+	limit := e.orderBookLimit(pair.Name)
+	e.mutex.Unlock()
+
+	m := &OrderBookMemory{Pair: pair.Name, Keys: len(keys), MaxOrders: limit.MaxOrders}
+	for _, key := range keys {
+		// the keys of the orderbook are <prefix>::<side>::book::<pp> for the volume of
+		// a price level and <prefix>::<side>::<pp>::<hash> for an order
+		parts := strings.Split(key, "::")
+		if len(parts) == 5 && parts[3] == "book" {
+			m.Levels++
+		} else if len(parts) == 5 {
+			m.Orders++
+		}
+	}
+
+	if len(keys) == 0 || samples <= 0 {
+		return m, nil
+	}
+
+	step := (len(keys) + samples - 1) / samples
+	total := int64(0)
+	for i := 0; i < len(keys); i += step {
+		bytes, err := redis.Int64(e.redisConn.Do("MEMORY", "USAGE", keys[i]))
+		if err == redis.ErrNil {
+			// the key was removed since it was scanned
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		total += bytes
+		m.Sampled++
+	}
+
+	if m.Sampled > 0 {
+		m.Bytes = total * int64(len(keys)) / int64(m.Sampled)
+	}
+
+	return m, nil
+}
+
+// scanKeys returns the keys matching a pattern. SCAN can return a key more than once,
+// the duplicates are removed.
+func (e *Resource) scanKeys(pattern string) ([]string, error) {
+	keys := []string{}
+	seen := map[string]bool{}
+	cursor := 0
+	for {
+		res, err := redis.Values(e.redisConn.Do("SCAN", cursor, "MATCH", pattern, "COUNT", scanCount))
+		if err != nil {
+			return nil, err
+		}
+
+		cursor, err = redis.Int(res[0], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		batch, err := redis.Strings(res[1], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range batch {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+
+		if cursor == 0 {
+			return keys, nil
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderBookMemoryKeys(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	e.addOrder(listingOrder("SELL", 229999999, "0x1"))
+	e.addOrder(listingOrder("SELL", 229999999, "0x2"))
+	e.addOrder(listingOrder("BUY", 200000000, "0x3"))
+
+	// the keys are counted without sampling their memory usage
+	m, err := e.GetOrderBookMemory(conformancePair, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "ZRX/WETH", m.Pair)
+	assert.Equal(t, 3, m.Orders)
+	assert.Equal(t, 2, m.Levels)
+	// the sides, the order lists of the levels and their volumes and the orders
+	assert.Equal(t, 9, m.Keys)
+	assert.Equal(t, 0, m.Sampled)
+	assert.Equal(t, int64(0), m.Bytes)
+}
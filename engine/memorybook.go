@@ -0,0 +1,540 @@
+package engine
+
+import (
+	"container/list"
+	"encoding/json"
+	"log"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/Proofsuite/go-ethereum/common"
+	"github.com/gomodule/redigo/redis"
+)
+
+// maxSkipLevel is the number of levels of the price level skip lists, enough for
+// millions of price levels on a side of the orderbook
+const maxSkipLevel = 16
+
+// priceLevel holds the orders of the orderbook at a pricepoint in time priority and
+// their remaining volume
+type priceLevel struct {
+	price  int64
+	volume *big.Int
+	orders *list.List
+}
+
+type skipNode struct {
+	level *priceLevel
+	next  []*skipNode
+}
+
+// priceLevels is a skip list of the price levels of a side of the orderbook, sorted from
+// the best price to the worst price
+type priceLevels struct {
+	head   *skipNode
+	height int
+	better func(a, b int64) bool
+	rand   *rand.Rand
+}
+
+func newPriceLevels(better func(a, b int64) bool) *priceLevels {
+	return &priceLevels{
+		head:   &skipNode{next: make([]*skipNode, maxSkipLevel)},
+		height: 1,
+		better: better,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// randomHeight returns the height of a new node, each level being used by a fourth of
+// the nodes of the level below
+func (l *priceLevels) randomHeight() int {
+	h := 1
+	for h < maxSkipLevel && l.rand.Intn(4) == 0 {
+		h++
+	}
+
+	return h
+}
+
+// predecessors returns the last node before price on each level of the list
+func (l *priceLevels) predecessors(price int64) []*skipNode {
+	update := make([]*skipNode, maxSkipLevel)
+	n := l.head
+	for i := l.height - 1; i >= 0; i-- {
+		for n.next[i] != nil && l.better(n.next[i].level.price, price) {
+			n = n.next[i]
+		}
+
+		update[i] = n
+	}
+
+	return update
+}
+
+// find returns the price level of a price, nil if there is none
+func (l *priceLevels) find(price int64) *priceLevel {
+	n := l.head
+	for i := l.height - 1; i >= 0; i-- {
+		for n.next[i] != nil && l.better(n.next[i].level.price, price) {
+			n = n.next[i]
+		}
+	}
+
+	n = n.next[0]
+	if n != nil && n.level.price == price {
+		return n.level
+	}
+
+	return nil
+}
+
+// insert returns the price level of a price, the level is created if there is none
+func (l *priceLevels) insert(price int64) *priceLevel {
+	update := l.predecessors(price)
+	if n := update[0].next[0]; n != nil && n.level.price == price {
+		return n.level
+	}
+
+	h := l.randomHeight()
+	if h > l.height {
+		for i := l.height; i < h; i++ {
+			update[i] = l.head
+		}
+
+		l.height = h
+	}
+
+	n := &skipNode{
+		level: &priceLevel{price: price, volume: big.NewInt(0), orders: list.New()},
+		next:  make([]*skipNode, h),
+	}
+
+	for i := 0; i < h; i++ {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+	}
+
+	return n.level
+}
+
+// remove deletes the price level of a price
+func (l *priceLevels) remove(price int64) {
+	update := l.predecessors(price)
+	n := update[0].next[0]
+	if n == nil || n.level.price != price {
+		return
+	}
+
+	for i := 0; i < l.height && update[i].next[i] == n; i++ {
+		update[i].next[i] = n.next[i]
+	}
+
+	for l.height > 1 && l.head.next[l.height-1] == nil {
+		l.height--
+	}
+}
+
+// first returns the best price level, nil if the side is empty
+func (l *priceLevels) first() *priceLevel {
+	if n := l.head.next[0]; n != nil {
+		return n.level
+	}
+
+	return nil
+}
+
+// each calls fn on the price levels from the best price until fn returns false
+func (l *priceLevels) each(fn func(*priceLevel) bool) {
+	for n := l.head.next[0]; n != nil; n = n.next[0] {
+		if !fn(n.level) {
+			return
+		}
+	}
+}
+
+// MemoryBook is an in-memory limit order book of a pair. The price levels of each side
+// are kept in a skip list with the orders of a level in a FIFO queue, so that orders are
+// added, cancelled and matched without any redis round-trip. Redis is only used as a
+// write-behind persistence layer: the changes are recorded by the writer of the book and
+// written with the layout of the redis orderbook when the writer is flushed.
+//
+// MemoryBook is not safe for concurrent use, the engine lock must be held by the callers
+// as for the redis orderbook.
+type MemoryBook struct {
+	bids   *priceLevels
+	asks   *priceLevels
+	orders map[common.Hash]*list.Element
+	writer *BookWriter
+}
+
+// NewMemoryBook returns an empty in-memory orderbook persisting its changes with writer.
+// The changes are not persisted if writer is nil.
+func NewMemoryBook(writer *BookWriter) *MemoryBook {
+	return &MemoryBook{
+		bids:   newPriceLevels(func(a, b int64) bool { return a > b }),
+		asks:   newPriceLevels(func(a, b int64) bool { return a < b }),
+		orders: make(map[common.Hash]*list.Element),
+		writer: writer,
+	}
+}
+
+// side returns the price levels of the side of the orderbook of an order side
+func (b *MemoryBook) side(side string) *priceLevels {
+	if side == "BUY" {
+		return b.bids
+	}
+
+	return b.asks
+}
+
+// Len returns the number of orders of the orderbook
+func (b *MemoryBook) Len() int {
+	return len(b.orders)
+}
+
+// Get returns an order of the orderbook by hash, nil if the order is not in the orderbook
+func (b *MemoryBook) Get(hash common.Hash) *types.Order {
+	if el, ok := b.orders[hash]; ok {
+		return el.Value.(*types.Order)
+	}
+
+	return nil
+}
+
+// Best returns the best price level of a side of the orderbook: its pricepoint and its
+// volume. ok is false if the side is empty.
+func (b *MemoryBook) Best(side string) (pricepoint int64, volume *big.Int, ok bool) {
+	l := b.side(side).first()
+	if l == nil {
+		return 0, nil, false
+	}
+
+	return l.price, new(big.Int).Set(l.volume), true
+}
+
+// Add adds an order to the queue of its price level after the orders with a lower or
+// equal sequence number, at the end of the queue for a new order
+func (b *MemoryBook) Add(o *types.Order) {
+	if _, ok := b.orders[o.Hash]; ok {
+		return
+	}
+
+	l := b.side(o.Side).insert(o.PricePoint.Int64())
+	l.volume.Add(l.volume, math.Sub(o.Amount, o.FilledAmount))
+
+	prev := l.orders.Back()
+	for prev != nil && prev.Value.(*types.Order).Sequence > o.Sequence {
+		prev = prev.Prev()
+	}
+
+	if prev == nil {
+		b.orders[o.Hash] = l.orders.PushFront(o)
+	} else {
+		b.orders[o.Hash] = l.orders.InsertAfter(o, prev)
+	}
+
+	b.writer.setOrder(o)
+	b.writer.setLevel(o, l.volume)
+}
+
+// Remove removes an order from the orderbook and returns it, nil if the order is not in
+// the orderbook
+func (b *MemoryBook) Remove(hash common.Hash) *types.Order {
+	el, ok := b.orders[hash]
+	if !ok {
+		return nil
+	}
+
+	o := el.Value.(*types.Order)
+	side := b.side(o.Side)
+	l := side.find(o.PricePoint.Int64())
+	l.orders.Remove(el)
+	l.volume.Sub(l.volume, math.Sub(o.Amount, o.FilledAmount))
+	delete(b.orders, hash)
+
+	if l.orders.Len() == 0 {
+		side.remove(l.price)
+		l.volume.SetInt64(0)
+	}
+
+	b.writer.removeOrder(o)
+	b.writer.setLevel(o, l.volume)
+	return o
+}
+
+// crosses returns true if an order can be matched at the price of a level of the
+// opposite side of the orderbook
+func crosses(order *types.Order, price int64) bool {
+	if order.Side == "BUY" {
+		return price <= order.PricePoint.Int64()
+	}
+
+	return price >= order.PricePoint.Int64()
+}
+
+// Match matches an order against the opposite side of the orderbook in price-time
+// priority, as buyOrder/sellOrder do on the redis orderbook. An order that does not
+// match any order of the orderbook is added to the orderbook. The matching orders of the
+// response are copies of the orders of the orderbook in their state after the trade.
+// The self-trade prevention is not applied by the in-memory orderbook.
+func (b *MemoryBook) Match(order *types.Order) *Response {
+	resp := &Response{
+		Order:          order,
+		FillStatus:     NOMATCH,
+		Trades:         make([]*types.Trade, 0),
+		MatchingOrders: make([]*FillOrder, 0),
+	}
+
+	remOrder := *order
+	resp.RemainingOrder = &remOrder
+	opposite := b.asks
+	if order.Side == "SELL" {
+		opposite = b.bids
+	}
+
+	for l := opposite.first(); l != nil && crosses(order, l.price); l = opposite.first() {
+		for el := l.orders.Front(); el != nil; el = l.orders.Front() {
+			bookEntry := el.Value.(*types.Order)
+			available := math.Sub(bookEntry.Amount, bookEntry.FilledAmount)
+			amount := math.Sub(order.Amount, order.FilledAmount)
+			if math.IsGreaterThan(amount, available) {
+				amount = available
+			}
+
+			bookEntry.FilledAmount = math.Add(bookEntry.FilledAmount, amount)
+			bookEntry.Status = "PARTIAL_FILLED"
+			l.volume.Sub(l.volume, amount)
+			if math.IsZero(math.Sub(bookEntry.Amount, bookEntry.FilledAmount)) {
+				bookEntry.Status = "FILLED"
+				l.orders.Remove(el)
+				delete(b.orders, bookEntry.Hash)
+				b.writer.removeOrder(bookEntry)
+			} else {
+				b.writer.setOrder(bookEntry)
+			}
+
+			filled := *bookEntry
+			filled.Timings = nil
+			order.FilledAmount = math.Add(order.FilledAmount, amount)
+			resp.Trades = append(resp.Trades, newTrade(order, &filled, amount))
+			resp.MatchingOrders = append(resp.MatchingOrders, &FillOrder{Amount: amount, Order: &filled})
+			resp.RemainingOrder.Amount = math.Sub(resp.RemainingOrder.Amount, amount)
+			resp.FillStatus = PARTIAL
+			order.Status = "PARTIAL_FILLED"
+
+			if l.orders.Len() == 0 {
+				opposite.remove(l.price)
+			}
+
+			b.writer.setLevel(bookEntry, l.volume)
+			if math.IsZero(resp.RemainingOrder.Amount) {
+				resp.FillStatus = FULL
+				order.Status = "FILLED"
+				resp.RemainingOrder = &types.Order{}
+				return resp
+			}
+		}
+	}
+
+	if resp.FillStatus == NOMATCH {
+		order.Status = "OPEN"
+		resp.RemainingOrder = &types.Order{}
+		b.Add(order)
+	}
+
+	return resp
+}
+
+// Depth returns the pricepoints and volumes of the best levels of a side of the
+// orderbook, all the levels if levels is 0
+func (b *MemoryBook) Depth(side string, levels int) (pricepoints []int64, volumes []*big.Int) {
+	b.side(side).each(func(l *priceLevel) bool {
+		pricepoints = append(pricepoints, l.price)
+		volumes = append(volumes, new(big.Int).Set(l.volume))
+		return levels == 0 || len(pricepoints) < levels
+	})
+
+	return
+}
+
+// Levels returns the price levels of a side of the orderbook, best price first
+func (b *MemoryBook) Levels(side string) []*types.BookLevel {
+	levels := []*types.BookLevel{}
+	b.side(side).each(func(l *priceLevel) bool {
+		levels = append(levels, &types.BookLevel{PricePoint: l.price, Volume: l.volume.Int64(), Orders: l.orders.Len()})
+		return true
+	})
+
+	return levels
+}
+
+// Orders returns copies of the orders of a side of the orderbook, best price first and
+// in time priority within a price level
+func (b *MemoryBook) Orders(side string) []*types.Order {
+	orders := []*types.Order{}
+	b.side(side).each(func(l *priceLevel) bool {
+		for el := l.orders.Front(); el != nil; el = el.Next() {
+			copied := *el.Value.(*types.Order)
+			copied.Timings = nil
+			orders = append(orders, &copied)
+		}
+
+		return true
+	})
+
+	return orders
+}
+
+// BookWriter persists the changes of in-memory orderbooks to redis behind the matching.
+// The orders and price levels changed since the last flush are kept, only their latest
+// state is written when the writer is flushed. The redis layout is the one of the redis
+// orderbook so that the orderbooks persisted by the writer can be read with GetOrderBook
+// and restored after a restart.
+type BookWriter struct {
+	redisConn redis.Conn
+	mutex     sync.Mutex
+	flushing  sync.Mutex
+	orders    map[string]*bookOrderWrite
+	levels    map[string]*bookLevelWrite
+}
+
+// bookOrderWrite is the latest state of an order of the orderbook, order is nil if the
+// order was removed from the orderbook
+type bookOrderWrite struct {
+	listKey string
+	hash    string
+	order   *types.Order
+}
+
+// bookLevelWrite is the latest volume of a price level of the orderbook
+type bookLevelWrite struct {
+	ssKey  string
+	pp     string
+	volume *big.Int
+}
+
+// NewBookWriter returns a writer persisting in-memory orderbooks with a redis connection
+// of its own
+func NewBookWriter(redisConn redis.Conn) *BookWriter {
+	return &BookWriter{
+		redisConn: redisConn,
+		orders:    make(map[string]*bookOrderWrite),
+		levels:    make(map[string]*bookLevelWrite),
+	}
+}
+
+// setOrder records the state of an order of the orderbook
+func (w *BookWriter) setOrder(o *types.Order) {
+	if w == nil {
+		return
+	}
+
+	_, listKey := o.GetOBKeys()
+	copied := *o
+	copied.Timings = nil
+	copied.Origin = ""
+	w.mutex.Lock()
+	w.orders[listKey+"::"+o.Hash.Hex()] = &bookOrderWrite{listKey, o.Hash.Hex(), &copied}
+	w.mutex.Unlock()
+}
+
+// removeOrder records the removal of an order from the orderbook
+func (w *BookWriter) removeOrder(o *types.Order) {
+	if w == nil {
+		return
+	}
+
+	_, listKey := o.GetOBKeys()
+	w.mutex.Lock()
+	w.orders[listKey+"::"+o.Hash.Hex()] = &bookOrderWrite{listKey, o.Hash.Hex(), nil}
+	w.mutex.Unlock()
+}
+
+// setLevel records the volume of the price level of an order
+func (w *BookWriter) setLevel(o *types.Order, volume *big.Int) {
+	if w == nil {
+		return
+	}
+
+	ssKey, _ := o.GetOBKeys()
+	pp := utils.UintToPaddedString(o.PricePoint.Int64())
+	w.mutex.Lock()
+	w.levels[ssKey+"::"+pp] = &bookLevelWrite{ssKey, pp, new(big.Int).Set(volume)}
+	w.mutex.Unlock()
+}
+
+// Flush writes the changes recorded since the last flush to redis in a single pipeline
+func (w *BookWriter) Flush() error {
+	w.flushing.Lock()
+	defer w.flushing.Unlock()
+
+	w.mutex.Lock()
+	orders, levels := w.orders, w.levels
+	w.orders = make(map[string]*bookOrderWrite)
+	w.levels = make(map[string]*bookLevelWrite)
+	w.mutex.Unlock()
+
+	if len(orders) == 0 && len(levels) == 0 {
+		return nil
+	}
+
+	for key, o := range orders {
+		if o.order == nil {
+			w.redisConn.Send("DEL", key)
+			w.redisConn.Send("ZREM", o.listKey, o.hash)
+			continue
+		}
+
+		bytes, err := json.Marshal(o.order)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		w.redisConn.Send("SET", key, string(bytes))
+		w.redisConn.Send("ZADD", o.listKey, "NX", o.order.Sequence, o.hash)
+	}
+
+	for _, l := range levels {
+		if l.volume.Sign() <= 0 {
+			w.redisConn.Send("ZREM", l.ssKey, l.pp)
+			w.redisConn.Send("DEL", l.ssKey+"::book::"+l.pp)
+			continue
+		}
+
+		w.redisConn.Send("ZADD", l.ssKey, "NX", 0, l.pp)
+		w.redisConn.Send("SET", l.ssKey+"::book::"+l.pp, l.volume.Int64())
+	}
+
+	_, err := w.redisConn.Do("")
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	return nil
+}
+
+// Start flushes the writer every interval until stop is closed. The writer is flushed a
+// last time when stopped.
+func (w *BookWriter) Start(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.Flush()
+			case <-stop:
+				w.Flush()
+				return
+			}
+		}
+	}()
+}
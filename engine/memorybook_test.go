@@ -0,0 +1,220 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// minMemoryBookOpsPerSecond is the throughput of inserts and matches the in-memory
+// orderbook must sustain
+const minMemoryBookOpsPerSecond = 20000
+
+func TestMemoryBookMatch(t *testing.T) {
+	b := NewMemoryBook(nil)
+
+	first := listingOrder("SELL", 200000000, "0x1")
+	second := listingOrder("SELL", 200000000, "0x2")
+	worse := listingOrder("SELL", 300000000, "0x3")
+	bid := listingOrder("BUY", 100000000, "0x4")
+	b.Add(worse)
+	b.Add(first)
+	b.Add(second)
+	b.Add(bid)
+	assert.Equal(t, 4, b.Len())
+
+	pp, volume, ok := b.Best("SELL")
+	assert.True(t, ok)
+	assert.Equal(t, int64(200000000), pp)
+	assert.Equal(t, big.NewInt(12000000000), volume)
+
+	// the order is filled by the orders of the best price in time priority
+	taker := listingOrder("BUY", 300000000, "0x5")
+	taker.Amount = big.NewInt(9000000000)
+	res := b.Match(taker)
+
+	assert.Equal(t, FULL, res.FillStatus)
+	assert.Equal(t, "FILLED", taker.Status)
+	assert.Equal(t, 2, len(res.Trades))
+	assert.Equal(t, first.Hash, res.Trades[0].OrderHash)
+	assert.Equal(t, big.NewInt(6000000000), res.Trades[0].Amount)
+	assert.Equal(t, second.Hash, res.Trades[1].OrderHash)
+	assert.Equal(t, big.NewInt(3000000000), res.Trades[1].Amount)
+	assert.Equal(t, "FILLED", res.MatchingOrders[0].Order.Status)
+	assert.Equal(t, "PARTIAL_FILLED", res.MatchingOrders[1].Order.Status)
+	assert.Nil(t, b.Get(first.Hash))
+	assert.NotNil(t, b.Get(second.Hash))
+
+	_, volume, _ = b.Best("SELL")
+	assert.Equal(t, big.NewInt(3000000000), volume)
+
+	// the order is not added to the orderbook if it matches
+	taker = listingOrder("BUY", 300000000, "0x6")
+	taker.Amount = big.NewInt(12000000000)
+	res = b.Match(taker)
+
+	assert.Equal(t, PARTIAL, res.FillStatus)
+	assert.Equal(t, big.NewInt(3000000000), res.RemainingOrder.Amount)
+	assert.Nil(t, b.Get(taker.Hash))
+
+	_, _, ok = b.Best("SELL")
+	assert.False(t, ok)
+
+	// an order that does not match rests in the orderbook
+	taker = listingOrder("SELL", 150000000, "0x7")
+	res = b.Match(taker)
+
+	assert.Equal(t, NOMATCH, res.FillStatus)
+	assert.Equal(t, "OPEN", taker.Status)
+	assert.NotNil(t, b.Get(taker.Hash))
+
+	assert.Equal(t, bid, b.Remove(bid.Hash))
+	assert.Nil(t, b.Remove(bid.Hash))
+
+	_, _, ok = b.Best("BUY")
+	assert.False(t, ok)
+}
+
+func TestMemoryBookDepth(t *testing.T) {
+	b := NewMemoryBook(nil)
+	for i, pp := range []int64{300, 100, 200, 100} {
+		b.Add(listingOrder("BUY", pp, common.BigToHash(big.NewInt(int64(i+1))).Hex()))
+	}
+
+	pricepoints, volumes := b.Depth("BUY", 0)
+	assert.Equal(t, []int64{300, 200, 100}, pricepoints)
+	assert.Equal(t, big.NewInt(12000000000), volumes[2])
+
+	pricepoints, _ = b.Depth("BUY", 2)
+	assert.Equal(t, []int64{300, 200}, pricepoints)
+}
+
+func TestBookWriter(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	pair := &types.Pair{
+		Name:              "ZRX/WETH",
+		BaseTokenAddress:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteTokenAddress: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+	}
+
+	w := NewBookWriter(e.redisConn)
+	b := NewMemoryBook(w)
+
+	filled := listingOrder("SELL", 229999999, "0x1")
+	partial := listingOrder("SELL", 229999999, "0x2")
+	b.Add(filled)
+	b.Add(partial)
+
+	// nothing is written before the writer is flushed
+	sells, _ := e.GetOrderBook(pair)
+	assert.Equal(t, 0, len(sells))
+
+	err := w.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sells, _ = e.GetOrderBook(pair)
+	assert.Equal(t, float64(120), (*sells[0])["volume"])
+	assert.True(t, inBook(e, filled))
+
+	taker := listingOrder("BUY", 229999999, "0x3")
+	taker.Amount = big.NewInt(9000000000)
+	b.Match(taker)
+
+	err = w.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sells, _ = e.GetOrderBook(pair)
+	assert.Equal(t, float64(30), (*sells[0])["volume"])
+	assert.False(t, inBook(e, filled))
+	assert.True(t, inBook(e, partial))
+
+	b.Remove(partial.Hash)
+	err = w.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sells, _ = e.GetOrderBook(pair)
+	assert.Equal(t, 0, len(sells))
+	assert.False(t, inBook(e, partial))
+}
+
+// bookOrders returns n orders of a side spread over 100 pricepoints
+func bookOrders(side string, n int) []*types.Order {
+	orders := make([]*types.Order, n)
+	for i := range orders {
+		orders[i] = listingOrder(side, int64(100000000+(i%100)*1000000), common.BigToHash(big.NewInt(int64(i+1))).Hex())
+	}
+
+	return orders
+}
+
+// TestMemoryBookThroughput fails if the in-memory orderbook handles less than
+// minMemoryBookOpsPerSecond inserts and matches per second
+func TestMemoryBookThroughput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping throughput test in short mode")
+	}
+
+	n := 50000
+	asks := bookOrders("SELL", n)
+	bids := bookOrders("BUY", n)
+	for _, o := range bids {
+		o.PricePoint = big.NewInt(300000000)
+	}
+
+	b := NewMemoryBook(nil)
+	start := time.Now()
+	for _, o := range asks {
+		b.Add(o)
+	}
+
+	for _, o := range bids {
+		b.Match(o)
+	}
+
+	elapsed := time.Since(start)
+	ops := float64(2*n) / elapsed.Seconds()
+	assert.Equal(t, 0, b.Len())
+	if ops < minMemoryBookOpsPerSecond {
+		t.Fatalf("in-memory orderbook handled %.0f ops/s, expected at least %d", ops, minMemoryBookOpsPerSecond)
+	}
+}
+
+func BenchmarkMemoryBookAdd(b *testing.B) {
+	orders := bookOrders("SELL", b.N)
+	book := NewMemoryBook(nil)
+
+	b.ResetTimer()
+	for _, o := range orders {
+		book.Add(o)
+	}
+}
+
+func BenchmarkMemoryBookMatch(b *testing.B) {
+	asks := bookOrders("SELL", b.N)
+	bids := bookOrders("BUY", b.N)
+	book := NewMemoryBook(nil)
+	for _, o := range asks {
+		book.Add(o)
+	}
+
+	for _, o := range bids {
+		o.PricePoint = big.NewInt(300000000)
+	}
+
+	b.ResetTimer()
+	for _, o := range bids {
+		book.Match(o)
+	}
+}
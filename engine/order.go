@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"math/big"
+	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils"
@@ -27,23 +28,62 @@ func (e *Resource) newOrder(order *types.Order) (err error) {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
+	err = e.throttleUpdate(order, time.Now())
+	if err != nil {
+		return e.rejectOrder(order, err)
+	}
+
+	return e.matchOrder(order)
+}
+
+// matchOrder matches an order against the orderbook and publishes the response. The
+// engine lock must be held by the caller.
+func (e *Resource) matchOrder(order *types.Order) (err error) {
+	err = e.sequence(order)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	evicted, err := e.limitOrderBook(order, false)
+	if _, ok := err.(*OrderBookFullError); ok {
+		return e.rejectOrder(order, err)
+	}
+
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	// the trades of 0x orders can only be settled on the 0x exchange contract, which the
+	// operator does not call: the 0x orders rest in their own orderbook without being
+	// matched, and are filled on-chain by the 0x takers
 	resp := &Response{}
-	if order.Side == "SELL" {
-		resp, err = e.sellOrder(order)
+	if order.ZeroEx != nil || e.isPreLaunch(order.PairName, time.Now()) {
+		err = e.orderBook().Sync(order.GetKVPrefix(), func() (err error) {
+			resp, err = e.preLaunchOrder(order)
+			return
+		})
+
 		if err != nil {
 			log.Print(err)
 			return err
 		}
 
-	} else if order.Side == "BUY" {
-		resp, err = e.buyOrder(order)
+	} else if order.Side == "SELL" || order.Side == "BUY" {
+		resp, err = e.orderBook().Match(order)
 		if err != nil {
 			log.Print(err)
 			return err
 		}
+
+	} else {
+		return e.rejectOrder(order, errors.New("Invalid order side"))
 	}
 
 	// Note: Plug the option for orders like FOC, Limit here (if needed)
+	order.Stamp(types.StageMatched, time.Now())
+	resp.EvictedOrders = evicted
 	err = e.publishEngineResponse(resp)
 	if err != nil {
 		log.Print(err)
@@ -53,6 +93,92 @@ func (e *Resource) newOrder(order *types.Order) (err error) {
 	return nil
 }
 
+// massQuote cancels the orders of a mass quote and matches its new orders while holding
+// the engine lock, so that the orderbook never shows both the old and the new quotes and
+// no other order is matched against a partially replaced quote set. The orders to cancel
+// that are not in the orderbook anymore (eg. filled in the meantime) are skipped. A mass
+// quote counts as a single update for the quote throttling of the pair, and is rejected
+// as a whole if one of its orders to cancel has not rested for the minimum resting time.
+func (e *Resource) massQuote(cancels, orders []*types.Order) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	now := time.Now()
+	err := e.throttleMassQuote(cancels, orders, now)
+	if err != nil {
+		for _, o := range orders {
+			if err := e.rejectOrder(o, err); err != nil {
+				log.Print(err)
+			}
+		}
+
+		return nil
+	}
+
+	for _, o := range cancels {
+		res, err := e.orderBook().Cancel(o)
+		if err != nil {
+			log.Printf("Could not cancel quote %s: %s", o.Hash.Hex(), err)
+			continue
+		}
+
+		// the cancelled order is read from the orderbook, which does not store the origin
+		res.Order.Origin = o.Origin
+
+		err = e.publishEngineResponse(res)
+		if err != nil {
+			log.Print(err)
+			return err
+		}
+	}
+
+	for _, o := range orders {
+		err := e.matchOrder(o)
+		if err != nil {
+			log.Print(err)
+		}
+	}
+
+	return nil
+}
+
+// addRemainingOrder puts the remaining part of a partially filled order back in the
+// orderbook. The size limit of the orderbook applies to the remainder: it is rejected if
+// it can not rest in the orderbook, and the orders evicted to make room for it are
+// published with the order.
+func (e *Resource) addRemainingOrder(order *types.Order) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	err := e.sequence(order)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	evicted, err := e.limitOrderBook(order, true)
+	if _, ok := err.(*OrderBookFullError); ok {
+		return e.rejectOrder(order, err)
+	}
+
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	err = e.orderBook().Add(order)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	if len(evicted) == 0 {
+		return nil
+	}
+
+	return e.publishEngineResponse(&Response{Order: order, FillStatus: NOMATCH, EvictedOrders: evicted})
+}
+
 // buyOrder is triggered when a buy order comes in, it fetches the ask list
 // from orderbook. First it checks ths price point list to check whether the order can be matched
 // or not, if there are pricepoints that can satisfy the order then corresponding list of orders
@@ -91,7 +217,8 @@ func (e *Resource) buyOrder(order *types.Order) (*Response, error) {
 	}
 
 	for _, pr := range priceRange {
-		bookEntries, err := redis.ByteSlices(e.redisConn.Do("SORT", oskv+"::"+utils.UintToPaddedString(pr), "GET", oskv+"::"+utils.UintToPaddedString(pr)+"::*", "ALPHA")) // "ZREVRANGEBYLEX" key max min
+		// the orders of the price level are read in the order of their sequence numbers
+		bookEntries, err := redis.ByteSlices(e.redisConn.Do("SORT", oskv+"::"+utils.UintToPaddedString(pr), "BY", "nosort", "GET", oskv+"::"+utils.UintToPaddedString(pr)+"::*"))
 		if err != nil {
 			log.Printf("LRANGE: %s\n", err)
 			return nil, err
@@ -105,6 +232,19 @@ func (e *Resource) buyOrder(order *types.Order) (*Response, error) {
 				return nil, err
 			}
 
+			if e.isSelfTrade(order, bookEntry) {
+				cancelled, err := e.preventSelfTrade(order, bookEntry, resp)
+				if err != nil {
+					return nil, err
+				}
+
+				if cancelled {
+					return resp, nil
+				}
+
+				continue
+			}
+
 			trade, fillOrder, err := e.execute(order, bookEntry)
 			if err != nil {
 				log.Printf("Error Executing Order: %s\n", err)
@@ -128,6 +268,13 @@ func (e *Resource) buyOrder(order *types.Order) (*Response, error) {
 		}
 	}
 
+	// the orders of the price range were all cancelled by the self-trade prevention
+	if resp.FillStatus == NOMATCH {
+		e.addOrder(order)
+		order.Status = "OPEN"
+		resp.RemainingOrder = &types.Order{}
+	}
+
 	return resp, nil
 }
 
@@ -170,7 +317,8 @@ func (e *Resource) sellOrder(order *types.Order) (resp *Response, err error) {
 	}
 
 	for _, pr := range priceRange {
-		bookEntries, err := redis.ByteSlices(e.redisConn.Do("SORT", obkv+"::"+utils.UintToPaddedString(pr), "GET", obkv+"::"+utils.UintToPaddedString(pr)+"::*", "ALPHA")) // "ZREVRANGEBYLEX" key max min
+		// the orders of the price level are read in the order of their sequence numbers
+		bookEntries, err := redis.ByteSlices(e.redisConn.Do("SORT", obkv+"::"+utils.UintToPaddedString(pr), "BY", "nosort", "GET", obkv+"::"+utils.UintToPaddedString(pr)+"::*"))
 		if err != nil {
 			log.Print(err)
 			return nil, err
@@ -184,6 +332,19 @@ func (e *Resource) sellOrder(order *types.Order) (resp *Response, err error) {
 				return nil, err
 			}
 
+			if e.isSelfTrade(order, bookEntry) {
+				cancelled, err := e.preventSelfTrade(order, bookEntry, resp)
+				if err != nil {
+					return nil, err
+				}
+
+				if cancelled {
+					return resp, nil
+				}
+
+				continue
+			}
+
 			trade, fillOrder, err := e.execute(order, bookEntry)
 			if err != nil {
 				log.Print(err)
@@ -206,6 +367,14 @@ func (e *Resource) sellOrder(order *types.Order) (resp *Response, err error) {
 			resp.Order.Status = "PARTIAL_FILLED"
 		}
 	}
+
+	// the orders of the price range were all cancelled by the self-trade prevention
+	if resp.FillStatus == NOMATCH {
+		e.addOrder(order)
+		order.Status = "OPEN"
+		resp.RemainingOrder = &types.Order{}
+	}
+
 	return
 }
 
@@ -226,8 +395,11 @@ func (e *Resource) addOrder(order *types.Order) error {
 		return err
 	}
 
-	// Add order to list
-	orderAsBytes, err := json.Marshal(order)
+	// Add order to list, the pipeline timings and the origin of the order are not stored
+	stored := *order
+	stored.Timings = nil
+	stored.Origin = ""
+	orderAsBytes, err := json.Marshal(&stored)
 	if err != nil {
 		log.Print(err)
 		return err
@@ -240,7 +412,7 @@ func (e *Resource) addOrder(order *types.Order) error {
 	}
 
 	// Add order reference to price sorted set
-	_, err = e.redisConn.Do("ZADD", listKey, "NX", order.CreatedAt.Unix(), order.Hash.Hex())
+	_, err = e.redisConn.Do("ZADD", listKey, "NX", order.Sequence, order.Hash.Hex())
 	if err != nil {
 		log.Print(err)
 		return err
@@ -413,8 +585,30 @@ func (e *Resource) deleteOrder(order *types.Order, tradeAmount *big.Int) (err er
 // RecoverOrders is responsible for recovering the orders that failed to execute after matching
 // Orders are updated or added to orderbook based on whether that order exists in orderbook or not.
 func (e *Resource) RecoverOrders(orders []*FillOrder) error {
+	for _, o := range orders {
+		err := e.pairResource(o.Order.GetKVPrefix()).recoverOrder(o)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recoverOrder puts the filled amount of a matching order back in the orderbook of its
+// pair
+func (e *Resource) recoverOrder(o *FillOrder) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
+
+	return e.orderBook().Sync(o.Order.GetKVPrefix(), func() error {
+		return e.recoverOrders([]*FillOrder{o})
+	})
+}
+
+// recoverOrders puts the filled amounts of matching orders back in the orderbook. The
+// engine lock must be held by the caller.
+func (e *Resource) recoverOrders(orders []*FillOrder) error {
 	for _, o := range orders {
 
 		// update order's filled amount and status before updating in redis
@@ -455,11 +649,27 @@ func (e *Resource) RecoverOrders2(hashes []common.Hash, amounts []*big.Int) erro
 	return nil
 }
 
-// CancelOrder is used to cancel the order from orderbook
+// CancelOrder is used to cancel the order from orderbook. It returns a RateLimitError if
+// the cancel violates the quote throttling of the pair of the order.
 func (e *Resource) CancelOrder(order *types.Order) (*Response, error) {
+	if p := e.pairResource(order.GetKVPrefix()); p != e {
+		return p.CancelOrder(order)
+	}
+
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
+	err := e.throttleCancel(order, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return e.orderBook().Cancel(order)
+}
+
+// cancelOrder removes an order from the orderbook. The engine lock must be held by the
+// caller.
+func (e *Resource) cancelOrder(order *types.Order) (*Response, error) {
 	_, listKey := order.GetOBKeys()
 	res, err := redis.Bytes(e.redisConn.Do("GET", listKey+"::"+order.Hash.Hex()))
 	if err != nil {
@@ -494,3 +704,74 @@ func (e *Resource) CancelOrder(order *types.Order) (*Response, error) {
 	}
 	return engineResponse, nil
 }
+
+// ReduceOrder reduces the remaining amount of an order of the orderbook by an amount that
+// was filled outside of the engine (eg. filled on-chain by a third party). The order is
+// removed from the orderbook once it is completely filled.
+func (e *Resource) ReduceOrder(order *types.Order, amount *big.Int) (*Response, error) {
+	if p := e.pairResource(order.GetKVPrefix()); p != e {
+		return p.ReduceOrder(order, amount)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var resp *Response
+	err := e.orderBook().Sync(order.GetKVPrefix(), func() (err error) {
+		resp, err = e.reduceOrder(order, amount)
+		return
+	})
+
+	return resp, err
+}
+
+// reduceOrder reduces the remaining amount of an order of the redis orderbook. The engine
+// lock must be held by the caller.
+func (e *Resource) reduceOrder(order *types.Order, amount *big.Int) (*Response, error) {
+	_, listKey := order.GetOBKeys()
+	res, err := redis.Bytes(e.redisConn.Do("GET", listKey+"::"+order.Hash.Hex()))
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	if res == nil {
+		return nil, errors.New("Order not found")
+	}
+
+	var stored *types.Order
+	if err := json.Unmarshal(res, &stored); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	engineResponse := &Response{
+		Order:          stored,
+		Trades:         make([]*types.Trade, 0),
+		RemainingOrder: &types.Order{},
+		MatchingOrders: make([]*FillOrder, 0),
+	}
+
+	remaining := math.Sub(stored.Amount, stored.FilledAmount)
+	if math.IsSmallerThan(amount, remaining) {
+		if err := e.updateOrder(stored, amount); err != nil {
+			log.Print(err)
+			return nil, err
+		}
+
+		stored.FilledAmount = math.Add(stored.FilledAmount, amount)
+		stored.Status = "PARTIAL_FILLED"
+		engineResponse.FillStatus = PARTIAL
+		return engineResponse, nil
+	}
+
+	if err := e.deleteOrder(stored, remaining); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	stored.FilledAmount = stored.Amount
+	stored.Status = "FILLED"
+	engineResponse.FillStatus = FULL
+	return engineResponse, nil
+}
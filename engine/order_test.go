@@ -1696,3 +1696,77 @@ func TestPartialMatchOrder1(t *testing.T) {
 	resBytes, _ = json.Marshal(response)
 	assert.JSONEq(t, string(erBytes), string(resBytes))
 }
+
+func TestReduceOrder(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	o := &types.Order{
+		ID:              bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		UserAddress:     common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		BuyToken:        common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		SellToken:       common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BaseToken:       common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken:      common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BuyAmount:       big.NewInt(6000000000),
+		SellAmount:      big.NewInt(13800000000),
+		Price:           big.NewInt(229999999),
+		PricePoint:      big.NewInt(229999999),
+		Amount:          big.NewInt(6000000000),
+		FilledAmount:    big.NewInt(0),
+		Status:          "OPEN",
+		Side:            "SELL",
+		PairName:        "ZRX/WETH",
+		Expires:         big.NewInt(10000),
+		MakeFee:         big.NewInt(50),
+		Nonce:           big.NewInt(1000),
+		TakeFee:         big.NewInt(50),
+		Signature:       &types.Signature{V: 28},
+		Hash:            common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880c"),
+		CreatedAt:       time.Unix(1405544146, 0),
+		UpdatedAt:       time.Unix(1405544146, 0),
+	}
+
+	e.addOrder(o)
+	ssKey, listKey := o.GetOBKeys()
+	bookKey := ssKey + "::book::" + utils.UintToPaddedString(o.PricePoint.Int64())
+
+	// partial fill
+	res, err := e.ReduceOrder(o, big.NewInt(1000000000))
+	if err != nil {
+		t.Fatalf("Error while reducing order: %s", err)
+	}
+
+	assert.Equal(t, PARTIAL, res.FillStatus)
+	assert.Equal(t, "PARTIAL_FILLED", res.Order.Status)
+	assert.Equal(t, big.NewInt(1000000000), res.Order.FilledAmount)
+
+	volume, err := getValue(e.redisConn, bookKey)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, "5000000000", volume)
+
+	// the fill is larger than the remaining amount
+	res, err = e.ReduceOrder(o, big.NewInt(6000000000))
+	if err != nil {
+		t.Fatalf("Error while reducing order: %s", err)
+	}
+
+	assert.Equal(t, FULL, res.FillStatus)
+	assert.Equal(t, "FILLED", res.Order.Status)
+	assert.Equal(t, o.Amount, res.Order.FilledAmount)
+
+	if exists(e.redisConn, listKey+"::"+o.Hash.Hex()) {
+		t.Errorf("Key : %v expected to be deleted but key exists", listKey+"::"+o.Hash.Hex())
+	}
+
+	if exists(e.redisConn, bookKey) {
+		t.Errorf("Key : %v expected to be deleted but key exists", bookKey)
+	}
+
+	_, err = e.ReduceOrder(o, big.NewInt(1))
+	assert.NotNil(t, err)
+}
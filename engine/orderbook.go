@@ -1,16 +1,51 @@
 package engine
 
 import (
+	"encoding/json"
 	"log"
-	"math"
+	"strconv"
 
 	"github.com/gomodule/redigo/redis"
 
 	"github.com/Proofsuite/amp-matching-engine/types"
 )
 
-// GetOrderBook fetches the complete orderbook from redis for the required pair
+// GetOrderBook fetches the complete orderbook of the required pair from the orderbook
+// backend
 func (e *Resource) GetOrderBook(pair *types.Pair) (sellBook, buyBook []*map[string]float64) {
+	book := e.orderBook()
+	if _, ok := book.(*memoryOrderBook); ok {
+		// the in-memory orderbook of a pair is held by the resource of the pair
+		book = e.pairResource(pairPrefix(pair)).orderBook()
+	}
+
+	return book.Depth(pair)
+}
+
+// GetOrderBookLevels returns the price levels of both sides of the orderbook of a pair
+// from the orderbook backend
+func (e *Resource) GetOrderBookLevels(pair *types.Pair) (asks, bids []*types.BookLevel, err error) {
+	book := e.orderBook()
+	if _, ok := book.(*memoryOrderBook); ok {
+		book = e.pairResource(pairPrefix(pair)).orderBook()
+	}
+
+	return book.Levels(pair)
+}
+
+// GetRawOrderBook returns the resting orders of both sides of the orderbook of a pair
+// from the orderbook backend
+func (e *Resource) GetRawOrderBook(pair *types.Pair) (asks, bids []*types.Order, err error) {
+	book := e.orderBook()
+	if _, ok := book.(*memoryOrderBook); ok {
+		book = e.pairResource(pairPrefix(pair)).orderBook()
+	}
+
+	return book.Orders(pair)
+}
+
+// redisDepth fetches the complete orderbook from redis for the required pair
+func (e *Resource) redisDepth(pair *types.Pair) (sellBook, buyBook []*map[string]float64) {
 	sKey, bKey := pair.GetOrderBookKeys()
 	res, err := redis.Int64s(e.redisConn.Do("SORT", sKey, "GET", sKey+"::book::*", "GET", "#")) // Add price point to order book
 	if err != nil {
@@ -18,11 +53,7 @@ func (e *Resource) GetOrderBook(pair *types.Pair) (sellBook, buyBook []*map[stri
 	}
 
 	for i := 0; i < len(res); i = i + 2 {
-		temp := &map[string]float64{
-			"volume": float64(res[i]) / math.Pow10(8),
-			"price":  float64(res[i+1]) / math.Pow10(8),
-		}
-		sellBook = append(sellBook, temp)
+		sellBook = append(sellBook, depthLevel(res[i+1], res[i]))
 	}
 
 	res, err = redis.Int64s(e.redisConn.Do("SORT", bKey, "GET", bKey+"::book::*", "GET", "#", "DESC"))
@@ -31,12 +62,135 @@ func (e *Resource) GetOrderBook(pair *types.Pair) (sellBook, buyBook []*map[stri
 	}
 
 	for i := 0; i < len(res); i = i + 2 {
-		temp := &map[string]float64{
-			"volume": float64(res[i]) / math.Pow10(8),
-			"price":  float64(res[i+1]) / math.Pow10(8),
-		}
-		buyBook = append(buyBook, temp)
+		buyBook = append(buyBook, depthLevel(res[i+1], res[i]))
 	}
 
 	return
 }
+
+// redisLevels returns the price levels of both sides of the orderbook of a pair stored in
+// redis, best price first. The number of orders of a level is the size of its order list.
+func (e *Resource) redisLevels(pair *types.Pair) (asks, bids []*types.BookLevel, err error) {
+	sKey, bKey := pair.GetOrderBookKeys()
+	asks, err = e.redisSideLevels(sKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bids, err = e.redisSideLevels(bKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the pricepoints are sorted in ascending order, the best bid is the highest one
+	for i, j := 0, len(bids)-1; i < j; i, j = i+1, j-1 {
+		bids[i], bids[j] = bids[j], bids[i]
+	}
+
+	return asks, bids, nil
+}
+
+// redisSideLevels returns the price levels of a side of an orderbook stored in redis by
+// ascending pricepoint
+func (e *Resource) redisSideLevels(ssKey string) ([]*types.BookLevel, error) {
+	pricePoints, err := redis.Strings(e.redisConn.Do("ZRANGE", ssKey, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	levels := []*types.BookLevel{}
+	for _, pp := range pricePoints {
+		pricepoint, err := strconv.ParseInt(pp, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		volume, err := redis.Int64(e.redisConn.Do("GET", ssKey+"::book::"+pp))
+		if err == redis.ErrNil {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		orders, err := redis.Int(e.redisConn.Do("ZCARD", ssKey+"::"+pp))
+		if err != nil {
+			return nil, err
+		}
+
+		levels = append(levels, &types.BookLevel{PricePoint: pricepoint, Volume: volume, Orders: orders})
+	}
+
+	return levels, nil
+}
+
+// redisOrders returns the resting orders of both sides of the orderbook of a pair stored
+// in redis, best price first and in time priority within a price level
+func (e *Resource) redisOrders(pair *types.Pair) (asks, bids []*types.Order, err error) {
+	sKey, bKey := pair.GetOrderBookKeys()
+	askLevels, err := e.redisSideOrders(sKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bidLevels, err := e.redisSideOrders(bKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	asks = []*types.Order{}
+	for _, l := range askLevels {
+		asks = append(asks, l...)
+	}
+
+	// the pricepoints are sorted in ascending order, the best bid is the highest one
+	bids = []*types.Order{}
+	for i := len(bidLevels) - 1; i >= 0; i-- {
+		bids = append(bids, bidLevels[i]...)
+	}
+
+	return asks, bids, nil
+}
+
+// redisSideOrders returns the orders of the price levels of a side of an orderbook stored
+// in redis by ascending pricepoint. The orders of a level are sorted by sequence number.
+func (e *Resource) redisSideOrders(ssKey string) ([][]*types.Order, error) {
+	pricePoints, err := redis.Strings(e.redisConn.Do("ZRANGE", ssKey, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	levels := [][]*types.Order{}
+	for _, pp := range pricePoints {
+		listKey := ssKey + "::" + pp
+		hashes, err := redis.Strings(e.redisConn.Do("ZRANGE", listKey, 0, -1))
+		if err != nil {
+			return nil, err
+		}
+
+		orders := []*types.Order{}
+		for _, hash := range hashes {
+			bytes, err := redis.Bytes(e.redisConn.Do("GET", listKey+"::"+hash))
+			if err == redis.ErrNil {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			o := &types.Order{}
+			err = json.Unmarshal(bytes, o)
+			if err != nil {
+				return nil, err
+			}
+
+			orders = append(orders, o)
+		}
+
+		levels = append(levels, orders)
+	}
+
+	return levels, nil
+}
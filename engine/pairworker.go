@@ -0,0 +1,270 @@
+package engine
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/go-ethereum/common"
+	"github.com/gomodule/redigo/redis"
+	"github.com/streadway/amqp"
+)
+
+// pairQueueSize is the number of messages waiting to be matched by the goroutine of a
+// pair before the messages of the other pairs are held back. The waiting messages are
+// not acknowledged yet.
+const pairQueueSize = 1024
+
+// pairWorker matches the orders of a pair in a goroutine of its own. Its resource has
+// its own lock and redis connection, so that the pairs are matched in parallel.
+type pairWorker struct {
+	e        *Resource
+	messages chan *pairMessage
+}
+
+// pairMessage is a message of the order queue waiting to be handled by a pair worker
+type pairMessage struct {
+	msg *Message
+	d   amqp.Delivery
+}
+
+// publication is an engine response or a reply sent by a pair worker, published on
+// rabbitmq by the fan-in goroutine of the engine. The response is marshalled by the pair
+// worker since its orders can be modified by the next messages of the pair. A publication
+// with a delivery acknowledges the message of the order queue handled by the worker,
+// after the responses of the message are published.
+type publication struct {
+	body          []byte
+	replyTo       string
+	correlationID string
+	delivery      *amqp.Delivery
+}
+
+// pairWorkers holds the workers of the pairs, created when the first message of a pair
+// is received
+type pairWorkers struct {
+	dial         func() redis.Conn
+	workers      map[string]*pairWorker
+	publications chan *publication
+	mutex        sync.Mutex
+}
+
+// SetPairWorkers matches each pair in a goroutine of its own instead of matching all the
+// pairs in turn. The resource of a pair uses a redis connection returned by dial. The
+// engine responses of all the pairs are published on rabbitmq by a single goroutine.
+func (e *Resource) SetPairWorkers(dial func() redis.Conn) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.pairs != nil {
+		return
+	}
+
+	e.pairs = &pairWorkers{
+		dial:         dial,
+		workers:      make(map[string]*pairWorker),
+		publications: make(chan *publication, pairQueueSize),
+	}
+
+	go e.fanInPublications(e.pairs.publications)
+}
+
+// fanInPublications publishes the engine responses and the replies of the pair workers
+func (e *Resource) fanInPublications(publications <-chan *publication) {
+	for p := range publications {
+		if p.delivery != nil {
+			ack(*p.delivery)
+			continue
+		}
+
+		var err error
+		if p.replyTo == "" {
+			err = publishResponseBytes(p.body)
+		} else {
+			err = publishReplyBytes(p.replyTo, p.correlationID, p.body)
+		}
+
+		if err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// pairResource returns the resource matching the pair of a redis key prefix, e itself if
+// the pairs are not matched in their own goroutine or if e is the resource of a pair
+func (e *Resource) pairResource(prefix string) *Resource {
+	if w := e.pairWorker(prefix); w != nil {
+		return w.e
+	}
+
+	return e
+}
+
+// pairWorker returns the worker of the pair of a redis key prefix, started if needed. It
+// returns nil if the pairs are not matched in their own goroutine.
+func (e *Resource) pairWorker(prefix string) *pairWorker {
+	if e.pairs == nil || prefix == "" {
+		return nil
+	}
+
+	e.pairs.mutex.Lock()
+	w, ok := e.pairs.workers[prefix]
+	e.pairs.mutex.Unlock()
+	if ok {
+		return w
+	}
+
+	// the engine lock is taken before the lock of the workers, as by updatePairResources
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.pairs.mutex.Lock()
+	defer e.pairs.mutex.Unlock()
+
+	if w, ok := e.pairs.workers[prefix]; ok {
+		return w
+	}
+
+	p := &Resource{
+		redisConn:    e.fence(e.pairs.dial()),
+		mutex:        &sync.Mutex{},
+		lease:        e.lease,
+		shard:        e.shard,
+		shards:       e.shards,
+		quoteUpdates: make(map[string][]time.Time),
+		publications: e.pairs.publications,
+	}
+
+	e.copySettings(p)
+
+	w = &pairWorker{e: p, messages: make(chan *pairMessage, pairQueueSize)}
+	e.pairs.workers[prefix] = w
+	go w.run()
+	return w
+}
+
+// run handles the messages of the pair of the worker in order
+func (w *pairWorker) run() {
+	for m := range w.messages {
+		w.e.handleMessage(m.msg, m.d)
+
+		d := m.d
+		w.e.publications <- &publication{delivery: &d}
+	}
+}
+
+// copySettings copies the settings of the engine to the resource of a pair. The engine
+// lock must be held by the caller.
+func (e *Resource) copySettings(p *Resource) {
+	p.selfTrade = e.selfTrade
+	p.throttles = e.throttles
+	p.bookLimits = e.bookLimits
+
+	m, ok := e.book.(*memoryOrderBook)
+	if !ok {
+		p.book = nil
+		return
+	}
+
+	if pm, ok := p.book.(*memoryOrderBook); !ok || pm.writer != m.writer {
+		p.book = newMemoryOrderBook(p, m.writer)
+	}
+}
+
+// updatePairResources copies the settings of the engine to the resources of the pairs.
+// The engine lock must be held by the caller.
+func (e *Resource) updatePairResources() {
+	if e.pairs == nil {
+		return
+	}
+
+	e.pairs.mutex.Lock()
+	defer e.pairs.mutex.Unlock()
+
+	for _, w := range e.pairs.workers {
+		w.e.mutex.Lock()
+		e.copySettings(w.e)
+		w.e.quoteUpdates = make(map[string][]time.Time)
+		w.e.mutex.Unlock()
+	}
+}
+
+// dispatchMessage sends a message of the order queue to the worker of its pair, or
+// handles it if the pairs are not matched in their own goroutine
+func (e *Resource) dispatchMessage(msg *Message, d amqp.Delivery) {
+	w := e.pairWorker(messagePrefix(msg))
+	if w == nil {
+		e.handleMessage(msg, d)
+
+		// the message can be handled by the resources of the pairs, whose responses are
+		// published by the fan-in goroutine
+		if e.pairs != nil {
+			e.pairs.publications <- &publication{delivery: &d}
+		} else {
+			ack(d)
+		}
+
+		return
+	}
+
+	w.messages <- &pairMessage{msg, d}
+}
+
+// messagePrefix returns the redis key prefix of the pair of a message, "" if the message
+// is not about a single pair
+func messagePrefix(msg *Message) string {
+	type pairOrder struct {
+		BaseToken  common.Address            `json:"baseToken"`
+		QuoteToken common.Address            `json:"quoteToken"`
+		ZeroEx     *types.ZeroExFieldsRecord `json:"zeroEx"`
+	}
+
+	o := &pairOrder{}
+	switch msg.Type {
+	case "NEW_ORDER", "ADD_ORDER", "CANCEL_ORDER", "RESERVE_ORDER":
+		if err := json.Unmarshal(msg.Data, o); err != nil {
+			return ""
+		}
+
+	case "REDUCE_ORDER":
+		m := &struct {
+			Order *pairOrder `json:"order"`
+		}{o}
+
+		if err := json.Unmarshal(msg.Data, m); err != nil {
+			return ""
+		}
+
+	case "MASS_QUOTE":
+		m := &struct {
+			Cancels []*pairOrder `json:"cancels"`
+			Orders  []*pairOrder `json:"orders"`
+		}{}
+
+		if err := json.Unmarshal(msg.Data, m); err != nil {
+			return ""
+		}
+
+		if len(m.Orders) > 0 {
+			o = m.Orders[0]
+		} else if len(m.Cancels) > 0 {
+			o = m.Cancels[0]
+		}
+
+	default:
+		return ""
+	}
+
+	if o.BaseToken == (common.Address{}) {
+		return ""
+	}
+
+	// the 0x orders are handled by the worker of their own orderbook
+	prefix := o.BaseToken.Hex() + "::" + o.QuoteToken.Hex()
+	if o.ZeroEx != nil {
+		return prefix + types.ZeroExOrderBook
+	}
+
+	return prefix
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/gomodule/redigo/redis"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessagePrefix(t *testing.T) {
+	o := listingOrder("BUY", 100000000, "0x1")
+	bytes, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, o.GetKVPrefix(), messagePrefix(&Message{Type: "NEW_ORDER", Data: bytes}))
+	assert.Equal(t, o.GetKVPrefix(), messagePrefix(&Message{Type: "CANCEL_ORDER", Data: bytes}))
+
+	reduce, err := json.Marshal(map[string]interface{}{"order": o, "amount": "1000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, o.GetKVPrefix(), messagePrefix(&Message{Type: "REDUCE_ORDER", Data: reduce}))
+
+	quote, err := json.Marshal(map[string]interface{}{"cancels": []*types.Order{}, "orders": []*types.Order{o}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, o.GetKVPrefix(), messagePrefix(&Message{Type: "MASS_QUOTE", Data: quote}))
+
+	// the 0x orders are handled by the worker of their own orderbook
+	o.ZeroEx = &types.ZeroExFields{}
+	zeroEx, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, o.GetKVPrefix(), messagePrefix(&Message{Type: "NEW_ORDER", Data: zeroEx}))
+	assert.NotEqual(t, o.GetKVPrefix(), messagePrefix(&Message{Type: "NEW_ORDER", Data: bytes}))
+
+	// the messages that are not about a single pair are handled by the engine
+	assert.Equal(t, "", messagePrefix(&Message{Type: "RECOVER_ORDERS", Data: bytes}))
+	assert.Equal(t, "", messagePrefix(&Message{Type: "NEW_ORDER", Data: []byte("{}")}))
+}
+
+func TestPairWorkers(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	o := listingOrder("BUY", 100000000, "0x1")
+	assert.Equal(t, e, e.pairResource(o.GetKVPrefix()))
+
+	e.SetPairWorkers(func() redis.Conn { return e.redisConn })
+	assert.Nil(t, e.SetSelfTradePrevention(CancelNewest))
+
+	p := e.pairResource(o.GetKVPrefix())
+	assert.NotEqual(t, e, p)
+	assert.Equal(t, p, e.pairResource(o.GetKVPrefix()))
+	assert.Equal(t, e, e.pairResource(""))
+	assert.Equal(t, CancelNewest, p.selfTrade)
+
+	// the settings of the engine are copied to the resources of the pairs
+	assert.Nil(t, e.SetSelfTradePrevention(CancelOldest))
+	assert.Equal(t, CancelOldest, p.selfTrade)
+
+	// the operations on an order are made by the resource of its pair
+	assert.Nil(t, p.orderBook().Add(o))
+	res, err := e.CancelOrder(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, CANCELLED, res.FillStatus)
+	assert.False(t, inBook(e, o))
+}
+
+// acknowledger records the tags of the acknowledged deliveries
+type acknowledger struct {
+	acked []uint64
+}
+
+func (a *acknowledger) Ack(tag uint64, multiple bool) error {
+	a.acked = append(a.acked, tag)
+	return nil
+}
+
+func (a *acknowledger) Nack(tag uint64, multiple, requeue bool) error { return nil }
+
+func (a *acknowledger) Reject(tag uint64, requeue bool) error { return nil }
+
+func TestFanInAcknowledgesDeliveries(t *testing.T) {
+	a := &acknowledger{}
+	publications := make(chan *publication, 2)
+	publications <- &publication{delivery: &amqp.Delivery{Acknowledger: a, DeliveryTag: 7}}
+	publications <- &publication{delivery: &amqp.Delivery{Acknowledger: a, DeliveryTag: 8}}
+	close(publications)
+
+	e := &Resource{}
+	e.fanInPublications(publications)
+
+	assert.Equal(t, []uint64{7, 8}, a.acked)
+}
+
+func TestHandleResponsesInOrder(t *testing.T) {
+	responses := make(chan *Response, 3)
+	for _, status := range []string{"OPEN", "PARTIAL_FILLED", "CANCELLED"} {
+		responses <- &Response{Order: &types.Order{Status: status}}
+	}
+	close(responses)
+
+	handled := []string{}
+	handleResponses(responses, func(er *Response) error {
+		handled = append(handled, er.Order.Status)
+		return nil
+	})
+
+	assert.Equal(t, []string{"OPEN", "PARTIAL_FILLED", "CANCELLED"}, handled)
+}
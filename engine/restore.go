@@ -0,0 +1,309 @@
+package engine
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gomodule/redigo/redis"
+)
+
+// RestoreReport lists the divergences between the open orders of a pair stored in the
+// database and its orderbook found by RestoreOrderBook or CheckOrderBook. Restored are
+// the orders missing from the orderbook, Updated the orders whose remaining amount or
+// price differed and Removed the orders of the orderbook that are not open in the
+// database. Levels is the number of price levels whose volume was wrong. The divergences
+// are only repaired by RestoreOrderBook.
+type RestoreReport struct {
+	Pair     string        `json:"pair"`
+	Orders   int           `json:"orders"`
+	Restored []common.Hash `json:"restored"`
+	Updated  []common.Hash `json:"updated"`
+	Removed  []common.Hash `json:"removed"`
+	Levels   int           `json:"levels"`
+}
+
+// Consistent returns true if the orderbook matched the database
+func (r *RestoreReport) Consistent() bool {
+	return len(r.Restored) == 0 && len(r.Updated) == 0 && len(r.Removed) == 0 && r.Levels == 0
+}
+
+// RestoreOrderBook rebuilds the orderbook of a pair from its open orders (OPEN and
+// PARTIAL_FILLED) stored in the database, which are the reference: the missing orders
+// are added, the orders that are not open anymore are removed and the volumes of the
+// price levels are recomputed. The orders are not matched.
+func (e *Resource) RestoreOrderBook(pair *types.Pair, orders []*types.Order) (*RestoreReport, error) {
+	return e.reconcile(pair, orders, true)
+}
+
+// CheckOrderBook compares the orderbook of a pair with its open orders stored in the
+// database like RestoreOrderBook, without repairing the divergences
+func (e *Resource) CheckOrderBook(pair *types.Pair, orders []*types.Order) (*RestoreReport, error) {
+	return e.reconcile(pair, orders, false)
+}
+
+// reconcile compares the orderbook of a pair with its open orders and repairs the
+// divergences if repair is true
+func (e *Resource) reconcile(pair *types.Pair, orders []*types.Order, repair bool) (*RestoreReport, error) {
+	if p := e.pairResource(pairPrefix(pair)); p != e {
+		return p.reconcile(pair, orders, repair)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var report *RestoreReport
+	err := e.orderBook().Sync(pairPrefix(pair), func() (err error) {
+		report, err = e.reconcileSides(pair, orders, repair)
+		return
+	})
+
+	return report, err
+}
+
+// reconcileSides compares both sides of the redis orderbook of a pair with its open
+// orders. The engine lock must be held by the caller.
+func (e *Resource) reconcileSides(pair *types.Pair, orders []*types.Order, repair bool) (*RestoreReport, error) {
+	report := &RestoreReport{
+		Pair:     pair.Name,
+		Orders:   len(orders),
+		Restored: []common.Hash{},
+		Updated:  []common.Hash{},
+		Removed:  []common.Hash{},
+	}
+
+	sKey, bKey := pair.GetOrderBookKeys()
+	sides := map[string][]*types.Order{sKey: {}, bKey: {}}
+	for _, o := range orders {
+		ssKey, _ := o.GetOBKeys()
+		if _, ok := sides[ssKey]; ok {
+			sides[ssKey] = append(sides[ssKey], o)
+		}
+	}
+
+	for ssKey, expected := range sides {
+		err := e.restoreSide(ssKey, expected, report, repair)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// restoreSide compares one side of an orderbook with its open orders and restores it if
+// repair is true. The engine lock must be held by the caller.
+func (e *Resource) restoreSide(ssKey string, expected []*types.Order, report *RestoreReport, repair bool) error {
+	stored, err := e.sideOrders(ssKey)
+	if err != nil {
+		return err
+	}
+
+	open := make(map[common.Hash]*types.Order)
+	for _, o := range expected {
+		open[o.Hash] = o
+	}
+
+	for hash, entry := range stored {
+		if _, ok := open[hash]; ok {
+			continue
+		}
+
+		report.Removed = append(report.Removed, hash)
+		if repair {
+			err := e.removeOrderEntry(entry.listKey, hash)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	volumes := make(map[string]*big.Int)
+	for _, o := range expected {
+		pp := utils.UintToPaddedString(o.PricePoint.Int64())
+		remaining := math.Sub(o.Amount, o.FilledAmount)
+		if volumes[pp] == nil {
+			volumes[pp] = big.NewInt(0)
+		}
+
+		volumes[pp] = math.Add(volumes[pp], remaining)
+
+		entry, ok := stored[o.Hash]
+		switch {
+		case !ok:
+			report.Restored = append(report.Restored, o.Hash)
+		case entry.diverges(o):
+			report.Updated = append(report.Updated, o.Hash)
+		default:
+			continue
+		}
+
+		if !repair {
+			continue
+		}
+
+		if ok {
+			err := e.removeOrderEntry(entry.listKey, o.Hash)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := e.setOrderEntry(o)
+		if err != nil {
+			return err
+		}
+	}
+
+	levels, err := redis.Strings(e.redisConn.Do("ZRANGE", ssKey, 0, -1))
+	if err != nil {
+		return err
+	}
+
+	for _, pp := range levels {
+		if volumes[pp] == nil {
+			report.Levels++
+			if !repair {
+				continue
+			}
+
+			_, err := e.redisConn.Do("ZREM", ssKey, pp)
+			if err != nil {
+				return err
+			}
+
+			_, err = e.redisConn.Do("DEL", ssKey+"::book::"+pp)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for pp, volume := range volumes {
+		current, err := redis.Int64(e.redisConn.Do("GET", ssKey+"::book::"+pp))
+		if err != nil && err != redis.ErrNil {
+			return err
+		}
+
+		if err == nil && current == volume.Int64() {
+			continue
+		}
+
+		report.Levels++
+		if !repair {
+			continue
+		}
+
+		_, err = e.redisConn.Do("ZADD", ssKey, "NX", 0, pp)
+		if err != nil {
+			return err
+		}
+
+		_, err = e.redisConn.Do("SET", ssKey+"::book::"+pp, volume.Int64())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bookEntry is an order stored in a price level of an orderbook. The order is nil if the
+// price level references an order that is not stored.
+type bookEntry struct {
+	listKey string
+	order   *types.Order
+}
+
+// diverges returns true if the stored order is not at the price or does not have the
+// remaining amount of the open order o
+func (b *bookEntry) diverges(o *types.Order) bool {
+	if b.order == nil || b.order.PricePoint == nil || b.order.Amount == nil || b.order.FilledAmount == nil {
+		return true
+	}
+
+	remaining := math.Sub(o.Amount, o.FilledAmount)
+	return b.order.PricePoint.Cmp(o.PricePoint) != 0 || math.Sub(b.order.Amount, b.order.FilledAmount).Cmp(remaining) != 0
+}
+
+// sideOrders returns the orders stored in one side of an orderbook by hash
+func (e *Resource) sideOrders(ssKey string) (map[common.Hash]*bookEntry, error) {
+	orders := make(map[common.Hash]*bookEntry)
+
+	levels, err := redis.Strings(e.redisConn.Do("ZRANGE", ssKey, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pp := range levels {
+		listKey := ssKey + "::" + pp
+		hashes, err := redis.Strings(e.redisConn.Do("ZRANGE", listKey, 0, -1))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, h := range hashes {
+			entry := &bookEntry{listKey: listKey}
+			orders[common.HexToHash(h)] = entry
+
+			bytes, err := redis.Bytes(e.redisConn.Do("GET", listKey+"::"+h))
+			if err == redis.ErrNil {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			o := &types.Order{}
+			err = json.Unmarshal(bytes, o)
+			if err != nil {
+				return nil, err
+			}
+
+			entry.order = o
+		}
+	}
+
+	return orders, nil
+}
+
+// setOrderEntry stores an order in its price level without changing the volume of the level
+func (e *Resource) setOrderEntry(o *types.Order) error {
+	_, listKey := o.GetOBKeys()
+
+	// the orders stored before sequence numbers were assigned get one in the order of
+	// the restore, which lists them oldest first
+	err := e.sequence(o)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.redisConn.Do("SET", listKey+"::"+o.Hash.Hex(), string(bytes))
+	if err != nil {
+		return err
+	}
+
+	_, err = e.redisConn.Do("ZADD", listKey, "NX", o.Sequence, o.Hash.Hex())
+	return err
+}
+
+// removeOrderEntry removes an order from a price level without changing the volume of
+// the level
+func (e *Resource) removeOrderEntry(listKey string, hash common.Hash) error {
+	_, err := e.redisConn.Do("DEL", listKey+"::"+hash.Hex())
+	if err != nil {
+		return err
+	}
+
+	_, err = e.redisConn.Do("ZREM", listKey, hash.Hex())
+	return err
+}
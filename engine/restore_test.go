@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreOrderBook(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	pair := &types.Pair{
+		Name:              "ZRX/WETH",
+		BaseTokenAddress:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteTokenAddress: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+	}
+
+	// the orderbook holds an order that is not open anymore and a partially filled order
+	// with an outdated filled amount
+	stale := listingOrder("SELL", 229999999, "0x1")
+	partial := listingOrder("SELL", 229999999, "0x2")
+	e.addOrder(stale)
+	e.addOrder(partial)
+
+	partial.FilledAmount = big.NewInt(1000000000)
+	partial.Status = "PARTIAL_FILLED"
+	missing := listingOrder("BUY", 200000000, "0x3")
+	missing.Status = "OPEN"
+
+	report, err := e.RestoreOrderBook(pair, []*types.Order{partial, missing})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, report.Consistent())
+	assert.Equal(t, []common.Hash{stale.Hash}, report.Removed)
+	assert.Equal(t, []common.Hash{partial.Hash}, report.Updated)
+	assert.Equal(t, []common.Hash{missing.Hash}, report.Restored)
+	assert.Equal(t, 2, report.Levels)
+
+	assert.False(t, inBook(e, stale))
+	assert.True(t, inBook(e, partial))
+	assert.True(t, inBook(e, missing))
+
+	sells, buys := e.GetOrderBook(pair)
+	assert.Equal(t, 1, len(sells))
+	assert.Equal(t, float64(50), (*sells[0])["volume"])
+	assert.Equal(t, 1, len(buys))
+	assert.Equal(t, float64(60), (*buys[0])["volume"])
+
+	// the orderbook is consistent once restored
+	report, err = e.RestoreOrderBook(pair, []*types.Order{partial, missing})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, report.Consistent())
+}
+
+func TestCheckOrderBook(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	pair := &types.Pair{
+		Name:              "ZRX/WETH",
+		BaseTokenAddress:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteTokenAddress: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+	}
+
+	stale := listingOrder("SELL", 229999999, "0x1")
+	e.addOrder(stale)
+	missing := listingOrder("BUY", 200000000, "0x2")
+	missing.Status = "OPEN"
+
+	report, err := e.CheckOrderBook(pair, []*types.Order{missing})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, report.Consistent())
+	assert.Equal(t, []common.Hash{stale.Hash}, report.Removed)
+	assert.Equal(t, []common.Hash{missing.Hash}, report.Restored)
+	assert.Equal(t, 2, report.Levels)
+
+	// the divergences are not repaired
+	assert.True(t, inBook(e, stale))
+	assert.False(t, inBook(e, missing))
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+)
+
+// errInsufficientLiquidity is the reason of the rejection of an order that can not be
+// reserved because the orderbook can not fill its whole amount
+const errInsufficientLiquidity = "Insufficient liquidity to fill the order"
+
+// ReserveOrder matches an order against the orderbook as a fill-or-kill order, for the
+// firm quotes of the RFQ flow. The matched maker quantity is removed from the orderbook
+// and the order itself is never added to it. The order is rejected, and the orderbook
+// left unchanged, if it can not be filled completely. The matched quantity is released
+// with RecoverOrders if the quote is not committed.
+func (e *Resource) ReserveOrder(order *types.Order) (*Response, error) {
+	if p := e.pairResource(order.GetKVPrefix()); p != e {
+		return p.ReserveOrder(order)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var resp *Response
+	err := e.orderBook().Sync(order.GetKVPrefix(), func() (err error) {
+		resp, err = e.reserveOrder(order)
+		return
+	})
+
+	return resp, err
+}
+
+// reserveOrder matches an order as a fill-or-kill order on the redis orderbook. The
+// engine lock must be held by the caller.
+func (e *Resource) reserveOrder(order *types.Order) (*Response, error) {
+	if e.isPreLaunch(order.PairName, time.Now()) {
+		return rejectReservation(order, nil), nil
+	}
+
+	var resp *Response
+	var err error
+	if order.Side == "SELL" {
+		resp, err = e.sellOrder(order)
+	} else if order.Side == "BUY" {
+		resp, err = e.buyOrder(order)
+	} else {
+		return nil, errors.New("Invalid order side")
+	}
+
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	if resp.FillStatus == FULL {
+		return resp, nil
+	}
+
+	// the order was added to the orderbook if nothing matched
+	if resp.FillStatus == NOMATCH && order.Status == "OPEN" {
+		err := e.deleteOrder(order, math.Sub(order.Amount, order.FilledAmount))
+		if err != nil {
+			log.Print(err)
+			return nil, err
+		}
+	}
+
+	err = e.recoverOrders(resp.MatchingOrders)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return rejectReservation(order, resp.CancelledOrders), nil
+}
+
+// rejectReservation returns the response of an order that could not be reserved. The
+// orders of the orderbook cancelled by the self-trade prevention stay cancelled.
+func rejectReservation(order *types.Order, cancelled []*types.Order) *Response {
+	order.Status = "REJECTED"
+	order.FilledAmount = math.ToBigInt("0")
+
+	return &Response{
+		Order:           order,
+		Trades:          make([]*types.Trade, 0),
+		RemainingOrder:  &types.Order{},
+		FillStatus:      REJECTED,
+		MatchingOrders:  make([]*FillOrder, 0),
+		CancelledOrders: cancelled,
+		Error:           errInsufficientLiquidity,
+	}
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserveOrder(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	pair := &types.Pair{
+		Name:              "ZRX/WETH",
+		BaseTokenAddress:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteTokenAddress: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+	}
+
+	e.addOrder(listingOrder("SELL", 229999999, "0x1"))
+	e.addOrder(listingOrder("SELL", 229999999, "0x2"))
+
+	// the orderbook can not fill the order, nothing is reserved
+	taker := listingOrder("BUY", 229999999, "0x3")
+	taker.UserAddress = common.HexToAddress("0x1")
+	taker.Amount = big.NewInt(15000000000)
+
+	res, err := e.ReserveOrder(taker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, REJECTED, res.FillStatus)
+	assert.Equal(t, "REJECTED", taker.Status)
+	assert.Equal(t, 0, len(res.MatchingOrders))
+	assert.False(t, inBook(e, taker))
+
+	sells, buys := e.GetOrderBook(pair)
+	assert.Equal(t, float64(120), (*sells[0])["volume"])
+	assert.Equal(t, 0, len(buys))
+
+	// the order is filled by both orders of the orderbook
+	taker = listingOrder("BUY", 229999999, "0x4")
+	taker.UserAddress = common.HexToAddress("0x1")
+	taker.Amount = big.NewInt(9000000000)
+
+	res, err = e.ReserveOrder(taker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, FULL, res.FillStatus)
+	assert.Equal(t, 2, len(res.Trades))
+	assert.False(t, inBook(e, taker))
+
+	sells, _ = e.GetOrderBook(pair)
+	assert.Equal(t, float64(30), (*sells[0])["volume"])
+
+	// the reserved quantity is put back in the orderbook when released
+	err = e.RecoverOrders(res.MatchingOrders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sells, _ = e.GetOrderBook(pair)
+	assert.Equal(t, float64(120), (*sells[0])["volume"])
+}
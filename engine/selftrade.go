@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+)
+
+// Self-trade prevention modes, applied when an order would match an order of the
+// orderbook from the same maker address:
+// - CancelNewest cancels the remaining amount of the incoming order
+// - CancelOldest cancels the order of the orderbook and keeps matching the incoming order
+// - CancelBoth cancels both orders
+// Self-trades are allowed when no mode is set.
+const (
+	CancelNewest = "cancel-newest"
+	CancelOldest = "cancel-oldest"
+	CancelBoth   = "cancel-both"
+)
+
+// SetSelfTradePrevention sets the self-trade prevention mode of the engine. An empty mode
+// allows self-trades.
+func (e *Resource) SetSelfTradePrevention(mode string) error {
+	switch mode {
+	case "", CancelNewest, CancelOldest, CancelBoth:
+	default:
+		return fmt.Errorf("Invalid self-trade prevention mode %q", mode)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.selfTrade = mode
+	e.updatePairResources()
+	return nil
+}
+
+// isSelfTrade returns true if matching order against bookEntry would be a self-trade
+// prevented by the engine
+func (e *Resource) isSelfTrade(order *types.Order, bookEntry *types.Order) bool {
+	return e.selfTrade != "" && order.UserAddress == bookEntry.UserAddress
+}
+
+// preventSelfTrade applies the self-trade prevention mode to an incoming order and an
+// order of the orderbook from the same maker. It returns true if the incoming order is
+// cancelled and the matching must stop.
+func (e *Resource) preventSelfTrade(order *types.Order, bookEntry *types.Order, resp *Response) (bool, error) {
+	if e.selfTrade == CancelOldest || e.selfTrade == CancelBoth {
+		err := e.deleteOrder(bookEntry, math.Sub(bookEntry.Amount, bookEntry.FilledAmount))
+		if err != nil {
+			log.Print(err)
+			return false, err
+		}
+
+		bookEntry.Status = "CANCELLED"
+		resp.CancelledOrders = append(resp.CancelledOrders, bookEntry)
+	}
+
+	if e.selfTrade == CancelOldest {
+		return false, nil
+	}
+
+	// the trades matched before the self-trade are kept, only the remaining amount of the
+	// incoming order is cancelled
+	order.Status = "CANCELLED"
+	resp.SelfTradeOrder = bookEntry
+	resp.RemainingOrder = &types.Order{}
+	if len(resp.Trades) == 0 {
+		resp.FillStatus = CANCELLED
+	}
+
+	return true, nil
+}
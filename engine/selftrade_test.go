@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func inBook(e *Resource, o *types.Order) bool {
+	_, listKey := o.GetOBKeys()
+	return exists(e.redisConn, listKey+"::"+o.Hash.Hex())
+}
+
+func TestSelfTradePreventionMode(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	assert.Nil(t, e.SetSelfTradePrevention(CancelBoth))
+	assert.Nil(t, e.SetSelfTradePrevention(""))
+	assert.Error(t, e.SetSelfTradePrevention("cancel-all"))
+}
+
+func TestSelfTradeCancelNewest(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+	e.SetSelfTradePrevention(CancelNewest)
+
+	sell := listingOrder("SELL", 229999999, "0x1")
+	e.sellOrder(sell)
+
+	buy := listingOrder("BUY", 229999999, "0x2")
+	res, err := e.buyOrder(buy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, CANCELLED, res.FillStatus)
+	assert.Equal(t, "CANCELLED", buy.Status)
+	assert.Equal(t, 0, len(res.Trades))
+	assert.Equal(t, 0, len(res.CancelledOrders))
+	assert.Equal(t, sell.Hash, res.SelfTradeOrder.Hash)
+	assert.True(t, inBook(e, sell))
+	assert.False(t, inBook(e, buy))
+}
+
+func TestSelfTradeCancelOldest(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+	e.SetSelfTradePrevention(CancelOldest)
+
+	sell := listingOrder("SELL", 229999999, "0x1")
+	e.sellOrder(sell)
+
+	// the incoming order is matched against the orders of the other makers
+	other := listingOrder("SELL", 229999999, "0x3")
+	other.UserAddress = common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47")
+	e.sellOrder(other)
+
+	buy := listingOrder("BUY", 229999999, "0x2")
+	res, err := e.buyOrder(buy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, FULL, res.FillStatus)
+	assert.Equal(t, 1, len(res.Trades))
+	assert.Equal(t, other.UserAddress, res.Trades[0].Maker)
+	assert.Equal(t, 1, len(res.CancelledOrders))
+	assert.Equal(t, sell.Hash, res.CancelledOrders[0].Hash)
+	assert.Equal(t, "CANCELLED", res.CancelledOrders[0].Status)
+	assert.Nil(t, res.SelfTradeOrder)
+	assert.False(t, inBook(e, sell))
+}
+
+func TestSelfTradeCancelBoth(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+	e.SetSelfTradePrevention(CancelBoth)
+
+	sell := listingOrder("SELL", 229999999, "0x1")
+	e.sellOrder(sell)
+
+	buy := listingOrder("BUY", 229999999, "0x2")
+	res, err := e.buyOrder(buy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, CANCELLED, res.FillStatus)
+	assert.Equal(t, "CANCELLED", buy.Status)
+	assert.Equal(t, 1, len(res.CancelledOrders))
+	assert.False(t, inBook(e, sell))
+	assert.False(t, inBook(e, buy))
+}
@@ -0,0 +1,28 @@
+package engine
+
+import (
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// sequenceKey is the redis counter of the sequence numbers assigned to the orders
+// entering the engine. It is shared by all the matchers so that the sequence keeps
+// increasing across restarts, failovers and shards.
+const sequenceKey = "engine::sequence"
+
+// sequence assigns the next sequence number to an order that does not have one yet. The
+// orders of a price level are matched in the order of their sequence numbers.
+func (e *Resource) sequence(order *types.Order) error {
+	if order.Sequence != 0 {
+		return nil
+	}
+
+	seq, err := redis.Int64(e.redisConn.Do("INCR", sequenceKey))
+	if err != nil {
+		return err
+	}
+
+	order.Sequence = uint64(seq)
+	return nil
+}
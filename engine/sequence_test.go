@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequenceTimePriority(t *testing.T) {
+	e := getResource()
+	defer flushData(e.redisConn)
+
+	// the hash of the second order sorts before the hash of the first one
+	first := listingOrder("SELL", 200000000, "0x2")
+	second := listingOrder("SELL", 200000000, "0x1")
+	for _, o := range []*types.Order{first, second} {
+		err := e.sequence(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		e.addOrder(o)
+	}
+
+	assert.True(t, first.Sequence < second.Sequence)
+
+	// an order keeps the sequence it was assigned when it entered the engine
+	seq := first.Sequence
+	e.sequence(first)
+	assert.Equal(t, seq, first.Sequence)
+
+	taker := listingOrder("BUY", 200000000, "0x3")
+	taker.Amount = big.NewInt(6000000000)
+	e.sequence(taker)
+	res, err := e.buyOrder(taker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, FULL, res.FillStatus)
+	assert.Equal(t, 1, len(res.Trades))
+	assert.Equal(t, first.Hash, res.Trades[0].OrderHash)
+}
+
+func TestMemoryBookSequence(t *testing.T) {
+	b := NewMemoryBook(nil)
+
+	// the orders loaded out of order are queued by sequence number
+	late := listingOrder("SELL", 200000000, "0x1")
+	late.Sequence = 3
+	early := listingOrder("SELL", 200000000, "0x2")
+	early.Sequence = 1
+	middle := listingOrder("SELL", 200000000, "0x3")
+	middle.Sequence = 2
+	b.Add(late)
+	b.Add(early)
+	b.Add(middle)
+
+	taker := listingOrder("BUY", 200000000, "0x4")
+	taker.Amount = big.NewInt(18000000000)
+	res := b.Match(taker)
+
+	assert.Equal(t, FULL, res.FillStatus)
+	assert.Equal(t, 3, len(res.Trades))
+	assert.Equal(t, early.Hash, res.Trades[0].OrderHash)
+	assert.Equal(t, middle.Hash, res.Trades[1].OrderHash)
+	assert.Equal(t, late.Hash, res.Trades[2].OrderHash)
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"log"
+	"sync"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// shardsKey is the redis hash that maps pair names to engine shards. Assignments stored
+// in redis override the static assignments and can be changed without restarting the API.
+const shardsKey = "engine::shards"
+
+// Shards assigns pairs to engine instances so that a very active pair can be matched
+// by a dedicated process. Pairs that are not assigned are matched by the default shard.
+type Shards struct {
+	pairs     map[string]string
+	redisConn redis.Conn
+	// mutex serializes the commands sent on the redis connection, Get is called
+	// concurrently by the API handlers
+	mutex *sync.Mutex
+}
+
+// NewShards returns the shard assignments from a map of shard names to pair names
+// (eg. {"zrx": ["ZRX/WETH"]}). If redisConn is not nil, assignments stored in redis
+// take precedence. The connection should not be shared with the engine.
+func NewShards(assignments map[string][]string, redisConn redis.Conn) *Shards {
+	pairs := make(map[string]string)
+	for shard, names := range assignments {
+		for _, name := range names {
+			pairs[name] = shard
+		}
+	}
+
+	return &Shards{pairs, redisConn, &sync.Mutex{}}
+}
+
+// Get returns the shard matching the given pair
+func (s *Shards) Get(pairName string) string {
+	if s == nil {
+		return ""
+	}
+
+	if s.redisConn != nil {
+		s.mutex.Lock()
+		shard, err := redis.String(s.redisConn.Do("HGET", shardsKey, pairName))
+		s.mutex.Unlock()
+		if err != nil && err != redis.ErrNil {
+			log.Print(err)
+		}
+
+		if shard != "" {
+			return shard
+		}
+	}
+
+	return s.pairs[pairName]
+}
+
+// Assign stores the shard of a pair in redis
+func (s *Shards) Assign(pairName, shard string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.redisConn.Do("HSET", shardsKey, pairName, shard)
+	return err
+}
+
+// orderQueue returns the name of the order queue consumed by a shard
+func orderQueue(shard string) string {
+	if shard == "" {
+		return "order"
+	}
+
+	return "order::" + shard
+}
+
+// leaseKey returns the key of the lease held by the active matcher of a shard
+func leaseKey(shard string) string {
+	if shard == "" {
+		return "engine::lease"
+	}
+
+	return "engine::lease::" + shard
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShards(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	c, _ := redis.Dial("tcp", s.Addr())
+	shards := NewShards(map[string][]string{"zrx": []string{"ZRX/WETH", "ZRX/DAI"}}, c)
+
+	assert.Equal(t, "zrx", shards.Get("ZRX/WETH"))
+	assert.Equal(t, "", shards.Get("MKR/WETH"))
+
+	err = shards.Assign("MKR/WETH", "mkr")
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, "mkr", shards.Get("MKR/WETH"))
+	assert.Equal(t, "order::mkr", orderQueue(shards.Get("MKR/WETH")))
+	assert.Equal(t, "order", orderQueue(""))
+
+	var none *Shards
+	assert.Equal(t, "", none.Get("ZRX/WETH"))
+}
+
+func TestShardsConcurrentGet(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	c, _ := redis.Dial("tcp", s.Addr())
+	shards := NewShards(nil, c)
+	shards.Assign("ZRX/WETH", "zrx")
+
+	// the replies of concurrent lookups must not be mixed up on the connection
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(pair, shard string) {
+			defer wg.Done()
+			assert.Equal(t, shard, shards.Get(pair))
+		}([]string{"ZRX/WETH", "MKR/WETH"}[i%2], []string{"zrx", ""}[i%2])
+	}
+
+	wg.Wait()
+}
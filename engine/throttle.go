@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// QuoteThrottle limits the quote updates of the makers of a pair to damp quote stuffing
+type QuoteThrottle struct {
+	// MinRestingTime is the time an order must rest in the orderbook before it can be
+	// cancelled. Not limited if 0
+	MinRestingTime time.Duration
+	// MaxUpdates is the number of orders and cancels a maker can send on the pair per
+	// second. Not limited if 0
+	MaxUpdates int
+}
+
+// RateLimitError is returned when an order or a cancel violates the quote throttling
+// of its pair
+type RateLimitError struct {
+	Reason string
+}
+
+func (e *RateLimitError) Error() string {
+	return "RATE_LIMITED: " + e.Reason
+}
+
+// IsRateLimited returns true if err is a violation of the quote throttling
+func IsRateLimited(err error) bool {
+	_, ok := err.(*RateLimitError)
+	return ok
+}
+
+// SetQuoteThrottles sets the quote throttling of the pairs by pair name. The throttle
+// of the "" pair applies to the pairs without throttle.
+func (e *Resource) SetQuoteThrottles(throttles map[string]QuoteThrottle) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.throttles = throttles
+	e.quoteUpdates = make(map[string][]time.Time)
+	e.updatePairResources()
+}
+
+// quoteThrottle returns the quote throttling of a pair
+func (e *Resource) quoteThrottle(pairName string) QuoteThrottle {
+	if t, ok := e.throttles[pairName]; ok {
+		return t
+	}
+
+	return e.throttles[""]
+}
+
+// throttleCancel returns a RateLimitError if the order has not rested in the orderbook
+// for the minimum resting time of its pair, or if its maker sent too many updates. The
+// engine lock must be held by the caller.
+func (e *Resource) throttleCancel(order *types.Order, now time.Time) error {
+	err := e.checkRestingTime(order, now)
+	if err != nil {
+		return err
+	}
+
+	return e.throttleUpdate(order, now)
+}
+
+// checkRestingTime returns a RateLimitError if the order has not rested in the orderbook
+// for the minimum resting time of its pair
+func (e *Resource) checkRestingTime(order *types.Order, now time.Time) error {
+	t := e.quoteThrottle(order.PairName)
+	if t.MinRestingTime <= 0 || order.CreatedAt.IsZero() {
+		return nil
+	}
+
+	if now.Sub(order.CreatedAt) < t.MinRestingTime {
+		return &RateLimitError{fmt.Sprintf("Orders of %s can not be cancelled during the first %s", order.PairName, t.MinRestingTime)}
+	}
+
+	return nil
+}
+
+// throttleUpdate records an update (a new order or a cancel) of the maker of an order at
+// now, unless the maker already sent the maximum number of updates of the pair during
+// the second before now. The engine lock must be held by the caller.
+func (e *Resource) throttleUpdate(order *types.Order, now time.Time) error {
+	t := e.quoteThrottle(order.PairName)
+	if t.MaxUpdates <= 0 {
+		return nil
+	}
+
+	key := order.PairName + "::" + order.UserAddress.Hex()
+	recent := []time.Time{}
+	for _, sent := range e.quoteUpdates[key] {
+		if now.Sub(sent) < time.Second {
+			recent = append(recent, sent)
+		}
+	}
+
+	if len(recent) >= t.MaxUpdates {
+		e.quoteUpdates[key] = recent
+		return &RateLimitError{fmt.Sprintf("At most %d orders and cancels per second are accepted on %s", t.MaxUpdates, order.PairName)}
+	}
+
+	e.quoteUpdates[key] = append(recent, now)
+	return nil
+}
+
+// throttleMassQuote checks the resting time of the orders cancelled by a mass quote and
+// records the mass quote as a single update of its maker. The engine lock must be held
+// by the caller.
+func (e *Resource) throttleMassQuote(cancels, orders []*types.Order, now time.Time) error {
+	for _, o := range cancels {
+		err := e.checkRestingTime(o, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case len(orders) > 0:
+		return e.throttleUpdate(orders[0], now)
+	case len(cancels) > 0:
+		return e.throttleUpdate(cancels[0], now)
+	}
+
+	return nil
+}
+
+// rejectOrder publishes the response of a new order rejected by the quote throttling
+// or by the size limit of the orderbook
+func (e *Resource) rejectOrder(order *types.Order, err error) error {
+	order.Status = "REJECTED"
+	return e.publishEngineResponse(&Response{
+		Order:      order,
+		FillStatus: REJECTED,
+		Error:      err.Error(),
+	})
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteThrottle(t *testing.T) {
+	e := &Resource{mutex: &sync.Mutex{}}
+	e.SetQuoteThrottles(map[string]QuoteThrottle{
+		"ZRX/WETH": {MinRestingTime: time.Second, MaxUpdates: 2},
+		"":         {MaxUpdates: 1},
+	})
+
+	o := listingOrder("BUY", 10, "0xabc")
+	now := o.CreatedAt.Add(500 * time.Millisecond)
+
+	// the order has not rested for the minimum resting time
+	err := e.throttleCancel(o, now)
+	assert.True(t, IsRateLimited(err))
+
+	now = o.CreatedAt.Add(2 * time.Second)
+	assert.Nil(t, e.throttleCancel(o, now))
+	assert.Nil(t, e.throttleUpdate(o, now))
+
+	err = e.throttleUpdate(o, now.Add(100*time.Millisecond))
+	assert.True(t, IsRateLimited(err))
+
+	// the updates older than a second are not counted anymore
+	assert.Nil(t, e.throttleUpdate(o, now.Add(time.Second)))
+
+	// the default throttle applies to the other pairs
+	other := listingOrder("BUY", 10, "0xdef")
+	other.PairName = "DAI/WETH"
+	assert.Nil(t, e.throttleCancel(other, other.CreatedAt))
+	assert.True(t, IsRateLimited(e.throttleUpdate(other, other.CreatedAt)))
+}
+
+func TestThrottleMassQuote(t *testing.T) {
+	e := &Resource{mutex: &sync.Mutex{}}
+	e.SetQuoteThrottles(map[string]QuoteThrottle{
+		"": {MinRestingTime: time.Second, MaxUpdates: 1},
+	})
+
+	old := listingOrder("BUY", 10, "0xabc")
+	quote := listingOrder("BUY", 11, "0xdef")
+
+	err := e.throttleMassQuote([]*types.Order{old}, []*types.Order{quote}, old.CreatedAt)
+	assert.True(t, IsRateLimited(err))
+
+	// a mass quote counts as a single update
+	now := old.CreatedAt.Add(time.Second)
+	assert.Nil(t, e.throttleMassQuote([]*types.Order{old}, []*types.Order{quote}, now))
+	assert.True(t, IsRateLimited(e.throttleMassQuote(nil, []*types.Order{quote}, now)))
+}
@@ -19,6 +19,20 @@ type Response struct {
 
 	FillStatus     FillStatus
 	MatchingOrders []*FillOrder
+	// CancelledOrders are the orders of the orderbook cancelled by the self-trade
+	// prevention instead of being matched against an order of the same maker
+	CancelledOrders []*types.Order
+	// SelfTradeOrder is the order of the orderbook from the same maker for which the
+	// self-trade prevention cancelled the incoming order
+	SelfTradeOrder *types.Order `json:",omitempty"`
+	// EvictedOrders are the orders of the orderbook cancelled to make room for the order
+	// in an orderbook holding its maximum number of orders
+	EvictedOrders []*types.Order `json:",omitempty"`
+	// Error is the reason of the rejection of a REJECTED order
+	Error string `json:",omitempty"`
+	// Foreign is set by the engine client on the responses of the orders sent by another
+	// API replica. They are only relayed to the websocket subscribers of this replica.
+	Foreign bool `json:"-"`
 }
 
 // this const block holds the possible valued of FillStatus
@@ -29,6 +43,7 @@ const (
 	FULL
 	ERROR
 	CANCELLED
+	REJECTED
 )
 
 // execute function is responsible for executing of matched orders
@@ -65,9 +80,15 @@ func (e *Resource) execute(order *types.Order, bookEntry *types.Order) (trade *t
 	}
 
 	order.FilledAmount = math.Add(order.FilledAmount, fillOrder.Amount)
-	// Create trade object to be passed to the system for further processing
-	trade = &types.Trade{
-		Amount:       fillOrder.Amount,
+	trade = newTrade(order, bookEntry, fillOrder.Amount)
+	return
+}
+
+// newTrade returns the trade of an order filling amount of an order of the orderbook, to
+// be passed to the system for further processing
+func newTrade(order *types.Order, bookEntry *types.Order, amount *big.Int) *types.Trade {
+	trade := &types.Trade{
+		Amount:       amount,
 		Price:        order.PricePoint,
 		BaseToken:    order.BaseToken,
 		QuoteToken:   order.QuoteToken,
@@ -83,5 +104,5 @@ func (e *Resource) execute(order *types.Order, bookEntry *types.Order) (trade *t
 	}
 
 	trade.Hash = trade.ComputeHash()
-	return
+	return trade
 }
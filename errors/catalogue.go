@@ -0,0 +1,235 @@
+package errors
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-validation"
+)
+
+// Code is an error of the catalogue. Its code is stable so that the clients can branch
+// on it, the status is the HTTP status of the REST responses and the message type the
+// type of the websocket messages carrying the error. The messages of the errors are the
+// templates of config/errors.yaml.
+type Code struct {
+	Code        string
+	Status      int
+	MessageType string
+}
+
+// New returns an API error with the code, the placeholders of its template are replaced
+// with params
+func (c Code) New(params Params) *APIError {
+	return NewAPIError(c.Status, c.Code, params)
+}
+
+// Wrap returns err if it is an API error (or the validation errors of a request) and an
+// error with the code otherwise, the error being the {error} param of its template
+func (c Code) Wrap(err error) *APIError {
+	if e, ok := asAPIError(err); ok {
+		return e
+	}
+
+	return c.New(Params{"error": err.Error()})
+}
+
+// Is returns true if err is an API error with the code
+func (c Code) Is(err error) bool {
+	e, ok := err.(*APIError)
+	return ok && e.ErrorCode == c.Code
+}
+
+var catalogue = map[string]Code{}
+
+// register adds an error sent in ERROR messages on the websocket to the catalogue
+func register(code string, status int) Code {
+	c := Code{Code: code, Status: status, MessageType: "ERROR"}
+	catalogue[code] = c
+	return c
+}
+
+// Lookup returns the error of the catalogue with a code
+func Lookup(code string) (Code, bool) {
+	c, ok := catalogue[code]
+	return c, ok
+}
+
+// Catalogue returns the errors of the catalogue sorted by code
+func Catalogue() []Code {
+	codes := []Code{}
+	for _, c := range catalogue {
+		codes = append(codes, c)
+	}
+
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// the generic errors also have constructors of their own (see errors.go)
+var (
+	InternalError        = register("INTERNAL_SERVER_ERROR", http.StatusInternalServerError)
+	ResourceNotFound     = register("NOT_FOUND", http.StatusNotFound)
+	AuthenticationFailed = register("UNAUTHORIZED", http.StatusUnauthorized)
+	InvalidRequestData   = register("INVALID_DATA", http.StatusBadRequest)
+)
+
+// this var block holds the catalogue of the errors returned by the endpoints and the
+// services. The codes are part of the API, they must not be changed.
+var (
+	AccountAlreadyExists       = register("ACCOUNT_ALREADY_EXISTS", http.StatusConflict)
+	AccountBlocked             = register("ACCOUNT_BLOCKED", http.StatusForbidden)
+	AccountError               = register("ACCOUNT_ERROR", http.StatusBadRequest)
+	AccountNotBlocked          = register("ACCOUNT_NOT_BLOCKED", http.StatusConflict)
+	AccountNotFound            = register("ACCOUNT_NOT_FOUND", http.StatusNotFound)
+	AdminRequired              = register("ADMIN_REQUIRED", http.StatusUnauthorized)
+	AmbiguousPairSymbol        = register("AMBIGUOUS_PAIR_SYMBOL", http.StatusConflict)
+	ApprovalNotFound           = register("APPROVAL_NOT_FOUND", http.StatusNotFound)
+	ApprovalNotPending         = register("APPROVAL_NOT_PENDING", http.StatusConflict)
+	ApprovalSameAdmin          = register("APPROVAL_SAME_ADMIN", http.StatusForbidden)
+	AuthenticationRequired     = register("AUTHENTICATION_REQUIRED", http.StatusUnauthorized)
+	BaseAndQuoteTokenIdentical = register("BASE_AND_QUOTE_TOKEN_ARE_IDENTICAL", http.StatusBadRequest)
+	BaseTokenNotFound          = register("BASE_TOKEN_NOT_FOUND", http.StatusNotFound)
+	BatchRejected              = register("BATCH_REJECTED", http.StatusBadRequest)
+	BustReasonRequired         = register("BUST_REASON_REQUIRED", http.StatusBadRequest)
+	CancelRejected             = register("CANCEL_REJECTED", http.StatusBadRequest)
+	CaptureNotFound            = register("CAPTURE_NOT_FOUND", http.StatusNotFound)
+	ClockSkew                  = register("CLOCK_SKEW", http.StatusUnauthorized)
+	CreateAccountFailed        = register("CREATE_ACCOUNT_FAIL", http.StatusBadRequest)
+	DeleteAccountFailed        = register("DELETE_ACCOUNT_FAIL", http.StatusBadRequest)
+	FetchError                 = register("FETCH_ERROR", http.StatusInternalServerError)
+	GetBalanceFailed           = register("ERROR_GETBALANCE", http.StatusBadRequest)
+	InsufficientAllowance      = register("INSUFFICIENT_ALLOWANCE", http.StatusBadRequest)
+	InsufficientBalance        = register("INSUFFICIENT_BALANCE", http.StatusBadRequest)
+	InsufficientFeeAllowance   = register("INSUFFICIENT_FEE_ALLOWANCE", http.StatusBadRequest)
+	InsufficientFeeBalance     = register("INSUFFICIENT_FEE_BALANCE", http.StatusBadRequest)
+	InsufficientLiquidity      = register("INSUFFICIENT_LIQUIDITY", http.StatusConflict)
+	InsufficientOrderFee       = register("INSUFFICIENT_ORDER_FEE", http.StatusBadRequest)
+	InvalidAddress             = register("INVALID_ADDRESS", http.StatusBadRequest)
+	InvalidAfter               = register("INVALID_AFTER", http.StatusBadRequest)
+	InvalidAmount              = register("INVALID_AMOUNT", http.StatusBadRequest)
+	InvalidBaseTokenParam      = register("INVALID_BASETOKEN", http.StatusBadRequest)
+	InvalidBatchSize           = register("INVALID_BATCH_SIZE", http.StatusBadRequest)
+	InvalidBefore              = register("INVALID_BEFORE", http.StatusBadRequest)
+	InvalidBuckets             = register("INVALID_BUCKETS", http.StatusBadRequest)
+	InvalidChannel             = register("INVALID_CHANNEL", http.StatusBadRequest)
+	InvalidDepth               = register("INVALID_DEPTH", http.StatusBadRequest)
+	InvalidDuration            = register("INVALID_DURATION", http.StatusBadRequest)
+	InvalidFeeOverride         = register("INVALID_FEE_OVERRIDE", http.StatusBadRequest)
+	InvalidField               = register("INVALID_FIELD", http.StatusBadRequest)
+	InvalidFrom                = register("INVALID_FROM", http.StatusBadRequest)
+	InvalidHash                = register("INVALID_HASH", http.StatusBadRequest)
+	InvalidHexAddress          = register("INVALID_HEX_ADDRESS", http.StatusBadRequest)
+	InvalidID                  = register("INVALID_ID", http.StatusBadRequest)
+	InvalidIntervals           = register("INVALID_INTERVALS", http.StatusBadRequest)
+	InvalidLimit               = register("INVALID_LIMIT", http.StatusBadRequest)
+	InvalidLimitOverride       = register("INVALID_LIMIT_OVERRIDE", http.StatusBadRequest)
+	InvalidListingTime         = register("INVALID_LISTING_TIME", http.StatusBadRequest)
+	InvalidMassQuote           = register("INVALID_MASS_QUOTE", http.StatusBadRequest)
+	InvalidMessage             = register("INVALID_MESSAGE", http.StatusBadRequest)
+	InvalidMode                = register("INVALID_MODE", http.StatusBadRequest)
+	InvalidOffset              = register("INVALID_OFFSET", http.StatusBadRequest)
+	InvalidOrder               = register("INVALID_ORDER", http.StatusBadRequest)
+	InvalidOrderCancel         = register("INVALID_ORDER_CANCEL", http.StatusBadRequest)
+	InvalidOrders              = register("INVALID_ORDERS", http.StatusBadRequest)
+	InvalidPair                = register("INVALID_PAIR", http.StatusBadRequest)
+	InvalidPairBaseToken       = register("INVALID_PAIR_BASE_TOKEN", http.StatusBadRequest)
+	InvalidPairQuoteToken      = register("INVALID_PAIR_QUOTE_TOKEN", http.StatusBadRequest)
+	InvalidPairSymbol          = register("INVALID_PAIR_SYMBOL", http.StatusBadRequest)
+	InvalidPayload             = register("INVALID_PAYLOAD", http.StatusBadRequest)
+	InvalidPrecision           = register("INVALID_PRECISION", http.StatusBadRequest)
+	InvalidQuote               = register("INVALID_QUOTE", http.StatusBadRequest)
+	InvalidQuoteTokenParam     = register("INVALID_QUOTETOKEN", http.StatusBadRequest)
+	InvalidSamples             = register("INVALID_SAMPLES", http.StatusBadRequest)
+	InvalidSide                = register("INVALID_SIDE", http.StatusBadRequest)
+	InvalidSignature           = register("INVALID_SIGNATURE", http.StatusUnauthorized)
+	InvalidStep                = register("INVALID_STEP", http.StatusBadRequest)
+	InvalidSubscription        = register("INVALID_SUBSCRIPTION", http.StatusBadRequest)
+	InvalidTag                 = register("INVALID_TAG", http.StatusBadRequest)
+	InvalidTo                  = register("INVALID_TO", http.StatusBadRequest)
+	InvalidTokenAddress        = register("INVALID_TOKEN_ADDRESS", http.StatusBadRequest)
+	InvalidTradeSignature      = register("INVALID_TRADE_SIGNATURE", http.StatusBadRequest)
+	InvalidTrades              = register("INVALID_TRADES", http.StatusBadRequest)
+	InvalidUnits               = register("INVALID_UNITS", http.StatusBadRequest)
+	InvalidWithdraw            = register("INVALID_WITHDRAW", http.StatusBadRequest)
+	InvalidZeroExOrder         = register("INVALID_0X_ORDER", http.StatusBadRequest)
+	MakerMismatch              = register("MAKER_MISMATCH", http.StatusForbidden)
+	MaxOpenOrdersReached       = register("MAX_OPEN_ORDERS_REACHED", http.StatusBadRequest)
+	NoChallenge                = register("NO_CHALLENGE", http.StatusBadRequest)
+	OperatorUnavailable        = register("OPERATOR_UNAVAILABLE", http.StatusServiceUnavailable)
+	OrderNotCancellable        = register("ORDER_NOT_CANCELLABLE", http.StatusConflict)
+	OrderNotFound              = register("ORDER_NOT_FOUND", http.StatusNotFound)
+	OrderRateLimited           = register("ORDER_RATE_LIMITED", http.StatusTooManyRequests)
+	OrderRejected              = register("ORDER_REJECTED", http.StatusBadRequest)
+	PairAlreadyExists          = register("PAIR_ALREADY_EXISTS", http.StatusUnauthorized)
+	PairAlreadyLaunched        = register("PAIR_ALREADY_LAUNCHED", http.StatusConflict)
+	PairNotFound               = register("PAIR_NOT_FOUND", http.StatusNotFound)
+	PairSymbolAlreadyUsed      = register("PAIR_SYMBOL_ALREADY_USED", http.StatusConflict)
+	QuoteNotFound              = register("QUOTE_NOT_FOUND", http.StatusNotFound)
+	QuoteTokenInUse            = register("QUOTE_TOKEN_IN_USE", http.StatusConflict)
+	QuoteTokenNotAllowed       = register("QUOTE_TOKEN_NOT_ALLOWED", http.StatusBadRequest)
+	QuoteTokenNotFound         = register("QUOTE_TOKEN_NOT_FOUND", http.StatusNotFound)
+	RateLimitExceeded          = register("RATE_LIMIT_EXCEEDED", http.StatusTooManyRequests)
+	RateLimited                = register("RATE_LIMITED", http.StatusTooManyRequests)
+	RequestTimeout             = register("REQUEST_TIMEOUT", http.StatusServiceUnavailable)
+	ServiceUnavailable         = register("SERVICE_UNAVAILABLE", http.StatusServiceUnavailable)
+	TokenAlreadyExists         = register("TOKEN_ALREADY_EXISTS", http.StatusUnauthorized)
+	TokenNotFound              = register("TOKEN_NOT_FOUND", http.StatusNotFound)
+	TradeAlreadySettled        = register("TRADE_ALREADY_SETTLED", http.StatusBadRequest)
+	TradeNotBustable           = register("TRADE_NOT_BUSTABLE", http.StatusConflict)
+	TradeNotFound              = register("TRADE_NOT_FOUND", http.StatusNotFound)
+	TradeStatusChanged         = register("TRADE_STATUS_CHANGED", http.StatusConflict)
+	UnableToRegister           = register("UNABLE_TO_REGISTER", http.StatusInternalServerError)
+	UnableToSubscribe          = register("UNABLE_TO_SUBSCRIBE", http.StatusInternalServerError)
+	UnknownAdminAction         = register("UNKNOWN_ADMIN_ACTION", http.StatusBadRequest)
+	WithdrawAlreadyExists      = register("WITHDRAW_ALREADY_EXISTS", http.StatusConflict)
+	WithdrawNotFound           = register("WITHDRAW_NOT_FOUND", http.StatusNotFound)
+	WithdrawsDisabled          = register("WITHDRAWS_DISABLED", http.StatusServiceUnavailable)
+)
+
+// asAPIError returns the API error of an error of the catalogue or of validation errors
+func asAPIError(err error) (*APIError, bool) {
+	switch e := err.(type) {
+	case *APIError:
+		return e, true
+	case validation.Errors:
+		return InvalidData(e), true
+	}
+
+	return nil, false
+}
+
+// InvalidQueryParam returns the error of an invalid query param, eg. INVALID_FROM for the
+// from param
+func InvalidQueryParam(name string) *APIError {
+	if c, ok := Lookup("INVALID_" + strings.ToUpper(name)); ok {
+		return c.New(Params{"param": name})
+	}
+
+	return InvalidRequestData.New(nil)
+}
+
+// WSError is the payload of the websocket messages of the errors of the catalogue
+type WSError struct {
+	Code    string      `json:"Code"`
+	Message string      `json:"Message"`
+	Details interface{} `json:"Details,omitempty"`
+}
+
+func (e *WSError) Error() string {
+	return e.Message
+}
+
+// NewWSError returns the websocket payload of an error. The errors that are not API
+// errors (eg. of the database) are internal errors: they are logged and their message is
+// not sent to the clients.
+func NewWSError(err error) *WSError {
+	e, ok := asAPIError(err)
+	if !ok {
+		log.Printf("Internal error sent on the websocket: %v", err)
+		e = InternalError.New(Params{"error": "see the server logs"})
+	}
+
+	return &WSError{Code: e.ErrorCode, Message: e.Message, Details: e.Details}
+}
@@ -0,0 +1,78 @@
+package errors
+
+import (
+	errs "errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogueTemplates(t *testing.T) {
+	defer func() {
+		templates = nil
+	}()
+
+	assert.Nil(t, LoadMessages(MESSAGE_FILE))
+
+	for _, c := range Catalogue() {
+		_, ok := templates[c.Code]
+		assert.True(t, ok, "no template for %v", c.Code)
+	}
+}
+
+func TestCode_New(t *testing.T) {
+	defer func() {
+		templates = nil
+	}()
+
+	assert.Nil(t, LoadMessages(MESSAGE_FILE))
+
+	e := OrderNotCancellable.New(Params{"status": "FILLED"})
+	assert.Equal(t, http.StatusConflict, e.Status)
+	assert.Equal(t, "ORDER_NOT_CANCELLABLE", e.ErrorCode)
+	assert.Equal(t, "An order with status FILLED can not be cancelled.", e.Message)
+
+	assert.True(t, OrderNotCancellable.Is(e))
+	assert.False(t, OrderNotFound.Is(e))
+	assert.False(t, OrderNotFound.Is(errs.New("ORDER_NOT_FOUND")))
+}
+
+func TestCode_Wrap(t *testing.T) {
+	e := PairNotFound.New(nil)
+	assert.Equal(t, e, OrderRejected.Wrap(e))
+
+	e = OrderRejected.Wrap(errs.New("xyz"))
+	assert.Equal(t, "ORDER_REJECTED", e.ErrorCode)
+	assert.Equal(t, http.StatusBadRequest, e.Status)
+}
+
+func TestLookup(t *testing.T) {
+	c, ok := Lookup("PAIR_NOT_FOUND")
+	assert.True(t, ok)
+	assert.Equal(t, PairNotFound, c)
+
+	_, ok = Lookup("xyz")
+	assert.False(t, ok)
+}
+
+func TestInvalidQueryParam(t *testing.T) {
+	assert.Equal(t, "INVALID_FROM", InvalidQueryParam("from").ErrorCode)
+	assert.Equal(t, "INVALID_DATA", InvalidQueryParam("xyz").ErrorCode)
+}
+
+func TestNewWSError(t *testing.T) {
+	defer func() {
+		templates = nil
+	}()
+
+	assert.Nil(t, LoadMessages(MESSAGE_FILE))
+
+	e := NewWSError(PairNotFound.New(nil))
+	assert.Equal(t, "PAIR_NOT_FOUND", e.Code)
+	assert.Equal(t, "The pair was not found.", e.Message)
+
+	e = NewWSError(errs.New("connection refused"))
+	assert.Equal(t, "INTERNAL_SERVER_ERROR", e.Code)
+	assert.NotContains(t, e.Message, "connection refused")
+}
@@ -1,7 +1,6 @@
 package errors
 
 import (
-	"net/http"
 	"sort"
 
 	"github.com/go-ozzo/ozzo-validation"
@@ -14,17 +13,17 @@ type validationError struct {
 
 // InternalServerError creates a new API error representing an internal server error (HTTP 500)
 func InternalServerError(err error) *APIError {
-	return NewAPIError(http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", Params{"error": err.Error()})
+	return InternalError.New(Params{"error": err.Error()})
 }
 
 // NotFound creates a new API error representing a resource-not-found error (HTTP 404)
 func NotFound(resource string) *APIError {
-	return NewAPIError(http.StatusNotFound, "NOT_FOUND", Params{"resource": resource})
+	return ResourceNotFound.New(Params{"resource": resource})
 }
 
 // Unauthorized creates a new API error representing an authentication failure (HTTP 401)
 func Unauthorized(err string) *APIError {
-	return NewAPIError(http.StatusUnauthorized, "UNAUTHORIZED", Params{"error": err})
+	return AuthenticationFailed.New(Params{"error": err})
 }
 
 // InvalidData converts a data validation error into an API error (HTTP 400)
@@ -43,7 +42,7 @@ func InvalidData(errs validation.Errors) *APIError {
 		})
 	}
 
-	err := NewAPIError(http.StatusBadRequest, "INVALID_DATA", nil)
+	err := InvalidRequestData.New(nil)
 	err.Details = result
 
 	return err
@@ -0,0 +1,112 @@
+package errors
+
+import "fmt"
+
+// ExchangeErrorID is an error code emitted by the exchange smart contract in LogError
+// events when a trade, order cancellation or withdrawal can not be executed
+type ExchangeErrorID uint8
+
+// this const block holds the error codes of the exchange smart contract. They must be
+// kept in the same order as the Errors enum of Exchange.sol.
+const (
+	UnknownExchangeError ExchangeErrorID = iota
+	MakerInsufficientBalance
+	TakerInsufficientBalance
+	WithdrawInsufficientBalance
+	WithdrawFeeTooHigh
+	OrderExpired
+	WithdrawAlreadyCompleted
+	TradeAlreadyCompleted
+	TradeAmountTooBig
+	SignatureInvalid
+	MakerSignatureInvalid
+	TakerSignatureInvalid
+)
+
+// ExchangeError describes an exchange smart contract error code. It is returned in
+// API and websocket error payloads.
+type ExchangeError struct {
+	ID          ExchangeErrorID `json:"id"`
+	Code        string          `json:"code"`
+	Cause       string          `json:"cause"`
+	Remediation string          `json:"remediation"`
+}
+
+// Error returns the error cause.
+func (e ExchangeError) Error() string {
+	return e.Cause
+}
+
+var exchangeErrors = map[ExchangeErrorID]ExchangeError{
+	MakerInsufficientBalance: {
+		Code:        "MAKER_INSUFFICIENT_BALANCE",
+		Cause:       "The maker does not have enough tokens deposited to settle the trade.",
+		Remediation: "The maker needs to deposit or approve more tokens before the order can be matched again.",
+	},
+	TakerInsufficientBalance: {
+		Code:        "TAKER_INSUFFICIENT_BALANCE",
+		Cause:       "The taker does not have enough tokens deposited to settle the trade.",
+		Remediation: "The taker needs to deposit or approve more tokens and submit a new order.",
+	},
+	WithdrawInsufficientBalance: {
+		Code:        "WITHDRAW_INSUFFICIENT_BALANCE",
+		Cause:       "The account balance is lower than the requested withdrawal amount.",
+		Remediation: "Request a withdrawal amount lower than the available balance.",
+	},
+	WithdrawFeeTooHigh: {
+		Code:        "WITHDRAW_FEE_TO_HIGH",
+		Cause:       "The withdrawal fee is higher than the maximum fee allowed by the exchange.",
+		Remediation: "Retry the withdrawal with a lower fee.",
+	},
+	OrderExpired: {
+		Code:        "ORDER_EXPIRED",
+		Cause:       "The order expired before the trade was settled.",
+		Remediation: "Submit a new order with a later expiry.",
+	},
+	WithdrawAlreadyCompleted: {
+		Code:        "WITHDRAW_ALREADY_COMPLETED",
+		Cause:       "The withdrawal has already been executed.",
+		Remediation: "No action required.",
+	},
+	TradeAlreadyCompleted: {
+		Code:        "TRADE_ALREADY_COMPLETED",
+		Cause:       "The trade has already been settled.",
+		Remediation: "No action required, the trade should not be retried.",
+	},
+	TradeAmountTooBig: {
+		Code:        "TRADE_AMOUNT_TOO_BIG",
+		Cause:       "The trade amount is larger than the remaining amount of the order, it might have been filled elsewhere.",
+		Remediation: "Check the remaining order amount and submit a smaller trade.",
+	},
+	SignatureInvalid: {
+		Code:        "SIGNATURE_INVALID",
+		Cause:       "The signature does not match the signed payload.",
+		Remediation: "Sign the payload again with the account private key.",
+	},
+	MakerSignatureInvalid: {
+		Code:        "MAKER_SIGNATURE_INVALID",
+		Cause:       "The maker order signature is invalid.",
+		Remediation: "The maker needs to cancel and sign the order again.",
+	},
+	TakerSignatureInvalid: {
+		Code:        "TAKER_SIGNATURE_INVALID",
+		Cause:       "The taker trade signature is invalid.",
+		Remediation: "The taker needs to sign the trade again.",
+	},
+}
+
+// GetExchangeError returns the description of an exchange smart contract error code
+func GetExchangeError(id ExchangeErrorID) ExchangeError {
+	e, ok := exchangeErrors[id]
+	if !ok {
+		return ExchangeError{
+			ID:          id,
+			Code:        "UNKNOWN_EXCHANGE_ERROR",
+			Cause:       fmt.Sprintf("The exchange contract returned an unknown error (%d).", id),
+			Remediation: "Contact support with the trade hash.",
+		}
+	}
+
+	e.ID = id
+	return e
+}
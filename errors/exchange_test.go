@@ -0,0 +1,18 @@
+package errors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetExchangeError(t *testing.T) {
+	e := GetExchangeError(OrderExpired)
+	assert.Equal(t, OrderExpired, e.ID)
+	assert.Equal(t, "ORDER_EXPIRED", e.Code)
+	assert.NotEmpty(t, e.Remediation)
+
+	e = GetExchangeError(ExchangeErrorID(42))
+	assert.Equal(t, ExchangeErrorID(42), e.ID)
+	assert.Equal(t, "UNKNOWN_EXCHANGE_ERROR", e.Code)
+}
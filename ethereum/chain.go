@@ -0,0 +1,168 @@
+package ethereum
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	eth "github.com/ethereum/go-ethereum/core/types"
+)
+
+// ChainStatus is the result of the last check of the latest block. Lag is the age of the
+// latest block and Stale is set when it exceeds the maximum block lag.
+type ChainStatus struct {
+	BlockNumber uint64        `json:"blockNumber"`
+	BlockTime   time.Time     `json:"blockTime"`
+	Lag         time.Duration `json:"lag"`
+	Stale       bool          `json:"stale"`
+	CheckedAt   time.Time     `json:"checkedAt"`
+}
+
+// ChainMonitor tracks the timestamp of the latest block returned by the ethereum node.
+// When the latest block is older than the maximum lag, the chain dependent features are
+// degraded: the listeners are notified so that settlement is paused instead of settling
+// against an outdated view of the chain, and the balances are flagged as stale.
+type ChainMonitor struct {
+	latestHeader func() (*eth.Header, error)
+	maxLag       time.Duration
+	now          func() time.Time
+	listeners    []func(*types.ChainAlert)
+	status       *ChainStatus
+	mutex        sync.Mutex
+}
+
+// currentChainMonitor is the chain monitor running in this process, if any
+var currentChainMonitor *ChainMonitor
+var currentChainMonitorMutex sync.Mutex
+
+// NewChainMonitor returns a monitor of the latest block returned by latestHeader
+func NewChainMonitor(latestHeader func() (*eth.Header, error), maxLag time.Duration) *ChainMonitor {
+	return &ChainMonitor{
+		latestHeader: latestHeader,
+		maxLag:       maxLag,
+		now:          time.Now,
+		status:       &ChainStatus{},
+	}
+}
+
+// NewClientChainMonitor returns a monitor of the latest block of the client providers
+func NewClientChainMonitor(c *Client, maxLag time.Duration) *ChainMonitor {
+	return NewChainMonitor(func() (*eth.Header, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		defer cancel()
+
+		return c.HeaderByNumber(ctx, nil)
+	}, maxLag)
+}
+
+// OnStaleChange adds a function called when the chain becomes stale or is back in sync
+func (m *ChainMonitor) OnStaleChange(fn func(*types.ChainAlert)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.listeners = append(m.listeners, fn)
+}
+
+// Start checks the latest block every interval. The monitor becomes the one reported by
+// GetChainMonitor and GetChainStatus.
+func (m *ChainMonitor) Start(interval time.Duration) {
+	currentChainMonitorMutex.Lock()
+	currentChainMonitor = m
+	currentChainMonitorMutex.Unlock()
+
+	go func() {
+		for {
+			_, err := m.Check()
+			if err != nil {
+				log.Printf("Could not check the latest block: %v", err)
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// Check fetches the latest block and notifies the listeners if the chain became stale or
+// is back in sync. While the node is unreachable, the lag of the last known block keeps
+// growing until the chain is stale.
+func (m *ChainMonitor) Check() (*ChainStatus, error) {
+	header, err := m.latestHeader()
+	now := m.now()
+
+	m.mutex.Lock()
+	previous := m.status
+	status := &ChainStatus{
+		BlockNumber: previous.BlockNumber,
+		BlockTime:   previous.BlockTime,
+		CheckedAt:   now,
+	}
+
+	if err == nil && header.Number.Uint64() >= previous.BlockNumber {
+		status.BlockNumber = header.Number.Uint64()
+		status.BlockTime = time.Unix(header.Time.Int64(), 0)
+	}
+
+	// the chain is stale as soon as the node can not be reached if no block was ever seen
+	if status.BlockNumber == 0 {
+		status.Stale = err != nil
+	} else {
+		status.Lag = now.Sub(status.BlockTime)
+		status.Stale = status.Lag > m.maxLag
+	}
+
+	m.status = status
+	listeners := m.listeners
+	m.mutex.Unlock()
+
+	if status.Stale != previous.Stale {
+		alert := &types.ChainAlert{
+			BlockNumber: status.BlockNumber,
+			BlockTime:   status.BlockTime,
+			Lag:         status.Lag,
+			Stale:       status.Stale,
+			CreatedAt:   now,
+		}
+
+		log.Print(alert.Message())
+		for _, fn := range listeners {
+			fn(alert)
+		}
+	}
+
+	return status, err
+}
+
+// Status returns the result of the last check
+func (m *ChainMonitor) Status() *ChainStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.status
+}
+
+// GetChainMonitor returns the chain monitor running in this process, or nil
+func GetChainMonitor() *ChainMonitor {
+	currentChainMonitorMutex.Lock()
+	defer currentChainMonitorMutex.Unlock()
+
+	return currentChainMonitor
+}
+
+// GetChainStatus returns the last chain status, or nil if the chain is not monitored by
+// this process
+func GetChainStatus() *ChainStatus {
+	m := GetChainMonitor()
+	if m == nil {
+		return nil
+	}
+
+	return m.Status()
+}
+
+// ChainStale returns true if the chain is monitored and stale
+func ChainStale() bool {
+	s := GetChainStatus()
+	return s != nil && s.Stale
+}
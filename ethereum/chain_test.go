@@ -0,0 +1,62 @@
+package ethereum
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	eth "github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestChainMonitor(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	header := &eth.Header{Number: big.NewInt(100), Time: big.NewInt(now.Unix() - 10)}
+	var headerErr error
+
+	m := NewChainMonitor(func() (*eth.Header, error) { return header, headerErr }, time.Minute)
+	m.now = func() time.Time { return now }
+
+	alerts := []*types.ChainAlert{}
+	m.OnStaleChange(func(a *types.ChainAlert) { alerts = append(alerts, a) })
+
+	status, err := m.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Stale || status.BlockNumber != 100 || status.Lag != 10*time.Second {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	// the node does not return new blocks
+	now = now.Add(2 * time.Minute)
+	m.Check()
+	if !m.Status().Stale || len(alerts) != 1 || !alerts[0].Stale {
+		t.Errorf("Expected the chain to be stale, got %+v", m.Status())
+	}
+
+	// the node is unreachable, the last known block keeps aging
+	headerErr = errors.New("connection refused")
+	m.Check()
+	if !m.Status().Stale || m.Status().BlockNumber != 100 || len(alerts) != 1 {
+		t.Errorf("Expected the chain to stay stale, got %+v", m.Status())
+	}
+
+	headerErr = nil
+	header = &eth.Header{Number: big.NewInt(110), Time: big.NewInt(now.Unix() - 5)}
+	m.Check()
+	if m.Status().Stale || len(alerts) != 2 || alerts[1].Stale || alerts[1].BlockNumber != 110 {
+		t.Errorf("Expected the chain to be back in sync, got %+v", m.Status())
+	}
+}
+
+func TestChainMonitorUnreachable(t *testing.T) {
+	m := NewChainMonitor(func() (*eth.Header, error) { return nil, errors.New("connection refused") }, time.Minute)
+
+	m.Check()
+	if !m.Status().Stale {
+		t.Error("Expected the chain to be stale when no block was ever seen")
+	}
+}
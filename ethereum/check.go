@@ -0,0 +1,29 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// CodeReader reads the code of a contract
+type CodeReader interface {
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+// CheckContract returns an error if there is no contract code at an address, eg. if the
+// configured address is wrong or the node is connected to another network
+func CheckContract(c CodeReader, name string, address common.Address) error {
+	code, err := c.CodeAt(context.Background(), address, nil)
+	if err != nil {
+		return fmt.Errorf("could not read the code of the %v contract at %v: %v", name, address.Hex(), err)
+	}
+
+	if len(code) == 0 {
+		return fmt.Errorf("there is no %v contract at %v, check the configured address and the network of the ethereum node", name, address.Hex())
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type codeReader map[common.Address][]byte
+
+func (r codeReader) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if contract == (common.Address{}) {
+		return nil, errors.New("connection refused")
+	}
+
+	return r[contract], nil
+}
+
+func TestCheckContract(t *testing.T) {
+	exchange := common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485")
+	r := codeReader{exchange: []byte{0x60, 0x80}}
+
+	if err := CheckContract(r, "exchange", exchange); err != nil {
+		t.Errorf("Expected the contract to be found, got %v", err)
+	}
+
+	other := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	if err := CheckContract(r, "exchange", other); err == nil {
+		t.Error("Expected an error for an address without code")
+	}
+
+	if err := CheckContract(r, "exchange", common.Address{}); err == nil {
+		t.Error("Expected the error of the node to be returned")
+	}
+}
@@ -0,0 +1,328 @@
+package ethereum
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	eth "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	mgo "gopkg.in/mgo.v2"
+)
+
+// transferTopic is the topic of the ERC20 Transfer(address,address,uint256) events
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// maxResubscribeDelay is the maximum time waited between two attempts to re-establish
+// the subscription of the deposit watcher, the delay doubles after each failed attempt
+const maxResubscribeDelay = time.Minute
+
+// DepositWatcher credits the accounts with the tokens they transfer to the exchange
+// contract. The Transfer events of the listed tokens toward the exchange contract are
+// recorded as pending deposits, and credited to the token balance of the sender once
+// their block has enough confirmations. The deposits are recorded with the hash of their
+// transaction and the index of their log, so that a deposit is only credited once even
+// if its log is delivered again or several watchers run concurrently.
+type DepositWatcher struct {
+	client        *Client
+	exchange      common.Address
+	confirmations uint64
+	accountDao    *daos.AccountDao
+	depositDao    *daos.DepositDao
+	tokenDao      *daos.TokenDao
+	listeners     []func(*types.Deposit)
+	lastBlock     uint64
+	mutex         sync.Mutex
+}
+
+// NewDepositWatcher returns a watcher of the deposits to the exchange contract crediting
+// them after the given number of confirmations
+func NewDepositWatcher(
+	c *Client,
+	exchange common.Address,
+	confirmations uint64,
+	accountDao *daos.AccountDao,
+	depositDao *daos.DepositDao,
+	tokenDao *daos.TokenDao,
+) *DepositWatcher {
+	return &DepositWatcher{
+		client:        c,
+		exchange:      exchange,
+		confirmations: confirmations,
+		accountDao:    accountDao,
+		depositDao:    depositDao,
+		tokenDao:      tokenDao,
+	}
+}
+
+// OnConfirmed adds a function called when a deposit is credited
+func (w *DepositWatcher) OnConfirmed(fn func(*types.Deposit)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.listeners = append(w.listeners, fn)
+}
+
+// Start subscribes to the Transfer events toward the exchange contract and checks the
+// confirmations of the pending deposits every interval. The events emitted since the
+// latest recorded deposit are replayed, so that the deposits made while no watcher was
+// running are not missed. The subscription is re-established if it fails, and the events
+// emitted since the last processed block are replayed.
+func (w *DepositWatcher) Start(interval time.Duration) error {
+	q := w.query()
+	logs := make(chan eth.Log)
+
+	sub, err := w.client.SubscribeFilterLogs(context.Background(), q, logs)
+	if err != nil {
+		return err
+	}
+
+	err = w.catchUp(q)
+	if err != nil {
+		log.Printf("Could not replay the past deposits: %v", err)
+	}
+
+	go w.watch(q, logs, sub)
+
+	go func() {
+		for {
+			w.confirmDeposits()
+			time.Sleep(interval)
+		}
+	}()
+
+	return nil
+}
+
+// query returns the filter of the Transfer events toward the exchange contract
+func (w *DepositWatcher) query() ethereum.FilterQuery {
+	return ethereum.FilterQuery{
+		Topics: [][]common.Hash{
+			{transferTopic},
+			nil,
+			{common.BytesToHash(w.exchange.Bytes())},
+		},
+	}
+}
+
+// watch handles the events of the subscription. The subscription is re-established when
+// it fails, with a delay doubling after each failed attempt, and the events emitted since
+// the last processed block are replayed.
+func (w *DepositWatcher) watch(q ethereum.FilterQuery, logs chan eth.Log, sub ethereum.Subscription) {
+	for {
+		select {
+		case l := <-logs:
+			w.handleLog(l)
+			continue
+		case err := <-sub.Err():
+			log.Printf("Deposit subscription failed: %v", err)
+		}
+
+		sub.Unsubscribe()
+
+		delay := resubscribeDelay
+		for {
+			time.Sleep(delay)
+
+			var err error
+			sub, err = w.client.SubscribeFilterLogs(context.Background(), q, logs)
+			if err == nil {
+				break
+			}
+
+			log.Printf("Could not re-establish the deposit subscription: %v", err)
+			delay *= 2
+			if delay > maxResubscribeDelay {
+				delay = maxResubscribeDelay
+			}
+		}
+
+		err := w.catchUp(q)
+		if err != nil {
+			log.Printf("Could not replay the deposits since block %d: %v", w.lastBlock, err)
+		}
+	}
+}
+
+// catchUp handles the events emitted since the last processed block, or since the block
+// of the latest recorded deposit if no event was processed yet
+func (w *DepositWatcher) catchUp(q ethereum.FilterQuery) error {
+	from := w.lastBlock
+	if from == 0 {
+		latest, err := w.depositDao.GetLatestBlock()
+		if err != nil {
+			return err
+		}
+
+		from = latest
+	}
+
+	if from == 0 {
+		return nil
+	}
+
+	q.FromBlock = new(big.Int).SetUint64(from)
+	logs, err := w.client.FilterLogs(context.Background(), q)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range logs {
+		w.handleLog(l)
+	}
+
+	return nil
+}
+
+// handleLog records the deposit of a Transfer event of a listed token as pending. The
+// deposit is removed if its log is removed by a chain reorganization, and pending again
+// if its transaction is included in another block.
+func (w *DepositWatcher) handleLog(l eth.Log) {
+	if !l.Removed && l.BlockNumber > w.lastBlock {
+		w.lastBlock = l.BlockNumber
+	}
+
+	d := parseDepositLog(l)
+	if d == nil {
+		return
+	}
+
+	if l.Removed {
+		err := w.depositDao.UpdateStatusByLog(d.TxHash, d.LogIndex, types.DepositPending, types.DepositRemoved)
+		if err == nil {
+			log.Printf("Deposit %s:%d removed by a chain reorganization", d.TxHash.Hex(), d.LogIndex)
+		}
+
+		return
+	}
+
+	token, err := w.tokenDao.GetByAddress(d.Token)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	if token == nil {
+		return
+	}
+
+	created, err := w.depositDao.Create(d)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	if !created {
+		err = w.depositDao.UpdateStatusByLog(d.TxHash, d.LogIndex, types.DepositRemoved, types.DepositPending)
+		if err != nil && err != mgo.ErrNotFound {
+			log.Print(err)
+		}
+
+		return
+	}
+
+	log.Printf("Deposit of %s %s by %s pending", d.Amount, token.Symbol, d.Owner.Hex())
+}
+
+// confirmDeposits credits the pending deposits with enough confirmations
+func (w *DepositWatcher) confirmDeposits() {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	header, err := w.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		log.Printf("Could not get the latest block: %v", err)
+		return
+	}
+
+	deposits, err := w.depositDao.GetByStatus(types.DepositPending)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	blockNumber := header.Number.Uint64()
+	for _, d := range deposits {
+		if d.Confirmations(blockNumber) < w.confirmations {
+			continue
+		}
+
+		// the logs removed while no watcher was running are not delivered, the
+		// transaction must still be in the chain
+		receipt, err := w.client.TransactionReceipt(ctx, d.TxHash)
+		if err == ethereum.NotFound {
+			continue
+		}
+
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		d.BlockNumber = receipt.BlockNumber.Uint64()
+		if receipt.Status != eth.ReceiptStatusSuccessful || d.Confirmations(blockNumber) < w.confirmations {
+			continue
+		}
+
+		w.confirm(d)
+	}
+}
+
+// confirm credits a deposit to the token balance of its owner and notifies the
+// listeners. The deposit is failed if it can not be credited (eg. the owner has no
+// account) and must then be credited manually.
+func (w *DepositWatcher) confirm(d *types.Deposit) {
+	err := w.depositDao.UpdateStatus(d, types.DepositPending, types.DepositConfirmed)
+	if err == mgo.ErrNotFound {
+		return
+	}
+
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	err = w.accountDao.AdjustTokenBalance(d.Owner, d.Token, d.Amount, big.NewInt(0))
+	if err != nil {
+		log.Printf("Could not credit deposit %s:%d of %s: %v", d.TxHash.Hex(), d.LogIndex, d.Owner.Hex(), err)
+
+		err = w.depositDao.UpdateStatus(d, types.DepositConfirmed, types.DepositFailed)
+		if err != nil {
+			log.Print(err)
+		}
+
+		return
+	}
+
+	w.mutex.Lock()
+	listeners := w.listeners
+	w.mutex.Unlock()
+
+	for _, fn := range listeners {
+		fn(d)
+	}
+}
+
+// parseDepositLog returns the deposit of a Transfer event, or nil if the log is not a
+// Transfer event
+func parseDepositLog(l eth.Log) *types.Deposit {
+	if len(l.Topics) != 3 || l.Topics[0] != transferTopic || len(l.Data) != 32 {
+		return nil
+	}
+
+	return &types.Deposit{
+		TxHash:      l.TxHash,
+		LogIndex:    l.Index,
+		BlockNumber: l.BlockNumber,
+		Token:       l.Address,
+		Owner:       common.BytesToAddress(l.Topics[1].Bytes()),
+		Amount:      new(big.Int).SetBytes(l.Data),
+		Status:      types.DepositPending,
+	}
+}
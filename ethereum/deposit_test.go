@@ -0,0 +1,42 @@
+package ethereum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	eth "github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestParseDepositLog(t *testing.T) {
+	owner := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	exchange := common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485")
+	token := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+
+	l := eth.Log{
+		Address:     token,
+		Topics:      []common.Hash{transferTopic, common.BytesToHash(owner.Bytes()), common.BytesToHash(exchange.Bytes())},
+		Data:        common.LeftPadBytes(big.NewInt(1000).Bytes(), 32),
+		BlockNumber: 100,
+		TxHash:      common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		Index:       3,
+	}
+
+	d := parseDepositLog(l)
+	if d == nil {
+		t.Fatal("Transfer log not parsed")
+	}
+
+	if d.Owner != owner || d.Token != token || d.Amount.Cmp(big.NewInt(1000)) != 0 || d.LogIndex != 3 {
+		t.Errorf("Unexpected deposit %+v", d)
+	}
+
+	if d.Confirmations(99) != 0 || d.Confirmations(100) != 1 || d.Confirmations(111) != 12 {
+		t.Errorf("Unexpected confirmations of deposit in block %d", d.BlockNumber)
+	}
+
+	l.Topics[0] = common.HexToHash("0x01")
+	if parseDepositLog(l) != nil {
+		t.Error("Log of another event parsed as a deposit")
+	}
+}
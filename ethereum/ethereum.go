@@ -1,20 +1,439 @@
 package ethereum
 
 import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
-// Conn is singleton rabbitmq connection
-var ethereumClient *ethclient.Client
+// healthCheckTimeout bounds the request checking the health of a provider
+const healthCheckTimeout = 5 * time.Second
+
+// resubscribeDelay is the time waited between two attempts to re-establish a subscription
+const resubscribeDelay = 2 * time.Second
+
+// replayedLogs is the number of delivered logs remembered by a subscription, so that the
+// logs replayed after a failover are not delivered twice
+const replayedLogs = 1024
+
+var errNoProvider = errors.New("No ethereum rpc provider")
+
+// ethereumClient is the singleton ethereum client
+var ethereumClient *Client
+
+// Client is an ethereum node client spreading the calls over several rpc providers. The
+// providers are health checked and the calls are sent to the first healthy provider, the
+// active provider, or in turn to the healthy providers for the read calls in round robin
+// mode. Client implements bind.ContractBackend, so the contract bindings keep working when
+// the active provider changes, and the log subscriptions are re-established on the new
+// active provider after a failover.
+type Client struct {
+	providers  []*provider
+	roundRobin bool
+	next       uint64
+
+	mutex    sync.RWMutex
+	active   int
+	failover chan struct{}
+}
+
+// provider is an rpc endpoint. healthy is protected by the client mutex.
+type provider struct {
+	url     string
+	client  *ethclient.Client
+	healthy bool
+}
+
+// InitConnection initializes the singleton client with a comma separated list of rpc
+// endpoints, in order of preference
+func InitConnection(endpoints string) *Client {
+	providers := []*provider{}
+	for _, url := range strings.Split(endpoints, ",") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+
+		var rpcClient *rpc.Client
+		var err error
+		if strings.Contains(url, "://") {
+			rpcClient, err = rpc.Dial(url)
+		} else {
+			rpcClient, err = rpc.DialHTTP(url)
+		}
+
+		if err != nil {
+			log.Printf("Could not connect to ethereum provider %s: %s", url, err)
+			continue
+		}
+
+		providers = append(providers, &provider{url: url, client: ethclient.NewClient(rpcClient), healthy: true})
+	}
+
+	if len(providers) == 0 {
+		panic(errNoProvider)
+	}
+
+	ethereumClient = newClient(providers)
+	return ethereumClient
+}
+
+// GetClient returns the singleton client
+func GetClient() *Client {
+	return ethereumClient
+}
+
+func newClient(providers []*provider) *Client {
+	return &Client{providers: providers, failover: make(chan struct{})}
+}
+
+// SetRoundRobin spreads the read calls over the healthy providers instead of sending them
+// to the active provider. The transactions, nonces and subscriptions always go to the
+// active provider, so that they are consistent.
+func (c *Client) SetRoundRobin(enabled bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.roundRobin = enabled
+}
+
+// StartHealthChecks checks the health of the providers at every interval. The providers
+// that do not return the latest block header are excluded until they do again.
+func (c *Client) StartHealthChecks(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			c.checkHealth()
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// checkHealth checks the health of every provider and updates the active provider
+func (c *Client) checkHealth() {
+	for _, p := range c.providers {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		_, err := p.client.HeaderByNumber(ctx, nil)
+		cancel()
+
+		c.setHealth(p, err == nil)
+	}
+}
+
+// setHealth sets the health of a provider and fails over to the first healthy provider
+// if the active provider changes
+func (c *Client) setHealth(p *provider, healthy bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if p.healthy != healthy {
+		log.Printf("Ethereum provider %s healthy: %v", p.url, healthy)
+		p.healthy = healthy
+	}
+
+	for i, candidate := range c.providers {
+		if !candidate.healthy {
+			continue
+		}
+
+		if i != c.active {
+			log.Printf("Ethereum provider failover from %s to %s", c.providers[c.active].url, candidate.url)
+			c.active = i
+			close(c.failover)
+			c.failover = make(chan struct{})
+		}
+
+		return
+	}
+}
+
+// report excludes a provider whose call failed because it is unreachable
+func (c *Client) report(p *provider, err error) {
+	if err == nil || !isConnectionError(err) {
+		return
+	}
+
+	c.setHealth(p, false)
+}
+
+// isConnectionError returns false for the json-rpc errors returned by a provider that is up
+// (eg. a reverted call) and for the errors of the callers (eg. a cancelled context)
+func isConnectionError(err error) bool {
+	if err == context.Canceled || err == ethereum.NotFound {
+		return false
+	}
+
+	_, ok := err.(interface {
+		ErrorCode() int
+	})
+
+	return !ok
+}
+
+// activeProvider returns the active provider and the channel closed at the next failover
+func (c *Client) activeProvider() (*provider, chan struct{}) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.providers[c.active], c.failover
+}
+
+// reader returns the provider of a read call
+func (c *Client) reader() *provider {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if !c.roundRobin {
+		return c.providers[c.active]
+	}
+
+	healthy := []*provider{}
+	for _, p := range c.providers {
+		if p.healthy {
+			healthy = append(healthy, p)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return c.providers[c.active]
+	}
+
+	n := atomic.AddUint64(&c.next, 1)
+	return healthy[n%uint64(len(healthy))]
+}
+
+// ActiveURL returns the url of the active provider
+func (c *Client) ActiveURL() string {
+	p, _ := c.activeProvider()
+	return p.url
+}
 
-// InitConnection Initializes single rabbitmq connection for whole system
-func InitConnection(url string) {
+func (c *Client) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	p := c.reader()
+	code, err := p.client.CodeAt(ctx, contract, blockNumber)
+	c.report(p, err)
+	return code, err
+}
+
+func (c *Client) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	p := c.reader()
+	res, err := p.client.CallContract(ctx, call, blockNumber)
+	c.report(p, err)
+	return res, err
+}
+
+func (c *Client) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	p := c.reader()
+	gas, err := p.client.EstimateGas(ctx, call)
+	c.report(p, err)
+	return gas, err
+}
+
+func (c *Client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	p := c.reader()
+	logs, err := p.client.FilterLogs(ctx, q)
+	c.report(p, err)
+	return logs, err
+}
+
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	p := c.reader()
+	header, err := p.client.HeaderByNumber(ctx, number)
+	c.report(p, err)
+	return header, err
+}
+
+func (c *Client) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	p := c.reader()
+	receipt, err := p.client.TransactionReceipt(ctx, hash)
+	c.report(p, err)
+	return receipt, err
+}
+
+func (c *Client) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	p, _ := c.activeProvider()
+	balance, err := p.client.PendingBalanceAt(ctx, account)
+	c.report(p, err)
+	return balance, err
+}
+
+func (c *Client) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	p, _ := c.activeProvider()
+	nonce, err := p.client.NonceAt(ctx, account, blockNumber)
+	c.report(p, err)
+	return nonce, err
+}
+
+func (c *Client) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	p, _ := c.activeProvider()
+	code, err := p.client.PendingCodeAt(ctx, account)
+	c.report(p, err)
+	return code, err
+}
+
+func (c *Client) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	p, _ := c.activeProvider()
+	nonce, err := p.client.PendingNonceAt(ctx, account)
+	c.report(p, err)
+	return nonce, err
+}
+
+func (c *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	p, _ := c.activeProvider()
+	price, err := p.client.SuggestGasPrice(ctx)
+	c.report(p, err)
+	return price, err
+}
 
-	rpcClient, err := rpc.DialHTTP(url)
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	p, _ := c.activeProvider()
+	err := p.client.SendTransaction(ctx, tx)
+	c.report(p, err)
+	return err
+}
+
+// SubscribeFilterLogs subscribes to the logs matching a query on the active provider. The
+// subscription is re-established on the new active provider after a failover, and the
+// logs emitted since the last delivered block are replayed so that none is missed.
+func (c *Client) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	p, failover := c.activeProvider()
+	logs := make(chan types.Log)
+
+	sub, err := p.client.SubscribeFilterLogs(ctx, q, logs)
+	c.report(p, err)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		delivered := newLogSet(replayedLogs)
+		var lastBlock uint64
+
+		deliver := func(l types.Log) bool {
+			if delivered.has(l) {
+				return true
+			}
+
+			select {
+			case ch <- l:
+			case <-quit:
+				return false
+			}
+
+			delivered.add(l)
+			if l.BlockNumber > lastBlock {
+				lastBlock = l.BlockNumber
+			}
+
+			return true
+		}
+
+		for {
+			select {
+			case l := <-logs:
+				if !deliver(l) {
+					sub.Unsubscribe()
+					return nil
+				}
+
+				continue
+			case <-quit:
+				sub.Unsubscribe()
+				return nil
+			case err := <-sub.Err():
+				log.Printf("Log subscription on %s failed: %v", p.url, err)
+				c.setHealth(p, false)
+			case <-failover:
+			}
+
+			sub.Unsubscribe()
+
+			// re-establish the subscription on the active provider, then replay the
+			// logs of the blocks since the last delivered log
+			for {
+				p, failover = c.activeProvider()
+				sub, err = p.client.SubscribeFilterLogs(context.Background(), q, logs)
+				if err == nil {
+					log.Printf("Log subscription re-established on %s", p.url)
+					break
+				}
+
+				c.report(p, err)
+				select {
+				case <-quit:
+					return nil
+				case <-time.After(resubscribeDelay):
+				}
+			}
+
+			if lastBlock > 0 {
+				replay := q
+				replay.FromBlock = new(big.Int).SetUint64(lastBlock)
+				replay.ToBlock = nil
+
+				missed, err := p.client.FilterLogs(context.Background(), replay)
+				if err != nil {
+					log.Printf("Could not replay the logs since block %d: %v", lastBlock, err)
+				}
+
+				for _, l := range missed {
+					if !deliver(l) {
+						sub.Unsubscribe()
+						return nil
+					}
+				}
+			}
+		}
+	}), nil
+}
+
+// logSet remembers the last delivered logs. The keys are kept in a ring buffer, the
+// oldest log is forgotten when a log is added to a full set.
+type logSet struct {
+	keys  []string
+	next  int
+	index map[string]bool
+}
+
+func newLogSet(size int) *logSet {
+	return &logSet{keys: make([]string, size), index: make(map[string]bool)}
+}
+
+// logKey identifies a log in a block. The removal of a log by a reorganization and its
+// inclusion in another block are delivered as different logs.
+func logKey(l types.Log) string {
+	return l.BlockHash.Hex() + ":" + l.TxHash.Hex() + ":" + strconv.FormatUint(uint64(l.Index), 10) + ":" + strconv.FormatBool(l.Removed)
+}
+
+func (s *logSet) has(l types.Log) bool {
+	return s.index[logKey(l)]
+}
+
+func (s *logSet) add(l types.Log) {
+	if len(s.keys) == 0 {
+		return
 	}
 
-	ethereumClient = ethclient.NewClient(rpcClient)
+	delete(s.index, s.keys[s.next])
+
+	k := logKey(l)
+	s.keys[s.next] = k
+	s.index[k] = true
+	s.next = (s.next + 1) % len(s.keys)
 }
@@ -0,0 +1,110 @@
+package ethereum
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestClientFailover(t *testing.T) {
+	p1 := &provider{url: "p1", healthy: true}
+	p2 := &provider{url: "p2", healthy: true}
+	p3 := &provider{url: "p3", healthy: true}
+	c := newClient([]*provider{p1, p2, p3})
+
+	failover := c.failover
+	if p, _ := c.activeProvider(); p != p1 {
+		t.Errorf("Expected p1 to be active, got %v", p.url)
+	}
+
+	c.setHealth(p1, false)
+	if p, _ := c.activeProvider(); p != p2 {
+		t.Errorf("Expected p2 to be active, got %v", p.url)
+	}
+
+	select {
+	case <-failover:
+	default:
+		t.Error("Expected the failover to be notified")
+	}
+
+	c.setHealth(p3, false)
+	if p, _ := c.activeProvider(); p != p2 {
+		t.Errorf("Expected p2 to stay active, got %v", p.url)
+	}
+
+	c.setHealth(p1, true)
+	if p, _ := c.activeProvider(); p != p1 {
+		t.Errorf("Expected p1 to be active again, got %v", p.url)
+	}
+
+	// the last healthy provider stays active when every provider is down
+	c.setHealth(p1, false)
+	c.setHealth(p2, false)
+	if p, _ := c.activeProvider(); p != p2 {
+		t.Errorf("Expected p2 to stay active, got %v", p.url)
+	}
+}
+
+func TestClientRoundRobin(t *testing.T) {
+	p1 := &provider{url: "p1", healthy: true}
+	p2 := &provider{url: "p2", healthy: true}
+	p3 := &provider{url: "p3", healthy: false}
+	c := newClient([]*provider{p1, p2, p3})
+
+	if c.reader() != p1 || c.reader() != p1 {
+		t.Error("Expected the reads to go to the active provider")
+	}
+
+	c.SetRoundRobin(true)
+	seen := map[*provider]int{}
+	for i := 0; i < 4; i++ {
+		seen[c.reader()]++
+	}
+
+	if seen[p1] != 2 || seen[p2] != 2 || seen[p3] != 0 {
+		t.Errorf("Expected the reads to be spread over the healthy providers, got %v", seen)
+	}
+}
+
+func TestLogSet(t *testing.T) {
+	s := newLogSet(2)
+	l1 := types.Log{BlockHash: common.HexToHash("0x1"), TxHash: common.HexToHash("0xa"), Index: 0}
+	l2 := types.Log{BlockHash: common.HexToHash("0x1"), TxHash: common.HexToHash("0xb"), Index: 1}
+
+	s.add(l1)
+	if !s.has(l1) {
+		t.Error("Expected the log to be delivered")
+	}
+
+	// the removal of a log by a reorganization is delivered
+	removed := l1
+	removed.Removed = true
+	if s.has(removed) {
+		t.Error("Expected the removed log not to be delivered yet")
+	}
+
+	s.add(removed)
+
+	// the log included in another block is delivered
+	reincluded := l1
+	reincluded.BlockHash = common.HexToHash("0x2")
+	if s.has(reincluded) {
+		t.Error("Expected the log of the new block not to be delivered yet")
+	}
+
+	// the oldest log is forgotten once the set is full
+	s.add(l2)
+	if s.has(l1) {
+		t.Error("Expected the oldest log to be forgotten")
+	}
+
+	if !s.has(removed) || !s.has(l2) {
+		t.Error("Expected the last logs to be remembered")
+	}
+
+	if len(s.keys) != 2 {
+		t.Errorf("Expected 2 keys, got %v", len(s.keys))
+	}
+}
@@ -0,0 +1,236 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Gas price tiers, from the fastest to the cheapest inclusion
+const (
+	GasPriceFast     = "fast"
+	GasPriceStandard = "standard"
+	GasPriceSlow     = "slow"
+)
+
+// GasPrices are the gas prices (in wei) of the tiers at the last check. Source is the
+// external oracle ("oracle") or the node ("node") the prices come from.
+type GasPrices struct {
+	Fast      *big.Int  `json:"fast"`
+	Standard  *big.Int  `json:"standard"`
+	Slow      *big.Int  `json:"slow"`
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Tier returns the gas price of a tier, the standard price for an unknown tier
+func (p *GasPrices) Tier(tier string) *big.Int {
+	switch tier {
+	case GasPriceFast:
+		return p.Fast
+	case GasPriceSlow:
+		return p.Slow
+	default:
+		return p.Standard
+	}
+}
+
+// GasPriceOracle polls the gas prices used to send the transactions of the operator. The
+// prices come from the external oracle when there is one, and from eth_gasPrice if the
+// oracle fails: the suggested price is the standard tier, the fast and slow tiers are
+// the suggested price scaled by fastPercent and slowPercent.
+type GasPriceOracle struct {
+	suggest     func() (*big.Int, error)
+	external    func() (*GasPrices, error)
+	fastPercent int64
+	slowPercent int64
+	prices      *GasPrices
+	mutex       sync.Mutex
+}
+
+// currentGasPriceOracle is the gas price oracle running in this process, if any
+var currentGasPriceOracle *GasPriceOracle
+var currentGasPriceOracleMutex sync.Mutex
+
+// NewGasPriceOracle returns a gas price oracle. external is nil when there is no
+// external oracle.
+func NewGasPriceOracle(
+	suggest func() (*big.Int, error),
+	external func() (*GasPrices, error),
+	fastPercent int64,
+	slowPercent int64,
+) *GasPriceOracle {
+	return &GasPriceOracle{
+		suggest:     suggest,
+		external:    external,
+		fastPercent: fastPercent,
+		slowPercent: slowPercent,
+	}
+}
+
+// NewClientGasPriceOracle returns a gas price oracle polling the client providers and the
+// external oracle at url if it is not empty. The external oracle returns the prices in
+// tenths of gwei in the ethgasstation format (eg. {"fast": 200, "average": 100, "safeLow": 50}).
+func NewClientGasPriceOracle(c *Client, url string, fastPercent, slowPercent int64) *GasPriceOracle {
+	suggest := func() (*big.Int, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		defer cancel()
+
+		return c.SuggestGasPrice(ctx)
+	}
+
+	var external func() (*GasPrices, error)
+	if url != "" {
+		external = func() (*GasPrices, error) {
+			return fetchGasStationPrices(url)
+		}
+	}
+
+	return NewGasPriceOracle(suggest, external, fastPercent, slowPercent)
+}
+
+// Start checks the gas prices every interval. The oracle becomes the one reported by
+// GetGasPriceOracle and GetGasPrices.
+func (o *GasPriceOracle) Start(interval time.Duration) {
+	currentGasPriceOracleMutex.Lock()
+	currentGasPriceOracle = o
+	currentGasPriceOracleMutex.Unlock()
+
+	go func() {
+		for {
+			_, err := o.Check()
+			if err != nil {
+				log.Printf("Could not check the gas prices: %v", err)
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// Check fetches the gas prices from the external oracle, or from the node if the oracle
+// fails. The last prices are kept if both fail.
+func (o *GasPriceOracle) Check() (*GasPrices, error) {
+	var prices *GasPrices
+	if o.external != nil {
+		p, err := o.external()
+		if err == nil {
+			prices = p
+			prices.Source = "oracle"
+		} else {
+			log.Printf("Could not get the gas prices of the oracle, using the node: %v", err)
+		}
+	}
+
+	if prices == nil {
+		suggested, err := o.suggest()
+		if err != nil {
+			return nil, err
+		}
+
+		prices = &GasPrices{
+			Fast:     scale(suggested, o.fastPercent),
+			Standard: suggested,
+			Slow:     scale(suggested, o.slowPercent),
+			Source:   "node",
+		}
+	}
+
+	prices.UpdatedAt = time.Now()
+
+	o.mutex.Lock()
+	o.prices = prices
+	o.mutex.Unlock()
+
+	return prices, nil
+}
+
+// Price returns the gas price of a tier at the last check. The prices are checked first
+// if they were never fetched.
+func (o *GasPriceOracle) Price(tier string) (*big.Int, error) {
+	prices := o.Prices()
+	if prices == nil {
+		var err error
+		prices, err = o.Check()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return prices.Tier(tier), nil
+}
+
+// Prices returns the result of the last check, or nil
+func (o *GasPriceOracle) Prices() *GasPrices {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.prices
+}
+
+// GetGasPriceOracle returns the gas price oracle running in this process, or nil
+func GetGasPriceOracle() *GasPriceOracle {
+	currentGasPriceOracleMutex.Lock()
+	defer currentGasPriceOracleMutex.Unlock()
+
+	return currentGasPriceOracle
+}
+
+// GetGasPrices returns the last gas prices, or nil if the gas prices are not polled by
+// this process
+func GetGasPrices() *GasPrices {
+	o := GetGasPriceOracle()
+	if o == nil {
+		return nil
+	}
+
+	return o.Prices()
+}
+
+// scale returns percent percent of a gas price
+func scale(price *big.Int, percent int64) *big.Int {
+	scaled := new(big.Int).Mul(price, big.NewInt(percent))
+	return scaled.Div(scaled, big.NewInt(100))
+}
+
+// fetchGasStationPrices fetches the gas prices of an oracle using the ethgasstation format
+func fetchGasStationPrices(url string) (*GasPrices, error) {
+	client := &http.Client{Timeout: healthCheckTimeout}
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Gas price oracle returned status %d", res.StatusCode)
+	}
+
+	var body struct {
+		Fast    float64 `json:"fast"`
+		Average float64 `json:"average"`
+		SafeLow float64 `json:"safeLow"`
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body.Average <= 0 {
+		return nil, fmt.Errorf("Gas price oracle returned no average gas price")
+	}
+
+	// the prices are in tenths of gwei
+	wei := func(v float64) *big.Int {
+		return big.NewInt(int64(v * 1e8))
+	}
+
+	return &GasPrices{Fast: wei(body.Fast), Standard: wei(body.Average), Slow: wei(body.SafeLow)}, nil
+}
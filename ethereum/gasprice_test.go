@@ -0,0 +1,53 @@
+package ethereum
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGasPriceOracleNode(t *testing.T) {
+	o := NewGasPriceOracle(func() (*big.Int, error) { return big.NewInt(10e9), nil }, nil, 125, 80)
+
+	prices, err := o.Check()
+	if err != nil {
+		t.Fatalf("Could not check the gas prices: %v", err)
+	}
+
+	if prices.Source != "node" {
+		t.Errorf("Expected the prices of the node, got %s", prices.Source)
+	}
+
+	for tier, expected := range map[string]int64{GasPriceFast: 12.5e9, GasPriceStandard: 10e9, GasPriceSlow: 8e9} {
+		price, err := o.Price(tier)
+		if err != nil || price.Int64() != expected {
+			t.Errorf("Expected %s gas price %d, got %v (%v)", tier, expected, price, err)
+		}
+	}
+}
+
+func TestGasPriceOracleExternal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"fast": 200, "average": 100, "safeLow": 50}`)
+	}))
+	defer server.Close()
+
+	suggest := func() (*big.Int, error) { return big.NewInt(1e9), nil }
+	external := func() (*GasPrices, error) { return fetchGasStationPrices(server.URL) }
+
+	o := NewGasPriceOracle(suggest, external, 125, 80)
+	price, err := o.Price(GasPriceFast)
+	if err != nil || price.Int64() != 20e9 {
+		t.Errorf("Expected the fast gas price of the oracle, got %v (%v)", price, err)
+	}
+
+	// the node is used when the oracle fails
+	o = NewGasPriceOracle(suggest, func() (*GasPrices, error) { return nil, errors.New("unavailable") }, 125, 80)
+	prices, err := o.Check()
+	if err != nil || prices.Source != "node" || prices.Standard.Int64() != 1e9 {
+		t.Errorf("Expected the prices of the node, got %+v (%v)", prices, err)
+	}
+}
@@ -0,0 +1,228 @@
+package ethereum
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	eth "github.com/ethereum/go-ethereum/core/types"
+)
+
+// Events of the transactions watched by a TxMonitor
+const (
+	// TxMined is sent when a transaction is included in a block, and again if it is
+	// included in another block after a chain reorganization
+	TxMined = "MINED"
+	// TxConfirmed is sent when the block of a successful transaction has enough confirmations
+	TxConfirmed = "CONFIRMED"
+	// TxReverted is sent when the block of a reverted transaction has enough confirmations
+	TxReverted = "REVERTED"
+	// TxReorged is sent when the block of a transaction is removed by a chain
+	// reorganization. The transaction is still watched.
+	TxReorged = "REORGED"
+)
+
+// TxEvent is an event of a watched transaction. Receipt is the receipt of the
+// transaction in its current block, it is nil if the transaction was reorged out.
+type TxEvent struct {
+	Hash          common.Hash
+	Event         string
+	Receipt       *eth.Receipt
+	Confirmations uint64
+}
+
+// TxMonitor follows the transactions sent to the chain until their block has enough
+// confirmations. A transaction is only final once it is confirmed: the block of a
+// transaction can be replaced by a chain reorganization, in which case the transaction
+// may be included in another block or dropped, and may not revert the same way.
+type TxMonitor struct {
+	receipt       func(common.Hash) (*eth.Receipt, error)
+	latestHeader  func() (*eth.Header, error)
+	confirmations uint64
+	txs           map[common.Hash]*watchedTx
+	mutex         sync.Mutex
+}
+
+// watchedTx is the handler of a watched transaction and the hash of the block it was last
+// seen in, which is empty until the transaction is mined
+type watchedTx struct {
+	handler func(*TxEvent)
+	block   common.Hash
+}
+
+// NewTxMonitor returns a monitor of the transactions whose receipts are returned by
+// receipt. The transactions are final after the given number of confirmations,
+// including their block.
+func NewTxMonitor(
+	receipt func(common.Hash) (*eth.Receipt, error),
+	latestHeader func() (*eth.Header, error),
+	confirmations uint64,
+) *TxMonitor {
+	if confirmations == 0 {
+		confirmations = 1
+	}
+
+	return &TxMonitor{
+		receipt:       receipt,
+		latestHeader:  latestHeader,
+		confirmations: confirmations,
+		txs:           make(map[common.Hash]*watchedTx),
+	}
+}
+
+// NewClientTxMonitor returns a monitor of the transactions of the client providers
+func NewClientTxMonitor(c *Client, confirmations uint64) *TxMonitor {
+	receipt := func(hash common.Hash) (*eth.Receipt, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		defer cancel()
+
+		return c.TransactionReceipt(ctx, hash)
+	}
+
+	latestHeader := func() (*eth.Header, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		defer cancel()
+
+		return c.HeaderByNumber(ctx, nil)
+	}
+
+	return NewTxMonitor(receipt, latestHeader, confirmations)
+}
+
+// Watch calls handler with the events of a transaction until it is confirmed or
+// reverted. Watching a transaction again replaces its handler.
+func (m *TxMonitor) Watch(hash common.Hash, handler func(*TxEvent)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if w, ok := m.txs[hash]; ok {
+		w.handler = handler
+		return
+	}
+
+	m.txs[hash] = &watchedTx{handler: handler}
+}
+
+// Replace watches the transaction replacing a transaction with the same nonce (eg. with a
+// higher gas price) with the handler of the replaced transaction
+func (m *TxMonitor) Replace(old, new common.Hash) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	w, ok := m.txs[old]
+	if !ok {
+		return
+	}
+
+	delete(m.txs, old)
+	m.txs[new] = &watchedTx{handler: w.handler}
+}
+
+// Watching returns the number of transactions that are not final yet
+func (m *TxMonitor) Watching() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return len(m.txs)
+}
+
+// Start checks the watched transactions every interval
+func (m *TxMonitor) Start(interval time.Duration) {
+	go func() {
+		for {
+			err := m.Check()
+			if err != nil {
+				log.Printf("Could not check the settlement transactions: %v", err)
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// Check fetches the receipts of the watched transactions and sends their events
+func (m *TxMonitor) Check() error {
+	header, err := m.latestHeader()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	hashes := make([]common.Hash, 0, len(m.txs))
+	for hash := range m.txs {
+		hashes = append(hashes, hash)
+	}
+	m.mutex.Unlock()
+
+	for _, hash := range hashes {
+		receipt, err := m.receipt(hash)
+		if err != nil && err != ethereum.NotFound {
+			log.Printf("Could not get the receipt of %s: %v", hash.Hex(), err)
+			continue
+		}
+
+		if err == ethereum.NotFound {
+			receipt = nil
+		}
+
+		handler, events := m.update(hash, receipt, header.Number.Uint64())
+		for _, e := range events {
+			handler(e)
+		}
+	}
+
+	return nil
+}
+
+// update records the block of a watched transaction and returns the events of the
+// transaction since the last check. The transaction is not watched anymore once final.
+func (m *TxMonitor) update(hash common.Hash, receipt *eth.Receipt, blockNumber uint64) (func(*TxEvent), []*TxEvent) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	w, ok := m.txs[hash]
+	if !ok {
+		return nil, nil
+	}
+
+	events := []*TxEvent{}
+	if receipt == nil {
+		// the transaction is back in the pool or dropped
+		if w.block != (common.Hash{}) {
+			w.block = common.Hash{}
+			events = append(events, &TxEvent{Hash: hash, Event: TxReorged})
+		}
+
+		return w.handler, events
+	}
+
+	if receipt.BlockHash != w.block {
+		if w.block != (common.Hash{}) {
+			events = append(events, &TxEvent{Hash: hash, Event: TxReorged})
+		}
+
+		w.block = receipt.BlockHash
+		events = append(events, &TxEvent{Hash: hash, Event: TxMined, Receipt: receipt})
+	}
+
+	confirmations := uint64(0)
+	if mined := receipt.BlockNumber.Uint64(); blockNumber >= mined {
+		confirmations = blockNumber - mined + 1
+	}
+
+	if confirmations < m.confirmations {
+		return w.handler, events
+	}
+
+	delete(m.txs, hash)
+
+	e := &TxEvent{Hash: hash, Event: TxConfirmed, Receipt: receipt, Confirmations: confirmations}
+	if receipt.Status != eth.ReceiptStatusSuccessful {
+		e.Event = TxReverted
+	}
+
+	return w.handler, append(events, e)
+}
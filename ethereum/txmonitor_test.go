@@ -0,0 +1,145 @@
+package ethereum
+
+import (
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	eth "github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestTxMonitor(t *testing.T) {
+	hash := common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a")
+	header := &eth.Header{Number: big.NewInt(100)}
+	var receipt *eth.Receipt
+
+	m := NewTxMonitor(
+		func(common.Hash) (*eth.Receipt, error) {
+			if receipt == nil {
+				return nil, ethereum.NotFound
+			}
+
+			return receipt, nil
+		},
+		func() (*eth.Header, error) { return header, nil },
+		3,
+	)
+
+	events := []string{}
+	m.Watch(hash, func(e *TxEvent) { events = append(events, e.Event) })
+
+	// the transaction is not mined yet
+	m.Check()
+	if len(events) != 0 {
+		t.Errorf("Unexpected events %v", events)
+	}
+
+	receipt = &eth.Receipt{
+		Status:      eth.ReceiptStatusSuccessful,
+		BlockHash:   common.HexToHash("0x01"),
+		BlockNumber: big.NewInt(100),
+	}
+
+	m.Check()
+	if len(events) != 1 || events[0] != TxMined {
+		t.Errorf("Expected the transaction to be mined, got %v", events)
+	}
+
+	// the block is removed by a reorganization and the transaction is back in the pool
+	receipt = nil
+	header = &eth.Header{Number: big.NewInt(101)}
+	m.Check()
+	if len(events) != 2 || events[1] != TxReorged {
+		t.Errorf("Expected the transaction to be reorged, got %v", events)
+	}
+
+	// the transaction is included in another block, where it reverts
+	receipt = &eth.Receipt{
+		Status:      eth.ReceiptStatusFailed,
+		BlockHash:   common.HexToHash("0x02"),
+		BlockNumber: big.NewInt(101),
+	}
+
+	m.Check()
+	if len(events) != 3 || events[2] != TxMined {
+		t.Errorf("Expected the transaction to be mined again, got %v", events)
+	}
+
+	header = &eth.Header{Number: big.NewInt(103)}
+	m.Check()
+	if len(events) != 4 || events[3] != TxReverted {
+		t.Errorf("Expected the transaction to be reverted, got %v", events)
+	}
+
+	if m.Watching() != 0 {
+		t.Error("Expected the reverted transaction not to be watched anymore")
+	}
+}
+
+func TestTxMonitorConfirmation(t *testing.T) {
+	hash := common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a")
+	receipt := &eth.Receipt{
+		Status:      eth.ReceiptStatusSuccessful,
+		BlockHash:   common.HexToHash("0x01"),
+		BlockNumber: big.NewInt(100),
+	}
+
+	m := NewTxMonitor(
+		func(common.Hash) (*eth.Receipt, error) { return receipt, nil },
+		func() (*eth.Header, error) { return &eth.Header{Number: big.NewInt(100)}, nil },
+		1,
+	)
+
+	events := []*TxEvent{}
+	m.Watch(hash, func(e *TxEvent) { events = append(events, e) })
+
+	// a transaction mined with enough confirmations is final at once
+	m.Check()
+	if len(events) != 2 || events[0].Event != TxMined || events[1].Event != TxConfirmed {
+		t.Fatalf("Expected the transaction to be mined and confirmed, got %d events", len(events))
+	}
+
+	if events[1].Confirmations != 1 || events[1].Receipt.BlockHash != receipt.BlockHash {
+		t.Errorf("Unexpected confirmation %+v", events[1])
+	}
+
+	// the confirmed transactions are not watched for reorganizations anymore
+	receipt.BlockHash = common.HexToHash("0x02")
+	m.Check()
+	if len(events) != 2 {
+		t.Error("Expected no event after the confirmation")
+	}
+}
+
+func TestTxMonitorReplace(t *testing.T) {
+	old := common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a")
+	replacement := common.HexToHash("0x07a9d9bdde1b0a8cba72e3b03d29cd11a22bf41f1f3e8af9ea1b4e7e28e2c6e0")
+	receipt := &eth.Receipt{
+		Status:      eth.ReceiptStatusSuccessful,
+		BlockHash:   common.HexToHash("0x01"),
+		BlockNumber: big.NewInt(100),
+	}
+
+	m := NewTxMonitor(
+		func(hash common.Hash) (*eth.Receipt, error) {
+			if hash != replacement {
+				return nil, ethereum.NotFound
+			}
+
+			return receipt, nil
+		},
+		func() (*eth.Header, error) { return &eth.Header{Number: big.NewInt(100)}, nil },
+		1,
+	)
+
+	events := []*TxEvent{}
+	m.Watch(old, func(e *TxEvent) { events = append(events, e) })
+	m.Replace(old, replacement)
+
+	// the handler of the replaced transaction receives the events of the replacement
+	m.Check()
+	if len(events) != 2 || events[1].Event != TxConfirmed || events[1].Hash != replacement {
+		t.Fatalf("Expected the replacement transaction to be confirmed, got %d events", len(events))
+	}
+}
@@ -0,0 +1,40 @@
+package ethereum
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	eth "github.com/ethereum/go-ethereum/core/types"
+)
+
+// withdrawABI is the withdraw function of the exchange contract. The contract checks the
+// signature of the trader and the withdrawal hash, and transfers the tokens to the
+// receiver. Only the operators of the exchange can send withdrawals.
+const withdrawABI = `[{"constant":false,"inputs":[{"name":"token","type":"address"},{"name":"amount","type":"uint256"},{"name":"trader","type":"address"},{"name":"receiver","type":"address"},{"name":"nonce","type":"uint256"},{"name":"v","type":"uint8"},{"name":"rs","type":"bytes32[2]"},{"name":"feeWithdrawal","type":"uint256"}],"name":"withdraw","outputs":[{"name":"","type":"bool"}],"payable":false,"stateMutability":"nonpayable","type":"function"}]`
+
+// Withdrawer sends the signed withdrawals of the traders to the exchange contract
+type Withdrawer struct {
+	contract *bind.BoundContract
+}
+
+// NewWithdrawer returns a withdrawer sending the withdrawals to the exchange contract
+// through backend
+func NewWithdrawer(exchange common.Address, backend bind.ContractBackend) (*Withdrawer, error) {
+	parsed, err := abi.JSON(strings.NewReader(withdrawABI))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Withdrawer{bind.NewBoundContract(exchange, parsed, backend, backend, backend)}, nil
+}
+
+// Withdraw sends the withdrawal transaction of a signed withdrawal with the given options
+// of an operator account. No withdrawal fee is charged.
+func (w *Withdrawer) Withdraw(opts *bind.TransactOpts, wd *types.Withdraw) (*eth.Transaction, error) {
+	rs := [2][32]byte{wd.Signature.R, wd.Signature.S}
+	return w.contract.Transact(opts, "withdraw", wd.Token, wd.Amount, wd.Trader, wd.Receiver, wd.Nonce, wd.Signature.V, rs, big.NewInt(0))
+}
@@ -1,22 +1,23 @@
 package mocks
 
 import (
+	"context"
 	"encoding/json"
-	"flag"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"sync"
+	"time"
 
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/websocket"
 	"github.com/posener/wstest"
 )
 
-var wg = &sync.WaitGroup{}
-var addr = flag.String("addr", "localhost:8080", "http service address")
-
 // Client simulates the client websocket handler that will be used to perform trading.
 // requests and responses are respectively the outbound and incoming messages.
 // requestLogs and responseLogs are arrays of messages that denote the history of received messages
@@ -24,59 +25,134 @@ var addr = flag.String("addr", "localhost:8080", "http service address")
 // mutex is used to prevent concurrent writes on the websocket connection
 type Client struct {
 	// ethereumClient *ethclient.Client
-	connection   *websocket.Conn
-	Requests     chan *types.WebSocketMessage
-	Responses    chan *types.WebSocketMessage
-	Logs         chan *ClientLogMessage
-	Wallet       *types.Wallet
-	RequestLogs  []*types.WebSocketMessage
-	ResponseLogs []*types.WebSocketMessage
-	mutex        sync.Mutex
+	connection *websocket.Conn
+	Requests   chan *types.WebSocketMessage
+	Responses  chan *types.WebSocketMessage
+	Logs       chan *ClientLogMessage
+	Wallet     *types.Wallet
+	mutex      sync.Mutex
+
+	// requestLogs, responseLogs and the market data messages received on the
+	// orderbook, trades and ohlcv channels are protected by logsMutex. updated is
+	// closed and replaced each time a message is recorded to wake up the Expect functions.
+	requestLogs     []*types.WebSocketMessage
+	responseLogs    []*types.WebSocketMessage
+	orderBookLogs   []*OrderBookLog
+	tradeLogs       []*TradeLog
+	ohlcvLogs       []*OHLCVLog
+	orderBookCursor int
+	logsMutex       sync.Mutex
+	updated         chan struct{}
+
+	// fixture records the conversation with the server when recording is enabled.
+	// It is protected by logsMutex.
+	fixture *Fixture
+
+	// ctx is done when the client is shut down, wg tracks the client goroutines
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// OrderBookPayload is the payload of the orderbook channel messages
+type OrderBookPayload struct {
+	Pair types.PairSubDoc     `json:"pair"`
+	Asks []map[string]float64 `json:"asks"`
+	Bids []map[string]float64 `json:"bids"`
+}
+
+// OrderBookLog records an orderbook channel message. Type is INIT or UPDATE
+type OrderBookLog struct {
+	Type    string
+	Payload *OrderBookPayload
+}
+
+// TradeLog records a trades channel message. Type is INIT or UPDATE
+type TradeLog struct {
+	Type   string
+	Trades []*types.Trade
+}
+
+// OHLCVLog records an ohlcv channel message. Type is INIT or UPDATE
+type OHLCVLog struct {
+	Type  string
+	Ticks []*types.Tick
 }
 
 // The client log is mostly used for testing. It optionally takes orders, trade,
 // error ids and transaction hashes. All these parameters are optional in order to
 // allow the client log message to take in a lot of different types of messages
-// An error id of -1 means that there was no error.
+// An error id of 0 means that there was no error.
 type ClientLogMessage struct {
-	MessageType string       `json:"messageType"`
-	Order       *types.Order `json:"order"`
-	Trade       *types.Trade `json:"trade"`
-	Tx          common.Hash  `json:"tx"`
-	ErrorID     int8         `json:"errorID"`
+	MessageType string                  `json:"messageType"`
+	Order       *types.Order            `json:"order"`
+	Trade       *types.Trade            `json:"trade"`
+	Tx          common.Hash             `json:"tx"`
+	ErrorID     aerrors.ExchangeErrorID `json:"errorID"`
 }
 
 type Server interface {
 	ServeHTTP(res http.ResponseWriter, req *http.Request)
 }
 
-// NewClient a default client struct connected to the given server
-func NewClient(w *types.Wallet, s Server) *Client {
-	flag.Parse()
-	uri := url.URL{Scheme: "ws", Host: "localhost:8080", Path: "/socket"}
+// Dialer opens websocket connections. *websocket.Dialer implements it, wstest.NewDialer
+// can be used to connect to a handler without going through the network.
+type Dialer interface {
+	Dial(urlStr string, requestHeader http.Header) (*websocket.Conn, *http.Response, error)
+}
+
+// NewClient returns a client connected to the websocket endpoint of the given server.
+// The connection does not go through the network.
+func NewClient(w *types.Wallet, s Server) (*Client, error) {
+	return DialClient(context.Background(), w, "ws://localhost/socket", wstest.NewDialer(s))
+}
 
-	d := wstest.NewDialer(s)
-	c, _, err := d.Dial(uri.String(), nil)
+// DialClient returns a client connected to the websocket endpoint at the target url.
+// http(s) urls are converted to ws(s) urls so that the url of an httptest.Server can be
+// used directly (eg. server.URL + "/socket"). If dialer is nil the default websocket
+// dialer is used. The client goroutines stop and the connection is closed when ctx is done.
+func DialClient(ctx context.Context, w *types.Wallet, target string, dialer Dialer) (*Client, error) {
+	uri, err := url.Parse(target)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	switch uri.Scheme {
+	case "http":
+		uri.Scheme = "ws"
+	case "https":
+		uri.Scheme = "wss"
 	}
 
-	reqs := make(chan *types.WebSocketMessage)
-	resps := make(chan *types.WebSocketMessage)
-	logs := make(chan *ClientLogMessage)
-	reqLogs := make([]*types.WebSocketMessage, 0)
-	respLogs := make([]*types.WebSocketMessage, 0)
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
 
-	return &Client{
-		connection:   c,
+	conn, _, err := dialer.Dial(uri.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	c := &Client{
+		connection:   conn,
 		Wallet:       w,
-		Requests:     reqs,
-		Responses:    resps,
-		RequestLogs:  reqLogs,
-		ResponseLogs: respLogs,
-		Logs:         logs,
-		// ethereumClient: ethClient,
+		Requests:     make(chan *types.WebSocketMessage),
+		Responses:    make(chan *types.WebSocketMessage),
+		Logs:         make(chan *ClientLogMessage),
+		requestLogs:  make([]*types.WebSocketMessage, 0),
+		responseLogs: make([]*types.WebSocketMessage, 0),
+		updated:      make(chan struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
 	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	return c, nil
 }
 
 // send is used to prevent concurrent writes on the websocket connection
@@ -92,38 +168,88 @@ func (c *Client) Start() {
 	c.handleIncomingMessages()
 }
 
+// Stop shuts down the client. It closes the connection and waits for the reader,
+// writer and handler goroutines to terminate.
+func (c *Client) Stop() {
+	c.cancel()
+	if c.connection != nil {
+		c.connection.Close()
+	}
+
+	c.wg.Wait()
+}
+
 // handleMessages waits for incoming messages and routes messages to the
 // corresponding handler.
 // requests are the messages that are written on the client and destined to
 // the server. responses are the message that are
 func (c *Client) handleMessages() {
+	c.wg.Add(1)
 	go func() {
+		defer c.wg.Done()
+
 		for {
 			select {
+			case <-c.ctx.Done():
+				return
+
 			case msg := <-c.Requests:
-				// log.Print("Handling Request: ", msg)
-				c.RequestLogs = append(c.RequestLogs, msg)
+				c.logsMutex.Lock()
+				c.requestLogs = append(c.requestLogs, msg)
+				c.recordFixtureMessage(FixtureOutgoing, msg)
+				c.logsMutex.Unlock()
+
 				c.handleOrderChannelMessagesOut(*msg)
 
 			case msg := <-c.Responses:
-				// log.Print("Handling Response: ", msg)
-				c.ResponseLogs = append(c.ResponseLogs, msg)
-
-				switch msg.Channel {
-				case "orders":
-					go c.handleOrderChannelMessagesIn(msg.Payload)
-				case "order_book":
-					go c.handleOrderBookChannelMessages(msg.Payload)
-				case "trades":
-					go c.handleTradeChannelMessages(msg.Payload)
-				case "ohlcv":
-					go c.handleOHLCVMessages(msg.Payload)
-				}
+				c.handleResponse(msg)
 			}
 		}
 	}()
 }
 
+// handleResponse logs an incoming message and routes it to the handler of its channel
+func (c *Client) handleResponse(msg *types.WebSocketMessage) {
+	c.logsMutex.Lock()
+	c.responseLogs = append(c.responseLogs, msg)
+	c.recordFixtureMessage(FixtureIncoming, msg)
+	c.logsMutex.Unlock()
+
+	switch msg.Channel {
+	case "orders":
+		c.goHandle(c.handleOrderChannelMessagesIn, msg.Payload)
+	case "order_book":
+		c.goHandle(c.handleOrderBookChannelMessages, msg.Payload)
+	case "trades":
+		c.goHandle(c.handleTradeChannelMessages, msg.Payload)
+	case "ohlcv":
+		c.goHandle(c.handleOHLCVMessages, msg.Payload)
+	}
+}
+
+// goHandle runs a message handler in a goroutine tracked by the client wait group
+func (c *Client) goHandle(fn func(types.WebSocketPayload), p types.WebSocketPayload) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		fn(p)
+	}()
+}
+
+// RequestLogs returns the messages sent by the client
+func (c *Client) RequestLogs() []*types.WebSocketMessage {
+	c.logsMutex.Lock()
+	defer c.logsMutex.Unlock()
+	return append([]*types.WebSocketMessage{}, c.requestLogs...)
+}
+
+// ResponseLogs returns the messages received by the client
+func (c *Client) ResponseLogs() []*types.WebSocketMessage {
+	c.logsMutex.Lock()
+	defer c.logsMutex.Unlock()
+	return append([]*types.WebSocketMessage{}, c.responseLogs...)
+}
+
 // handleChannelMessagesOut
 func (c *Client) handleOrderChannelMessagesOut(m types.WebSocketMessage) {
 	err := c.send(m)
@@ -181,10 +307,14 @@ func (c *Client) handleOHLCVMessages(p types.WebSocketPayload) {
 // handleIncomingMessages reads incomings JSON messages from the websocket connection and
 // feeds them into the responses channel
 func (c *Client) handleIncomingMessages() {
-	message := new(types.WebSocketMessage)
+	c.wg.Add(1)
 	go func() {
+		defer c.wg.Done()
+
 		for {
-			err := c.connection.ReadJSON(&message)
+			// each message is decoded in a new value as it is shared with the handlers
+			message := &types.WebSocketMessage{}
+			err := c.connection.ReadJSON(message)
 			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					log.Printf("Error: %#v", err)
@@ -192,7 +322,11 @@ func (c *Client) handleIncomingMessages() {
 				break
 			}
 
-			c.Responses <- message
+			select {
+			case c.Responses <- message:
+			case <-c.ctx.Done():
+				return
+			}
 		}
 	}()
 }
@@ -216,7 +350,7 @@ func (c *Client) handleOrderAdded(p types.WebSocketPayload) {
 		Order:       o,
 	}
 
-	c.Logs <- l
+	c.log(l)
 }
 
 // handleOrderAdded handles incoming order canceled messages
@@ -238,47 +372,269 @@ func (c *Client) handleOrderCancelled(p types.WebSocketPayload) {
 		Order:       o,
 	}
 
-	c.Logs <- l
+	c.log(l)
+}
+
+// log sends a message on the client logs channel unless the client is shut down
+func (c *Client) log(l *ClientLogMessage) {
+	select {
+	case c.Logs <- l:
+	case <-c.ctx.Done():
+	}
 }
 
+// handleSignatureRequested handles incoming signature requests. The matched order is
+// logged, the trades to sign can be found in the response logs.
 func (c *Client) handleSignatureRequested(p types.WebSocketPayload) {
+	res := &struct {
+		Order *types.Order
+	}{}
 
+	err := decodePayloadData(p.Data, res)
+	if err != nil {
+		log.Print(err)
+	}
+
+	l := &ClientLogMessage{
+		MessageType: "REQUEST_SIGNATURE",
+		Order:       res.Order,
+	}
+
+	c.log(l)
 }
 
 func (c *Client) handleTradeExecuted(p types.WebSocketPayload) {
 
 }
 
+// handleOrderTxSuccess handles incoming settlement success messages
 func (c *Client) handleOrderTxSuccess(p types.WebSocketPayload) {
+	tr := &types.Trade{}
+	err := decodePayloadData(p.Data, tr)
+	if err != nil {
+		log.Print(err)
+	}
+
+	l := &ClientLogMessage{
+		MessageType: "TRADE_TX_SUCCESS",
+		Trade:       tr,
+		Order:       &types.Order{Hash: common.HexToHash(p.Hash)},
+	}
 
+	c.log(l)
 }
 
+// handleOrderTxError handles incoming settlement failure messages
 func (c *Client) handleOrderTxError(p types.WebSocketPayload) {
+	res := &struct {
+		Trade     *types.Trade            `json:"trade"`
+		ErrorCode aerrors.ExchangeErrorID `json:"errorCode"`
+	}{}
+
+	err := decodePayloadData(p.Data, res)
+	if err != nil {
+		log.Print(err)
+	}
 
+	l := &ClientLogMessage{
+		MessageType: "TRADE_TX_ERROR",
+		Trade:       res.Trade,
+		Order:       &types.Order{Hash: common.HexToHash(p.Hash)},
+		ErrorID:     res.ErrorCode,
+	}
+
+	c.log(l)
 }
 
 func (c *Client) handleOrderBookInit(p types.WebSocketPayload) {
-
+	c.recordOrderBook("INIT", p)
 }
 
 func (c *Client) handleOrderBookUpdate(p types.WebSocketPayload) {
-
+	c.recordOrderBook("UPDATE", p)
 }
 
 func (c *Client) handleTradesInit(p types.WebSocketPayload) {
-
+	c.recordTrades("INIT", p)
 }
 
 func (c *Client) handleTradesUpdate(p types.WebSocketPayload) {
-
+	c.recordTrades("UPDATE", p)
 }
 
 func (c *Client) handleOHLCVInit(p types.WebSocketPayload) {
-
+	c.recordOHLCV("INIT", p)
 }
 
 func (c *Client) handleOHLCVUpdate(p types.WebSocketPayload) {
+	c.recordOHLCV("UPDATE", p)
+}
+
+// recordOrderBook decodes an orderbook channel payload and appends it to the orderbook logs
+func (c *Client) recordOrderBook(msgType string, p types.WebSocketPayload) {
+	ob := &OrderBookPayload{}
+	err := decodePayloadData(p.Data, ob)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	c.record(func() {
+		c.orderBookLogs = append(c.orderBookLogs, &OrderBookLog{Type: msgType, Payload: ob})
+	})
+}
+
+// recordTrades decodes a trades channel payload and appends it to the trade logs.
+// The payload is either a list of trades or a single trade.
+func (c *Client) recordTrades(msgType string, p types.WebSocketPayload) {
+	trades := []*types.Trade{}
+	err := decodePayloadData(p.Data, &trades)
+	if err != nil {
+		tr := &types.Trade{}
+		err = decodePayloadData(p.Data, tr)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		trades = append(trades, tr)
+	}
+
+	c.record(func() {
+		c.tradeLogs = append(c.tradeLogs, &TradeLog{Type: msgType, Trades: trades})
+	})
+}
+
+// recordOHLCV decodes an ohlcv channel payload and appends it to the ohlcv logs.
+// The payload is either a list of ticks or a single tick.
+func (c *Client) recordOHLCV(msgType string, p types.WebSocketPayload) {
+	ticks := []*types.Tick{}
+	err := decodePayloadData(p.Data, &ticks)
+	if err != nil {
+		tick := &types.Tick{}
+		err = decodePayloadData(p.Data, tick)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		ticks = append(ticks, tick)
+	}
+
+	c.record(func() {
+		c.ohlcvLogs = append(c.ohlcvLogs, &OHLCVLog{Type: msgType, Ticks: ticks})
+	})
+}
+
+// record applies fn to the market data logs and wakes up the Expect functions
+func (c *Client) record(fn func()) {
+	c.logsMutex.Lock()
+	defer c.logsMutex.Unlock()
+
+	fn()
+	close(c.updated)
+	c.updated = make(chan struct{})
+}
+
+// wait calls cond each time a market data message is recorded until it returns
+// true or until the timeout expires. cond is called with logsMutex held.
+func (c *Client) wait(timeout time.Duration, cond func() bool) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		c.logsMutex.Lock()
+		ok := cond()
+		updated := c.updated
+		c.logsMutex.Unlock()
+
+		if ok {
+			return true
+		}
+
+		select {
+		case <-updated:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// OrderBookLogs returns the orderbook channel messages received by the client
+func (c *Client) OrderBookLogs() []*OrderBookLog {
+	c.logsMutex.Lock()
+	defer c.logsMutex.Unlock()
+	return append([]*OrderBookLog{}, c.orderBookLogs...)
+}
+
+// TradeLogs returns the trades channel messages received by the client
+func (c *Client) TradeLogs() []*TradeLog {
+	c.logsMutex.Lock()
+	defer c.logsMutex.Unlock()
+	return append([]*TradeLog{}, c.tradeLogs...)
+}
+
+// OHLCVLogs returns the ohlcv channel messages received by the client
+func (c *Client) OHLCVLogs() []*OHLCVLog {
+	c.logsMutex.Lock()
+	defer c.logsMutex.Unlock()
+	return append([]*OHLCVLog{}, c.ohlcvLogs...)
+}
+
+// ExpectOrderBookUpdate waits for the next orderbook UPDATE message that was not
+// returned by a previous call and returns its payload.
+func (c *Client) ExpectOrderBookUpdate(timeout time.Duration) (*OrderBookPayload, error) {
+	var res *OrderBookPayload
+	ok := c.wait(timeout, func() bool {
+		for ; c.orderBookCursor < len(c.orderBookLogs); c.orderBookCursor++ {
+			if c.orderBookLogs[c.orderBookCursor].Type == "UPDATE" {
+				res = c.orderBookLogs[c.orderBookCursor].Payload
+				c.orderBookCursor++
+				return true
+			}
+		}
+
+		return false
+	})
+
+	if !ok {
+		return nil, errors.New("No orderbook update received")
+	}
+
+	return res, nil
+}
+
+// ExpectTrade waits until a trade with the given hash is received on the trades channel
+func (c *Client) ExpectTrade(hash common.Hash, timeout time.Duration) (*types.Trade, error) {
+	var res *types.Trade
+	ok := c.wait(timeout, func() bool {
+		for _, l := range c.tradeLogs {
+			for _, tr := range l.Trades {
+				if tr.Hash == hash {
+					res = tr
+					return true
+				}
+			}
+		}
+
+		return false
+	})
+
+	if !ok {
+		return nil, fmt.Errorf("Trade %s not received", hash.Hex())
+	}
+
+	return res, nil
+}
+
+// decodePayloadData decodes the data of a websocket payload into v
+func decodePayloadData(data interface{}, v interface{}) error {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
 
+	return json.Unmarshal(bytes, v)
 }
 
 // func (c *Client) placeOrder(req *Message) {
@@ -0,0 +1,160 @@
+package mocks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func toPayloadData(t *testing.T, v interface{}) interface{} {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Could not marshal payload: %v", err)
+	}
+
+	var data interface{}
+	err = json.Unmarshal(bytes, &data)
+	if err != nil {
+		t.Fatalf("Could not unmarshal payload: %v", err)
+	}
+
+	return data
+}
+
+func TestClientExpectTrade(t *testing.T) {
+	err := app.LoadConfig("../config")
+	if err != nil {
+		t.Errorf("Could not load configuration: %v", err)
+	}
+
+	pair := getZRXWETHPairMock()
+	f, err := NewOrderFactory(pair, getMockWallet(), common.HexToAddress(app.Config.ExchangeAddress))
+	if err != nil {
+		t.Errorf("Error creating order factory client: %v", err)
+	}
+
+	order, _ := f.NewOrder(pair.BaseTokenAddress, 1, pair.QuoteTokenAddress, 1)
+	trade, _ := f.NewTrade(order, 1)
+
+	c := &Client{updated: make(chan struct{})}
+	go c.handleTradesUpdate(types.WebSocketPayload{Type: "UPDATE", Data: toPayloadData(t, []*types.Trade{trade})})
+
+	received, err := c.ExpectTrade(trade.Hash, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, trade.OrderHash, received.OrderHash)
+	assert.Equal(t, 1, len(c.TradeLogs()))
+
+	_, err = c.ExpectTrade(common.HexToHash("0x1"), 10*time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestClientExpectOrderBookUpdate(t *testing.T) {
+	c := &Client{updated: make(chan struct{})}
+
+	ob := map[string]interface{}{
+		"asks": []map[string]float64{{"price": 1, "volume": 2}},
+		"bids": []map[string]float64{},
+	}
+
+	c.handleOrderBookInit(types.WebSocketPayload{Type: "INIT", Data: toPayloadData(t, ob)})
+	go c.handleOrderBookUpdate(types.WebSocketPayload{Type: "UPDATE", Data: toPayloadData(t, ob)})
+
+	update, err := c.ExpectOrderBookUpdate(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, float64(2), update.Asks[0]["volume"])
+
+	_, err = c.ExpectOrderBookUpdate(10 * time.Millisecond)
+	assert.Error(t, err)
+	assert.Equal(t, 2, len(c.OrderBookLogs()))
+}
+
+func TestDialClient(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	closed := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		// blocks until the client closes the connection
+		conn.ReadMessage()
+		close(closed)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := DialClient(ctx, getMockWallet(), server.URL+"/socket", nil)
+	if err != nil {
+		t.Fatalf("Could not dial test server: %v", err)
+	}
+
+	c.Start()
+	cancel()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Error("Connection was not closed on shutdown")
+	}
+
+	_, err = DialClient(context.Background(), getMockWallet(), "ws://127.0.0.1:1/socket", nil)
+	assert.Error(t, err)
+}
+
+func TestClientStop(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		for i := 0; i < 50; i++ {
+			msg := types.WebSocketMessage{
+				Channel: "order_book",
+				Payload: types.WebSocketPayload{
+					Type: "UPDATE",
+					Data: map[string]interface{}{"asks": []map[string]float64{{"price": float64(i), "volume": 1}}},
+				},
+			}
+
+			conn.WriteJSON(msg)
+		}
+
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	c, err := DialClient(context.Background(), getMockWallet(), server.URL, nil)
+	if err != nil {
+		t.Fatalf("Could not dial test server: %v", err)
+	}
+
+	c.Start()
+	for i := 0; i < 50; i++ {
+		_, err := c.ExpectOrderBookUpdate(time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c.Stop()
+	assert.Equal(t, 50, len(c.ResponseLogs()))
+	assert.Equal(t, 50, len(c.OrderBookLogs()))
+}
@@ -0,0 +1,124 @@
+package mocks
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// Directions of the messages recorded in a fixture
+const (
+	FixtureOutgoing = "OUT"
+	FixtureIncoming = "IN"
+)
+
+// Fixture is a websocket conversation between a client and the server. Fixtures are
+// recorded with Client.Record and saved as JSON files so that they can be replayed
+// against the client handlers or compared with golden files.
+type Fixture struct {
+	Messages []*FixtureMessage `json:"messages"`
+}
+
+// FixtureMessage is a message of a recorded conversation. Direction is OUT for the
+// messages sent by the client and IN for the messages received from the server.
+type FixtureMessage struct {
+	Direction string                  `json:"direction"`
+	Message   *types.WebSocketMessage `json:"message"`
+}
+
+// LoadFixture reads a fixture from a JSON file
+func LoadFixture(path string) (*Fixture, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Fixture{}
+	err = json.Unmarshal(bytes, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Save writes the fixture to a JSON file
+func (f *Fixture) Save(path string) error {
+	bytes, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, bytes, 0644)
+}
+
+// Incoming returns the messages received from the server
+func (f *Fixture) Incoming() []*types.WebSocketMessage {
+	msgs := []*types.WebSocketMessage{}
+	for _, m := range f.Messages {
+		if m.Direction == FixtureIncoming {
+			msgs = append(msgs, m.Message)
+		}
+	}
+
+	return msgs
+}
+
+// Record starts recording the messages sent and received by the client. Messages
+// exchanged before Record is called are not part of the fixture.
+func (c *Client) Record() {
+	c.logsMutex.Lock()
+	defer c.logsMutex.Unlock()
+
+	c.fixture = &Fixture{Messages: []*FixtureMessage{}}
+}
+
+// Fixture returns a copy of the conversation recorded since Record was called, or
+// nil if the client is not recording
+func (c *Client) Fixture() *Fixture {
+	c.logsMutex.Lock()
+	defer c.logsMutex.Unlock()
+
+	if c.fixture == nil {
+		return nil
+	}
+
+	return &Fixture{Messages: append([]*FixtureMessage{}, c.fixture.Messages...)}
+}
+
+// recordFixtureMessage appends a message to the fixture if the client is recording.
+// It must be called with logsMutex held.
+func (c *Client) recordFixtureMessage(direction string, msg *types.WebSocketMessage) {
+	if c.fixture == nil {
+		return
+	}
+
+	c.fixture.Messages = append(c.fixture.Messages, &FixtureMessage{Direction: direction, Message: msg})
+}
+
+// NewReplayClient returns a client that is not connected to a server. Recorded
+// conversations are fed to its handlers with Replay.
+func NewReplayClient(w *types.Wallet) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{
+		Wallet:       w,
+		Requests:     make(chan *types.WebSocketMessage),
+		Responses:    make(chan *types.WebSocketMessage),
+		Logs:         make(chan *ClientLogMessage),
+		requestLogs:  make([]*types.WebSocketMessage, 0),
+		responseLogs: make([]*types.WebSocketMessage, 0),
+		updated:      make(chan struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Replay feeds the incoming messages of a fixture to the client handlers, in the
+// order in which they were recorded. Outgoing messages are not sent.
+func (c *Client) Replay(f *Fixture) {
+	for _, msg := range f.Incoming() {
+		c.handleResponse(msg)
+	}
+}
@@ -0,0 +1,78 @@
+package mocks
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplayFixture(t *testing.T) {
+	update := types.WebSocketMessage{
+		Channel: "order_book",
+		Payload: types.WebSocketPayload{
+			Type: "UPDATE",
+			Data: map[string]interface{}{"asks": []map[string]float64{{"price": 1, "volume": 2}}},
+		},
+	}
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		conn.WriteJSON(update)
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	c, err := DialClient(context.Background(), getMockWallet(), server.URL, nil)
+	if err != nil {
+		t.Fatalf("Could not dial test server: %v", err)
+	}
+
+	c.Record()
+	c.Start()
+	defer c.Stop()
+
+	_, err = c.ExpectOrderBookUpdate(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := c.Fixture()
+	assert.Equal(t, 1, len(f.Messages))
+	assert.Equal(t, FixtureIncoming, f.Messages[0].Direction)
+
+	dir, _ := ioutil.TempDir("", "fixtures")
+	path := filepath.Join(dir, "orderbook.json")
+	err = f.Save(path)
+	if err != nil {
+		t.Fatalf("Could not save fixture: %v", err)
+	}
+
+	loaded, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("Could not load fixture: %v", err)
+	}
+
+	replay := NewReplayClient(getMockWallet())
+	defer replay.Stop()
+
+	replay.Replay(loaded)
+	ob, err := replay.ExpectOrderBookUpdate(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, float64(2), ob.Asks[0]["volume"])
+}
@@ -0,0 +1,106 @@
+package operator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// Alerter sends the balance alerts of the operator wallet and the alerts of the ethereum
+// node block lag
+type Alerter interface {
+	Alert(a *types.BalanceAlert) error
+	AlertChain(a *types.ChainAlert) error
+}
+
+// WebhookAlerter posts the json encoded alerts to a webhook
+type WebhookAlerter struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookAlerter returns an alerter posting to the given url
+func NewWebhookAlerter(url string) *WebhookAlerter {
+	return &WebhookAlerter{url, &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *WebhookAlerter) Alert(a *types.BalanceAlert) error {
+	return w.post("OPERATOR_BALANCE", a.Message(), a)
+}
+
+func (w *WebhookAlerter) AlertChain(a *types.ChainAlert) error {
+	return w.post("CHAIN_LAG", a.Message(), a)
+}
+
+func (w *WebhookAlerter) post(alertType string, message string, alert interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"type":    alertType,
+		"message": message,
+		"alert":   alert,
+	})
+	if err != nil {
+		return err
+	}
+
+	res, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("Webhook returned status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// EmailAlerter sends the alerts by email
+type EmailAlerter struct {
+	config app.AlertEmailConfig
+}
+
+// NewEmailAlerter returns an alerter sending emails with the given smtp configuration
+func NewEmailAlerter(config app.AlertEmailConfig) *EmailAlerter {
+	return &EmailAlerter{config}
+}
+
+func (e *EmailAlerter) Alert(a *types.BalanceAlert) error {
+	return e.send("Operator balance "+a.Level, a.Message())
+}
+
+func (e *EmailAlerter) AlertChain(a *types.ChainAlert) error {
+	subject := "Ethereum node back in sync"
+	if a.Stale {
+		subject = "Ethereum node stale"
+	}
+
+	return e.send(subject, a.Message())
+}
+
+func (e *EmailAlerter) send(subject string, message string) error {
+	if len(e.config.To) == 0 {
+		return nil
+	}
+
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		host := strings.Split(e.config.SMTPServer, ":")[0]
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, host)
+	}
+
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(e.config.To, ", "))
+	fmt.Fprintf(msg, "Subject: [AMP] %s\r\n\r\n", subject)
+	fmt.Fprintf(msg, "%s\r\n", message)
+
+	return smtp.SendMail(e.config.SMTPServer, auth, e.config.From, e.config.To, msg.Bytes())
+}
@@ -0,0 +1,204 @@
+package operator
+
+import (
+	"errors"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// BalanceStatus is the result of the last check of the operator wallet balance
+type BalanceStatus struct {
+	Address   common.Address
+	Balance   *big.Int
+	Level     string
+	CheckedAt time.Time
+}
+
+// BalanceMonitor checks the ether balance of the operator wallet. Alerts are sent when
+// the balance crosses the warning or critical threshold and onLevelChange is called so
+// that settlement can be paused before transactions start failing for lack of gas.
+type BalanceMonitor struct {
+	address       common.Address
+	balanceAt     func(common.Address) (*big.Int, error)
+	warning       *big.Int
+	critical      *big.Int
+	alerters      []Alerter
+	onLevelChange func(previous, level string)
+	status        *BalanceStatus
+	mutex         sync.Mutex
+}
+
+// currentMonitor is the balance monitor running in this process, if any
+var currentMonitor *BalanceMonitor
+var currentMonitorMutex sync.Mutex
+
+// NewBalanceMonitor returns a balance monitor of the given address. The thresholds are
+// in wei and the critical threshold can not be above the warning threshold.
+func NewBalanceMonitor(
+	address common.Address,
+	balanceAt func(common.Address) (*big.Int, error),
+	warning *big.Int,
+	critical *big.Int,
+	alerters ...Alerter,
+) (*BalanceMonitor, error) {
+	if warning.Cmp(critical) < 0 {
+		return nil, errors.New("Critical balance threshold can not be above the warning threshold")
+	}
+
+	return &BalanceMonitor{
+		address:   address,
+		balanceAt: balanceAt,
+		warning:   warning,
+		critical:  critical,
+		alerters:  alerters,
+		status:    &BalanceStatus{Address: address, Level: types.BalanceOK},
+	}, nil
+}
+
+// OnLevelChange sets the function called when the balance enters a new level
+func (m *BalanceMonitor) OnLevelChange(fn func(previous, level string)) {
+	m.onLevelChange = fn
+}
+
+// Start checks the balance every interval. The monitor becomes the one reported by
+// GetBalanceStatus.
+func (m *BalanceMonitor) Start(interval time.Duration) {
+	currentMonitorMutex.Lock()
+	currentMonitor = m
+	currentMonitorMutex.Unlock()
+
+	go func() {
+		for {
+			_, err := m.Check()
+			if err != nil {
+				log.Printf("Could not check operator balance: %v", err)
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// Check fetches the balance and sends alerts if the balance level changed
+func (m *BalanceMonitor) Check() (*BalanceStatus, error) {
+	balance, err := m.balanceAt(m.address)
+	if err != nil {
+		return nil, err
+	}
+
+	level := m.level(balance)
+
+	m.mutex.Lock()
+	previous := m.status.Level
+	m.status = &BalanceStatus{
+		Address:   m.address,
+		Balance:   balance,
+		Level:     level,
+		CheckedAt: time.Now(),
+	}
+
+	status := m.status
+	m.mutex.Unlock()
+
+	if level == previous {
+		return status, nil
+	}
+
+	if m.onLevelChange != nil {
+		m.onLevelChange(previous, level)
+	}
+
+	alert := &types.BalanceAlert{
+		Address:       m.address,
+		Balance:       balance,
+		Level:         level,
+		PreviousLevel: previous,
+		CreatedAt:     status.CheckedAt,
+	}
+
+	switch level {
+	case types.BalanceWarning:
+		alert.Threshold = m.warning
+	case types.BalanceCritical:
+		alert.Threshold = m.critical
+	}
+
+	log.Print(alert.Message())
+	for _, a := range m.alerters {
+		err := a.Alert(alert)
+		if err != nil {
+			log.Printf("Could not send balance alert: %v", err)
+		}
+	}
+
+	return status, nil
+}
+
+// Status returns the result of the last balance check
+func (m *BalanceMonitor) Status() *BalanceStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.status
+}
+
+func (m *BalanceMonitor) level(balance *big.Int) string {
+	switch {
+	case balance.Cmp(m.critical) < 0:
+		return types.BalanceCritical
+	case balance.Cmp(m.warning) < 0:
+		return types.BalanceWarning
+	}
+
+	return types.BalanceOK
+}
+
+// GetBalanceStatus returns the last balance status of the operator wallet, or nil if the
+// balance is not monitored by this process
+func GetBalanceStatus() *BalanceStatus {
+	currentMonitorMutex.Lock()
+	defer currentMonitorMutex.Unlock()
+
+	if currentMonitor == nil {
+		return nil
+	}
+
+	return currentMonitor.Status()
+}
+
+// etherToWei converts an amount of ether from the configuration to wei
+func etherToWei(amount float64) *big.Int {
+	wei, _ := new(big.Float).Mul(big.NewFloat(amount), big.NewFloat(1e18)).Int(nil)
+	return wei
+}
+
+// newConfiguredBalanceMonitor returns a monitor of the operator wallet configured with
+// app.Config.OperatorBalance. The alerts are sent to the operator messages, to the
+// webhook and by email when configured. The chain alerts are sent to the same alerters.
+func newConfiguredBalanceMonitor(op *Operator, address common.Address) (*BalanceMonitor, error) {
+	config := app.Config.OperatorBalance
+	alerters := []Alerter{op}
+
+	if config.WebhookURL != "" {
+		alerters = append(alerters, NewWebhookAlerter(config.WebhookURL))
+	}
+
+	if config.Email.SMTPServer != "" {
+		alerters = append(alerters, NewEmailAlerter(config.Email))
+	}
+
+	op.alerters = alerters
+	return NewBalanceMonitor(
+		address,
+		op.EthereumService.GetPendingBalanceAt,
+		etherToWei(config.WarningThreshold),
+		etherToWei(config.CriticalThreshold),
+		alerters...,
+	)
+}
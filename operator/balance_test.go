@@ -0,0 +1,94 @@
+package operator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingAlerter struct {
+	alerts []*types.BalanceAlert
+}
+
+func (r *recordingAlerter) Alert(a *types.BalanceAlert) error {
+	r.alerts = append(r.alerts, a)
+	return nil
+}
+
+func (r *recordingAlerter) AlertChain(a *types.ChainAlert) error {
+	return nil
+}
+
+func TestBalanceMonitor(t *testing.T) {
+	address := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	balance := etherToWei(2)
+	balanceAt := func(common.Address) (*big.Int, error) { return balance, nil }
+
+	alerter := &recordingAlerter{}
+	m, err := NewBalanceMonitor(address, balanceAt, etherToWei(1), etherToWei(0.2), alerter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	levels := []string{}
+	m.OnLevelChange(func(previous, level string) { levels = append(levels, level) })
+
+	status, err := m.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.BalanceOK, status.Level)
+	assert.Equal(t, 0, len(alerter.alerts))
+
+	balance = etherToWei(0.5)
+	m.Check()
+	// the alert is only sent when the level changes
+	m.Check()
+	assert.Equal(t, 1, len(alerter.alerts))
+	assert.Equal(t, types.BalanceWarning, alerter.alerts[0].Level)
+	assert.Equal(t, etherToWei(1), alerter.alerts[0].Threshold)
+
+	balance = etherToWei(0.1)
+	m.Check()
+	assert.Equal(t, types.BalanceCritical, m.Status().Level)
+
+	balance = etherToWei(5)
+	m.Check()
+	assert.Equal(t, 3, len(alerter.alerts))
+	assert.Equal(t, types.BalanceCritical, alerter.alerts[2].PreviousLevel)
+	assert.Nil(t, alerter.alerts[2].Threshold)
+
+	assert.Equal(t, []string{types.BalanceWarning, types.BalanceCritical, types.BalanceOK}, levels)
+}
+
+func TestBalanceMonitorThresholds(t *testing.T) {
+	_, err := NewBalanceMonitor(common.Address{}, nil, etherToWei(0.1), etherToWei(1))
+	assert.Error(t, err)
+}
+
+func TestHandleBalanceLevel(t *testing.T) {
+	op := &Operator{}
+
+	op.handleBalanceLevel(types.BalanceWarning, types.BalanceCritical)
+	assert.True(t, op.SettlementPaused())
+
+	// a warning does not pause settlement
+	op = &Operator{}
+	op.handleBalanceLevel(types.BalanceOK, types.BalanceWarning)
+	assert.False(t, op.SettlementPaused())
+}
+
+func TestHandleChainAlert(t *testing.T) {
+	op := &Operator{}
+
+	op.handleChainAlert(&types.ChainAlert{Stale: true})
+	assert.True(t, op.SettlementPaused())
+
+	// settlement is resumed once every pause reason is lifted
+	op.handleBalanceLevel(types.BalanceWarning, types.BalanceCritical)
+	assert.Equal(t, map[string]bool{pauseChain: true, pauseBalance: true}, op.pauses)
+}
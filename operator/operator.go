@@ -4,14 +4,22 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"math/big"
+	"sync"
+	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/contracts"
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/ethereum"
 	"github.com/Proofsuite/amp-matching-engine/rabbitmq"
 	"github.com/Proofsuite/amp-matching-engine/services"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/ethereum/go-ethereum/common"
 	eth "github.com/ethereum/go-ethereum/core/types"
 	"github.com/streadway/amqp"
+	mgo "gopkg.in/mgo.v2"
 )
 
 // Operator manages the transaction queue that will eventually be
@@ -19,24 +27,62 @@ import (
 // account that initially deployed the exchange contract or an address with operator rights
 // on the contract
 type Operator struct {
-	WalletService   *services.WalletService
-	TxService       *services.TxService
-	TradeService    *services.TradeService
-	EthereumService *services.EthereumService
-	Exchange        *contracts.Exchange
+	WalletService     *services.WalletService
+	TxService         *services.TxService
+	TradeService      *services.TradeService
+	OrderService      *services.OrderService
+	EthereumService   *services.EthereumService
+	SettlementService *services.SettlementService
+	Exchange          *contracts.Exchange
+
+	// Wallet is the admin wallet sending the settlement transactions. It must be an
+	// operator of the exchange contract.
+	Wallet *types.OperatorWallet
+
+	// pauses are the reasons settlement is paused for, eg. the balance of the operator
+	// wallet is too low to send settlement transactions or the ethereum node is stale. The
+	// trades are kept in the settlement queue while settlement is paused.
+	pauses      map[string]bool
+	pausedMutex sync.RWMutex
+	alerters    []Alerter
+
+	// txMonitor follows the settlement transactions until their block has enough confirmations
+	txMonitor *ethereum.TxMonitor
+
+	// queued wakes the settlement goroutine up when trades are queued
+	queued chan struct{}
 }
 
+// Reasons settlement is paused for
+const (
+	pauseBalance = "balance"
+	pauseChain   = "chain"
+)
+
 type OperatorMessage struct {
 	MessageType string
 	Order       *types.Order
 	Trade       *types.Trade
-	ErrID       int
+	ErrID       aerrors.ExchangeErrorID
+	Reason      string
+	Alert       *types.BalanceAlert
+	ChainAlert  *types.ChainAlert
 }
 
-type PendingTradeMessage struct {
-	Order *types.Order
-	Trade *types.Trade
-}
+// ErrSimulationFailed is returned when the settlement transaction of a trade is not
+// broadcasted because it would revert
+var ErrSimulationFailed = errors.New("Settlement transaction simulation failed")
+
+// ErrTradeBusted is returned when a trade was busted by the administrators before being settled
+var ErrTradeBusted = errors.New("Trade busted before settlement")
+
+// ErrQueueFull is returned when a trade is queued while maxQueuedTrades trades are
+// waiting to be settled
+var ErrQueueFull = errors.New("Transaction queue is full")
+
+// maxQueuedTrades is the number of trades awaiting broadcast above which the trades are
+// not queued anymore
+const maxQueuedTrades = 1000
 
 var channels = make(map[string]*amqp.Channel)
 var queues = make(map[string]*amqp.Queue)
@@ -50,15 +96,20 @@ func InitOperator(
 	walletService *services.WalletService,
 	txService *services.TxService,
 	tradeService *services.TradeService,
+	orderService *services.OrderService,
 	ethereumService *services.EthereumService,
+	settlementService *services.SettlementService,
 	exchange *contracts.Exchange,
 ) (*Operator, error) {
 	op := &Operator{
-		WalletService:   walletService,
-		TxService:       txService,
-		TradeService:    tradeService,
-		EthereumService: ethereumService,
-		Exchange:        exchange,
+		WalletService:     walletService,
+		TxService:         txService,
+		TradeService:      tradeService,
+		OrderService:      orderService,
+		EthereumService:   ethereumService,
+		SettlementService: settlementService,
+		Exchange:          exchange,
+		queued:            make(chan struct{}, 1),
 	}
 
 	tradeEvents, err := exchange.ListenToTrades()
@@ -76,6 +127,50 @@ func InitOperator(
 		return nil, err
 	}
 
+	wallet, err := op.WalletService.GetDefaultAdminWallet()
+	if err != nil {
+		return nil, err
+	}
+
+	op.Wallet = wallet
+
+	// the nonces of the settlement transactions are assigned and repaired locally
+	// and the stuck transactions are re-priced
+	pendingTxDao := daos.NewPendingTxDao()
+	nonces := services.NewNonceManager(ethereumService.EthereumClient, op.signNoop, pendingTxDao)
+	nonces.SetGasBumpPolicy(services.GasBumpPolicy{
+		Timeout:     time.Duration(app.Config.Settlement.GasBumpTimeout) * time.Second,
+		Percent:     app.Config.Settlement.GasBumpPercent,
+		MaxGasPrice: new(big.Int).Mul(big.NewInt(app.Config.Settlement.MaxGasPrice), big.NewInt(1e9)),
+	})
+	nonces.OnReplaced(op.handleTxReplaced)
+	txService.SetNonceManager(nonces)
+	nonces.Start(time.Duration(app.Config.NonceCheckInterval) * time.Second)
+
+	// the trades are settled once their transaction has enough confirmations, the
+	// transactions sent before a restart are watched again
+	op.txMonitor = ethereum.NewClientTxMonitor(ethereum.GetClient(), uint64(app.Config.Settlement.Confirmations))
+	op.watchPendingTrades()
+	op.txMonitor.Start(time.Duration(app.Config.Settlement.CheckInterval) * time.Second)
+
+	monitor, err := newConfiguredBalanceMonitor(op, wallet.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	monitor.OnLevelChange(op.handleBalanceLevel)
+	monitor.Start(time.Duration(app.Config.OperatorBalance.CheckInterval) * time.Second)
+
+	if chain := ethereum.GetChainMonitor(); chain != nil {
+		chain.OnStaleChange(op.handleChainAlert)
+	}
+
+	// the trades queued before a restart are settled first, except those whose settlement
+	// transaction was sent before the restart
+	op.recoverQueuedTrades(pendingTxDao)
+	go op.runSettlement()
+	op.signalQueue()
+
 	// Bug: In certain cases, the trade channel seems to be receiving additional unexpected trades.
 	// In the case TestSocketExecuteOrder (in file socket_test.go) is run on its own, everything is working correctly.
 	// However, in the case TestSocketExecuteOrder is run among other tests, some tradeLogs do not correspond to an
@@ -86,7 +181,7 @@ func InitOperator(
 			select {
 			case event := <-errorEvents:
 				tradeHash := event.TradeHash
-				errID := int(event.ErrorId)
+				errID := aerrors.ExchangeErrorID(event.ErrorId)
 				//TODO add this function in the trade service
 				tr, err := op.TradeService.GetByHash(tradeHash)
 				if err != nil {
@@ -94,6 +189,17 @@ func InitOperator(
 					return
 				}
 
+				err = op.TradeService.RecordFailure(tr, errID, aerrors.GetExchangeError(errID).Cause)
+				if err != nil {
+					log.Printf("Could not update trade status: %v", err)
+				}
+
+				// settlement errors emitted by the exchange contract are terminal
+				err = op.OrderService.HandleSettlementFailure(tr)
+				if err != nil {
+					log.Printf("Could not revert failed trade: %v", err)
+				}
+
 				err = op.PublishTxErrorMessage(tr, errID)
 				if err != nil {
 					log.Printf("Could not publish tx error message")
@@ -112,40 +218,23 @@ func InitOperator(
 					return
 				}
 
-				// only execute the next transaction in the queue when this transaction is mined
-				go func() {
-					_, err := op.EthereumService.WaitMined(tr.Tx)
-					if err != nil {
-						log.Printf("Could not execute trade: %v\n", err)
-					}
-
-					err = op.PublishTradeSuccessMessage(tr)
-					if err != nil {
-						log.Printf("Could not publish order success message")
-					}
-
-					ch := getChannel("PENDING_TRADES")
-					q := getQueue(ch, "PENDING_TRADES")
-
-					length := q.Messages
-					if length > 0 {
-						msg, _, _ := ch.Get(
-							q.Name,
-							true,
-						)
-
-						var pendingTrade PendingTradeMessage
-						err = json.Unmarshal(msg.Body, &pendingTrade)
-						if err != nil {
-							log.Printf("Could not executed trade: %v\n", err)
-						}
-
-						_, err = op.ExecuteTrade(pendingTrade.Order, pendingTrade.Trade)
-						if err != nil {
-							log.Printf("Could not execute trade: %v", err)
-						}
-					}
-				}()
+				// the settlement transactions of the trades matched by the engine are
+				// followed by the tx monitor until they are confirmed
+				if tr != nil {
+					continue
+				}
+
+				// the trades that were not matched by the engine are fills of shared orders
+				// made on-chain by third parties
+				err = op.OrderService.HandleOnChainFill(
+					event.OrderHash,
+					event.TokenBuy,
+					event.FilledAmountBuy,
+					event.FilledAmountSell,
+				)
+				if err != nil {
+					log.Printf("Could not reconcile on-chain fill: %v", err)
+				}
 			}
 		}
 	}()
@@ -153,6 +242,263 @@ func InitOperator(
 	return op, nil
 }
 
+// PauseSettlement stops sending settlement transactions for a reason. The trades queued
+// in the meantime are kept in the settlement queue.
+func (op *Operator) PauseSettlement(reason string) {
+	op.pausedMutex.Lock()
+	defer op.pausedMutex.Unlock()
+
+	if op.pauses == nil {
+		op.pauses = make(map[string]bool)
+	}
+
+	op.pauses[reason] = true
+}
+
+// ResumeSettlement lifts a reason settlement was paused for. The settlement transactions
+// of all the queued trades are sent once no reason is left.
+func (op *Operator) ResumeSettlement(reason string) {
+	op.pausedMutex.Lock()
+	delete(op.pauses, reason)
+	paused := len(op.pauses) > 0
+	op.pausedMutex.Unlock()
+
+	if !paused {
+		op.signalQueue()
+	}
+}
+
+// SettlementPaused returns true if settlement is paused
+func (op *Operator) SettlementPaused() bool {
+	op.pausedMutex.RLock()
+	defer op.pausedMutex.RUnlock()
+
+	return len(op.pauses) > 0
+}
+
+// handleBalanceLevel pauses settlement when the balance of the operator wallet becomes
+// critical and resumes it once the wallet has been funded
+func (op *Operator) handleBalanceLevel(previous, level string) {
+	if level == types.BalanceCritical {
+		op.PauseSettlement(pauseBalance)
+		return
+	}
+
+	if previous == types.BalanceCritical {
+		op.ResumeSettlement(pauseBalance)
+	}
+}
+
+// handleChainAlert pauses settlement while the ethereum node is stale, so that trades are
+// not settled against an outdated view of the chain, and sends the alert
+func (op *Operator) handleChainAlert(a *types.ChainAlert) {
+	if a.Stale {
+		op.PauseSettlement(pauseChain)
+	} else {
+		op.ResumeSettlement(pauseChain)
+	}
+
+	for _, alerter := range op.alerters {
+		err := alerter.AlertChain(a)
+		if err != nil {
+			log.Printf("Could not send chain alert: %v", err)
+		}
+	}
+}
+
+// Alert publishes a balance alert to the operator messages
+func (op *Operator) Alert(a *types.BalanceAlert) error {
+	msg := &OperatorMessage{
+		MessageType: "BALANCE_ALERT_MESSAGE",
+		Alert:       a,
+		Reason:      a.Message(),
+	}
+
+	return op.Publish(msg)
+}
+
+// AlertChain publishes a chain alert to the operator messages
+func (op *Operator) AlertChain(a *types.ChainAlert) error {
+	msg := &OperatorMessage{
+		MessageType: "CHAIN_ALERT_MESSAGE",
+		ChainAlert:  a,
+		Reason:      a.Message(),
+	}
+
+	return op.Publish(msg)
+}
+
+// signNoop signs the no-op transactions filling the nonce gaps of the operator wallet
+func (op *Operator) signNoop(from common.Address, tx *eth.Transaction) (*eth.Transaction, error) {
+	opts, err := op.Exchange.GetTxSendOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.Signer(eth.HomesteadSigner{}, from, tx)
+}
+
+// watchPendingTrades watches the settlement transactions of the trades that are not
+// confirmed yet
+func (op *Operator) watchPendingTrades() {
+	trades, err := op.TradeService.GetByStatus("PENDING_CONFIRMATION", "REORGED")
+	if err != nil {
+		log.Printf("Could not get the pending settlements: %v", err)
+		return
+	}
+
+	for _, tr := range trades {
+		if tr.Tx != nil {
+			op.watchTrade(tr)
+		}
+	}
+}
+
+// watchTrade follows the settlement transaction of a trade
+func (op *Operator) watchTrade(tr *types.Trade) {
+	hash := tr.Hash
+	op.txMonitor.Watch(tr.Tx.Hash(), func(e *ethereum.TxEvent) {
+		op.handleTxEvent(hash, e)
+	})
+}
+
+// handleTxReplaced follows the transaction replacing the settlement transaction of a
+// trade with a higher gas price
+func (op *Operator) handleTxReplaced(old, new *eth.Transaction) {
+	tr, err := op.TradeService.GetByTxHash(old.Hash())
+	if err != nil || tr == nil {
+		// the replaced transaction is not a settlement transaction (eg. a no-op)
+		return
+	}
+
+	err = op.TradeService.ReplaceTradeTx(tr, new)
+	if err != nil {
+		log.Printf("Could not update the transaction of trade %s: %v", tr.Hash.Hex(), err)
+	}
+
+	op.txMonitor.Replace(old.Hash(), new.Hash())
+}
+
+// handleTxEvent updates the settlement status of a trade with an event of its settlement
+// transaction and notifies the maker and the taker. The statuses are updated
+// conditionally: a trade failed by an error event of the exchange contract is not
+// settled again when its transaction is confirmed.
+func (op *Operator) handleTxEvent(hash common.Hash, e *ethereum.TxEvent) {
+	tr, err := op.TradeService.GetByHash(hash)
+	if err != nil || tr == nil {
+		log.Printf("Could not retrieve trade %s: %v", hash.Hex(), err)
+		return
+	}
+
+	switch e.Event {
+	case ethereum.TxMined:
+		err = op.TradeService.UpdateTradeStatusIf(tr, "REORGED", "PENDING_CONFIRMATION")
+		if err != nil && err != mgo.ErrNotFound {
+			log.Printf("Could not update trade status: %v", err)
+		}
+
+	case ethereum.TxReorged:
+		err = op.TradeService.UpdateTradeStatusIf(tr, "PENDING_CONFIRMATION", "REORGED")
+		if err != nil {
+			if err != mgo.ErrNotFound {
+				log.Printf("Could not update trade status: %v", err)
+			}
+
+			return
+		}
+
+		log.Printf("Settlement transaction of trade %s removed by a chain reorganization", hash.Hex())
+		err = op.OrderService.HandleSettlementReorg(tr)
+		if err != nil {
+			log.Printf("Could not notify reorged trade: %v", err)
+		}
+
+	case ethereum.TxConfirmed:
+		cost := op.recordCost(tr, e.Receipt)
+
+		err = op.TradeService.UpdateTradeStatusIf(tr, "PENDING_CONFIRMATION", "SUCCESS")
+		if err != nil {
+			if err != mgo.ErrNotFound {
+				log.Printf("Could not update trade status: %v", err)
+			}
+
+			return
+		}
+
+		err = op.OrderService.HandleSettlementSuccess(tr)
+		if err != nil {
+			log.Printf("Could not notify settled trade: %v", err)
+		}
+
+		op.handleSettled(tr, cost, e.Receipt)
+
+		err = op.PublishTradeSuccessMessage(tr)
+		if err != nil {
+			log.Printf("Could not publish order success message")
+		}
+
+	case ethereum.TxReverted:
+		// reverted transactions consume gas as well
+		op.recordCost(tr, e.Receipt)
+
+		reason := "Transaction reverted"
+		if tr.Tx != nil {
+			r, err := op.EthereumService.GetRevertReason(tr.Tx)
+			if err == nil && r != "" {
+				reason = r
+			}
+		}
+
+		tr.ErrorCode = aerrors.UnknownExchangeError
+		tr.FailureReason = reason
+		err = op.TradeService.UpdateTradeStatusIf(tr, "PENDING_CONFIRMATION", "ERROR")
+		if err != nil {
+			if err != mgo.ErrNotFound {
+				log.Printf("Could not update trade status: %v", err)
+			}
+
+			return
+		}
+
+		// a reverted transaction is terminal, the trade is reverted off-chain
+		err = op.OrderService.HandleSettlementFailure(tr)
+		if err != nil {
+			log.Printf("Could not revert failed trade: %v", err)
+		}
+	}
+}
+
+// recordCost records the gas used by the settlement transaction of a trade
+func (op *Operator) recordCost(tr *types.Trade, receipt *eth.Receipt) *types.SettlementCost {
+	if op.SettlementService == nil {
+		return nil
+	}
+
+	c, err := op.SettlementService.RecordCost(tr, receipt)
+	if err != nil {
+		log.Printf("Could not record settlement cost: %v", err)
+	}
+
+	return c
+}
+
+// handleSettled posts a settled trade to the settlement webhook
+func (op *Operator) handleSettled(tr *types.Trade, c *types.SettlementCost, receipt *eth.Receipt) {
+	if op.SettlementService == nil {
+		return
+	}
+
+	var blockNumber uint64
+	if receipt != nil && receipt.BlockNumber != nil {
+		blockNumber = receipt.BlockNumber.Uint64()
+	}
+
+	err := op.SettlementService.HandleSettled(tr, c, blockNumber)
+	if err != nil {
+		log.Printf("Could not post settled trade: %v", err)
+	}
+}
+
 func (op *Operator) SubscribeOperatorMessages(fn func(*OperatorMessage) error) error {
 	ch := getChannel("OPERATOR_SUB")
 	q := getQueue(ch, "TX_MESSAGES")
@@ -191,11 +537,12 @@ func (op *Operator) SubscribeOperatorMessages(fn func(*OperatorMessage) error) e
 	return nil
 }
 
-func (op *Operator) PublishTxErrorMessage(tr *types.Trade, errID int) error {
+func (op *Operator) PublishTxErrorMessage(tr *types.Trade, errID aerrors.ExchangeErrorID) error {
 	msg := &OperatorMessage{
 		MessageType: "TX_ERROR_MESSAGE",
 		Trade:       tr,
 		ErrID:       errID,
+		Reason:      tr.FailureReason,
 	}
 
 	err := op.Publish(msg)
@@ -276,49 +623,171 @@ func (op *Operator) Publish(msg *OperatorMessage) error {
 	return nil
 }
 
-// AddTradeToExecutionList adds a new trade to the execution list. If the execution list is empty (= contains 1 element
-// after adding the transaction hash), the given order/trade pair gets executed. If the tranasction queue is full,
-// we return an error. Ultimately we want to account send the transaction to another queue that is handled by another ethereum account
+// QueueTrade queues a trade for settlement. The queue is stored in the database: the
+// queued trades are the trades awaiting broadcast, settled one at a time and oldest first
+// by the settlement goroutine of the operator. The trade is not queued if the queue
+// already holds maxQueuedTrades trades, it stays in the settlement backlog.
 func (op *Operator) QueueTrade(o *types.Order, t *types.Trade) error {
-	// err := t.Validate()
-	// if err != nil {
-	// 	return err
-	// }
+	if op.isBusted(t) {
+		return ErrTradeBusted
+	}
 
-	ch := getChannel("tradeTxs")
-	q := getQueue(ch, "tradeTxs")
+	if t.Status != "AWAITING_BROADCAST" {
+		queued, err := op.TradeService.CountByStatus("AWAITING_BROADCAST")
+		if err != nil {
+			return err
+		}
 
-	bytes, err := json.Marshal(t)
+		if queued >= maxQueuedTrades {
+			return ErrQueueFull
+		}
+	}
+
+	err := op.TradeService.UpdateTradeStatus(t, "AWAITING_BROADCAST")
 	if err != nil {
-		return errors.New("Failed to marshal trade object")
+		return err
 	}
 
-	err = ch.Publish(
-		"",
-		q.Name,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "text/json",
-			Body:        bytes,
-		})
+	op.signalQueue()
+	return nil
+}
 
-	length := q.Messages
-	if length == 1 {
-		op.ExecuteTrade(o, t)
+// recoverQueuedTrades looks for the settlement transactions of the queued trades among the
+// pending transactions of the operator wallet, in case the operator stopped between
+// sending the transaction of a trade and recording it. The trades whose transaction is
+// pending are followed again instead of being settled twice, and the trades already
+// settled on-chain are marked as settled.
+func (op *Operator) recoverQueuedTrades(pendingTxDao *daos.PendingTxDao) {
+	trades, err := op.TradeService.GetByStatus("AWAITING_BROADCAST")
+	if err != nil || len(trades) == 0 {
+		if err != nil {
+			log.Printf("Could not get the queued trades: %v", err)
+		}
+
+		return
 	}
 
-	if length == 10 {
-		return errors.New("Transaction queue is full")
+	pending, err := pendingTxDao.GetByAccount(op.Wallet.Address)
+	if err != nil {
+		log.Printf("Could not get the pending transactions: %v", err)
+		return
 	}
 
-	return nil
+	sent := make(map[string]*eth.Transaction)
+	for _, p := range pending {
+		if p.Tx.To() != nil && *p.Tx.To() == op.Exchange.Address {
+			sent[common.Bytes2Hex(p.Tx.Data())] = p.Tx
+		}
+	}
+
+	for _, tr := range trades {
+		o, err := op.OrderService.GetByHash(tr.OrderHash)
+		if err != nil || o == nil {
+			log.Printf("Could not get the maker order of trade %s: %v", tr.Hash.Hex(), err)
+			continue
+		}
+
+		msg, err := op.Exchange.TradeCallMsg(o, tr)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		if tx := sent[common.Bytes2Hex(msg.Data)]; tx != nil {
+			log.Printf("Settlement transaction of trade %s sent before the restart: %s", tr.Hash.Hex(), tx.Hash().Hex())
+			err = op.TradeService.UpdateTradeTx(tr, tx)
+			if err != nil {
+				log.Print(err)
+				continue
+			}
+
+			op.watchTrade(tr)
+			continue
+		}
+
+		// the pending transaction of the trade was mined and removed
+		traded, err := op.Exchange.Traded(tr.Hash)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		if traded {
+			log.Printf("Trade %s settled before the restart", tr.Hash.Hex())
+			err = op.TradeService.UpdateTradeStatus(tr, "SUCCESS")
+			if err != nil {
+				log.Print(err)
+				continue
+			}
+
+			err = op.OrderService.HandleSettlementSuccess(tr)
+			if err != nil {
+				log.Printf("Could not notify settled trade: %v", err)
+			}
+		}
+	}
+}
+
+// signalQueue wakes the settlement goroutine up
+func (op *Operator) signalQueue() {
+	select {
+	case op.queued <- struct{}{}:
+	default:
+	}
+}
+
+// runSettlement settles the queued trades each time trades are queued
+func (op *Operator) runSettlement() {
+	for range op.queued {
+		op.settleQueuedTrades()
+	}
+}
+
+// settleQueuedTrades sends the settlement transactions of the queued trades in turn. The
+// trades queued in the meantime are settled by the next run. It stops while settlement is
+// paused, the trades are then kept in the queue.
+func (op *Operator) settleQueuedTrades() {
+	trades, err := op.TradeService.GetByStatus("AWAITING_BROADCAST")
+	if err != nil {
+		log.Printf("Could not get the queued trades: %v", err)
+		return
+	}
+
+	for _, tr := range trades {
+		if op.SettlementPaused() {
+			return
+		}
+
+		o, err := op.OrderService.GetByHash(tr.OrderHash)
+		if err != nil || o == nil {
+			log.Printf("Could not get the maker order of trade %s: %v", tr.Hash.Hex(), err)
+			continue
+		}
+
+		_, err = op.ExecuteTrade(o, tr)
+		if err != nil && err != ErrSimulationFailed && err != ErrTradeBusted {
+			log.Printf("Could not execute trade %s: %v", tr.Hash.Hex(), err)
+		}
+	}
 }
 
 // Trade executes a settlements transaction. The order and trade payloads need to be signed respectively
 // by the Maker and the Taker of the trade. Only the operator account can send a Trade function to the
 // Exchange smart contract.
 func (op *Operator) ExecuteTrade(o *types.Order, tr *types.Trade) (*eth.Transaction, error) {
+	if op.isBusted(tr) {
+		return nil, ErrTradeBusted
+	}
+
+	// a transaction that would revert is not broadcasted, the trade fails without burning gas
+	reason, err := op.SimulateTrade(o, tr)
+	if err != nil {
+		log.Printf("Could not simulate trade: %v", err)
+	} else if reason != "" {
+		op.handleSimulationFailure(tr, reason)
+		return nil, ErrSimulationFailed
+	}
+
 	tx, err := op.Exchange.Trade(o, tr)
 	if err != nil {
 		return nil, err
@@ -329,6 +798,13 @@ func (op *Operator) ExecuteTrade(o *types.Order, tr *types.Trade) (*eth.Transact
 		return nil, errors.New("Could not update trade tx attribute")
 	}
 
+	op.watchTrade(tr)
+
+	err = op.OrderService.HandleTradeExecuted(tr)
+	if err != nil {
+		log.Printf("Could not notify executed trade: %v", err)
+	}
+
 	err = op.PublishTradeExecutedMessage(tr)
 	if err != nil {
 		return nil, errors.New("Could not publish trade executed message")
@@ -337,6 +813,70 @@ func (op *Operator) ExecuteTrade(o *types.Order, tr *types.Trade) (*eth.Transact
 	return tx, nil
 }
 
+// isBusted returns true if the trade was busted by the administrators since it was queued
+func (op *Operator) isBusted(tr *types.Trade) bool {
+	current, err := op.TradeService.GetByHash(tr.Hash)
+	if err != nil {
+		log.Print(err)
+		return false
+	}
+
+	return current != nil && current.Status == "BUSTED"
+}
+
+// SimulateTrade runs the settlement transaction of a trade against the latest state with
+// eth_call. It returns the reason why the transaction would fail (eg. a stale allowance or
+// an order already filled on-chain) or an empty string if it would succeed.
+func (op *Operator) SimulateTrade(o *types.Order, tr *types.Trade) (string, error) {
+	msg, err := op.Exchange.TradeCallMsg(o, tr)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := op.EthereumService.Simulate(msg)
+	if err != nil {
+		return "", err
+	}
+
+	if res.Reverted {
+		if res.Reason == "" {
+			return "Transaction reverted", nil
+		}
+
+		return res.Reason, nil
+	}
+
+	success, err := op.Exchange.TradeSucceeded(res.Output)
+	if err != nil {
+		return "", err
+	}
+
+	if !success {
+		return "Trade rejected by the exchange contract", nil
+	}
+
+	return "", nil
+}
+
+// handleSimulationFailure fails a trade whose settlement transaction would revert. The
+// traded amounts are given back to the maker and the taker as for a reverted transaction.
+func (op *Operator) handleSimulationFailure(tr *types.Trade, reason string) {
+	err := op.TradeService.RecordFailure(tr, aerrors.UnknownExchangeError, "Simulation failed: "+reason)
+	if err != nil {
+		log.Printf("Could not update trade status: %v", err)
+	}
+
+	err = op.OrderService.HandleSettlementFailure(tr)
+	if err != nil {
+		log.Printf("Could not revert failed trade: %v", err)
+	}
+
+	err = op.PublishTxErrorMessage(tr, aerrors.UnknownExchangeError)
+	if err != nil {
+		log.Printf("Could not publish tx error message")
+	}
+}
+
 // Validate checks that the operator configuration is sufficient.
 func (op *Operator) Validate() error {
 	// wallet, err := op.WalletService.GetDefaultAdminWallet()
@@ -0,0 +1,28 @@
+package rabbitmq
+
+import (
+	"fmt"
+
+	"github.com/Proofsuite/amp-matching-engine/utils"
+)
+
+// minVersion is the oldest supported rabbitmq version
+const minVersion = "3.7.7"
+
+// CheckServer verifies the version of the rabbitmq server of the connection
+func CheckServer() error {
+	if Conn == nil {
+		return fmt.Errorf("rabbitmq is not connected")
+	}
+
+	version, _ := Conn.Properties["version"].(string)
+	if version == "" {
+		return fmt.Errorf("could not read the rabbitmq version, the message broker must be rabbitmq %v or later", minVersion)
+	}
+
+	if !utils.VersionAtLeast(version, minVersion) {
+		return fmt.Errorf("rabbitmq %v is not supported, upgrade to rabbitmq %v or later", version, minVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/gomodule/redigo/redis"
+)
+
+// minVersion is the oldest supported redis version, the engine uses ZADD NX and the
+// orderbook memory report MEMORY USAGE
+const minVersion = "4.0"
+
+// commands are the commands used by the engine, they can be disabled or renamed in the
+// redis configuration
+var commands = []string{
+	"GET", "SET", "DEL", "EXISTS", "INCR", "INCRBY",
+	"ZADD", "ZREM", "ZCARD", "ZRANGE", "ZRANGEBYLEX", "ZREVRANGEBYLEX", "SORT",
+	"HGET", "HSET", "HDEL", "HGETALL", "HINCRBY",
+	"SCAN", "EVAL", "EVALSHA", "MEMORY",
+}
+
+// CheckServer verifies that the redis server supports the commands used by the engine
+func CheckServer(c redis.Conn) error {
+	info, err := redis.String(c.Do("INFO", "server"))
+	if err != nil {
+		return fmt.Errorf("could not read the redis version: %v", err)
+	}
+
+	version := infoField(info, "redis_version")
+	if !utils.VersionAtLeast(version, minVersion) {
+		return fmt.Errorf("redis %v is not supported, upgrade to redis %v or later", version, minVersion)
+	}
+
+	args := []interface{}{"INFO"}
+	for _, cmd := range commands {
+		args = append(args, cmd)
+	}
+
+	res, err := redis.Values(c.Do("COMMAND", args...))
+	if err != nil {
+		return fmt.Errorf("could not list the redis commands: %v", err)
+	}
+
+	missing := []string{}
+	for i, r := range res {
+		if r == nil && i < len(commands) {
+			missing = append(missing, commands[i])
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("the redis commands %v are disabled or renamed, they are required by the engine", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// infoField returns the value of a field of the reply of the INFO command
+func infoField(info, name string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if strings.HasPrefix(line, name+":") {
+			return strings.TrimSpace(strings.TrimPrefix(line, name+":"))
+		}
+	}
+
+	return ""
+}
@@ -3,15 +3,54 @@ package redis
 import (
 	"fmt"
 
+	"github.com/Proofsuite/amp-matching-engine/utils/breaker"
 	"github.com/gomodule/redigo/redis"
 )
 
-// InitConnection returns a new connection to redis
+// InitConnection returns a new connection to redis. The commands go through the redis
+// circuit breaker and the socket operations are bounded by the breaker timeout.
 func InitConnection(uri string) redis.Conn {
-	c, err := redis.DialURL(uri)
+	timeout := breaker.Get("redis").Settings().Timeout
+
+	c, err := redis.DialURL(uri, redis.DialReadTimeout(timeout), redis.DialWriteTimeout(timeout))
 	if err != nil {
 		fmt.Println(err)
 		panic(err)
 	}
-	return c
+
+	return &breakerConn{c}
+}
+
+// Dialer returns a function opening a new connection to redis, for the components that
+// need a connection of their own
+func Dialer(uri string) func() redis.Conn {
+	return func() redis.Conn {
+		return InitConnection(uri)
+	}
+}
+
+func init() {
+	breaker.Get("redis").SetFailureFilter(isConnectionError)
+}
+
+// breakerConn is a redis connection whose commands fail fast while redis is unreachable
+type breakerConn struct {
+	redis.Conn
+}
+
+// Do sends a command to the server and returns the received reply
+func (c *breakerConn) Do(cmd string, args ...interface{}) (reply interface{}, err error) {
+	err = breaker.Get("redis").Guard(func() error {
+		reply, err = c.Conn.Do(cmd, args...)
+		return err
+	})
+
+	return reply, err
+}
+
+// isConnectionError returns false for the error replies of the server, which must not
+// open the circuit breaker
+func isConnectionError(err error) bool {
+	_, ok := err.(redis.Error)
+	return !ok && err != redis.ErrNil
 }
@@ -3,14 +3,21 @@ package main
 import (
 	"fmt"
 	"log"
+	"math/big"
 	"net/http"
+	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/contracts"
 	"github.com/Proofsuite/amp-matching-engine/crons"
 	"github.com/Proofsuite/amp-matching-engine/endpoints"
 	"github.com/Proofsuite/amp-matching-engine/ethereum"
+	"github.com/Proofsuite/amp-matching-engine/operator"
 	"github.com/Proofsuite/amp-matching-engine/rabbitmq"
 	"github.com/Proofsuite/amp-matching-engine/redis"
 	"github.com/Proofsuite/amp-matching-engine/services"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/breaker"
+	"github.com/Proofsuite/amp-matching-engine/utils/encryption"
 	"github.com/Proofsuite/amp-matching-engine/ws"
 
 	"github.com/Proofsuite/amp-matching-engine/engine"
@@ -19,9 +26,11 @@ import (
 	"github.com/Proofsuite/amp-matching-engine/daos"
 	"github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Sirupsen/logrus"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-ozzo/ozzo-routing"
 	"github.com/go-ozzo/ozzo-routing/content"
 	"github.com/go-ozzo/ozzo-routing/cors"
+	redigo "github.com/gomodule/redigo/redis"
 )
 
 func main() {
@@ -37,16 +46,105 @@ func main() {
 	log.SetPrefix("\nLOG: ")
 	logger := logrus.New()
 
+	types.EIP712ChainID = big.NewInt(app.Config.ChainID)
+
+	for name, c := range app.Config.Breakers {
+		breaker.Configure(name, c.Settings())
+	}
+
 	rabbitmq.InitConnection(app.Config.Rabbitmq)
-	ethereum.InitConnection(app.Config.Ethereum)
-	redis.InitConnection(app.Config.Redis)
+
+	// in matcher mode the process only consumes orders from rabbitmq and
+	// publishes the engine responses, the API is served by other processes.
+	// Additional matchers wait as warm standbys until the active one fails.
+	if app.Config.EngineMode == "matcher" {
+		redisConn := redis.InitConnection(app.Config.Redis)
+		leaseConn := redis.InitConnection(app.Config.Redis)
+		if err := selfCheck(redisConn, nil); err != nil {
+			panic(err)
+		}
+
+		matcher, err := engine.InitStandbyEngine(redisConn, leaseConn, app.Config.EngineShard)
+		if err != nil {
+			panic(err)
+		}
+
+		if err := matcher.SetSelfTradePrevention(app.Config.SelfTradePrevention); err != nil {
+			panic(err)
+		}
+
+		matcher.SetQuoteThrottles(quoteThrottles())
+		if err := matcher.SetOrderBookLimits(orderBookLimits()); err != nil {
+			panic(err)
+		}
+
+		if err := configureMatcher(matcher); err != nil {
+			panic(err)
+		}
+
+		logger.Infof("matching engine %v is started for shard %q\n", app.Version, app.Config.EngineShard)
+		select {}
+	}
+
+	ethereumClient := ethereum.InitConnection(app.Config.Ethereum)
+	ethereumClient.SetRoundRobin(app.Config.EthereumRoundRobin)
+	ethereumClient.StartHealthChecks(time.Duration(app.Config.EthereumHealthCheck) * time.Second)
+
+	// the chain dependent features are degraded while the ethereum node lags behind
+	chainMonitor := ethereum.NewClientChainMonitor(ethereumClient, time.Duration(app.Config.ChainLag.MaxLag)*time.Second)
+	chainMonitor.Start(time.Duration(app.Config.ChainLag.CheckInterval) * time.Second)
+
+	gasPrice := app.Config.GasPrice
+	gasPrices := ethereum.NewClientGasPriceOracle(ethereumClient, gasPrice.Oracle, gasPrice.FastPercent, gasPrice.SlowPercent)
+	gasPrices.Start(time.Duration(gasPrice.CheckInterval) * time.Second)
+
+	redisConn := redis.InitConnection(app.Config.Redis)
 
 	// connect to the database
 	if _, err := daos.InitSession(); err != nil {
 		panic(err)
 	}
 
-	http.Handle("/", buildRouter(logger))
+	if err := selfCheck(redisConn, ethereumClient); err != nil {
+		panic(err)
+	}
+
+	// credit the tokens transferred to the exchange contract, read replicas do not
+	// update the balances
+	if app.Config.Deposits.Enabled && !app.Config.ReadOnly {
+		startDepositWatcher(ethereumClient)
+	}
+
+	// encrypt wallet private keys at rest if a wallet key is configured
+	if app.Config.WalletKeyID != "" {
+		kr, err := encryption.NewKeyRing(app.Config.WalletKeyID, app.Config.WalletKeys)
+		if err != nil {
+			panic(err)
+		}
+
+		encryption.SetKeyRing(kr)
+		if _, err := daos.NewWalletDao().EncryptPrivateKeys(); err != nil {
+			panic(err)
+		}
+	}
+
+	routeTimeouts := make(map[string]time.Duration)
+	for prefix, seconds := range app.Config.RouteTimeouts {
+		routeTimeouts[prefix] = time.Duration(seconds) * time.Second
+	}
+
+	requestTimeout := time.Duration(app.Config.RequestTimeout) * time.Second
+	http.Handle("/", app.TimeoutHandler(buildRouter(logger), requestTimeout, routeTimeouts))
+	ws.SetMaxMessageSize(int64(app.Config.WSMaxMessageSize))
+	ws.SetAuthRequired(app.Config.WSAuthRequired)
+	ws.SetRateLimits(ws.RateLimits{
+		ConnectionRate:  app.Config.WSRateLimit.ConnectionRate,
+		ConnectionBurst: app.Config.WSRateLimit.ConnectionBurst,
+		AddressRate:     app.Config.WSRateLimit.AddressRate,
+		AddressBurst:    app.Config.WSRateLimit.AddressBurst,
+		MaxViolations:   app.Config.WSRateLimit.MaxViolations,
+	})
+	ws.SetHeartbeat(time.Duration(app.Config.WSPingInterval)*time.Second, time.Duration(app.Config.WSPongDeadline)*time.Second)
 	http.HandleFunc("/socket", ws.ConnectionEndpoint)
 
 	// start the server
@@ -55,6 +153,186 @@ func main() {
 	panic(http.ListenAndServe(address, nil))
 }
 
+// selfCheck verifies the dependencies of the server so that it fails on startup rather
+// than mid-trade: the versions and the features of rabbitmq, redis and mongodb and the
+// code of the exchange contract. The database and the contract are not checked if the
+// ethereum client is nil, in matcher mode.
+func selfCheck(redisConn redigo.Conn, ethereumClient *ethereum.Client) error {
+	if app.Config.SkipSelfCheck {
+		return nil
+	}
+
+	if err := rabbitmq.CheckServer(); err != nil {
+		return fmt.Errorf("rabbitmq self-check failed: %v", err)
+	}
+
+	if err := redis.CheckServer(redisConn); err != nil {
+		return fmt.Errorf("redis self-check failed: %v", err)
+	}
+
+	if ethereumClient == nil {
+		return nil
+	}
+
+	if err := daos.CheckDatabase(); err != nil {
+		return fmt.Errorf("mongodb self-check failed: %v", err)
+	}
+
+	exchange := common.HexToAddress(app.Config.ExchangeAddress)
+	if err := ethereum.CheckContract(ethereumClient, "exchange", exchange); err != nil {
+		return fmt.Errorf("ethereum self-check failed: %v", err)
+	}
+
+	return nil
+}
+
+// startDepositWatcher starts crediting the deposits to the exchange contract and
+// publishing them on the balances channel
+func startDepositWatcher(c *ethereum.Client) {
+	accountDao := daos.NewAccountDao()
+	watcher := ethereum.NewDepositWatcher(
+		c,
+		common.HexToAddress(app.Config.ExchangeAddress),
+		uint64(app.Config.Deposits.Confirmations),
+		accountDao,
+		daos.NewDepositDao(),
+		daos.NewTokenDao(),
+	)
+
+	watcher.OnConfirmed(func(d *types.Deposit) {
+		services.PublishDeposit(accountDao, d)
+	})
+
+	err := watcher.Start(time.Duration(app.Config.Deposits.CheckInterval) * time.Second)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// startOperator starts the operator sending the settlement transactions of the signed
+// trades to the exchange contract with the default admin wallet
+func startOperator(
+	txService *services.TxService,
+	tradeService *services.TradeService,
+	orderService *services.OrderService,
+	settlementService *services.SettlementService,
+) *operator.Operator {
+	client := ethereum.GetClient()
+	walletService := services.NewWalletService(txService.WalletDao)
+
+	exchange, err := contracts.NewExchange(walletService, txService, common.HexToAddress(app.Config.ExchangeAddress), client)
+	if err != nil {
+		panic(err)
+	}
+
+	op, err := operator.InitOperator(
+		walletService,
+		txService,
+		tradeService,
+		orderService,
+		services.NewEthereumService(client),
+		settlementService,
+		exchange,
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return op
+}
+
+// newWithdrawService returns the withdraw service sending the withdrawals with the default
+// admin wallet, and starts checking the withdrawal transactions if withdrawals are enabled
+func newWithdrawService(accountDao *daos.AccountDao, tokenDao *daos.TokenDao, txService *services.TxService) *services.WithdrawService {
+	client := ethereum.GetClient()
+	withdrawer, err := ethereum.NewWithdrawer(common.HexToAddress(app.Config.ExchangeAddress), client)
+	if err != nil {
+		panic(err)
+	}
+
+	s := services.NewWithdrawService(daos.NewWithdrawDao(), accountDao, tokenDao, txService, withdrawer, client)
+	if app.Config.Withdraws.Enabled {
+		s.Start(time.Duration(app.Config.Withdraws.CheckInterval) * time.Second)
+	}
+
+	return s
+}
+
+// newSettlementWebhook returns the webhook the settled trades are posted to and starts
+// posting the pending events, or nil if there is no webhook
+func newSettlementWebhook() *services.SettlementWebhook {
+	if app.Config.SettlementWebhook.URL == "" {
+		return nil
+	}
+
+	w := services.NewSettlementWebhook(daos.NewSettlementEventDao(), app.Config.SettlementWebhook.URL, app.Config.SettlementWebhook.Secret)
+	w.Start(time.Duration(app.Config.SettlementWebhook.CheckInterval) * time.Second)
+	return w
+}
+
+// newPortfolioService returns the portfolio service, valuing the balances with the fiat
+// prices of the feed when there is one
+func newPortfolioService(accountDao *daos.AccountDao, tokenDao *daos.TokenDao, pairDao *daos.PairDao, tradeDao *daos.TradeDao) *services.PortfolioService {
+	var fiatPrices *services.FiatPriceFeed
+	if app.Config.FiatPrices.Feed != "" {
+		fiatPrices = services.NewFiatPriceFeed(app.Config.FiatPrices.Feed)
+		fiatPrices.Start(time.Duration(app.Config.FiatPrices.CheckInterval) * time.Second)
+	}
+
+	return services.NewPortfolioService(accountDao, tokenDao, pairDao, tradeDao, daos.NewDepositDao(), fiatPrices)
+}
+
+// quoteThrottles returns the quote throttling of the configuration by pair name
+func quoteThrottles() map[string]engine.QuoteThrottle {
+	throttles := make(map[string]engine.QuoteThrottle)
+	for _, c := range app.Config.QuoteThrottles {
+		throttles[c.Pair] = engine.QuoteThrottle{
+			MinRestingTime: time.Duration(c.MinRestingTime) * time.Millisecond,
+			MaxUpdates:     c.MaxUpdates,
+		}
+	}
+
+	return throttles
+}
+
+// orderBookLimits returns the orderbook size limits of the configuration by pair name
+func orderBookLimits() map[string]engine.OrderBookLimit {
+	limits := make(map[string]engine.OrderBookLimit)
+	for _, c := range app.Config.OrderBookLimits {
+		policy := c.Policy
+		if policy == "" {
+			policy = engine.RejectFarOrders
+		}
+
+		limits[c.Pair] = engine.OrderBookLimit{MaxOrders: c.MaxOrders, Policy: policy}
+	}
+
+	return limits
+}
+
+// configureMatcher selects the orderbook backend of the configuration and starts the
+// pair workers if enabled. The memory backend writes its snapshots to redis with a
+// connection of its own, as does each pair worker.
+func configureMatcher(matcher *engine.Resource) error {
+	c := app.Config.Engine
+	if c.Backend != engine.MemoryBackend {
+		if err := matcher.SetOrderBookBackend(c.Backend, nil, 0); err != nil {
+			return err
+		}
+	} else {
+		interval := time.Duration(c.SnapshotInterval) * time.Millisecond
+		if err := matcher.SetOrderBookBackend(c.Backend, redis.InitConnection(app.Config.Redis), interval); err != nil {
+			return err
+		}
+	}
+
+	if c.PairWorkers {
+		matcher.SetPairWorkers(redis.Dialer(app.Config.Redis))
+	}
+
+	return nil
+}
+
 func buildRouter(logger *logrus.Logger) *routing.Router {
 	router := routing.New()
 
@@ -63,6 +341,16 @@ func buildRouter(logger *logrus.Logger) *routing.Router {
 		return c.Write("OK " + app.Version)
 	})
 
+	// the request quotas are counted with a redis connection of their own
+	rateLimit := app.Config.RateLimit
+	rateLimiter := app.NewRateLimiter(
+		redis.InitConnection(app.Config.Redis),
+		time.Duration(rateLimit.Window)*time.Second,
+		rateLimit.IPLimit,
+		rateLimit.AddressLimit,
+		rateLimit.ForwardedFor,
+	)
+
 	router.Use(
 		app.Init(logger),
 		content.TypeNegotiator(content.JSON),
@@ -71,6 +359,7 @@ func buildRouter(logger *logrus.Logger) *routing.Router {
 			AllowHeaders: "*",
 			AllowMethods: "*",
 		}),
+		rateLimiter.Handler(),
 	)
 
 	rg := router.Group("")
@@ -80,34 +369,106 @@ func buildRouter(logger *logrus.Logger) *routing.Router {
 	tokenDao := daos.NewTokenDao()
 	pairDao := daos.NewPairDao()
 	tradeDao := daos.NewTradeDao()
+	journalDao := daos.NewJournalDao()
 	accountDao := daos.NewAccountDao()
+	auditDao := daos.NewAuditDao()
+	settlementCostDao := daos.NewSettlementCostDao()
+	approvalDao := daos.NewApprovalDao()
 
 	redisClient := redis.InitConnection(app.Config.Redis)
 
 	// instantiate engine
-	engineResource, err := engine.InitEngine(redisClient)
+	var engineResource engine.Engine
+	var err error
+	if app.Config.ReadOnly {
+		engineResource = engine.InitReadOnlyEngine(redisClient)
+	} else if app.Config.EngineMode == "api" {
+		// the shard assignments are read with a connection of their own as they are
+		// looked up concurrently by the API handlers
+		shards := engine.NewShards(app.Config.EngineShards, redis.InitConnection(app.Config.Redis))
+		engineResource, err = engine.InitEngineClient(redisClient, shards)
+	} else {
+		var matcher *engine.Resource
+		matcher, err = engine.InitEngine(redisClient)
+		if err == nil {
+			err = matcher.SetSelfTradePrevention(app.Config.SelfTradePrevention)
+		}
+
+		if err == nil {
+			matcher.SetQuoteThrottles(quoteThrottles())
+			err = matcher.SetOrderBookLimits(orderBookLimits())
+		}
+
+		if err == nil {
+			err = configureMatcher(matcher)
+		}
+
+		engineResource = matcher
+	}
+
 	if err != nil {
 		panic(err)
 	}
 
 	// get services for injection
-	accountService := services.NewAccountService(accountDao, tokenDao)
-	ohlcvService := services.NewOHLCVService(tradeDao)
-	tokenService := services.NewTokenService(tokenDao)
-	tradeService := services.NewTradeService(tradeDao)
+	accountService := services.NewAccountService(accountDao, tokenDao, auditDao)
+	ohlcvService := services.NewOHLCVService(tradeDao, pairDao)
+	tradeService := services.NewTradeService(tradeDao, pairDao)
 	pairService := services.NewPairService(pairDao, tokenDao, engineResource, tradeService)
-	orderService := services.NewOrderService(orderDao, pairDao, accountDao, tradeDao, engineResource)
+	tokenService := services.NewTokenService(tokenDao, pairDao, pairService)
+	orderService := services.NewOrderService(orderDao, pairDao, accountDao, tradeDao, journalDao, engineResource)
 	orderBookService := services.NewOrderBookService(pairDao, tokenDao, engineResource)
-	cronService := crons.NewCronService(ohlcvService)
+	tickerService := services.NewTickerService(tradeDao, pairDao, engineResource)
+	cronService := crons.NewCronService(ohlcvService, pairService, orderService, tickerService)
+	// the settlements can only be retried when the operator runs in this process
+	settlementService := services.NewSettlementService(tradeDao, orderDao, auditDao, settlementCostDao, nil, orderService)
+	approvalService := services.NewApprovalService(approvalDao, auditDao, time.Duration(app.Config.ApprovalTTL)*time.Hour)
 	// walletService := services.NewWalletService(walletDao, balanceDao)
 
-	endpoints.ServeAccountResource(rg, accountService)
+	endpoints.ServeTimeResource(rg)
+
+	// read replicas only serve market data, the orders are submitted to the primary
+	if app.Config.ReadOnly {
+		endpoints.ServeMarketDataResource(rg, tokenService, pairService, orderBookService, ohlcvService, tradeService, tickerService)
+		cronService.InitCrons()
+		return router
+	}
+
+	// the orderbooks are rebuilt from the open orders when the engine runs in this process,
+	// so that the orders are not orphaned if redis was flushed
+	if app.Config.EngineMode != "api" {
+		if _, err := orderService.RestoreOrderBooks(); err != nil {
+			logger.Errorf("Could not restore the orderbooks: %v", err)
+		}
+	}
+
+	// the settlement and withdrawal transactions are sent with the default admin wallet,
+	// they share its nonces
+	txService := services.NewTxService(daos.NewWalletDao())
+	txService.SetGasPriceOracle(ethereum.GetGasPriceOracle(), map[string]string{
+		services.SettlementTx: app.Config.GasPrice.SettlementTier,
+		services.WithdrawTx:   app.Config.GasPrice.WithdrawTier,
+	})
+	if app.Config.Settlement.Enabled {
+		op := startOperator(txService, tradeService, orderService, settlementService)
+		orderService.SetTradeQueue(op)
+		settlementService.SetTradeQueue(op)
+		settlementService.SetWebhook(newSettlementWebhook())
+	}
+
+	endpoints.ServeAccountResource(rg, accountService, approvalService, newPortfolioService(accountDao, tokenDao, pairDao, tradeDao))
 	endpoints.ServeTokenResource(rg, tokenService)
-	endpoints.ServePairResource(rg, pairService)
-	endpoints.ServeOrderBookResource(rg, orderBookService)
-	endpoints.ServeOHLCVResource(rg, ohlcvService)
-	endpoints.ServeTradeResource(rg, tradeService)
-	endpoints.ServeOrderResource(rg, orderService, engineResource)
+	endpoints.ServePairResource(rg, pairService, approvalService)
+	endpoints.ServeOrderBookResource(rg, orderBookService, pairService)
+	endpoints.ServeOHLCVResource(rg, ohlcvService, pairService)
+	endpoints.ServeTradeResource(rg, tradeService, pairService)
+	endpoints.ServeTickerResource(rg, tickerService)
+	endpoints.ServeOrderResource(rg, orderService, engineResource, approvalService)
+	endpoints.ServeSettlementResource(rg, settlementService, approvalService)
+	endpoints.ServeWithdrawResource(rg, newWithdrawService(accountDao, tokenDao, txService))
+	endpoints.ServeApprovalResource(rg, approvalService)
+	endpoints.ServeMetricsResource(rg, pairService)
+	endpoints.ServeCaptureResource(rg)
 
 	cronService.InitCrons()
 	return router
@@ -1,24 +1,44 @@
 package services
 
 import (
-	"errors"
+	"log"
 	"math/big"
+	"regexp"
+	"strings"
+	"time"
 
+	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 
 	"github.com/Proofsuite/amp-matching-engine/daos"
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/ws"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
 )
 
+// tagPattern is the format of the account tags: lowercase words separated by dashes
+var tagPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
 type AccountService struct {
 	AccountDao *daos.AccountDao
 	TokenDao   *daos.TokenDao
+	AuditDao   *daos.AuditDao
 }
 
 // NewAddressService returns a new instance of accountService
-func NewAccountService(AccountDao *daos.AccountDao, TokenDao *daos.TokenDao) *AccountService {
-	return &AccountService{AccountDao, TokenDao}
+func NewAccountService(AccountDao *daos.AccountDao, TokenDao *daos.TokenDao, AuditDao *daos.AuditDao) *AccountService {
+	return &AccountService{AccountDao, TokenDao, AuditDao}
+}
+
+// accountError returns an ACCOUNT_NOT_FOUND error for the accounts missing from the database
+func accountError(err error) error {
+	if err.Error() == "NO_ACCOUNT_FOUND" {
+		return aerrors.AccountNotFound.New(nil)
+	}
+
+	return err
 }
 
 func (s *AccountService) Create(account *types.Account) error {
@@ -29,7 +49,7 @@ func (s *AccountService) Create(account *types.Account) error {
 	}
 
 	if acc != nil {
-		return errors.New("ACCOUNT_ALREADY_EXISTS")
+		return aerrors.AccountAlreadyExists.New(nil)
 	}
 
 	tokens, err := s.TokenDao.GetAll()
@@ -61,6 +81,211 @@ func (s *AccountService) Create(account *types.Account) error {
 	return nil
 }
 
+// Delete removes an account. The account document is tombstoned rather than removed
+// so that existing orders and trades keep resolving it.
+func (s *AccountService) Delete(a common.Address) error {
+	_, err := s.AccountDao.GetByAddress(a)
+	if err != nil {
+		return accountError(err)
+	}
+
+	return s.AccountDao.Delete(a)
+}
+
+// Unblock allows a blocked account to place orders again
+func (s *AccountService) Unblock(a common.Address) (*types.Account, error) {
+	_, err := s.AccountDao.GetByAddress(a)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.AccountDao.SetBlocked(a, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AccountDao.GetByAddress(a)
+}
+
+// AddTags tags an account (eg. "vip", "market-maker" or "under-review")
+func (s *AccountService) AddTags(a common.Address, tags []string) (*types.Account, error) {
+	if len(tags) == 0 {
+		return nil, aerrors.InvalidTag.New(aerrors.Params{"tag": ""})
+	}
+
+	for i, t := range tags {
+		tags[i] = strings.ToLower(strings.TrimSpace(t))
+		if !tagPattern.MatchString(tags[i]) {
+			return nil, aerrors.InvalidTag.New(aerrors.Params{"tag": t})
+		}
+	}
+
+	_, err := s.AccountDao.GetByAddress(a)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.AccountDao.AddTags(a, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AccountDao.GetByAddress(a)
+}
+
+// RemoveTag removes a tag from an account
+func (s *AccountService) RemoveTag(a common.Address, tag string) (*types.Account, error) {
+	_, err := s.AccountDao.GetByAddress(a)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.AccountDao.RemoveTag(a, strings.ToLower(tag))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AccountDao.GetByAddress(a)
+}
+
+// AddNote writes a note on an account on behalf of an administrator
+func (s *AccountService) AddNote(a common.Address, text, admin string) (*types.Account, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, aerrors.InvalidRequestData.New(nil)
+	}
+
+	_, err := s.AccountDao.GetByAddress(a)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.AccountDao.AddNote(a, &types.AccountNote{Text: text, Admin: admin})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AccountDao.GetByAddress(a)
+}
+
+// GetByTags returns the accounts tagged with all the given tags, most recent first
+func (s *AccountService) GetByTags(tags []string, offset, limit int) ([]types.Account, error) {
+	for i, t := range tags {
+		tags[i] = strings.ToLower(strings.TrimSpace(t))
+	}
+
+	return s.AccountDao.GetByTags(tags, offset, limit)
+}
+
+// GetLimits returns the limits currently applied to the orders of an account along with
+// its limit override
+func (s *AccountService) GetLimits(a common.Address) (map[string]interface{}, error) {
+	acc, err := s.AccountDao.GetByAddress(a)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]interface{}{
+		"address":       acc.Address,
+		"limits":        acc.EffectiveLimits(AccountTiers(), time.Now()),
+		"limitOverride": acc.LimitOverride,
+	}
+
+	return res, nil
+}
+
+// SetLimitOverride replaces some of the tier limits of an account (fees, order rate and
+// open orders) until the override is removed or expires. Accounts are loaded for each
+// order so the new limits apply to the orders received from then on.
+func (s *AccountService) SetLimitOverride(a common.Address, override *types.AccountLimitOverride, admin string) (*types.Account, error) {
+	if err := override.Validate(); err != nil {
+		return nil, aerrors.InvalidLimitOverride.New(aerrors.Params{"error": err.Error()})
+	}
+
+	now := time.Now()
+	if override.ExpiresAt != nil && !override.ExpiresAt.After(now) {
+		return nil, aerrors.InvalidLimitOverride.New(aerrors.Params{"error": "The override is already expired"})
+	}
+
+	acc, err := s.AccountDao.GetByAddress(a)
+	if err != nil {
+		return nil, err
+	}
+
+	override.UpdatedAt = now
+	err = s.AccountDao.UpdateLimitOverride(a, override)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit("SET_LIMIT_OVERRIDE", a, admin, map[string]interface{}{
+		"previous": acc.LimitOverride,
+		"override": override,
+	})
+
+	acc.LimitOverride = override
+	return acc, nil
+}
+
+// RemoveLimitOverride restores the tier limits of an account
+func (s *AccountService) RemoveLimitOverride(a common.Address, admin string) (*types.Account, error) {
+	acc, err := s.AccountDao.GetByAddress(a)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.AccountDao.UpdateLimitOverride(a, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit("REMOVE_LIMIT_OVERRIDE", a, admin, map[string]interface{}{"previous": acc.LimitOverride})
+
+	acc.LimitOverride = nil
+	return acc, nil
+}
+
+// anonymizedFields are the personal metadata of an account erased by Anonymize
+var anonymizedFields = []string{"tags", "notes", "limitOverride.reason"}
+
+// Anonymize erases the personal metadata of an account on request of its owner, once
+// approved by a second administrator. The orders, trades and balances of the account are
+// kept as they are needed to settle and audit the trades. The erased content is not
+// written in the audit log, only the erased fields and the administrators.
+func (s *AccountService) Anonymize(a common.Address, requestedBy, approvedBy string) error {
+	err := s.AccountDao.Anonymize(a)
+	if err == mgo.ErrNotFound {
+		return aerrors.AccountNotFound.New(nil)
+	}
+
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	s.audit(types.ActionAnonymizeAccount, a, approvedBy, map[string]interface{}{
+		"fields":      anonymizedFields,
+		"requestedBy": requestedBy,
+		"approvedBy":  approvedBy,
+	})
+
+	return nil
+}
+
+// audit records a change of an account made by an administrator
+func (s *AccountService) audit(action string, a common.Address, admin string, details map[string]interface{}) {
+	err := s.AuditDao.Create(&types.AuditLog{
+		Action:  action,
+		Target:  a.Hex(),
+		Admin:   admin,
+		Details: details,
+	})
+
+	if err != nil {
+		log.Print(err)
+	}
+}
+
 func (s *AccountService) GetByID(id bson.ObjectId) (*types.Account, error) {
 	return s.AccountDao.GetByID(id)
 }
@@ -80,3 +305,83 @@ func (s *AccountService) GetTokenBalance(owner common.Address, token common.Addr
 func (s *AccountService) GetTokenBalances(owner common.Address) (map[common.Address]*types.TokenBalance, error) {
 	return s.AccountDao.GetTokenBalances(owner)
 }
+
+// SubscribeBalances subscribes the connection to the token balances of an account after
+// checking that the subscription is signed by the account, or that the connection is
+// authenticated as the account. The current balances are sent in an INIT message and the
+// balances are then sent in an UPDATE message each time they change.
+func (s *AccountService) SubscribeBalances(conn *websocket.Conn, sub *types.UserSubscription) {
+	// the connections authenticated as the account do not need a signed subscription
+	if addr, ok := ws.AuthenticatedAddress(conn); !ok || addr != sub.Address {
+		err := sub.VerifySignature(time.Now(), maxClockSkew())
+		if err != nil {
+			ws.SendBalanceErrorMessage(conn, subscriptionError(err))
+			return
+		}
+	}
+
+	balances, err := s.AccountDao.GetTokenBalances(sub.Address)
+	if err != nil {
+		ws.SendBalanceErrorMessage(conn, aerrors.NewWSError(err))
+		return
+	}
+
+	socket := ws.GetBalanceSocket()
+	err = socket.Subscribe(sub.Address, conn)
+	if err != nil {
+		log.Print(err)
+		ws.SendBalanceErrorMessage(conn, aerrors.NewWSError(aerrors.UnableToRegister.New(nil)))
+		return
+	}
+
+	ws.RegisterConnectionUnsubscribeHandler(conn, socket.UnsubscribeHandler(sub.Address))
+	ws.SendBalanceMessage(conn, "INIT", types.NewAccountBalances(sub.Address, balances))
+}
+
+// UnsubscribeBalances removes the connection from the token balances of an account
+func (s *AccountService) UnsubscribeBalances(conn *websocket.Conn, addr common.Address) {
+	ws.GetBalanceSocket().Unsubscribe(addr, conn)
+}
+
+// PublishBalances sends the token balances of an account to the connections subscribed
+// to them. It must be called after the balances of an account are updated (eg. an
+// order locks its sold amount or is filled). The balances are not read if no connection
+// is subscribed to the account.
+func PublishBalances(accountDao *daos.AccountDao, addr common.Address) {
+	socket := ws.GetBalanceSocket()
+	if !socket.IsSubscribed(addr) {
+		return
+	}
+
+	balances, err := accountDao.GetTokenBalances(addr)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	socket.BroadcastMessage(addr, "UPDATE", types.NewAccountBalances(addr, balances))
+}
+
+// PublishDeposit sends a DEPOSIT_CONFIRMED message and the updated token balances to the
+// connections subscribed to the balances of the owner of a credited deposit
+func PublishDeposit(accountDao *daos.AccountDao, d *types.Deposit) {
+	socket := ws.GetBalanceSocket()
+	if !socket.IsSubscribed(d.Owner) {
+		return
+	}
+
+	socket.BroadcastMessage(d.Owner, "DEPOSIT_CONFIRMED", d)
+	PublishBalances(accountDao, d.Owner)
+}
+
+// PublishWithdraw sends a WITHDRAW_UPDATED message with the status of a withdrawal and
+// the updated token balances to the connections subscribed to the balances of its trader
+func PublishWithdraw(accountDao *daos.AccountDao, w *types.Withdraw) {
+	socket := ws.GetBalanceSocket()
+	if !socket.IsSubscribed(w.Trader) {
+		return
+	}
+
+	socket.BroadcastMessage(w.Trader, "WITHDRAW_UPDATED", w)
+	PublishBalances(accountDao, w.Trader)
+}
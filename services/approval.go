@@ -0,0 +1,195 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ApprovalService runs the destructive admin actions that require the approval of two
+// distinct administrators. An action is requested by one administrator and executed
+// when a second administrator approves it. Every step is written to the audit log.
+type ApprovalService struct {
+	approvalDao *daos.ApprovalDao
+	auditDao    *daos.AuditDao
+	ttl         time.Duration
+	executors   map[string]func(*types.Approval) error
+}
+
+// NewApprovalService returns a new instance of ApprovalService. The pending requests
+// expire after ttl.
+func NewApprovalService(approvalDao *daos.ApprovalDao, auditDao *daos.AuditDao, ttl time.Duration) *ApprovalService {
+	return &ApprovalService{
+		approvalDao: approvalDao,
+		auditDao:    auditDao,
+		ttl:         ttl,
+		executors:   make(map[string]func(*types.Approval) error),
+	}
+}
+
+// Register sets the function executing an action once it is approved
+func (s *ApprovalService) Register(action string, fn func(*types.Approval) error) {
+	s.executors[action] = fn
+}
+
+// Request creates a pending approval request for an action
+func (s *ApprovalService) Request(action, target string, params map[string]interface{}, admin string) (*types.Approval, error) {
+	if s.executors[action] == nil {
+		return nil, aerrors.UnknownAdminAction.New(aerrors.Params{"action": action})
+	}
+
+	a := &types.Approval{
+		Action:      action,
+		Target:      target,
+		Params:      params,
+		Status:      types.ApprovalPending,
+		RequestedBy: admin,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}
+
+	err := s.approvalDao.Create(a)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	s.audit("APPROVAL_REQUESTED", a, admin)
+	return a, nil
+}
+
+// Approve executes a pending action. The action must be approved by another
+// administrator than the one who requested it.
+func (s *ApprovalService) Approve(id bson.ObjectId, admin string) (*types.Approval, error) {
+	a, err := s.getPending(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.RequestedBy == admin {
+		return nil, aerrors.ApprovalSameAdmin.New(nil)
+	}
+
+	a.ReviewedBy = admin
+	err = s.review(a, types.ApprovalExecuted)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.executors[a.Action](a)
+	if err != nil {
+		a.Status = types.ApprovalFailed
+		a.Error = err.Error()
+
+		if err := s.approvalDao.Update(a); err != nil {
+			log.Print(err)
+		}
+	}
+
+	s.audit("APPROVAL_"+a.Status, a, admin)
+	return a, nil
+}
+
+// Reject cancels a pending action. Either administrator can reject it.
+func (s *ApprovalService) Reject(id bson.ObjectId, admin string) (*types.Approval, error) {
+	a, err := s.getPending(id)
+	if err != nil {
+		return nil, err
+	}
+
+	a.ReviewedBy = admin
+	err = s.review(a, types.ApprovalRejected)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit("APPROVAL_REJECTED", a, admin)
+	return a, nil
+}
+
+// GetByID returns an approval request
+func (s *ApprovalService) GetByID(id bson.ObjectId) (*types.Approval, error) {
+	a, err := s.approvalDao.GetByID(id)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	if a == nil {
+		return nil, aerrors.ApprovalNotFound.New(nil)
+	}
+
+	return a, nil
+}
+
+// GetPending returns the pending approval requests, most recent first
+func (s *ApprovalService) GetPending() ([]*types.Approval, error) {
+	return s.approvalDao.GetByStatus(types.ApprovalPending)
+}
+
+// getPending returns a pending approval request. The expired requests are marked as such.
+func (s *ApprovalService) getPending(id bson.ObjectId) (*types.Approval, error) {
+	a, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Status != types.ApprovalPending {
+		return nil, aerrors.ApprovalNotPending.New(aerrors.Params{"status": a.Status})
+	}
+
+	if time.Now().After(a.ExpiresAt) {
+		if err := s.approvalDao.Review(a, types.ApprovalExpired); err != nil && err != mgo.ErrNotFound {
+			log.Print(err)
+		}
+
+		return nil, aerrors.ApprovalNotPending.New(aerrors.Params{"status": types.ApprovalExpired})
+	}
+
+	return a, nil
+}
+
+// review moves a pending request to a status, it fails if the request was reviewed
+// in the meantime
+func (s *ApprovalService) review(a *types.Approval, status string) error {
+	err := s.approvalDao.Review(a, status)
+	if err == mgo.ErrNotFound {
+		return aerrors.ApprovalNotPending.New(nil)
+	}
+
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	return nil
+}
+
+// audit writes a step of an approval request to the audit log
+func (s *ApprovalService) audit(action string, a *types.Approval, admin string) {
+	entry := &types.AuditLog{
+		Action: action,
+		Target: a.Target,
+		Admin:  admin,
+		Details: map[string]interface{}{
+			"approval":    a.ID.Hex(),
+			"action":      a.Action,
+			"params":      a.Params,
+			"requestedBy": a.RequestedBy,
+			"status":      a.Status,
+		},
+	}
+
+	if a.Error != "" {
+		entry.Details["error"] = a.Error
+	}
+
+	err := s.auditDao.Create(entry)
+	if err != nil {
+		log.Print(err)
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Proofsuite/amp-matching-engine/engine"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+)
+
+// ConsistencyReport is the result of a comparison of the orderbooks with the open orders
+// stored in the database and of the locked balances of the accounts with their current
+// orders. OrderBooks only lists the orderbooks that diverge. Fixed is true if the
+// divergences were repaired.
+type ConsistencyReport struct {
+	CheckedAt  time.Time                         `json:"checkedAt"`
+	Fixed      bool                              `json:"fixed"`
+	OrderBooks []*engine.RestoreReport           `json:"orderBooks"`
+	Balances   []*types.LockedBalanceDiscrepancy `json:"balances"`
+}
+
+// Consistent returns true if no divergence was found
+func (r *ConsistencyReport) Consistent() bool {
+	return len(r.OrderBooks) == 0 && len(r.Balances) == 0
+}
+
+var consistencyReport *ConsistencyReport
+var consistencyMutex sync.Mutex
+
+// GetConsistencyReport returns the report of the latest consistency check, or nil if
+// no check ran in this process
+func GetConsistencyReport() *ConsistencyReport {
+	consistencyMutex.Lock()
+	defer consistencyMutex.Unlock()
+
+	return consistencyReport
+}
+
+// CheckConsistency compares the orderbook of each pair with the open orders of the pair
+// stored in the database, and the locked balance of each token of the accounts with the
+// amount still to be sold by their current orders. The database is the reference, the
+// divergences are repaired if fix is true. The maker quantity reserved by the firm quotes
+// waiting to be committed is taken into account.
+func (s *OrderService) CheckConsistency(fix bool) (*ConsistencyReport, error) {
+	report := &ConsistencyReport{
+		CheckedAt:  time.Now(),
+		Fixed:      fix,
+		OrderBooks: []*engine.RestoreReport{},
+		Balances:   []*types.LockedBalanceDiscrepancy{},
+	}
+
+	pairs, err := s.pairDao.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := s.rfq.reservedOrders()
+	for i := range pairs {
+		p := &pairs[i]
+		orders, err := s.orderDao.GetOpenByPair(p.BaseTokenAddress, p.QuoteTokenAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = withReservations(orders, reserved)
+
+		var r *engine.RestoreReport
+		if fix {
+			r, err = s.engine.RestoreOrderBook(p, orders)
+		} else {
+			r, err = s.engine.CheckOrderBook(p, orders)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if r.Consistent() {
+			continue
+		}
+
+		report.OrderBooks = append(report.OrderBooks, r)
+		if fix {
+			PublishOrderBookUpdate(s.engine, p)
+		}
+	}
+
+	report.Balances, err = s.checkLockedBalances(fix)
+	if err != nil {
+		return nil, err
+	}
+
+	consistencyMutex.Lock()
+	consistencyReport = report
+	consistencyMutex.Unlock()
+
+	return report, nil
+}
+
+// checkLockedBalances returns the token balances whose locked amount differs from the
+// amount still to be sold by the current orders of the account. The locked amounts are
+// set to the expected amounts if fix is true.
+func (s *OrderService) checkLockedBalances(fix bool) ([]*types.LockedBalanceDiscrepancy, error) {
+	orders, err := s.orderDao.GetCurrent()
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[common.Address]map[common.Address]*big.Int)
+	for _, o := range orders {
+		sold, _ := filledAmounts(o, o.FilledAmount)
+		if expected[o.UserAddress] == nil {
+			expected[o.UserAddress] = make(map[common.Address]*big.Int)
+		}
+
+		locked := expected[o.UserAddress][o.SellToken]
+		if locked == nil {
+			locked = big.NewInt(0)
+		}
+
+		expected[o.UserAddress][o.SellToken] = math.Add(locked, math.Sub(o.SellAmount, sold))
+	}
+
+	accounts, err := s.accountDao.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	discrepancies := []*types.LockedBalanceDiscrepancy{}
+	for _, a := range accounts {
+		for token, tb := range a.TokenBalances {
+			if tb == nil || tb.LockedBalance == nil {
+				continue
+			}
+
+			locked := expected[a.Address][token]
+			if locked == nil {
+				locked = big.NewInt(0)
+			}
+
+			if tb.LockedBalance.Cmp(locked) == 0 {
+				continue
+			}
+
+			discrepancies = append(discrepancies, &types.LockedBalanceDiscrepancy{
+				Address:  a.Address,
+				Token:    token,
+				Locked:   tb.LockedBalance,
+				Expected: locked,
+			})
+
+			if !fix {
+				continue
+			}
+
+			delta := math.Sub(locked, tb.LockedBalance)
+			err := s.accountDao.AdjustTokenBalance(a.Address, token, math.Neg(delta), delta)
+			if err != nil {
+				log.Printf("Could not fix the locked balance of %s for token %s: %v", a.Address.Hex(), token.Hex(), err)
+				continue
+			}
+
+			PublishBalances(s.accountDao, a.Address)
+		}
+	}
+
+	return discrepancies, nil
+}
+
+// withReservations replaces the open orders whose quantity is reserved by a firm quote
+// with their reserved state, the orders completely reserved are not in the orderbook
+func withReservations(orders []*types.Order, reserved map[common.Hash]*types.Order) []*types.Order {
+	if len(reserved) == 0 {
+		return orders
+	}
+
+	res := []*types.Order{}
+	for _, o := range orders {
+		if r, ok := reserved[o.Hash]; ok {
+			if r.Status == "FILLED" {
+				continue
+			}
+
+			o = r
+		}
+
+		res = append(res, o)
+	}
+
+	return res
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"log"
+
+	"github.com/Proofsuite/amp-matching-engine/engine"
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// DryRunResult is the outcome of an order matched against the current orderbook without
+// being placed. FillStatus is NOMATCH, PARTIAL, FULL, CANCELLED (by the self-trade
+// prevention) or REJECTED (crossing the orderbook of a pair that is not launched).
+type DryRunResult struct {
+	Order           *types.Order   `json:"order"`
+	FillStatus      string         `json:"fillStatus"`
+	Trades          []*types.Trade `json:"trades"`
+	Matches         []*DryRunMatch `json:"matches"`
+	CancelledOrders []*types.Order `json:"cancelledOrders,omitempty"`
+	SelfTradeOrder  *types.Order   `json:"selfTradeOrder,omitempty"`
+	RemainingAmount string         `json:"remainingAmount"`
+}
+
+// DryRunMatch is an order of the orderbook the order would be matched against, in its
+// state after the match, and the matched amount
+type DryRunMatch struct {
+	Order  *types.Order `json:"order"`
+	Amount string       `json:"amount"`
+}
+
+var dryRunFillStatuses = map[engine.FillStatus]string{
+	engine.NOMATCH:   "NOMATCH",
+	engine.PARTIAL:   "PARTIAL",
+	engine.FULL:      "FULL",
+	engine.CANCELLED: "CANCELLED",
+	engine.ERROR:     "REJECTED",
+}
+
+// DryRunOrder runs the checks of a new order (signature, account, limits, fees and
+// balances) and reports the trades it would make against the orderbook right now. No
+// balance is locked, the order is neither stored nor sent to the engine and the order
+// rate of the account is not counted.
+func (s *OrderService) DryRunOrder(o *types.Order) (*DryRunResult, error) {
+	if err := s.checkOrder(o, 0, true); err != nil {
+		return nil, err
+	}
+
+	res, err := s.engine.DryRunOrder(o)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	result := &DryRunResult{
+		Order:           res.Order,
+		FillStatus:      dryRunFillStatuses[res.FillStatus],
+		Trades:          res.Trades,
+		Matches:         []*DryRunMatch{},
+		CancelledOrders: res.CancelledOrders,
+		SelfTradeOrder:  res.SelfTradeOrder,
+		RemainingAmount: "0",
+	}
+
+	for _, m := range res.MatchingOrders {
+		result.Matches = append(result.Matches, &DryRunMatch{Order: m.Order, Amount: m.Amount.String()})
+	}
+
+	if res.FillStatus == engine.NOMATCH {
+		result.RemainingAmount = o.Amount.String()
+	} else if res.RemainingOrder != nil && res.RemainingOrder.Amount != nil {
+		result.RemainingAmount = res.RemainingOrder.Amount.String()
+	}
+
+	return result, nil
+}
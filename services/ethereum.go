@@ -2,22 +2,48 @@ package services
 
 import (
 	"context"
+	"encoding/binary"
 	"math/big"
+	"strings"
 
+	"github.com/Proofsuite/amp-matching-engine/utils/breaker"
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// EthereumClient is the ethereum node client used by the services. It is implemented by
+// ethclient.Client and by the multi-provider ethereum.Client.
+type EthereumClient interface {
+	bind.ContractBackend
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*ethTypes.Receipt, error)
+	PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+}
+
 type EthereumService struct {
-	EthereumClient *ethclient.Client
+	EthereumClient EthereumClient
 }
 
-func NewEthereumService(e *ethclient.Client) *EthereumService {
+func NewEthereumService(e EthereumClient) *EthereumService {
 	return &EthereumService{e}
 }
 
+func init() {
+	breaker.Get("ethereum").SetFailureFilter(isNodeUnavailable)
+}
+
+// isNodeUnavailable returns false for the json-rpc errors returned by the node (eg. a
+// reverted call), which must not open the circuit breaker
+func isNodeUnavailable(err error) bool {
+	_, ok := err.(interface {
+		ErrorCode() int
+	})
+
+	return !ok
+}
+
 func (s *EthereumService) WaitMined(tx *ethTypes.Transaction) (*ethTypes.Receipt, error) {
 	ctx := context.Background()
 	receipt, err := bind.WaitMined(ctx, s.EthereumClient, tx)
@@ -30,11 +56,111 @@ func (s *EthereumService) WaitMined(tx *ethTypes.Transaction) (*ethTypes.Receipt
 }
 
 func (s *EthereumService) GetPendingBalanceAt(a common.Address) (*big.Int, error) {
-	ctx := context.Background()
-	balance, err := s.EthereumClient.PendingBalanceAt(ctx, a)
+	var balance *big.Int
+	err := breaker.Get("ethereum").Call(func(ctx context.Context) (err error) {
+		balance, err = s.EthereumClient.PendingBalanceAt(ctx, a)
+		return
+	})
+
 	if err != nil {
 		return nil, err
 	}
 
 	return balance, nil
 }
+
+// GetRevertReason replays a reverted transaction with eth_call and returns the revert
+// reason returned by the contract, if any. The call is replayed on the latest state,
+// so the reason might differ from the original one if the state changed in between.
+func (s *EthereumService) GetRevertReason(tx *ethTypes.Transaction) (string, error) {
+	from, err := ethTypes.Sender(ethTypes.NewEIP155Signer(tx.ChainId()), tx)
+	if err != nil {
+		return "", err
+	}
+
+	msg := ethereum.CallMsg{
+		From:     from,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}
+
+	var res []byte
+	err = breaker.Get("ethereum").Call(func(ctx context.Context) (err error) {
+		res, err = s.EthereumClient.CallContract(ctx, msg, nil)
+		return
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return decodeRevertReason(res), nil
+}
+
+// SimulationResult is the result of a transaction simulated against the latest state.
+// Output is the data returned by the call and Gas the estimated gas of the transaction.
+type SimulationResult struct {
+	Output   []byte
+	Gas      uint64
+	Reverted bool
+	Reason   string
+}
+
+// Simulate runs a transaction with eth_call and estimates its gas without broadcasting it.
+// The result is marked as reverted if the call reverts or if the gas can not be estimated,
+// which nodes report when the transaction always fails.
+func (s *EthereumService) Simulate(msg ethereum.CallMsg) (*SimulationResult, error) {
+	var output []byte
+	err := breaker.Get("ethereum").Call(func(ctx context.Context) (err error) {
+		output, err = s.EthereumClient.CallContract(ctx, msg, nil)
+		return
+	})
+
+	if err != nil {
+		// recent nodes return an error when the call reverts
+		if strings.Contains(err.Error(), "revert") {
+			return &SimulationResult{Reverted: true, Reason: err.Error()}, nil
+		}
+
+		return nil, err
+	}
+
+	if reason := decodeRevertReason(output); reason != "" {
+		return &SimulationResult{Output: output, Reverted: true, Reason: reason}, nil
+	}
+
+	var gas uint64
+	err = breaker.Get("ethereum").Call(func(ctx context.Context) (err error) {
+		gas, err = s.EthereumClient.EstimateGas(ctx, msg)
+		return
+	})
+
+	if err == breaker.ErrOpen || err == breaker.ErrTimeout {
+		return nil, err
+	}
+
+	if err != nil {
+		return &SimulationResult{Output: output, Reverted: true, Reason: err.Error()}, nil
+	}
+
+	return &SimulationResult{Output: output, Gas: gas}, nil
+}
+
+// decodeRevertReason decodes the return data of a call that reverted with a reason
+// string, ie. the ABI encoding of Error(string)
+func decodeRevertReason(data []byte) string {
+	selector := []byte{0x08, 0xc3, 0x79, 0xa0}
+	if len(data) < 4+32+32 || string(data[:4]) != string(selector) {
+		return ""
+	}
+
+	length := binary.BigEndian.Uint64(data[4+32+24 : 4+32+32])
+	if uint64(len(data)) < 4+32+32+length {
+		return ""
+	}
+
+	return string(data[4+32+32 : 4+32+32+length])
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"log"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/engine"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// GetJournal fetches the journal of an order: the decisions made by the order service and
+// the engine about the order, oldest first
+func (s *OrderService) GetJournal(hash common.Hash) ([]*types.JournalEntry, error) {
+	return s.journalDao.GetByOrderHash(hash)
+}
+
+// journal records the journal entries of orders. The journal is only used for support
+// investigations, a failure to record it does not fail the order.
+func (s *OrderService) journal(entries ...*types.JournalEntry) {
+	err := s.journalDao.Create(entries...)
+	if err != nil {
+		log.Printf("Could not record the order journal: %v", err)
+	}
+}
+
+// journalRejected records the rejection of an order by the order service
+func (s *OrderService) journalRejected(o *types.Order, err error) {
+	s.journal(newJournalEntry(o.Hash, types.JournalRejected, map[string]interface{}{"reason": err.Error()}))
+}
+
+// journalEngineResponse records the decisions of the engine in a response: the makers
+// matched by the order, the orders cancelled by the self-trade prevention and the
+// rejections of the quote throttling
+func (s *OrderService) journalEngineResponse(res *engine.Response) {
+	o := res.Order
+	entries := []*types.JournalEntry{}
+
+	switch res.FillStatus {
+	case engine.ERROR:
+		entries = append(entries, newJournalEntry(o.Hash, types.JournalError, nil))
+	case engine.REJECTED:
+		entries = append(entries, newJournalEntry(o.Hash, types.JournalRejected, map[string]interface{}{"reason": res.Error}))
+	case engine.NOMATCH:
+		entries = append(entries, newJournalEntry(o.Hash, types.JournalAdded, map[string]interface{}{
+			"amount":     o.Amount.String(),
+			"pricepoint": o.PricePoint.String(),
+		}))
+	case engine.CANCELLED:
+		if res.SelfTradeOrder == nil {
+			entries = append(entries, newJournalEntry(o.Hash, types.JournalCancelled, nil))
+		}
+	case engine.FULL, engine.PARTIAL:
+		makers := []map[string]interface{}{}
+		for _, m := range res.MatchingOrders {
+			makers = append(makers, map[string]interface{}{
+				"orderHash":  m.Order.Hash.Hex(),
+				"maker":      m.Order.UserAddress.Hex(),
+				"amount":     m.Amount.String(),
+				"pricepoint": m.Order.PricePoint.String(),
+			})
+
+			entries = append(entries, newJournalEntry(m.Order.Hash, types.JournalMatchedAsMaker, map[string]interface{}{
+				"takerOrderHash": o.Hash.Hex(),
+				"amount":         m.Amount.String(),
+				"status":         m.Order.Status,
+			}))
+		}
+
+		entries = append(entries, newJournalEntry(o.Hash, types.JournalMatched, map[string]interface{}{
+			"filledAmount": o.FilledAmount.String(),
+			"status":       o.Status,
+			"makers":       makers,
+		}))
+	}
+
+	if res.SelfTradeOrder != nil {
+		entries = append(entries, newJournalEntry(o.Hash, types.JournalSelfTradeCancelled, map[string]interface{}{
+			"mode":           app.Config.SelfTradePrevention,
+			"makerOrderHash": res.SelfTradeOrder.Hash.Hex(),
+		}))
+	}
+
+	for _, c := range res.CancelledOrders {
+		entries = append(entries, newJournalEntry(c.Hash, types.JournalSelfTradeCancelled, map[string]interface{}{
+			"mode":           app.Config.SelfTradePrevention,
+			"takerOrderHash": o.Hash.Hex(),
+		}))
+	}
+
+	s.journal(entries...)
+}
+
+func newJournalEntry(hash common.Hash, event string, details map[string]interface{}) *types.JournalEntry {
+	return &types.JournalEntry{OrderHash: hash.Hex(), Event: event, Details: details}
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// pipelineLatencies are the latency histograms of the orders broadcast by this process
+var pipelineLatencies = types.NewLatencyHistograms()
+
+// GetPipelineLatencies returns the latency histograms of the stages of the order pipeline
+func GetPipelineLatencies() []*types.LatencyHistogram {
+	return pipelineLatencies.Get()
+}
+
+// latencyBreakdown returns true if the latency breakdown of the orders is sent to the
+// clients in the ORDER_ADDED messages
+func latencyBreakdown() bool {
+	return app.Config.LatencyBreakdown
+}
+
+// broadcastOrder returns the order sent to the client once the engine response is
+// handled, and records its latencies. The timings are replaced by the latency breakdown
+// when enabled, they are removed otherwise.
+func broadcastOrder(o *types.Order, breakdown bool) *types.Order {
+	o.Stamp(types.StageBroadcast, time.Now())
+	pipelineLatencies.Observe(o)
+
+	sent := *o
+	sent.Timings = nil
+	if breakdown {
+		sent.Latency = o.Latencies()
+	}
+
+	return &sent
+}
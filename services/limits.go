@@ -0,0 +1,71 @@
+package services
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AccountTiers returns the account tiers of the configuration
+func AccountTiers() []types.AccountLimits {
+	tiers := []types.AccountLimits{}
+	for _, c := range app.Config.AccountTiers {
+		tier := types.AccountLimits{
+			Tier:            c.Tag,
+			OrdersPerMinute: c.OrdersPerMinute,
+			MaxOpenOrders:   c.MaxOpenOrders,
+		}
+
+		if c.MakeFee != nil {
+			tier.MakeFee = big.NewInt(*c.MakeFee)
+		}
+
+		if c.TakeFee != nil {
+			tier.TakeFee = big.NewInt(*c.TakeFee)
+		}
+
+		tiers = append(tiers, tier)
+	}
+
+	return tiers
+}
+
+// orderRateLimiter counts the orders placed by each account over the last minute
+type orderRateLimiter struct {
+	orders map[common.Address][]time.Time
+	mutex  sync.Mutex
+}
+
+func newOrderRateLimiter() *orderRateLimiter {
+	return &orderRateLimiter{orders: make(map[common.Address][]time.Time)}
+}
+
+// allow records an order of an account placed at t, unless the account already placed
+// limit orders during the minute before t. Accounts are not limited if limit is 0.
+func (l *orderRateLimiter) allow(addr common.Address, limit int, t time.Time) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	recent := []time.Time{}
+	for _, placed := range l.orders[addr] {
+		if t.Sub(placed) < time.Minute {
+			recent = append(recent, placed)
+		}
+	}
+
+	if len(recent) >= limit {
+		l.orders[addr] = recent
+		return false
+	}
+
+	l.orders[addr] = append(recent, t)
+	return true
+}
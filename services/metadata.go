@@ -0,0 +1,218 @@
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var metadata = newMetadataCache()
+
+// metadataCache is a read-through cache of the pairs and tokens, which are read on each
+// order submission but rarely change. It is cleared on each catalog change published on
+// the markets channel (see publishMarketEvent) and its entries expire after
+// metadata_cache_ttl seconds, so that the changes made by other replicas are picked up.
+// mutex protects the pairs and tokens maps and the generation, which is incremented on
+// each invalidation so that the values read from the database before an invalidation
+// are not cached after it
+type metadataCache struct {
+	hits       uint64
+	misses     uint64
+	pairs      map[string]cachedPair
+	tokens     map[common.Address]cachedToken
+	generation uint64
+	mutex      sync.RWMutex
+}
+
+type cachedPair struct {
+	pair    types.Pair
+	expires time.Time
+}
+
+type cachedToken struct {
+	token   types.Token
+	expires time.Time
+}
+
+// MetadataCacheStats are the lookups of the pairs and tokens served by the cache (hits)
+// and by the database (misses)
+type MetadataCacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+func newMetadataCache() *metadataCache {
+	return &metadataCache{
+		pairs:  make(map[string]cachedPair),
+		tokens: make(map[common.Address]cachedToken),
+	}
+}
+
+// GetMetadataCacheStats returns the hits and misses of the pair and token cache
+func GetMetadataCacheStats() MetadataCacheStats {
+	return MetadataCacheStats{
+		Hits:   atomic.LoadUint64(&metadata.hits),
+		Misses: atomic.LoadUint64(&metadata.misses),
+	}
+}
+
+func metadataCacheTTL() time.Duration {
+	return time.Duration(app.Config.MetadataCacheTTL) * time.Second
+}
+
+// pair returns the cached pair of the base and quote token. The pairs are copied so that
+// the callers can not modify the cached values.
+func (c *metadataCache) pair(bt, qt common.Address, now time.Time) (*types.Pair, bool) {
+	c.mutex.RLock()
+	e, ok := c.pairs[utils.GetPairKey(bt, qt)]
+	c.mutex.RUnlock()
+
+	if !ok || !now.Before(e.expires) {
+		return nil, false
+	}
+
+	p := e.pair
+	return &p, true
+}
+
+// current returns the generation of the cache before a database read
+func (c *metadataCache) current() uint64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.generation
+}
+
+func (c *metadataCache) setPair(p *types.Pair, generation uint64, now time.Time) {
+	ttl := metadataCacheTTL()
+	if ttl <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	c.pairs[utils.GetPairKey(p.BaseTokenAddress, p.QuoteTokenAddress)] = cachedPair{*p, now.Add(ttl)}
+}
+
+func (c *metadataCache) token(addr common.Address, now time.Time) (*types.Token, bool) {
+	c.mutex.RLock()
+	e, ok := c.tokens[addr]
+	c.mutex.RUnlock()
+
+	if !ok || !now.Before(e.expires) {
+		return nil, false
+	}
+
+	t := e.token
+	return &t, true
+}
+
+func (c *metadataCache) setToken(t *types.Token, generation uint64, now time.Time) {
+	ttl := metadataCacheTTL()
+	if ttl <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	c.tokens[t.ContractAddress] = cachedToken{*t, now.Add(ttl)}
+}
+
+// invalidate removes all the cached pairs and tokens
+func (c *metadataCache) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.pairs = make(map[string]cachedPair)
+	c.tokens = make(map[common.Address]cachedToken)
+	c.generation++
+}
+
+func (c *metadataCache) hit() {
+	atomic.AddUint64(&c.hits, 1)
+}
+
+func (c *metadataCache) miss() {
+	atomic.AddUint64(&c.misses, 1)
+}
+
+// getPair returns the pair of the base and quote token from the cache, or from the
+// database if it is not cached. The pairs that are not found are not cached.
+func getPair(pairDao *daos.PairDao, bt, qt common.Address) (*types.Pair, error) {
+	now := time.Now()
+	if p, ok := metadata.pair(bt, qt, now); ok {
+		metadata.hit()
+		return p, nil
+	}
+
+	metadata.miss()
+	generation := metadata.current()
+	p, err := pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata.setPair(p, generation, now)
+	return p, nil
+}
+
+// getPairByBuySellToken returns the pair made of the buy and sell token of an order,
+// whichever of them is the base token
+func getPairByBuySellToken(pairDao *daos.PairDao, buyToken, sellToken common.Address) (*types.Pair, error) {
+	now := time.Now()
+	if p, ok := metadata.pair(buyToken, sellToken, now); ok {
+		metadata.hit()
+		return p, nil
+	}
+
+	if p, ok := metadata.pair(sellToken, buyToken, now); ok {
+		metadata.hit()
+		return p, nil
+	}
+
+	metadata.miss()
+	generation := metadata.current()
+	p, err := pairDao.GetByBuySellTokenAddress(buyToken, sellToken)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata.setPair(p, generation, now)
+	return p, nil
+}
+
+// getToken returns the token of the contract address from the cache, or from the
+// database if it is not cached. It returns nil if the token does not exist.
+func getToken(tokenDao *daos.TokenDao, addr common.Address) (*types.Token, error) {
+	now := time.Now()
+	if t, ok := metadata.token(addr, now); ok {
+		metadata.hit()
+		return t, nil
+	}
+
+	metadata.miss()
+	generation := metadata.current()
+	t, err := tokenDao.GetByAddress(addr)
+	if err != nil || t == nil {
+		return t, err
+	}
+
+	metadata.setToken(t, generation, now)
+	return t, nil
+}
@@ -0,0 +1,504 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// Conditions of the nonces of an operator account
+const (
+	NonceOK        = "OK"
+	NonceGap       = "GAP"
+	NonceDuplicate = "DUPLICATE"
+)
+
+// noopGasLimit is the gas limit of the no-op transactions filling the nonce gaps
+const noopGasLimit = 21000
+
+// NonceClient is the part of the ethereum client used by the nonce manager
+type NonceClient interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SendTransaction(ctx context.Context, tx *ethTypes.Transaction) error
+}
+
+// GasBumpPolicy is the re-pricing of the stuck transactions: a transaction that is not
+// mined after Timeout is replaced by the same transaction with a gas price raised by
+// Percent, or the suggested gas price if it is higher, up to MaxGasPrice (unlimited if
+// nil). The nodes only accept a replacement raising the gas price by 10% or more.
+type GasBumpPolicy struct {
+	Timeout     time.Duration
+	Percent     int64
+	MaxGasPrice *big.Int
+}
+
+// NonceStatus is the result of the last nonce check of an operator account. Next is the
+// next nonce assigned locally, Pending and Mined are the transaction counts of the account
+// in the pending state and in the latest block. Gaps are the nonces below Next that the
+// node does not know, they block the transactions sent with the next nonces. Bumps is the
+// number of stuck transactions replaced with a higher gas price.
+type NonceStatus struct {
+	Address   common.Address `json:"address"`
+	Next      uint64         `json:"next"`
+	Pending   uint64         `json:"pending"`
+	Mined     uint64         `json:"mined"`
+	Gaps      []uint64       `json:"gaps"`
+	Condition string         `json:"condition"`
+	Repairs   int            `json:"repairs"`
+	Bumps     int            `json:"bumps"`
+	CheckedAt time.Time      `json:"checkedAt"`
+}
+
+// accountNonces is the local nonce tracking of an account. sent holds the signed
+// transactions that are not mined yet, so that they can be broadcasted again.
+type accountNonces struct {
+	next    uint64
+	sent    map[uint64]*types.PendingTx
+	repairs int
+	bumps   int
+	status  *NonceStatus
+}
+
+// NonceManager assigns the nonces of the transactions sent by the operator accounts
+// instead of relying on the pending nonce of the node, so that several transactions can
+// be in flight. The local nonces are checked against eth_getTransactionCount: nonces the
+// node lost (eg. after a crash of the node) are repaired by broadcasting the transactions
+// again or no-op transactions, and nonces used outside of the manager are skipped.
+// The transactions that are not mined yet are stored so that the nonces of an account
+// are restored without gaps after a restart, and the stuck ones are re-priced according
+// to the gas bump policy.
+type NonceManager struct {
+	client       NonceClient
+	sign         func(common.Address, *ethTypes.Transaction) (*ethTypes.Transaction, error)
+	pendingTxDao *daos.PendingTxDao
+	policy       GasBumpPolicy
+	replaced     []func(old, new *ethTypes.Transaction)
+	accounts     map[common.Address]*accountNonces
+	mutex        sync.Mutex
+}
+
+// currentNonceManager is the nonce manager running in this process, if any
+var currentNonceManager *NonceManager
+var currentNonceManagerMutex sync.Mutex
+
+// NewNonceManager returns a nonce manager. sign signs the no-op transactions filling the
+// nonce gaps of an account and the re-priced transactions. The pending transactions are
+// only kept in memory if pendingTxDao is nil.
+func NewNonceManager(
+	client NonceClient,
+	sign func(common.Address, *ethTypes.Transaction) (*ethTypes.Transaction, error),
+	pendingTxDao *daos.PendingTxDao,
+) *NonceManager {
+	return &NonceManager{
+		client:       client,
+		sign:         sign,
+		pendingTxDao: pendingTxDao,
+		accounts:     make(map[common.Address]*accountNonces),
+	}
+}
+
+// SetGasBumpPolicy sets the re-pricing of the stuck transactions. The transactions are
+// never re-priced with a zero timeout, which is the default.
+func (m *NonceManager) SetGasBumpPolicy(policy GasBumpPolicy) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.policy = policy
+}
+
+// OnReplaced registers a function called when a stuck transaction is replaced with a
+// higher gas price, since the replacement has a different hash
+func (m *NonceManager) OnReplaced(fn func(old, new *ethTypes.Transaction)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.replaced = append(m.replaced, fn)
+}
+
+// Start checks the nonces of the accounts every interval. The manager becomes the one
+// reported by GetNonceStatuses.
+func (m *NonceManager) Start(interval time.Duration) {
+	currentNonceManagerMutex.Lock()
+	currentNonceManager = m
+	currentNonceManagerMutex.Unlock()
+
+	go func() {
+		for {
+			time.Sleep(interval)
+
+			for _, a := range m.addresses() {
+				_, err := m.Check(a)
+				if err != nil {
+					log.Printf("Could not check the nonces of %s: %v", a.Hex(), err)
+				}
+			}
+		}
+	}()
+}
+
+// Send calls fn with the next nonce of an account. The nonce is released if fn fails
+// and the transaction is kept until it is mined otherwise.
+func (m *NonceManager) Send(from common.Address, fn func(nonce *big.Int) (*ethTypes.Transaction, error)) (*ethTypes.Transaction, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, err := m.account(from)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := a.next
+	tx, err := fn(new(big.Int).SetUint64(nonce))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	m.save(a, &types.PendingTx{From: from, Nonce: nonce, Tx: tx, SentAt: now, CreatedAt: now})
+	a.next++
+	return tx, nil
+}
+
+// save keeps a transaction until it is mined. It is called with the mutex held.
+func (m *NonceManager) save(a *accountNonces, p *types.PendingTx) {
+	a.sent[p.Nonce] = p
+	if m.pendingTxDao == nil {
+		return
+	}
+
+	// the transaction is already broadcasted, it is only lost on a restart
+	err := m.pendingTxDao.Save(p)
+	if err != nil {
+		log.Printf("Could not save pending transaction %s: %v", p.Tx.Hash().Hex(), err)
+	}
+}
+
+// account returns the local nonces of an account, initialized with the pending nonce of
+// the node and the stored pending transactions. The next nonce follows the stored
+// transactions if the node lost them, the gaps are repaired by the next check. It is
+// called with the mutex held.
+func (m *NonceManager) account(from common.Address) (*accountNonces, error) {
+	a := m.accounts[from]
+	if a != nil {
+		return a, nil
+	}
+
+	pending, err := m.client.PendingNonceAt(context.Background(), from)
+	if err != nil {
+		return nil, err
+	}
+
+	a = &accountNonces{next: pending, sent: make(map[uint64]*types.PendingTx)}
+	if m.pendingTxDao != nil {
+		stored, err := m.pendingTxDao.GetByAccount(from)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range stored {
+			a.sent[p.Nonce] = p
+			if p.Nonce >= a.next {
+				a.next = p.Nonce + 1
+			}
+		}
+	}
+
+	m.accounts[from] = a
+	return a, nil
+}
+
+func (m *NonceManager) addresses() []common.Address {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	addresses := []common.Address{}
+	for a := range m.accounts {
+		addresses = append(addresses, a)
+	}
+
+	return addresses
+}
+
+// Check compares the local nonces of an account with the transaction counts of the node
+// and repairs them:
+// - if the node is ahead, the nonces were used by transactions sent outside of the
+// manager and the local nonce is resynced with the node
+// - if the node lost transactions, the nonce gaps are filled by broadcasting the kept
+// transactions again, or no-op transactions for the nonces whose transaction is not
+// kept. When no transaction was sent after the gaps, the local nonce is resynced instead.
+// The transactions pending for longer than the timeout of the gas bump policy are then
+// replaced with a higher gas price.
+func (m *NonceManager) Check(from common.Address) (*NonceStatus, error) {
+	status, replaced, err := m.check(from)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	listeners := m.replaced
+	m.mutex.Unlock()
+
+	for _, r := range replaced {
+		for _, fn := range listeners {
+			fn(r[0], r[1])
+		}
+	}
+
+	return status, nil
+}
+
+// check runs the nonce check of an account and returns the replaced transactions, the
+// listeners are called by Check once the mutex is released
+func (m *NonceManager) check(from common.Address) (*NonceStatus, [][2]*ethTypes.Transaction, error) {
+	ctx := context.Background()
+
+	mined, err := m.client.NonceAt(ctx, from, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pending, err := m.client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, err := m.account(from)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for n := range a.sent {
+		if n < mined {
+			delete(a.sent, n)
+		}
+	}
+
+	if m.pendingTxDao != nil {
+		err = m.pendingTxDao.DeleteBelow(from, mined)
+		if err != nil {
+			log.Printf("Could not delete the mined transactions of %s: %v", from.Hex(), err)
+		}
+	}
+
+	status := &NonceStatus{
+		Address:   from,
+		Next:      a.next,
+		Pending:   pending,
+		Mined:     mined,
+		Gaps:      []uint64{},
+		Condition: NonceOK,
+		CheckedAt: time.Now(),
+	}
+
+	switch {
+	case pending > a.next:
+		status.Condition = NonceDuplicate
+		log.Printf("Nonces %d to %d of %s were used outside of the nonce manager, resyncing", a.next, pending-1, from.Hex())
+
+		a.next = pending
+		a.repairs++
+	case pending < a.next:
+		status.Condition = NonceGap
+		for n := pending; n < a.next; n++ {
+			status.Gaps = append(status.Gaps, n)
+		}
+
+		log.Printf("Nonces %v of %s are unknown to the node, repairing", status.Gaps, from.Hex())
+		m.repair(a, from, pending)
+		a.repairs++
+	}
+
+	replaced := m.bump(a, from, mined)
+
+	status.Repairs = a.repairs
+	status.Bumps = a.bumps
+	a.status = status
+	return status, replaced, nil
+}
+
+// bump replaces the transactions of an account that are pending for longer than the
+// timeout of the gas bump policy. It is called with the mutex held.
+func (m *NonceManager) bump(a *accountNonces, from common.Address, mined uint64) [][2]*ethTypes.Transaction {
+	replaced := [][2]*ethTypes.Transaction{}
+	if m.policy.Timeout == 0 {
+		return replaced
+	}
+
+	nonces := []uint64{}
+	for n, p := range a.sent {
+		if n >= mined && time.Since(p.SentAt) > m.policy.Timeout {
+			nonces = append(nonces, n)
+		}
+	}
+
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+
+	for _, n := range nonces {
+		p := a.sent[n]
+		tx, err := m.reprice(from, p.Tx)
+		if err != nil {
+			log.Printf("Could not re-price transaction %s with nonce %d: %v", p.Tx.Hash().Hex(), n, err)
+			continue
+		}
+
+		if tx == nil {
+			continue
+		}
+
+		err = m.client.SendTransaction(context.Background(), tx)
+		if err != nil {
+			log.Printf("Could not broadcast the replacement of transaction %s with nonce %d: %v", p.Tx.Hash().Hex(), n, err)
+			continue
+		}
+
+		log.Printf("Replaced transaction %s with nonce %d by %s (gas price %v)", p.Tx.Hash().Hex(), n, tx.Hash().Hex(), tx.GasPrice())
+
+		old := p.Tx
+		m.save(a, &types.PendingTx{
+			ID:        p.ID,
+			From:      from,
+			Nonce:     n,
+			Tx:        tx,
+			Bumps:     p.Bumps + 1,
+			SentAt:    time.Now(),
+			CreatedAt: p.CreatedAt,
+		})
+
+		a.bumps++
+		replaced = append(replaced, [2]*ethTypes.Transaction{old, tx})
+	}
+
+	return replaced
+}
+
+// reprice returns a signed copy of a transaction with the gas price raised according
+// to the gas bump policy, or nil if the gas price already is the maximum gas price
+func (m *NonceManager) reprice(from common.Address, tx *ethTypes.Transaction) (*ethTypes.Transaction, error) {
+	price := new(big.Int).Mul(tx.GasPrice(), big.NewInt(100+m.policy.Percent))
+	price.Div(price, big.NewInt(100))
+
+	suggested, err := m.client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if suggested.Cmp(price) > 0 {
+		price = suggested
+	}
+
+	max := m.policy.MaxGasPrice
+	if max != nil && max.Sign() > 0 && price.Cmp(max) > 0 {
+		if tx.GasPrice().Cmp(max) >= 0 {
+			return nil, nil
+		}
+
+		price = max
+	}
+
+	var unsigned *ethTypes.Transaction
+	if tx.To() == nil {
+		unsigned = ethTypes.NewContractCreation(tx.Nonce(), tx.Value(), tx.Gas(), price, tx.Data())
+	} else {
+		unsigned = ethTypes.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), price, tx.Data())
+	}
+
+	return m.sign(from, unsigned)
+}
+
+// repair fills the nonce gaps of an account from the pending nonce of the node. It is
+// called with the mutex held.
+func (m *NonceManager) repair(a *accountNonces, from common.Address, pending uint64) {
+	last := uint64(0)
+	found := false
+	for n := range a.sent {
+		if n >= pending && (!found || n > last) {
+			last = n
+			found = true
+		}
+	}
+
+	// no transaction was sent after the gaps, they are reused by the next transactions
+	if !found {
+		a.next = pending
+		return
+	}
+
+	ctx := context.Background()
+	for n := pending; n <= last; n++ {
+		p := a.sent[n]
+		if p == nil {
+			noop, err := m.noop(from, n)
+			if err != nil {
+				log.Printf("Could not create a no-op transaction with nonce %d: %v", n, err)
+				return
+			}
+
+			now := time.Now()
+			p = &types.PendingTx{From: from, Nonce: n, Tx: noop, SentAt: now, CreatedAt: now}
+			m.save(a, p)
+		}
+
+		tx := p.Tx
+
+		// the transactions queued by the node are rejected as known transactions
+		err := m.client.SendTransaction(ctx, tx)
+		if err != nil {
+			log.Printf("Could not broadcast transaction %s with nonce %d: %v", tx.Hash().Hex(), n, err)
+		}
+	}
+
+	a.next = last + 1
+}
+
+// noop returns a signed transaction sending no ether to the account itself
+func (m *NonceManager) noop(from common.Address, nonce uint64) (*ethTypes.Transaction, error) {
+	gasPrice, err := m.client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	tx := ethTypes.NewTransaction(nonce, from, big.NewInt(0), noopGasLimit, gasPrice, nil)
+	return m.sign(from, tx)
+}
+
+// Statuses returns the result of the last check of every account
+func (m *NonceManager) Statuses() []*NonceStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	statuses := []*NonceStatus{}
+	for _, a := range m.accounts {
+		if a.status != nil {
+			statuses = append(statuses, a.status)
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Address.Hex() < statuses[j].Address.Hex() })
+	return statuses
+}
+
+// GetNonceStatuses returns the last nonce statuses of the operator accounts, or nil if the
+// nonces are not managed by this process
+func GetNonceStatuses() []*NonceStatus {
+	currentNonceManagerMutex.Lock()
+	defer currentNonceManagerMutex.Unlock()
+
+	if currentNonceManager == nil {
+		return nil
+	}
+
+	return currentNonceManager.Statuses()
+}
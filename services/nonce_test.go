@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/dbtest"
+)
+
+var server dbtest.DBServer
+
+func init() {
+	temp, _ := ioutil.TempDir("", "test")
+	server.SetPath(temp)
+
+	session := server.Session()
+	app.Config.DSN = session.LiveServers()[0]
+	app.Config.DBName = "proofdextest"
+	if _, err := daos.InitSession(); err != nil {
+		panic(err)
+	}
+}
+
+// nonceClient is a node whose transaction counts are set by the tests
+type nonceClient struct {
+	pending uint64
+	mined   uint64
+	sent    []*ethTypes.Transaction
+	mutex   sync.Mutex
+}
+
+func (c *nonceClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.pending, nil
+}
+
+func (c *nonceClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.mined, nil
+}
+
+func (c *nonceClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1e9), nil
+}
+
+func (c *nonceClient) SendTransaction(ctx context.Context, tx *ethTypes.Transaction) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.sent = append(c.sent, tx)
+	return nil
+}
+
+func (c *nonceClient) sentNonces() []uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	nonces := []uint64{}
+	for _, tx := range c.sent {
+		nonces = append(nonces, tx.Nonce())
+	}
+
+	return nonces
+}
+
+func signNoopTx(from common.Address, tx *ethTypes.Transaction) (*ethTypes.Transaction, error) {
+	return tx, nil
+}
+
+func sendNonceTx(t *testing.T, m *NonceManager, from common.Address) uint64 {
+	to := common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47")
+	tx, err := m.Send(from, func(nonce *big.Int) (*ethTypes.Transaction, error) {
+		return ethTypes.NewTransaction(nonce.Uint64(), to, big.NewInt(0), 21000, big.NewInt(1e9), nil), nil
+	})
+
+	if err != nil {
+		t.Errorf("Could not send transaction: %v", err)
+		return 0
+	}
+
+	return tx.Nonce()
+}
+
+func TestNonceManagerRestart(t *testing.T) {
+	from := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	dao := daos.NewPendingTxDao()
+	client := &nonceClient{pending: 3, mined: 3}
+
+	m := NewNonceManager(client, signNoopTx, dao)
+	for i := uint64(0); i < 3; i++ {
+		assert.Equal(t, 3+i, sendNonceTx(t, m, from))
+	}
+
+	// the nonce of a transaction that could not be sent is released
+	_, err := m.Send(from, func(nonce *big.Int) (*ethTypes.Transaction, error) {
+		return nil, errors.New("rejected")
+	})
+
+	assert.NotNil(t, err)
+
+	// the node lost the transactions while the manager was restarted, the next nonce
+	// follows the stored transactions
+	restarted := NewNonceManager(client, signNoopTx, dao)
+	assert.Equal(t, uint64(6), sendNonceTx(t, restarted, from))
+
+	status, err := restarted.Check(from)
+	if err != nil {
+		t.Fatalf("Could not check nonces: %v", err)
+	}
+
+	assert.Equal(t, NonceGap, status.Condition)
+	assert.Equal(t, []uint64{3, 4, 5, 6}, status.Gaps)
+	assert.Equal(t, []uint64{3, 4, 5, 6}, client.sentNonces())
+	assert.Equal(t, uint64(7), sendNonceTx(t, restarted, from))
+}
+
+func TestNonceManagerGapRefill(t *testing.T) {
+	from := common.HexToAddress("0x3e9a9c5a0bd8bd9a1d1ae8d7c5b9f5a4c0c1f2ab")
+	client := &nonceClient{}
+
+	m := NewNonceManager(client, signNoopTx, nil)
+	for i := uint64(0); i < 3; i++ {
+		assert.Equal(t, i, sendNonceTx(t, m, from))
+	}
+
+	// the node only knows the first transaction and the transaction of nonce 1 is lost
+	client.pending = 1
+	delete(m.accounts[from].sent, 1)
+
+	status, err := m.Check(from)
+	if err != nil {
+		t.Fatalf("Could not check nonces: %v", err)
+	}
+
+	assert.Equal(t, NonceGap, status.Condition)
+	assert.Equal(t, []uint64{1, 2}, status.Gaps)
+	assert.Equal(t, []uint64{1, 2}, client.sentNonces())
+
+	// the lost nonce is filled with a no-op transaction to the account itself
+	assert.Equal(t, from, *client.sent[0].To())
+	assert.Equal(t, int64(0), client.sent[0].Value().Int64())
+	assert.Equal(t, uint64(3), sendNonceTx(t, m, from))
+
+	// the gaps after the last sent transaction are reused
+	client.pending = 2
+	client.sent = nil
+	m.accounts[from].sent = map[uint64]*types.PendingTx{}
+
+	status, err = m.Check(from)
+	if err != nil {
+		t.Fatalf("Could not check nonces: %v", err)
+	}
+
+	assert.Equal(t, NonceGap, status.Condition)
+	assert.Equal(t, 0, len(client.sentNonces()))
+	assert.Equal(t, uint64(2), sendNonceTx(t, m, from))
+}
+
+func TestNonceManagerConcurrentSend(t *testing.T) {
+	from := common.HexToAddress("0x5b0e8f5e4e2a8c9d1f6b1d3e7a9c0f2e4b6d8a01")
+	client := &nonceClient{pending: 10}
+	m := NewNonceManager(client, signNoopTx, nil)
+
+	nonces := make([]uint64, 50)
+	wg := sync.WaitGroup{}
+	for i := range nonces {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			nonces[i] = sendNonceTx(t, m, from)
+		}(i)
+	}
+
+	wg.Wait()
+
+	// every transaction gets its own nonce, without gaps
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	for i, n := range nonces {
+		assert.Equal(t, uint64(10+i), n)
+	}
+}
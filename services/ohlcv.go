@@ -2,12 +2,13 @@ package services
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"log"
 	"math"
 	"time"
 
 	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/Proofsuite/amp-matching-engine/ws"
@@ -19,21 +20,34 @@ import (
 
 type OHLCVService struct {
 	tradeDao *daos.TradeDao
+	pairDao  *daos.PairDao
 }
 
-func NewOHLCVService(TradeDao *daos.TradeDao) *OHLCVService {
-	return &OHLCVService{TradeDao}
+func NewOHLCVService(TradeDao *daos.TradeDao, pairDao *daos.PairDao) *OHLCVService {
+	return &OHLCVService{TradeDao, pairDao}
 }
 
+// ohlcvInitChunkSize is the maximum number of ticks sent in an ohlcv INIT message
+const ohlcvInitChunkSize = 500
+
 // UnregisterForTicks handles all the unsubscription messages for ticks corresponding to a pair
 func (s *OHLCVService) Unsubscribe(conn *websocket.Conn, bt, qt common.Address, params *types.Params) {
-	id := utils.GetOHLCVChannelID(bt, qt, params.Units, params.Duration)
-	ws.GetTradeSocket().Unsubscribe(id, conn)
+	for _, i := range subscriptionIntervals(params) {
+		id := utils.GetOHLCVChannelID(bt, qt, i.Units, i.Duration)
+		ws.GetOHLCVSocket().Unsubscribe(id, conn)
+	}
 }
 
 // RegisterForTicks handles all the subscription messages for ticks corresponding to a pair
-// It calls the corresponding channel's subscription method and sends trade history back on the connection
+// It calls the corresponding channel's subscription method and sends trade history back on the connection.
+// The history is sent in chunks followed by an END message, the connection is subscribed
+// to the tick updates once the history is sent.
 func (s *OHLCVService) Subscribe(conn *websocket.Conn, bt, qt common.Address, params *types.Params) {
+	if len(params.Intervals) > 0 {
+		s.subscribeBundle(conn, bt, qt, params)
+		return
+	}
+
 	ohlcv, err := s.GetOHLCV([]types.PairSubDoc{types.PairSubDoc{BaseToken: bt, QuoteToken: qt}},
 		params.Duration,
 		params.Units,
@@ -42,22 +56,83 @@ func (s *OHLCVService) Subscribe(conn *websocket.Conn, bt, qt common.Address, pa
 	)
 
 	if err != nil {
-		ws.SendTradeErrorMessage(conn, err.Error())
+		ws.SendOHLCVErrorMessage(conn, errors.NewWSError(err))
+		return
 	}
 
-	id := utils.GetOHLCVChannelID(bt, qt, params.Units, params.Duration)
-	err = ws.GetTradeSocket().Subscribe(id, conn)
+	if params.Formatted {
+		s.SetFormatted(ohlcv)
+	}
+
+	err = ws.SendOHLCVInitChunks(conn, ohlcv, ohlcvInitChunkSize)
 	if err != nil {
-		message := map[string]string{
-			"Code":    "UNABLE_TO_SUBSCRIBE",
-			"Message": "UNABLE_TO_SUBSCRIBE: " + err.Error(),
+		// the connection is closed when a message can not be written
+		return
+	}
+
+	s.subscribeIntervals(conn, bt, qt, subscriptionIntervals(params))
+}
+
+// subscribeBundle subscribes a connection to several intervals of the ticks of a pair.
+// The histories of the intervals are sent in INIT messages tagged with their interval
+// followed by a single END message, then the connection receives the tick updates of all
+// the intervals.
+func (s *OHLCVService) subscribeBundle(conn *websocket.Conn, bt, qt common.Address, params *types.Params) {
+	intervals := subscriptionIntervals(params)
+	bundle := []ws.OHLCVIntervalTicks{}
+
+	for _, i := range intervals {
+		ohlcv, err := s.GetOHLCV([]types.PairSubDoc{types.PairSubDoc{BaseToken: bt, QuoteToken: qt}},
+			i.Duration,
+			i.Units,
+			params.From,
+			params.To,
+		)
+
+		if err != nil {
+			ws.SendOHLCVErrorMessage(conn, errors.NewWSError(err))
+			return
+		}
+
+		if params.Formatted {
+			s.SetFormatted(ohlcv)
 		}
 
-		ws.SendTradeErrorMessage(conn, message)
+		bundle = append(bundle, ws.OHLCVIntervalTicks{Interval: i.Key(), Ticks: ohlcv})
 	}
 
-	ws.RegisterConnectionUnsubscribeHandler(conn, ws.GetTradeSocket().UnsubscribeHandler(id))
-	ws.SendTradeInitMessage(conn, ohlcv)
+	err := ws.SendOHLCVBundleInitChunks(conn, bundle, ohlcvInitChunkSize)
+	if err != nil {
+		// the connection is closed when a message can not be written
+		return
+	}
+
+	s.subscribeIntervals(conn, bt, qt, intervals)
+}
+
+// subscribeIntervals subscribes a connection to the tick updates of intervals of a pair
+func (s *OHLCVService) subscribeIntervals(conn *websocket.Conn, bt, qt common.Address, intervals []types.OHLCVInterval) {
+	for _, i := range intervals {
+		id := utils.GetOHLCVChannelID(bt, qt, i.Units, i.Duration)
+		err := ws.GetOHLCVSocket().Subscribe(id, conn)
+		if err != nil {
+			log.Print(err)
+			ws.SendOHLCVErrorMessage(conn, errors.NewWSError(errors.UnableToSubscribe.New(nil)))
+			return
+		}
+
+		ws.RegisterConnectionUnsubscribeHandler(conn, ws.GetOHLCVSocket().UnsubscribeHandler(id))
+	}
+}
+
+// subscriptionIntervals returns the intervals of an ohlcv subscription: its intervals if it
+// is bundled, otherwise its duration and units
+func subscriptionIntervals(params *types.Params) []types.OHLCVInterval {
+	if len(params.Intervals) > 0 {
+		return params.Intervals
+	}
+
+	return []types.OHLCVInterval{{Duration: params.Duration, Units: params.Units}}
 }
 
 // GETOHLCV fetches OHLCV data using
@@ -125,7 +200,7 @@ func (s *OHLCVService) GetOHLCV(pairs []types.PairSubDoc, duration int64, unit s
 		modTime = currentTs - int64(math.Mod(float64(currentTs), float64(intervalSeconds)))
 
 	default:
-		return nil, errors.New("Invalid unit")
+		return nil, errors.InvalidUnits.New(errors.Params{"units": unit})
 	}
 
 	lt := time.Unix(modTime, 0)
@@ -173,9 +248,29 @@ func (s *OHLCVService) GetOHLCV(pairs []types.PairSubDoc, duration int64, unit s
 	}
 
 	json.Unmarshal(bytes, &resp)
+
+	pairs := newPairCache(s.pairDao)
+	for _, tick := range resp {
+		bt := common.HexToAddress(tick.ID.BaseToken)
+		qt := common.HexToAddress(tick.ID.QuoteToken)
+		tick.ID.Symbol = pairs.Symbol(bt, qt)
+	}
+
 	return resp, nil
 }
 
+// SetFormatted adds the display representation of the prices and volume to ticks
+func (s *OHLCVService) SetFormatted(ticks []*types.Tick) {
+	pairs := newPairCache(s.pairDao)
+	for _, tick := range ticks {
+		bt := common.HexToAddress(tick.ID.BaseToken)
+		qt := common.HexToAddress(tick.ID.QuoteToken)
+		if p := pairs.Get(bt, qt); p != nil {
+			tick.SetFormatted(p)
+		}
+	}
+}
+
 // query for grouping of the documents and addition of required fields using aggregate pipeline
 func getGroupTsBson(key, units string, duration int64) (resp bson.M, addFields bson.M) {
 	t := time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
@@ -2,7 +2,6 @@ package services
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"math/big"
@@ -15,7 +14,9 @@ import (
 
 	"github.com/Proofsuite/amp-matching-engine/daos"
 	"github.com/Proofsuite/amp-matching-engine/engine"
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 )
 
 // OrderService struct with daos required, responsible for communicating with daos.
@@ -25,12 +26,23 @@ type OrderService struct {
 	pairDao    *daos.PairDao
 	accountDao *daos.AccountDao
 	tradeDao   *daos.TradeDao
-	engine     *engine.Resource
+	journalDao *daos.JournalDao
+	engine     engine.Engine
+	orderRates *orderRateLimiter
+	queue      TradeQueue
+	rfq        *rfqReservations
 }
 
 // NewOrderService returns a new instance of orderservice
-func NewOrderService(orderDao *daos.OrderDao, pairDao *daos.PairDao, accountDao *daos.AccountDao, tradeDao *daos.TradeDao, engine *engine.Resource) *OrderService {
-	return &OrderService{orderDao, pairDao, accountDao, tradeDao, engine}
+func NewOrderService(orderDao *daos.OrderDao, pairDao *daos.PairDao, accountDao *daos.AccountDao, tradeDao *daos.TradeDao, journalDao *daos.JournalDao, engine engine.Engine) *OrderService {
+	return &OrderService{orderDao, pairDao, accountDao, tradeDao, journalDao, engine, newOrderRateLimiter(), nil, newRFQReservations()}
+}
+
+// SetTradeQueue sets the operator settling the trades. The trades are queued for
+// settlement once signed by the taker. They stay AWAITING_SIGNATURE if no queue is set,
+// eg. when the operator does not run in this process.
+func (s *OrderService) SetTradeQueue(queue TradeQueue) {
+	s.queue = queue
 }
 
 // GetByID fetches the details of an order using order's mongo ID
@@ -43,48 +55,266 @@ func (s *OrderService) GetByUserAddress(addr common.Address) ([]*types.Order, er
 	return s.orderDao.GetByUserAddress(addr)
 }
 
+// GetCurrentByUserAddress fetches the orders of an account still resting in the orderbook
+func (s *OrderService) GetCurrentByUserAddress(addr common.Address) ([]*types.Order, error) {
+	return s.orderDao.GetCurrentByUserAddress(addr)
+}
+
+// GetHistoryByUserAddress fetches the filled, cancelled and expired orders of an account
+// and the total number of such orders
+func (s *OrderService) GetHistoryByUserAddress(addr common.Address, offset, limit int) ([]*types.Order, int, error) {
+	return s.orderDao.GetHistoryByUserAddress(addr, offset, limit)
+}
+
+// Query fetches the orders of an account matching the filters of the query, most recent
+// first, and the total number of matching orders
+func (s *OrderService) Query(q *types.OrderQuery) ([]*types.Order, int, error) {
+	return s.orderDao.Query(q)
+}
+
+// GetByHash fetches the details of an order using its hash. It returns nil if the
+// order does not exist.
+func (s *OrderService) GetByHash(hash common.Hash) (*types.Order, error) {
+	return s.orderDao.GetByHash(hash)
+}
+
+// LookupByHash fetches an order like GetByHash, or from the archive collection if it was
+// archived. The archived orders are flagged as such.
+func (s *OrderService) LookupByHash(hash common.Hash) (*types.Order, error) {
+	o, err := s.orderDao.GetByHash(hash)
+	if err != nil || o != nil {
+		return o, err
+	}
+
+	return s.orderDao.GetArchivedByHash(hash)
+}
+
+// GetTrades fetches the trades filling an order (fill history), oldest first
+func (s *OrderService) GetTrades(o *types.Order) ([]*types.Trade, error) {
+	return s.tradeDao.GetByOrder(o.Hash, o.ID)
+}
+
+// SetFormatted adds the display representation of the price and amount to orders
+func (s *OrderService) SetFormatted(orders []*types.Order) {
+	pairs := newPairCache(s.pairDao)
+	for _, o := range orders {
+		if p := pairs.Get(o.BaseToken, o.QuoteToken); p != nil {
+			o.SetFormatted(p)
+		}
+	}
+}
+
+// SetFormattedTrades adds the display representation of the price and amount to the
+// trades of an order
+func (s *OrderService) SetFormattedTrades(trades []*types.Trade) {
+	pairs := newPairCache(s.pairDao)
+	for _, tr := range trades {
+		if p := pairs.Get(tr.BaseToken, tr.QuoteToken); p != nil {
+			tr.SetFormatted(p)
+		}
+	}
+}
+
 // Create validates if the passed order is valid or not based on user's available
 // funds and order data.
 // If valid: Order is inserted in DB with order status as new and order is publiched
 // on rabbitmq queue for matching engine to process the order
 func (s *OrderService) NewOrder(o *types.Order) error {
-	// Validate if the address is not blacklisted
-	acc, err := s.accountDao.GetByAddress(o.UserAddress)
+	o.Stamp(types.StageReceived, time.Now())
+	if err := s.acceptOrder(o, 0); err != nil {
+		s.journalRejected(o, err)
+		return err
+	}
+
+	// Push o to queue
+	o.Stamp(types.StageEnqueued, time.Now())
+	if err := s.engine.AddOrder(o); err != nil {
+		log.Print(err)
+		s.unlockAmount(o, o.SellAmount)
+		return err
+	}
+
+	return nil
+}
+
+// MassQuote replaces the current orders of a market maker on a pair with a new set of
+// quotes. The quotes are accepted like new orders and are sent to the engine along with
+// the orders they replace in a single message, so that the old quotes are cancelled and
+// the new quotes matched in one engine operation. The quote set is rejected if one of
+// the quotes does not belong to the pair or to the maker of the other quotes. The result
+// of each quote (bids first) is returned otherwise, a quote failing the balance or limit
+// checks does not prevent the other quotes from being placed.
+func (s *OrderService) MassQuote(q *types.MassQuote) ([]*types.OrderResult, error) {
+	orders := q.Orders()
+	if len(orders) == 0 {
+		return nil, aerrors.InvalidMassQuote.New(aerrors.Params{"error": "the quote set is empty"})
+	}
+
+	p, err := getPair(s.pairDao, q.BaseToken, q.QuoteToken)
 	if err != nil {
+		log.Print(err)
+		return nil, pairError(err)
+	}
+
+	maker := orders[0].UserAddress
+	for i, o := range orders {
+		side, buyToken, sellToken := "buy", p.BaseTokenAddress, p.QuoteTokenAddress
+		if i >= len(q.Bids) {
+			side, buyToken, sellToken = "sell", p.QuoteTokenAddress, p.BaseTokenAddress
+		}
+
+		if o.BuyToken != buyToken || o.SellToken != sellToken {
+			return nil, aerrors.InvalidMassQuote.New(aerrors.Params{"error": fmt.Sprintf("quote %s is not a %s order of %s", o.Hash.Hex(), side, p.Name)})
+		}
+
+		if o.UserAddress != maker {
+			return nil, aerrors.InvalidMassQuote.New(aerrors.Params{"error": "the quotes must be placed by the same address"})
+		}
+	}
+
+	current, err := s.orderDao.GetCurrentByUserAddress(maker)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	replaced := []*types.Order{}
+	for _, o := range current {
+		if o.PairName == p.Name {
+			replaced = append(replaced, o)
+		}
+	}
+
+	results := make([]*types.OrderResult, len(orders))
+	accepted := []*types.Order{}
+	for i, o := range orders {
+		results[i] = &types.OrderResult{Hash: o.Hash}
+
+		o.Stamp(types.StageReceived, time.Now())
+		if err := s.acceptOrder(o, len(replaced)); err != nil {
+			s.journalRejected(o, err)
+			results[i].Reject(err)
+			continue
+		}
+
+		accepted = append(accepted, o)
+	}
+
+	for _, o := range accepted {
+		o.Stamp(types.StageEnqueued, time.Now())
+	}
+
+	err = s.engine.MassQuote(p.Name, replaced, accepted)
+	if err != nil {
+		log.Print(err)
+		for _, o := range accepted {
+			s.unlockAmount(o, o.SellAmount)
+		}
+
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// acceptOrder runs the checks of a new order, locks its sold amount and stores it.
+// replaced is the number of open orders of the account that are cancelled along with the
+// order and do not count towards its maximum number of open orders.
+func (s *OrderService) acceptOrder(o *types.Order, replaced int) error {
+	if err := s.checkOrder(o, replaced, false); err != nil {
 		return err
 	}
 
-	if acc.IsBlocked {
-		return fmt.Errorf("Address: %+v isBlocked", acc)
+	o.Stamp(types.StageValidated, time.Now())
+	err := s.accountDao.LockBalance(o.UserAddress, o.SellToken, o.SellAmount)
+	if err == daos.ErrInsufficientBalance {
+		return aerrors.InsufficientBalance.New(nil)
 	}
 
-	if err := o.Validate(); err != nil {
+	if err != nil {
+		log.Print(err)
 		return err
 	}
 
-	ok, err := o.VerifySignature()
+	if err = s.orderDao.Create(o); err != nil {
+		log.Print(err)
+		s.unlockAmount(o, o.SellAmount)
+		return err
+	}
+
+	PublishBalances(s.accountDao, o.UserAddress)
+	return nil
+}
+
+// checkOrder runs the checks of a new order (account, limits, fees and balances) and
+// fills its token and pair data. The order rate of the account is not counted for dry
+// runs, which only check the available balance as the sold amount is not locked.
+func (s *OrderService) checkOrder(o *types.Order, replaced int, dryRun bool) error {
+	// Validate if the address is not blacklisted
+	acc, err := s.accountDao.GetByAddress(o.UserAddress)
 	if err != nil {
+		return accountError(err)
+	}
+
+	if acc.IsBlocked {
+		return aerrors.AccountBlocked.New(nil)
+	}
+
+	if err := s.ValidateOrder(o); err != nil {
 		return err
 	}
-	if !ok {
-		return errors.New("Invalid signature")
+
+	// the limits of the account tier, or of the account override
+	now := time.Now()
+	limits := acc.EffectiveLimits(AccountTiers(), now)
+	if limits.MaxOpenOrders > 0 {
+		open, err := s.orderDao.CountOpenOrdersByAddress(o.UserAddress)
+		if err != nil {
+			log.Print(err)
+			return err
+		}
+
+		if open-replaced >= limits.MaxOpenOrders {
+			return aerrors.MaxOpenOrdersReached.New(aerrors.Params{"limit": limits.MaxOpenOrders})
+		}
 	}
 
-	p, err := s.pairDao.GetByBuySellTokenAddress(o.BuyToken, o.SellToken)
+	if !dryRun && !s.orderRates.allow(o.UserAddress, limits.OrdersPerMinute, now) {
+		return aerrors.OrderRateLimited.New(aerrors.Params{"limit": limits.OrdersPerMinute})
+	}
+
+	p, err := getPairByBuySellToken(s.pairDao, o.BuyToken, o.SellToken)
 	if err != nil {
 		log.Print(err)
-		return err
+		return pairError(err)
 	}
 
 	if p == nil {
-		return errors.New("Pair not found")
+		return aerrors.PairNotFound.New(nil)
 	}
 
 	// Fill token and pair data
 	err = o.Process(p)
 	if err != nil {
 		log.Print(err)
-		return err
+		return aerrors.InvalidOrder.New(aerrors.Params{"error": err.Error()})
+	}
+
+	// the fees of the order can not be lower than the fees currently charged on the pair,
+	// or than the fees of the account when they are set
+	makeFee, takeFee := p.EffectiveFees(now)
+	if limits.MakeFee != nil {
+		makeFee = limits.MakeFee
+	}
+
+	if limits.TakeFee != nil {
+		takeFee = limits.TakeFee
+	}
+	if o.MakeFee.Cmp(makeFee) == -1 || o.TakeFee.Cmp(takeFee) == -1 {
+		return aerrors.InsufficientOrderFee.New(aerrors.Params{
+			"makeFee": makeFee.String(),
+			"takeFee": takeFee.String(),
+		})
 	}
 
 	// fee balance validation
@@ -99,62 +329,55 @@ func (s *OrderService) NewOrder(o *types.Order) error {
 	}
 
 	if wethTokenBalance.Balance.Cmp(o.MakeFee) == -1 {
-		log.Printf("Error retrieving ")
-		return errors.New("Insufficient WETH Balance")
+		return aerrors.InsufficientFeeBalance.New(nil)
 	}
 
 	if wethTokenBalance.Balance.Cmp(o.TakeFee) == -1 {
-		return errors.New("Insufficient WETH Balance")
+		return aerrors.InsufficientFeeBalance.New(nil)
 	}
 
 	if wethTokenBalance.Allowance.Cmp(o.MakeFee) == -1 {
-		return errors.New("Insufficient WETH Allowance")
+		return aerrors.InsufficientFeeAllowance.New(nil)
 	}
 
 	if wethTokenBalance.Allowance.Cmp(o.TakeFee) == -1 {
-		return errors.New("Insufficient WETH Allowance")
+		return aerrors.InsufficientFeeAllowance.New(nil)
 	}
 
-	wethTokenBalance.Balance.Sub(wethTokenBalance.Balance, o.MakeFee)
-	wethTokenBalance.LockedBalance.Add(wethTokenBalance.LockedBalance, o.TakeFee)
-
-	err = s.accountDao.UpdateTokenBalance(o.UserAddress, o.QuoteToken, wethTokenBalance)
+	// balance validation. The sold amount is locked until the order is filled or
+	// cancelled so that it can not be used by other orders.
+	sellTokenBalance, err := s.accountDao.GetTokenBalance(o.UserAddress, o.SellToken)
 	if err != nil {
 		log.Print(err)
 		return err
 	}
 
-	// balance validation
-	sellTokenBalance, err := s.accountDao.GetTokenBalance(o.UserAddress, o.SellToken)
-	if err != nil {
-		log.Print(err)
-		return err
+	if sellTokenBalance == nil {
+		return aerrors.InsufficientBalance.New(nil)
 	}
 
-	if sellTokenBalance.Balance.Cmp(o.SellAmount) != 1 {
-		return errors.New("Insufficient Balance")
+	if sellTokenBalance.Allowance.Cmp(math.Add(sellTokenBalance.LockedBalance, o.SellAmount)) == -1 {
+		return aerrors.InsufficientAllowance.New(nil)
 	}
 
-	if sellTokenBalance.Allowance.Cmp(o.SellAmount) != 1 {
-		return errors.New("Insufficient Allowance")
+	if dryRun && sellTokenBalance.Balance.Cmp(o.SellAmount) == -1 {
+		return aerrors.InsufficientBalance.New(nil)
 	}
 
-	sellTokenBalance.Balance.Sub(sellTokenBalance.Balance, o.SellAmount)
-	sellTokenBalance.LockedBalance.Add(sellTokenBalance.Balance, o.SellAmount)
-	err = s.accountDao.UpdateTokenBalance(o.UserAddress, o.SellToken, sellTokenBalance)
-	if err != nil {
-		log.Print(err)
+	return nil
+}
+
+// ValidateOrder checks the fields and the signature of an order
+func (s *OrderService) ValidateOrder(o *types.Order) error {
+	if err := o.Validate(); err != nil {
 		return err
 	}
 
-	if err = s.orderDao.Create(o); err != nil {
-		log.Print(err)
-		return err
+	ok, err := o.VerifySignature()
+	if err != nil || !ok {
+		return aerrors.InvalidSignature.New(nil)
 	}
 
-	// Push o to queue
-	bytes, _ := json.Marshal(o)
-	s.engine.PublishMessage(&engine.Message{Type: "NEW_ORDER", Data: bytes})
 	return nil
 }
 
@@ -169,7 +392,7 @@ func (s *OrderService) CancelOrder(oc *types.OrderCancel) error {
 	}
 
 	if dbOrder == nil {
-		return fmt.Errorf("No order with this hash present")
+		return aerrors.OrderNotFound.New(aerrors.Params{"hash": oc.OrderHash.Hex()})
 	}
 
 	_, err = json.Marshal(dbOrder)
@@ -180,6 +403,10 @@ func (s *OrderService) CancelOrder(oc *types.OrderCancel) error {
 
 	if dbOrder.Status == "OPEN" || dbOrder.Status == "NEW" {
 		res, err := s.engine.CancelOrder(dbOrder)
+		if engine.IsRateLimited(err) {
+			return aerrors.RateLimited.New(aerrors.Params{"error": err.(*engine.RateLimitError).Reason})
+		}
+
 		if err != nil {
 			log.Print(err)
 			return err
@@ -196,24 +423,44 @@ func (s *OrderService) CancelOrder(oc *types.OrderCancel) error {
 		return nil
 	}
 
-	return fmt.Errorf("Cannot cancel the order")
+	return aerrors.OrderNotCancellable.New(aerrors.Params{"status": dbOrder.Status})
 }
 
 // HandleEngineResponse listens to messages incoming from the engine and handles websocket
 // responses and database updates accordingly
 func (s *OrderService) HandleEngineResponse(res *engine.Response) error {
+	// the responses of the orders sent by another API replica are processed by that
+	// replica, the updates are only sent to the websocket subscribers of this one
+	if res.Foreign {
+		s.RelayUpdateOverSocket(res)
+		return nil
+	}
+
+	s.journalEngineResponse(res)
+
 	switch res.FillStatus {
 	case engine.ERROR:
 		s.handleEngineError(res)
 	case engine.NOMATCH:
 		s.handleEngineOrderAdded(res)
-	case engine.FULL:
-	case engine.PARTIAL:
+	case engine.FULL, engine.PARTIAL:
 		s.handleEngineOrderMatched(res)
+	case engine.CANCELLED:
+		s.handleEngineOrderCancelled(res.Order)
+	case engine.REJECTED:
+		s.handleEngineOrderRejected(res)
 	default:
 		s.handleEngineUnknownMessage(res)
 	}
 
+	for _, o := range res.CancelledOrders {
+		s.handleEngineOrderCancelled(o)
+	}
+
+	for _, o := range res.EvictedOrders {
+		s.handleEngineOrderEvicted(o)
+	}
+
 	s.RelayUpdateOverSocket(res)
 	ws.CloseOrderReadChannel(res.Order.Hash)
 	return nil
@@ -224,28 +471,58 @@ func (s *OrderService) HandleEngineResponse(res *engine.Response) error {
 func (s *OrderService) handleEngineError(res *engine.Response) {
 	s.orderDao.Update(res.Order.ID, res.Order)
 	s.cancelOrderUnlockAmount(res.Order)
-	ws.SendOrderErrorMessage(ws.GetOrderConnection(res.Order.Hash), "Some error", res.Order.Hash)
+	ws.SendOrderErrorMessage(ws.GetOrderConnection(res.Order.Hash), aerrors.NewWSError(aerrors.InternalError.New(nil)), res.Order.Hash)
+}
+
+// handleEngineOrderRejected releases the amount locked by an order rejected by the quote
+// throttling (RATE_LIMITED) or the orderbook size limit (ORDERBOOK_FULL) of the engine
+// and returns the error message to the client
+func (s *OrderService) handleEngineOrderRejected(res *engine.Response) {
+	err := s.orderDao.Update(res.Order.ID, res.Order)
+	if err != nil {
+		log.Print(err)
+	}
+
+	err = s.cancelOrderUnlockAmount(res.Order)
+	if err != nil {
+		log.Print(err)
+	}
+
+	ws.SendOrderErrorMessage(ws.GetOrderConnection(res.Order.Hash), aerrors.NewWSError(aerrors.OrderRejected.New(aerrors.Params{"error": res.Error})), res.Order.Hash)
 }
 
 // handleEngineOrderAdded returns a websocket message informing the client that his order has been added
 // to the orderbook (but currently not matched)
 func (s *OrderService) handleEngineOrderAdded(res *engine.Response) {
-	s.SendMessage("ORDER_ADDED", res.Order.Hash, res.Order)
+	// the sequence number assigned by the engine is stored so that the time priority of
+	// the order is kept when the orderbook is restored from the database
+	err := s.orderDao.Update(res.Order.ID, res.Order)
+	if err != nil {
+		log.Print(err)
+	}
+
+	res.Order.Stamp(types.StagePersisted, time.Now())
+	s.SendMessage("ORDER_ADDED", res.Order.Hash, broadcastOrder(res.Order, latencyBreakdown()))
 }
 
 // handleEngineOrderMatched returns a websocket message informing the client that his order has been added.
 // The request signature message also signals the client to sign trades.
 func (s *OrderService) handleEngineOrderMatched(resp *engine.Response) {
+	resp.Order = broadcastOrder(resp.Order, false)
 	s.SendMessage("REQUEST_SIGNATURE", resp.Order.Hash, resp)
 	s.orderDao.Update(resp.Order.ID, resp.Order)
 	s.transferAmount(resp.Order, resp.Order.FilledAmount)
 
 	for _, o := range resp.MatchingOrders {
-		s.orderDao.Update(o.Order.ID, resp.Order)
+		s.orderDao.Update(o.Order.ID, o.Order)
 		s.transferAmount(o.Order, o.Amount)
 	}
 
 	if len(resp.Trades) != 0 {
+		for _, t := range resp.Trades {
+			t.Status = "AWAITING_SIGNATURE"
+		}
+
 		err := s.tradeDao.Create(resp.Trades...)
 		if err != nil {
 			log.Fatalf("\n Error saving trades to db: %s\n", err)
@@ -256,47 +533,322 @@ func (s *OrderService) handleEngineOrderMatched(resp *engine.Response) {
 	ch := ws.GetOrderChannel(resp.Order.Hash)
 
 	if ch == nil {
-		s.RecoverOrders(resp)
+		s.recoverMatchedOrders(resp)
 	} else {
 		select {
 		case msg := <-ch:
 			if msg.Type == "SUBMIT_SIGNATURE" {
 				bytes, err := json.Marshal(msg.Data)
 				if err != nil {
-					s.RecoverOrders(resp)
-					ws.SendOrderErrorMessage(ws.GetOrderConnection(resp.Order.Hash), err.Error(), resp.Order.Hash)
+					s.recoverMatchedOrders(resp)
+					ws.SendOrderErrorMessage(ws.GetOrderConnection(resp.Order.Hash), aerrors.NewWSError(aerrors.InvalidMessage.New(nil)), resp.Order.Hash)
+					return
 				}
 
 				clientResponse := &engine.Response{}
 				err = json.Unmarshal(bytes, clientResponse)
 				if err != nil {
-					s.RecoverOrders(resp)
-					ws.SendOrderErrorMessage(ws.GetOrderConnection(resp.Order.Hash), err.Error(), resp.Order.Hash)
+					s.recoverMatchedOrders(resp)
+					ws.SendOrderErrorMessage(ws.GetOrderConnection(resp.Order.Hash), aerrors.NewWSError(aerrors.InvalidMessage.New(nil)), resp.Order.Hash)
+					return
 				}
 
-				if clientResponse.FillStatus == engine.PARTIAL {
+				// the remaining amount of an order cancelled by the self-trade prevention
+				// is not put back in the orderbook
+				if clientResponse.FillStatus == engine.PARTIAL && resp.Order.Status != "CANCELLED" {
 					resp.Order.OrderBook = &types.OrderSubDoc{Amount: clientResponse.RemainingOrder.Amount, Signature: clientResponse.RemainingOrder.Signature}
-					bytes, _ := json.Marshal(resp.Order)
-					s.engine.PublishMessage(&engine.Message{Type: "ADD_ORDER", Data: bytes})
+					s.engine.AddRemainingOrder(resp.Order)
 				}
+
+				s.queueSignedTrades(resp, clientResponse.Trades)
 			}
 
 			t.Stop()
 			break
 		case <-t.C:
-			s.RecoverOrders(resp)
+			s.recoverMatchedOrders(resp)
 			t.Stop()
 			break
 		}
 	}
 }
 
+// queueSignedTrades queues the trades of a match for settlement with the signatures
+// submitted by the taker. Only the signatures are taken from the client, the trades are
+// the ones recorded when the orders matched.
+func (s *OrderService) queueSignedTrades(resp *engine.Response, signed []*types.Trade) {
+	if s.queue == nil {
+		return
+	}
+
+	makers := make(map[common.Hash]*types.Order)
+	for _, fill := range resp.MatchingOrders {
+		makers[fill.Order.Hash] = fill.Order
+	}
+
+	signatures := make(map[common.Hash]*types.Signature)
+	for _, t := range signed {
+		if t != nil && t.Signature != nil {
+			signatures[t.Hash] = t.Signature
+		}
+	}
+
+	for _, t := range resp.Trades {
+		maker := makers[t.OrderHash]
+		if maker == nil || signatures[t.Hash] == nil {
+			log.Printf("Trade %s not signed, it is not settled", t.Hash.Hex())
+			continue
+		}
+
+		t.Signature = signatures[t.Hash]
+		if ok, _ := t.VerifySignature(); !ok {
+			log.Printf("Invalid signature of trade %s, it is not settled", t.Hash.Hex())
+			continue
+		}
+
+		// the trades that could not be queued stay in the settlement backlog
+		err := s.queue.QueueTrade(maker, t)
+		if err != nil {
+			log.Printf("Could not queue trade %s: %v", t.Hash.Hex(), err)
+		}
+	}
+}
+
+// handleEngineOrderCancelled updates an order cancelled by the self-trade prevention of
+// the engine, unlocks its amount and notifies its maker
+func (s *OrderService) handleEngineOrderCancelled(o *types.Order) {
+	err := s.orderDao.Update(o.ID, o)
+	if err != nil {
+		log.Print(err)
+	}
+
+	err = s.cancelOrderUnlockAmount(o)
+	if err != nil {
+		log.Print(err)
+	}
+
+	s.SendMessage("ORDER_CANCELLED", o.Hash, o)
+}
+
+// handleEngineOrderEvicted updates an order evicted from a full orderbook to make room
+// for an order closer to the touch, unlocks its amount and notifies its maker
+func (s *OrderService) handleEngineOrderEvicted(o *types.Order) {
+	err := s.orderDao.Update(o.ID, o)
+	if err != nil {
+		log.Print(err)
+	}
+
+	err = s.cancelOrderUnlockAmount(o)
+	if err != nil {
+		log.Print(err)
+	}
+
+	s.journal(newJournalEntry(o.Hash, types.JournalCancelled, map[string]interface{}{"reason": "EVICTED"}))
+	s.SendMessage("ORDER_EVICTED", o.Hash, o)
+}
+
 // handleEngineUnknownMessage returns a websocket messsage in case the engine response is not recognized
 func (s *OrderService) handleEngineUnknownMessage(resp *engine.Response) {
 	s.RecoverOrders(resp)
 	ws.SendOrderErrorMessage(ws.GetOrderConnection(resp.Order.Hash), "UNKNOWN_MESSAGE", resp.Order.Hash)
 }
 
+// HandleSettlementFailure reverts a trade whose settlement failed permanently (eg. the
+// transaction reverted or the order was filled elsewhere). The traded amount is given back
+// to both parties, the maker order is put back in the orderbook if it can still be filled
+// and both sides are notified with the error code and reason of the failure.
+func (s *OrderService) HandleSettlementFailure(tr *types.Trade) error {
+	maker, err := s.orderDao.GetByHash(tr.OrderHash)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	taker, err := s.orderDao.GetByID(tr.TakerOrderID)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	if maker != nil {
+		requeue := maker.Status != "CANCELLED" && maker.Status != "ERROR"
+		s.revertTransferAmount(maker, tr.Amount, requeue)
+
+		if requeue {
+			// the amount put back in the orderbook cannot exceed the saved fill of the order
+			amount := tr.Amount
+			if maker.FilledAmount == nil {
+				amount = big.NewInt(0)
+			} else if math.IsGreaterThan(amount, maker.FilledAmount) {
+				amount = maker.FilledAmount
+			}
+
+			// the engine gets a copy of the order as it restores the filled amount of the
+			// order it receives, locally or in a separate matcher process
+			recovered := *maker
+			fill := &engine.FillOrder{Amount: amount, Order: &recovered}
+			err = s.engine.RecoverOrders([]*engine.FillOrder{fill})
+			if err != nil {
+				log.Print(err)
+				return err
+			}
+
+			unfillOrder(maker, amount)
+			err = s.orderDao.Update(maker.ID, maker)
+			if err != nil {
+				log.Print(err)
+				return err
+			}
+		}
+	}
+
+	if taker != nil {
+		s.revertTransferAmount(taker, tr.Amount, false)
+	}
+
+	payload := map[string]interface{}{
+		"trade":     tr,
+		"errorCode": tr.ErrorCode,
+		"reason":    tr.FailureReason,
+		"error":     aerrors.GetExchangeError(tr.ErrorCode),
+	}
+
+	if maker != nil {
+		s.SendMessage("TRADE_TX_ERROR", maker.Hash, payload)
+	}
+
+	if taker != nil {
+		s.SendMessage("TRADE_TX_ERROR", taker.Hash, payload)
+	}
+
+	return nil
+}
+
+// HandleTradeExecuted notifies the maker and the taker of a trade that its settlement
+// transaction was sent. The trade is sent with the hash of the transaction.
+func (s *OrderService) HandleTradeExecuted(tr *types.Trade) error {
+	return s.notifyTrade("TRADE_EXECUTED", tr)
+}
+
+// HandleSettlementSuccess notifies the maker and the taker of a trade whose settlement
+// transaction is confirmed
+func (s *OrderService) HandleSettlementSuccess(tr *types.Trade) error {
+	return s.notifyTrade("TRADE_TX_SUCCESS", tr)
+}
+
+// HandleSettlementReorg notifies the maker and the taker of a trade whose settlement
+// transaction was removed from the chain by a reorganization. The trade is pending again
+// until the transaction is mined in another block.
+func (s *OrderService) HandleSettlementReorg(tr *types.Trade) error {
+	return s.notifyTrade("TRADE_TX_REORGED", tr)
+}
+
+// notifyTrade sends a message about a trade to the channels of its maker and taker orders
+func (s *OrderService) notifyTrade(msgType string, tr *types.Trade) error {
+	taker, err := s.orderDao.GetByID(tr.TakerOrderID)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	s.SendMessage(msgType, tr.OrderHash, tr)
+
+	if taker != nil {
+		s.SendMessage(msgType, taker.Hash, tr)
+	}
+
+	return nil
+}
+
+// HandleTradeBust notifies the maker and the taker of a trade busted or flagged by the
+// administrators. The traded amount of a busted trade is given back to both parties: the
+// sold amount is made available again (the maker order is not put back in the orderbook)
+// and the bought amount is removed.
+func (s *OrderService) HandleTradeBust(tr *types.Trade) error {
+	maker, err := s.orderDao.GetByHash(tr.OrderHash)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	taker, err := s.orderDao.GetByID(tr.TakerOrderID)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	msgType := "TRADE_FLAGGED"
+	if tr.Status == "BUSTED" {
+		msgType = "TRADE_BUSTED"
+
+		if maker != nil {
+			s.revertTransferAmount(maker, tr.Amount, false)
+		}
+
+		if taker != nil {
+			s.revertTransferAmount(taker, tr.Amount, false)
+		}
+	}
+
+	if maker != nil {
+		s.SendMessage(msgType, maker.Hash, tr)
+	}
+
+	if taker != nil {
+		s.SendMessage(msgType, taker.Hash, tr)
+	}
+
+	return nil
+}
+
+// HandleOnChainFill reconciles the orderbook with a fill of an order made on-chain by a
+// third party, ie. a trade of the exchange contract that was not matched by the engine.
+// The filled amount is removed from the remaining amount of the order in the orderbook
+// and the maker is notified. tokenBuy, filledBuy and filledSell are the bought token and
+// the filled amounts of the order, as emitted in the trade event.
+func (s *OrderService) HandleOnChainFill(orderHash common.Hash, tokenBuy common.Address, filledBuy, filledSell *big.Int) error {
+	o, err := s.orderDao.GetByHash(orderHash)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	// the order was not placed on the exchange
+	if o == nil {
+		return nil
+	}
+
+	if o.Status != "NEW" && o.Status != "OPEN" && o.Status != "PARTIAL_FILLED" {
+		log.Printf("Order %s filled on-chain while not in the orderbook (status: %s)", o.Hash.Hex(), o.Status)
+		return nil
+	}
+
+	// order amounts are expressed in base token
+	amount := filledSell
+	if tokenBuy == o.BaseToken {
+		amount = filledBuy
+	}
+
+	res, err := s.engine.ReduceOrder(o, amount)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	o.FilledAmount = res.Order.FilledAmount
+	o.Status = res.Order.Status
+	err = s.orderDao.Update(o.ID, o)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	s.transferAmount(o, amount)
+	s.SendMessage("ORDER_FILLED_ON_CHAIN", o.Hash, o)
+	s.RelayUpdateOverSocket(&engine.Response{Order: o})
+	return nil
+}
+
 // RecoverOrders recovers orders i.e puts back matched orders to orderbook
 // in case of failure of trade signing by the maker
 func (s *OrderService) RecoverOrders(resp *engine.Response) {
@@ -312,8 +864,108 @@ func (s *OrderService) RecoverOrders(resp *engine.Response) {
 	resp.MatchingOrders = nil
 }
 
+// recoverMatchedOrders reverts a match whose trades were not signed by the taker. The
+// transfers made when the orders matched are reverted: the maker orders are put back in
+// the orderbook with their sold amounts locked again, and the amount locked by the taker
+// order is released as the order ends with an error.
+func (s *OrderService) recoverMatchedOrders(resp *engine.Response) {
+	for _, o := range resp.MatchingOrders {
+		s.revertTransferAmount(o.Order, o.Amount, true)
+
+		// the engine gets a copy of the order as it restores the filled amount of the
+		// order it receives, locally or in a separate matcher process
+		maker := *o.Order
+		unfillOrder(&maker, o.Amount)
+		err := s.orderDao.Update(maker.ID, &maker)
+		if err != nil {
+			log.Print(err)
+		}
+	}
+
+	s.revertTransferAmount(resp.Order, resp.Order.FilledAmount, false)
+	err := s.cancelOrderUnlockAmount(resp.Order)
+	if err != nil {
+		log.Print(err)
+	}
+
+	s.RecoverOrders(resp)
+	resp.Order.FilledAmount = big.NewInt(0)
+	err = s.orderDao.Update(resp.Order.ID, resp.Order)
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// unfillOrder removes a reverted fill from the filled amount of an order put back in the
+// orderbook. The filled amount never goes below zero and the status of the order is
+// derived from the remaining fill.
+func unfillOrder(o *types.Order, amount *big.Int) {
+	if o.FilledAmount == nil {
+		o.FilledAmount = big.NewInt(0)
+	}
+
+	o.FilledAmount = math.Sub(o.FilledAmount, amount)
+	if o.FilledAmount.Sign() < 0 {
+		o.FilledAmount = big.NewInt(0)
+	}
+
+	o.Status = "PARTIAL_FILLED"
+	if math.IsZero(o.FilledAmount) {
+		o.Status = "OPEN"
+	}
+}
+
+// RestoreOrderBooks rebuilds the orderbooks of all the pairs from the open orders stored
+// in the database (eg. after redis was flushed) and logs the divergences that were
+// repaired. The orderbook of a repaired pair is sent again to its subscribers.
+func (s *OrderService) RestoreOrderBooks() ([]*engine.RestoreReport, error) {
+	pairs, err := s.pairDao.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []*engine.RestoreReport{}
+	for i := range pairs {
+		p := &pairs[i]
+		orders, err := s.orderDao.GetOpenByPair(p.BaseTokenAddress, p.QuoteTokenAddress)
+		if err != nil {
+			return reports, err
+		}
+
+		report, err := s.engine.RestoreOrderBook(p, orders)
+		if err != nil {
+			return reports, err
+		}
+
+		if !report.Consistent() {
+			log.Printf(
+				"Restored orderbook of %s: %d orders restored, %d updated, %d removed, %d price levels repaired",
+				p.Name, len(report.Restored), len(report.Updated), len(report.Removed), report.Levels,
+			)
+
+			PublishOrderBookUpdate(s.engine, p)
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
 // RelayUpdateOverSocket is responsible for notifying listening clients about new order/trade addition/deletion
 func (s *OrderService) RelayUpdateOverSocket(resp *engine.Response) {
+	if resp.Order != nil {
+		p, err := getPair(s.pairDao, resp.Order.BaseToken, resp.Order.QuoteToken)
+		if err != nil {
+			log.Print(err)
+		} else {
+			PublishOrderBookUpdate(s.engine, p)
+		}
+	}
+
+	if len(resp.Trades) > 0 {
+		PublishTrades(resp.Trades)
+	}
 
 	// if
 	// if len(resp.Trades) > 0 {
@@ -337,83 +989,102 @@ func (s *OrderService) SendMessage(msgType string, hash common.Hash, data interf
 	ws.SendOrderMessage(ws.GetOrderConnection(hash), msgType, data, hash)
 }
 
-// this function is responsible for unlocking of maker's amount in balance document
-// in case maker cancels the order or some error occurs
-func (s *OrderService) cancelOrderUnlockAmount(o *types.Order) error {
-	// Unlock Amount
-	acc, err := s.accountDao.GetByAddress(o.UserAddress)
-	if err != nil {
-		log.Fatalf("\n%v\n", err)
-		return err
+// filledAmounts returns the amounts of sold and bought tokens exchanged by a fill of an
+// order. filled is expressed in base token, like the amount of the order.
+func filledAmounts(o *types.Order, filled *big.Int) (sold, bought *big.Int) {
+	if filled == nil {
+		return big.NewInt(0), big.NewInt(0)
 	}
 
-	if o.Side == "BUY" {
-		tokenBalance := acc.TokenBalances[o.QuoteToken]
-		tokenBalance.Balance.Add(tokenBalance.Balance, o.SellAmount)
-		tokenBalance.LockedBalance.Sub(tokenBalance.LockedBalance, o.SellAmount)
+	if o.Side == "SELL" {
+		return filled, math.Div(math.Mul(o.BuyAmount, filled), o.Amount)
+	}
 
-		err = s.accountDao.UpdateTokenBalance(o.UserAddress, o.QuoteToken, tokenBalance)
-		if err != nil {
-			log.Fatalf("\n%s\n", err)
-		}
+	return math.Div(math.Mul(o.SellAmount, filled), o.Amount), filled
+}
+
+// fillAmounts returns the amounts of sold and bought tokens exchanged by a fill of an
+// order whose filled amount includes the fill. The amounts are the differences between
+// the amounts exchanged after and before the fill so that the rounding errors do not add
+// up: the last fill of an order spends all of its remaining locked amount, and no dust
+// stays locked once the order is filled.
+func fillAmounts(o *types.Order, fill *big.Int) (sold, bought *big.Int) {
+	if o.FilledAmount == nil || fill == nil {
+		return filledAmounts(o, fill)
 	}
 
-	if o.Side == "SELL" {
-		tokenBalance := acc.TokenBalances[o.BaseToken]
-		tokenBalance.Balance.Add(tokenBalance.Balance, o.SellAmount)
-		tokenBalance.LockedBalance.Sub(tokenBalance.LockedBalance, o.SellAmount)
+	soldAfter, boughtAfter := filledAmounts(o, o.FilledAmount)
+	soldBefore, boughtBefore := filledAmounts(o, math.Sub(o.FilledAmount, fill))
+	return math.Sub(soldAfter, soldBefore), math.Sub(boughtAfter, boughtBefore)
+}
 
-		err = s.accountDao.UpdateTokenBalance(o.UserAddress, o.BaseToken, tokenBalance)
-		if err != nil {
-			log.Fatalf("\n%v\n", err)
-		}
+// unlockAmount releases an amount of the sold token locked by an order
+func (s *OrderService) unlockAmount(o *types.Order, amount *big.Int) error {
+	err := s.accountDao.UnlockBalance(o.UserAddress, o.SellToken, amount)
+	if err != nil {
+		log.Print(err)
+		return err
 	}
 
+	PublishBalances(s.accountDao, o.UserAddress)
 	return nil
 }
 
-// transferAmount is used to transfer amount from seller to buyer
-// it removes the lockedAmount of one token and adds confirmed amount for another token
-// based on the type of order i.e. buy/sell
-func (s *OrderService) transferAmount(o *types.Order, filledAmount *big.Int) {
-	tokenBalances, err := s.accountDao.GetTokenBalances(o.UserAddress)
-	if err != nil {
-		log.Fatalf("\n%v\n", err)
+// cancelOrderUnlockAmount releases the sold amount of the unfilled part of an order, in
+// case the maker cancels the order or some error occurs
+func (s *OrderService) cancelOrderUnlockAmount(o *types.Order) error {
+	sold, _ := filledAmounts(o, o.FilledAmount)
+	remaining := math.Sub(o.SellAmount, sold)
+	if remaining.Sign() <= 0 {
+		return nil
 	}
 
-	if o.Side == "BUY" {
-		sellBalance := tokenBalances[o.QuoteToken]
-		sellBalance.LockedBalance = sellBalance.LockedBalance.Sub(sellBalance.LockedBalance, filledAmount)
+	return s.unlockAmount(o, remaining)
+}
 
-		err = s.accountDao.UpdateTokenBalance(o.UserAddress, o.QuoteToken, sellBalance)
-		if err != nil {
-			log.Fatalf("\n%v\n", err)
-		}
+// revertTransferAmount reverts a transfer made by transferAmount. The amount that was
+// bought is removed and the amount that was sold is locked again if the order is put back
+// in the orderbook, or made available otherwise.
+func (s *OrderService) revertTransferAmount(o *types.Order, amount *big.Int, relock bool) {
+	sold, bought := fillAmounts(o, amount)
 
-		buyBalance := tokenBalances[o.BaseToken]
-		buyBalance.Balance = buyBalance.Balance.Add(buyBalance.Balance, filledAmount)
-		err = s.accountDao.UpdateTokenBalance(o.UserAddress, o.BaseToken, buyBalance)
-		if err != nil {
-			log.Fatalf("\n%v\n", err)
-		}
+	lockedDelta, balanceDelta := big.NewInt(0), sold
+	if relock {
+		lockedDelta, balanceDelta = sold, big.NewInt(0)
 	}
 
-	if o.Side == "SELL" {
-		buyBalance := tokenBalances[o.BaseToken]
-		buyBalance.LockedBalance = buyBalance.LockedBalance.Sub(buyBalance.LockedBalance, filledAmount)
-		err = s.accountDao.UpdateTokenBalance(o.UserAddress, o.BaseToken, buyBalance)
-		if err != nil {
-			log.Fatalf("\n%v\n", err)
-		}
+	err := s.accountDao.AdjustTokenBalance(o.UserAddress, o.SellToken, balanceDelta, lockedDelta)
+	if err != nil {
+		log.Print(err)
+	}
 
-		sellBalance := tokenBalances[o.QuoteToken]
-		sellBalance.Balance = sellBalance.Balance.Add(sellBalance.Balance, filledAmount)
-		err = s.accountDao.UpdateTokenBalance(o.UserAddress, o.BaseToken, sellBalance)
-		if err != nil {
-			log.Fatalf("\n%v\n", err)
-		}
+	err = s.accountDao.AdjustTokenBalance(o.UserAddress, o.BuyToken, math.Neg(bought), big.NewInt(0))
+	if err != nil {
+		log.Print(err)
 	}
 
+	PublishBalances(s.accountDao, o.UserAddress)
+}
+
+// transferAmount is used to transfer amount from seller to buyer. The sold amount of the
+// fill is removed from the locked balance of the sold token and the bought amount is
+// added to the balance of the bought token. The filled amount of the order must include
+// the fill.
+func (s *OrderService) transferAmount(o *types.Order, filledAmount *big.Int) {
+	sold, bought := fillAmounts(o, filledAmount)
+
+	err := s.accountDao.SpendLockedBalance(o.UserAddress, o.SellToken, sold)
+	if err != nil {
+		log.Print(err)
+	}
+
+	err = s.accountDao.AdjustTokenBalance(o.UserAddress, o.BuyToken, bought, big.NewInt(0))
+	if err != nil {
+		log.Print(err)
+	}
+
+	PublishBalances(s.accountDao, o.UserAddress)
+
 	// func (s *OrderService) handleNewTrade(msg *types.Message, res *engine.Response) {
 	// 	bytes, err := json.Marshal(msg.Data)
 	// 	if err != nil {
@@ -1,8 +1,9 @@
 package services
 
 import (
-	"encoding/json"
-	"errors"
+	"log"
+	"sort"
+	"sync"
 
 	"github.com/Proofsuite/amp-matching-engine/engine"
 	"github.com/Proofsuite/amp-matching-engine/utils"
@@ -13,6 +14,8 @@ import (
 	"github.com/gorilla/websocket"
 
 	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/types"
 )
 
 // PairService struct with daos required, responsible for communicating with daos.
@@ -20,11 +23,69 @@ import (
 type OrderBookService struct {
 	pairDao  *daos.PairDao
 	tokenDao *daos.TokenDao
-	eng      *engine.Resource
+	eng      engine.Engine
+}
+
+// orderBookLevels holds the price levels of the orderbooks and rawOrderBooks the resting
+// orders of the full orderbooks last sent to the subscribers of the orderbook channels by
+// channel id, the updates of a channel are the changes since then. The state of a
+// channel is dropped when it has no subscriber.
+var (
+	orderBookLevels = newBookStates()
+	rawOrderBooks   = newBookStates()
+)
+
+// bookLevels are the price levels of both sides of an orderbook
+type bookLevels struct {
+	asks []*types.BookLevel
+	bids []*types.BookLevel
+}
+
+type bookStates struct {
+	states map[string]interface{}
+	mutex  sync.Mutex
+}
+
+func newBookStates() *bookStates {
+	return &bookStates{states: make(map[string]interface{})}
+}
+
+func (b *bookStates) get(id string) (interface{}, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	state, ok := b.states[id]
+	return state, ok
+}
+
+func (b *bookStates) set(id string, state interface{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.states[id] = state
+}
+
+// setIfMissing sets the state of a channel if it has none. The changes of the existing
+// subscribers are computed from the state they were sent, the changes not sent yet are
+// then sent again to a new subscriber, which applies them as upserts.
+func (b *bookStates) setIfMissing(id string, state interface{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.states[id]; !ok {
+		b.states[id] = state
+	}
+}
+
+func (b *bookStates) remove(id string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.states, id)
 }
 
 // NewPairService returns a new instance of balance service
-func NewOrderBookService(pairDao *daos.PairDao, tokenDao *daos.TokenDao, eng *engine.Resource) *OrderBookService {
+func NewOrderBookService(pairDao *daos.PairDao, tokenDao *daos.TokenDao, eng engine.Engine) *OrderBookService {
 	return &OrderBookService{pairDao, tokenDao, eng}
 }
 
@@ -32,55 +93,255 @@ func NewOrderBookService(pairDao *daos.PairDao, tokenDao *daos.TokenDao, eng *en
 func (s *OrderBookService) GetOrderBook(bt, qt common.Address) (ob map[string]interface{}, err error) {
 	res, err := s.pairDao.GetByTokenAddress(bt, qt)
 	if err != nil {
-		message := map[string]string{
-			"Code":    "Invalid_Pair",
-			"Message": "Invalid Pair " + err.Error(),
-		}
-		bytes, _ := json.Marshal(message)
-		return nil, errors.New(string(bytes))
+		return nil, pairError(err)
 	}
 
 	// sKey, bKey := res.GetOrderBookKeys()
 
 	bids, asks := s.eng.GetOrderBook(res)
 	ob = map[string]interface{}{
+		"pair": res.Reference(),
 		"asks": asks,
 		"bids": bids,
 	}
 	return
 }
 
+// GetAggregatedOrderBook returns the orderbook of a pair with its price levels grouped
+// in buckets of precision decimals, limited to the depth best buckets of each side
+func (s *OrderBookService) GetAggregatedOrderBook(p *types.Pair, precision, depth int) (*types.AggregatedOrderBook, error) {
+	asks, bids, err := s.eng.GetOrderBookLevels(p)
+	if err != nil {
+		return nil, err
+	}
+
+	ob := types.NewAggregatedOrderBook(bids, asks, precision, depth)
+	ob.Pair = p.Reference()
+	return ob, nil
+}
+
+// GetRawOrderBook returns the resting orders of the orderbook of a pair
+func (s *OrderBookService) GetRawOrderBook(p *types.Pair) (*types.RawOrderBook, error) {
+	asks, bids, err := s.eng.GetRawOrderBook(p)
+	if err != nil {
+		return nil, err
+	}
+
+	ob := types.NewRawOrderBook(bids, asks)
+	ob.Pair = p.Reference()
+	return ob, nil
+}
+
+// GetMemoryUsage estimates the redis memory used by the orderbook of each pair by
+// sampling the memory usage of at most samples keys per pair, largest orderbook first
+func (s *OrderBookService) GetMemoryUsage(samples int) ([]*engine.OrderBookMemory, error) {
+	pairs, err := s.pairDao.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := []*engine.OrderBookMemory{}
+	for i := range pairs {
+		m, err := s.eng.GetOrderBookMemory(&pairs[i], samples)
+		if err != nil {
+			return nil, err
+		}
+
+		usage = append(usage, m)
+	}
+
+	sort.SliceStable(usage, func(i, j int) bool { return usage[i].Bytes > usage[j].Bytes })
+	return usage, nil
+}
+
+// GetDepthChart returns the cumulative volume of the bids and asks of a pair in buckets
+// of step percent from the mid-price
+func (s *OrderBookService) GetDepthChart(p *types.Pair, step float64, buckets int) *types.DepthChart {
+	asks, bids := s.eng.GetOrderBook(p)
+	chart := types.NewDepthChart(bids, asks, step, buckets)
+	chart.Pair = p.Reference()
+	return chart
+}
+
+// GetQuote returns the expected fill of an order of a pair against the current orderbook
+func (s *OrderBookService) GetQuote(p *types.Pair, side string, amount float64) *types.Quote {
+	asks, bids := s.eng.GetOrderBook(p)
+
+	levels := asks
+	if side == "SELL" {
+		levels = bids
+	}
+
+	q := types.NewQuote(side, amount, levels)
+	q.Pair = p.Reference()
+	return q
+}
+
 // RegisterForOrderBook is responsible for handling incoming orderbook subscription messages
-// It makes an entry of connection in pairSocket corresponding to pair,unit and duration
+// It makes an entry of connection in pairSocket corresponding to pair,unit and duration.
+// The orderbook snapshot is sequenced with the changes of the price levels sent by the
+// updates of the channel (see PublishOrderBookUpdate). Subscribing again sends a new
+// snapshot, which clients do to resync their orderbook after a gap in the sequence.
 func (s *OrderBookService) Subscribe(conn *websocket.Conn, bt, qt common.Address) {
 	socket := ws.GetOrderBookSocket()
 
-	ob, err := s.GetOrderBook(bt, qt)
+	id := utils.GetOrderBookChannelID(bt, qt)
+	err := socket.SubscribeWithSnapshot(id, conn, func() (map[string]interface{}, error) {
+		p, err := s.pairDao.GetByTokenAddress(bt, qt)
+		if err != nil {
+			return nil, err
+		}
+
+		asks, bids, err := s.eng.GetOrderBookLevels(p)
+		if err != nil {
+			return nil, err
+		}
+
+		orderBookLevels.setIfMissing(id, &bookLevels{asks, bids})
+		return map[string]interface{}{
+			"pair": p.Reference(),
+			"asks": types.OrderBookEntries(asks),
+			"bids": types.OrderBookEntries(bids),
+		}, nil
+	})
+
 	if err != nil {
-		ws.SendOrderBookErrorMessage(conn, err.Error())
+		log.Print(err)
+		ws.SendOrderBookErrorMessage(conn, errors.NewWSError(errors.UnableToRegister.New(nil)))
 		return
 	}
 
+	ws.RegisterConnectionUnsubscribeHandler(conn, socket.UnsubscribeHandler(id))
+}
+
+// UnRegisterForOrderBook is responsible for handling incoming orderbook unsubscription messages
+func (s *OrderBookService) Unsubscribe(conn *websocket.Conn, bt, qt common.Address) {
+	socket := ws.GetOrderBookSocket()
+
 	id := utils.GetOrderBookChannelID(bt, qt)
-	err = socket.Subscribe(id, conn)
+	socket.Unsubscribe(id, conn)
+}
+
+// SubscribeRawOrderBook handles the subscriptions to the full orderbook channel. The
+// subscriber receives the resting orders of the pair, then the changes of the orders.
+func (s *OrderBookService) SubscribeRawOrderBook(conn *websocket.Conn, bt, qt common.Address) {
+	socket := ws.GetRawOrderBookSocket()
+
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
 	if err != nil {
-		message := map[string]string{
-			"Code":    "UNABLE_TO_REGISTER",
-			"Message": "UNABLE_TO_REGISTER " + err.Error(),
+		ws.SendRawOrderBookErrorMessage(conn, errors.NewWSError(pairError(err)))
+		return
+	}
+
+	id := utils.GetOrderBookChannelID(bt, qt)
+	err = socket.SubscribeWithSnapshot(id, conn, func() (map[string]interface{}, error) {
+		ob, err := s.GetRawOrderBook(p)
+		if err != nil {
+			return nil, err
 		}
 
-		ws.SendOrderBookErrorMessage(conn, message)
+		rawOrderBooks.setIfMissing(id, ob.Orders())
+		return map[string]interface{}{
+			"pair": ob.Pair,
+			"asks": ob.Asks,
+			"bids": ob.Bids,
+		}, nil
+	})
+
+	if err != nil {
+		log.Print(err)
+		ws.SendRawOrderBookErrorMessage(conn, errors.NewWSError(errors.UnableToRegister.New(nil)))
 		return
 	}
 
 	ws.RegisterConnectionUnsubscribeHandler(conn, socket.UnsubscribeHandler(id))
-	ws.SendOrderBookInitMessage(conn, ob)
 }
 
-// UnRegisterForOrderBook is responsible for handling incoming orderbook unsubscription messages
-func (s *OrderBookService) Unsubscribe(conn *websocket.Conn, bt, qt common.Address) {
+// UnsubscribeRawOrderBook handles the unsubscriptions from the full orderbook channel
+func (s *OrderBookService) UnsubscribeRawOrderBook(conn *websocket.Conn, bt, qt common.Address) {
+	id := utils.GetOrderBookChannelID(bt, qt)
+	ws.GetRawOrderBookSocket().Unsubscribe(id, conn)
+}
+
+// PublishOrderBookUpdate sends the price levels of a pair changed since the last update
+// to the subscribers of its orderbook channel, and the changes of its resting orders to
+// the subscribers of its full orderbook channel. The orderbook is read while the channel
+// stream is held so that updates and snapshots are numbered in the order they were read.
+// A removed price level is sent with a 0 amount.
+func PublishOrderBookUpdate(eng engine.Engine, p *types.Pair) {
+	publishRawOrderBookUpdate(eng, p)
+
+	id := utils.GetOrderBookChannelID(p.BaseTokenAddress, p.QuoteTokenAddress)
 	socket := ws.GetOrderBookSocket()
+	err := socket.PublishUpdate(id, func() (map[string]interface{}, error) {
+		if !socket.Subscribed(id) {
+			orderBookLevels.remove(id)
+			return nil, nil
+		}
 
-	id := utils.GetOrderBookChannelID(bt, qt)
-	socket.Unsubscribe(id, conn)
+		asks, bids, err := eng.GetOrderBookLevels(p)
+		if err != nil {
+			return nil, err
+		}
+
+		previous := &bookLevels{}
+		if state, ok := orderBookLevels.get(id); ok {
+			previous = state.(*bookLevels)
+		}
+
+		changes := append(types.DiffBookLevels("BUY", previous.bids, bids), types.DiffBookLevels("SELL", previous.asks, asks)...)
+		orderBookLevels.set(id, &bookLevels{asks, bids})
+		if len(changes) == 0 {
+			return nil, nil
+		}
+
+		return map[string]interface{}{
+			"pair":    p.Reference(),
+			"changes": changes,
+		}, nil
+	})
+
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// publishRawOrderBookUpdate sends the orders added, updated and removed since the last
+// update of the full orderbook of a pair to the subscribers of its channel. The orders
+// are not read if the channel has no subscriber.
+func publishRawOrderBookUpdate(eng engine.Engine, p *types.Pair) {
+	id := utils.GetOrderBookChannelID(p.BaseTokenAddress, p.QuoteTokenAddress)
+	socket := ws.GetRawOrderBookSocket()
+	err := socket.PublishUpdate(id, func() (map[string]interface{}, error) {
+		if !socket.Subscribed(id) {
+			rawOrderBooks.remove(id)
+			return nil, nil
+		}
+
+		asks, bids, err := eng.GetRawOrderBook(p)
+		if err != nil {
+			return nil, err
+		}
+
+		previous := []*types.RawOrder{}
+		if state, ok := rawOrderBooks.get(id); ok {
+			previous = state.([]*types.RawOrder)
+		}
+
+		orders := types.NewRawOrderBook(bids, asks).Orders()
+		changes := types.DiffRawOrders(previous, orders)
+		rawOrderBooks.set(id, orders)
+		if len(changes) == 0 {
+			return nil, nil
+		}
+
+		return map[string]interface{}{
+			"pair":    p.Reference(),
+			"changes": changes,
+		}, nil
+	})
+
+	if err != nil {
+		log.Print(err)
+	}
 }
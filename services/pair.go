@@ -1,8 +1,13 @@
 package services
 
 import (
+	"log"
+	"math/big"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/engine"
 	"github.com/ethereum/go-ethereum/common"
 
@@ -11,19 +16,25 @@ import (
 	"github.com/Proofsuite/amp-matching-engine/daos"
 	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/ws"
+	"github.com/gorilla/websocket"
 )
 
+// pairSymbolRegexp matches the display symbols of pairs (eg. AMP-WETH)
+var pairSymbolRegexp = regexp.MustCompile(`^[A-Z0-9.]+-[A-Z0-9.]+$`)
+
 // PairService struct with daos required, responsible for communicating with daos.
 // PairService functions are responsible for interacting with daos and implements business logics.
 type PairService struct {
 	pairDao      *daos.PairDao
 	tokenDao     *daos.TokenDao
-	eng          *engine.Resource
+	eng          engine.Engine
 	tradeService *TradeService
 }
 
 // NewPairService returns a new instance of balance service
-func NewPairService(pairDao *daos.PairDao, tokenDao *daos.TokenDao, eng *engine.Resource, tradeService *TradeService) *PairService {
+func NewPairService(pairDao *daos.PairDao, tokenDao *daos.TokenDao, eng engine.Engine, tradeService *TradeService) *PairService {
 
 	return &PairService{pairDao, tokenDao, eng, tradeService}
 }
@@ -31,30 +42,34 @@ func NewPairService(pairDao *daos.PairDao, tokenDao *daos.TokenDao, eng *engine.
 // Create function is responsible for inserting new pair in DB.
 // It checks for existence of tokens in DB first
 func (s *PairService) Create(pair *types.Pair) error {
+	if pair.BaseTokenAddress == pair.QuoteTokenAddress {
+		return aerrors.BaseAndQuoteTokenIdentical.New(nil)
+	}
+
 	p, err := s.pairDao.GetByBuySellTokenAddress(pair.BaseTokenAddress, pair.QuoteTokenAddress)
 	if err != nil && err.Error() != "NO_PAIR_FOUND" {
-		return aerrors.NewAPIError(400, err.Error(), nil)
+		return aerrors.InternalServerError(err)
 	} else if p != nil {
-		return aerrors.NewAPIError(401, "PAIR_ALREADY_EXISTS", nil)
+		return aerrors.PairAlreadyExists.New(nil)
 	}
 
 	bt, err := s.tokenDao.GetByAddress(pair.BaseTokenAddress)
 	if err != nil {
-		return aerrors.NewAPIError(400, err.Error(), nil)
+		return aerrors.InternalServerError(err)
 	}
 	if bt == nil {
-		return aerrors.NewAPIError(401, "BaseTokenAddress_DOESNT_EXIST", nil)
+		return aerrors.BaseTokenNotFound.New(nil)
 	}
 
 	st, err := s.tokenDao.GetByAddress(pair.QuoteTokenAddress)
 	if err != nil {
-		return aerrors.NewAPIError(400, err.Error(), nil)
+		return aerrors.InternalServerError(err)
 	}
 	if st == nil {
-		return aerrors.NewAPIError(401, "QuoteTokenAddress_DOESNT_EXIST", nil)
+		return aerrors.QuoteTokenNotFound.New(nil)
 	}
 	if !st.Quote {
-		return aerrors.NewAPIError(401, "QuoteTokenAddress_CAN_NOT_BE_USED_AS_QUOTE_TOKEN", nil)
+		return aerrors.QuoteTokenNotAllowed.New(aerrors.Params{"symbol": st.Symbol})
 	}
 
 	pair.QuoteTokenSymbol = st.Symbol
@@ -67,9 +82,326 @@ func (s *PairService) Create(pair *types.Pair) error {
 	pair.BaseTokenDecimal = bt.Decimal
 	pair.Name = strings.ToUpper(st.Symbol + "/" + bt.Symbol)
 
+	// fee overrides are set through the admin API
+	pair.FeeOverride = nil
+
+	if pair.Symbol == "" {
+		pair.Symbol = pair.DefaultSymbol()
+	}
+
+	pair.Symbol = strings.ToUpper(pair.Symbol)
+	err = s.checkSymbol(pair.Symbol, nil)
+	if err != nil {
+		return err
+	}
+
+	if pair.ListingTime != nil && !pair.ListingTime.After(time.Now()) {
+		return aerrors.InvalidListingTime.New(nil)
+	}
+
+	pair.LaunchedAt = nil
 	err = s.pairDao.Create(pair)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if pair.ListingTime != nil {
+		err = s.eng.ScheduleListing(pair.Name, *pair.ListingTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	pair.EffectiveMakeFee, pair.EffectiveTakeFee = pair.EffectiveFees(now)
+	publishMarketEvent("PAIR_LISTED", pair.Market(now))
+	return nil
+}
+
+// CreateAutoPairs applies the automatic pair creation policy (see app.AutoPairsConfig)
+// to a newly listed token. A pair is created between the token, as base token, and each
+// quote token of the policy. Combinations that can not be listed (eg. the pair already
+// exists in either direction) are skipped, the created pairs are returned.
+func (s *PairService) CreateAutoPairs(token *types.Token) ([]*types.Pair, error) {
+	policy := app.Config.AutoPairs
+	if !policy.Enabled {
+		return nil, nil
+	}
+
+	quotes, err := s.autoPairQuoteTokens(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := []*types.Pair{}
+	for _, qt := range quotes {
+		if qt.ContractAddress == token.ContractAddress {
+			continue
+		}
+
+		pair := &types.Pair{
+			BaseTokenAddress:  token.ContractAddress,
+			QuoteTokenAddress: qt.ContractAddress,
+			Active:            policy.Active,
+			MakeFee:           big.NewInt(policy.MakeFee),
+			TakeFee:           big.NewInt(policy.TakeFee),
+			PricePrecision:    policy.PricePrecision,
+			AmountPrecision:   policy.AmountPrecision,
+		}
+
+		err := s.Create(pair)
+		if err != nil {
+			log.Printf("Could not create pair %v/%v automatically: %v", token.Symbol, qt.Symbol, err)
+			continue
+		}
+
+		pairs = append(pairs, pair)
+	}
+
+	return pairs, nil
+}
+
+// autoPairQuoteTokens returns the quote tokens of the automatic pair creation policy.
+// Configured tokens that are not listed or not flagged as quote tokens are ignored.
+func (s *PairService) autoPairQuoteTokens(policy app.AutoPairsConfig) ([]types.Token, error) {
+	if len(policy.QuoteTokens) == 0 {
+		return s.tokenDao.GetQuoteTokens()
+	}
+
+	quotes := []types.Token{}
+	for _, a := range policy.QuoteTokens {
+		if !common.IsHexAddress(a) {
+			log.Printf("Invalid auto pair quote token address: %v", a)
+			continue
+		}
+
+		t, err := s.tokenDao.GetByAddress(common.HexToAddress(a))
+		if err != nil {
+			return nil, err
+		}
+
+		if t == nil || !t.Quote {
+			log.Printf("Auto pair quote token %v is not listed as a quote token", a)
+			continue
+		}
+
+		quotes = append(quotes, *t)
+	}
+
+	return quotes, nil
+}
+
+// Delete removes a pair from the listed pairs. The pair document is tombstoned
+// rather than removed so that existing orders and trades keep resolving it.
+func (s *PairService) Delete(bt, qt common.Address) error {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		return aerrors.PairNotFound.New(nil)
+	}
+
+	err = s.pairDao.Delete(bt, qt)
+	if err != nil {
+		return err
+	}
+
+	publishMarketEvent("PAIR_DELISTED", p.Market(time.Now()))
+	return nil
+}
+
+// HasOpenInterest returns true if the orderbook of a pair holds open orders
+func (s *PairService) HasOpenInterest(bt, qt common.Address) (bool, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		return false, aerrors.PairNotFound.New(nil)
+	}
+
+	sellBook, buyBook := s.eng.GetOrderBook(p)
+	return len(sellBook) > 0 || len(buyBook) > 0, nil
+}
+
+// GetOrderBookEvictions returns the number of orders evicted from the orderbook of each
+// pair, by pair name, by the orderbook size limits
+func (s *PairService) GetOrderBookEvictions() (map[string]int64, error) {
+	return s.eng.GetEvictions()
+}
+
+// Rename changes the display symbol of a pair. Symbols are unique, renaming a pair
+// to a symbol already used by another pair fails.
+func (s *PairService) Rename(bt, qt common.Address, symbol string) (*types.Pair, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		return nil, aerrors.PairNotFound.New(nil)
+	}
+
+	symbol = strings.ToUpper(symbol)
+	err = s.checkSymbol(symbol, p)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.pairDao.UpdateSymbol(bt, qt, symbol)
+	if err != nil {
+		return nil, aerrors.InternalServerError(err)
+	}
+
+	p.Symbol = symbol
+	publishMarketEvent("PAIR_UPDATED", p.Market(time.Now()))
+	return p, nil
+}
+
+// SetFeeOverride replaces the make and take fees of a pair until the override is
+// removed or expires. Pairs are loaded for each order so the new fees apply to the
+// orders received from then on.
+func (s *PairService) SetFeeOverride(bt, qt common.Address, override *types.PairFeeOverride) (*types.Pair, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		return nil, aerrors.PairNotFound.New(nil)
+	}
+
+	if err := override.Validate(); err != nil {
+		return nil, aerrors.InvalidFeeOverride.New(aerrors.Params{"error": err.Error()})
+	}
+
+	now := time.Now()
+	if override.ExpiresAt != nil && !override.ExpiresAt.After(now) {
+		return nil, aerrors.InvalidFeeOverride.New(aerrors.Params{"error": "The override is already expired"})
+	}
+
+	override.UpdatedAt = now
+	err = s.pairDao.UpdateFeeOverride(bt, qt, override)
+	if err != nil {
+		return nil, aerrors.InternalServerError(err)
+	}
+
+	p.FeeOverride = override
+	p.EffectiveMakeFee, p.EffectiveTakeFee = p.EffectiveFees(now)
+	publishMarketEvent("PAIR_UPDATED", p.Market(now))
+	return p, nil
+}
+
+// RemoveFeeOverride restores the default make and take fees of a pair
+func (s *PairService) RemoveFeeOverride(bt, qt common.Address) (*types.Pair, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		return nil, aerrors.PairNotFound.New(nil)
+	}
+
+	err = s.pairDao.UpdateFeeOverride(bt, qt, nil)
+	if err != nil {
+		return nil, aerrors.InternalServerError(err)
+	}
+
+	now := time.Now()
+	p.FeeOverride = nil
+	p.EffectiveMakeFee, p.EffectiveTakeFee = p.EffectiveFees(now)
+	publishMarketEvent("PAIR_UPDATED", p.Market(now))
+	return p, nil
+}
+
+// GetFees returns the fees currently charged on a pair along with its default fees
+// and its fee override
+func (s *PairService) GetFees(bt, qt common.Address) (map[string]interface{}, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		return nil, aerrors.PairNotFound.New(nil)
+	}
+
+	makeFee, takeFee := p.EffectiveFees(time.Now())
+	defaultMakeFee, defaultTakeFee := p.DefaultFees()
+	res := map[string]interface{}{
+		"pair":           p.Reference(),
+		"makeFee":        makeFee.String(),
+		"takeFee":        takeFee.String(),
+		"defaultMakeFee": defaultMakeFee.String(),
+		"defaultTakeFee": defaultTakeFee.String(),
+		"feeOverride":    p.FeeOverride,
+	}
 
+	return res, nil
+}
+
+// ScheduleListing sets or changes the go-live time of a pair. Orders of the pair are
+// collected in the orderbook but not matched until then. Pairs that are already live
+// can not be scheduled.
+func (s *PairService) ScheduleListing(bt, qt common.Address, goLive time.Time) (*types.Pair, error) {
+	p, err := s.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		return nil, aerrors.PairNotFound.New(nil)
+	}
+
+	now := time.Now()
+	if !p.IsPreLaunch(now) {
+		return nil, aerrors.PairAlreadyLaunched.New(nil)
+	}
+
+	if !goLive.After(now) {
+		return nil, aerrors.InvalidListingTime.New(nil)
+	}
+
+	err = s.pairDao.UpdateListingTime(bt, qt, goLive)
+	if err != nil {
+		return nil, aerrors.InternalServerError(err)
+	}
+
+	err = s.eng.ScheduleListing(p.Name, goLive)
+	if err != nil {
+		return nil, err
+	}
+
+	p.ListingTime = &goLive
+	publishMarketEvent("PAIR_UPDATED", p.Market(now))
+	return p, nil
+}
+
+// LaunchDueListings launches the scheduled listings whose go-live time has passed. The
+// engine starts matching orders at the go-live time on its own, launching the listing
+// clears the schedule and records the launch.
+func (s *PairService) LaunchDueListings() ([]types.Pair, error) {
+	now := time.Now()
+	pairs, err := s.pairDao.GetDueListings(now)
+	if err != nil {
+		return nil, err
+	}
+
+	launched := []types.Pair{}
+	for _, p := range pairs {
+		err := s.eng.LaunchListing(p.Name)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		err = s.pairDao.SetLaunched(p.BaseTokenAddress, p.QuoteTokenAddress, now)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		p.LaunchedAt = &now
+		launched = append(launched, p)
+		publishMarketEvent("PAIR_LAUNCHED", p.Market(now))
+	}
+
+	return launched, nil
+}
+
+// checkSymbol verifies that a symbol is well formed (BASE-QUOTE) and that it is
+// not used by another pair than p
+func (s *PairService) checkSymbol(symbol string, p *types.Pair) error {
+	if !pairSymbolRegexp.MatchString(symbol) {
+		return aerrors.InvalidPairSymbol.New(nil)
+	}
+
+	existing, err := s.pairDao.GetBySymbol(symbol)
+	if err != nil {
+		return aerrors.InternalServerError(err)
+	}
+
+	if existing != nil && (p == nil || existing.ID != p.ID) {
+		return aerrors.PairSymbolAlreadyUsed.New(aerrors.Params{"symbol": symbol})
+	}
+
+	return nil
 }
 
 // GetByID fetches details of a pair using its mongo ID
@@ -80,7 +412,64 @@ func (s *PairService) GetByID(id bson.ObjectId) (*types.Pair, error) {
 // GetByTokenAddress fetches details of a pair using contract address of
 // its constituting tokens
 func (s *PairService) GetByTokenAddress(bt, qt common.Address) (*types.Pair, error) {
-	return s.pairDao.GetByTokenAddress(bt, qt)
+	p, err := getPair(s.pairDao, bt, qt)
+	if err != nil {
+		return nil, pairError(err)
+	}
+
+	return p, nil
+}
+
+// pairError returns a PAIR_NOT_FOUND error for the pairs missing from the database
+func pairError(err error) error {
+	if err.Error() == "NO_PAIR_FOUND" {
+		return aerrors.PairNotFound.New(nil)
+	}
+
+	return err
+}
+
+// GetBySymbol fetches a pair from its symbol. The registered display symbol of the
+// pairs is looked up first. Otherwise the symbol is considered to be made of the base
+// and quote token symbols separated by a dash (eg. AMP-WETH). Token symbols are not
+// unique, if several pairs match the symbol an error listing the matching pairs is
+// returned so that the caller can use the token addresses instead.
+func (s *PairService) GetBySymbol(symbol string) (*types.Pair, error) {
+	p, err := s.pairDao.GetBySymbol(symbol)
+	if err != nil {
+		return nil, aerrors.InternalServerError(err)
+	}
+
+	if p != nil {
+		return p, nil
+	}
+
+	symbols := strings.Split(symbol, "-")
+	if len(symbols) != 2 || symbols[0] == "" || symbols[1] == "" {
+		return nil, aerrors.InvalidPairSymbol.New(nil)
+	}
+
+	pairs, err := s.pairDao.GetAllByTokenSymbols(symbols[0], symbols[1])
+	if err != nil {
+		return nil, aerrors.InternalServerError(err)
+	}
+
+	if len(pairs) == 0 {
+		return nil, aerrors.PairNotFound.New(nil)
+	}
+
+	if len(pairs) > 1 {
+		candidates := []types.PairSubDoc{}
+		for _, pair := range pairs {
+			candidates = append(candidates, pair.Reference())
+		}
+
+		err := aerrors.AmbiguousPairSymbol.New(aerrors.Params{"symbol": symbol})
+		err.Details = candidates
+		return nil, err
+	}
+
+	return &pairs[0], nil
 }
 
 // GetAll is reponsible for fetching all the pairs in the DB
@@ -88,6 +477,47 @@ func (s *PairService) GetAll() ([]types.Pair, error) {
 	return s.pairDao.GetAll()
 }
 
+// SubscribeMarkets subscribes the connection to the catalog changes. The listed tokens
+// and pairs are sent in an INIT message, each listing, rule change and delisting is then
+// sent in a message of its own.
+func (s *PairService) SubscribeMarkets(conn *websocket.Conn) {
+	tokens, err := s.tokenDao.GetAll()
+	if err != nil {
+		ws.SendMarketErrorMessage(conn, aerrors.NewWSError(err))
+		return
+	}
+
+	pairs, err := s.pairDao.GetAll()
+	if err != nil {
+		ws.SendMarketErrorMessage(conn, aerrors.NewWSError(err))
+		return
+	}
+
+	socket := ws.GetMarketSocket()
+	err = socket.Subscribe(conn)
+	if err != nil {
+		log.Print(err)
+		ws.SendMarketErrorMessage(conn, aerrors.NewWSError(aerrors.UnableToRegister.New(nil)))
+		return
+	}
+
+	ws.RegisterConnectionUnsubscribeHandler(conn, socket.UnsubscribeHandler())
+	ws.SendMarketMessage(conn, "INIT", types.NewMarkets(tokens, pairs, time.Now()))
+}
+
+// UnsubscribeMarkets removes the connection from the catalog changes
+func (s *PairService) UnsubscribeMarkets(conn *websocket.Conn) {
+	ws.GetMarketSocket().Unsubscribe(conn)
+}
+
+// publishMarketEvent sends a catalog change (eg. PAIR_LISTED with the listed pair) to
+// the connections subscribed to the markets channel. The cached pairs and tokens are
+// cleared as they may be outdated by the change.
+func publishMarketEvent(msgType string, p interface{}) {
+	metadata.invalidate()
+	ws.GetMarketSocket().BroadcastMessage(msgType, p)
+}
+
 // // GetOrderBook fetches orderbook from engine/redis and returns it as an map[string]interface
 // func (s *PairService) GetOrderBook(bt, qt common.Address) (ob map[string]interface{}, err error) {
 // 	res, err := s.GetByTokenAddress(bt, qt)
@@ -139,3 +569,43 @@ func (s *PairService) GetAll() ([]types.Pair, error) {
 // func (s *PairService) UnRegisterForOrderBook(conn *websocket.Conn, bt, qt common.Address) {
 // 	ws.GetPairSockets().UnregisterConnection(bt, qt, conn)
 // }
+
+// pairCache resolves pairs from their token addresses. It caches the pairs it fetched
+// and is meant to be used for the duration of a request to annotate market data payloads
+// with the symbol of the pairs and display formatted values.
+type pairCache struct {
+	pairDao *daos.PairDao
+	pairs   map[string]*types.Pair
+}
+
+func newPairCache(pairDao *daos.PairDao) *pairCache {
+	return &pairCache{pairDao, map[string]*types.Pair{}}
+}
+
+// Get returns the pair made of the given base and quote token. It returns nil if
+// the pair does not exist.
+func (c *pairCache) Get(bt, qt common.Address) *types.Pair {
+	key := utils.GetPairKey(bt, qt)
+	if p, ok := c.pairs[key]; ok {
+		return p
+	}
+
+	p, err := c.pairDao.GetByTokenAddress(bt, qt)
+	if err != nil {
+		p = nil
+	}
+
+	c.pairs[key] = p
+	return p
+}
+
+// Symbol returns the display symbol of the pair made of the given base and quote
+// token. It returns an empty string if the pair does not exist.
+func (c *pairCache) Symbol(bt, qt common.Address) string {
+	p := c.Get(bt, qt)
+	if p == nil {
+		return ""
+	}
+
+	return p.Symbol
+}
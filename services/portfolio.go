@@ -0,0 +1,262 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrInvalidQuote is returned when the quote currency of a portfolio is neither a quote
+// token nor a currency of the fiat price feed
+var ErrInvalidQuote = errors.New("INVALID_QUOTE")
+
+// fiatCrossCurrency is the currency of the fiat prices used to price a token in a quote
+// token when there is no trade between them
+const fiatCrossCurrency = "USD"
+
+// The values are rounded to 8 digits in a quote token and to 2 digits in a fiat currency
+const (
+	tokenValuePrecision = 8
+	fiatValuePrecision  = 2
+)
+
+// PortfolioService values the token balances of the accounts
+type PortfolioService struct {
+	accountDao *daos.AccountDao
+	tokenDao   *daos.TokenDao
+	pairDao    *daos.PairDao
+	tradeDao   *daos.TradeDao
+	depositDao *daos.DepositDao
+	fiatPrices *FiatPriceFeed
+}
+
+// NewPortfolioService returns a portfolio service. fiatPrices is nil when there is no fiat
+// price feed, the portfolios are then only valued in quote tokens.
+func NewPortfolioService(
+	accountDao *daos.AccountDao,
+	tokenDao *daos.TokenDao,
+	pairDao *daos.PairDao,
+	tradeDao *daos.TradeDao,
+	depositDao *daos.DepositDao,
+	fiatPrices *FiatPriceFeed,
+) *PortfolioService {
+	return &PortfolioService{accountDao, tokenDao, pairDao, tradeDao, depositDao, fiatPrices}
+}
+
+// GetPortfolio values the available, locked and pending balances of an account in a quote
+// currency. A quote token (symbol or address) values the tokens with the last trade price
+// of their pair with the quote token, or with the ratio of their fiat prices if they do not
+// trade against it. A fiat currency values the tokens with the prices of the feed.
+func (s *PortfolioService) GetPortfolio(owner common.Address, quote string) (*types.Portfolio, error) {
+	tokens, err := s.tokenDao.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	quoteToken, currency, err := s.resolveQuote(quote, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := s.accountDao.GetTokenBalances(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := s.pendingDeposits(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	precision := tokenValuePrecision
+	portfolio := &types.Portfolio{
+		Address:   owner,
+		Tokens:    []*types.PortfolioToken{},
+		UpdatedAt: time.Now(),
+	}
+
+	if quoteToken != nil {
+		portfolio.Quote = quoteToken.Symbol
+	} else {
+		portfolio.Quote = currency
+		precision = fiatValuePrecision
+	}
+
+	total := new(big.Rat)
+	for _, t := range tokens {
+		tb := balances[t.ContractAddress]
+		p := pending[t.ContractAddress]
+		if tb == nil && p == nil {
+			continue
+		}
+
+		available, locked := big.NewInt(0), big.NewInt(0)
+		if tb != nil {
+			available, locked = tb.Balance, tb.LockedBalance
+		}
+
+		if p == nil {
+			p = big.NewInt(0)
+		}
+
+		amount := math.Add(math.Add(available, locked), p)
+		pt := &types.PortfolioToken{
+			Token:          t.ContractAddress,
+			Symbol:         t.Symbol,
+			Balance:        math.ToDecimalString(available, t.Decimal, t.Decimal),
+			LockedBalance:  math.ToDecimalString(locked, t.Decimal, t.Decimal),
+			PendingBalance: math.ToDecimalString(p, t.Decimal, t.Decimal),
+			Total:          math.ToDecimalString(amount, t.Decimal, t.Decimal),
+			PriceSource:    types.PriceSourceNone,
+		}
+
+		var price *big.Rat
+		if quoteToken != nil {
+			price, pt.PriceSource = s.tokenPrice(&t, quoteToken)
+		} else {
+			price, pt.PriceSource = s.fiatPrice(t.Symbol, currency)
+		}
+
+		if price != nil {
+			value := new(big.Rat).SetFrac(amount, math.Exp10(t.Decimal))
+			value.Mul(value, price)
+			total.Add(total, value)
+
+			pt.Price = price.FloatString(precision)
+			pt.Value = value.FloatString(precision)
+		}
+
+		portfolio.Tokens = append(portfolio.Tokens, pt)
+	}
+
+	portfolio.Value = total.FloatString(precision)
+	return portfolio, nil
+}
+
+// resolveQuote returns the quote token whose symbol or address is quote, or the fiat
+// currency quote is if it is a currency of the price feed
+func (s *PortfolioService) resolveQuote(quote string, tokens []types.Token) (*types.Token, string, error) {
+	for i := range tokens {
+		t := &tokens[i]
+		if !t.Quote {
+			continue
+		}
+
+		if strings.EqualFold(t.Symbol, quote) || (common.IsHexAddress(quote) && common.HexToAddress(quote) == t.ContractAddress) {
+			return t, "", nil
+		}
+	}
+
+	if s.fiatPrices != nil && s.fiatPrices.IsCurrency(quote) {
+		return nil, strings.ToUpper(quote), nil
+	}
+
+	return nil, "", ErrInvalidQuote
+}
+
+// pendingDeposits returns the amounts of the deposits of an account waiting for
+// confirmations, by token
+func (s *PortfolioService) pendingDeposits(owner common.Address) (map[common.Address]*big.Int, error) {
+	deposits, err := s.depositDao.GetByOwner(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make(map[common.Address]*big.Int)
+	for _, d := range deposits {
+		if d.Status != types.DepositPending {
+			continue
+		}
+
+		if pending[d.Token] == nil {
+			pending[d.Token] = big.NewInt(0)
+		}
+
+		pending[d.Token].Add(pending[d.Token], d.Amount)
+	}
+
+	return pending, nil
+}
+
+// tokenPrice returns the price of a token in a quote token and where it comes from: the
+// last trade of their pair, or the ratio of their fiat prices. The price is nil if there is
+// neither.
+func (s *PortfolioService) tokenPrice(t, quote *types.Token) (*big.Rat, string) {
+	if t.ContractAddress == quote.ContractAddress {
+		return big.NewRat(1, 1), types.PriceSourceQuote
+	}
+
+	if price := s.lastTradePrice(t, quote); price != nil {
+		return price, types.PriceSourceTrade
+	}
+
+	// the quote token is priced in another quote token when it is the base token of their pair
+	if price := s.lastTradePrice(quote, t); price != nil && price.Sign() > 0 {
+		return price.Inv(price), types.PriceSourceTrade
+	}
+
+	price, source := s.fiatPrice(t.Symbol, fiatCrossCurrency)
+	if price == nil {
+		return nil, types.PriceSourceNone
+	}
+
+	quotePrice, _ := s.fiatPrice(quote.Symbol, fiatCrossCurrency)
+	if quotePrice == nil || quotePrice.Sign() == 0 {
+		return nil, types.PriceSourceNone
+	}
+
+	return price.Quo(price, quotePrice), source
+}
+
+// lastTradePrice returns the price of the last trade of the base/quote pair in quote
+// tokens for one base token, or nil if the pair does not exist or has no trade
+func (s *PortfolioService) lastTradePrice(base, quote *types.Token) *big.Rat {
+	pair, err := s.pairDao.GetByTokenAddress(base.ContractAddress, quote.ContractAddress)
+	if err != nil || pair == nil {
+		return nil
+	}
+
+	trades, err := s.tradeDao.GetLatestByPairAddress(pair.BaseTokenAddress, pair.QuoteTokenAddress, time.Time{}, 1)
+	if err != nil || len(trades) == 0 {
+		return nil
+	}
+
+	// the price points are the prices multiplied by 1e8
+	price, decimals := trades[0].Price, pair.QuoteTokenDecimal-pair.BaseTokenDecimal
+	if trades[0].PricePoint != nil {
+		price, decimals = trades[0].PricePoint, decimals+8
+	}
+
+	if price == nil {
+		return nil
+	}
+
+	r := new(big.Rat).SetInt(price)
+	if decimals > 0 {
+		r.Quo(r, new(big.Rat).SetInt(math.Exp10(decimals)))
+	} else if decimals < 0 {
+		r.Mul(r, new(big.Rat).SetInt(math.Exp10(-decimals)))
+	}
+
+	return r
+}
+
+// fiatPrice returns the price of a token in a fiat currency from the price feed, or nil
+func (s *PortfolioService) fiatPrice(symbol, currency string) (*big.Rat, string) {
+	if s.fiatPrices == nil {
+		return nil, types.PriceSourceNone
+	}
+
+	price, ok := s.fiatPrices.Price(symbol, currency)
+	if !ok {
+		return nil, types.PriceSourceNone
+	}
+
+	return new(big.Rat).SetFloat64(price), types.PriceSourceFiat
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fiatPriceFeedTimeout is the timeout of the requests to the fiat price feed
+const fiatPriceFeedTimeout = 10 * time.Second
+
+// FiatPriceFeed polls the prices of the tokens in fiat currencies from an external feed.
+// The prices are kept by token symbol and currency, both upper case.
+type FiatPriceFeed struct {
+	url    string
+	prices map[string]map[string]float64
+	mutex  sync.Mutex
+}
+
+// NewFiatPriceFeed returns a price feed fetching the prices from url, which returns them
+// in the cryptocompare format (eg. {"WETH": {"USD": 200.5, "EUR": 175.2}})
+func NewFiatPriceFeed(url string) *FiatPriceFeed {
+	return &FiatPriceFeed{url: url}
+}
+
+// Start fetches the prices every interval
+func (f *FiatPriceFeed) Start(interval time.Duration) {
+	go func() {
+		for {
+			if err := f.Check(); err != nil {
+				log.Printf("Could not fetch the fiat prices: %v", err)
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// Check fetches the prices of the feed. The last prices are kept if it fails.
+func (f *FiatPriceFeed) Check() error {
+	client := &http.Client{Timeout: fiatPriceFeedTimeout}
+	res, err := client.Get(f.url)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("Fiat price feed returned status %d", res.StatusCode)
+	}
+
+	var body map[string]map[string]float64
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return err
+	}
+
+	prices := make(map[string]map[string]float64)
+	for symbol, currencies := range body {
+		p := make(map[string]float64)
+		for currency, price := range currencies {
+			p[strings.ToUpper(currency)] = price
+		}
+
+		prices[strings.ToUpper(symbol)] = p
+	}
+
+	f.mutex.Lock()
+	f.prices = prices
+	f.mutex.Unlock()
+
+	return nil
+}
+
+// Price returns the last price of a token in a currency, if the feed has it
+func (f *FiatPriceFeed) Price(symbol, currency string) (float64, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	price, ok := f.prices[strings.ToUpper(symbol)][strings.ToUpper(currency)]
+	return price, ok && price > 0
+}
+
+// IsCurrency returns true if the feed has prices in a currency
+func (f *FiatPriceFeed) IsCurrency(currency string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	currency = strings.ToUpper(currency)
+	for _, p := range f.prices {
+		if _, ok := p[currency]; ok {
+			return true
+		}
+	}
+
+	return false
+}
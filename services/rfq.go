@@ -0,0 +1,250 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/engine"
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+)
+
+// rfqQuoteTTL returns the time the maker quantity of a firm quote stays reserved
+func rfqQuoteTTL() time.Duration {
+	return time.Duration(app.Config.RFQQuoteTTL) * time.Second
+}
+
+// rfqReservation is a firm quote waiting to be committed, with the engine response of
+// the reservation and the timer releasing it
+type rfqReservation struct {
+	quote *types.RFQQuote
+	resp  *engine.Response
+	timer *time.Timer
+}
+
+// rfqReservations holds the firm quotes of the process. The quotes are not shared
+// between API replicas, a quote must be committed on the replica that issued it.
+type rfqReservations struct {
+	quotes map[bson.ObjectId]*rfqReservation
+	mutex  sync.Mutex
+}
+
+func newRFQReservations() *rfqReservations {
+	return &rfqReservations{quotes: make(map[bson.ObjectId]*rfqReservation)}
+}
+
+func (r *rfqReservations) add(res *rfqReservation) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.quotes[res.quote.ID] = res
+}
+
+func (r *rfqReservations) get(id bson.ObjectId) *rfqReservation {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.quotes[id]
+}
+
+// take removes a quote, it returns nil if the quote was already committed or released
+func (r *rfqReservations) take(id bson.ObjectId) *rfqReservation {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	res := r.quotes[id]
+	delete(r.quotes, id)
+	return res
+}
+
+// reservedOrders returns the maker orders of the quotes in their reserved state, by hash.
+// The state of the latest reservation is returned for the orders reserved by several
+// quotes.
+func (r *rfqReservations) reservedOrders() map[common.Hash]*types.Order {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	orders := make(map[common.Hash]*types.Order)
+	for _, res := range r.quotes {
+		for _, fill := range res.resp.MatchingOrders {
+			o := orders[fill.Order.Hash]
+			if o == nil || o.FilledAmount.Cmp(fill.Order.FilledAmount) < 0 {
+				orders[fill.Order.Hash] = fill.Order
+			}
+		}
+	}
+
+	return orders
+}
+
+// RequestQuote returns a firm quote for an order of a taker. The order is accepted like
+// a new order (its sold amount is locked) and filled completely against the orderbook by
+// the engine, the matched maker quantity is then reserved for the taker until the quote
+// expires. The order is never added to the orderbook, it is rejected with an
+// INSUFFICIENT_LIQUIDITY error if the orderbook can not fill it.
+func (s *OrderService) RequestQuote(o *types.Order) (*types.RFQQuote, error) {
+	if err := s.acceptOrder(o, 0); err != nil {
+		s.journalRejected(o, err)
+		return nil, err
+	}
+
+	res, err := s.engine.ReserveOrder(o)
+	if err != nil {
+		log.Print(err)
+		o.Status = "ERROR"
+		s.releaseQuoteOrder(o)
+		return nil, err
+	}
+
+	for _, c := range res.CancelledOrders {
+		s.handleEngineOrderCancelled(c)
+	}
+
+	if res.FillStatus != engine.FULL {
+		err := aerrors.InsufficientLiquidity.New(nil)
+		s.journalRejected(o, err)
+		s.releaseQuoteOrder(o)
+		return nil, err
+	}
+
+	s.journalEngineResponse(res)
+	q := &types.RFQQuote{
+		ID:        bson.NewObjectId(),
+		OrderHash: o.Hash,
+		Taker:     o.UserAddress,
+		PairName:  o.PairName,
+		Side:      o.Side,
+		Amount:    o.Amount,
+		Trades:    res.Trades,
+		ExpiresAt: time.Now().Add(rfqQuoteTTL()),
+	}
+
+	s.rfq.add(&rfqReservation{
+		quote: q,
+		resp:  res,
+		timer: time.AfterFunc(rfqQuoteTTL(), func() { s.expireQuote(q.ID) }),
+	})
+
+	s.publishQuoteOrderBook(o)
+	return q, nil
+}
+
+// CommitQuote settles the trades of a firm quote with the signatures of the taker. All
+// the trades of the quote must be signed by the taker, the quote stays reserved until it
+// expires otherwise. The orders and balances are then updated as for a matched order and
+// the trades are queued for settlement.
+func (s *OrderService) CommitQuote(id bson.ObjectId, signed []*types.Trade) ([]*types.Trade, error) {
+	r := s.rfq.get(id)
+	if r == nil {
+		return nil, aerrors.QuoteNotFound.New(nil)
+	}
+
+	signatures := make(map[common.Hash]*types.Signature)
+	for _, t := range signed {
+		if t != nil && t.Signature != nil {
+			signatures[t.Hash] = t.Signature
+		}
+	}
+
+	for _, t := range r.quote.Trades {
+		if signatures[t.Hash] == nil {
+			return nil, aerrors.InvalidTradeSignature.New(aerrors.Params{"hash": t.Hash.Hex()})
+		}
+
+		sig := *signatures[t.Hash]
+		trade := *t
+		trade.Signature = &sig
+		if ok, _ := trade.VerifySignature(); !ok {
+			return nil, aerrors.InvalidTradeSignature.New(aerrors.Params{"hash": t.Hash.Hex()})
+		}
+	}
+
+	// the quote expired while the signatures were verified
+	if s.rfq.take(id) == nil {
+		return nil, aerrors.QuoteNotFound.New(nil)
+	}
+
+	r.timer.Stop()
+	resp := r.resp
+	err := s.orderDao.Update(resp.Order.ID, resp.Order)
+	if err != nil {
+		log.Print(err)
+	}
+
+	s.transferAmount(resp.Order, resp.Order.FilledAmount)
+	for _, o := range resp.MatchingOrders {
+		err := s.orderDao.Update(o.Order.ID, o.Order)
+		if err != nil {
+			log.Print(err)
+		}
+
+		s.transferAmount(o.Order, o.Amount)
+	}
+
+	for _, t := range resp.Trades {
+		t.Status = "AWAITING_SIGNATURE"
+	}
+
+	err = s.tradeDao.Create(resp.Trades...)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	s.queueSignedTrades(resp, signed)
+	PublishTrades(resp.Trades)
+	return resp.Trades, nil
+}
+
+// expireQuote releases the maker quantity of a quote that was not committed in time and
+// the amount locked by the order of the taker
+func (s *OrderService) expireQuote(id bson.ObjectId) {
+	r := s.rfq.take(id)
+	if r == nil {
+		return
+	}
+
+	err := s.engine.RecoverOrders(r.resp.MatchingOrders)
+	if err != nil {
+		log.Print(err)
+	}
+
+	o := r.resp.Order
+	o.Status = "CANCELLED"
+	s.releaseQuoteOrder(o)
+	s.journal(newJournalEntry(o.Hash, types.JournalCancelled, map[string]interface{}{"reason": "QUOTE_EXPIRED"}))
+	s.publishQuoteOrderBook(o)
+}
+
+// releaseQuoteOrder stores the final status of the order of a quote that was not
+// committed and unlocks its sold amount
+func (s *OrderService) releaseQuoteOrder(o *types.Order) {
+	o.FilledAmount = math.ToBigInt("0")
+	err := s.orderDao.Update(o.ID, o)
+	if err != nil {
+		log.Print(err)
+	}
+
+	err = s.unlockAmount(o, o.SellAmount)
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// publishQuoteOrderBook sends the orderbook of the pair of a quote to its subscribers
+// after maker quantity was reserved or released
+func (s *OrderService) publishQuoteOrderBook(o *types.Order) {
+	p, err := s.pairDao.GetByTokenAddress(o.BaseToken, o.QuoteToken)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	PublishOrderBookUpdate(s.engine, p)
+}
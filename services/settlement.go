@@ -0,0 +1,418 @@
+package services
+
+import (
+	"log"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+	eth "github.com/ethereum/go-ethereum/core/types"
+	mgo "gopkg.in/mgo.v2"
+)
+
+// SettlementStatuses are the statuses of the trades that have not been settled yet. The
+// trades whose settlement transaction was removed by a chain reorganization are REORGED
+// until the transaction is mined again.
+var SettlementStatuses = []string{"AWAITING_SIGNATURE", "AWAITING_BROADCAST", "PENDING_CONFIRMATION", "REORGED"}
+
+// BustableStatuses are the statuses of the trades that can be busted: their settlement
+// transaction has not been sent yet
+var BustableStatuses = []string{"AWAITING_SIGNATURE", "AWAITING_BROADCAST"}
+
+// FlaggableStatuses are the statuses of the settled trades, which can only be flagged
+var FlaggableStatuses = []string{"SUCCESS", "SKIPPED"}
+
+// TradeQueue is implemented by the operator to queue trades for settlement
+type TradeQueue interface {
+	QueueTrade(o *types.Order, t *types.Trade) error
+}
+
+// TradeBustHandler is implemented by the order service to revert the balance effects of
+// a busted trade and notify its maker and taker
+type TradeBustHandler interface {
+	HandleTradeBust(t *types.Trade) error
+}
+
+// SettlementService exposes the settlement backlog and allows administrators to
+// retry, skip or cancel stuck settlements. Every intervention is written to the audit log.
+// It also records the cost of the settlement transactions and busts erroneous trades.
+type SettlementService struct {
+	tradeDao *daos.TradeDao
+	orderDao *daos.OrderDao
+	auditDao *daos.AuditDao
+	costDao  *daos.SettlementCostDao
+	queue    TradeQueue
+	busts    TradeBustHandler
+	webhook  *SettlementWebhook
+}
+
+// NewSettlementService returns a new instance of SettlementService. queue can be nil
+// if the operator is not running in this process, in which case trades can not be retried.
+func NewSettlementService(
+	tradeDao *daos.TradeDao,
+	orderDao *daos.OrderDao,
+	auditDao *daos.AuditDao,
+	costDao *daos.SettlementCostDao,
+	queue TradeQueue,
+	busts TradeBustHandler,
+) *SettlementService {
+	return &SettlementService{tradeDao, orderDao, auditDao, costDao, queue, busts, nil}
+}
+
+// SetTradeQueue sets the operator queue used to retry the settlement of the trades
+func (s *SettlementService) SetTradeQueue(queue TradeQueue) {
+	s.queue = queue
+}
+
+// SetWebhook sets the webhook the settled trades are posted to
+func (s *SettlementService) SetWebhook(webhook *SettlementWebhook) {
+	s.webhook = webhook
+}
+
+// GetBacklog returns the trades with the given settlement statuses. All the trades that
+// have not been settled yet are returned if no status is given.
+func (s *SettlementService) GetBacklog(statuses ...string) ([]*types.Trade, error) {
+	if len(statuses) == 0 {
+		statuses = SettlementStatuses
+	}
+
+	return s.tradeDao.GetByStatus(statuses...)
+}
+
+// Retry queues a trade for settlement again on behalf of an administrator
+func (s *SettlementService) Retry(hash common.Hash, admin string) (*types.Trade, error) {
+	if s.queue == nil {
+		return nil, errors.OperatorUnavailable.New(nil)
+	}
+
+	t, err := s.getPendingTrade(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := s.orderDao.GetByHash(t.OrderHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if o == nil {
+		return nil, errors.OrderNotFound.New(nil)
+	}
+
+	err = s.updateStatus("RETRY", admin, t, "AWAITING_BROADCAST")
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.queue.QueueTrade(o, t)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Skip marks a trade as settled outside of the settlement pipeline on behalf of an
+// administrator
+func (s *SettlementService) Skip(hash common.Hash, admin string) (*types.Trade, error) {
+	t, err := s.getPendingTrade(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.updateStatus("SKIP", admin, t, "SKIPPED")
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Cancel removes a trade from the settlement pipeline on behalf of an administrator
+func (s *SettlementService) Cancel(hash common.Hash, admin string) (*types.Trade, error) {
+	t, err := s.getPendingTrade(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.updateStatus("CANCEL", admin, t, "CANCELLED")
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// GetBustableTrade returns a trade that can be busted or flagged
+func (s *SettlementService) GetBustableTrade(hash common.Hash) (*types.Trade, error) {
+	t, err := s.tradeDao.GetByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		return nil, errors.TradeNotFound.New(nil)
+	}
+
+	if t.Flagged || !(hasStatus(t, BustableStatuses) || hasStatus(t, FlaggableStatuses)) {
+		return nil, errors.TradeNotBustable.New(errors.Params{"status": t.Status})
+	}
+
+	return t, nil
+}
+
+// Bust busts an erroneous trade whose settlement transaction has not been sent yet: the
+// trade is removed from the settlement pipeline (status BUSTED) and its balance effects
+// are reverted. A settled trade can not be reverted and is flagged instead. Both parties
+// are notified and the decision is written to the audit log with its rationale and the
+// administrators who requested and approved it.
+func (s *SettlementService) Bust(hash common.Hash, reason, requestedBy, approvedBy string) (*types.Trade, error) {
+	t, err := s.GetBustableTrade(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	previousStatus := t.Status
+	action := "TRADE_FLAGGED"
+	t.BustReason = reason
+	if hasStatus(t, BustableStatuses) {
+		action = "TRADE_BUSTED"
+		t.Status = "BUSTED"
+	} else {
+		t.Flagged = true
+	}
+
+	// the operator may have sent the settlement transaction in the meantime
+	err = s.tradeDao.UpdateIfStatus(t, previousStatus)
+	if err == mgo.ErrNotFound {
+		return nil, errors.TradeStatusChanged.New(nil)
+	}
+
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	err = s.auditDao.Create(&types.AuditLog{
+		Action: action,
+		Target: t.Hash.Hex(),
+		Admin:  approvedBy,
+		Details: map[string]interface{}{
+			"previousStatus": previousStatus,
+			"status":         t.Status,
+			"reason":         reason,
+			"requestedBy":    requestedBy,
+			"approvedBy":     approvedBy,
+		},
+	})
+
+	if err != nil {
+		log.Print(err)
+	}
+
+	if s.busts != nil {
+		err = s.busts.HandleTradeBust(t)
+		if err != nil {
+			log.Print(err)
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// RecordCost records the gas used and the cost of the mined settlement transaction of a
+// trade, along with the fees paid by the maker and the taker of the trade
+func (s *SettlementService) RecordCost(t *types.Trade, receipt *eth.Receipt) (*types.SettlementCost, error) {
+	if t.Tx == nil || receipt == nil {
+		return nil, nil
+	}
+
+	fees, err := s.getTradeFees(t)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	c := types.NewSettlementCost(t, receipt.GasUsed, t.Tx.GasPrice(), fees)
+
+	// the status of the trade might not be updated yet
+	c.Status = "SUCCESS"
+	if receipt.Status == eth.ReceiptStatusFailed {
+		c.Status = "ERROR"
+	}
+
+	err = s.costDao.Create(c)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// HandleSettled posts a settled trade to the settlement webhook with the cost of its
+// settlement transaction, mined in the block blockNumber. c can be nil if the cost could
+// not be recorded.
+func (s *SettlementService) HandleSettled(t *types.Trade, c *types.SettlementCost, blockNumber uint64) error {
+	if s.webhook == nil {
+		return nil
+	}
+
+	return s.webhook.Enqueue(types.NewSettlementEvent(t, c, blockNumber))
+}
+
+// GetCostStats returns the settlement costs between from and to aggregated per pair
+// and per day, most recent day first
+func (s *SettlementService) GetCostStats(from, to time.Time) ([]*types.SettlementCostStats, error) {
+	costs, err := s.costDao.GetByDateRange(from, to)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return aggregateCosts(costs), nil
+}
+
+// getTradeFees returns the part of the make fee of the maker order and of the take fee of
+// the taker order paid by a trade. The fees of an order are charged for its whole amount,
+// an order filled by several trades pays its fees in proportion to the amount of each.
+func (s *SettlementService) getTradeFees(t *types.Trade) (*big.Int, error) {
+	fees := big.NewInt(0)
+
+	orders, err := s.orderDao.GetByIDs(t.MakerOrderID, t.TakerOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range orders {
+		fee := o.TakeFee
+		if o.ID == t.MakerOrderID {
+			fee = o.MakeFee
+		}
+
+		fees = math.Add(fees, tradeFee(fee, t.Amount, o.Amount))
+	}
+
+	return fees, nil
+}
+
+// tradeFee returns the part of the fee of an order of the given amount paid by a trade
+func tradeFee(fee, tradeAmount, orderAmount *big.Int) *big.Int {
+	if fee == nil || tradeAmount == nil || orderAmount == nil || orderAmount.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+
+	return math.Div(math.Mul(fee, tradeAmount), orderAmount)
+}
+
+// aggregateCosts aggregates the settlement costs per pair and per day (UTC)
+func aggregateCosts(costs []*types.SettlementCost) []*types.SettlementCostStats {
+	type key struct {
+		pair string
+		date string
+	}
+
+	stats := map[key]*types.SettlementCostStats{}
+	for _, c := range costs {
+		k := key{c.PairName, c.CreatedAt.UTC().Format("2006-01-02")}
+		if stats[k] == nil {
+			stats[k] = &types.SettlementCostStats{
+				PairName:   c.PairName,
+				BaseToken:  c.BaseToken,
+				QuoteToken: c.QuoteToken,
+				Date:       k.date,
+			}
+		}
+
+		stats[k].Add(c)
+	}
+
+	res := []*types.SettlementCostStats{}
+	for _, st := range stats {
+		res = append(res, st)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Date != res[j].Date {
+			return res[i].Date > res[j].Date
+		}
+
+		return res[i].PairName < res[j].PairName
+	})
+
+	return res
+}
+
+func (s *SettlementService) getPendingTrade(hash common.Hash) (*types.Trade, error) {
+	t, err := s.tradeDao.GetByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		return nil, errors.TradeNotFound.New(nil)
+	}
+
+	// trades that failed on-chain can be retried as well
+	if t.Status != "ERROR" {
+		pending := false
+		for _, status := range SettlementStatuses {
+			if t.Status == status {
+				pending = true
+			}
+		}
+
+		if !pending {
+			return nil, errors.TradeAlreadySettled.New(nil)
+		}
+	}
+
+	return t, nil
+}
+
+// hasStatus returns true if the status of the trade is one of statuses
+func hasStatus(t *types.Trade, statuses []string) bool {
+	for _, status := range statuses {
+		if t.Status == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateStatus updates the status of the trade and writes the intervention of the
+// administrator to the audit log
+func (s *SettlementService) updateStatus(action, admin string, t *types.Trade, status string) error {
+	entry := &types.AuditLog{
+		Action: "SETTLEMENT_" + action,
+		Target: t.Hash.Hex(),
+		Admin:  admin,
+		Details: map[string]interface{}{
+			"previousStatus": t.Status,
+			"status":         status,
+		},
+	}
+
+	t.Status = status
+	err := s.tradeDao.Update(t)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	err = s.auditDao.Create(entry)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/types"
+)
+
+// settlementWebhookBatch is the number of events posted by a check of the pending events
+const settlementWebhookBatch = 100
+
+// maxSettlementRetryDelay caps the delay between two delivery attempts of an event
+const maxSettlementRetryDelay = time.Hour
+
+// SettlementWebhook posts the settled trades to the webhook of an external system. The
+// events are stored before they are posted and posted again until the webhook answers with
+// a 2xx status, so that each settled trade is delivered at least once. The body of the
+// requests is signed with a shared secret.
+type SettlementWebhook struct {
+	eventDao *daos.SettlementEventDao
+	url      string
+	secret   []byte
+	client   *http.Client
+}
+
+// NewSettlementWebhook returns a webhook posting the settlement events to url, signed
+// with secret
+func NewSettlementWebhook(eventDao *daos.SettlementEventDao, url, secret string) *SettlementWebhook {
+	return &SettlementWebhook{eventDao, url, []byte(secret), &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Start posts the pending events, then checks the events due for delivery at each interval
+func (w *SettlementWebhook) Start(interval time.Duration) {
+	go func() {
+		for {
+			w.deliverDue()
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// Enqueue stores the settlement event of a trade and posts it right away. An event that
+// is not delivered is posted again by the next checks.
+func (w *SettlementWebhook) Enqueue(e *types.SettlementEvent) error {
+	created, err := w.eventDao.Create(e)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	if created {
+		go w.deliver(e)
+	}
+
+	return nil
+}
+
+// deliverDue posts the events whose next delivery attempt is due
+func (w *SettlementWebhook) deliverDue() {
+	for {
+		events, err := w.eventDao.GetDue(time.Now(), settlementWebhookBatch)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		failed := 0
+		for _, e := range events {
+			if !w.deliver(e) {
+				failed++
+			}
+		}
+
+		// the failed events are not due anymore, stop when the webhook fails them all
+		if len(events) < settlementWebhookBatch || failed == len(events) {
+			return
+		}
+	}
+}
+
+// deliver posts an event and records the outcome of the attempt. Failed attempts are
+// retried with an exponential backoff.
+func (w *SettlementWebhook) deliver(e *types.SettlementEvent) bool {
+	err := w.post(e)
+	if err == nil {
+		err = w.eventDao.MarkDelivered(e)
+		if err != nil {
+			log.Printf("Could not record the delivery of the settlement of trade %s: %v", e.TradeHash.Hex(), err)
+		}
+
+		return true
+	}
+
+	log.Printf("Could not post the settlement of trade %s: %v", e.TradeHash.Hex(), err)
+	next := time.Now().Add(settlementRetryDelay(e.Attempts + 1))
+	err = w.eventDao.MarkFailed(e, err.Error(), next)
+	if err != nil {
+		log.Printf("Could not record the failure of the settlement of trade %s: %v", e.TradeHash.Hex(), err)
+	}
+
+	return false
+}
+
+// post sends an event to the webhook. The X-Signature header is the hex encoded
+// HMAC-SHA256 of the X-Signature-Timestamp header, a dot and the body.
+func (w *SettlementWebhook) post(e *types.SettlementEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", e.ID.Hex())
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature", SignSettlementEvent(w.secret, timestamp, body))
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("Webhook returned status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// SignSettlementEvent returns the signature of the body of a settlement event posted at
+// timestamp (unix seconds)
+func SignSettlementEvent(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// settlementRetryDelay returns the delay before the next attempt after a number of failed
+// attempts: 10 seconds doubled at each attempt, up to an hour
+func settlementRetryDelay(attempts int) time.Duration {
+	delay := 10 * time.Second
+	for i := 1; i < attempts && delay < maxSettlementRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxSettlementRetryDelay {
+		return maxSettlementRetryDelay
+	}
+
+	return delay
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/engine"
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/Proofsuite/amp-matching-engine/ws"
+	"github.com/gorilla/websocket"
+)
+
+// tickerPeriod is the period of the trade statistics of the tickers
+const tickerPeriod = 24 * time.Hour
+
+// tickerInterval returns the time after which the tickers are computed again
+func tickerInterval() time.Duration {
+	return time.Duration(app.Config.TickerInterval) * time.Second
+}
+
+// TickerService computes the 24 hour tickers of the pairs from the trades of the last 24
+// hours and the top of the orderbooks. The tickers are computed at most once per
+// ticker_interval seconds and shared by the requests and the tickers channel.
+// mutex protects the tickers and the time they were computed
+type TickerService struct {
+	tradeDao *daos.TradeDao
+	pairDao  *daos.PairDao
+	eng      engine.Engine
+	tickers  []*types.Ticker
+	updated  time.Time
+	mutex    sync.Mutex
+}
+
+// NewTickerService returns a new instance of TickerService
+func NewTickerService(tradeDao *daos.TradeDao, pairDao *daos.PairDao, eng engine.Engine) *TickerService {
+	return &TickerService{tradeDao: tradeDao, pairDao: pairDao, eng: eng}
+}
+
+// GetTickers returns the tickers of the active pairs. The display representation of the
+// prices and of the volumes is added when formatted is true.
+func (s *TickerService) GetTickers(formatted bool) ([]*types.Ticker, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if s.tickers == nil || now.Sub(s.updated) >= tickerInterval() {
+		tickers, err := s.computeTickers(now)
+		if err != nil {
+			return nil, err
+		}
+
+		s.tickers, s.updated = tickers, now
+	}
+
+	if !formatted {
+		return s.tickers, nil
+	}
+
+	// the cached tickers are shared, the formatted tickers are copies
+	pairs := newPairCache(s.pairDao)
+	res := []*types.Ticker{}
+	for _, t := range s.tickers {
+		tk := *t
+		if p := pairs.Get(tk.Pair.BaseToken, tk.Pair.QuoteToken); p != nil {
+			tk.SetFormatted(p)
+		}
+
+		res = append(res, &tk)
+	}
+
+	return res, nil
+}
+
+// computeTickers computes the tickers of the active pairs at the given time
+func (s *TickerService) computeTickers(now time.Time) ([]*types.Ticker, error) {
+	pairs, err := s.pairDao.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.tradeDao.GetStatsSince(now.Add(-tickerPeriod))
+	if err != nil {
+		return nil, err
+	}
+
+	byPair := map[string]*types.TradeStats{}
+	for _, st := range stats {
+		byPair[utils.GetPairKey(st.BaseToken, st.QuoteToken)] = st
+	}
+
+	tickers := []*types.Ticker{}
+	untraded := map[string]*types.Ticker{}
+	untradedPairs := []*types.Pair{}
+	for i := range pairs {
+		p := &pairs[i]
+		if !p.Active {
+			continue
+		}
+
+		asks, bids, err := s.eng.GetOrderBookLevels(p)
+		if err != nil {
+			log.Print(err)
+		}
+
+		key := utils.GetPairKey(p.BaseTokenAddress, p.QuoteTokenAddress)
+		st := byPair[key]
+		tk := types.NewTicker(p, st, asks, bids, now)
+		if st == nil {
+			untraded[key] = tk
+			untradedPairs = append(untradedPairs, p)
+		}
+
+		tickers = append(tickers, tk)
+	}
+
+	// the last price of the pairs not traded during the period is the price of their
+	// latest trade
+	latest, err := s.tradeDao.GetLatestByPairs(untradedPairs)
+	if err != nil {
+		log.Print(err)
+		return tickers, nil
+	}
+
+	for _, t := range latest {
+		if tk := untraded[utils.GetPairKey(t.BaseToken, t.QuoteToken)]; tk != nil && t.PricePoint != nil {
+			tk.Last = t.PricePoint.String()
+		}
+	}
+
+	return tickers, nil
+}
+
+// Subscribe subscribes the connection to the tickers. The current tickers are sent in an
+// INIT message, followed by an UPDATE message with all the tickers every ticker_interval
+// seconds.
+func (s *TickerService) Subscribe(conn *websocket.Conn) {
+	tickers, err := s.GetTickers(false)
+	if err != nil {
+		ws.SendTickerErrorMessage(conn, aerrors.NewWSError(err))
+		return
+	}
+
+	socket := ws.GetTickerSocket()
+	err = socket.Subscribe(conn)
+	if err != nil {
+		log.Print(err)
+		ws.SendTickerErrorMessage(conn, aerrors.NewWSError(aerrors.UnableToRegister.New(nil)))
+		return
+	}
+
+	ws.RegisterConnectionUnsubscribeHandler(conn, socket.UnsubscribeHandler())
+	ws.SendTickerMessage(conn, "INIT", tickers)
+}
+
+// Unsubscribe removes the connection from the tickers
+func (s *TickerService) Unsubscribe(conn *websocket.Conn) {
+	ws.GetTickerSocket().Unsubscribe(conn)
+}
+
+// PublishTickers sends the current tickers to the connections subscribed to the tickers
+// channel. The tickers are not computed when there are no subscribers.
+func (s *TickerService) PublishTickers() {
+	socket := ws.GetTickerSocket()
+	if !socket.HasSubscribers() {
+		return
+	}
+
+	tickers, err := s.GetTickers(false)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	socket.BroadcastMessage("UPDATE", tickers)
+}
@@ -1,6 +1,9 @@
 package services
 
 import (
+	"log"
+	"strconv"
+
 	"github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/ethereum/go-ethereum/common"
 	"gopkg.in/mgo.v2/bson"
@@ -12,15 +15,18 @@ import (
 // TokenService struct with daos required, responsible for communicating with daos.
 // TokenService functions are responsible for interacting with daos and implements business logics.
 type TokenService struct {
-	tokenDao *daos.TokenDao
+	tokenDao    *daos.TokenDao
+	pairDao     *daos.PairDao
+	pairService *PairService
 }
 
 // NewTokenService returns a new instance of TokenService
-func NewTokenService(tokenDao *daos.TokenDao) *TokenService {
-	return &TokenService{tokenDao}
+func NewTokenService(tokenDao *daos.TokenDao, pairDao *daos.PairDao, pairService *PairService) *TokenService {
+	return &TokenService{tokenDao, pairDao, pairService}
 }
 
-// Create inserts a new token into the database
+// Create inserts a new token into the database. Pairs are then created for the
+// token according to the automatic pair creation policy.
 func (s *TokenService) Create(token *types.Token) error {
 	t, err := s.tokenDao.GetByAddress(token.ContractAddress)
 	if err != nil {
@@ -28,10 +34,152 @@ func (s *TokenService) Create(token *types.Token) error {
 	}
 
 	if t != nil {
-		return errors.NewAPIError(401, "TOKEN_ALREADY_EXISTS", nil)
+		return errors.TokenAlreadyExists.New(nil)
+	}
+
+	err = s.tokenDao.Create(token)
+	if err != nil {
+		return err
 	}
 
-	return s.tokenDao.Create(token)
+	publishMarketEvent("TOKEN_LISTED", token.Market())
+	s.createAutoPairs(token)
+	return nil
+}
+
+// CreateMany lists several tokens at once. The tokens are all checked before any of
+// them is inserted: if one of them is invalid, is listed twice or already exists,
+// nothing is inserted and the problems are returned in the error details indexed
+// by the position of the token in the request.
+func (s *TokenService) CreateMany(tokens []*types.Token) error {
+	if len(tokens) == 0 {
+		err := errors.InvalidRequestData.New(nil)
+		err.Details = "no token to create"
+		return err
+	}
+
+	problems := map[string]string{}
+	seen := map[common.Address]int{}
+	for i, token := range tokens {
+		key := strconv.Itoa(i)
+
+		if err := token.Validate(); err != nil {
+			problems[key] = err.Error()
+			continue
+		}
+
+		if j, ok := seen[token.ContractAddress]; ok {
+			problems[key] = "DUPLICATE_OF_TOKEN_" + strconv.Itoa(j)
+			continue
+		}
+		seen[token.ContractAddress] = i
+
+		t, err := s.tokenDao.GetByAddress(token.ContractAddress)
+		if err != nil {
+			return err
+		}
+
+		if t != nil {
+			problems[key] = "TOKEN_ALREADY_EXISTS"
+		}
+	}
+
+	if len(problems) > 0 {
+		err := errors.InvalidRequestData.New(nil)
+		err.Details = problems
+		return err
+	}
+
+	err := s.tokenDao.CreateMany(tokens)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		publishMarketEvent("TOKEN_LISTED", token.Market())
+		s.createAutoPairs(token)
+	}
+
+	return nil
+}
+
+// createAutoPairs creates the pairs of a newly listed token. The token is listed
+// even if its pairs can not be created, they can still be created with POST /pairs.
+func (s *TokenService) createAutoPairs(token *types.Token) {
+	if s.pairService == nil {
+		return
+	}
+
+	pairs, err := s.pairService.CreateAutoPairs(token)
+	if err != nil {
+		log.Printf("Could not create pairs for token %v: %v", token.Symbol, err)
+		return
+	}
+
+	for _, p := range pairs {
+		log.Printf("Created pair %v for token %v", p.Symbol, token.Symbol)
+	}
+}
+
+// SetQuote sets whether a token can be used as the quote token of a pair. A token
+// can not lose its quote designation while listed pairs are quoted in it.
+func (s *TokenService) SetQuote(addr common.Address, quote bool) (*types.Token, error) {
+	t, err := s.tokenDao.GetByAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		return nil, errors.TokenNotFound.New(nil)
+	}
+
+	if !quote {
+		pairs, err := s.pairDao.GetByQuoteTokenAddress(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(pairs) > 0 {
+			refs := []types.PairSubDoc{}
+			for _, p := range pairs {
+				refs = append(refs, p.Reference())
+			}
+
+			err := errors.QuoteTokenInUse.New(errors.Params{"symbol": t.Symbol})
+			err.Details = refs
+			return nil, err
+		}
+	}
+
+	err = s.tokenDao.UpdateQuote(addr, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Quote = quote
+	publishMarketEvent("TOKEN_UPDATED", t.Market())
+	return t, nil
+}
+
+// Delete removes a token from the listed tokens. The token document is tombstoned
+// rather than removed so that existing pairs, orders and trades keep resolving it.
+func (s *TokenService) Delete(addr common.Address) error {
+	t, err := s.tokenDao.GetByAddress(addr)
+	if err != nil {
+		return err
+	}
+
+	if t == nil {
+		return errors.TokenNotFound.New(nil)
+	}
+
+	err = s.tokenDao.Delete(addr)
+	if err != nil {
+		return err
+	}
+
+	publishMarketEvent("TOKEN_DELISTED", t.Market())
+	return nil
 }
 
 // GetByID fetches the detailed document of a token using its mongo ID
@@ -41,10 +189,15 @@ func (s *TokenService) GetByID(id bson.ObjectId) (*types.Token, error) {
 
 // GetByAddress fetches the detailed document of a token using its contract address
 func (s *TokenService) GetByAddress(addr common.Address) (*types.Token, error) {
-	return s.tokenDao.GetByAddress(addr)
+	return getToken(s.tokenDao, addr)
 }
 
 // GetAll fetches all the tokens from db
 func (s *TokenService) GetAll() ([]types.Token, error) {
 	return s.tokenDao.GetAll()
 }
+
+// GetQuoteTokens fetches all the tokens that can be used as quote tokens
+func (s *TokenService) GetQuoteTokens() ([]types.Token, error) {
+	return s.tokenDao.GetQuoteTokens()
+}
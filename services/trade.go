@@ -1,7 +1,12 @@
 package services
 
 import (
+	"log"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
 	"github.com/Proofsuite/amp-matching-engine/daos"
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/Proofsuite/amp-matching-engine/ws"
@@ -11,35 +16,165 @@ import (
 	eth "github.com/ethereum/go-ethereum/core/types"
 )
 
+// The trades INIT message contains the latest trades of the pair. The number of trades
+// can be set with the limit parameter of the subscription, up to maxTradeHistoryLimit.
+const (
+	defaultTradeHistoryLimit = 100
+	maxTradeHistoryLimit     = 1000
+)
+
+// maxClockSkew returns the maximum difference between the timestamp signed in a request
+// (eg. a user channel subscription) and the time it is received
+func maxClockSkew() time.Duration {
+	return time.Duration(app.Config.MaxClockSkew) * time.Second
+}
+
+// subscriptionError returns the error message of a rejected signed subscription. The
+// subscriptions rejected for their timestamp get a CLOCK_SKEW error with the server time,
+// so that clients can resync their clock and sign the subscription again.
+func subscriptionError(err error) map[string]interface{} {
+	if err == types.ErrClockSkew {
+		return map[string]interface{}{
+			"Code":       aerrors.ClockSkew.Code,
+			"Message":    "CLOCK_SKEW " + err.Error(),
+			"ServerTime": time.Now().Unix(),
+		}
+	}
+
+	return map[string]interface{}{
+		"Code":    aerrors.AuthenticationFailed.Code,
+		"Message": "UNAUTHORIZED " + err.Error(),
+	}
+}
+
 // TradeService struct with daos required, responsible for communicating with daos.
 // TradeService functions are responsible for interacting with daos and implements business logics.
 type TradeService struct {
 	tradeDao *daos.TradeDao
+	pairDao  *daos.PairDao
 }
 
 // NewTradeService returns a new instance of TradeService
-func NewTradeService(TradeDao *daos.TradeDao) *TradeService {
-	return &TradeService{TradeDao}
+func NewTradeService(TradeDao *daos.TradeDao, pairDao *daos.PairDao) *TradeService {
+	return &TradeService{TradeDao, pairDao}
 }
 
 // GetByPairName fetches all the trades corresponding to a pair using pair's name
 func (t *TradeService) GetByPairName(pairName string) ([]*types.Trade, error) {
-	return t.tradeDao.GetByPairName(pairName)
+	trades, err := t.tradeDao.GetByPairName(pairName)
+	if err != nil {
+		return nil, err
+	}
+
+	t.setPairSymbols(trades)
+	return trades, nil
 }
 
 // GetTrades is currently not implemented correctly
 func (t *TradeService) GetTrades(bt, qt common.Address) ([]types.Trade, error) {
-	return t.tradeDao.GetAll()
+	trades, err := t.tradeDao.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := newPairCache(t.pairDao)
+	for i := range trades {
+		trades[i].PairSymbol = pairs.Symbol(trades[i].BaseToken, trades[i].QuoteToken)
+	}
+
+	return trades, nil
+}
+
+// GetLatestTrades fetches the latest trades of a pair in chronological order. If from
+// is not zero, only the trades created at or after from are returned. The number of
+// trades defaults to the last 100 trades and is capped to 1000.
+func (t *TradeService) GetLatestTrades(bt, qt common.Address, from time.Time, limit int) ([]*types.Trade, error) {
+	if limit <= 0 {
+		limit = defaultTradeHistoryLimit
+	}
+
+	if limit > maxTradeHistoryLimit {
+		limit = maxTradeHistoryLimit
+	}
+
+	trades, err := t.tradeDao.GetLatestByPairAddress(bt, qt, from, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// the trades are fetched from the most recent one
+	for i, j := 0, len(trades)-1; i < j; i, j = i+1, j-1 {
+		trades[i], trades[j] = trades[j], trades[i]
+	}
+
+	t.setPairSymbols(trades)
+	return trades, nil
 }
 
 // GetByPairAddress fetches all the trades corresponding to a pair using pair's token address
 func (t *TradeService) GetByPairAddress(bt, qt common.Address) ([]*types.Trade, error) {
-	return t.tradeDao.GetByPairAddress(bt, qt)
+	trades, err := t.tradeDao.GetByPairAddress(bt, qt)
+	if err != nil {
+		return nil, err
+	}
+
+	t.setPairSymbols(trades)
+	return trades, nil
+}
+
+// Query fetches a page of the trades matching the filters of the query, most recent
+// first. The number of trades defaults to 100 and is capped to 1000.
+func (t *TradeService) Query(q *types.TradeQuery) ([]*types.Trade, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultTradeHistoryLimit
+	}
+
+	if q.Limit > maxTradeHistoryLimit {
+		q.Limit = maxTradeHistoryLimit
+	}
+
+	trades, err := t.tradeDao.Query(q)
+	if err != nil {
+		return nil, err
+	}
+
+	t.setPairSymbols(trades)
+	return trades, nil
 }
 
 // GetByUserAddress fetches all the trades corresponding to a user address
 func (t *TradeService) GetByUserAddress(addr common.Address) ([]*types.Trade, error) {
-	return t.tradeDao.GetByUserAddress(addr)
+	trades, err := t.tradeDao.GetByUserAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	t.setPairSymbols(trades)
+	return trades, nil
+}
+
+// setPairSymbols annotates trades with the current display symbol of their pair
+func (t *TradeService) setPairSymbols(trades []*types.Trade) {
+	pairs := newPairCache(t.pairDao)
+	for _, tr := range trades {
+		if tr != nil {
+			tr.PairSymbol = pairs.Symbol(tr.BaseToken, tr.QuoteToken)
+		}
+	}
+}
+
+// SetFormatted adds the display representation of the price and amount to trades
+func (t *TradeService) SetFormatted(trades []*types.Trade) {
+	pairs := newPairCache(t.pairDao)
+	for _, tr := range trades {
+		if tr == nil {
+			continue
+		}
+
+		if p := pairs.Get(tr.BaseToken, tr.QuoteToken); p != nil {
+			tr.SetFormatted(p)
+		}
+	}
 }
 
 // GetByHash fetches all trades corresponding to a trade hash
@@ -47,13 +182,31 @@ func (t *TradeService) GetByHash(hash common.Hash) (*types.Trade, error) {
 	return t.tradeDao.GetByHash(hash)
 }
 
+// GetByTxHash fetches the trade settled by a transaction
+func (t *TradeService) GetByTxHash(hash common.Hash) (*types.Trade, error) {
+	return t.tradeDao.GetByTxHash(hash)
+}
+
+// GetByStatus fetches the trades with one of the given settlement statuses
+func (t *TradeService) GetByStatus(statuses ...string) ([]*types.Trade, error) {
+	return t.tradeDao.GetByStatus(statuses...)
+}
+
+// CountByStatus returns the number of trades with one of the given settlement statuses
+func (t *TradeService) CountByStatus(statuses ...string) (int, error) {
+	return t.tradeDao.CountByStatus(statuses...)
+}
+
 // GetByOrderHash fetches all trades corresponding to an order hash
 func (t *TradeService) GetByOrderHash(hash common.Hash) ([]*types.Trade, error) {
 	return t.tradeDao.GetByOrderHash(hash)
 }
 
+// UpdateTradeTx sets the settlement transaction of a trade. The trade is then pending
+// until the transaction is mined.
 func (t *TradeService) UpdateTradeTx(tr *types.Trade, tx *eth.Transaction) error {
 	tr.Tx = tx
+	tr.Status = "PENDING_CONFIRMATION"
 
 	err := t.tradeDao.Update(tr)
 	if err != nil {
@@ -63,30 +216,71 @@ func (t *TradeService) UpdateTradeTx(tr *types.Trade, tx *eth.Transaction) error
 	return nil
 }
 
-// Subscribe
-func (s *TradeService) Subscribe(conn *websocket.Conn, bt, qt common.Address) {
+// ReplaceTradeTx sets the transaction replacing the settlement transaction of a trade
+// (eg. with a higher gas price), the settlement status is unchanged
+func (t *TradeService) ReplaceTradeTx(tr *types.Trade, tx *eth.Transaction) error {
+	tr.Tx = tx
+	return t.tradeDao.Update(tr)
+}
+
+// UpdateTradeStatus updates the settlement status of a trade
+func (t *TradeService) UpdateTradeStatus(tr *types.Trade, status string) error {
+	tr.Status = status
+	return t.tradeDao.Update(tr)
+}
+
+// UpdateTradeStatusIf moves a trade from a settlement status to another. It fails with
+// mgo.ErrNotFound if the status of the trade was changed in the meantime (eg. the trade
+// failed on an error event of the exchange contract).
+func (t *TradeService) UpdateTradeStatusIf(tr *types.Trade, from, status string) error {
+	tr.Status = status
+	return t.tradeDao.UpdateIfStatus(tr, from)
+}
+
+// RecordFailure marks the settlement of a trade as failed and stores the error code
+// and reason of the failure on the trade
+func (t *TradeService) RecordFailure(tr *types.Trade, code aerrors.ExchangeErrorID, reason string) error {
+	tr.Status = "ERROR"
+	tr.ErrorCode = code
+	tr.FailureReason = reason
+	return t.tradeDao.Update(tr)
+}
+
+// Subscribe sends the latest trades of a pair to the connection and subscribes it to
+// the trades of the pair. The number of trades is set by the limit and from parameters.
+func (s *TradeService) Subscribe(conn *websocket.Conn, bt, qt common.Address, params *types.Params) {
 	socket := ws.GetTradeSocket()
 
-	trades, err := s.GetTrades(bt, qt)
+	from := time.Time{}
+	limit := 0
+	if params != nil {
+		if params.From != 0 {
+			from = time.Unix(params.From, 0)
+		}
+
+		limit = params.Limit
+	}
+
+	trades, err := s.GetLatestTrades(bt, qt, from, limit)
 	if err != nil {
-		ws.SendTradeErrorMessage(conn, err.Error())
+		ws.SendTradeErrorMessage(conn, aerrors.NewWSError(err))
 		return
 	}
 
+	if params != nil && params.Formatted {
+		s.SetFormatted(trades)
+	}
+
 	id := utils.GetTradeChannelID(bt, qt)
 	err = socket.Subscribe(id, conn)
 	if err != nil {
-		message := map[string]string{
-			"Code":    "UNABLE_TO_REGISTER",
-			"Message": "UNABLE_TO_REGISTER " + err.Error(),
-		}
-
-		ws.SendTradeErrorMessage(conn, message)
+		log.Print(err)
+		ws.SendTradeErrorMessage(conn, aerrors.NewWSError(aerrors.UnableToRegister.New(nil)))
 		return
 	}
 
 	ws.RegisterConnectionUnsubscribeHandler(conn, socket.UnsubscribeHandler(id))
-	ws.SendTradeInitMessage(conn, trades)
+	ws.SendTradeInitMessage(conn, types.NewPublicTrades(trades))
 }
 
 // Unsubscribe
@@ -97,6 +291,70 @@ func (s *TradeService) Unsubscribe(conn *websocket.Conn, bt, qt common.Address)
 	socket.Unsubscribe(id, conn)
 }
 
+// SubscribeUser subscribes the connection to the fills of an account after checking
+// that the subscription is signed by the account, or that the connection is
+// authenticated as the account. The fills are sent with full detail.
+func (s *TradeService) SubscribeUser(conn *websocket.Conn, sub *types.UserSubscription) {
+	// the connections authenticated as the account do not need a signed subscription
+	if addr, ok := ws.AuthenticatedAddress(conn); !ok || addr != sub.Address {
+		err := sub.VerifySignature(time.Now(), maxClockSkew())
+		if err != nil {
+			ws.SendUserErrorMessage(conn, subscriptionError(err))
+			return
+		}
+	}
+
+	trades, err := s.GetByUserAddress(sub.Address)
+	if err != nil {
+		ws.SendUserErrorMessage(conn, aerrors.NewWSError(err))
+		return
+	}
+
+	socket := ws.GetUserSocket()
+	err = socket.Subscribe(sub.Address, conn)
+	if err != nil {
+		log.Print(err)
+		ws.SendUserErrorMessage(conn, aerrors.NewWSError(aerrors.UnableToRegister.New(nil)))
+		return
+	}
+
+	ws.RegisterConnectionUnsubscribeHandler(conn, socket.UnsubscribeHandler(sub.Address))
+	ws.SendUserMessage(conn, "INIT", trades)
+}
+
+// UnsubscribeUser removes the connection from the fills of an account
+func (s *TradeService) UnsubscribeUser(conn *websocket.Conn, addr common.Address) {
+	ws.GetUserSocket().Unsubscribe(addr, conn)
+}
+
+// PublishTrades broadcasts new trades. The public trades channel of the pair receives
+// the anonymized trades while the maker and taker receive their own fills with full
+// detail on the user channel.
+func PublishTrades(trades []*types.Trade) {
+	byPair := map[string][]*types.Trade{}
+	byUser := map[common.Address][]*types.Trade{}
+	for _, t := range trades {
+		if t == nil {
+			continue
+		}
+
+		id := utils.GetTradeChannelID(t.BaseToken, t.QuoteToken)
+		byPair[id] = append(byPair[id], t)
+		byUser[t.Maker] = append(byUser[t.Maker], t)
+		if t.Taker != t.Maker {
+			byUser[t.Taker] = append(byUser[t.Taker], t)
+		}
+	}
+
+	for id, trades := range byPair {
+		ws.GetTradeSocket().BroadcastMessage(id, "UPDATE", types.NewPublicTrades(trades))
+	}
+
+	for addr, trades := range byUser {
+		ws.GetUserSocket().BroadcastMessage(addr, "UPDATE", trades)
+	}
+}
+
 // // UnregisterForTicks handles all the unsubscription messages for ticks corresponding to a pair
 // func (t *TradeService) UnregisterForTicks(conn *websocket.Conn, bt, qt common.Address, params *types.Params) {
 // 	tickChannelID := utils.GetTickChannelID(bt, qt, params.Units, params.Duration)
@@ -1,18 +1,60 @@
 package services
 
 import (
+	"log"
+	"math/big"
+
 	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/ethereum"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// Uses of the transactions sent by the operator, each use is priced with a gas price tier
+const (
+	SettlementTx = "settlement"
+	WithdrawTx   = "withdraw"
 )
 
 // WalletService struct with daos required, responsible for communicating with daos
 type TxService struct {
 	WalletDao *daos.WalletDao
+	Nonces    *NonceManager
+	gasPrices *ethereum.GasPriceOracle
+	tiers     map[string]string
 }
 
 func NewTxService(WalletDao *daos.WalletDao) *TxService {
-	return &TxService{WalletDao}
+	return &TxService{WalletDao: WalletDao}
+}
+
+// SetNonceManager assigns the nonces of the transactions sent with Send
+func (s *TxService) SetNonceManager(m *NonceManager) {
+	s.Nonces = m
+}
+
+// SetGasPriceOracle prices the transactions with the gas price tier of their use (eg.
+// SettlementTx: ethereum.GasPriceFast) instead of the gas price suggested by the node
+func (s *TxService) SetGasPriceOracle(o *ethereum.GasPriceOracle, tiers map[string]string) {
+	s.gasPrices = o
+	s.tiers = tiers
+}
+
+// SetGasPrice sets the gas price of the transactions sent with opts for a use. The gas
+// price is left to the node if there is no gas price oracle or the oracle fails.
+func (s *TxService) SetGasPrice(opts *bind.TransactOpts, use string) {
+	if s.gasPrices == nil {
+		return
+	}
+
+	price, err := s.gasPrices.Price(s.tiers[use])
+	if err != nil {
+		log.Printf("Could not get the gas price of %s transactions: %v", use, err)
+		return
+	}
+
+	opts.GasPrice = price
 }
 
 func (s *TxService) GetTxCallOptions() *bind.CallOpts {
@@ -28,6 +70,19 @@ func (s *TxService) GetTxSendOptions() (*bind.TransactOpts, error) {
 	return bind.NewKeyedTransactor(wallet.PrivateKey), nil
 }
 
-func (s *TxService) GetCustomTxSendOptions(w *types.Wallet) *bind.TransactOpts {
+func (s *TxService) GetCustomTxSendOptions(w *types.OperatorWallet) *bind.TransactOpts {
 	return bind.NewKeyedTransactor(w.PrivateKey)
 }
+
+// Send sends a transaction with fn. The nonce of the transaction is assigned by the nonce
+// manager if one is set, and by the node otherwise.
+func (s *TxService) Send(opts *bind.TransactOpts, fn func(*bind.TransactOpts) (*ethTypes.Transaction, error)) (*ethTypes.Transaction, error) {
+	if s.Nonces == nil {
+		return fn(opts)
+	}
+
+	return s.Nonces.Send(opts.From, func(nonce *big.Int) (*ethTypes.Transaction, error) {
+		opts.Nonce = nonce
+		return fn(opts)
+	})
+}
@@ -6,7 +6,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 )
 
-// WalletService struct with daos required, responsible for communicating with daos
+// WalletService struct with daos required, responsible for communicating with daos.
+// It only manages operator wallets, user private keys are never handled by the server.
 type WalletService struct {
 	WalletDao *daos.WalletDao
 }
@@ -15,13 +16,16 @@ func NewWalletService(walletDao *daos.WalletDao) *WalletService {
 	return &WalletService{walletDao}
 }
 
-func (s *WalletService) CreateAdminWallet(a common.Address) (*types.Wallet, error) {
-	w := &types.Wallet{
-		Address: a,
-		Admin:   true,
+// CreateAdminWallet stores the private key of an account controlled by the operator
+// and sets it as an admin wallet
+func (s *WalletService) CreateAdminWallet(key string) (*types.OperatorWallet, error) {
+	w, err := types.NewOperatorWalletFromPrivateKey(key)
+	if err != nil {
+		return nil, err
 	}
 
-	err := s.WalletDao.Create(w)
+	w.Admin = true
+	err = s.WalletDao.Create(w)
 	if err != nil {
 		return nil, err
 	}
@@ -29,14 +33,14 @@ func (s *WalletService) CreateAdminWallet(a common.Address) (*types.Wallet, erro
 	return w, nil
 }
 
-func (s *WalletService) GetDefaultAdminWallet() (*types.Wallet, error) {
+func (s *WalletService) GetDefaultAdminWallet() (*types.OperatorWallet, error) {
 	return s.WalletDao.GetDefaultAdminWallet()
 }
 
-func (s *WalletService) GetAll() ([]types.Wallet, error) {
+func (s *WalletService) GetAll() ([]types.OperatorWallet, error) {
 	return s.WalletDao.GetAll()
 }
 
-func (s *WalletService) GetbyAddress(a common.Address) (*types.Wallet, error) {
+func (s *WalletService) GetbyAddress(a common.Address) (*types.OperatorWallet, error) {
 	return s.WalletDao.GetByAddress(a)
 }
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/app"
+	"github.com/Proofsuite/amp-matching-engine/daos"
+	"github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/ethereum"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	mgo "gopkg.in/mgo.v2"
+)
+
+// WithdrawService executes the signed withdrawal requests of the traders. The withdrawn
+// amount is locked in the balance of the trader when the request is accepted, so that it
+// can not be used by orders, and removed from the balance once the withdrawal transaction
+// succeeds. It is made available again if the transaction fails.
+type WithdrawService struct {
+	withdrawDao *daos.WithdrawDao
+	accountDao  *daos.AccountDao
+	tokenDao    *daos.TokenDao
+	txService   *TxService
+	withdrawer  *ethereum.Withdrawer
+	client      EthereumClient
+}
+
+// NewWithdrawService returns a new instance of WithdrawService
+func NewWithdrawService(
+	withdrawDao *daos.WithdrawDao,
+	accountDao *daos.AccountDao,
+	tokenDao *daos.TokenDao,
+	txService *TxService,
+	withdrawer *ethereum.Withdrawer,
+	client EthereumClient,
+) *WithdrawService {
+	return &WithdrawService{withdrawDao, accountDao, tokenDao, txService, withdrawer, client}
+}
+
+// GetByHash fetches a withdrawal by its hash. It returns nil if the withdrawal does not exist.
+func (s *WithdrawService) GetByHash(hash common.Hash) (*types.Withdraw, error) {
+	return s.withdrawDao.GetByHash(hash)
+}
+
+// GetByTrader fetches the withdrawals of an account, most recent first
+func (s *WithdrawService) GetByTrader(trader common.Address) ([]*types.Withdraw, error) {
+	return s.withdrawDao.GetByTrader(trader)
+}
+
+// Create checks a signed withdrawal request against the available balance of the trader,
+// locks the withdrawn amount and sends the withdrawal transaction. The withdrawal is
+// returned with its status, it is failed and its amount unlocked if the transaction could
+// not be sent.
+func (s *WithdrawService) Create(w *types.Withdraw) (*types.Withdraw, error) {
+	if !app.Config.Withdraws.Enabled {
+		return nil, errors.WithdrawsDisabled.New(nil)
+	}
+
+	if w.ExchangeAddress != common.HexToAddress(app.Config.ExchangeAddress) {
+		return nil, errors.InvalidWithdraw.New(errors.Params{"error": "Invalid exchange address"})
+	}
+
+	if err := w.Validate(); err != nil {
+		return nil, errors.InvalidWithdraw.New(errors.Params{"error": err.Error()})
+	}
+
+	if ok, _ := w.VerifySignature(); !ok {
+		return nil, errors.InvalidSignature.New(nil)
+	}
+
+	existing, err := s.withdrawDao.GetByHash(w.Hash)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	if existing != nil {
+		return nil, errors.WithdrawAlreadyExists.New(nil)
+	}
+
+	acc, err := s.accountDao.GetByAddress(w.Trader)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	if acc.IsBlocked {
+		return nil, errors.AccountBlocked.New(nil)
+	}
+
+	token, err := getToken(s.tokenDao, w.Token)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	if token == nil {
+		return nil, errors.InvalidWithdraw.New(errors.Params{"error": "Token not listed"})
+	}
+
+	err = s.accountDao.LockBalance(w.Trader, w.Token, w.Amount)
+	if err == daos.ErrInsufficientBalance {
+		return nil, errors.InsufficientBalance.New(nil)
+	}
+
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	w.Status = types.WithdrawPending
+	created, err := s.withdrawDao.Create(w)
+	if err != nil || !created {
+		s.unlock(w)
+		if err != nil {
+			log.Print(err)
+			return nil, err
+		}
+
+		return nil, errors.WithdrawAlreadyExists.New(nil)
+	}
+
+	PublishBalances(s.accountDao, w.Trader)
+	s.submit(w)
+	return w, nil
+}
+
+// Start checks the transactions of the submitted withdrawals every interval. The
+// withdrawals left pending by a crash are recovered first: a withdrawal whose transaction
+// was signed may have been broadcasted, it is submitted with the recorded transaction
+// whose receipt is checked like the others. It is not sent again as the second
+// transaction would revert and make the withdrawn amount available again. The
+// withdrawals without transaction are sent.
+func (s *WithdrawService) Start(interval time.Duration) {
+	pending, err := s.withdrawDao.GetByStatus(types.WithdrawPending)
+	if err != nil {
+		log.Print(err)
+	}
+
+	for _, w := range pending {
+		if w.TxHash == (common.Hash{}) {
+			s.submit(w)
+			continue
+		}
+
+		err = s.withdrawDao.UpdateStatus(w, types.WithdrawPending, types.WithdrawSubmitted)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		PublishWithdraw(s.accountDao, w)
+	}
+
+	go func() {
+		for {
+			time.Sleep(interval)
+			s.checkWithdraws()
+		}
+	}()
+}
+
+// submit sends the transaction of a pending withdrawal with the operator account. The
+// hash of the transaction is recorded once it is signed, before it is broadcasted, so
+// that the withdrawal is not sent twice after a crash.
+func (s *WithdrawService) submit(w *types.Withdraw) {
+	opts, err := s.txService.GetTxSendOptions()
+	if err == nil {
+		s.txService.SetGasPrice(opts, WithdrawTx)
+
+		sign := opts.Signer
+		opts.Signer = func(signer ethTypes.Signer, from common.Address, tx *ethTypes.Transaction) (*ethTypes.Transaction, error) {
+			signed, err := sign(signer, from, tx)
+			if err != nil {
+				return nil, err
+			}
+
+			w.TxHash = signed.Hash()
+			err = s.withdrawDao.SetTxHash(w)
+			if err != nil {
+				w.TxHash = common.Hash{}
+				return nil, err
+			}
+
+			return signed, nil
+		}
+
+		var tx *ethTypes.Transaction
+		tx, err = s.txService.Send(opts, func(opts *bind.TransactOpts) (*ethTypes.Transaction, error) {
+			return s.withdrawer.Withdraw(opts, w)
+		})
+
+		if err == nil {
+			w.TxHash = tx.Hash()
+		}
+	}
+
+	if err != nil {
+		log.Printf("Could not send withdrawal %s: %v", w.Hash.Hex(), err)
+		w.Error = err.Error()
+		s.fail(w, types.WithdrawPending)
+		return
+	}
+
+	err = s.withdrawDao.UpdateStatus(w, types.WithdrawPending, types.WithdrawSubmitted)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	PublishWithdraw(s.accountDao, w)
+}
+
+// checkWithdraws settles the balances of the submitted withdrawals whose transaction was
+// mined
+func (s *WithdrawService) checkWithdraws() {
+	submitted, err := s.withdrawDao.GetByStatus(types.WithdrawSubmitted)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, w := range submitted {
+		receipt, err := s.client.TransactionReceipt(context.Background(), w.TxHash)
+		if err == goethereum.NotFound {
+			continue
+		}
+
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		if receipt.Status != ethTypes.ReceiptStatusSuccessful {
+			w.Error = "Withdrawal transaction reverted"
+			s.fail(w, types.WithdrawSubmitted)
+			continue
+		}
+
+		err = s.withdrawDao.UpdateStatus(w, types.WithdrawSubmitted, types.WithdrawSuccess)
+		if err == mgo.ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		err = s.accountDao.SpendLockedBalance(w.Trader, w.Token, w.Amount)
+		if err != nil {
+			log.Printf("Could not debit withdrawal %s of %s: %v", w.Hash.Hex(), w.Trader.Hex(), err)
+		}
+
+		PublishWithdraw(s.accountDao, w)
+	}
+}
+
+// fail moves a withdrawal to the failed status and makes its amount available again
+func (s *WithdrawService) fail(w *types.Withdraw, from string) {
+	err := s.withdrawDao.UpdateStatus(w, from, types.WithdrawFailed)
+	if err != nil {
+		if err != mgo.ErrNotFound {
+			log.Print(err)
+		}
+
+		return
+	}
+
+	s.unlock(w)
+	PublishWithdraw(s.accountDao, w)
+}
+
+// unlock makes the amount of a withdrawal available again
+func (s *WithdrawService) unlock(w *types.Withdraw) {
+	err := s.accountDao.UnlockBalance(w.Trader, w.Token, w.Amount)
+	if err != nil {
+		log.Printf("Could not unlock withdrawal %s of %s: %v", w.Hash.Hex(), w.Trader.Hex(), err)
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"fmt"
 	"math/big"
+	"sort"
 	"time"
 
 	"encoding/json"
@@ -18,8 +19,33 @@ type Account struct {
 	Address       common.Address                   `json:"address" bson:"address"`
 	TokenBalances map[common.Address]*TokenBalance `json:"tokenBalances" bson:"tokenBalances"`
 	IsBlocked     bool                             `json:"isBlocked" bson:"isBlocked"`
+	Tags          []string                         `json:"tags,omitempty" bson:"tags,omitempty"`
+	Notes         []AccountNote                    `json:"notes,omitempty" bson:"notes,omitempty"`
+	LimitOverride *AccountLimitOverride            `json:"limitOverride,omitempty" bson:"limitOverride,omitempty"`
 	CreatedAt     time.Time                        `json:"createdAt" bson:"createdAt"`
 	UpdatedAt     time.Time                        `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt     *time.Time                       `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	// AnonymizedAt is set once the personal metadata of the account (tags, notes and
+	// limit override reason) was erased on request of its owner
+	AnonymizedAt *time.Time `json:"anonymizedAt,omitempty" bson:"anonymizedAt,omitempty"`
+	// BalancesStale is set in the responses when the ethereum node lags behind the chain,
+	// the token balances might then be outdated
+	BalancesStale bool `json:"balancesStale,omitempty" bson:"-"`
+}
+
+// Tags commonly set on accounts by the support and operations teams. Other tags can be
+// used, they are lowercase words separated by dashes.
+const (
+	TagVIP         = "vip"
+	TagMarketMaker = "market-maker"
+	TagUnderReview = "under-review"
+)
+
+// AccountNote is a freeform note written on an account by an administrator
+type AccountNote struct {
+	Text      string    `json:"text" bson:"text"`
+	Admin     string    `json:"admin" bson:"admin"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
 }
 
 // TokenBalance holds the Balance, Allowance and the Locked balance values for a single Ethereum token
@@ -39,8 +65,13 @@ type AccountRecord struct {
 	Address       string                        `json:"address" bson:"address"`
 	TokenBalances map[string]TokenBalanceRecord `json:"tokenBalances" bson:"tokenBalances"`
 	IsBlocked     bool                          `json:"isBlocked" bson:"isBlocked"`
+	Tags          []string                      `json:"tags,omitempty" bson:"tags,omitempty"`
+	Notes         []AccountNote                 `json:"notes,omitempty" bson:"notes,omitempty"`
+	LimitOverride *AccountLimitOverride         `json:"limitOverride,omitempty" bson:"limitOverride,omitempty"`
 	CreatedAt     time.Time                     `json:"createdAt" bson:"createdAt"`
 	UpdatedAt     time.Time                     `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt     *time.Time                    `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	AnonymizedAt  *time.Time                    `json:"anonymizedAt,omitempty" bson:"anonymizedAt,omitempty"`
 }
 
 // TokenBalanceRecord corresponds to a TokenBalance struct that is stored in the DB. big.Ints are encoded as strings
@@ -72,6 +103,14 @@ func (a *Account) GetBSON() (interface{}, error) {
 		ID:            a.ID,
 		Address:       a.Address.Hex(),
 		TokenBalances: tokenBalances,
+		IsBlocked:     a.IsBlocked,
+		Tags:          a.Tags,
+		Notes:         a.Notes,
+		LimitOverride: a.LimitOverride,
+		CreatedAt:     a.CreatedAt,
+		UpdatedAt:     a.UpdatedAt,
+		DeletedAt:     a.DeletedAt,
+		AnonymizedAt:  a.AnonymizedAt,
 	}, nil
 }
 
@@ -107,8 +146,13 @@ func (a *Account) SetBSON(raw bson.Raw) error {
 	a.ID = decoded.ID
 	a.Address = common.HexToAddress(decoded.Address)
 	a.IsBlocked = decoded.IsBlocked
+	a.Tags = decoded.Tags
+	a.Notes = decoded.Notes
+	a.LimitOverride = decoded.LimitOverride
 	a.CreatedAt = decoded.CreatedAt
 	a.UpdatedAt = decoded.UpdatedAt
+	a.DeletedAt = decoded.DeletedAt
+	a.AnonymizedAt = decoded.AnonymizedAt
 
 	return nil
 }
@@ -124,21 +168,83 @@ func (a *Account) MarshalJSON() ([]byte, error) {
 		"createdAt": a.CreatedAt.String(),
 		"updatedAt": a.UpdatedAt.String(),
 	}
+
+	if a.DeletedAt != nil {
+		account["deletedAt"] = a.DeletedAt.String()
+	}
+
+	if a.AnonymizedAt != nil {
+		account["anonymizedAt"] = a.AnonymizedAt.String()
+	}
+
+	if a.BalancesStale {
+		account["balancesStale"] = true
+	}
+
+	if len(a.Tags) > 0 {
+		account["tags"] = a.Tags
+	}
+
+	if len(a.Notes) > 0 {
+		account["notes"] = a.Notes
+	}
+
+	if a.LimitOverride != nil {
+		account["limitOverride"] = a.LimitOverride
+	}
+
 	tokenBalance := make(map[string]interface{})
 	for address, balance := range a.TokenBalances {
-		tokenBalance[address.Hex()] = map[string]interface{}{
-			"id":            balance.ID.Hex(),
-			"address":       balance.Address.Hex(),
-			"symbol":        balance.Symbol,
-			"balance":       balance.Balance.String(),
-			"allowance":     balance.Allowance.String(),
-			"lockedBalance": balance.LockedBalance.String(),
-		}
+		tokenBalance[address.Hex()] = balance.jsonMap()
 	}
 	account["tokenBalances"] = tokenBalance
 	return json.Marshal(account)
 }
 
+// jsonMap returns the json representation of a token balance, the amounts are encoded
+// as strings
+func (b *TokenBalance) jsonMap() map[string]interface{} {
+	return map[string]interface{}{
+		"id":            b.ID.Hex(),
+		"address":       b.Address.Hex(),
+		"symbol":        b.Symbol,
+		"balance":       b.Balance.String(),
+		"allowance":     b.Allowance.String(),
+		"lockedBalance": b.LockedBalance.String(),
+	}
+}
+
+// AccountBalances holds the token balances of an account, as sent on the balances
+// websocket channel
+type AccountBalances struct {
+	Address  common.Address
+	Balances []*TokenBalance
+}
+
+// NewAccountBalances returns the token balances of an account sorted by token symbol
+func NewAccountBalances(addr common.Address, tokenBalances map[common.Address]*TokenBalance) *AccountBalances {
+	balances := []*TokenBalance{}
+	for _, b := range tokenBalances {
+		balances = append(balances, b)
+	}
+
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Symbol < balances[j].Symbol })
+	return &AccountBalances{Address: addr, Balances: balances}
+}
+
+// MarshalJSON implements the json.Marshal interface
+func (b *AccountBalances) MarshalJSON() ([]byte, error) {
+	balances := []interface{}{}
+	for _, tb := range b.Balances {
+		balances = append(balances, tb.jsonMap())
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"address":  b.Address,
+		"balances": balances,
+	})
+}
+
 func (a *Account) UnmarshalJSON(b []byte) error {
 	account := map[string]interface{}{}
 	err := json.Unmarshal(b, &account)
@@ -188,6 +294,17 @@ func (a *Account) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// HasTag returns true if the account is tagged with tag
+func (a *Account) HasTag(tag string) bool {
+	for _, t := range a.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Validate enforces the account model
 func (a Account) Validate() error {
 	return validation.ValidateStruct(&a,
@@ -0,0 +1,168 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AccountLimits are the fees and the order limits applied to the orders of an account.
+// The limits set to 0 are not enforced and the orders pay the fees of their pair when
+// the fees are nil. Tier is the account tag selecting the limits ("" for the default
+// limits).
+type AccountLimits struct {
+	Tier            string   `json:"tier"`
+	MakeFee         *big.Int `json:"makeFee,omitempty"`
+	TakeFee         *big.Int `json:"takeFee,omitempty"`
+	OrdersPerMinute int      `json:"ordersPerMinute"`
+	MaxOpenOrders   int      `json:"maxOpenOrders"`
+}
+
+// AccountLimitOverride replaces some of the tier limits of an account (eg. negotiated
+// market maker fees). The fields that are not set keep the limits of the tier. The
+// override does not apply anymore after ExpiresAt when it is set.
+type AccountLimitOverride struct {
+	MakeFee         *big.Int   `json:"makeFee,omitempty"`
+	TakeFee         *big.Int   `json:"takeFee,omitempty"`
+	OrdersPerMinute *int       `json:"ordersPerMinute,omitempty"`
+	MaxOpenOrders   *int       `json:"maxOpenOrders,omitempty"`
+	Reason          string     `json:"reason,omitempty"`
+	ExpiresAt       *time.Time `json:"expiresAt,omitempty"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// AccountLimitOverrideRecord is the struct which is stored in db
+type AccountLimitOverrideRecord struct {
+	MakeFee         string     `bson:"makeFee,omitempty"`
+	TakeFee         string     `bson:"takeFee,omitempty"`
+	OrdersPerMinute *int       `bson:"ordersPerMinute,omitempty"`
+	MaxOpenOrders   *int       `bson:"maxOpenOrders,omitempty"`
+	Reason          string     `bson:"reason,omitempty"`
+	ExpiresAt       *time.Time `bson:"expiresAt,omitempty"`
+	UpdatedAt       time.Time  `bson:"updatedAt"`
+}
+
+// Validate verifies that the override sets a limit and that the limits are not negative
+func (o AccountLimitOverride) Validate() error {
+	if o.MakeFee == nil && o.TakeFee == nil && o.OrdersPerMinute == nil && o.MaxOpenOrders == nil {
+		return errors.New("No limit is overridden")
+	}
+
+	if o.MakeFee != nil && o.MakeFee.Sign() < 0 {
+		return errors.New("Invalid make fee")
+	}
+
+	if o.TakeFee != nil && o.TakeFee.Sign() < 0 {
+		return errors.New("Invalid take fee")
+	}
+
+	if o.OrdersPerMinute != nil && *o.OrdersPerMinute < 0 {
+		return errors.New("Invalid orders per minute")
+	}
+
+	if o.MaxOpenOrders != nil && *o.MaxOpenOrders < 0 {
+		return errors.New("Invalid max open orders")
+	}
+
+	return nil
+}
+
+// AppliesAt returns true if the override has not expired at the given time
+func (o *AccountLimitOverride) AppliesAt(t time.Time) bool {
+	return o.ExpiresAt == nil || t.Before(*o.ExpiresAt)
+}
+
+func (o *AccountLimitOverride) GetBSON() (interface{}, error) {
+	or := &AccountLimitOverrideRecord{
+		OrdersPerMinute: o.OrdersPerMinute,
+		MaxOpenOrders:   o.MaxOpenOrders,
+		Reason:          o.Reason,
+		ExpiresAt:       o.ExpiresAt,
+		UpdatedAt:       o.UpdatedAt,
+	}
+
+	if o.MakeFee != nil {
+		or.MakeFee = o.MakeFee.String()
+	}
+
+	if o.TakeFee != nil {
+		or.TakeFee = o.TakeFee.String()
+	}
+
+	return or, nil
+}
+
+func (o *AccountLimitOverride) SetBSON(raw bson.Raw) error {
+	decoded := &AccountLimitOverrideRecord{}
+
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	if decoded.MakeFee != "" {
+		o.MakeFee = math.ToBigInt(decoded.MakeFee)
+	}
+
+	if decoded.TakeFee != "" {
+		o.TakeFee = math.ToBigInt(decoded.TakeFee)
+	}
+
+	o.OrdersPerMinute = decoded.OrdersPerMinute
+	o.MaxOpenOrders = decoded.MaxOpenOrders
+	o.Reason = decoded.Reason
+	o.ExpiresAt = decoded.ExpiresAt
+	o.UpdatedAt = decoded.UpdatedAt
+	return nil
+}
+
+// EffectiveLimits returns the limits applied to the orders of the account at the given
+// time. The tier is the first of the tiers whose tag is set on the account, or the
+// default tier (with an empty tag), and the limit override of the account replaces the
+// limits of the tier when it applies.
+func (a *Account) EffectiveLimits(tiers []AccountLimits, t time.Time) AccountLimits {
+	limits := AccountLimits{}
+	found := false
+	for _, tier := range tiers {
+		if tier.Tier != "" && a.HasTag(tier.Tier) {
+			limits = tier
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		for _, tier := range tiers {
+			if tier.Tier == "" {
+				limits = tier
+				break
+			}
+		}
+	}
+
+	o := a.LimitOverride
+	if o == nil || !o.AppliesAt(t) {
+		return limits
+	}
+
+	if o.MakeFee != nil {
+		limits.MakeFee = o.MakeFee
+	}
+
+	if o.TakeFee != nil {
+		limits.TakeFee = o.TakeFee
+	}
+
+	if o.OrdersPerMinute != nil {
+		limits.OrdersPerMinute = *o.OrdersPerMinute
+	}
+
+	if o.MaxOpenOrders != nil {
+		limits.MaxOpenOrders = *o.MaxOpenOrders
+	}
+
+	return limits
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountEffectiveLimits(t *testing.T) {
+	tiers := []AccountLimits{
+		{Tier: "", OrdersPerMinute: 60, MaxOpenOrders: 100},
+		{Tier: TagMarketMaker, MakeFee: big.NewInt(0), TakeFee: big.NewInt(0), OrdersPerMinute: 600, MaxOpenOrders: 1000},
+		{Tier: TagVIP, OrdersPerMinute: 120, MaxOpenOrders: 200},
+	}
+
+	now := time.Now()
+	a := &Account{}
+	assert.Equal(t, tiers[0], a.EffectiveLimits(tiers, now))
+
+	a.Tags = []string{TagVIP, TagMarketMaker}
+	assert.Equal(t, tiers[1], a.EffectiveLimits(tiers, now))
+
+	maxOpen := 5000
+	expires := now.Add(time.Hour)
+	a.LimitOverride = &AccountLimitOverride{
+		TakeFee:       big.NewInt(1),
+		MaxOpenOrders: &maxOpen,
+		ExpiresAt:     &expires,
+	}
+
+	limits := a.EffectiveLimits(tiers, now)
+	assert.Equal(t, TagMarketMaker, limits.Tier)
+	assert.Equal(t, big.NewInt(0), limits.MakeFee)
+	assert.Equal(t, big.NewInt(1), limits.TakeFee)
+	assert.Equal(t, 600, limits.OrdersPerMinute)
+	assert.Equal(t, 5000, limits.MaxOpenOrders)
+
+	// the override does not apply after it expires
+	assert.Equal(t, tiers[1], a.EffectiveLimits(tiers, expires))
+
+	// no limit without tiers
+	assert.Equal(t, AccountLimits{}, (&Account{}).EffectiveLimits(nil, now))
+}
+
+func TestAccountLimitOverrideValidate(t *testing.T) {
+	assert.NotNil(t, AccountLimitOverride{}.Validate())
+	assert.NotNil(t, AccountLimitOverride{MakeFee: big.NewInt(-1)}.Validate())
+
+	n := -1
+	assert.NotNil(t, AccountLimitOverride{OrdersPerMinute: &n}.Validate())
+
+	n = 0
+	assert.Nil(t, AccountLimitOverride{OrdersPerMinute: &n}.Validate())
+}
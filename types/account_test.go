@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"math/big"
 	"testing"
 
@@ -56,3 +57,39 @@ func TestAccountBSON(t *testing.T) {
 
 	assert.Equal(decoded, account)
 }
+
+func TestAccountBalancesJSON(t *testing.T) {
+	address := common.HexToAddress("0xe8e84ee367bc63ddb38d3d01bccef106c194dc47")
+	zrx := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	eos := common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5")
+	id := bson.ObjectIdHex("537f700b537461b70c5f0000")
+
+	balances := NewAccountBalances(address, map[common.Address]*TokenBalance{
+		zrx: {ID: id, Address: zrx, Symbol: "ZRX", Balance: big.NewInt(100), Allowance: big.NewInt(1000), LockedBalance: big.NewInt(50)},
+		eos: {ID: id, Address: eos, Symbol: "EOS", Balance: big.NewInt(200), Allowance: big.NewInt(0), LockedBalance: big.NewInt(0)},
+	})
+
+	// the balances are sorted by token symbol
+	assert.Equal(t, "EOS", balances.Balances[0].Symbol)
+	assert.Equal(t, "ZRX", balances.Balances[1].Symbol)
+
+	data, err := json.Marshal(balances)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := map[string]interface{}{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "0xe8e84ee367bc63ddb38d3d01bccef106c194dc47", decoded["address"])
+	assert.Equal(t, map[string]interface{}{
+		"id":            "537f700b537461b70c5f0000",
+		"address":       zrx.Hex(),
+		"symbol":        "ZRX",
+		"balance":       "100",
+		"allowance":     "1000",
+		"lockedBalance": "50",
+	}, decoded["balances"].([]interface{})[1])
+}
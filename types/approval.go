@@ -0,0 +1,43 @@
+package types
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Statuses of an approval request. A pending request is executed once it is approved
+// by a second administrator, before it expires.
+const (
+	ApprovalPending  = "PENDING"
+	ApprovalExecuted = "EXECUTED"
+	ApprovalFailed   = "FAILED"
+	ApprovalRejected = "REJECTED"
+	ApprovalExpired  = "EXPIRED"
+)
+
+// Admin actions requiring the approval of two distinct administrators
+const (
+	ActionDelistPair       = "DELIST_PAIR"
+	ActionUnblockAccount   = "UNBLOCK_ACCOUNT"
+	ActionRestoreSnapshot  = "RESTORE_ENGINE_SNAPSHOT"
+	ActionBustTrade        = "BUST_TRADE"
+	ActionAnonymizeAccount = "ANONYMIZE_ACCOUNT"
+)
+
+// Approval is a destructive admin action waiting for the approval of a second
+// administrator. Target is the identifier of the affected document (eg. a pair name or
+// an account address) and Params holds the arguments of the action.
+type Approval struct {
+	ID          bson.ObjectId          `json:"id" bson:"_id"`
+	Action      string                 `json:"action" bson:"action"`
+	Target      string                 `json:"target" bson:"target"`
+	Params      map[string]interface{} `json:"params,omitempty" bson:"params,omitempty"`
+	Status      string                 `json:"status" bson:"status"`
+	RequestedBy string                 `json:"requestedBy" bson:"requestedBy"`
+	ReviewedBy  string                 `json:"reviewedBy,omitempty" bson:"reviewedBy,omitempty"`
+	Error       string                 `json:"error,omitempty" bson:"error,omitempty"`
+	ExpiresAt   time.Time              `json:"expiresAt" bson:"expiresAt"`
+	CreatedAt   time.Time              `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time              `json:"updatedAt" bson:"updatedAt"`
+}
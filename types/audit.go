@@ -0,0 +1,19 @@
+package types
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AuditLog records a manual intervention made by an administrator (eg. retrying or
+// skipping a stuck settlement). Target is the identifier of the affected document
+// (eg. a trade hash) and Admin the administrator, when identified.
+type AuditLog struct {
+	ID        bson.ObjectId          `json:"id" bson:"_id"`
+	Action    string                 `json:"action" bson:"action"`
+	Target    string                 `json:"target" bson:"target"`
+	Details   map[string]interface{} `json:"details" bson:"details"`
+	Admin     string                 `json:"admin,omitempty" bson:"admin,omitempty"`
+	CreatedAt time.Time              `json:"createdAt" bson:"createdAt"`
+}
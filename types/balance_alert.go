@@ -0,0 +1,98 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Levels of the ether balance of the operator wallet. Settlement is paused while the
+// balance is critical.
+const (
+	BalanceOK       = "OK"
+	BalanceWarning  = "WARNING"
+	BalanceCritical = "CRITICAL"
+)
+
+// BalanceAlert is sent when the ether balance of the operator wallet crosses one of
+// the alert thresholds, in either direction. Threshold is the threshold of the level
+// entered, it is nil when the balance is back to normal.
+type BalanceAlert struct {
+	Address       common.Address
+	Balance       *big.Int
+	Threshold     *big.Int
+	Level         string
+	PreviousLevel string
+	CreatedAt     time.Time
+}
+
+// BalanceAlertRecord is the json representation of a balance alert. The amounts are
+// encoded as strings in wei, BalanceEther is the balance in ether.
+type BalanceAlertRecord struct {
+	Address       string    `json:"address"`
+	Balance       string    `json:"balance"`
+	BalanceEther  string    `json:"balanceEther"`
+	Threshold     string    `json:"threshold,omitempty"`
+	Level         string    `json:"level"`
+	PreviousLevel string    `json:"previousLevel"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// Message returns a human readable description of the alert
+func (a *BalanceAlert) Message() string {
+	balance := math.ToDecimalString(a.Balance, 18, 6)
+	switch a.Level {
+	case BalanceCritical:
+		return "Operator wallet " + a.Address.Hex() + " balance is critical (" + balance + " ETH), settlement is paused"
+	case BalanceWarning:
+		return "Operator wallet " + a.Address.Hex() + " balance is low (" + balance + " ETH)"
+	}
+
+	return "Operator wallet " + a.Address.Hex() + " balance is back to normal (" + balance + " ETH)"
+}
+
+func (a *BalanceAlert) MarshalJSON() ([]byte, error) {
+	r := &BalanceAlertRecord{
+		Address:       a.Address.Hex(),
+		Balance:       a.Balance.String(),
+		BalanceEther:  math.ToDecimalString(a.Balance, 18, 6),
+		Level:         a.Level,
+		PreviousLevel: a.PreviousLevel,
+		CreatedAt:     a.CreatedAt,
+	}
+
+	if a.Threshold != nil {
+		r.Threshold = a.Threshold.String()
+	}
+
+	return json.Marshal(r)
+}
+
+func (a *BalanceAlert) UnmarshalJSON(b []byte) error {
+	r := &BalanceAlertRecord{}
+	err := json.Unmarshal(b, r)
+	if err != nil {
+		return err
+	}
+
+	balance, ok := new(big.Int).SetString(r.Balance, 10)
+	if !ok {
+		return errors.New("Invalid balance")
+	}
+
+	a.Address = common.HexToAddress(r.Address)
+	a.Balance = balance
+	a.Threshold = nil
+	if r.Threshold != "" {
+		a.Threshold = math.ToBigInt(r.Threshold)
+	}
+
+	a.Level = r.Level
+	a.PreviousLevel = r.PreviousLevel
+	a.CreatedAt = r.CreatedAt
+	return nil
+}
@@ -0,0 +1,31 @@
+package types
+
+import (
+	"strconv"
+	"time"
+)
+
+// ChainAlert is sent when the latest block returned by the ethereum node becomes older
+// than the maximum block lag, and when the node is back in sync. Settlement is paused
+// while the chain is stale.
+type ChainAlert struct {
+	BlockNumber uint64        `json:"blockNumber"`
+	BlockTime   time.Time     `json:"blockTime"`
+	Lag         time.Duration `json:"lag"`
+	Stale       bool          `json:"stale"`
+	CreatedAt   time.Time     `json:"createdAt"`
+}
+
+// Message returns a human readable description of the alert
+func (a *ChainAlert) Message() string {
+	block := strconv.FormatUint(a.BlockNumber, 10)
+	if !a.Stale {
+		return "Ethereum node is back in sync at block " + block
+	}
+
+	if a.BlockNumber == 0 {
+		return "Ethereum node is unreachable, settlement is paused"
+	}
+
+	return "Ethereum node is stale, the latest block " + block + " is " + a.Lag.Round(time.Second).String() + " old, settlement is paused"
+}
@@ -0,0 +1,20 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrClockSkew is returned when the timestamp of a signed request is too far from the
+// time of the server, either because the request is replayed or because the clock of the
+// client is off. Clients can get the server time with GET /time and sign again.
+var ErrClockSkew = errors.New("Timestamp is outside the allowed clock skew")
+
+// CheckTimestamp checks that the timestamp of a signed request is within maxSkew of now
+func CheckTimestamp(ts, now time.Time, maxSkew time.Duration) error {
+	if ts.Before(now.Add(-maxSkew)) || ts.After(now.Add(maxSkew)) {
+		return ErrClockSkew
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// LockedBalanceDiscrepancy is a token balance of an account whose locked amount differs
+// from the amount still to be sold by the current orders (NEW, OPEN or PARTIAL_FILLED)
+// of the account
+type LockedBalanceDiscrepancy struct {
+	Address  common.Address `json:"address"`
+	Token    common.Address `json:"token"`
+	Locked   *big.Int       `json:"locked"`
+	Expected *big.Int       `json:"expected"`
+}
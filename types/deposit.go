@@ -0,0 +1,111 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Statuses of the deposits. A deposit is pending until its block has enough
+// confirmations, it is then confirmed and credited to the account of its owner. It is
+// removed if its block is dropped by a chain reorganization before, and failed if it
+// could not be credited (eg. the owner has no account).
+const (
+	DepositPending   = "PENDING"
+	DepositConfirmed = "CONFIRMED"
+	DepositRemoved   = "REMOVED"
+	DepositFailed    = "FAILED"
+)
+
+// Deposit is a transfer of tokens to the exchange contract, identified by the hash of
+// its transaction and the index of its log
+type Deposit struct {
+	ID          bson.ObjectId
+	TxHash      common.Hash
+	LogIndex    uint
+	BlockNumber uint64
+	Token       common.Address
+	Owner       common.Address
+	Amount      *big.Int
+	Status      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// DepositRecord is the struct which is stored in db
+type DepositRecord struct {
+	ID          bson.ObjectId `bson:"_id"`
+	TxHash      string        `bson:"txHash"`
+	LogIndex    int64         `bson:"logIndex"`
+	BlockNumber int64         `bson:"blockNumber"`
+	Token       string        `bson:"token"`
+	Owner       string        `bson:"owner"`
+	Amount      string        `bson:"amount"`
+	Status      string        `bson:"status"`
+	CreatedAt   time.Time     `bson:"createdAt"`
+	UpdatedAt   time.Time     `bson:"updatedAt"`
+}
+
+// Confirmations returns the number of confirmations of the deposit at a block
+func (d *Deposit) Confirmations(blockNumber uint64) uint64 {
+	if blockNumber < d.BlockNumber {
+		return 0
+	}
+
+	return blockNumber - d.BlockNumber + 1
+}
+
+// MarshalJSON returns the json encoded deposit. The amount is encoded as a string.
+func (d *Deposit) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"txHash":      d.TxHash.Hex(),
+		"logIndex":    d.LogIndex,
+		"blockNumber": d.BlockNumber,
+		"token":       d.Token.Hex(),
+		"owner":       d.Owner.Hex(),
+		"amount":      d.Amount.String(),
+		"status":      d.Status,
+		"createdAt":   d.CreatedAt,
+		"updatedAt":   d.UpdatedAt,
+	})
+}
+
+func (d *Deposit) GetBSON() (interface{}, error) {
+	return &DepositRecord{
+		ID:          d.ID,
+		TxHash:      d.TxHash.Hex(),
+		LogIndex:    int64(d.LogIndex),
+		BlockNumber: int64(d.BlockNumber),
+		Token:       d.Token.Hex(),
+		Owner:       d.Owner.Hex(),
+		Amount:      d.Amount.String(),
+		Status:      d.Status,
+		CreatedAt:   d.CreatedAt,
+		UpdatedAt:   d.UpdatedAt,
+	}, nil
+}
+
+func (d *Deposit) SetBSON(raw bson.Raw) error {
+	decoded := &DepositRecord{}
+
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	d.ID = decoded.ID
+	d.TxHash = common.HexToHash(decoded.TxHash)
+	d.LogIndex = uint(decoded.LogIndex)
+	d.BlockNumber = uint64(decoded.BlockNumber)
+	d.Token = common.HexToAddress(decoded.Token)
+	d.Owner = common.HexToAddress(decoded.Owner)
+	d.Amount = math.ToBigInt(decoded.Amount)
+	d.Status = decoded.Status
+	d.CreatedAt = decoded.CreatedAt
+	d.UpdatedAt = decoded.UpdatedAt
+	return nil
+}
@@ -0,0 +1,69 @@
+package types
+
+// DepthPoint is a point of a depth chart curve: the cumulative volume of the orders
+// priced within Percent of the mid-price, up to Price
+type DepthPoint struct {
+	Percent float64 `json:"percent"`
+	Price   float64 `json:"price"`
+	Volume  float64 `json:"volume"`
+}
+
+// DepthChart is the cumulative volume of the bids and asks of an orderbook bucketed by
+// distance from the mid-price. The curves are empty if the orderbook is empty.
+type DepthChart struct {
+	Pair     PairSubDoc   `json:"pair"`
+	MidPrice float64      `json:"midPrice"`
+	Bids     []DepthPoint `json:"bids"`
+	Asks     []DepthPoint `json:"asks"`
+}
+
+// NewDepthChart computes the depth chart of an orderbook from its price levels (as
+// returned by the engine, best price first) in buckets of step percent from the
+// mid-price. The mid-price is the best price of the other side if one side is empty.
+func NewDepthChart(bids, asks []*map[string]float64, step float64, buckets int) *DepthChart {
+	chart := &DepthChart{Bids: []DepthPoint{}, Asks: []DepthPoint{}}
+
+	bestBid, bestAsk := bestPrice(bids), bestPrice(asks)
+	switch {
+	case bestBid > 0 && bestAsk > 0:
+		chart.MidPrice = (bestBid + bestAsk) / 2
+	case bestBid > 0:
+		chart.MidPrice = bestBid
+	case bestAsk > 0:
+		chart.MidPrice = bestAsk
+	default:
+		return chart
+	}
+
+	for i := 1; i <= buckets; i++ {
+		percent := step * float64(i)
+
+		bid := DepthPoint{Percent: percent, Price: chart.MidPrice * (1 - percent/100)}
+		for _, l := range bids {
+			if (*l)["price"] >= bid.Price {
+				bid.Volume += (*l)["volume"]
+			}
+		}
+
+		ask := DepthPoint{Percent: percent, Price: chart.MidPrice * (1 + percent/100)}
+		for _, l := range asks {
+			if (*l)["price"] <= ask.Price {
+				ask.Volume += (*l)["volume"]
+			}
+		}
+
+		chart.Bids = append(chart.Bids, bid)
+		chart.Asks = append(chart.Asks, ask)
+	}
+
+	return chart
+}
+
+// bestPrice returns the price of the first level of a side of the orderbook
+func bestPrice(levels []*map[string]float64) float64 {
+	if len(levels) == 0 {
+		return 0
+	}
+
+	return (*levels[0])["price"]
+}
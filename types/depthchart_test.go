@@ -0,0 +1,43 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func level(price, volume float64) *map[string]float64 {
+	return &map[string]float64{"price": price, "volume": volume}
+}
+
+func TestNewDepthChart(t *testing.T) {
+	bids := []*map[string]float64{level(99.5, 1), level(98.5, 2), level(90, 5)}
+	asks := []*map[string]float64{level(100.5, 3), level(102.5, 1), level(120, 10)}
+
+	chart := NewDepthChart(bids, asks, 1, 3)
+	assert.Equal(t, float64(100), chart.MidPrice)
+	assert.Equal(t, 3, len(chart.Bids))
+	assert.Equal(t, 3, len(chart.Asks))
+
+	// the volumes are cumulative from the mid-price
+	assert.InDelta(t, 99, chart.Bids[0].Price, 1e-9)
+	assert.Equal(t, float64(1), chart.Bids[0].Volume)
+	assert.Equal(t, float64(3), chart.Bids[1].Volume)
+	assert.Equal(t, float64(3), chart.Bids[2].Volume)
+	assert.InDelta(t, 101, chart.Asks[0].Price, 1e-9)
+	assert.Equal(t, float64(3), chart.Asks[0].Volume)
+	assert.Equal(t, float64(3), chart.Asks[1].Volume)
+	assert.Equal(t, float64(4), chart.Asks[2].Volume)
+}
+
+func TestNewDepthChartOneSided(t *testing.T) {
+	chart := NewDepthChart(nil, []*map[string]float64{level(100, 2)}, 5, 2)
+	assert.Equal(t, float64(100), chart.MidPrice)
+	assert.Equal(t, float64(0), chart.Bids[1].Volume)
+	assert.Equal(t, float64(2), chart.Asks[0].Volume)
+
+	chart = NewDepthChart(nil, nil, 5, 2)
+	assert.Equal(t, float64(0), chart.MidPrice)
+	assert.Equal(t, 0, len(chart.Bids))
+	assert.Equal(t, 0, len(chart.Asks))
+}
@@ -0,0 +1,185 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signing schemes of the orders. The orders signed with eth_sign (the default) sign the
+// keccak hash of their fields, the orders signed with eth_signTypedData sign their EIP712
+// hash, so that wallets such as MetaMask display the fields of the order.
+const (
+	SchemeEthSign = ""
+	SchemeEIP712  = "EIP712"
+)
+
+// Name and version of the EIP712 domain of the exchange
+const (
+	EIP712DomainName    = "AMP Exchange"
+	EIP712DomainVersion = "1"
+)
+
+// EIP712ChainID is the chain id of the EIP712 domain, it is set from the configuration
+// at startup. Defaults to the main network.
+var EIP712ChainID = big.NewInt(1)
+
+// EIP712 type definitions, the fields are in the order of the encoding
+var (
+	EIP712DomainType = []EIP712Field{
+		{"name", "string"},
+		{"version", "string"},
+		{"chainId", "uint256"},
+		{"verifyingContract", "address"},
+	}
+
+	EIP712OrderType = []EIP712Field{
+		{"exchangeAddress", "address"},
+		{"userAddress", "address"},
+		{"buyToken", "address"},
+		{"buyAmount", "uint256"},
+		{"sellToken", "address"},
+		{"sellAmount", "uint256"},
+		{"makeFee", "uint256"},
+		{"takeFee", "uint256"},
+		{"expires", "uint256"},
+		{"nonce", "uint256"},
+	}
+
+	EIP712TradeType = []EIP712Field{
+		{"orderHash", "bytes32"},
+		{"amount", "uint256"},
+		{"taker", "address"},
+		{"tradeNonce", "uint256"},
+	}
+)
+
+var (
+	eip712DomainTypeHash = crypto.Keccak256([]byte(encodeEIP712Type("EIP712Domain", EIP712DomainType)))
+	eip712OrderTypeHash  = crypto.Keccak256([]byte(encodeEIP712Type("Order", EIP712OrderType)))
+	eip712TradeTypeHash  = crypto.Keccak256([]byte(encodeEIP712Type("Trade", EIP712TradeType)))
+)
+
+// EIP712Field is a field of an EIP712 type
+type EIP712Field struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// encodeEIP712Type returns the type encoding of an EIP712 type, eg. "Trade(bytes32 orderHash,...)"
+func encodeEIP712Type(name string, fields []EIP712Field) string {
+	s := name + "("
+	for i, f := range fields {
+		if i > 0 {
+			s += ","
+		}
+
+		s += f.Type + " " + f.Name
+	}
+
+	return s + ")"
+}
+
+// EIP712DomainSeparator returns the hash of the EIP712 domain of an exchange contract
+func EIP712DomainSeparator(exchange common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		eip712DomainTypeHash,
+		crypto.Keccak256([]byte(EIP712DomainName)),
+		crypto.Keccak256([]byte(EIP712DomainVersion)),
+		common.BigToHash(EIP712ChainID).Bytes(),
+		common.BytesToHash(exchange.Bytes()).Bytes(),
+	)
+}
+
+// eip712Hash returns the hash signed by eth_signTypedData for a struct hash
+func eip712Hash(exchange common.Address, structHash []byte) common.Hash {
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, EIP712DomainSeparator(exchange).Bytes(), structHash)
+}
+
+// eip712Domain returns the EIP712 domain of an exchange contract as sent to eth_signTypedData
+func eip712Domain(exchange common.Address) map[string]interface{} {
+	return map[string]interface{}{
+		"name":              EIP712DomainName,
+		"version":           EIP712DomainVersion,
+		"chainId":           EIP712ChainID.String(),
+		"verifyingContract": exchange.Hex(),
+	}
+}
+
+// ComputeTypedHash returns the EIP712 hash of the order
+func (o *Order) ComputeTypedHash() common.Hash {
+	structHash := crypto.Keccak256(
+		eip712OrderTypeHash,
+		common.BytesToHash(o.ExchangeAddress.Bytes()).Bytes(),
+		common.BytesToHash(o.UserAddress.Bytes()).Bytes(),
+		common.BytesToHash(o.BuyToken.Bytes()).Bytes(),
+		common.BigToHash(o.BuyAmount).Bytes(),
+		common.BytesToHash(o.SellToken.Bytes()).Bytes(),
+		common.BigToHash(o.SellAmount).Bytes(),
+		common.BigToHash(o.MakeFee).Bytes(),
+		common.BigToHash(o.TakeFee).Bytes(),
+		common.BigToHash(o.Expires).Bytes(),
+		common.BigToHash(o.Nonce).Bytes(),
+	)
+
+	return eip712Hash(o.ExchangeAddress, structHash)
+}
+
+// TypedData returns the order in the format of eth_signTypedData, so that clients can
+// have it signed by a wallet displaying its fields
+func (o *Order) TypedData() map[string]interface{} {
+	return map[string]interface{}{
+		"types": map[string][]EIP712Field{
+			"EIP712Domain": EIP712DomainType,
+			"Order":        EIP712OrderType,
+		},
+		"primaryType": "Order",
+		"domain":      eip712Domain(o.ExchangeAddress),
+		"message": map[string]interface{}{
+			"exchangeAddress": o.ExchangeAddress.Hex(),
+			"userAddress":     o.UserAddress.Hex(),
+			"buyToken":        o.BuyToken.Hex(),
+			"buyAmount":       o.BuyAmount.String(),
+			"sellToken":       o.SellToken.Hex(),
+			"sellAmount":      o.SellAmount.String(),
+			"makeFee":         o.MakeFee.String(),
+			"takeFee":         o.TakeFee.String(),
+			"expires":         o.Expires.String(),
+			"nonce":           o.Nonce.String(),
+		},
+	}
+}
+
+// ComputeTypedHash returns the EIP712 hash of the trade in the domain of an exchange
+// contract. The OrderHash, Amount, Taker and TradeNonce attributes must be set.
+func (t *Trade) ComputeTypedHash(exchange common.Address) common.Hash {
+	structHash := crypto.Keccak256(
+		eip712TradeTypeHash,
+		t.OrderHash.Bytes(),
+		common.BigToHash(t.Amount).Bytes(),
+		common.BytesToHash(t.Taker.Bytes()).Bytes(),
+		common.BigToHash(t.TradeNonce).Bytes(),
+	)
+
+	return eip712Hash(exchange, structHash)
+}
+
+// VerifyTypedSignature checks that a signature of an EIP712 hash was made by an address
+func VerifyTypedSignature(hash common.Hash, sig *Signature, signer common.Address) (bool, error) {
+	if sig == nil {
+		return false, errors.New("Missing signature")
+	}
+
+	address, err := sig.Verify(hash)
+	if err != nil {
+		return false, err
+	}
+
+	if address != signer {
+		return false, errors.New("Recovered address is incorrect")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,125 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func typedOrder(w *Wallet) *Order {
+	return &Order{
+		UserAddress:     w.Address,
+		ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		BuyToken:        common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		SellToken:       common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BuyAmount:       big.NewInt(1000),
+		SellAmount:      big.NewInt(100),
+		MakeFee:         big.NewInt(0),
+		TakeFee:         big.NewInt(0),
+		Expires:         big.NewInt(10000),
+		Nonce:           big.NewInt(1),
+	}
+}
+
+func TestEncodeEIP712Type(t *testing.T) {
+	assert.Equal(t, "EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)", encodeEIP712Type("EIP712Domain", EIP712DomainType))
+	assert.Equal(t, "Trade(bytes32 orderHash,uint256 amount,address taker,uint256 tradeNonce)", encodeEIP712Type("Trade", EIP712TradeType))
+}
+
+func TestSignTypedOrder(t *testing.T) {
+	w := NewWallet()
+	o := typedOrder(w)
+
+	err := w.SignTypedOrder(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, SchemeEIP712, o.SignatureScheme)
+	assert.Equal(t, o.ComputeTypedHash(), o.Hash)
+	assert.Equal(t, o.Hash, o.ComputeHash())
+	assert.NotEqual(t, o.Hash, typedOrder(w).ComputeHash())
+
+	ok, err := o.VerifySignature()
+	assert.True(t, ok)
+	assert.Nil(t, err)
+
+	// the signature does not match the order once a field is changed
+	o.BuyAmount = big.NewInt(2000)
+	ok, err = o.VerifySignature()
+	assert.False(t, ok)
+	assert.NotNil(t, err)
+
+	// the domain includes the chain id
+	o.BuyAmount = big.NewInt(1000)
+	EIP712ChainID = big.NewInt(3)
+	defer func() { EIP712ChainID = big.NewInt(1) }()
+
+	ok, _ = o.VerifySignature()
+	assert.False(t, ok)
+}
+
+func TestSignTypedTrade(t *testing.T) {
+	w := NewWallet()
+	exchange := common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485")
+	trade := &Trade{
+		OrderHash:  common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		Amount:     big.NewInt(100),
+		Taker:      w.Address,
+		TradeNonce: big.NewInt(1),
+	}
+
+	err := w.SignTypedTrade(trade, exchange)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := VerifyTypedSignature(trade.Hash, trade.Signature, w.Address)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+
+	ok, _ = VerifyTypedSignature(trade.ComputeTypedHash(common.Address{}), trade.Signature, w.Address)
+	assert.False(t, ok)
+}
+
+func TestTypedOrderJSON(t *testing.T) {
+	w := NewWallet()
+	o := typedOrder(w)
+
+	err := w.SignTypedOrder(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := json.Marshal(o.TypedData())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	typed := map[string]interface{}{}
+	json.Unmarshal(encoded, &typed)
+	assert.Equal(t, "Order", typed["primaryType"])
+	assert.Equal(t, "1", typed["domain"].(map[string]interface{})["chainId"])
+
+	o.Price = big.NewInt(0)
+	o.PricePoint = big.NewInt(0)
+	o.Amount = big.NewInt(0)
+	o.FilledAmount = big.NewInt(0)
+
+	encoded, err = json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &Order{}
+	err = json.Unmarshal(encoded, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, SchemeEIP712, decoded.SignatureScheme)
+	assert.Equal(t, o.Hash, decoded.ComputeHash())
+}
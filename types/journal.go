@@ -0,0 +1,36 @@
+package types
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Events of the order journal
+const (
+	// JournalRejected: the order was rejected before or by the engine (eg. insufficient
+	// balance, rate limit), with the reason
+	JournalRejected = "REJECTED"
+	// JournalAdded: the order did not match and rests in the orderbook
+	JournalAdded = "ADDED"
+	// JournalMatched: the order matched orders of the orderbook, with the makers and amounts
+	JournalMatched = "MATCHED"
+	// JournalMatchedAsMaker: the order was matched by an incoming order
+	JournalMatchedAsMaker = "MATCHED_AS_MAKER"
+	// JournalSelfTradeCancelled: the order was cancelled by the self-trade prevention
+	JournalSelfTradeCancelled = "SELF_TRADE_CANCELLED"
+	// JournalCancelled: the order was cancelled by its maker
+	JournalCancelled = "CANCELLED"
+	// JournalError: the engine failed to process the order
+	JournalError = "ERROR"
+)
+
+// JournalEntry records a decision made about an order by the order service or the engine,
+// so that support can explain how an order was handled without reading the logs
+type JournalEntry struct {
+	ID        bson.ObjectId          `json:"id" bson:"_id"`
+	OrderHash string                 `json:"orderHash" bson:"orderHash"`
+	Event     string                 `json:"event" bson:"event"`
+	Details   map[string]interface{} `json:"details,omitempty" bson:"details,omitempty"`
+	CreatedAt time.Time              `json:"createdAt" bson:"createdAt"`
+}
@@ -0,0 +1,137 @@
+package types
+
+import (
+	"sync"
+	"time"
+)
+
+// Stages of the order pipeline. An order is stamped with the time it reaches each stage:
+// received by an endpoint, validated by the order service, enqueued for the engine,
+// matched by the engine, persisted once the engine response is handled and broadcast to
+// the client.
+const (
+	StageReceived  = "received"
+	StageValidated = "validated"
+	StageEnqueued  = "enqueued"
+	StageMatched   = "matched"
+	StagePersisted = "persisted"
+	StageBroadcast = "broadcast"
+)
+
+// PipelineStages are the stages of the order pipeline in order
+var PipelineStages = []string{StageReceived, StageValidated, StageEnqueued, StageMatched, StagePersisted, StageBroadcast}
+
+// LatencyBuckets are the upper bounds in seconds of the buckets of the latency histograms
+var LatencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// Stamp records the time an order reached a stage of the pipeline. The first time is kept
+// if the stage is stamped again.
+func (o *Order) Stamp(stage string, t time.Time) {
+	if o.Timings == nil {
+		o.Timings = make(map[string]time.Time)
+	}
+
+	if _, ok := o.Timings[stage]; !ok {
+		o.Timings[stage] = t
+	}
+}
+
+// Latencies returns the latency in milliseconds between the consecutive stages the order
+// was stamped with, keyed by "<from>_<to>" (eg. "received_validated"). The stages the
+// order did not go through are skipped.
+func (o *Order) Latencies() map[string]float64 {
+	latencies := make(map[string]float64)
+	forEachStageLatency(o.Timings, func(from, to string, d time.Duration) {
+		latencies[from+"_"+to] = float64(d) / float64(time.Millisecond)
+	})
+
+	return latencies
+}
+
+// forEachStageLatency calls fn with the duration between the consecutive stamped stages
+func forEachStageLatency(timings map[string]time.Time, fn func(from, to string, d time.Duration)) {
+	from := ""
+	for _, stage := range PipelineStages {
+		t, ok := timings[stage]
+		if !ok {
+			continue
+		}
+
+		if from != "" {
+			fn(from, stage, t.Sub(timings[from]))
+		}
+
+		from = stage
+	}
+}
+
+// LatencyHistogram is the distribution of the latency between two stages of the order
+// pipeline. Buckets are the cumulative counts of the LatencyBuckets bounds.
+type LatencyHistogram struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Buckets []uint64 `json:"buckets"`
+	Count   uint64   `json:"count"`
+	Sum     float64  `json:"sum"`
+}
+
+func (h *LatencyHistogram) observe(seconds float64) {
+	for i, bound := range LatencyBuckets {
+		if seconds <= bound {
+			h.Buckets[i]++
+		}
+	}
+
+	h.Count++
+	h.Sum += seconds
+}
+
+// LatencyHistograms holds the latency histograms of the stages of the order pipeline.
+// It is safe for concurrent use.
+type LatencyHistograms struct {
+	histograms map[string]*LatencyHistogram
+	mutex      sync.Mutex
+}
+
+// NewLatencyHistograms returns empty latency histograms
+func NewLatencyHistograms() *LatencyHistograms {
+	return &LatencyHistograms{histograms: make(map[string]*LatencyHistogram)}
+}
+
+// Observe records the latencies between the consecutive stages an order was stamped with
+func (l *LatencyHistograms) Observe(o *Order) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	forEachStageLatency(o.Timings, func(from, to string, d time.Duration) {
+		h, ok := l.histograms[from+"_"+to]
+		if !ok {
+			h = &LatencyHistogram{From: from, To: to, Buckets: make([]uint64, len(LatencyBuckets))}
+			l.histograms[from+"_"+to] = h
+		}
+
+		h.observe(d.Seconds())
+	})
+}
+
+// Get returns a copy of the histograms in the order of the pipeline stages
+func (l *LatencyHistograms) Get() []*LatencyHistogram {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	res := []*LatencyHistogram{}
+	for _, from := range PipelineStages {
+		for _, to := range PipelineStages {
+			h, ok := l.histograms[from+"_"+to]
+			if !ok {
+				continue
+			}
+
+			copied := *h
+			copied.Buckets = append([]uint64{}, h.Buckets...)
+			res = append(res, &copied)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderLatencies(t *testing.T) {
+	now := time.Now()
+	o := &Order{}
+	o.Stamp(StageReceived, now)
+	o.Stamp(StageValidated, now.Add(2*time.Millisecond))
+	o.Stamp(StageMatched, now.Add(5*time.Millisecond))
+
+	// the first time of a stage is kept
+	o.Stamp(StageReceived, now.Add(time.Second))
+
+	latencies := o.Latencies()
+	assert.Equal(t, 2, len(latencies))
+	assert.InDelta(t, 2, latencies["received_validated"], 1e-9)
+
+	// the stages the order did not go through are skipped
+	assert.InDelta(t, 3, latencies["validated_matched"], 1e-9)
+
+	h := NewLatencyHistograms()
+	h.Observe(o)
+	h.Observe(o)
+
+	histograms := h.Get()
+	assert.Equal(t, 2, len(histograms))
+	assert.Equal(t, StageReceived, histograms[0].From)
+	assert.Equal(t, StageValidated, histograms[0].To)
+	assert.Equal(t, uint64(2), histograms[0].Count)
+	assert.InDelta(t, 0.004, histograms[0].Sum, 1e-9)
+
+	// 2ms is over the 1ms bucket and within the 2.5ms one
+	assert.Equal(t, uint64(0), histograms[0].Buckets[0])
+	assert.Equal(t, uint64(2), histograms[0].Buckets[1])
+}
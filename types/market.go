@@ -0,0 +1,83 @@
+package types
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MarketToken is the description of a listed token sent on the markets channel
+type MarketToken struct {
+	Address  common.Address `json:"address"`
+	Symbol   string         `json:"symbol"`
+	Name     string         `json:"name"`
+	Decimals int            `json:"decimals"`
+	Active   bool           `json:"active"`
+	Quote    bool           `json:"quote"`
+}
+
+// MarketPair is the description of a listed pair and of its trading rules sent on the
+// markets channel. The fees are the fees charged when the message is sent.
+type MarketPair struct {
+	Pair            PairSubDoc `json:"pair"`
+	Active          bool       `json:"active"`
+	MakeFee         string     `json:"makeFee"`
+	TakeFee         string     `json:"takeFee"`
+	PricePrecision  int        `json:"pricePrecision"`
+	AmountPrecision int        `json:"amountPrecision"`
+	ListingTime     *time.Time `json:"listingTime,omitempty"`
+}
+
+// Markets is the catalog of the listed tokens and pairs
+type Markets struct {
+	Tokens []MarketToken `json:"tokens"`
+	Pairs  []MarketPair  `json:"pairs"`
+}
+
+// Market returns the description of the token sent on the markets channel
+func (t *Token) Market() MarketToken {
+	return MarketToken{
+		Address:  t.ContractAddress,
+		Symbol:   t.Symbol,
+		Name:     t.Name,
+		Decimals: t.Decimal,
+		Active:   t.Active,
+		Quote:    t.Quote,
+	}
+}
+
+// Market returns the description of the pair sent on the markets channel with the fees
+// charged at the given time
+func (p *Pair) Market(t time.Time) MarketPair {
+	makeFee, takeFee := p.EffectiveFees(t)
+
+	m := MarketPair{
+		Pair:            p.Reference(),
+		Active:          p.Active,
+		MakeFee:         makeFee.String(),
+		TakeFee:         takeFee.String(),
+		PricePrecision:  p.PricePrecision,
+		AmountPrecision: p.AmountPrecision,
+	}
+
+	if p.IsPreLaunch(t) {
+		m.ListingTime = p.ListingTime
+	}
+
+	return m
+}
+
+// NewMarkets returns the catalog of the given tokens and pairs with the fees charged at
+// the given time
+func NewMarkets(tokens []Token, pairs []Pair, t time.Time) *Markets {
+	m := &Markets{Tokens: []MarketToken{}, Pairs: []MarketPair{}}
+	for i := range tokens {
+		m.Tokens = append(m.Tokens, tokens[i].Market())
+	}
+
+	for i := range pairs {
+		m.Pairs = append(m.Pairs, pairs[i].Market(t))
+	}
+
+	return m
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPairMarket(t *testing.T) {
+	now := time.Now()
+	listingTime := now.Add(time.Hour)
+	pair := &Pair{
+		Name:              "ZRX/WETH",
+		Symbol:            "ZRX-WETH",
+		BaseTokenAddress:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteTokenAddress: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		Active:            true,
+		MakeFee:           big.NewInt(10000),
+		TakeFee:           big.NewInt(20000),
+		FeeOverride:       &PairFeeOverride{MakeFee: big.NewInt(0), TakeFee: big.NewInt(5000)},
+		PricePrecision:    8,
+		AmountPrecision:   2,
+		ListingTime:       &listingTime,
+	}
+
+	m := pair.Market(now)
+	assert.Equal(t, pair.Reference(), m.Pair)
+	assert.True(t, m.Active)
+	assert.Equal(t, "0", m.MakeFee)
+	assert.Equal(t, "5000", m.TakeFee)
+	assert.Equal(t, 8, m.PricePrecision)
+	assert.Equal(t, 2, m.AmountPrecision)
+	assert.Equal(t, &listingTime, m.ListingTime)
+
+	// the listing time is not sent once the pair is live
+	m = pair.Market(listingTime.Add(time.Second))
+	assert.Nil(t, m.ListingTime)
+}
+
+func TestNewMarkets(t *testing.T) {
+	tokens := []Token{{
+		Name:            "0x Protocol Token",
+		Symbol:          "ZRX",
+		ContractAddress: common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		Decimal:         18,
+		Quote:           false,
+	}}
+
+	m := NewMarkets(tokens, nil, time.Now())
+	assert.Equal(t, 1, len(m.Tokens))
+	assert.Equal(t, tokens[0].ContractAddress, m.Tokens[0].Address)
+	assert.Equal(t, 18, m.Tokens[0].Decimals)
+	assert.NotNil(t, m.Pairs)
+}
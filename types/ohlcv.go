@@ -1,5 +1,10 @@
 package types
 
+import (
+	"fmt"
+	"math/big"
+)
+
 // Tick is the format in which mongo aggregate pipeline returns data when queried for OHLCV data
 type Tick struct {
 	ID    TickID `json:"_id,omitempty" bson:"_id"`
@@ -10,6 +15,34 @@ type Tick struct {
 	O     int64  `json:"o" bson:"o"`
 	Ts    int64  `json:"ts" bson:"ts"`
 	V     int64  `json:"v" bson:"v"`
+
+	// Formatted holds the display representation of the tick values. It is only
+	// set when requested by the client.
+	Formatted *TickFormatted `json:"formatted,omitempty" bson:"-"`
+	// Interval is the interval of the tick (eg. "5min"), set on the tick updates so that
+	// the subscriptions to several intervals of a pair can tell them apart
+	Interval string `json:"interval,omitempty" bson:"-"`
+}
+
+// TickFormatted is the display representation of the prices and volume of a tick
+type TickFormatted struct {
+	O string `json:"o"`
+	H string `json:"h"`
+	L string `json:"l"`
+	C string `json:"c"`
+	V string `json:"v"`
+}
+
+// SetFormatted computes the display representation of the tick values using the
+// decimals and precision of the pair
+func (t *Tick) SetFormatted(p *Pair) {
+	t.Formatted = &TickFormatted{
+		O: p.FormatPrice(big.NewInt(t.O)),
+		H: p.FormatPrice(big.NewInt(t.H)),
+		L: p.FormatPrice(big.NewInt(t.L)),
+		C: p.FormatPrice(big.NewInt(t.C)),
+		V: p.FormatAmount(big.NewInt(t.V)),
+	}
 }
 
 // TickID is the subdocument for aggregate grouping for OHLCV data
@@ -17,6 +50,19 @@ type TickID struct {
 	Pair       string `json:"pair" bson:"pair"`
 	BaseToken  string `json:"baseToken" bson:"baseToken"`
 	QuoteToken string `json:"quoteToken" bson:"quoteToken"`
+	// Symbol is the display symbol of the pair, it is not part of the aggregation
+	Symbol string `json:"symbol,omitempty" bson:"-"`
+}
+
+// OHLCVInterval is an interval of the ticks of an ohlcv subscription (eg. 5 min)
+type OHLCVInterval struct {
+	Duration int64  `json:"duration"`
+	Units    string `json:"units"`
+}
+
+// Key returns the tag of the interval in the ohlcv messages (eg. "5min")
+func (i OHLCVInterval) Key() string {
+	return fmt.Sprintf("%d%s", i.Duration, i.Units)
 }
 
 type TickRequest struct {
@@ -25,4 +71,6 @@ type TickRequest struct {
 	To       int64        `json:"to"`
 	Duration int64        `json:"duration"`
 	Units    string       `json:"units"`
+	// Formatted adds display formatted values to the ticks
+	Formatted bool `json:"formatted"`
 }
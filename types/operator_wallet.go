@@ -0,0 +1,117 @@
+package types
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/encryption"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// OperatorWallet holds the address and private key of an account controlled by the
+// exchange operator (eg. the account used to send settlement transactions). It is the
+// only kind of wallet persisted by the server.
+type OperatorWallet struct {
+	ID         bson.ObjectId
+	Address    common.Address
+	PrivateKey *ecdsa.PrivateKey
+	Admin      bool
+}
+
+// NewOperatorWalletFromPrivateKey returns a new operator wallet object corresponding
+// to a given private key
+func NewOperatorWalletFromPrivateKey(key string) (*OperatorWallet, error) {
+	privateKey, err := crypto.HexToECDSA(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OperatorWallet{
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+		PrivateKey: privateKey,
+	}, nil
+}
+
+// GetAddress returns the wallet address
+func (w *OperatorWallet) GetAddress() string {
+	return w.Address.Hex()
+}
+
+func (w *OperatorWallet) Validate() error {
+	if w.PrivateKey == nil {
+		return errors.New("Operator wallet private key is required")
+	}
+
+	return nil
+}
+
+// OperatorWalletRecord is the BSON representation of an operator wallet. When a key ring
+// is configured the private key is only persisted as an encrypted envelope. The plaintext
+// PrivateKey field is kept to read records that have not been migrated yet.
+type OperatorWalletRecord struct {
+	ID                  bson.ObjectId        `json:"id,omitempty" bson:"_id"`
+	Address             string               `json:"address" bson:"address"`
+	PrivateKey          string               `json:"privateKey,omitempty" bson:"privateKey,omitempty"`
+	EncryptedPrivateKey *encryption.Envelope `json:"encryptedPrivateKey,omitempty" bson:"encryptedPrivateKey,omitempty"`
+	Admin               bool                 `json:"admin" bson:"admin"`
+}
+
+func (w *OperatorWallet) GetBSON() (interface{}, error) {
+	wr := OperatorWalletRecord{
+		ID:      w.ID,
+		Address: w.Address.Hex(),
+		Admin:   w.Admin,
+	}
+
+	key := hex.EncodeToString(w.PrivateKey.D.Bytes())
+	kr := encryption.GetKeyRing()
+	if kr == nil {
+		wr.PrivateKey = key
+		return wr, nil
+	}
+
+	e, err := kr.Seal([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+
+	wr.EncryptedPrivateKey = e
+	return wr, nil
+}
+
+func (w *OperatorWallet) SetBSON(raw bson.Raw) error {
+	decoded := &OperatorWalletRecord{}
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	key := decoded.PrivateKey
+	if decoded.EncryptedPrivateKey != nil {
+		kr := encryption.GetKeyRing()
+		if kr == nil {
+			return errors.New("Wallet private key is encrypted but no key ring is configured")
+		}
+
+		plaintext, err := kr.Open(decoded.EncryptedPrivateKey)
+		if err != nil {
+			return err
+		}
+
+		key = string(plaintext)
+	}
+
+	w.ID = decoded.ID
+	w.Address = common.HexToAddress(decoded.Address)
+	w.PrivateKey, err = crypto.HexToECDSA(key)
+	if err != nil {
+		return fmt.Errorf("Invalid private key for operator wallet %v", decoded.Address)
+	}
+
+	w.Admin = decoded.Admin
+	return nil
+}
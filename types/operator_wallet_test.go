@@ -0,0 +1,78 @@
+package types
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/encryption"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestOperatorWalletBSON(t *testing.T) {
+	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
+	w, _ := NewOperatorWalletFromPrivateKey(key)
+	w.ID = bson.NewObjectId()
+
+	data, err := bson.Marshal(w)
+	if err != nil {
+		t.Error("some error:", err)
+	}
+
+	decoded := &OperatorWallet{}
+	bson.Unmarshal(data, decoded)
+
+	assert.Equal(
+		t,
+		w.ID,
+		decoded.ID,
+		"ID should be encoded and decoded correctly",
+	)
+
+	assert.Equal(
+		t,
+		decoded.Address.Hex(),
+		"0xE8E84ee367BC63ddB38d3D01bCCEF106c194dc47",
+		"Address should be encoded and decoded correctly",
+	)
+
+	assert.Equal(
+		t,
+		hex.EncodeToString(decoded.PrivateKey.D.Bytes()),
+		"7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660",
+		"Private key should be encoded and decoded correctly",
+	)
+}
+
+func TestOperatorWalletEncryptedBSON(t *testing.T) {
+	kr, err := encryption.NewKeyRing("v1", map[string]string{"v1": "passphrase"})
+	if err != nil {
+		t.Fatalf("Could not create key ring: %v", err)
+	}
+
+	encryption.SetKeyRing(kr)
+	defer encryption.SetKeyRing(nil)
+
+	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
+	w, _ := NewOperatorWalletFromPrivateKey(key)
+	w.ID = bson.NewObjectId()
+
+	data, err := bson.Marshal(w)
+	if err != nil {
+		t.Error("some error:", err)
+	}
+
+	record := &OperatorWalletRecord{}
+	bson.Unmarshal(data, record)
+
+	assert.Equal(t, "", record.PrivateKey, "Private key should not be stored in plaintext")
+	assert.Equal(t, "v1", record.EncryptedPrivateKey.KeyID)
+
+	decoded := &OperatorWallet{}
+	err = bson.Unmarshal(data, decoded)
+	if err != nil {
+		t.Error("some error:", err)
+	}
+
+	assert.Equal(t, key, hex.EncodeToString(decoded.PrivateKey.D.Bytes()))
+}
@@ -8,6 +8,7 @@ import (
 	"math/big"
 	"time"
 
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/utils"
 	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
@@ -32,6 +33,7 @@ type Order struct {
 	Side            string         `json:"side" bson:"side"`
 	Hash            common.Hash    `json:"hash" bson:"hash"`
 	Signature       *Signature     `json:"signature,omitempty" bson:"signature"`
+	SignatureScheme string         `json:"signatureScheme,omitempty" bson:"signatureScheme,omitempty"`
 	Price           *big.Int       `json:"price" bson:"price"`
 	PricePoint      *big.Int       `json:"pricepoint" bson:"pricepoint"`
 	Amount          *big.Int       `json:"amount" bson:"amount"`
@@ -42,11 +44,40 @@ type Order struct {
 	TakeFee         *big.Int       `json:"takeFee" bson:"takeFee"`
 	OrderBook       *OrderSubDoc   `json:"orderBook" bson:"orderBook"`
 
+	// ZeroEx holds the 0x specific fields of the orders converted from signed 0x orders.
+	// It is nil for the native orders.
+	ZeroEx *ZeroExFields `json:"zeroEx,omitempty" bson:"zeroEx,omitempty"`
+
 	PairID   bson.ObjectId `json:"pairID,omitempty" bson:"_pairId"`
 	PairName string        `json:"pairName" bson:"pairName"`
 
+	// Sequence is assigned by the engine when the order enters it. It increases with
+	// every order and sets the time priority of the orders of a price level.
+	Sequence uint64 `json:"sequence,omitempty" bson:"sequence,omitempty"`
+
 	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+
+	// PriceFormatted and AmountFormatted are the display representation of the
+	// price and amount. They are not persisted and only set when requested by clients.
+	PriceFormatted  string `json:"priceFormatted,omitempty" bson:"-"`
+	AmountFormatted string `json:"amountFormatted,omitempty" bson:"-"`
+
+	// Archived is set in the responses when the order was found in the archive
+	// collection, where the old orders are moved out of the orders collection
+	Archived bool `json:"archived,omitempty" bson:"-"`
+
+	// Timings are the times the order reached the stages of the pipeline (see Stamp).
+	// They travel with the order through the engine and are not persisted.
+	Timings map[string]time.Time `json:"timings,omitempty" bson:"-"`
+	// Latency is the latency breakdown of the order sent to the client in the ORDER_ADDED
+	// messages when enabled (see Latencies)
+	Latency map[string]float64 `json:"latency,omitempty" bson:"-"`
+
+	// Origin identifies the API replica that sent the order to a separate matcher. It
+	// travels with the order through the engine so that only that replica processes the
+	// engine responses of the order, and is not persisted.
+	Origin string `json:"origin,omitempty" bson:"-"`
 }
 
 // OrderSubDoc is a sub document, it is used to store the order in order book
@@ -62,6 +93,48 @@ type OrderSubDoc struct {
 	Signature *Signature `json:"signature,omitempty" bson:"signature" redis:"signature"`
 }
 
+// OrderQuery holds the filters and the pagination of an order history request. Empty
+// filters are not applied, Limit 0 returns all the matching orders.
+type OrderQuery struct {
+	UserAddress common.Address
+	Statuses    []string
+	BaseToken   common.Address
+	QuoteToken  common.Address
+	From        time.Time
+	To          time.Time
+	Offset      int
+	Limit       int
+}
+
+// OrderResult is the result of an order, or of an order cancel, of a bulk request. Code
+// and Error are empty if the order was accepted.
+type OrderResult struct {
+	Hash  common.Hash `json:"hash"`
+	Code  string      `json:"code,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// Reject sets the code and the message of the error of a rejected order. The errors that
+// are not part of the error catalogue are reported as internal errors.
+func (r *OrderResult) Reject(err error) {
+	e := aerrors.NewWSError(err)
+	r.Code, r.Error = e.Code, e.Message
+}
+
+// MassQuote is the two-sided quote set of a market maker on a pair. Bids are buy orders
+// and asks are sell orders of the pair.
+type MassQuote struct {
+	BaseToken  common.Address `json:"baseToken"`
+	QuoteToken common.Address `json:"quoteToken"`
+	Bids       []*Order       `json:"bids"`
+	Asks       []*Order       `json:"asks"`
+}
+
+// Orders returns the bids followed by the asks of the quote set
+func (q *MassQuote) Orders() []*Order {
+	return append(append([]*Order{}, q.Bids...), q.Asks...)
+}
+
 func (o Order) Validate() error {
 	return validation.ValidateStruct(&o,
 		validation.Field(&o.ExchangeAddress, validation.Required),
@@ -79,8 +152,19 @@ func (o Order) Validate() error {
 	)
 }
 
-// ComputeHash calculates the orderRequest hash
+// ComputeHash calculates the orderRequest hash. The hash of an order converted from a 0x
+// order is the 0x order hash and the hash of an order signed with eth_signTypedData is its
+// EIP712 hash.
 func (o *Order) ComputeHash() common.Hash {
+	if o.ZeroEx != nil {
+		z, _ := NewZeroExOrder(o)
+		return z.ComputeHash()
+	}
+
+	if o.SignatureScheme == SchemeEIP712 {
+		return o.ComputeTypedHash()
+	}
+
 	sha := sha3.NewKeccak256()
 	sha.Write(o.UserAddress.Bytes())
 	sha.Write(o.ExchangeAddress.Bytes())
@@ -95,6 +179,18 @@ func (o *Order) ComputeHash() common.Hash {
 
 // VerifySignature checks that the orderRequest signature corresponds to the address in the userAddress field
 func (o *Order) VerifySignature() (bool, error) {
+	if o.ZeroEx != nil {
+		z, _ := NewZeroExOrder(o)
+		o.Hash = z.ComputeHash()
+		return z.VerifySignature()
+	}
+
+	// the EIP712 hash is signed without the eth_sign prefix
+	if o.SignatureScheme == SchemeEIP712 {
+		o.Hash = o.ComputeTypedHash()
+		return VerifyTypedSignature(o.Hash, o.Signature, o.UserAddress)
+	}
+
 	o.Hash = o.ComputeHash()
 	message := crypto.Keccak256(
 		[]byte("\x19Ethereum Signed Message:\n32"),
@@ -201,9 +297,16 @@ func (o *Order) Process(p *Pair) error {
 // }
 
 // GetKVPrefix returns the key value store(redis) prefix to be used
-// by matching engine correspondind to a particular order.
+// by matching engine correspondind to a particular order. The orders converted from 0x
+// orders are kept in an orderbook of their own, so that they are never matched against
+// the orders settled on the exchange contract.
 func (o *Order) GetKVPrefix() string {
-	return o.BaseToken.Hex() + "::" + o.QuoteToken.Hex()
+	prefix := o.BaseToken.Hex() + "::" + o.QuoteToken.Hex()
+	if o.ZeroEx != nil {
+		return prefix + ZeroExOrderBook
+	}
+
+	return prefix
 }
 
 // GetOBKeys returns the keys corresponding to an order
@@ -281,9 +384,55 @@ func (o *Order) MarshalJSON() ([]byte, error) {
 		}
 	}
 
+	if o.SignatureScheme != SchemeEthSign {
+		order["signatureScheme"] = o.SignatureScheme
+	}
+
+	if o.ZeroEx != nil {
+		order["zeroEx"] = o.ZeroEx.Record()
+	}
+
+	if o.PriceFormatted != "" {
+		order["priceFormatted"] = o.PriceFormatted
+		order["amountFormatted"] = o.AmountFormatted
+	}
+
+	if o.Archived {
+		order["archived"] = true
+	}
+
+	if o.Sequence != 0 {
+		order["sequence"] = o.Sequence
+	}
+
+	if len(o.Timings) > 0 {
+		timings := map[string]string{}
+		for stage, t := range o.Timings {
+			timings[stage] = t.Format(time.RFC3339Nano)
+		}
+
+		order["timings"] = timings
+	}
+
+	if o.Latency != nil {
+		order["latency"] = o.Latency
+	}
+
 	return json.Marshal(order)
 }
 
+// SetFormatted computes the display representation of the order price and amount
+// using the decimals and precision of the pair
+func (o *Order) SetFormatted(p *Pair) {
+	if o.PricePoint != nil {
+		o.PriceFormatted = p.FormatPricePoint(o.PricePoint)
+	} else {
+		o.PriceFormatted = p.FormatPrice(o.Price)
+	}
+
+	o.AmountFormatted = p.FormatAmount(o.Amount)
+}
+
 func (o *Order) UnmarshalJSON(b []byte) error {
 	order := map[string]interface{}{}
 
@@ -388,6 +537,10 @@ func (o *Order) UnmarshalJSON(b []byte) error {
 		}
 	}
 
+	if order["signatureScheme"] != nil {
+		o.SignatureScheme = order["signatureScheme"].(string)
+	}
+
 	if order["orderBook"] != nil {
 		subdoc := order["orderBook"].(map[string]interface{})
 		sudocsig := subdoc["signature"].(map[string]interface{})
@@ -401,6 +554,31 @@ func (o *Order) UnmarshalJSON(b []byte) error {
 		}
 	}
 
+	if order["zeroEx"] != nil {
+		decoded := struct {
+			ZeroEx *ZeroExFieldsRecord `json:"zeroEx"`
+		}{}
+
+		err = json.Unmarshal(b, &decoded)
+		if err != nil {
+			return err
+		}
+
+		o.ZeroEx = NewZeroExFields(decoded.ZeroEx)
+	}
+
+	if order["sequence"] != nil {
+		o.Sequence = uint64(order["sequence"].(float64))
+	}
+
+	if order["timings"] != nil {
+		o.Timings = make(map[string]time.Time)
+		for stage, v := range order["timings"].(map[string]interface{}) {
+			t, _ := time.Parse(time.RFC3339Nano, v.(string))
+			o.Timings[stage] = t
+		}
+	}
+
 	if order["createdAt"] != nil {
 		t, _ := time.Parse(time.RFC3339Nano, order["createdAt"].(string))
 		o.CreatedAt = t
@@ -473,10 +651,14 @@ type OrderRecord struct {
 	MakeFee         string             `json:"makeFee" bson:"makeFee"`
 	TakeFee         string             `json:"takeFee" bson:"takeFee"`
 	Signature       *SignatureRecord   `json:"signature,omitempty" bson:"signature"`
+	SignatureScheme string             `json:"signatureScheme,omitempty" bson:"signatureScheme,omitempty"`
 	OrderBook       *OrderSubDocRecord `json:"orderBook" bson:"orderBook"`
 
+	ZeroEx *ZeroExFieldsRecord `json:"zeroEx,omitempty" bson:"zeroEx,omitempty"`
+
 	PairID    bson.ObjectId `json:"pairID" bson:"_pairId"`
 	PairName  string        `json:"pairName" bson:"pairName"`
+	Sequence  uint64        `json:"sequence,omitempty" bson:"sequence,omitempty"`
 	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
 	UpdatedAt time.Time     `json:"updatedAt" bson:"updatedAt"`
 }
@@ -510,6 +692,8 @@ func (o *Order) GetBSON() (interface{}, error) {
 		Expires:         o.Expires.String(),
 		MakeFee:         o.MakeFee.String(),
 		TakeFee:         o.TakeFee.String(),
+		SignatureScheme: o.SignatureScheme,
+		Sequence:        o.Sequence,
 		CreatedAt:       o.CreatedAt,
 		UpdatedAt:       o.UpdatedAt,
 	}
@@ -533,6 +717,10 @@ func (o *Order) GetBSON() (interface{}, error) {
 		}
 	}
 
+	if o.ZeroEx != nil {
+		or.ZeroEx = o.ZeroEx.Record()
+	}
+
 	return or, nil
 }
 
@@ -561,9 +749,14 @@ func (o *Order) SetBSON(raw bson.Raw) error {
 		MakeFee         string             `json:"makeFee" bson:"makeFee"`
 		TakeFee         string             `json:"takeFee" bson:"takeFee"`
 		Signature       *SignatureRecord   `json:"signature" bson:"signature"`
+		SignatureScheme string             `json:"signatureScheme" bson:"signatureScheme"`
 		OrderBook       *OrderSubDocRecord `json:"orderBook" bson:"orderBook"`
-		CreatedAt       time.Time          `json:"createdAt" bson:"createdAt"`
-		UpdatedAt       time.Time          `json:"updatedAt" bson:"updatedAt"`
+
+		ZeroEx *ZeroExFieldsRecord `json:"zeroEx" bson:"zeroEx"`
+
+		Sequence  uint64    `json:"sequence" bson:"sequence"`
+		CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+		UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
 	})
 
 	err := raw.Unmarshal(decoded)
@@ -596,6 +789,7 @@ func (o *Order) SetBSON(raw bson.Raw) error {
 	o.Status = decoded.Status
 	o.Side = decoded.Side
 	o.Hash = common.HexToHash(decoded.Hash)
+	o.SignatureScheme = decoded.SignatureScheme
 
 	if decoded.Signature != nil {
 		o.Signature = &Signature{
@@ -616,6 +810,11 @@ func (o *Order) SetBSON(raw bson.Raw) error {
 		}
 	}
 
+	if decoded.ZeroEx != nil {
+		o.ZeroEx = NewZeroExFields(decoded.ZeroEx)
+	}
+
+	o.Sequence = decoded.Sequence
 	o.CreatedAt = decoded.CreatedAt
 	o.UpdatedAt = decoded.UpdatedAt
 
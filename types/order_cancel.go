@@ -62,18 +62,35 @@ func (oc *OrderCancel) UnmarshalJSON(b []byte) error {
 	if parsed["orderHash"] == nil {
 		return errors.New("Order Hash is missing")
 	}
-	oc.OrderHash = HexToHash(parsed["orderHash"].(string))
+
+	orderHash, ok := parsed["orderHash"].(string)
+	if !ok {
+		return errors.New("Order Hash should be a string")
+	}
+	oc.OrderHash = HexToHash(orderHash)
 
 	if parsed["hash"] == nil {
 		return errors.New("Hash is missing")
 	}
-	oc.Hash = HexToHash(parsed["hash"].(string))
 
-	sig := parsed["signature"].(map[string]interface{})
+	hash, ok := parsed["hash"].(string)
+	if !ok {
+		return errors.New("Hash should be a string")
+	}
+	oc.Hash = HexToHash(hash)
+
+	sig, ok := parsed["signature"].(map[string]interface{})
+	if !ok {
+		return errors.New("Signature is missing")
+	}
+
+	v, _ := sig["V"].(float64)
+	r, _ := sig["R"].(string)
+	s, _ := sig["S"].(string)
 	oc.Signature = &Signature{
-		V: byte(sig["V"].(float64)),
-		R: HexToHash(sig["R"].(string)),
-		S: HexToHash(sig["S"].(string)),
+		V: byte(v),
+		R: HexToHash(r),
+		S: HexToHash(s),
 	}
 
 	return nil
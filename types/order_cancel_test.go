@@ -0,0 +1,57 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderCancelSignature(t *testing.T) {
+	w := NewWallet()
+	o := &Order{UserAddress: w.Address}
+
+	oc := &OrderCancel{OrderHash: common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a")}
+	if err := oc.Sign(w); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, oc.ComputeHash(), oc.Hash)
+
+	ok, err := oc.VerifySignature(o)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// the cancel is not signed by the maker of the order
+	ok, _ = oc.VerifySignature(&Order{UserAddress: NewWallet().Address})
+	assert.False(t, ok)
+}
+
+func TestOrderCancelUnmarshal(t *testing.T) {
+	w := NewWallet()
+	oc := &OrderCancel{OrderHash: common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a")}
+	if err := oc.Sign(w); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(oc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &OrderCancel{}
+	err = json.Unmarshal(b, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, oc, decoded)
+
+	err = json.Unmarshal([]byte(`{"orderHash": "0x1", "hash": "0x2"}`), &OrderCancel{})
+	assert.NotNil(t, err)
+
+	// the hashes must be strings
+	err = json.Unmarshal([]byte(`{"orderHash": 1, "hash": "0x2", "signature": {}}`), &OrderCancel{})
+	assert.NotNil(t, err)
+
+	err = json.Unmarshal([]byte(`{"orderHash": "0x1", "hash": {}, "signature": {}}`), &OrderCancel{})
+	assert.NotNil(t, err)
+}
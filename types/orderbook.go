@@ -0,0 +1,244 @@
+package types
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// BookLevel is a price level of a side of an orderbook as stored by the engine: its
+// pricepoint, the remaining amount of its orders and their number
+type BookLevel struct {
+	PricePoint int64
+	Volume     int64
+	Orders     int
+}
+
+// AggregatedLevel is a price bucket of an aggregated orderbook. The price and amount are
+// in the units of the orderbook levels returned by the engine.
+type AggregatedLevel struct {
+	Price  float64 `json:"price"`
+	Amount float64 `json:"amount"`
+	Orders int     `json:"orders"`
+}
+
+// AggregatedOrderBook is an orderbook whose price levels are grouped in buckets of
+// Precision decimals, best price first
+type AggregatedOrderBook struct {
+	Pair      PairSubDoc        `json:"pair"`
+	Precision int               `json:"precision"`
+	Bids      []AggregatedLevel `json:"bids"`
+	Asks      []AggregatedLevel `json:"asks"`
+}
+
+// MaxBookPrecision is the number of decimals of the prices of the orderbook levels, the
+// pricepoints being the prices multiplied by 1e8
+const MaxBookPrecision = 8
+
+// NewAggregatedOrderBook groups the price levels of an orderbook (best price first, as
+// returned by the engine) in buckets of precision decimals and keeps the depth best
+// buckets of each side, all of them if depth is 0. The bids are rounded down and the
+// asks up, so that a bucket is never priced better than its orders.
+func NewAggregatedOrderBook(bids, asks []*BookLevel, precision, depth int) *AggregatedOrderBook {
+	return &AggregatedOrderBook{
+		Precision: precision,
+		Bids:      aggregateLevels(bids, precision, depth, false),
+		Asks:      aggregateLevels(asks, precision, depth, true),
+	}
+}
+
+func aggregateLevels(levels []*BookLevel, precision, depth int, roundUp bool) []AggregatedLevel {
+	bucket := int64(math.Pow10(MaxBookPrecision - precision))
+	unit := math.Pow10(MaxBookPrecision)
+
+	res := []AggregatedLevel{}
+	last := int64(-1)
+	for _, l := range levels {
+		pp := l.PricePoint / bucket * bucket
+		if roundUp && pp < l.PricePoint {
+			pp += bucket
+		}
+
+		if pp != last {
+			if depth > 0 && len(res) == depth {
+				break
+			}
+
+			res = append(res, AggregatedLevel{Price: float64(pp) / unit})
+			last = pp
+		}
+
+		res[len(res)-1].Amount += float64(l.Volume) / unit
+		res[len(res)-1].Orders += l.Orders
+	}
+
+	return res
+}
+
+// Actions of the changes of a full orderbook
+const (
+	RawOrderAdded   = "add"
+	RawOrderUpdated = "update"
+	RawOrderRemoved = "remove"
+)
+
+// RawOrder is a resting order of a full orderbook. The price and the remaining amount of
+// the order are in the units of the aggregated orderbook, the sequence sets the time
+// priority of the order within its price level.
+type RawOrder struct {
+	Hash     common.Hash    `json:"hash"`
+	Maker    common.Address `json:"maker"`
+	Side     string         `json:"side"`
+	Price    float64        `json:"price"`
+	Amount   float64        `json:"amount"`
+	Sequence uint64         `json:"sequence"`
+}
+
+// RawOrderBook is the full orderbook of a pair: its resting orders, best price first and
+// in time priority within a price level
+type RawOrderBook struct {
+	Pair PairSubDoc  `json:"pair"`
+	Bids []*RawOrder `json:"bids"`
+	Asks []*RawOrder `json:"asks"`
+}
+
+// RawOrderChange is a change of a resting order of a full orderbook. The order of an
+// added or updated order is its new state, the order of a removed order its last state.
+type RawOrderChange struct {
+	Action string    `json:"action"`
+	Order  *RawOrder `json:"order"`
+}
+
+// NewRawOrder returns the resting order of a full orderbook of an order of the engine
+func NewRawOrder(o *Order) *RawOrder {
+	unit := math.Pow10(MaxBookPrecision)
+	remaining := new(big.Int).Set(o.Amount)
+	if o.FilledAmount != nil {
+		remaining.Sub(remaining, o.FilledAmount)
+	}
+
+	amount, _ := new(big.Float).Quo(new(big.Float).SetInt(remaining), big.NewFloat(unit)).Float64()
+	price, _ := new(big.Float).Quo(new(big.Float).SetInt(o.PricePoint), big.NewFloat(unit)).Float64()
+
+	return &RawOrder{
+		Hash:     o.Hash,
+		Maker:    o.UserAddress,
+		Side:     o.Side,
+		Price:    price,
+		Amount:   amount,
+		Sequence: o.Sequence,
+	}
+}
+
+// NewRawOrderBook returns the full orderbook of the resting orders of a pair, as
+// returned by the engine
+func NewRawOrderBook(bids, asks []*Order) *RawOrderBook {
+	ob := &RawOrderBook{Bids: []*RawOrder{}, Asks: []*RawOrder{}}
+	for _, o := range bids {
+		ob.Bids = append(ob.Bids, NewRawOrder(o))
+	}
+
+	for _, o := range asks {
+		ob.Asks = append(ob.Asks, NewRawOrder(o))
+	}
+
+	return ob
+}
+
+// Orders returns the resting orders of both sides of the orderbook
+func (ob *RawOrderBook) Orders() []*RawOrder {
+	return append(append([]*RawOrder{}, ob.Bids...), ob.Asks...)
+}
+
+// DiffRawOrders returns the changes turning the resting orders from into the resting
+// orders to: the removed orders first, then the added and updated orders in the order of
+// to.
+func DiffRawOrders(from, to []*RawOrder) []*RawOrderChange {
+	previous := map[common.Hash]*RawOrder{}
+	for _, o := range from {
+		previous[o.Hash] = o
+	}
+
+	current := map[common.Hash]bool{}
+	for _, o := range to {
+		current[o.Hash] = true
+	}
+
+	changes := []*RawOrderChange{}
+	for _, o := range from {
+		if !current[o.Hash] {
+			changes = append(changes, &RawOrderChange{Action: RawOrderRemoved, Order: o})
+		}
+	}
+
+	for _, o := range to {
+		p, ok := previous[o.Hash]
+		switch {
+		case !ok:
+			changes = append(changes, &RawOrderChange{Action: RawOrderAdded, Order: o})
+		case *p != *o:
+			changes = append(changes, &RawOrderChange{Action: RawOrderUpdated, Order: o})
+		}
+	}
+
+	return changes
+}
+
+// OrderBookChange is a change of a price level of an orderbook: its new amount, 0 if the
+// level was removed. The price and the amount are in the units of the orderbook entries.
+type OrderBookChange struct {
+	Side   string  `json:"side"`
+	Price  float64 `json:"price"`
+	Amount float64 `json:"amount"`
+}
+
+// OrderBookEntries returns the entries (price and volume) of the orderbook messages of
+// price levels
+func OrderBookEntries(levels []*BookLevel) []map[string]float64 {
+	unit := math.Pow10(MaxBookPrecision)
+	entries := []map[string]float64{}
+	for _, l := range levels {
+		entries = append(entries, map[string]float64{
+			"price":  float64(l.PricePoint) / unit,
+			"volume": float64(l.Volume) / unit,
+		})
+	}
+
+	return entries
+}
+
+// DiffBookLevels returns the changes turning the price levels from of a side of an
+// orderbook into the price levels to: the removed levels first, then the added and
+// updated levels in the order of to.
+func DiffBookLevels(side string, from, to []*BookLevel) []*OrderBookChange {
+	unit := math.Pow10(MaxBookPrecision)
+	previous := map[int64]int64{}
+	for _, l := range from {
+		previous[l.PricePoint] = l.Volume
+	}
+
+	current := map[int64]bool{}
+	for _, l := range to {
+		current[l.PricePoint] = true
+	}
+
+	changes := []*OrderBookChange{}
+	for _, l := range from {
+		if !current[l.PricePoint] {
+			changes = append(changes, &OrderBookChange{Side: side, Price: float64(l.PricePoint) / unit})
+		}
+	}
+
+	for _, l := range to {
+		if volume, ok := previous[l.PricePoint]; !ok || volume != l.Volume {
+			changes = append(changes, &OrderBookChange{
+				Side:   side,
+				Price:  float64(l.PricePoint) / unit,
+				Amount: float64(l.Volume) / unit,
+			})
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,107 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAggregatedOrderBook(t *testing.T) {
+	// prices of 0.1234, 0.1201 and 0.11 with 1e8 pricepoint units
+	bids := []*BookLevel{
+		{PricePoint: 12340000, Volume: 100000000, Orders: 1},
+		{PricePoint: 12010000, Volume: 200000000, Orders: 2},
+		{PricePoint: 11000000, Volume: 300000000, Orders: 1},
+	}
+
+	asks := []*BookLevel{
+		{PricePoint: 12350000, Volume: 100000000, Orders: 1},
+		{PricePoint: 12900000, Volume: 100000000, Orders: 4},
+		{PricePoint: 13000000, Volume: 500000000, Orders: 1},
+	}
+
+	ob := NewAggregatedOrderBook(bids, asks, 2, 0)
+	assert.Equal(t, 2, ob.Precision)
+	assert.Equal(t, 2, len(ob.Bids))
+	assert.InDelta(t, 0.12, ob.Bids[0].Price, 1e-9)
+	assert.InDelta(t, 3, ob.Bids[0].Amount, 1e-9)
+	assert.Equal(t, 3, ob.Bids[0].Orders)
+	assert.InDelta(t, 0.11, ob.Bids[1].Price, 1e-9)
+
+	// the asks are rounded up
+	assert.Equal(t, 1, len(ob.Asks))
+	assert.InDelta(t, 0.13, ob.Asks[0].Price, 1e-9)
+	assert.InDelta(t, 7, ob.Asks[0].Amount, 1e-9)
+	assert.Equal(t, 6, ob.Asks[0].Orders)
+
+	// the depth limits the number of buckets of each side
+	ob = NewAggregatedOrderBook(bids, asks, MaxBookPrecision, 2)
+	assert.Equal(t, 2, len(ob.Bids))
+	assert.Equal(t, 2, len(ob.Asks))
+	assert.InDelta(t, 0.1234, ob.Bids[0].Price, 1e-9)
+	assert.InDelta(t, 0.129, ob.Asks[1].Price, 1e-9)
+}
+
+func TestDiffRawOrders(t *testing.T) {
+	first := &RawOrder{Hash: common.HexToHash("0x1"), Side: "BUY", Price: 0.1, Amount: 10, Sequence: 1}
+	second := &RawOrder{Hash: common.HexToHash("0x2"), Side: "BUY", Price: 0.1, Amount: 5, Sequence: 2}
+	third := &RawOrder{Hash: common.HexToHash("0x3"), Side: "SELL", Price: 0.2, Amount: 1, Sequence: 3}
+
+	filled := *second
+	filled.Amount = 2
+
+	changes := DiffRawOrders([]*RawOrder{first, second}, []*RawOrder{&filled, third})
+	assert.Equal(t, 3, len(changes))
+	assert.Equal(t, RawOrderRemoved, changes[0].Action)
+	assert.Equal(t, first, changes[0].Order)
+	assert.Equal(t, RawOrderUpdated, changes[1].Action)
+	assert.Equal(t, float64(2), changes[1].Order.Amount)
+	assert.Equal(t, RawOrderAdded, changes[2].Action)
+	assert.Equal(t, third, changes[2].Order)
+
+	assert.Equal(t, 0, len(DiffRawOrders([]*RawOrder{first}, []*RawOrder{first})))
+}
+
+func TestNewRawOrder(t *testing.T) {
+	o := &Order{
+		Hash:         common.HexToHash("0x1"),
+		UserAddress:  common.HexToAddress("0x2"),
+		Side:         "SELL",
+		PricePoint:   big.NewInt(12340000),
+		Amount:       big.NewInt(500000000),
+		FilledAmount: big.NewInt(200000000),
+		Sequence:     7,
+	}
+
+	raw := NewRawOrder(o)
+	assert.Equal(t, o.UserAddress, raw.Maker)
+	assert.InDelta(t, 0.1234, raw.Price, 1e-9)
+	assert.InDelta(t, 3, raw.Amount, 1e-9)
+	assert.Equal(t, uint64(7), raw.Sequence)
+}
+
+func TestDiffBookLevels(t *testing.T) {
+	from := []*BookLevel{
+		{PricePoint: 12000000, Volume: 100000000, Orders: 1},
+		{PricePoint: 11000000, Volume: 200000000, Orders: 2},
+	}
+
+	to := []*BookLevel{
+		{PricePoint: 12000000, Volume: 100000000, Orders: 1},
+		{PricePoint: 11500000, Volume: 300000000, Orders: 1},
+		{PricePoint: 11000000, Volume: 150000000, Orders: 1},
+	}
+
+	changes := DiffBookLevels("BUY", from, to)
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, &OrderBookChange{Side: "BUY", Price: 0.115, Amount: 3}, changes[0])
+	assert.Equal(t, &OrderBookChange{Side: "BUY", Price: 0.11, Amount: 1.5}, changes[1])
+
+	// the removed levels have a 0 amount
+	changes = DiffBookLevels("SELL", to, to[:1])
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, float64(0), changes[0].Amount)
+	assert.Equal(t, 0.115, changes[0].Price)
+}
@@ -2,10 +2,13 @@ package types
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 
 	validation "github.com/go-ozzo/ozzo-validation"
@@ -16,6 +19,7 @@ import (
 type Pair struct {
 	ID                bson.ObjectId  `json:"id" bson:"_id"`
 	Name              string         `json:"name" bson:"name"`
+	Symbol            string         `json:"symbol" bson:"symbol"`
 	BaseTokenID       bson.ObjectId  `json:"baseTokenId" bson:"baseTokenId"`
 	BaseTokenSymbol   string         `json:"baseTokenSymbol" bson:"baseTokenSymbol"`
 	BaseTokenAddress  common.Address `json:"baseTokenAddress" bson:"baseTokenAddress"`
@@ -29,12 +33,31 @@ type Pair struct {
 	MakeFee *big.Int `json:"makeFee" bson:"makeFee"`
 	TakeFee *big.Int `json:"takeFee" bson:"takeFee"`
 
-	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+	// FeeOverride replaces the make and take fees of the pair while it applies.
+	// EffectiveMakeFee and EffectiveTakeFee are the fees charged when the pair was
+	// loaded, they are not stored.
+	FeeOverride      *PairFeeOverride `json:"feeOverride,omitempty" bson:"feeOverride,omitempty"`
+	EffectiveMakeFee *big.Int         `json:"effectiveMakeFee" bson:"-"`
+	EffectiveTakeFee *big.Int         `json:"effectiveTakeFee" bson:"-"`
+
+	// ListingTime is the go-live time of a scheduled listing. Orders are collected but
+	// not matched until then. LaunchedAt is set when the listing has been launched.
+	ListingTime *time.Time `json:"listingTime,omitempty" bson:"listingTime,omitempty"`
+	LaunchedAt  *time.Time `json:"launchedAt,omitempty" bson:"launchedAt,omitempty"`
+
+	// PricePrecision and AmountPrecision are the number of digits after the decimal
+	// point used to format prices and amounts for display
+	PricePrecision  int `json:"pricePrecision" bson:"pricePrecision"`
+	AmountPrecision int `json:"amountPrecision" bson:"amountPrecision"`
+
+	CreatedAt time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
 }
 
 type PairSubDoc struct {
 	Name       string         `json:"name" bson:"name"`
+	Symbol     string         `json:"symbol,omitempty" bson:"symbol,omitempty"`
 	BaseToken  common.Address `json:"baseToken" bson:"baseToken"`
 	QuoteToken common.Address `json:"quoteToken" bson:"quoteToken"`
 }
@@ -42,6 +65,7 @@ type PairSubDoc struct {
 type PairRecord struct {
 	ID                bson.ObjectId `json:"id" bson:"_id"`
 	Name              string        `json:"name" bson:"name"`
+	Symbol            string        `json:"symbol" bson:"symbol"`
 	BaseTokenID       bson.ObjectId `json:"baseTokenId" bson:"baseTokenId"`
 	BaseTokenSymbol   string        `json:"baseTokenSymbol" bson:"baseTokenSymbol"`
 	BaseTokenAddress  string        `json:"baseTokenAddress" bson:"baseTokenAddress"`
@@ -55,8 +79,80 @@ type PairRecord struct {
 	MakeFee string `json:"makeFee" bson:"makeFee"`
 	TakeFee string `json:"takeFee" bson:"takeFee"`
 
-	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+	FeeOverride *PairFeeOverride `json:"feeOverride,omitempty" bson:"feeOverride,omitempty"`
+
+	ListingTime *time.Time `json:"listingTime,omitempty" bson:"listingTime,omitempty"`
+	LaunchedAt  *time.Time `json:"launchedAt,omitempty" bson:"launchedAt,omitempty"`
+
+	PricePrecision  int `json:"pricePrecision" bson:"pricePrecision"`
+	AmountPrecision int `json:"amountPrecision" bson:"amountPrecision"`
+
+	CreatedAt time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// PairFeeOverride replaces the make and take fees of a pair (eg. for zero fee
+// promotions). The override does not apply anymore after ExpiresAt when it is set.
+type PairFeeOverride struct {
+	MakeFee   *big.Int   `json:"makeFee"`
+	TakeFee   *big.Int   `json:"takeFee"`
+	Reason    string     `json:"reason,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// PairFeeOverrideRecord is the struct which is stored in db
+type PairFeeOverrideRecord struct {
+	MakeFee   string     `bson:"makeFee"`
+	TakeFee   string     `bson:"takeFee"`
+	Reason    string     `bson:"reason,omitempty"`
+	ExpiresAt *time.Time `bson:"expiresAt,omitempty"`
+	UpdatedAt time.Time  `bson:"updatedAt"`
+}
+
+// Validate verifies that the fees of the override are set and not negative
+func (f PairFeeOverride) Validate() error {
+	if f.MakeFee == nil || f.MakeFee.Sign() < 0 {
+		return errors.New("Invalid make fee")
+	}
+
+	if f.TakeFee == nil || f.TakeFee.Sign() < 0 {
+		return errors.New("Invalid take fee")
+	}
+
+	return nil
+}
+
+// AppliesAt returns true if the override has not expired at the given time
+func (f *PairFeeOverride) AppliesAt(t time.Time) bool {
+	return f.ExpiresAt == nil || t.Before(*f.ExpiresAt)
+}
+
+func (f *PairFeeOverride) GetBSON() (interface{}, error) {
+	return &PairFeeOverrideRecord{
+		MakeFee:   f.MakeFee.String(),
+		TakeFee:   f.TakeFee.String(),
+		Reason:    f.Reason,
+		ExpiresAt: f.ExpiresAt,
+		UpdatedAt: f.UpdatedAt,
+	}, nil
+}
+
+func (f *PairFeeOverride) SetBSON(raw bson.Raw) error {
+	decoded := &PairFeeOverrideRecord{}
+
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	f.MakeFee = math.ToBigInt(decoded.MakeFee)
+	f.TakeFee = math.ToBigInt(decoded.TakeFee)
+	f.Reason = decoded.Reason
+	f.ExpiresAt = decoded.ExpiresAt
+	f.UpdatedAt = decoded.UpdatedAt
+	return nil
 }
 
 func (p *Pair) SetBSON(raw bson.Raw) error {
@@ -74,6 +170,7 @@ func (p *Pair) SetBSON(raw bson.Raw) error {
 
 	p.ID = decoded.ID
 	p.Name = decoded.Name
+	p.Symbol = decoded.Symbol
 	p.BaseTokenID = decoded.BaseTokenID
 	p.BaseTokenSymbol = decoded.BaseTokenSymbol
 	p.BaseTokenAddress = common.HexToAddress(decoded.BaseTokenAddress)
@@ -86,10 +183,22 @@ func (p *Pair) SetBSON(raw bson.Raw) error {
 	p.Active = decoded.Active
 	p.MakeFee = makeFee
 	p.TakeFee = takeFee
+	p.FeeOverride = decoded.FeeOverride
+	p.ListingTime = decoded.ListingTime
+	p.LaunchedAt = decoded.LaunchedAt
+	p.PricePrecision = decoded.PricePrecision
+	p.AmountPrecision = decoded.AmountPrecision
 
 	p.CreatedAt = decoded.CreatedAt
 	p.UpdatedAt = decoded.UpdatedAt
+	p.DeletedAt = decoded.DeletedAt
+
+	// pairs created before symbols were registered use the default symbol
+	if p.Symbol == "" {
+		p.Symbol = p.DefaultSymbol()
+	}
 
+	p.EffectiveMakeFee, p.EffectiveTakeFee = p.EffectiveFees(time.Now())
 	return nil
 }
 
@@ -97,6 +206,7 @@ func (p *Pair) GetBSON() (interface{}, error) {
 	return &PairRecord{
 		ID:                p.ID,
 		Name:              p.Name,
+		Symbol:            p.Symbol,
 		BaseTokenID:       p.BaseTokenID,
 		BaseTokenSymbol:   p.BaseTokenSymbol,
 		BaseTokenAddress:  p.BaseTokenAddress.Hex(),
@@ -108,8 +218,14 @@ func (p *Pair) GetBSON() (interface{}, error) {
 		Active:            p.Active,
 		MakeFee:           p.MakeFee.String(),
 		TakeFee:           p.TakeFee.String(),
+		FeeOverride:       p.FeeOverride,
+		ListingTime:       p.ListingTime,
+		LaunchedAt:        p.LaunchedAt,
+		PricePrecision:    p.PricePrecision,
+		AmountPrecision:   p.AmountPrecision,
 		CreatedAt:         p.CreatedAt,
 		UpdatedAt:         p.UpdatedAt,
+		DeletedAt:         p.DeletedAt,
 	}, nil
 }
 
@@ -122,6 +238,86 @@ func (p Pair) Validate() error {
 	)
 }
 
+// DefaultSymbol returns the display symbol of the pair made of the base and quote token
+// symbols (eg. AMP-WETH)
+func (p *Pair) DefaultSymbol() string {
+	return strings.ToUpper(p.BaseTokenSymbol + "-" + p.QuoteTokenSymbol)
+}
+
+// Reference returns the subdocument identifying the pair in market data payloads,
+// with both its display symbol and its token addresses
+func (p *Pair) Reference() PairSubDoc {
+	return PairSubDoc{
+		Name:       p.Name,
+		Symbol:     p.Symbol,
+		BaseToken:  p.BaseTokenAddress,
+		QuoteToken: p.QuoteTokenAddress,
+	}
+}
+
+// IsPreLaunch returns true if the pair is a scheduled listing that is not live at the
+// given time
+func (p *Pair) IsPreLaunch(t time.Time) bool {
+	return p.ListingTime != nil && p.LaunchedAt == nil && t.Before(*p.ListingTime)
+}
+
+// EffectiveFees returns the make and take fees charged on the pair at the given time,
+// which are the fees of the fee override if one applies
+func (p *Pair) EffectiveFees(t time.Time) (makeFee, takeFee *big.Int) {
+	if p.FeeOverride != nil && p.FeeOverride.AppliesAt(t) {
+		return p.FeeOverride.MakeFee, p.FeeOverride.TakeFee
+	}
+
+	return p.DefaultFees()
+}
+
+// DefaultFees returns the make and take fees of the pair without its fee override
+func (p *Pair) DefaultFees() (makeFee, takeFee *big.Int) {
+	makeFee, takeFee = p.MakeFee, p.TakeFee
+	if makeFee == nil {
+		makeFee = big.NewInt(0)
+	}
+
+	if takeFee == nil {
+		takeFee = big.NewInt(0)
+	}
+
+	return makeFee, takeFee
+}
+
+// FormatPrice returns the display representation of a price expressed as the ratio
+// of quote token units to base token units (eg. Order.Price)
+func (p *Pair) FormatPrice(price *big.Int) string {
+	return math.ToDecimalString(price, p.QuoteTokenDecimal-p.BaseTokenDecimal, p.pricePrecision())
+}
+
+// FormatPricePoint returns the display representation of a price point, which is
+// a price multiplied by 1e8 (eg. Order.PricePoint)
+func (p *Pair) FormatPricePoint(pricepoint *big.Int) string {
+	return math.ToDecimalString(pricepoint, 8+p.QuoteTokenDecimal-p.BaseTokenDecimal, p.pricePrecision())
+}
+
+// FormatAmount returns the display representation of an amount of base token units
+func (p *Pair) FormatAmount(amount *big.Int) string {
+	return math.ToDecimalString(amount, p.BaseTokenDecimal, p.amountPrecision())
+}
+
+func (p *Pair) pricePrecision() int {
+	if p.PricePrecision == 0 {
+		return 8
+	}
+
+	return p.PricePrecision
+}
+
+func (p *Pair) amountPrecision() int {
+	if p.AmountPrecision == 0 {
+		return p.BaseTokenDecimal
+	}
+
+	return p.AmountPrecision
+}
+
 // GetOrderBookKeys returns the orderbook price point keys for corresponding pair
 // It is used to fetch the orderbook from redis of a pair
 func (p *Pair) GetOrderBookKeys() (sell, buy string) {
@@ -3,6 +3,7 @@ package types
 import (
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
@@ -50,3 +51,58 @@ func TestPairBSON(t *testing.T) {
 
 	ComparePair(t, pair, decoded)
 }
+
+func TestPairFormat(t *testing.T) {
+	p := &Pair{BaseTokenDecimal: 18, QuoteTokenDecimal: 18, PricePrecision: 4, AmountPrecision: 2}
+
+	amount, _ := new(big.Int).SetString("2500000000000000000", 10)
+	assert.Equal(t, "2.50", p.FormatAmount(amount))
+	assert.Equal(t, "2.3000", p.FormatPricePoint(big.NewInt(229999999)))
+	assert.Equal(t, "3.0000", p.FormatPrice(big.NewInt(3)))
+
+	p = &Pair{BaseTokenDecimal: 18, QuoteTokenDecimal: 6}
+	assert.Equal(t, "2299999990000.00000000", p.FormatPricePoint(big.NewInt(229999999)))
+	assert.Equal(t, "2.500000000000000000", p.FormatAmount(amount))
+}
+
+func TestPairFeeOverride(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	pair := &Pair{
+		ID:                bson.NewObjectId(),
+		BaseTokenAddress:  common.HexToAddress("0xcf7389dc6c63637598402907d5431160ec8972a5"),
+		QuoteTokenAddress: common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		MakeFee:           big.NewInt(10000),
+		TakeFee:           big.NewInt(20000),
+		FeeOverride: &PairFeeOverride{
+			MakeFee:   big.NewInt(0),
+			TakeFee:   big.NewInt(5000),
+			Reason:    "promotion",
+			ExpiresAt: &expiresAt,
+		},
+	}
+
+	data, err := bson.Marshal(pair)
+	if err != nil {
+		t.Errorf("%+v", err)
+	}
+
+	decoded := &Pair{}
+	if err := bson.Unmarshal(data, decoded); err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, pair.FeeOverride.MakeFee, decoded.FeeOverride.MakeFee)
+	assert.Equal(t, pair.FeeOverride.TakeFee, decoded.FeeOverride.TakeFee)
+	assert.Equal(t, "promotion", decoded.FeeOverride.Reason)
+	assert.True(t, expiresAt.Equal(*decoded.FeeOverride.ExpiresAt))
+	assert.Equal(t, big.NewInt(0), decoded.EffectiveMakeFee)
+	assert.Equal(t, big.NewInt(5000), decoded.EffectiveTakeFee)
+
+	makeFee, takeFee := decoded.EffectiveFees(expiresAt.Add(time.Second))
+	assert.Equal(t, big.NewInt(10000), makeFee)
+	assert.Equal(t, big.NewInt(20000), takeFee)
+
+	assert.NotNil(t, PairFeeOverride{MakeFee: big.NewInt(-1), TakeFee: big.NewInt(0)}.Validate())
+	assert.NotNil(t, PairFeeOverride{MakeFee: big.NewInt(0)}.Validate())
+	assert.Nil(t, PairFeeOverride{MakeFee: big.NewInt(0), TakeFee: big.NewInt(0)}.Validate())
+}
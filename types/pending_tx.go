@@ -0,0 +1,105 @@
+package types
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	eth "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// PendingTx is a signed transaction of an operator account that is not mined yet. The
+// pending transactions are kept so that their nonces are not reused and the transactions
+// can be broadcasted again after a restart. Bumps is the number of times the transaction
+// was replaced with a higher gas price.
+type PendingTx struct {
+	ID        bson.ObjectId
+	From      common.Address
+	Nonce     uint64
+	Tx        *eth.Transaction
+	Bumps     int
+	SentAt    time.Time
+	CreatedAt time.Time
+}
+
+// PendingTxRecord is the struct which is stored in db
+type PendingTxRecord struct {
+	ID        bson.ObjectId `bson:"_id,omitempty"`
+	From      string        `bson:"from"`
+	Nonce     int64         `bson:"nonce"`
+	Hash      string        `bson:"hash"`
+	Tx        string        `bson:"tx"`
+	Bumps     int           `bson:"bumps"`
+	SentAt    time.Time     `bson:"sentAt"`
+	CreatedAt time.Time     `bson:"createdAt"`
+}
+
+// GetBSON implements the bson.Getter interface
+func (p *PendingTx) GetBSON() (interface{}, error) {
+	tx, err := EncodeTx(p.Tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PendingTxRecord{
+		ID:        p.ID,
+		From:      p.From.Hex(),
+		Nonce:     int64(p.Nonce),
+		Hash:      p.Tx.Hash().Hex(),
+		Tx:        tx,
+		Bumps:     p.Bumps,
+		SentAt:    p.SentAt,
+		CreatedAt: p.CreatedAt,
+	}, nil
+}
+
+// SetBSON implements the bson.Setter interface
+func (p *PendingTx) SetBSON(raw bson.Raw) error {
+	decoded := &PendingTxRecord{}
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	p.Tx, err = DecodeTx(decoded.Tx)
+	if err != nil {
+		return err
+	}
+
+	p.ID = decoded.ID
+	p.From = common.HexToAddress(decoded.From)
+	p.Nonce = uint64(decoded.Nonce)
+	p.Bumps = decoded.Bumps
+	p.SentAt = decoded.SentAt
+	p.CreatedAt = decoded.CreatedAt
+	return nil
+}
+
+// EncodeTx returns the hex encoded RLP encoding of a signed transaction, as sent with
+// eth_sendRawTransaction
+func EncodeTx(tx *eth.Transaction) (string, error) {
+	b, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return "", err
+	}
+
+	return hexutil.Encode(b), nil
+}
+
+// DecodeTx decodes a transaction encoded with EncodeTx
+func DecodeTx(s string) (*eth.Transaction, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &eth.Transaction{}
+	err = rlp.DecodeBytes(b, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
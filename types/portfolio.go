@@ -0,0 +1,41 @@
+package types
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Sources of the prices of the portfolio tokens. A token with no price is not counted in
+// the value of the portfolio.
+const (
+	PriceSourceQuote = "quote"
+	PriceSourceTrade = "trade"
+	PriceSourceFiat  = "fiat"
+	PriceSourceNone  = "none"
+)
+
+// Portfolio is the value of the token balances of an account in a quote currency, which is
+// a quote token symbol or address, or a fiat currency of the price feed (eg. USD)
+type Portfolio struct {
+	Address   common.Address    `json:"address"`
+	Quote     string            `json:"quote"`
+	Value     string            `json:"value"`
+	Tokens    []*PortfolioToken `json:"tokens"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// PortfolioToken is the balance of a token in a portfolio. The amounts are in token units,
+// the price and the value in the quote currency. Total is the sum of the available, locked
+// and pending (deposits waiting for confirmations) amounts.
+type PortfolioToken struct {
+	Token          common.Address `json:"token"`
+	Symbol         string         `json:"symbol"`
+	Balance        string         `json:"balance"`
+	LockedBalance  string         `json:"lockedBalance"`
+	PendingBalance string         `json:"pendingBalance"`
+	Total          string         `json:"total"`
+	Price          string         `json:"price"`
+	PriceSource    string         `json:"priceSource"`
+	Value          string         `json:"value"`
+}
@@ -0,0 +1,57 @@
+package types
+
+// Quote is the expected fill of an order against the current orderbook of a pair,
+// without placing the order. The prices and amounts are in the units of the orderbook
+// levels. Slippage is the distance in percent of the average fill price from the best
+// price. Complete is false if the orderbook cannot fill the whole amount, the quote is
+// then the fill of the available volume.
+type Quote struct {
+	Pair         PairSubDoc `json:"pair"`
+	Side         string     `json:"side"`
+	Amount       float64    `json:"amount"`
+	FilledAmount float64    `json:"filledAmount"`
+	BestPrice    float64    `json:"bestPrice"`
+	AveragePrice float64    `json:"averagePrice"`
+	WorstPrice   float64    `json:"worstPrice"`
+	Slippage     float64    `json:"slippage"`
+	Complete     bool       `json:"complete"`
+}
+
+// NewQuote walks the levels of the other side of the orderbook (the asks for a BUY
+// order, the bids for a SELL order), best price first as returned by the engine
+func NewQuote(side string, amount float64, levels []*map[string]float64) *Quote {
+	q := &Quote{Side: side, Amount: amount}
+
+	cost := float64(0)
+	for _, l := range levels {
+		if q.FilledAmount >= amount {
+			break
+		}
+
+		price, volume := (*l)["price"], (*l)["volume"]
+		if volume > amount-q.FilledAmount {
+			volume = amount - q.FilledAmount
+		}
+
+		if q.BestPrice == 0 {
+			q.BestPrice = price
+		}
+
+		q.FilledAmount += volume
+		q.WorstPrice = price
+		cost += price * volume
+	}
+
+	q.Complete = q.FilledAmount >= amount
+	if q.FilledAmount == 0 {
+		return q
+	}
+
+	q.AveragePrice = cost / q.FilledAmount
+	q.Slippage = (q.AveragePrice - q.BestPrice) / q.BestPrice * 100
+	if side == "SELL" {
+		q.Slippage = -q.Slippage
+	}
+
+	return q
+}
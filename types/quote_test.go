@@ -0,0 +1,36 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewQuote(t *testing.T) {
+	asks := []*map[string]float64{level(100, 1), level(101, 2), level(110, 10)}
+
+	q := NewQuote("BUY", 2, asks)
+	assert.True(t, q.Complete)
+	assert.Equal(t, float64(2), q.FilledAmount)
+	assert.Equal(t, float64(100), q.BestPrice)
+	assert.Equal(t, float64(101), q.WorstPrice)
+	assert.InDelta(t, 100.5, q.AveragePrice, 1e-9)
+	assert.InDelta(t, 0.5, q.Slippage, 1e-9)
+
+	bids := []*map[string]float64{level(100, 1), level(90, 1)}
+
+	q = NewQuote("SELL", 2, bids)
+	assert.InDelta(t, 95, q.AveragePrice, 1e-9)
+	assert.InDelta(t, 5, q.Slippage, 1e-9)
+}
+
+func TestNewQuoteMissingLiquidity(t *testing.T) {
+	q := NewQuote("BUY", 5, []*map[string]float64{level(100, 1)})
+	assert.False(t, q.Complete)
+	assert.Equal(t, float64(1), q.FilledAmount)
+	assert.Equal(t, float64(0), q.Slippage)
+
+	q = NewQuote("BUY", 5, nil)
+	assert.False(t, q.Complete)
+	assert.Equal(t, float64(0), q.AveragePrice)
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// RFQQuote is a firm quote of the RFQ flow. The maker quantity filling the order of the
+// taker is reserved in the engine until the quote expires. The taker commits the quote by
+// signing its trades before ExpiresAt, the trades are then settled at the quoted prices.
+type RFQQuote struct {
+	ID        bson.ObjectId  `json:"id"`
+	OrderHash common.Hash    `json:"orderHash"`
+	Taker     common.Address `json:"taker"`
+	PairName  string         `json:"pairName"`
+	Side      string         `json:"side"`
+	Amount    *big.Int       `json:"amount"`
+	Trades    []*Trade       `json:"trades"`
+	ExpiresAt time.Time      `json:"expiresAt"`
+}
+
+// RFQCommit is the request committing a quote. Trades are the trades of the quote signed
+// by the taker.
+type RFQCommit struct {
+	Trades []*Trade `json:"trades"`
+}
@@ -0,0 +1,167 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SettlementCost records the gas used by a settlement transaction and its cost in wei.
+// Fees is the sum of the maker and taker fees of the settled trade, so that the
+// revenue of a pair can be compared to the cost of its settlements.
+type SettlementCost struct {
+	ID         bson.ObjectId
+	TradeHash  common.Hash
+	TxHash     common.Hash
+	PairName   string
+	BaseToken  common.Address
+	QuoteToken common.Address
+	Status     string
+	GasUsed    uint64
+	GasPrice   *big.Int
+	Cost       *big.Int
+	Fees       *big.Int
+	CreatedAt  time.Time
+}
+
+// SettlementCostRecord is the struct which is stored in db
+type SettlementCostRecord struct {
+	ID         bson.ObjectId `bson:"_id"`
+	TradeHash  string        `bson:"tradeHash"`
+	TxHash     string        `bson:"txHash"`
+	PairName   string        `bson:"pairName"`
+	BaseToken  string        `bson:"baseToken"`
+	QuoteToken string        `bson:"quoteToken"`
+	Status     string        `bson:"status"`
+	GasUsed    int64         `bson:"gasUsed"`
+	GasPrice   string        `bson:"gasPrice"`
+	Cost       string        `bson:"cost"`
+	Fees       string        `bson:"fees"`
+	CreatedAt  time.Time     `bson:"createdAt"`
+}
+
+// NewSettlementCost returns the cost of the settlement transaction of a trade
+func NewSettlementCost(t *Trade, gasUsed uint64, gasPrice *big.Int, fees *big.Int) *SettlementCost {
+	if fees == nil {
+		fees = big.NewInt(0)
+	}
+
+	c := &SettlementCost{
+		TradeHash:  t.Hash,
+		PairName:   t.PairName,
+		BaseToken:  t.BaseToken,
+		QuoteToken: t.QuoteToken,
+		Status:     t.Status,
+		GasUsed:    gasUsed,
+		GasPrice:   gasPrice,
+		Cost:       math.Mul(new(big.Int).SetUint64(gasUsed), gasPrice),
+		Fees:       fees,
+	}
+
+	if t.Tx != nil {
+		c.TxHash = t.Tx.Hash()
+	}
+
+	return c
+}
+
+func (c *SettlementCost) GetBSON() (interface{}, error) {
+	return &SettlementCostRecord{
+		ID:         c.ID,
+		TradeHash:  c.TradeHash.Hex(),
+		TxHash:     c.TxHash.Hex(),
+		PairName:   c.PairName,
+		BaseToken:  c.BaseToken.Hex(),
+		QuoteToken: c.QuoteToken.Hex(),
+		Status:     c.Status,
+		GasUsed:    int64(c.GasUsed),
+		GasPrice:   c.GasPrice.String(),
+		Cost:       c.Cost.String(),
+		Fees:       c.Fees.String(),
+		CreatedAt:  c.CreatedAt,
+	}, nil
+}
+
+func (c *SettlementCost) SetBSON(raw bson.Raw) error {
+	decoded := &SettlementCostRecord{}
+
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	c.ID = decoded.ID
+	c.TradeHash = common.HexToHash(decoded.TradeHash)
+	c.TxHash = common.HexToHash(decoded.TxHash)
+	c.PairName = decoded.PairName
+	c.BaseToken = common.HexToAddress(decoded.BaseToken)
+	c.QuoteToken = common.HexToAddress(decoded.QuoteToken)
+	c.Status = decoded.Status
+	c.GasUsed = uint64(decoded.GasUsed)
+	c.GasPrice = math.ToBigInt(decoded.GasPrice)
+	c.Cost = math.ToBigInt(decoded.Cost)
+	c.Fees = math.ToBigInt(decoded.Fees)
+	c.CreatedAt = decoded.CreatedAt
+	return nil
+}
+
+// SettlementCostStats aggregates the settlement costs of a pair over a day (UTC)
+type SettlementCostStats struct {
+	PairName     string
+	BaseToken    common.Address
+	QuoteToken   common.Address
+	Date         string
+	Transactions int
+	Failed       int
+	GasUsed      uint64
+	Cost         *big.Int
+	Fees         *big.Int
+}
+
+// Add adds a settlement cost to the stats
+func (s *SettlementCostStats) Add(c *SettlementCost) {
+	s.Transactions++
+	if c.Status != "SUCCESS" {
+		s.Failed++
+	}
+
+	if s.Cost == nil {
+		s.Cost = big.NewInt(0)
+		s.Fees = big.NewInt(0)
+	}
+
+	s.GasUsed += c.GasUsed
+	s.Cost = math.Add(s.Cost, c.Cost)
+	s.Fees = math.Add(s.Fees, c.Fees)
+}
+
+// Balance returns the fee revenue minus the settlement costs
+func (s *SettlementCostStats) Balance() *big.Int {
+	return math.Sub(s.Fees, s.Cost)
+}
+
+// MarshalJSON returns the json encoded stats. The amounts are encoded as strings.
+func (s *SettlementCostStats) MarshalJSON() ([]byte, error) {
+	stats := map[string]interface{}{
+		"pairName":     s.PairName,
+		"baseToken":    s.BaseToken.Hex(),
+		"quoteToken":   s.QuoteToken.Hex(),
+		"date":         s.Date,
+		"transactions": s.Transactions,
+		"failed":       s.Failed,
+		"gasUsed":      s.GasUsed,
+		"cost":         s.Cost.String(),
+		"fees":         s.Fees.String(),
+		"balance":      s.Balance().String(),
+	}
+
+	if s.Transactions > 0 {
+		stats["averageGasUsed"] = s.GasUsed / uint64(s.Transactions)
+	}
+
+	return json.Marshal(stats)
+}
@@ -0,0 +1,202 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Delivery statuses of the settlement events. An event is pending until the settlement
+// webhook accepts it.
+const (
+	SettlementEventPending   = "PENDING"
+	SettlementEventDelivered = "DELIVERED"
+)
+
+// SettlementEvent is a trade whose settlement transaction is final, posted to the
+// settlement webhook. The event is kept in the database until it is delivered, so that a
+// trade is delivered at least once. The receivers use the trade hash to drop duplicates.
+type SettlementEvent struct {
+	ID          bson.ObjectId
+	TradeHash   common.Hash
+	TxHash      common.Hash
+	PairName    string
+	BaseToken   common.Address
+	QuoteToken  common.Address
+	Maker       common.Address
+	Taker       common.Address
+	Amount      *big.Int
+	PricePoint  *big.Int
+	BlockNumber uint64
+	GasUsed     uint64
+	GasPrice    *big.Int
+	GasCost     *big.Int
+	Fees        *big.Int
+	SettledAt   time.Time
+
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// SettlementEventRecord is the struct which is stored in db
+type SettlementEventRecord struct {
+	ID          bson.ObjectId `bson:"_id"`
+	TradeHash   string        `bson:"tradeHash"`
+	TxHash      string        `bson:"txHash"`
+	PairName    string        `bson:"pairName"`
+	BaseToken   string        `bson:"baseToken"`
+	QuoteToken  string        `bson:"quoteToken"`
+	Maker       string        `bson:"maker"`
+	Taker       string        `bson:"taker"`
+	Amount      string        `bson:"amount"`
+	PricePoint  string        `bson:"pricepoint"`
+	BlockNumber int64         `bson:"blockNumber"`
+	GasUsed     int64         `bson:"gasUsed"`
+	GasPrice    string        `bson:"gasPrice"`
+	GasCost     string        `bson:"gasCost"`
+	Fees        string        `bson:"fees"`
+	SettledAt   time.Time     `bson:"settledAt"`
+
+	Status        string    `bson:"status"`
+	Attempts      int       `bson:"attempts"`
+	NextAttemptAt time.Time `bson:"nextAttemptAt"`
+	LastError     string    `bson:"lastError,omitempty"`
+	CreatedAt     time.Time `bson:"createdAt"`
+	UpdatedAt     time.Time `bson:"updatedAt"`
+}
+
+// NewSettlementEvent returns the settlement event of a trade with the cost of its
+// settlement transaction, mined in the block blockNumber
+func NewSettlementEvent(t *Trade, c *SettlementCost, blockNumber uint64) *SettlementEvent {
+	e := &SettlementEvent{
+		TradeHash:   t.Hash,
+		PairName:    t.PairName,
+		BaseToken:   t.BaseToken,
+		QuoteToken:  t.QuoteToken,
+		Maker:       t.Maker,
+		Taker:       t.Taker,
+		Amount:      t.Amount,
+		PricePoint:  t.PricePoint,
+		BlockNumber: blockNumber,
+		GasPrice:    big.NewInt(0),
+		GasCost:     big.NewInt(0),
+		Fees:        big.NewInt(0),
+		SettledAt:   time.Now(),
+		Status:      SettlementEventPending,
+	}
+
+	if t.Tx != nil {
+		e.TxHash = t.Tx.Hash()
+	}
+
+	if c != nil {
+		e.GasUsed = c.GasUsed
+		e.GasPrice = c.GasPrice
+		e.GasCost = c.Cost
+		e.Fees = c.Fees
+	}
+
+	return e
+}
+
+// MarshalJSON returns the payload of the event posted to the settlement webhook
+func (e *SettlementEvent) MarshalJSON() ([]byte, error) {
+	event := map[string]interface{}{
+		"id":          e.ID.Hex(),
+		"type":        "TRADE_SETTLED",
+		"tradeHash":   e.TradeHash.Hex(),
+		"txHash":      e.TxHash.Hex(),
+		"pairName":    e.PairName,
+		"baseToken":   e.BaseToken.Hex(),
+		"quoteToken":  e.QuoteToken.Hex(),
+		"maker":       e.Maker.Hex(),
+		"taker":       e.Taker.Hex(),
+		"amount":      bigString(e.Amount),
+		"pricepoint":  bigString(e.PricePoint),
+		"blockNumber": e.BlockNumber,
+		"gasUsed":     e.GasUsed,
+		"gasPrice":    bigString(e.GasPrice),
+		"gasCost":     bigString(e.GasCost),
+		"fees":        bigString(e.Fees),
+		"settledAt":   e.SettledAt.UTC().Format(time.RFC3339),
+	}
+
+	return json.Marshal(event)
+}
+
+func (e *SettlementEvent) GetBSON() (interface{}, error) {
+	return &SettlementEventRecord{
+		ID:            e.ID,
+		TradeHash:     e.TradeHash.Hex(),
+		TxHash:        e.TxHash.Hex(),
+		PairName:      e.PairName,
+		BaseToken:     e.BaseToken.Hex(),
+		QuoteToken:    e.QuoteToken.Hex(),
+		Maker:         e.Maker.Hex(),
+		Taker:         e.Taker.Hex(),
+		Amount:        bigString(e.Amount),
+		PricePoint:    bigString(e.PricePoint),
+		BlockNumber:   int64(e.BlockNumber),
+		GasUsed:       int64(e.GasUsed),
+		GasPrice:      bigString(e.GasPrice),
+		GasCost:       bigString(e.GasCost),
+		Fees:          bigString(e.Fees),
+		SettledAt:     e.SettledAt,
+		Status:        e.Status,
+		Attempts:      e.Attempts,
+		NextAttemptAt: e.NextAttemptAt,
+		LastError:     e.LastError,
+		CreatedAt:     e.CreatedAt,
+		UpdatedAt:     e.UpdatedAt,
+	}, nil
+}
+
+func (e *SettlementEvent) SetBSON(raw bson.Raw) error {
+	decoded := &SettlementEventRecord{}
+
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	e.ID = decoded.ID
+	e.TradeHash = common.HexToHash(decoded.TradeHash)
+	e.TxHash = common.HexToHash(decoded.TxHash)
+	e.PairName = decoded.PairName
+	e.BaseToken = common.HexToAddress(decoded.BaseToken)
+	e.QuoteToken = common.HexToAddress(decoded.QuoteToken)
+	e.Maker = common.HexToAddress(decoded.Maker)
+	e.Taker = common.HexToAddress(decoded.Taker)
+	e.Amount = math.ToBigInt(decoded.Amount)
+	e.PricePoint = math.ToBigInt(decoded.PricePoint)
+	e.BlockNumber = uint64(decoded.BlockNumber)
+	e.GasUsed = uint64(decoded.GasUsed)
+	e.GasPrice = math.ToBigInt(decoded.GasPrice)
+	e.GasCost = math.ToBigInt(decoded.GasCost)
+	e.Fees = math.ToBigInt(decoded.Fees)
+	e.SettledAt = decoded.SettledAt
+	e.Status = decoded.Status
+	e.Attempts = decoded.Attempts
+	e.NextAttemptAt = decoded.NextAttemptAt
+	e.LastError = decoded.LastError
+	e.CreatedAt = decoded.CreatedAt
+	e.UpdatedAt = decoded.UpdatedAt
+	return nil
+}
+
+// bigString returns the decimal string of an amount, "0" for a nil amount
+func bigString(x *big.Int) string {
+	if x == nil {
+		return "0"
+	}
+
+	return x.String()
+}
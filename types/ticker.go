@@ -0,0 +1,108 @@
+package types
+
+import (
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TradeStats are the statistics of the trades of a pair over a period. The prices are
+// pricepoints and the volume is the sum of the traded amounts.
+type TradeStats struct {
+	BaseToken  common.Address
+	QuoteToken common.Address
+	Open       *big.Int
+	Last       *big.Int
+	High       *big.Int
+	Low        *big.Int
+	Volume     *big.Int
+	Count      int
+}
+
+// Ticker holds the statistics of the trades of a pair over the last 24 hours and the top
+// of its orderbook. The prices are pricepoints, like the prices of the orderbook levels,
+// and Change is the change in percent from the first to the last trade of the period.
+// Last is the price of the latest trade of the pair, even if it is older than 24 hours.
+type Ticker struct {
+	Pair      PairSubDoc       `json:"pair"`
+	Last      string           `json:"last,omitempty"`
+	Open      string           `json:"open,omitempty"`
+	High      string           `json:"high,omitempty"`
+	Low       string           `json:"low,omitempty"`
+	Volume    string           `json:"volume"`
+	Change    float64          `json:"change"`
+	Trades    int              `json:"trades"`
+	BestBid   string           `json:"bestBid,omitempty"`
+	BestAsk   string           `json:"bestAsk,omitempty"`
+	Timestamp time.Time        `json:"timestamp"`
+	Formatted *TickerFormatted `json:"formatted,omitempty"`
+}
+
+// TickerFormatted is the display representation of the prices and of the volume of a
+// ticker
+type TickerFormatted struct {
+	Last    string `json:"last,omitempty"`
+	Open    string `json:"open,omitempty"`
+	High    string `json:"high,omitempty"`
+	Low     string `json:"low,omitempty"`
+	Volume  string `json:"volume"`
+	BestBid string `json:"bestBid,omitempty"`
+	BestAsk string `json:"bestAsk,omitempty"`
+}
+
+// NewTicker returns the ticker of a pair from the statistics of its trades of the last 24
+// hours (nil if it was not traded) and from the price levels of its orderbook, best
+// price first
+func NewTicker(p *Pair, stats *TradeStats, asks, bids []*BookLevel, t time.Time) *Ticker {
+	tk := &Ticker{Pair: p.Reference(), Volume: "0", Timestamp: t}
+
+	if stats != nil {
+		tk.Last = stats.Last.String()
+		tk.Open = stats.Open.String()
+		tk.High = stats.High.String()
+		tk.Low = stats.Low.String()
+		tk.Volume = stats.Volume.String()
+		tk.Trades = stats.Count
+
+		if stats.Open.Sign() > 0 {
+			change := new(big.Float).SetInt(math.Sub(stats.Last, stats.Open))
+			change.Quo(change, new(big.Float).SetInt(stats.Open))
+			tk.Change, _ = change.Mul(change, big.NewFloat(100)).Float64()
+		}
+	}
+
+	if len(bids) > 0 {
+		tk.BestBid = strconv.FormatInt(bids[0].PricePoint, 10)
+	}
+
+	if len(asks) > 0 {
+		tk.BestAsk = strconv.FormatInt(asks[0].PricePoint, 10)
+	}
+
+	return tk
+}
+
+// SetFormatted computes the display representation of the ticker prices and volume using
+// the decimals and precision of the pair
+func (tk *Ticker) SetFormatted(p *Pair) {
+	price := func(pp string) string {
+		if pp == "" {
+			return ""
+		}
+
+		return p.FormatPricePoint(math.ToBigInt(pp))
+	}
+
+	tk.Formatted = &TickerFormatted{
+		Last:    price(tk.Last),
+		Open:    price(tk.Open),
+		High:    price(tk.High),
+		Low:     price(tk.Low),
+		Volume:  p.FormatAmount(math.ToBigInt(tk.Volume)),
+		BestBid: price(tk.BestBid),
+		BestAsk: price(tk.BestAsk),
+	}
+}
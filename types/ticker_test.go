@@ -0,0 +1,57 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTicker(t *testing.T) {
+	now := time.Now()
+	pair := &Pair{
+		Name:              "ZRX/WETH",
+		Symbol:            "ZRX-WETH",
+		BaseTokenAddress:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteTokenAddress: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+	}
+
+	stats := &TradeStats{
+		BaseToken:  pair.BaseTokenAddress,
+		QuoteToken: pair.QuoteTokenAddress,
+		Open:       big.NewInt(200),
+		Last:       big.NewInt(150),
+		High:       big.NewInt(300),
+		Low:        big.NewInt(100),
+		Volume:     big.NewInt(1000),
+		Count:      4,
+	}
+
+	asks := []*BookLevel{{PricePoint: 160, Volume: 10, Orders: 1}, {PricePoint: 170, Volume: 10, Orders: 1}}
+	bids := []*BookLevel{{PricePoint: 140, Volume: 10, Orders: 2}}
+
+	tk := NewTicker(pair, stats, asks, bids, now)
+	assert.Equal(t, pair.Reference(), tk.Pair)
+	assert.Equal(t, "150", tk.Last)
+	assert.Equal(t, "200", tk.Open)
+	assert.Equal(t, "300", tk.High)
+	assert.Equal(t, "100", tk.Low)
+	assert.Equal(t, "1000", tk.Volume)
+	assert.Equal(t, 4, tk.Trades)
+	assert.Equal(t, -25.0, tk.Change)
+	assert.Equal(t, "140", tk.BestBid)
+	assert.Equal(t, "160", tk.BestAsk)
+	assert.Equal(t, now, tk.Timestamp)
+
+	// a pair that was not traded and has an empty orderbook has no prices
+	tk = NewTicker(pair, nil, nil, nil, now)
+	assert.Equal(t, "", tk.Last)
+	assert.Equal(t, "", tk.Open)
+	assert.Equal(t, "0", tk.Volume)
+	assert.Equal(t, 0, tk.Trades)
+	assert.Equal(t, 0.0, tk.Change)
+	assert.Equal(t, "", tk.BestBid)
+	assert.Equal(t, "", tk.BestAsk)
+}
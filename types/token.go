@@ -21,8 +21,9 @@ type Token struct {
 	Active          bool           `json:"active" bson:"active"`
 	Quote           bool           `json:"quote" bson:"quote"`
 
-	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+	CreatedAt time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
 }
 
 // TokenRecord is the struct which is stored in db
@@ -36,8 +37,9 @@ type TokenRecord struct {
 	Active          bool          `json:"active" bson:"active"`
 	Quote           bool          `json:"quote" bson:"quote"`
 
-	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+	CreatedAt time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
 }
 
 // Image is a sub document used to store data related to images
@@ -70,6 +72,7 @@ func (t *Token) GetBSON() (interface{}, error) {
 		Quote:           t.Quote,
 		CreatedAt:       t.CreatedAt,
 		UpdatedAt:       t.UpdatedAt,
+		DeletedAt:       t.DeletedAt,
 	}, nil
 }
 
@@ -93,6 +96,7 @@ func (t *Token) SetBSON(raw bson.Raw) error {
 	t.Quote = decoded.Quote
 	t.CreatedAt = decoded.CreatedAt
 	t.UpdatedAt = decoded.UpdatedAt
+	t.DeletedAt = decoded.DeletedAt
 	return nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"math/big"
 	"time"
 
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/utils/math"
 	"github.com/ethereum/go-ethereum/common"
 	eth "github.com/ethereum/go-ethereum/core/types"
@@ -32,13 +33,48 @@ type Trade struct {
 	TradeNonce   *big.Int         `json:"tradeNonce" bson:"tradeNonce"`
 	Signature    *Signature       `json:"signature" bson:"signature"`
 	Tx           *eth.Transaction `json:"tx" bson:"tx"`
-	CreatedAt    time.Time        `json:"createdAt" bson:"createdAt" redis:"createdAt"`
-	UpdatedAt    time.Time        `json:"updatedAt" bson:"updatedAt" redis:"updatedAt"`
+	Status       string           `json:"status" bson:"status"`
+	// ErrorCode and FailureReason describe why the settlement of the trade failed
+	ErrorCode     aerrors.ExchangeErrorID `json:"errorCode,omitempty" bson:"errorCode,omitempty"`
+	FailureReason string                  `json:"failureReason,omitempty" bson:"failureReason,omitempty"`
+	CreatedAt     time.Time               `json:"createdAt" bson:"createdAt" redis:"createdAt"`
+	UpdatedAt     time.Time               `json:"updatedAt" bson:"updatedAt" redis:"updatedAt"`
+
+	// BustReason is the rationale of the administrators who busted the trade before its
+	// settlement (status BUSTED), or flagged it as erroneous after its settlement
+	BustReason string `json:"bustReason,omitempty" bson:"bustReason,omitempty"`
+	Flagged    bool   `json:"flagged,omitempty" bson:"flagged,omitempty"`
 
 	Price      *big.Int `json:"price" bson:"price"`
 	PricePoint *big.Int `json:"pricepoint" bson:"pricepoint"`
 	Side       string   `json:"side" bson:"side"`
 	Amount     *big.Int `json:"amount" bson:"amount"`
+
+	// PairSymbol is the current display symbol of the pair. It is not persisted as
+	// pairs can be renamed, it is set when the trade is sent to clients.
+	PairSymbol string `json:"pairSymbol,omitempty" bson:"-"`
+
+	// PriceFormatted and AmountFormatted are the display representation of the
+	// price and amount. They are only set when requested by clients.
+	PriceFormatted  string `json:"priceFormatted,omitempty" bson:"-"`
+	AmountFormatted string `json:"amountFormatted,omitempty" bson:"-"`
+}
+
+// TradeQuery holds the filters and the cursors of a trade history request. The trades
+// are returned from the most recent one. Before and After are trade ids: only the trades
+// older than Before, or more recent than After, are returned. Empty filters are not
+// applied. Side is the side of UserAddress in the trades when it is set, the side of the
+// taker otherwise.
+type TradeQuery struct {
+	BaseToken   common.Address
+	QuoteToken  common.Address
+	UserAddress common.Address
+	Side        string
+	From        time.Time
+	To          time.Time
+	Before      bson.ObjectId
+	After       bson.ObjectId
+	Limit       int
 }
 
 // NewTrade returns a new unsigned trade corresponding to an Order, amount and taker address
@@ -57,6 +93,18 @@ func NewTrade(o *Order, amount *big.Int, price *big.Int, taker common.Address) *
 	return t
 }
 
+// SetFormatted computes the display representation of the trade price and amount
+// using the decimals and precision of the pair
+func (t *Trade) SetFormatted(p *Pair) {
+	if t.PricePoint != nil {
+		t.PriceFormatted = p.FormatPricePoint(t.PricePoint)
+	} else {
+		t.PriceFormatted = p.FormatPrice(t.Price)
+	}
+
+	t.AmountFormatted = p.FormatAmount(t.Amount)
+}
+
 // MarshalJSON returns the json encoded byte array representing the trade struct
 func (t *Trade) MarshalJSON() ([]byte, error) {
 	trade := map[string]interface{}{
@@ -71,6 +119,7 @@ func (t *Trade) MarshalJSON() ([]byte, error) {
 		"side":         t.Side,
 		"hash":         t.Hash,
 		"pairName":     t.PairName,
+		"status":       t.Status,
 		"tradeNonce":   t.TradeNonce.String(),
 		"signature": map[string]interface{}{
 			"V":      t.Signature.V,
@@ -97,6 +146,87 @@ func (t *Trade) MarshalJSON() ([]byte, error) {
 		trade["makerOrderId"] = t.MakerOrderID
 	}
 
+	if t.PairSymbol != "" {
+		trade["pairSymbol"] = t.PairSymbol
+	}
+
+	if t.PriceFormatted != "" {
+		trade["priceFormatted"] = t.PriceFormatted
+		trade["amountFormatted"] = t.AmountFormatted
+	}
+
+	if t.FailureReason != "" {
+		trade["errorCode"] = t.ErrorCode
+		trade["failureReason"] = t.FailureReason
+	}
+
+	if t.BustReason != "" {
+		trade["bustReason"] = t.BustReason
+	}
+
+	if t.Flagged {
+		trade["flagged"] = true
+	}
+
+	if t.Tx != nil {
+		trade["txHash"] = t.Tx.Hash().Hex()
+	}
+
+	return json.Marshal(trade)
+}
+
+// PublicTrade is the anonymized representation of a trade sent on the public trades
+// channel. It does not contain the maker and taker addresses, the order ids and hashes
+// nor the signature of the trade.
+type PublicTrade struct {
+	trade *Trade
+}
+
+// Public returns the anonymized representation of the trade
+func (t *Trade) Public() *PublicTrade {
+	return &PublicTrade{t}
+}
+
+// NewPublicTrades returns the anonymized representation of a list of trades
+func NewPublicTrades(trades []*Trade) []*PublicTrade {
+	public := []*PublicTrade{}
+	for _, t := range trades {
+		if t != nil {
+			public = append(public, t.Public())
+		}
+	}
+
+	return public
+}
+
+// MarshalJSON returns the json encoded byte array representing the anonymized trade
+func (p *PublicTrade) MarshalJSON() ([]byte, error) {
+	t := p.trade
+	trade := map[string]interface{}{
+		"baseToken":  t.BaseToken,
+		"quoteToken": t.QuoteToken,
+		"side":       t.Side,
+		"pairName":   t.PairName,
+		"status":     t.Status,
+		"createdAt":  t.CreatedAt.String(),
+		"price":      t.Price.String(),
+		"pricepoint": t.PricePoint.String(),
+		"amount":     t.Amount.String(),
+	}
+
+	if t.ID != bson.ObjectId("") {
+		trade["id"] = t.ID
+	}
+
+	if t.PairSymbol != "" {
+		trade["pairSymbol"] = t.PairSymbol
+	}
+
+	if t.PriceFormatted != "" {
+		trade["priceFormatted"] = t.PriceFormatted
+		trade["amountFormatted"] = t.AmountFormatted
+	}
+
 	return json.Marshal(trade)
 }
 
@@ -161,10 +291,34 @@ func (t *Trade) UnmarshalJSON(b []byte) error {
 		t.PairName = trade["pairName"].(string)
 	}
 
+	if trade["pairSymbol"] != nil {
+		t.PairSymbol = trade["pairSymbol"].(string)
+	}
+
 	if trade["side"] != nil {
 		t.Side = trade["side"].(string)
 	}
 
+	if trade["status"] != nil {
+		t.Status = trade["status"].(string)
+	}
+
+	if trade["errorCode"] != nil {
+		t.ErrorCode = aerrors.ExchangeErrorID(trade["errorCode"].(float64))
+	}
+
+	if trade["failureReason"] != nil {
+		t.FailureReason = trade["failureReason"].(string)
+	}
+
+	if trade["bustReason"] != nil {
+		t.BustReason = trade["bustReason"].(string)
+	}
+
+	if trade["flagged"] != nil {
+		t.Flagged = trade["flagged"].(bool)
+	}
+
 	if trade["price"] != nil {
 		t.Price = math.ToBigInt(trade["price"].(string))
 	}
@@ -195,27 +349,45 @@ func (t *Trade) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-// GetBSON implements the bson.Getter interface
+// GetBSON implements the bson.Getter interface. The settlement transaction is stored
+// signed, so that it can be followed again after a restart.
 func (t *Trade) GetBSON() (interface{}, error) {
+	var tx, txHash string
+	if t.Tx != nil {
+		encoded, err := EncodeTx(t.Tx)
+		if err != nil {
+			return nil, err
+		}
+
+		tx, txHash = encoded, t.Tx.Hash().Hex()
+	}
+
 	return struct {
-		ID           bson.ObjectId   `json:"id,omitempty" bson:"_id"`
-		TakerOrderID bson.ObjectId   `json:"takerOrderId" bson:"takerOrderId"`
-		MakerOrderID bson.ObjectId   `json:"makerOrderId" bson:"makerOrderId"`
-		PairName     string          `json:"pairName" bson:"pairName"`
-		Taker        string          `json:"taker" bson:"taker"`
-		Maker        string          `json:"maker" bson:"maker"`
-		BaseToken    string          `json:"baseToken" bson:"baseToken"`
-		QuoteToken   string          `json:"quoteToken" bson:"quoteToken"`
-		OrderHash    string          `json:"orderHash" bson:"orderHash"`
-		Hash         string          `json:"hash" bson:"hash"`
-		TradeNonce   string          `json:"tradeNonce" bson:"tradeNonce"`
-		Signature    SignatureRecord `json:"signature" bson:"signature"`
-		CreatedAt    time.Time       `json:"createdAt" bson:"createdAt" redis:"createdAt"`
-		UpdatedAt    time.Time       `json:"updatedAt" bson:"updatedAt" redis:"updatedAt"`
-		Price        string          `json:"price" bson:"price"`
-		PricePoint   string          `json:"pricepoint" bson:"pricepoint"`
-		Side         string          `json:"side" bson:"side"`
-		Amount       string          `json:"amount" bson:"amount"`
+		ID            bson.ObjectId           `json:"id,omitempty" bson:"_id"`
+		TakerOrderID  bson.ObjectId           `json:"takerOrderId" bson:"takerOrderId"`
+		MakerOrderID  bson.ObjectId           `json:"makerOrderId" bson:"makerOrderId"`
+		PairName      string                  `json:"pairName" bson:"pairName"`
+		Taker         string                  `json:"taker" bson:"taker"`
+		Maker         string                  `json:"maker" bson:"maker"`
+		BaseToken     string                  `json:"baseToken" bson:"baseToken"`
+		QuoteToken    string                  `json:"quoteToken" bson:"quoteToken"`
+		OrderHash     string                  `json:"orderHash" bson:"orderHash"`
+		Hash          string                  `json:"hash" bson:"hash"`
+		TradeNonce    string                  `json:"tradeNonce" bson:"tradeNonce"`
+		Signature     SignatureRecord         `json:"signature" bson:"signature"`
+		Status        string                  `json:"status" bson:"status"`
+		TxHash        string                  `json:"txHash,omitempty" bson:"txHash,omitempty"`
+		Tx            string                  `json:"tx,omitempty" bson:"tx,omitempty"`
+		ErrorCode     aerrors.ExchangeErrorID `json:"errorCode,omitempty" bson:"errorCode,omitempty"`
+		FailureReason string                  `json:"failureReason,omitempty" bson:"failureReason,omitempty"`
+		BustReason    string                  `json:"bustReason,omitempty" bson:"bustReason,omitempty"`
+		Flagged       bool                    `json:"flagged,omitempty" bson:"flagged,omitempty"`
+		CreatedAt     time.Time               `json:"createdAt" bson:"createdAt" redis:"createdAt"`
+		UpdatedAt     time.Time               `json:"updatedAt" bson:"updatedAt" redis:"updatedAt"`
+		Price         string                  `json:"price" bson:"price"`
+		PricePoint    string                  `json:"pricepoint" bson:"pricepoint"`
+		Side          string                  `json:"side" bson:"side"`
+		Amount        string                  `json:"amount" bson:"amount"`
 	}{
 		ID:           t.ID,
 		TakerOrderID: t.TakerOrderID,
@@ -233,35 +405,49 @@ func (t *Trade) GetBSON() (interface{}, error) {
 			R: t.Signature.R.Hex(),
 			S: t.Signature.S.Hex(),
 		},
-		CreatedAt:  t.CreatedAt,
-		UpdatedAt:  t.UpdatedAt,
-		Price:      t.Price.String(),
-		PricePoint: t.PricePoint.String(),
-		Side:       t.Side,
-		Amount:     t.Amount.String(),
+		Status:        t.Status,
+		TxHash:        txHash,
+		Tx:            tx,
+		ErrorCode:     t.ErrorCode,
+		FailureReason: t.FailureReason,
+		BustReason:    t.BustReason,
+		Flagged:       t.Flagged,
+		CreatedAt:     t.CreatedAt,
+		UpdatedAt:     t.UpdatedAt,
+		Price:         t.Price.String(),
+		PricePoint:    t.PricePoint.String(),
+		Side:          t.Side,
+		Amount:        t.Amount.String(),
 	}, nil
 }
 
 func (t *Trade) SetBSON(raw bson.Raw) error {
 	decoded := new(struct {
-		ID           bson.ObjectId   `json:"id,omitempty" bson:"_id"`
-		TakerOrderID bson.ObjectId   `json:"takerOrderId" bson:"takerOrderId"`
-		MakerOrderID bson.ObjectId   `json:"makerOrderId" bson:"makerOrderId"`
-		PairName     string          `json:"pairName" bson:"pairName"`
-		Taker        string          `json:"taker" bson:"taker"`
-		Maker        string          `json:"maker" bson:"maker"`
-		BaseToken    string          `json:"baseToken" bson:"baseToken"`
-		QuoteToken   string          `json:"quoteToken" bson:"quoteToken"`
-		OrderHash    string          `json:"orderHash" bson:"orderHash"`
-		Hash         string          `json:"hash" bson:"hash"`
-		TradeNonce   string          `json:"tradeNonce" bson:"tradeNonce"`
-		Signature    SignatureRecord `json:"signature" bson:"signature"`
-		CreatedAt    time.Time       `json:"createdAt" bson:"createdAt" redis:"createdAt"`
-		UpdatedAt    time.Time       `json:"updatedAt" bson:"updatedAt" redis:"updatedAt"`
-		Price        string          `json:"price" bson:"price"`
-		PricePoint   string          `json:"pricepoint" bson:"pricepoint"`
-		Side         string          `json:"side" bson:"side"`
-		Amount       string          `json:"amount" bson:"amount"`
+		ID            bson.ObjectId           `json:"id,omitempty" bson:"_id"`
+		TakerOrderID  bson.ObjectId           `json:"takerOrderId" bson:"takerOrderId"`
+		MakerOrderID  bson.ObjectId           `json:"makerOrderId" bson:"makerOrderId"`
+		PairName      string                  `json:"pairName" bson:"pairName"`
+		Taker         string                  `json:"taker" bson:"taker"`
+		Maker         string                  `json:"maker" bson:"maker"`
+		BaseToken     string                  `json:"baseToken" bson:"baseToken"`
+		QuoteToken    string                  `json:"quoteToken" bson:"quoteToken"`
+		OrderHash     string                  `json:"orderHash" bson:"orderHash"`
+		Hash          string                  `json:"hash" bson:"hash"`
+		TradeNonce    string                  `json:"tradeNonce" bson:"tradeNonce"`
+		Signature     SignatureRecord         `json:"signature" bson:"signature"`
+		Status        string                  `json:"status" bson:"status"`
+		TxHash        string                  `json:"txHash,omitempty" bson:"txHash,omitempty"`
+		Tx            string                  `json:"tx,omitempty" bson:"tx,omitempty"`
+		ErrorCode     aerrors.ExchangeErrorID `json:"errorCode,omitempty" bson:"errorCode,omitempty"`
+		FailureReason string                  `json:"failureReason,omitempty" bson:"failureReason,omitempty"`
+		BustReason    string                  `json:"bustReason,omitempty" bson:"bustReason,omitempty"`
+		Flagged       bool                    `json:"flagged,omitempty" bson:"flagged,omitempty"`
+		CreatedAt     time.Time               `json:"createdAt" bson:"createdAt" redis:"createdAt"`
+		UpdatedAt     time.Time               `json:"updatedAt" bson:"updatedAt" redis:"updatedAt"`
+		Price         string                  `json:"price" bson:"price"`
+		PricePoint    string                  `json:"pricepoint" bson:"pricepoint"`
+		Side          string                  `json:"side" bson:"side"`
+		Amount        string                  `json:"amount" bson:"amount"`
 	})
 
 	err := raw.Unmarshal(decoded)
@@ -286,6 +472,11 @@ func (t *Trade) SetBSON(raw bson.Raw) error {
 	t.PricePoint = math.ToBigInt(decoded.PricePoint)
 
 	t.Side = decoded.Side
+	t.Status = decoded.Status
+	t.ErrorCode = decoded.ErrorCode
+	t.FailureReason = decoded.FailureReason
+	t.BustReason = decoded.BustReason
+	t.Flagged = decoded.Flagged
 
 	t.Signature = &Signature{
 		V: byte(decoded.Signature.V),
@@ -293,6 +484,13 @@ func (t *Trade) SetBSON(raw bson.Raw) error {
 		S: common.HexToHash(decoded.Signature.S),
 	}
 
+	if decoded.Tx != "" {
+		t.Tx, err = DecodeTx(decoded.Tx)
+		if err != nil {
+			return err
+		}
+	}
+
 	t.CreatedAt = decoded.CreatedAt
 	t.UpdatedAt = decoded.UpdatedAt
 	return nil
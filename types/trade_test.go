@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	eth "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/go-test/deep"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/mgo.v2/bson"
@@ -53,6 +55,79 @@ func TestTradeJSON(t *testing.T) {
 	}
 }
 
+func TestTradeJSONTxHash(t *testing.T) {
+	trade := &Trade{
+		TradeNonce: big.NewInt(100),
+		Signature:  &Signature{V: 28},
+		Price:      big.NewInt(100),
+		PricePoint: big.NewInt(10000),
+		Amount:     big.NewInt(100),
+	}
+
+	encoded, err := json.Marshal(trade)
+	if err != nil {
+		t.Errorf("Error encoding trade: %v", err)
+	}
+
+	assert.NotContains(t, string(encoded), "txHash")
+
+	// the hash of the settlement transaction is sent once the transaction is sent
+	trade.Tx = eth.NewTransaction(1, common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"), big.NewInt(0), 200000, big.NewInt(1), nil)
+	encoded, err = json.Marshal(trade)
+	if err != nil {
+		t.Errorf("Error encoding trade: %v", err)
+	}
+
+	decoded := map[string]interface{}{}
+	err = json.Unmarshal(encoded, &decoded)
+	if err != nil {
+		t.Errorf("Could not unmarshal payload: %v", err)
+	}
+
+	assert.Equal(t, trade.Tx.Hash().Hex(), decoded["txHash"])
+}
+
+func TestPublicTradeJSON(t *testing.T) {
+	trade := &Trade{
+		ID:           bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		TakerOrderID: bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		MakerOrderID: bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		Maker:        common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Taker:        common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		BaseToken:    common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken:   common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		Hash:         common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		OrderHash:    common.HexToHash("0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff"),
+		PairName:     "ZRX/WETH",
+		TradeNonce:   big.NewInt(100),
+		Signature:    &Signature{V: 28},
+		Price:        big.NewInt(100),
+		PricePoint:   big.NewInt(10000),
+		Side:         "BUY",
+		Amount:       big.NewInt(100),
+	}
+
+	encoded, err := json.Marshal(trade.Public())
+	if err != nil {
+		t.Errorf("Error encoding trade: %v", err)
+	}
+
+	decoded := map[string]interface{}{}
+	err = json.Unmarshal(encoded, &decoded)
+	if err != nil {
+		t.Errorf("Could not unmarshal payload: %v", err)
+	}
+
+	for _, key := range []string{"maker", "taker", "orderHash", "hash", "makerOrderId", "takerOrderId", "signature", "tradeNonce"} {
+		assert.NotContains(t, decoded, key)
+	}
+
+	assert.Equal(t, "537f700b537461b70c5f0000", decoded["id"])
+	assert.Equal(t, "ZRX/WETH", decoded["pairName"])
+	assert.Equal(t, "10000", decoded["pricepoint"])
+	assert.Equal(t, "100", decoded["amount"])
+}
+
 func TestTradeBSON(t *testing.T) {
 	expected := &Trade{
 		ID:           bson.ObjectIdHex("537f700b537461b70c5f0000"),
@@ -91,3 +166,39 @@ func TestTradeBSON(t *testing.T) {
 
 	assert.Equal(t, decoded, expected)
 }
+
+func TestTradeBSONTx(t *testing.T) {
+	key, _ := crypto.HexToECDSA("7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b7c8a4c6bb7d9b4f2e0a1")
+	tx, err := eth.SignTx(
+		eth.NewTransaction(1, common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"), big.NewInt(0), 200000, big.NewInt(1), []byte{1, 2}),
+		eth.HomesteadSigner{},
+		key,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trade := &Trade{
+		ID:         bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		TradeNonce: big.NewInt(100),
+		Signature:  &Signature{V: 28},
+		Price:      big.NewInt(100),
+		PricePoint: big.NewInt(10000),
+		Amount:     big.NewInt(100),
+		Tx:         tx,
+	}
+
+	data, err := bson.Marshal(trade)
+	if err != nil {
+		t.Error(err)
+	}
+
+	decoded := &Trade{}
+	if err := bson.Unmarshal(data, decoded); err != nil {
+		t.Error(err)
+	}
+
+	// the signed settlement transaction is stored
+	assert.Equal(t, tx.Hash(), decoded.Tx.Hash())
+	assert.Equal(t, tx.Data(), decoded.Tx.Data())
+}
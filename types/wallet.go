@@ -4,19 +4,19 @@ import (
 	"crypto/ecdsa"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
-	"gopkg.in/mgo.v2/bson"
 )
 
-// Wallet holds both the address and the private key of an ethereum account
+// Wallet holds both the address and the private key of an ethereum account. It is
+// used client-side to sign orders, cancels and trades. The server never holds user
+// wallets, the only keys it manages are operator keys (see OperatorWallet).
 type Wallet struct {
-	ID         bson.ObjectId
 	Address    common.Address
 	PrivateKey *ecdsa.PrivateKey
-	Admin      bool
 }
 
 // NewWallet returns a new wallet object corresponding to a random private key
@@ -54,38 +54,10 @@ func (w *Wallet) GetPrivateKey() string {
 	return hex.EncodeToString(w.PrivateKey.D.Bytes())
 }
 
-func (w *Wallet) Validate() error {
-	return nil
-}
-
-type WalletRecord struct {
-	ID         bson.ObjectId `json:"id,omitempty" bson:"_id"`
-	Address    string        `json:"address" bson:"address"`
-	PrivateKey string        `json:"privateKey" bson:"privateKey"`
-	Admin      bool          `json:"admin" bson:"admin"`
-}
-
+// GetBSON prevents wallets from being persisted. Wallets are only used client-side
+// (SDK, mocks and test tooling). Keys held by the server must be stored as OperatorWallet.
 func (w *Wallet) GetBSON() (interface{}, error) {
-	return WalletRecord{
-		ID:         w.ID,
-		Address:    w.Address.Hex(),
-		PrivateKey: hex.EncodeToString(w.PrivateKey.D.Bytes()),
-		Admin:      w.Admin,
-	}, nil
-}
-
-func (w *Wallet) SetBSON(raw bson.Raw) error {
-	decoded := &WalletRecord{}
-	err := raw.Unmarshal(decoded)
-	if err != nil {
-		return err
-	}
-
-	w.ID = decoded.ID
-	w.Address = common.HexToAddress(decoded.Address)
-	w.PrivateKey, _ = crypto.HexToECDSA(decoded.PrivateKey)
-	w.Admin = decoded.Admin
-	return nil
+	return nil, errors.New("Wallet can not be persisted, use OperatorWallet instead")
 }
 
 // SignHash signs a hashed message with a wallet private key
@@ -110,6 +82,36 @@ func (w *Wallet) SignHash(h common.Hash) (*Signature, error) {
 	return sig, nil
 }
 
+// SignTypedOrder signs an order with eth_signTypedData: the EIP712 hash of the order is
+// signed without the eth_sign prefix
+func (w *Wallet) SignTypedOrder(o *Order) error {
+	o.SignatureScheme = SchemeEIP712
+	hash := o.ComputeTypedHash()
+
+	sig, err := Sign(hash, w.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	o.Hash = hash
+	o.Signature = sig
+	return nil
+}
+
+// SignTypedTrade signs the EIP712 hash of a trade in the domain of an exchange contract
+func (w *Wallet) SignTypedTrade(t *Trade, exchange common.Address) error {
+	hash := t.ComputeTypedHash(exchange)
+
+	sig, err := Sign(hash, w.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	t.Hash = hash
+	t.Signature = sig
+	return nil
+}
+
 // SignTrade signs and sets the signature of a trade with a wallet private key
 func (w *Wallet) SignTrade(t *Trade) error {
 	hash := t.ComputeHash()
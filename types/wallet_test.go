@@ -1,11 +1,9 @@
 package types
 
 import (
-	"encoding/hex"
 	"reflect"
 	"testing"
 
-	"github.com/stretchr/testify/assert"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -36,37 +34,11 @@ func TestNewWalletFromPrivateKey(t *testing.T) {
 	}
 }
 
-func TestBSON(t *testing.T) {
-	key := "7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660"
-	w := NewWalletFromPrivateKey(key)
-	w.ID = bson.NewObjectId()
+func TestWalletCanNotBePersisted(t *testing.T) {
+	wallet := NewWallet()
 
-	data, err := bson.Marshal(w)
-	if err != nil {
-		t.Error("some error:", err)
+	_, err := bson.Marshal(wallet)
+	if err == nil {
+		t.Error("Expected client wallets to not be marshalled to BSON")
 	}
-
-	decoded := &Wallet{}
-	bson.Unmarshal(data, decoded)
-
-	assert.Equal(
-		t,
-		w.ID,
-		decoded.ID,
-		"ID should be encoded and decoded correctly",
-	)
-
-	assert.Equal(
-		t,
-		decoded.Address.Hex(),
-		"0xE8E84ee367BC63ddB38d3D01bCCEF106c194dc47",
-		"Address should be encoded and decoded correctly",
-	)
-
-	assert.Equal(
-		t,
-		hex.EncodeToString(decoded.PrivateKey.D.Bytes()),
-		"7c78c6e2f65d0d84c44ac0f7b53d6e4dd7a82c35f51b251d387c2a69df712660",
-		"Private key should be encoded and decoded correctly",
-	)
 }
@@ -0,0 +1,271 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Statuses of the withdrawals. A withdrawal is pending until its transaction is sent to
+// the exchange contract, it is then submitted until the transaction is mined. The
+// withdrawn amount is locked in the balance of the trader until the withdrawal succeeds,
+// and made available again if it fails.
+const (
+	WithdrawPending   = "PENDING"
+	WithdrawSubmitted = "SUBMITTED"
+	WithdrawSuccess   = "SUCCESS"
+	WithdrawFailed    = "FAILED"
+)
+
+// Withdraw is a request of a trader to withdraw tokens from the exchange contract to a
+// receiver address. The request is signed by the trader and the withdrawal transaction
+// is sent by the operator.
+type Withdraw struct {
+	ID              bson.ObjectId
+	Hash            common.Hash
+	ExchangeAddress common.Address
+	Token           common.Address
+	Amount          *big.Int
+	Trader          common.Address
+	Receiver        common.Address
+	Nonce           *big.Int
+	Signature       *Signature
+	Status          string
+	TxHash          common.Hash
+	Error           string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// WithdrawRecord is the struct which is stored in db
+type WithdrawRecord struct {
+	ID              bson.ObjectId    `bson:"_id"`
+	Hash            string           `bson:"hash"`
+	ExchangeAddress string           `bson:"exchangeAddress"`
+	Token           string           `bson:"token"`
+	Amount          string           `bson:"amount"`
+	Trader          string           `bson:"trader"`
+	Receiver        string           `bson:"receiver"`
+	Nonce           string           `bson:"nonce"`
+	Signature       *SignatureRecord `bson:"signature"`
+	Status          string           `bson:"status"`
+	TxHash          string           `bson:"txHash,omitempty"`
+	Error           string           `bson:"error,omitempty"`
+	CreatedAt       time.Time        `bson:"createdAt"`
+	UpdatedAt       time.Time        `bson:"updatedAt"`
+}
+
+// ComputeHash computes the hash of a withdrawal, signed by the trader. The hash matches
+// the withdrawal hash of the exchange contract.
+func (w *Withdraw) ComputeHash() common.Hash {
+	sha := sha3.NewKeccak256()
+	sha.Write(w.ExchangeAddress.Bytes())
+	sha.Write(w.Token.Bytes())
+	sha.Write(common.BigToHash(w.Amount).Bytes())
+	sha.Write(w.Trader.Bytes())
+	sha.Write(w.Receiver.Bytes())
+	sha.Write(common.BigToHash(w.Nonce).Bytes())
+	return common.BytesToHash(sha.Sum(nil))
+}
+
+// Validate checks the fields of a withdrawal request and that its hash is the hash of
+// its fields
+func (w *Withdraw) Validate() error {
+	if w.Amount == nil || w.Amount.Sign() <= 0 {
+		return errors.New("Amount should be positive")
+	}
+
+	if w.Nonce == nil || w.Nonce.Sign() < 0 {
+		return errors.New("Invalid nonce")
+	}
+
+	if w.Receiver == (common.Address{}) {
+		return errors.New("Receiver is missing")
+	}
+
+	if w.Signature == nil {
+		return errors.New("Signature is missing")
+	}
+
+	if w.Hash != w.ComputeHash() {
+		return errors.New("Invalid hash")
+	}
+
+	return nil
+}
+
+// VerifySignature checks that the withdrawal hash is signed by the trader with eth_sign
+func (w *Withdraw) VerifySignature() (bool, error) {
+	message := crypto.Keccak256(
+		[]byte("\x19Ethereum Signed Message:\n32"),
+		w.Hash.Bytes(),
+	)
+
+	address, err := w.Signature.Verify(common.BytesToHash(message))
+	if err != nil {
+		return false, err
+	}
+
+	if address != w.Trader {
+		return false, errors.New("Recovered address is incorrect")
+	}
+
+	return true, nil
+}
+
+// Sign computes the withdrawal hash, then signs and sets the signature
+func (w *Withdraw) Sign(wallet *Wallet) error {
+	h := w.ComputeHash()
+	sig, err := wallet.SignHash(h)
+	if err != nil {
+		return err
+	}
+
+	w.Hash = h
+	w.Signature = sig
+	return nil
+}
+
+// MarshalJSON returns the json encoded withdrawal. The amounts are encoded as strings.
+func (w *Withdraw) MarshalJSON() ([]byte, error) {
+	withdraw := map[string]interface{}{
+		"hash":            w.Hash.Hex(),
+		"exchangeAddress": w.ExchangeAddress.Hex(),
+		"token":           w.Token.Hex(),
+		"amount":          w.Amount.String(),
+		"trader":          w.Trader.Hex(),
+		"receiver":        w.Receiver.Hex(),
+		"nonce":           w.Nonce.String(),
+		"status":          w.Status,
+		"createdAt":       w.CreatedAt,
+		"updatedAt":       w.UpdatedAt,
+	}
+
+	if w.TxHash != (common.Hash{}) {
+		withdraw["txHash"] = w.TxHash.Hex()
+	}
+
+	if w.Error != "" {
+		withdraw["error"] = w.Error
+	}
+
+	return json.Marshal(withdraw)
+}
+
+// UnmarshalJSON decodes a signed withdrawal request
+func (w *Withdraw) UnmarshalJSON(b []byte) error {
+	parsed := map[string]interface{}{}
+
+	err := json.Unmarshal(b, &parsed)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range []string{"exchangeAddress", "token", "trader", "receiver"} {
+		if a, _ := parsed[field].(string); !common.IsHexAddress(a) {
+			return errors.New("Invalid " + field)
+		}
+	}
+
+	w.ExchangeAddress = common.HexToAddress(parsed["exchangeAddress"].(string))
+	w.Token = common.HexToAddress(parsed["token"].(string))
+	w.Trader = common.HexToAddress(parsed["trader"].(string))
+	w.Receiver = common.HexToAddress(parsed["receiver"].(string))
+
+	amount, _ := parsed["amount"].(string)
+	w.Amount = math.ToBigInt(amount)
+
+	nonce, _ := parsed["nonce"].(string)
+	w.Nonce = math.ToBigInt(nonce)
+
+	hash, _ := parsed["hash"].(string)
+	w.Hash = common.HexToHash(hash)
+
+	sig, ok := parsed["signature"].(map[string]interface{})
+	if ok {
+		v, _ := sig["V"].(float64)
+		r, _ := sig["R"].(string)
+		s, _ := sig["S"].(string)
+		w.Signature = &Signature{
+			V: byte(v),
+			R: common.HexToHash(r),
+			S: common.HexToHash(s),
+		}
+	}
+
+	return nil
+}
+
+func (w *Withdraw) GetBSON() (interface{}, error) {
+	record := &WithdrawRecord{
+		ID:              w.ID,
+		Hash:            w.Hash.Hex(),
+		ExchangeAddress: w.ExchangeAddress.Hex(),
+		Token:           w.Token.Hex(),
+		Amount:          w.Amount.String(),
+		Trader:          w.Trader.Hex(),
+		Receiver:        w.Receiver.Hex(),
+		Nonce:           w.Nonce.String(),
+		Status:          w.Status,
+		Error:           w.Error,
+		CreatedAt:       w.CreatedAt,
+		UpdatedAt:       w.UpdatedAt,
+	}
+
+	if w.Signature != nil {
+		record.Signature = &SignatureRecord{
+			V: w.Signature.V,
+			R: w.Signature.R.Hex(),
+			S: w.Signature.S.Hex(),
+		}
+	}
+
+	if w.TxHash != (common.Hash{}) {
+		record.TxHash = w.TxHash.Hex()
+	}
+
+	return record, nil
+}
+
+func (w *Withdraw) SetBSON(raw bson.Raw) error {
+	decoded := &WithdrawRecord{}
+
+	err := raw.Unmarshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	w.ID = decoded.ID
+	w.Hash = common.HexToHash(decoded.Hash)
+	w.ExchangeAddress = common.HexToAddress(decoded.ExchangeAddress)
+	w.Token = common.HexToAddress(decoded.Token)
+	w.Amount = math.ToBigInt(decoded.Amount)
+	w.Trader = common.HexToAddress(decoded.Trader)
+	w.Receiver = common.HexToAddress(decoded.Receiver)
+	w.Nonce = math.ToBigInt(decoded.Nonce)
+	w.Status = decoded.Status
+	w.Error = decoded.Error
+	w.CreatedAt = decoded.CreatedAt
+	w.UpdatedAt = decoded.UpdatedAt
+
+	if decoded.Signature != nil {
+		w.Signature = &Signature{
+			V: decoded.Signature.V,
+			R: common.HexToHash(decoded.Signature.R),
+			S: common.HexToHash(decoded.Signature.S),
+		}
+	}
+
+	if decoded.TxHash != "" {
+		w.TxHash = common.HexToHash(decoded.TxHash)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithdrawSignature(t *testing.T) {
+	wallet := NewWallet()
+	w := &Withdraw{
+		ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		Token:           common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		Amount:          big.NewInt(1000),
+		Trader:          wallet.Address,
+		Receiver:        wallet.Address,
+		Nonce:           big.NewInt(1),
+	}
+
+	if err := w.Sign(wallet); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, w.Validate())
+
+	ok, err := w.VerifySignature()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// the hash does not match the fields anymore
+	w.Amount = big.NewInt(2000)
+	assert.Error(t, w.Validate())
+
+	// the withdrawal is not signed by the trader
+	w.Amount = big.NewInt(1000)
+	w.Trader = NewWallet().Address
+	w.Hash = w.ComputeHash()
+	ok, _ = w.VerifySignature()
+	assert.False(t, ok)
+}
+
+func TestWithdrawUnmarshal(t *testing.T) {
+	b := []byte(`{
+		"exchangeAddress": "0xae55690d4b079460e6ac28aaa58c9ec7b73a7485",
+		"token": "0xe41d2489571d322189246dafa5ebde1f4699f498",
+		"amount": "1000",
+		"trader": "0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa",
+		"receiver": "0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa",
+		"nonce": "1",
+		"hash": "0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a",
+		"signature": {"V": 28, "R": "0x01", "S": "0x02"}
+	}`)
+
+	w := &Withdraw{}
+	if err := json.Unmarshal(b, w); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, big.NewInt(1000), w.Amount)
+	assert.Equal(t, big.NewInt(1), w.Nonce)
+	assert.Equal(t, common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"), w.Trader)
+	assert.Equal(t, byte(28), w.Signature.V)
+
+	err := json.Unmarshal([]byte(`{"token": "0x01"}`), &Withdraw{})
+	assert.Error(t, err)
+}
@@ -2,7 +2,14 @@ package types
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
 )
 
 // SubscriptionEvent is an enum signifies whether the incoming message is of type Subscribe or unsubscribe
@@ -17,8 +24,14 @@ const (
 
 const TradeChannel = "trades"
 const OrderbookChannel = "order_book"
+const RawOrderBookChannel = "raw_order_book"
 const OrderChannel = "orders"
 const OHLCVChannel = "ohlcv"
+const UserChannel = "user"
+const BalanceChannel = "balances"
+const MarketChannel = "markets"
+const TickerChannel = "tickers"
+const AuthChannel = "auth"
 
 type WebSocketMessage struct {
 	Channel string           `json:"channel"`
@@ -44,6 +57,125 @@ type Params struct {
 	Duration int64  `json:"duration"`
 	Units    string `json:"units"`
 	TickID   string `json:"tickID"`
+	// Formatted adds display formatted prices and amounts to the payloads
+	Formatted bool `json:"formatted"`
+	// Limit is the maximum number of items sent at subscription (eg. trades history)
+	Limit int `json:"limit"`
+	// Intervals subscribes to several intervals of the ticks of a pair at once, in place
+	// of the duration and units
+	Intervals []OHLCVInterval `json:"intervals,omitempty"`
+}
+
+// UserSubscription is the message used to subscribe to the fills of an account on the
+// user channel, or to its token balances on the balances channel. The signature is made by the account over the hash of the address and
+// of the timestamp, so that a captured subscription message can not be replayed later.
+type UserSubscription struct {
+	Event     SubscriptionEvent `json:"event"`
+	Address   common.Address    `json:"address"`
+	Timestamp int64             `json:"timestamp"`
+	Signature *Signature        `json:"signature"`
+}
+
+// ComputeHash calculates the hash signed by the account in the subscription message
+func (s *UserSubscription) ComputeHash() common.Hash {
+	sha := sha3.NewKeccak256()
+	sha.Write(s.Address.Bytes())
+	sha.Write(common.BigToHash(big.NewInt(s.Timestamp)).Bytes())
+	return common.BytesToHash(sha.Sum(nil))
+}
+
+// VerifySignature checks that the subscription is signed by the account and that the
+// timestamp is within maxSkew of now. It fails with ErrClockSkew otherwise.
+func (s *UserSubscription) VerifySignature(now time.Time, maxSkew time.Duration) error {
+	if s.Signature == nil {
+		return errors.New("Signature is not set")
+	}
+
+	if err := CheckTimestamp(time.Unix(s.Timestamp, 0), now, maxSkew); err != nil {
+		return err
+	}
+
+	message := crypto.Keccak256(
+		[]byte("\x19Ethereum Signed Message:\n32"),
+		s.ComputeHash().Bytes(),
+	)
+
+	address, err := s.Signature.Verify(common.BytesToHash(message))
+	if err != nil {
+		return err
+	}
+
+	if address != s.Address {
+		return errors.New("Recovered address is incorrect")
+	}
+
+	return nil
+}
+
+// Sign computes the subscription hash and signs it with the given wallet
+func (s *UserSubscription) Sign(w *Wallet) error {
+	sig, err := w.SignHash(s.ComputeHash())
+	if err != nil {
+		return err
+	}
+
+	s.Signature = sig
+	return nil
+}
+
+// WebSocketAuth is the message authenticating a websocket connection as an account on
+// the auth channel. The account signs the hash of its address and of the challenge sent
+// by the server to the connection, a challenge is only accepted once.
+type WebSocketAuth struct {
+	Address   common.Address `json:"address"`
+	Challenge common.Hash    `json:"challenge"`
+	Signature *Signature     `json:"signature"`
+}
+
+// ComputeHash calculates the hash signed by the account in the auth message
+func (a *WebSocketAuth) ComputeHash() common.Hash {
+	sha := sha3.NewKeccak256()
+	sha.Write(a.Address.Bytes())
+	sha.Write(a.Challenge.Bytes())
+	return common.BytesToHash(sha.Sum(nil))
+}
+
+// VerifySignature checks that the auth message signs challenge and is signed by the account
+func (a *WebSocketAuth) VerifySignature(challenge common.Hash) error {
+	if a.Signature == nil {
+		return errors.New("Signature is not set")
+	}
+
+	if a.Challenge != challenge {
+		return errors.New("Invalid challenge")
+	}
+
+	message := crypto.Keccak256(
+		[]byte("\x19Ethereum Signed Message:\n32"),
+		a.ComputeHash().Bytes(),
+	)
+
+	address, err := a.Signature.Verify(common.BytesToHash(message))
+	if err != nil {
+		return err
+	}
+
+	if address != a.Address {
+		return errors.New("Recovered address is incorrect")
+	}
+
+	return nil
+}
+
+// Sign computes the auth hash and signs it with the given wallet
+func (a *WebSocketAuth) Sign(w *Wallet) error {
+	sig, err := w.SignHash(a.ComputeHash())
+	if err != nil {
+		return err
+	}
+
+	a.Signature = sig
+	return nil
 }
 
 func NewOrderWebsocketMessage(o *Order) *WebSocketMessage {
@@ -68,6 +200,19 @@ func NewOrderCancelWebsocketMessage(oc *OrderCancel) *WebSocketMessage {
 	}
 }
 
+// NewSubmitSignatureWebsocketMessage returns the message sent by a taker in response
+// to a REQUEST_SIGNATURE message. data is the matching engine response with signed trades.
+func NewSubmitSignatureWebsocketMessage(hash common.Hash, data interface{}) *WebSocketMessage {
+	return &WebSocketMessage{
+		Channel: "orders",
+		Payload: WebSocketPayload{
+			Type: "SUBMIT_SIGNATURE",
+			Hash: hash.Hex(),
+			Data: data,
+		},
+	}
+}
+
 func (w *WebSocketMessage) Print() {
 	b, err := json.MarshalIndent(w, "", "  ")
 	if err != nil {
@@ -107,6 +252,7 @@ func (w *WebSocketPayload) Print() {
 //orders/TRADE_EXECUTED
 //orders/TRADE_TX_SUCCESS
 //orders/TRADE_TX_ERROR
+//orders/TRADE_TX_REORGED
 
 //order_book/INIT
 //order_book/UPDATE
@@ -114,6 +260,8 @@ func (w *WebSocketPayload) Print() {
 //trades/UPDATE
 //ohlcv/INIT
 //ohlcv/UPDATE
+//user/INIT
+//user/UPDATE
 
 //To be replaced by WebsocketMessage i think
 // type ChannelMessage struct {
@@ -222,3 +222,48 @@ func TestNewWebsocketMessage(t *testing.T) {
 	Compare(t, expected, msg)
 	CompareStructs(t, expected, msg)
 }
+
+func TestUserSubscriptionVerifySignature(t *testing.T) {
+	w := NewWallet()
+	now := time.Unix(1405544146, 0)
+
+	sub := &UserSubscription{Event: SUBSCRIBE, Address: w.Address, Timestamp: now.Unix()}
+	err := sub.Sign(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, sub.VerifySignature(now, time.Minute))
+
+	// the signature does not match a different account
+	other := *sub
+	other.Address = NewWallet().Address
+	assert.NotNil(t, other.VerifySignature(now, time.Minute))
+
+	// the subscription message can not be replayed later
+	assert.Equal(t, ErrClockSkew, sub.VerifySignature(now.Add(2*time.Minute), time.Minute))
+
+	// nor signed with a clock running ahead of the server
+	assert.Equal(t, ErrClockSkew, sub.VerifySignature(now.Add(-2*time.Minute), time.Minute))
+}
+
+func TestWebSocketAuthVerifySignature(t *testing.T) {
+	w := NewWallet()
+	challenge := common.HexToHash("0x1234")
+
+	auth := &WebSocketAuth{Address: w.Address, Challenge: challenge}
+	err := auth.Sign(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, auth.VerifySignature(challenge))
+
+	// the signature does not match a different account
+	other := *auth
+	other.Address = NewWallet().Address
+	assert.NotNil(t, other.VerifySignature(challenge))
+
+	// a signed challenge can not authenticate another connection
+	assert.NotNil(t, auth.VerifySignature(common.HexToHash("0x5678")))
+}
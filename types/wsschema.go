@@ -0,0 +1,444 @@
+package types
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/schema"
+	"github.com/ethereum/go-ethereum/common"
+	eth "github.com/ethereum/go-ethereum/core/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// WebSocketSchemaTypes returns the payload types of the websocket messages. The client
+// types are generated from the json encoding of the samples (see cmd/wsschema), a type
+// must be added here when a new payload is sent over the websocket.
+func WebSocketSchemaTypes() []schema.Type {
+	order, minimalOrder := schemaOrder(), schemaOrder()
+	minimalOrder.ID = ""
+	minimalOrder.PairID = ""
+	minimalOrder.Signature = nil
+	minimalOrder.SignatureScheme = SchemeEthSign
+	minimalOrder.PriceFormatted = ""
+	minimalOrder.Sequence = 0
+
+	trade, minimalTrade := schemaTrade(), schemaTrade()
+	minimalTrade.PairSymbol = ""
+	minimalTrade.PriceFormatted = ""
+	minimalTrade.FailureReason = ""
+	minimalTrade.BustReason = ""
+	minimalTrade.Flagged = false
+	minimalTrade.Tx = nil
+
+	tick, minimalTick := schemaTick(), schemaTick()
+	minimalTick.ID.Symbol = ""
+	minimalTick.Formatted = nil
+	minimalTick.Interval = ""
+
+	pair := PairSubDoc{
+		Name:       "ZRX/WETH",
+		Symbol:     "ZRX-WETH",
+		BaseToken:  common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken: common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+	}
+
+	minimalPair := pair
+	minimalPair.Symbol = ""
+
+	entry := map[string]float64{"price": 1.5, "volume": 100}
+
+	// the v2 orders do not have the chain id and the fee asset data of the v3 orders
+	zeroEx, minimalZeroEx := schemaZeroExOrder(), schemaZeroExOrder()
+	minimalZeroEx.ChainID = 0
+
+	// the transaction hash and the error are only set once the withdrawal is submitted or failed
+	withdraw, minimalWithdraw := schemaWithdraw(), schemaWithdraw()
+	minimalWithdraw.TxHash = common.Hash{}
+	minimalWithdraw.Error = ""
+
+	// the listing time is only set while the listing of a pair is scheduled
+	marketPair := map[string]interface{}{
+		"pair":            schema.Ref("Pair"),
+		"active":          true,
+		"makeFee":         "0",
+		"takeFee":         "0",
+		"pricePrecision":  8,
+		"amountPrecision": 2,
+		"listingTime":     time.Unix(1405544146, 0).UTC(),
+	}
+
+	minimalMarketPair := map[string]interface{}{}
+	for k, v := range marketPair {
+		if k != "listingTime" {
+			minimalMarketPair[k] = v
+		}
+	}
+
+	// the prices are not set when a pair was never traded or when its orderbook is empty
+	ticker := map[string]interface{}{
+		"pair":      schema.Ref("Pair"),
+		"last":      "200",
+		"open":      "100",
+		"high":      "300",
+		"low":       "100",
+		"volume":    "30",
+		"change":    100.5,
+		"trades":    3,
+		"bestBid":   "190",
+		"bestAsk":   "210",
+		"timestamp": time.Unix(1405544146, 0).UTC(),
+	}
+
+	minimalTicker := map[string]interface{}{}
+	for _, k := range []string{"pair", "volume", "change", "trades", "timestamp"} {
+		minimalTicker[k] = ticker[k]
+	}
+
+	return []schema.Type{
+		{Name: "Signature", Sample: map[string]interface{}{"V": 28, "R": common.Hash{}, "S": common.Hash{}}},
+		{Name: "Pair", Sample: pair, Minimal: minimalPair},
+		{Name: "Order", Sample: order, Minimal: minimalOrder},
+		{Name: "OrderCancel", Sample: &OrderCancel{Signature: &Signature{}}},
+		{Name: "OrderResult", Sample: &OrderResult{Code: "INVALID_SIGNATURE", Error: "The signature is invalid."}, Minimal: &OrderResult{}},
+		// the payload of the ERROR messages, see the error catalogue of the errors package
+		{
+			Name:    "ErrorPayload",
+			Sample:  map[string]interface{}{"Code": "PAIR_NOT_FOUND", "Message": "The pair was not found.", "Details": nil},
+			Minimal: map[string]interface{}{"Code": "PAIR_NOT_FOUND", "Message": "The pair was not found."},
+		},
+		{Name: "MassQuote", Sample: map[string]interface{}{
+			"baseToken":  common.Address{},
+			"quoteToken": common.Address{},
+			"bids":       []schema.Ref{"Order"},
+			"asks":       []schema.Ref{"Order"},
+		}},
+		{Name: "Trade", Sample: trade, Minimal: minimalTrade},
+		{Name: "PublicTrade", Sample: trade.Public(), Minimal: minimalTrade.Public()},
+		{Name: "Tick", Sample: tick, Minimal: minimalTick},
+		{Name: "OrderBookEntry", Sample: entry},
+		{Name: "OrderBook", Sample: map[string]interface{}{
+			"pair":     schema.Ref("Pair"),
+			"asks":     []schema.Ref{"OrderBookEntry"},
+			"bids":     []schema.Ref{"OrderBookEntry"},
+			"sequence": 1,
+		}},
+		{Name: "OrderBookChange", Sample: &OrderBookChange{Side: "BUY", Price: 1.5, Amount: 100}},
+		{Name: "OrderBookUpdate", Sample: map[string]interface{}{
+			"pair":     schema.Ref("Pair"),
+			"changes":  []schema.Ref{"OrderBookChange"},
+			"sequence": 1,
+		}},
+		{Name: "RawOrder", Sample: &RawOrder{Side: "BUY", Price: 1.5, Amount: 100, Sequence: 1}},
+		{Name: "RawOrderBook", Sample: map[string]interface{}{
+			"pair":     schema.Ref("Pair"),
+			"asks":     []schema.Ref{"RawOrder"},
+			"bids":     []schema.Ref{"RawOrder"},
+			"sequence": 1,
+		}},
+		{Name: "RawOrderChange", Sample: map[string]interface{}{
+			"action": RawOrderAdded,
+			"order":  schema.Ref("RawOrder"),
+		}},
+		{Name: "RawOrderBookUpdate", Sample: map[string]interface{}{
+			"pair":     schema.Ref("Pair"),
+			"changes":  []schema.Ref{"RawOrderChange"},
+			"sequence": 1,
+		}},
+		// the chunks and the END message of the bundled subscriptions hold their intervals
+		{
+			Name: "OHLCVChunk",
+			Sample: map[string]interface{}{
+				"sequence": 0,
+				"ticks":    []schema.Ref{"Tick"},
+				"interval": "5min",
+			},
+			Minimal: map[string]interface{}{
+				"sequence": 0,
+				"ticks":    []schema.Ref{"Tick"},
+			},
+		},
+		{
+			Name:    "OHLCVEnd",
+			Sample:  map[string]interface{}{"chunks": 1, "ticks": 1, "intervals": []string{"5min"}},
+			Minimal: map[string]interface{}{"chunks": 1, "ticks": 1},
+		},
+		{Name: "TradeFailure", Sample: map[string]interface{}{
+			"trade":     schema.Ref("Trade"),
+			"errorCode": 1,
+			"reason":    "Transaction reverted",
+			"error":     nil,
+		}},
+		{
+			Name:    "Subscription",
+			Sample:  &WebSocketSubscription{Event: SUBSCRIBE, Pair: pair, Params: Params{Intervals: []OHLCVInterval{{Duration: 5, Units: "min"}}}},
+			Minimal: &WebSocketSubscription{Event: SUBSCRIBE, Pair: minimalPair},
+		},
+		{Name: "UserSubscription", Sample: map[string]interface{}{
+			"event":     SUBSCRIBE,
+			"address":   common.Address{},
+			"timestamp": 1,
+			"signature": schema.Ref("Signature"),
+		}},
+		{Name: "ZeroExOrder", Sample: zeroEx, Minimal: minimalZeroEx},
+		{Name: "TokenBalance", Sample: schemaTokenBalance().jsonMap()},
+		{Name: "AccountBalances", Sample: map[string]interface{}{
+			"address":  common.Address{},
+			"balances": []schema.Ref{"TokenBalance"},
+		}},
+		{Name: "Deposit", Sample: schemaDeposit()},
+		{Name: "Withdraw", Sample: withdraw, Minimal: minimalWithdraw},
+		{Name: "MarketToken", Sample: schemaToken().Market()},
+		{Name: "MarketPair", Sample: marketPair, Minimal: minimalMarketPair},
+		{Name: "Markets", Sample: map[string]interface{}{
+			"tokens": []schema.Ref{"MarketToken"},
+			"pairs":  []schema.Ref{"MarketPair"},
+		}},
+		{Name: "MarketSubscription", Sample: map[string]interface{}{"event": SUBSCRIBE}},
+		{Name: "Ticker", Sample: ticker, Minimal: minimalTicker},
+		{Name: "TickerSubscription", Sample: map[string]interface{}{"event": SUBSCRIBE}},
+		{Name: "WebSocketAuth", Sample: map[string]interface{}{
+			"address":   common.Address{},
+			"challenge": common.Hash{},
+			"signature": schema.Ref("Signature"),
+		}},
+		{Name: "AuthChallenge", Sample: map[string]interface{}{"challenge": common.Hash{}}},
+		{Name: "Authenticated", Sample: map[string]interface{}{"address": common.Address{}}},
+	}
+}
+
+// WebSocketSchemaMessages returns the messages of the websocket channels with the type
+// of their data
+func WebSocketSchemaMessages() []schema.Message {
+	server := func(channel, msgType, data string) schema.Message {
+		return schema.Message{Channel: channel, Direction: schema.ServerToClient, Type: msgType, Data: data}
+	}
+
+	client := func(channel, msgType, data string) schema.Message {
+		return schema.Message{Channel: channel, Direction: schema.ClientToServer, Type: msgType, Data: data}
+	}
+
+	subscription := func(channel, payload string) schema.Message {
+		return schema.Message{Channel: channel, Direction: schema.ClientToServer, Payload: payload}
+	}
+
+	return []schema.Message{
+		server(OrderChannel, "ORDER_ADDED", "Order"),
+		server(OrderChannel, "ORDER_CANCELLED", "Order"),
+		server(OrderChannel, "ORDER_FILLED_ON_CHAIN", "Order"),
+		server(OrderChannel, "NEW_ORDERS_RESULT", "OrderResult[]"),
+		server(OrderChannel, "CANCEL_ORDERS_RESULT", "OrderResult[]"),
+		server(OrderChannel, "MASS_QUOTE_RESULT", "OrderResult[]"),
+		server(OrderChannel, "REQUEST_SIGNATURE", "any"),
+		server(OrderChannel, "TRADE_EXECUTED", "Trade"),
+		server(OrderChannel, "TRADE_TX_SUCCESS", "Trade"),
+		server(OrderChannel, "TRADE_TX_ERROR", "TradeFailure"),
+		server(OrderChannel, "TRADE_TX_REORGED", "Trade"),
+		server(OrderChannel, "TRADE_BUSTED", "Trade"),
+		server(OrderChannel, "TRADE_FLAGGED", "Trade"),
+		server(OrderChannel, "ERROR", "ErrorPayload"),
+		server(OrderbookChannel, "INIT", "OrderBook"),
+		server(OrderbookChannel, "UPDATE", "OrderBookUpdate"),
+		server(OrderbookChannel, "ERROR", "ErrorPayload"),
+		server(RawOrderBookChannel, "INIT", "RawOrderBook"),
+		server(RawOrderBookChannel, "UPDATE", "RawOrderBookUpdate"),
+		server(RawOrderBookChannel, "ERROR", "ErrorPayload"),
+		server(TradeChannel, "INIT", "PublicTrade[]"),
+		server(TradeChannel, "UPDATE", "PublicTrade[]"),
+		server(TradeChannel, "ERROR", "ErrorPayload"),
+		server(OHLCVChannel, "INIT", "OHLCVChunk"),
+		server(OHLCVChannel, "END", "OHLCVEnd"),
+		server(OHLCVChannel, "UPDATE", "Tick"),
+		server(OHLCVChannel, "ERROR", "ErrorPayload"),
+		server(UserChannel, "INIT", "Trade[]"),
+		server(UserChannel, "UPDATE", "Trade[]"),
+		server(UserChannel, "ERROR", "ErrorPayload"),
+		server(BalanceChannel, "INIT", "AccountBalances"),
+		server(BalanceChannel, "UPDATE", "AccountBalances"),
+		server(BalanceChannel, "DEPOSIT_CONFIRMED", "Deposit"),
+		server(BalanceChannel, "WITHDRAW_UPDATED", "Withdraw"),
+		server(BalanceChannel, "ERROR", "ErrorPayload"),
+		server(MarketChannel, "INIT", "Markets"),
+		server(MarketChannel, "TOKEN_LISTED", "MarketToken"),
+		server(MarketChannel, "TOKEN_UPDATED", "MarketToken"),
+		server(MarketChannel, "TOKEN_DELISTED", "MarketToken"),
+		server(MarketChannel, "PAIR_LISTED", "MarketPair"),
+		server(MarketChannel, "PAIR_UPDATED", "MarketPair"),
+		server(MarketChannel, "PAIR_LAUNCHED", "MarketPair"),
+		server(MarketChannel, "PAIR_DELISTED", "MarketPair"),
+		server(MarketChannel, "ERROR", "ErrorPayload"),
+		server(TickerChannel, "INIT", "Ticker[]"),
+		server(TickerChannel, "UPDATE", "Ticker[]"),
+		server(TickerChannel, "ERROR", "ErrorPayload"),
+		server(AuthChannel, "CHALLENGE", "AuthChallenge"),
+		server(AuthChannel, "AUTHENTICATED", "Authenticated"),
+		server(AuthChannel, "ERROR", "ErrorPayload"),
+		client(OrderChannel, "NEW_ORDER", "Order"),
+		client(OrderChannel, "CANCEL_ORDER", "OrderCancel"),
+		client(OrderChannel, "SUBMIT_SIGNATURE", "any"),
+		client(OrderChannel, "NEW_0X_ORDER", "ZeroExOrder"),
+		client(OrderChannel, "NEW_ORDERS", "Order[]"),
+		client(OrderChannel, "CANCEL_ORDERS", "OrderCancel[]"),
+		client(OrderChannel, "MASS_QUOTE", "MassQuote"),
+		client(AuthChannel, "CHALLENGE", "any"),
+		client(AuthChannel, "AUTHENTICATE", "WebSocketAuth"),
+		subscription(OrderbookChannel, "Subscription"),
+		subscription(RawOrderBookChannel, "Subscription"),
+		subscription(TradeChannel, "Subscription"),
+		subscription(OHLCVChannel, "Subscription"),
+		subscription(UserChannel, "UserSubscription"),
+		subscription(BalanceChannel, "UserSubscription"),
+		subscription(MarketChannel, "MarketSubscription"),
+		subscription(TickerChannel, "TickerSubscription"),
+	}
+}
+
+func schemaOrder() *Order {
+	return &Order{
+		ID:              bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		PairID:          bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		PairName:        "ZRX/WETH",
+		UserAddress:     common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		BuyToken:        common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		SellToken:       common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BaseToken:       common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken:      common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		BuyAmount:       big.NewInt(1000),
+		SellAmount:      big.NewInt(100),
+		Price:           big.NewInt(1000),
+		PricePoint:      big.NewInt(1000),
+		Amount:          big.NewInt(1000),
+		FilledAmount:    big.NewInt(100),
+		Nonce:           big.NewInt(1),
+		Expires:         big.NewInt(10000),
+		MakeFee:         big.NewInt(0),
+		TakeFee:         big.NewInt(0),
+		Side:            "BUY",
+		Status:          "OPEN",
+		Signature:       &Signature{V: 28},
+		SignatureScheme: SchemeEIP712,
+		PriceFormatted:  "0.00001000",
+		AmountFormatted: "1000",
+		Sequence:        1,
+		CreatedAt:       time.Unix(1405544146, 0),
+		UpdatedAt:       time.Unix(1405544146, 0),
+	}
+}
+
+func schemaTrade() *Trade {
+	return &Trade{
+		ID:              bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		TakerOrderID:    bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		MakerOrderID:    bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		Maker:           common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Taker:           common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		BaseToken:       common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		QuoteToken:      common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"),
+		PairName:        "ZRX/WETH",
+		PairSymbol:      "ZRX-WETH",
+		TradeNonce:      big.NewInt(1),
+		Signature:       &Signature{V: 28},
+		Price:           big.NewInt(1000),
+		PricePoint:      big.NewInt(1000),
+		Amount:          big.NewInt(100),
+		Side:            "BUY",
+		Status:          "SUCCESS",
+		PriceFormatted:  "0.00001000",
+		AmountFormatted: "100",
+		FailureReason:   "Transaction reverted",
+		BustReason:      "Erroneous price",
+		Flagged:         true,
+		Tx:              eth.NewTransaction(1, common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"), big.NewInt(0), 200000, big.NewInt(1), nil),
+		CreatedAt:       time.Unix(1405544146, 0),
+		UpdatedAt:       time.Unix(1405544146, 0),
+	}
+}
+
+func schemaTick() *Tick {
+	return &Tick{
+		ID: TickID{
+			Pair:       "ZRX/WETH",
+			BaseToken:  "0xe41d2489571d322189246dafa5ebde1f4699f498",
+			QuoteToken: "0x12459c951127e0c374ff9105dda097662a027093",
+			Symbol:     "ZRX-WETH",
+		},
+		O:         1000,
+		H:         1200,
+		L:         900,
+		C:         1100,
+		V:         5000,
+		Count:     3,
+		Ts:        1535760000000,
+		Formatted: &TickFormatted{},
+		Interval:  "5min",
+	}
+}
+
+func schemaTokenBalance() *TokenBalance {
+	return &TokenBalance{
+		ID:            bson.ObjectIdHex("537f700b537461b70c5f0000"),
+		Address:       common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		Symbol:        "ZRX",
+		Balance:       big.NewInt(1000),
+		Allowance:     big.NewInt(1000),
+		LockedBalance: big.NewInt(100),
+	}
+}
+
+func schemaToken() *Token {
+	return &Token{
+		Name:            "0x Protocol Token",
+		Symbol:          "ZRX",
+		ContractAddress: common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		Decimal:         18,
+		Active:          true,
+	}
+}
+
+func schemaDeposit() *Deposit {
+	return &Deposit{
+		TxHash:      common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		LogIndex:    3,
+		BlockNumber: 6000000,
+		Token:       common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		Owner:       common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Amount:      big.NewInt(1000),
+		Status:      DepositConfirmed,
+		CreatedAt:   time.Unix(1535760000, 0).UTC(),
+		UpdatedAt:   time.Unix(1535760180, 0).UTC(),
+	}
+}
+
+func schemaWithdraw() *Withdraw {
+	return &Withdraw{
+		Hash:            common.HexToHash("0x2c3b7c4a2f5d1b0e7e0f9f6b5e0d63c0a7d8d8b2e6b4f0b1d9e62b0f6f3c9a11"),
+		ExchangeAddress: common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485"),
+		Token:           common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"),
+		Amount:          big.NewInt(1000),
+		Trader:          common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Receiver:        common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		Nonce:           big.NewInt(1),
+		Status:          WithdrawFailed,
+		TxHash:          common.HexToHash("0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a"),
+		Error:           "Withdrawal transaction reverted",
+		CreatedAt:       time.Unix(1535760000, 0).UTC(),
+		UpdatedAt:       time.Unix(1535760180, 0).UTC(),
+	}
+}
+
+func schemaZeroExOrder() *ZeroExOrder {
+	return &ZeroExOrder{
+		ChainID:               1,
+		ExchangeAddress:       common.HexToAddress("0x61935cbdd02287b511119ddb11aeb42f1593b7ef"),
+		MakerAddress:          common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"),
+		MakerAssetAmount:      big.NewInt(1000),
+		TakerAssetAmount:      big.NewInt(100),
+		MakerFee:              big.NewInt(0),
+		TakerFee:              big.NewInt(0),
+		ExpirationTimeSeconds: big.NewInt(1535760000),
+		Salt:                  big.NewInt(1),
+		MakerAssetData:        EncodeERC20AssetData(common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")),
+		TakerAssetData:        EncodeERC20AssetData(common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093")),
+		Signature:             EncodeZeroExSignature(&Signature{V: 28}, ZeroExSignatureEthSign),
+	}
+}
@@ -0,0 +1,467 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Proofsuite/amp-matching-engine/utils/math"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// 0x signature types supported by the adapter. The other types (wallet, validator,
+// pre-signed) require calls to the 0x exchange contract.
+const (
+	ZeroExSignatureEIP712  byte = 0x02
+	ZeroExSignatureEthSign byte = 0x03
+)
+
+// ZeroExERC20ProxyID is the id of the ERC20 asset proxy prefixing the ERC20 asset data
+var ZeroExERC20ProxyID = []byte{0xf4, 0x72, 0x61, 0xb0}
+
+// ZeroExOrderBook is appended to the redis prefix of the orderbooks of the 0x orders
+const ZeroExOrderBook = "::0x"
+
+var (
+	zeroExDomainTypeHashV2 = crypto.Keccak256([]byte("EIP712Domain(string name,string version,address verifyingContract)"))
+	zeroExDomainTypeHashV3 = crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	zeroExOrderTypeHashV2  = crypto.Keccak256([]byte(
+		"Order(address makerAddress,address takerAddress,address feeRecipientAddress,address senderAddress," +
+			"uint256 makerAssetAmount,uint256 takerAssetAmount,uint256 makerFee,uint256 takerFee," +
+			"uint256 expirationTimeSeconds,uint256 salt,bytes makerAssetData,bytes takerAssetData)",
+	))
+	zeroExOrderTypeHashV3 = crypto.Keccak256([]byte(
+		"Order(address makerAddress,address takerAddress,address feeRecipientAddress,address senderAddress," +
+			"uint256 makerAssetAmount,uint256 takerAssetAmount,uint256 makerFee,uint256 takerFee," +
+			"uint256 expirationTimeSeconds,uint256 salt,bytes makerAssetData,bytes takerAssetData," +
+			"bytes makerFeeAssetData,bytes takerFeeAssetData)",
+	))
+)
+
+// ZeroExOrder is a signed 0x v2 or v3 order, as sent by the 0x relayer clients. The v3
+// orders are the orders with a chain id, the v2 orders do not have one.
+type ZeroExOrder struct {
+	ChainID               uint64
+	ExchangeAddress       common.Address
+	MakerAddress          common.Address
+	TakerAddress          common.Address
+	FeeRecipientAddress   common.Address
+	SenderAddress         common.Address
+	MakerAssetAmount      *big.Int
+	TakerAssetAmount      *big.Int
+	MakerFee              *big.Int
+	TakerFee              *big.Int
+	ExpirationTimeSeconds *big.Int
+	Salt                  *big.Int
+	MakerAssetData        []byte
+	TakerAssetData        []byte
+	MakerFeeAssetData     []byte
+	TakerFeeAssetData     []byte
+	Signature             []byte
+}
+
+// ZeroExOrderRecord is the json representation of a 0x order, it follows the standard
+// relayer api (SRA) format
+type ZeroExOrderRecord struct {
+	ChainID               uint64 `json:"chainId,omitempty"`
+	ExchangeAddress       string `json:"exchangeAddress"`
+	MakerAddress          string `json:"makerAddress"`
+	TakerAddress          string `json:"takerAddress"`
+	FeeRecipientAddress   string `json:"feeRecipientAddress"`
+	SenderAddress         string `json:"senderAddress"`
+	MakerAssetAmount      string `json:"makerAssetAmount"`
+	TakerAssetAmount      string `json:"takerAssetAmount"`
+	MakerFee              string `json:"makerFee"`
+	TakerFee              string `json:"takerFee"`
+	ExpirationTimeSeconds string `json:"expirationTimeSeconds"`
+	Salt                  string `json:"salt"`
+	MakerAssetData        string `json:"makerAssetData"`
+	TakerAssetData        string `json:"takerAssetData"`
+	MakerFeeAssetData     string `json:"makerFeeAssetData,omitempty"`
+	TakerFeeAssetData     string `json:"takerFeeAssetData,omitempty"`
+	Signature             string `json:"signature"`
+}
+
+// ZeroExFields holds the fields of a 0x order that have no equivalent in the order type.
+// They are kept with the order so that the signed 0x order can be rebuilt.
+type ZeroExFields struct {
+	ChainID             uint64
+	FeeRecipientAddress common.Address
+	SenderAddress       common.Address
+	MakerFeeAssetData   []byte
+	TakerFeeAssetData   []byte
+	Signature           []byte
+}
+
+// ZeroExFieldsRecord is the object saved in the database and in the json encoding of the
+// orders
+type ZeroExFieldsRecord struct {
+	ChainID             uint64 `json:"chainId,omitempty" bson:"chainId,omitempty"`
+	FeeRecipientAddress string `json:"feeRecipientAddress" bson:"feeRecipientAddress"`
+	SenderAddress       string `json:"senderAddress" bson:"senderAddress"`
+	MakerFeeAssetData   string `json:"makerFeeAssetData,omitempty" bson:"makerFeeAssetData,omitempty"`
+	TakerFeeAssetData   string `json:"takerFeeAssetData,omitempty" bson:"takerFeeAssetData,omitempty"`
+	Signature           string `json:"signature" bson:"signature"`
+}
+
+// Version returns the version of the 0x protocol of the order (2 or 3)
+func (z *ZeroExOrder) Version() int {
+	if z.ChainID != 0 {
+		return 3
+	}
+
+	return 2
+}
+
+// ComputeHash returns the EIP712 hash of the order, as computed by the 0x exchange contract
+func (z *ZeroExOrder) ComputeHash() common.Hash {
+	var domain []byte
+	if z.Version() == 3 {
+		domain = crypto.Keccak256(
+			zeroExDomainTypeHashV3,
+			crypto.Keccak256([]byte("0x Protocol")),
+			crypto.Keccak256([]byte("3.0.0")),
+			common.BigToHash(new(big.Int).SetUint64(z.ChainID)).Bytes(),
+			common.BytesToHash(z.ExchangeAddress.Bytes()).Bytes(),
+		)
+	} else {
+		domain = crypto.Keccak256(
+			zeroExDomainTypeHashV2,
+			crypto.Keccak256([]byte("0x Protocol")),
+			crypto.Keccak256([]byte("2")),
+			common.BytesToHash(z.ExchangeAddress.Bytes()).Bytes(),
+		)
+	}
+
+	typeHash := zeroExOrderTypeHashV2
+	if z.Version() == 3 {
+		typeHash = zeroExOrderTypeHashV3
+	}
+
+	fields := [][]byte{
+		typeHash,
+		common.BytesToHash(z.MakerAddress.Bytes()).Bytes(),
+		common.BytesToHash(z.TakerAddress.Bytes()).Bytes(),
+		common.BytesToHash(z.FeeRecipientAddress.Bytes()).Bytes(),
+		common.BytesToHash(z.SenderAddress.Bytes()).Bytes(),
+		common.BigToHash(z.MakerAssetAmount).Bytes(),
+		common.BigToHash(z.TakerAssetAmount).Bytes(),
+		common.BigToHash(z.MakerFee).Bytes(),
+		common.BigToHash(z.TakerFee).Bytes(),
+		common.BigToHash(z.ExpirationTimeSeconds).Bytes(),
+		common.BigToHash(z.Salt).Bytes(),
+		crypto.Keccak256(z.MakerAssetData),
+		crypto.Keccak256(z.TakerAssetData),
+	}
+
+	if z.Version() == 3 {
+		fields = append(fields, crypto.Keccak256(z.MakerFeeAssetData), crypto.Keccak256(z.TakerFeeAssetData))
+	}
+
+	return common.BytesToHash(crypto.Keccak256([]byte{0x19, 0x01}, domain, crypto.Keccak256(fields...)))
+}
+
+// ParseZeroExSignature decodes a 0x signature (v, r, s, signature type). Only the EIP712
+// and EthSign signature types are supported.
+func ParseZeroExSignature(b []byte) (*Signature, byte, error) {
+	if len(b) != 66 {
+		return nil, 0, errors.New("0x signature length should be 66 bytes")
+	}
+
+	sigType := b[65]
+	if sigType != ZeroExSignatureEIP712 && sigType != ZeroExSignatureEthSign {
+		return nil, 0, fmt.Errorf("Unsupported 0x signature type %d", sigType)
+	}
+
+	sig := &Signature{
+		V: b[0],
+		R: common.BytesToHash(b[1:33]),
+		S: common.BytesToHash(b[33:65]),
+	}
+
+	return sig, sigType, nil
+}
+
+// EncodeZeroExSignature encodes a signature in the 0x format
+func EncodeZeroExSignature(sig *Signature, sigType byte) []byte {
+	b := []byte{sig.V}
+	b = append(b, sig.R.Bytes()...)
+	b = append(b, sig.S.Bytes()...)
+	return append(b, sigType)
+}
+
+// VerifySignature checks that the order is signed by its maker
+func (z *ZeroExOrder) VerifySignature() (bool, error) {
+	sig, sigType, err := ParseZeroExSignature(z.Signature)
+	if err != nil {
+		return false, err
+	}
+
+	hash := z.ComputeHash()
+	if sigType == ZeroExSignatureEthSign {
+		hash = common.BytesToHash(crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n32"), hash.Bytes()))
+	}
+
+	address, err := sig.Verify(hash)
+	if err != nil {
+		return false, err
+	}
+
+	if address != z.MakerAddress {
+		return false, errors.New("Recovered address is incorrect")
+	}
+
+	return true, nil
+}
+
+// Sign signs the order with the given wallet using the EthSign signature type
+func (z *ZeroExOrder) Sign(w *Wallet) error {
+	sig, err := w.SignHash(z.ComputeHash())
+	if err != nil {
+		return err
+	}
+
+	z.Signature = EncodeZeroExSignature(sig, ZeroExSignatureEthSign)
+	return nil
+}
+
+// EncodeERC20AssetData returns the 0x asset data of an ERC20 token
+func EncodeERC20AssetData(token common.Address) []byte {
+	return append(append([]byte{}, ZeroExERC20ProxyID...), common.BytesToHash(token.Bytes()).Bytes()...)
+}
+
+// DecodeERC20AssetData returns the token of an ERC20 asset data. The other asset types
+// (ERC721, multi assets) can not be traded on the exchange.
+func DecodeERC20AssetData(data []byte) (common.Address, error) {
+	if len(data) != 36 || !bytes.Equal(data[:4], ZeroExERC20ProxyID) {
+		return common.Address{}, errors.New("Only ERC20 asset data is supported")
+	}
+
+	return common.BytesToAddress(data[4:]), nil
+}
+
+// Validate checks that the order can be converted to an order of the exchange
+func (z *ZeroExOrder) Validate() error {
+	if z.MakerAssetAmount == nil || z.TakerAssetAmount == nil || z.MakerFee == nil ||
+		z.TakerFee == nil || z.ExpirationTimeSeconds == nil || z.Salt == nil {
+		return errors.New("Missing 0x order amount")
+	}
+
+	if math.IsZero(z.MakerAssetAmount) || math.IsZero(z.TakerAssetAmount) {
+		return errors.New("0x order amounts can not be zero")
+	}
+
+	if z.TakerAddress != (common.Address{}) {
+		return errors.New("0x orders with a taker address can not be matched on the exchange")
+	}
+
+	if _, err := DecodeERC20AssetData(z.MakerAssetData); err != nil {
+		return err
+	}
+
+	if _, err := DecodeERC20AssetData(z.TakerAssetData); err != nil {
+		return err
+	}
+
+	_, _, err := ParseZeroExSignature(z.Signature)
+	return err
+}
+
+// ToOrder converts the 0x order to an order of the exchange. The maker asset is the sold
+// token and the taker asset the bought token. The order hash is the 0x order hash, so that
+// the order is identified by the same hash in the 0x clients.
+func (z *ZeroExOrder) ToOrder() (*Order, error) {
+	if err := z.Validate(); err != nil {
+		return nil, err
+	}
+
+	sig, _, _ := ParseZeroExSignature(z.Signature)
+	sellToken, _ := DecodeERC20AssetData(z.MakerAssetData)
+	buyToken, _ := DecodeERC20AssetData(z.TakerAssetData)
+
+	o := &Order{
+		UserAddress:     z.MakerAddress,
+		ExchangeAddress: z.ExchangeAddress,
+		SellToken:       sellToken,
+		BuyToken:        buyToken,
+		SellAmount:      new(big.Int).Set(z.MakerAssetAmount),
+		BuyAmount:       new(big.Int).Set(z.TakerAssetAmount),
+		MakeFee:         new(big.Int).Set(z.MakerFee),
+		TakeFee:         new(big.Int).Set(z.TakerFee),
+		Expires:         new(big.Int).Set(z.ExpirationTimeSeconds),
+		Nonce:           new(big.Int).Set(z.Salt),
+		FilledAmount:    big.NewInt(0),
+		Signature:       sig,
+		ZeroEx: &ZeroExFields{
+			ChainID:             z.ChainID,
+			FeeRecipientAddress: z.FeeRecipientAddress,
+			SenderAddress:       z.SenderAddress,
+			MakerFeeAssetData:   z.MakerFeeAssetData,
+			TakerFeeAssetData:   z.TakerFeeAssetData,
+			Signature:           z.Signature,
+		},
+	}
+
+	o.Hash = z.ComputeHash()
+	return o, nil
+}
+
+// NewZeroExOrder converts an order created from a 0x order back to the signed 0x order
+func NewZeroExOrder(o *Order) (*ZeroExOrder, error) {
+	if o.ZeroEx == nil {
+		return nil, errors.New("Order was not created from a 0x order")
+	}
+
+	return &ZeroExOrder{
+		ChainID:               o.ZeroEx.ChainID,
+		ExchangeAddress:       o.ExchangeAddress,
+		MakerAddress:          o.UserAddress,
+		FeeRecipientAddress:   o.ZeroEx.FeeRecipientAddress,
+		SenderAddress:         o.ZeroEx.SenderAddress,
+		MakerAssetAmount:      o.SellAmount,
+		TakerAssetAmount:      o.BuyAmount,
+		MakerFee:              o.MakeFee,
+		TakerFee:              o.TakeFee,
+		ExpirationTimeSeconds: o.Expires,
+		Salt:                  o.Nonce,
+		MakerAssetData:        EncodeERC20AssetData(o.SellToken),
+		TakerAssetData:        EncodeERC20AssetData(o.BuyToken),
+		MakerFeeAssetData:     o.ZeroEx.MakerFeeAssetData,
+		TakerFeeAssetData:     o.ZeroEx.TakerFeeAssetData,
+		Signature:             o.ZeroEx.Signature,
+	}, nil
+}
+
+// MarshalJSON implements the json.Marshal interface
+func (z *ZeroExOrder) MarshalJSON() ([]byte, error) {
+	r := ZeroExOrderRecord{
+		ChainID:               z.ChainID,
+		ExchangeAddress:       z.ExchangeAddress.Hex(),
+		MakerAddress:          z.MakerAddress.Hex(),
+		TakerAddress:          z.TakerAddress.Hex(),
+		FeeRecipientAddress:   z.FeeRecipientAddress.Hex(),
+		SenderAddress:         z.SenderAddress.Hex(),
+		MakerAssetAmount:      z.MakerAssetAmount.String(),
+		TakerAssetAmount:      z.TakerAssetAmount.String(),
+		MakerFee:              z.MakerFee.String(),
+		TakerFee:              z.TakerFee.String(),
+		ExpirationTimeSeconds: z.ExpirationTimeSeconds.String(),
+		Salt:                  z.Salt.String(),
+		MakerAssetData:        hexutil.Encode(z.MakerAssetData),
+		TakerAssetData:        hexutil.Encode(z.TakerAssetData),
+		Signature:             hexutil.Encode(z.Signature),
+	}
+
+	if z.Version() == 3 {
+		r.MakerFeeAssetData = hexutil.Encode(z.MakerFeeAssetData)
+		r.TakerFeeAssetData = hexutil.Encode(z.TakerFeeAssetData)
+	}
+
+	return json.Marshal(r)
+}
+
+// UnmarshalJSON implements the json.Unmarshal interface
+func (z *ZeroExOrder) UnmarshalJSON(b []byte) error {
+	r := ZeroExOrderRecord{}
+	err := json.Unmarshal(b, &r)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range []string{r.ExchangeAddress, r.MakerAddress, r.TakerAddress, r.FeeRecipientAddress, r.SenderAddress} {
+		if !common.IsHexAddress(a) {
+			return fmt.Errorf("Invalid address %q", a)
+		}
+	}
+
+	z.ChainID = r.ChainID
+	z.ExchangeAddress = common.HexToAddress(r.ExchangeAddress)
+	z.MakerAddress = common.HexToAddress(r.MakerAddress)
+	z.TakerAddress = common.HexToAddress(r.TakerAddress)
+	z.FeeRecipientAddress = common.HexToAddress(r.FeeRecipientAddress)
+	z.SenderAddress = common.HexToAddress(r.SenderAddress)
+
+	amounts := []struct {
+		value string
+		dst   **big.Int
+	}{
+		{r.MakerAssetAmount, &z.MakerAssetAmount},
+		{r.TakerAssetAmount, &z.TakerAssetAmount},
+		{r.MakerFee, &z.MakerFee},
+		{r.TakerFee, &z.TakerFee},
+		{r.ExpirationTimeSeconds, &z.ExpirationTimeSeconds},
+		{r.Salt, &z.Salt},
+	}
+
+	for _, a := range amounts {
+		v, ok := new(big.Int).SetString(a.value, 10)
+		if !ok {
+			return fmt.Errorf("Invalid amount %q", a.value)
+		}
+
+		*a.dst = v
+	}
+
+	data := []struct {
+		value string
+		dst   *[]byte
+	}{
+		{r.MakerAssetData, &z.MakerAssetData},
+		{r.TakerAssetData, &z.TakerAssetData},
+		{r.Signature, &z.Signature},
+	}
+
+	if z.Version() == 3 {
+		data = append(data, []struct {
+			value string
+			dst   *[]byte
+		}{
+			{r.MakerFeeAssetData, &z.MakerFeeAssetData},
+			{r.TakerFeeAssetData, &z.TakerFeeAssetData},
+		}...)
+	}
+
+	for _, d := range data {
+		v, err := hexutil.Decode(d.value)
+		if err != nil {
+			return fmt.Errorf("Invalid hex data %q: %v", d.value, err)
+		}
+
+		*d.dst = v
+	}
+
+	return nil
+}
+
+// Record returns the database and json representation of the fields
+func (z *ZeroExFields) Record() *ZeroExFieldsRecord {
+	r := &ZeroExFieldsRecord{
+		ChainID:             z.ChainID,
+		FeeRecipientAddress: z.FeeRecipientAddress.Hex(),
+		SenderAddress:       z.SenderAddress.Hex(),
+		Signature:           hexutil.Encode(z.Signature),
+	}
+
+	if z.ChainID != 0 {
+		r.MakerFeeAssetData = hexutil.Encode(z.MakerFeeAssetData)
+		r.TakerFeeAssetData = hexutil.Encode(z.TakerFeeAssetData)
+	}
+
+	return r
+}
+
+// NewZeroExFields returns the fields of their database or json representation
+func NewZeroExFields(r *ZeroExFieldsRecord) *ZeroExFields {
+	return &ZeroExFields{
+		ChainID:             r.ChainID,
+		FeeRecipientAddress: common.HexToAddress(r.FeeRecipientAddress),
+		SenderAddress:       common.HexToAddress(r.SenderAddress),
+		MakerFeeAssetData:   common.FromHex(r.MakerFeeAssetData),
+		TakerFeeAssetData:   common.FromHex(r.TakerFeeAssetData),
+		Signature:           common.FromHex(r.Signature),
+	}
+}
@@ -0,0 +1,183 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-test/deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestZeroExOrder(maker common.Address, chainID uint64) *ZeroExOrder {
+	z := &ZeroExOrder{
+		ChainID:               chainID,
+		ExchangeAddress:       common.HexToAddress("0x61935cbdd02287b511119ddb11aeb42f1593b7ef"),
+		MakerAddress:          maker,
+		FeeRecipientAddress:   common.HexToAddress("0xa258b39954cef5cb142fd567a46cddb31a670124"),
+		MakerAssetAmount:      big.NewInt(1000),
+		TakerAssetAmount:      big.NewInt(100),
+		MakerFee:              big.NewInt(0),
+		TakerFee:              big.NewInt(0),
+		ExpirationTimeSeconds: big.NewInt(1535760000),
+		Salt:                  big.NewInt(42),
+		MakerAssetData:        EncodeERC20AssetData(common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")),
+		TakerAssetData:        EncodeERC20AssetData(common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093")),
+	}
+
+	if chainID != 0 {
+		z.MakerFeeAssetData = []byte{}
+		z.TakerFeeAssetData = []byte{}
+	}
+
+	return z
+}
+
+func TestZeroExOrderSignature(t *testing.T) {
+	w := NewWallet()
+
+	for _, chainID := range []uint64{0, 1} {
+		z := newTestZeroExOrder(w.Address, chainID)
+
+		// eth_sign signature
+		err := z.Sign(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ok, err := z.VerifySignature()
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		// eip712 signature
+		sig, err := Sign(z.ComputeHash(), w.PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		z.Signature = EncodeZeroExSignature(sig, ZeroExSignatureEIP712)
+		ok, err = z.VerifySignature()
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		// the signature does not match a modified order
+		z.Salt = big.NewInt(43)
+		ok, _ = z.VerifySignature()
+		assert.False(t, ok)
+	}
+}
+
+func TestZeroExOrderVersions(t *testing.T) {
+	w := NewWallet()
+	v2 := newTestZeroExOrder(w.Address, 0)
+	v3 := newTestZeroExOrder(w.Address, 1)
+
+	assert.Equal(t, 2, v2.Version())
+	assert.Equal(t, 3, v3.Version())
+	assert.NotEqual(t, v2.ComputeHash(), v3.ComputeHash())
+
+	z := newTestZeroExOrder(w.Address, 3)
+	assert.NotEqual(t, v3.ComputeHash(), z.ComputeHash())
+}
+
+func TestZeroExOrderJSON(t *testing.T) {
+	w := NewWallet()
+
+	for _, chainID := range []uint64{0, 1} {
+		z := newTestZeroExOrder(w.Address, chainID)
+		if err := z.Sign(w); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := json.Marshal(z)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decoded := &ZeroExOrder{}
+		err = json.Unmarshal(b, decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := deep.Equal(z, decoded); diff != nil {
+			t.Errorf("\n%+v\nGot: \n%+v\n\n", z, decoded)
+		}
+	}
+}
+
+func TestZeroExOrderConversion(t *testing.T) {
+	w := NewWallet()
+	z := newTestZeroExOrder(w.Address, 1)
+	if err := z.Sign(w); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := z.ToOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, w.Address, o.UserAddress)
+	assert.Equal(t, common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"), o.SellToken)
+	assert.Equal(t, common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093"), o.BuyToken)
+	assert.Equal(t, big.NewInt(1000), o.SellAmount)
+	assert.Equal(t, big.NewInt(100), o.BuyAmount)
+	assert.Equal(t, z.ComputeHash(), o.Hash)
+	assert.Equal(t, o.Hash, o.ComputeHash())
+
+	ok, err := o.VerifySignature()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	converted, err := NewZeroExOrder(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(z, converted); diff != nil {
+		t.Errorf("\n%+v\nGot: \n%+v\n\n", z, converted)
+	}
+
+	// the 0x fields are kept in the json encoding used by the engine
+	b, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &Order{}
+	err = json.Unmarshal(b, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, o.Hash, decoded.ComputeHash())
+
+	_, err = NewZeroExOrder(&Order{})
+	assert.NotNil(t, err)
+}
+
+func TestZeroExOrderValidation(t *testing.T) {
+	w := NewWallet()
+
+	z := newTestZeroExOrder(w.Address, 0)
+	z.Sign(w)
+	z.TakerAddress = common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	_, err := z.ToOrder()
+	assert.NotNil(t, err)
+
+	// erc721 asset data
+	z = newTestZeroExOrder(w.Address, 0)
+	z.Sign(w)
+	z.MakerAssetData = append([]byte{0x02, 0x57, 0x17, 0x92}, make([]byte, 64)...)
+	_, err = z.ToOrder()
+	assert.NotNil(t, err)
+
+	// unsupported signature type
+	z = newTestZeroExOrder(w.Address, 0)
+	z.Sign(w)
+	z.Signature[65] = 0x04
+	_, err = z.ToOrder()
+	assert.NotNil(t, err)
+}
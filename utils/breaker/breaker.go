@@ -0,0 +1,223 @@
+// Package breaker implements the circuit breakers guarding the outbound dependencies of
+// the API (mongodb, redis, ethereum node). A breaker opens after a number of consecutive
+// failures and fails the calls fast until its cooldown expires, so that a hung dependency
+// degrades the features depending on it instead of blocking every request.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by the calls rejected while a breaker is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// ErrTimeout is returned by the calls that did not return before the breaker timeout
+var ErrTimeout = errors.New("call timed out")
+
+// States of a breaker. A half open breaker lets one call through to probe the dependency.
+const (
+	Closed   = "closed"
+	Open     = "open"
+	HalfOpen = "half-open"
+)
+
+// Settings of a breaker. Threshold is the number of consecutive failures opening the
+// breaker and Cooldown the time it stays open. Timeout is the maximum duration of a call,
+// calls are not bounded when it is 0.
+type Settings struct {
+	Threshold int
+	Cooldown  time.Duration
+	Timeout   time.Duration
+}
+
+// DefaultSettings are the settings of the breakers that are not configured
+var DefaultSettings = Settings{Threshold: 5, Cooldown: 30 * time.Second, Timeout: 10 * time.Second}
+
+// Breaker is a circuit breaker guarding the calls to a dependency
+type Breaker struct {
+	name      string
+	settings  Settings
+	isFailure func(error) bool
+	mutex     sync.Mutex
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+var registryMutex sync.Mutex
+var registry = make(map[string]*Breaker)
+
+// New returns a closed breaker
+func New(name string, s Settings) *Breaker {
+	return &Breaker{name: name, settings: s, state: Closed}
+}
+
+// Get returns the breaker of a dependency, created with the default settings if it was
+// not configured
+func Get(name string) *Breaker {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	b := registry[name]
+	if b == nil {
+		b = New(name, DefaultSettings)
+		registry[name] = b
+	}
+
+	return b
+}
+
+// Configure sets the settings of the breaker of a dependency
+func Configure(name string, s Settings) {
+	b := Get(name)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.settings = s
+}
+
+// All returns the breakers of the registry sorted by name
+func All() []*Breaker {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	breakers := []*Breaker{}
+	for _, b := range registry {
+		breakers = append(breakers, b)
+	}
+
+	sort.Slice(breakers, func(i, j int) bool { return breakers[i].name < breakers[j].name })
+	return breakers
+}
+
+// Name returns the name of the dependency guarded by the breaker
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Settings returns the settings of the breaker
+func (b *Breaker) Settings() Settings {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.settings
+}
+
+// State returns the state of the breaker
+func (b *Breaker) State() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == Open && time.Since(b.openedAt) >= b.settings.Cooldown {
+		return HalfOpen
+	}
+
+	return b.state
+}
+
+// SetFailureFilter sets the function deciding which errors are failures of the
+// dependency. By default every error is a failure, errors like "not found" should not
+// open the breaker.
+func (b *Breaker) SetFailureFilter(fn func(error) bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.isFailure = fn
+}
+
+// Call calls fn unless the breaker is open. The context passed to fn expires after the
+// breaker timeout, Call returns ErrTimeout if fn did not return by then (fn keeps running
+// in the background, it should use the context or bounded operations).
+func (b *Breaker) Call(fn func(ctx context.Context) error) error {
+	timeout, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	if timeout == 0 {
+		err = fn(context.Background())
+		b.record(err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ErrTimeout
+	}
+
+	b.record(err)
+	return err
+}
+
+// Guard calls fn unless the breaker is open, without bounding its duration. It is used
+// for the clients that can not be called concurrently (eg. a redis connection) and whose
+// operations are bounded by the client itself.
+func (b *Breaker) Guard(fn func() error) error {
+	if _, err := b.allow(); err != nil {
+		return err
+	}
+
+	err := fn()
+	b.record(err)
+	return err
+}
+
+// allow returns the call timeout, or ErrOpen if the call is rejected
+func (b *Breaker) allow() (time.Duration, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.settings.Cooldown {
+			return 0, ErrOpen
+		}
+
+		b.state = HalfOpen
+		b.probing = true
+	case HalfOpen:
+		if b.probing {
+			return 0, ErrOpen
+		}
+
+		b.probing = true
+	}
+
+	return b.settings.Timeout, nil
+}
+
+// record updates the state of the breaker with the result of a call
+func (b *Breaker) record(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	failed := err != nil && (err == ErrTimeout || b.isFailure == nil || b.isFailure(err))
+	b.probing = false
+
+	if !failed {
+		b.state = Closed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.settings.Threshold {
+		b.state = Open
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,132 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("connection refused")
+
+func fail(ctx context.Context) error {
+	return errDown
+}
+
+func succeed(ctx context.Context) error {
+	return nil
+}
+
+func TestBreakerOpens(t *testing.T) {
+	b := New("test", Settings{Threshold: 2, Cooldown: 50 * time.Millisecond})
+
+	if err := b.Call(fail); err != errDown {
+		t.Fatalf("Expected %v, got %v", errDown, err)
+	}
+
+	if b.State() != Closed {
+		t.Errorf("Expected breaker to be closed, got %s", b.State())
+	}
+
+	b.Call(fail)
+	if b.State() != Open {
+		t.Errorf("Expected breaker to be open, got %s", b.State())
+	}
+
+	if err := b.Call(succeed); err != ErrOpen {
+		t.Errorf("Expected %v, got %v", ErrOpen, err)
+	}
+
+	// the probe call closes the breaker once the cooldown expired
+	time.Sleep(60 * time.Millisecond)
+	if b.State() != HalfOpen {
+		t.Errorf("Expected breaker to be half open, got %s", b.State())
+	}
+
+	if err := b.Call(succeed); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if b.State() != Closed {
+		t.Errorf("Expected breaker to be closed, got %s", b.State())
+	}
+}
+
+func TestBreakerProbeFailure(t *testing.T) {
+	b := New("test", Settings{Threshold: 3, Cooldown: 50 * time.Millisecond})
+	for i := 0; i < 3; i++ {
+		b.Call(fail)
+	}
+
+	// a failed probe opens the breaker again
+	time.Sleep(60 * time.Millisecond)
+	b.Call(fail)
+	if b.State() != Open {
+		t.Errorf("Expected breaker to be open, got %s", b.State())
+	}
+}
+
+func TestBreakerTimeout(t *testing.T) {
+	b := New("test", Settings{Threshold: 1, Cooldown: time.Minute, Timeout: 10 * time.Millisecond})
+
+	err := b.Call(func(ctx context.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+
+	if err != ErrTimeout {
+		t.Errorf("Expected %v, got %v", ErrTimeout, err)
+	}
+
+	if b.State() != Open {
+		t.Errorf("Expected breaker to be open, got %s", b.State())
+	}
+}
+
+func TestBreakerFailureFilter(t *testing.T) {
+	errNotFound := errors.New("not found")
+	b := New("test", Settings{Threshold: 1, Cooldown: time.Minute})
+	b.SetFailureFilter(func(err error) bool { return err != errNotFound })
+
+	err := b.Call(func(ctx context.Context) error { return errNotFound })
+	if err != errNotFound {
+		t.Errorf("Expected %v, got %v", errNotFound, err)
+	}
+
+	if b.State() != Closed {
+		t.Errorf("Expected breaker to be closed, got %s", b.State())
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	Configure("redis", Settings{Threshold: 1, Cooldown: time.Minute})
+	Get("mongodb")
+
+	breakers := All()
+	if len(breakers) != 2 || breakers[0].Name() != "mongodb" || breakers[1].Name() != "redis" {
+		t.Errorf("Unexpected breakers %v", breakers)
+	}
+
+	Get("redis").Call(fail)
+	if Get("redis").State() != Open {
+		t.Errorf("Expected breaker to be open, got %s", Get("redis").State())
+	}
+}
+
+func TestBreakerGuard(t *testing.T) {
+	b := New("test", Settings{Threshold: 1, Cooldown: time.Minute, Timeout: time.Millisecond})
+
+	err := b.Guard(func() error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	b.Guard(func() error { return errDown })
+	if err := b.Guard(func() error { return nil }); err != ErrOpen {
+		t.Errorf("Expected %v, got %v", ErrOpen, err)
+	}
+}
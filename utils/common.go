@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -39,6 +40,49 @@ func GetOrderBookChannelID(bt, qt common.Address) string {
 	return strings.ToLower(fmt.Sprintf("%s::%s", bt.Hex(), qt.Hex()))
 }
 
+// IsHexHash returns true if the string is a 0x prefixed hex encoded 32 bytes hash
+func IsHexHash(s string) bool {
+	if len(s) != 66 || !strings.HasPrefix(s, "0x") {
+		return false
+	}
+
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
+
+// VersionAtLeast returns true if a dotted version (eg. "3.6.8") is greater or equal to
+// min. The non numeric suffix of a component (eg. "-rc1") is ignored.
+func VersionAtLeast(version, min string) bool {
+	v := strings.Split(version, ".")
+	for i, m := range strings.Split(min, ".") {
+		// the missing components of the version are 0
+		a := 0
+		if i < len(v) {
+			a = versionNumber(v[i])
+		}
+
+		if b := versionNumber(m); a != b {
+			return a > b
+		}
+	}
+
+	return true
+}
+
+// versionNumber returns the leading digits of a version component
+func versionNumber(s string) int {
+	n := 0
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			break
+		}
+
+		n = n*10 + int(c-'0')
+	}
+
+	return n
+}
+
 func PrintJSON(x interface{}) {
 	b, err := json.MarshalIndent(x, "", "  ")
 	if err != nil {
@@ -0,0 +1,192 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters used to derive key encryption keys from passphrases
+const (
+	scryptN      = 32768
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// Envelope holds a secret encrypted with a random data key. The data key is itself
+// encrypted with the key encryption key identified by KeyID. Rotating the key encryption
+// key only requires re-encrypting the data key, the ciphertext is left untouched.
+type Envelope struct {
+	KeyID        string `json:"keyId" bson:"keyId"`
+	EncryptedKey string `json:"encryptedKey" bson:"encryptedKey"`
+	Ciphertext   string `json:"ciphertext" bson:"ciphertext"`
+}
+
+// KeyRing holds the key encryption keys indexed by key id. New envelopes are always
+// sealed with the current key, older keys are only kept to open and rotate envelopes.
+type KeyRing struct {
+	current string
+	keys    map[string][]byte
+}
+
+var keyRing *KeyRing
+
+// SetKeyRing sets the key ring used by the system to encrypt private keys at rest
+func SetKeyRing(kr *KeyRing) {
+	keyRing = kr
+}
+
+// GetKeyRing returns the key ring used by the system. It returns nil if encryption at
+// rest has not been configured.
+func GetKeyRing() *KeyRing {
+	return keyRing
+}
+
+// NewKeyRing derives a key encryption key for each of the passphrases (indexed by key id)
+// and returns a key ring that seals new envelopes with the current key id.
+func NewKeyRing(current string, passphrases map[string]string) (*KeyRing, error) {
+	if passphrases[current] == "" {
+		return nil, fmt.Errorf("No passphrase for current key id %s", current)
+	}
+
+	kr := &KeyRing{current, make(map[string][]byte)}
+	for id, passphrase := range passphrases {
+		key, err := scrypt.Key([]byte(passphrase), []byte("amp::"+id), scryptN, scryptR, scryptP, scryptKeyLen)
+		if err != nil {
+			return nil, err
+		}
+
+		kr.keys[id] = key
+	}
+
+	return kr, nil
+}
+
+// CurrentKeyID returns the id of the key used to seal new envelopes
+func (kr *KeyRing) CurrentKeyID() string {
+	return kr.current
+}
+
+// Seal encrypts the plaintext with a new random data key and wraps the data key
+// with the current key encryption key
+func (kr *KeyRing) Seal(plaintext []byte) (*Envelope, error) {
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := encrypt(dataKey, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := encrypt(kr.keys[kr.current], dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		KeyID:        kr.current,
+		EncryptedKey: hex.EncodeToString(encryptedKey),
+		Ciphertext:   hex.EncodeToString(ciphertext),
+	}, nil
+}
+
+// Open unwraps the data key of the envelope and returns the decrypted plaintext
+func (kr *KeyRing) Open(e *Envelope) ([]byte, error) {
+	dataKey, err := kr.dataKey(e)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := hex.DecodeString(e.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	return decrypt(dataKey, ciphertext)
+}
+
+// Rotate re-wraps the data key of the envelope with the current key encryption key.
+// Envelopes that are already sealed with the current key are returned as is.
+func (kr *KeyRing) Rotate(e *Envelope) (*Envelope, error) {
+	if e.KeyID == kr.current {
+		return e, nil
+	}
+
+	dataKey, err := kr.dataKey(e)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := encrypt(kr.keys[kr.current], dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		KeyID:        kr.current,
+		EncryptedKey: hex.EncodeToString(encryptedKey),
+		Ciphertext:   e.Ciphertext,
+	}, nil
+}
+
+func (kr *KeyRing) dataKey(e *Envelope) ([]byte, error) {
+	key := kr.keys[e.KeyID]
+	if key == nil {
+		return nil, fmt.Errorf("Unknown key id %s", e.KeyID)
+	}
+
+	encryptedKey, err := hex.DecodeString(e.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return decrypt(key, encryptedKey)
+}
+
+// encrypt seals the plaintext with AES-GCM and prepends the random nonce to the ciphertext
+func encrypt(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// decrypt opens a ciphertext produced by encrypt
+func decrypt(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("Ciphertext too short")
+	}
+
+	nonce := ciphertext[:gcm.NonceSize()]
+	return gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
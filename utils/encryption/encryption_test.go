@@ -0,0 +1,64 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSealOpen(t *testing.T) {
+	kr, err := NewKeyRing("v1", map[string]string{"v1": "passphrase"})
+	if err != nil {
+		t.Fatalf("Could not create key ring: %v", err)
+	}
+
+	e, err := kr.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Could not seal secret: %v", err)
+	}
+
+	assert.Equal(t, "v1", e.KeyID)
+	assert.NotContains(t, e.Ciphertext, "secret")
+
+	plaintext, err := kr.Open(e)
+	if err != nil {
+		t.Fatalf("Could not open envelope: %v", err)
+	}
+
+	assert.Equal(t, "secret", string(plaintext))
+}
+
+func TestRotate(t *testing.T) {
+	old, err := NewKeyRing("v1", map[string]string{"v1": "old passphrase"})
+	if err != nil {
+		t.Fatalf("Could not create key ring: %v", err)
+	}
+
+	e, err := old.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Could not seal secret: %v", err)
+	}
+
+	kr, err := NewKeyRing("v2", map[string]string{"v1": "old passphrase", "v2": "new passphrase"})
+	if err != nil {
+		t.Fatalf("Could not create key ring: %v", err)
+	}
+
+	rotated, err := kr.Rotate(e)
+	if err != nil {
+		t.Fatalf("Could not rotate envelope: %v", err)
+	}
+
+	assert.Equal(t, "v2", rotated.KeyID)
+	assert.Equal(t, e.Ciphertext, rotated.Ciphertext)
+
+	plaintext, err := kr.Open(rotated)
+	if err != nil {
+		t.Fatalf("Could not open rotated envelope: %v", err)
+	}
+
+	assert.Equal(t, "secret", string(plaintext))
+
+	_, err = old.Open(rotated)
+	assert.Error(t, err)
+}
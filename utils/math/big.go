@@ -59,3 +59,26 @@ func IsSmallerThan(x, y *big.Int) bool {
 		return false
 	}
 }
+
+// ToDecimalString returns the decimal representation of x divided by 10^decimals,
+// rounded to the given number of digits after the decimal point. Decimals can be
+// negative, in which case x is multiplied by 10^-decimals.
+func ToDecimalString(x *big.Int, decimals int, precision int) string {
+	if x == nil {
+		return ""
+	}
+
+	r := new(big.Rat).SetInt(x)
+	if decimals > 0 {
+		r.Quo(r, new(big.Rat).SetInt(Exp10(decimals)))
+	} else if decimals < 0 {
+		r.Mul(r, new(big.Rat).SetInt(Exp10(-decimals)))
+	}
+
+	return r.FloatString(precision)
+}
+
+// Exp10 returns 10^n
+func Exp10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
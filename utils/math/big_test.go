@@ -0,0 +1,16 @@
+package math
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToDecimalString(t *testing.T) {
+	amount, _ := new(big.Int).SetString("1500000000000000000", 10)
+	assert.Equal(t, "1.50", ToDecimalString(amount, 18, 2))
+	assert.Equal(t, "0.00000230", ToDecimalString(big.NewInt(230), 8, 8))
+	assert.Equal(t, "2300", ToDecimalString(big.NewInt(23), -2, 0))
+	assert.Equal(t, "", ToDecimalString(nil, 18, 2))
+}
@@ -0,0 +1,424 @@
+// Package schema generates the json schema and the typescript types of the websocket
+// messages. The types are inferred from the json encoding of sample values so that the
+// custom json marshalers of the payloads are taken into account.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Directions of the websocket messages
+const (
+	ServerToClient = "server"
+	ClientToServer = "client"
+)
+
+// refPrefix marks the json encoding of a Ref in the samples
+const refPrefix = "$ref:"
+
+// Type is a named payload type. Sample is a value with all the fields of the type set,
+// Minimal is a value without the optional fields. The fields that are in the sample but
+// not in the minimal value are optional.
+type Type struct {
+	Name    string
+	Sample  interface{}
+	Minimal interface{}
+}
+
+// Message is a message type of a websocket channel. Data is the type of the data of the
+// message payload: a primitive (string, number, boolean, any), the name of a Type, or
+// an array of them (eg. "Trade[]"). Payload replaces the whole payload of the messages
+// that are not made of a type and data (eg. the subscriptions).
+type Message struct {
+	Channel   string
+	Direction string
+	Type      string
+	Data      string
+	Payload   string
+}
+
+// Ref is used in samples in place of a value of a named type (eg. the ticks of a chunk)
+type Ref string
+
+// MarshalJSON encodes the reference as a marked string
+func (r Ref) MarshalJSON() ([]byte, error) {
+	return json.Marshal(refPrefix + string(r))
+}
+
+// node is the inferred type of a json value
+type node struct {
+	kind     string
+	ref      string
+	fields   map[string]*node
+	optional map[string]bool
+	items    *node
+}
+
+// infer returns the type of a decoded json sample. The fields of the objects of the
+// sample that are not in the minimal value are optional.
+func infer(v interface{}, minimal interface{}) *node {
+	switch val := v.(type) {
+	case nil:
+		return &node{kind: "any"}
+	case bool:
+		return &node{kind: "boolean"}
+	case float64:
+		return &node{kind: "number"}
+	case string:
+		if strings.HasPrefix(val, refPrefix) {
+			return &node{kind: "ref", ref: strings.TrimPrefix(val, refPrefix)}
+		}
+
+		return &node{kind: "string"}
+	case []interface{}:
+		n := &node{kind: "array", items: &node{kind: "any"}}
+		if len(val) > 0 {
+			var min interface{}
+			if m, ok := minimal.([]interface{}); ok && len(m) > 0 {
+				min = m[0]
+			}
+
+			n.items = infer(val[0], min)
+		}
+
+		return n
+	case map[string]interface{}:
+		n := &node{kind: "object", fields: map[string]*node{}, optional: map[string]bool{}}
+		min, hasMin := minimal.(map[string]interface{})
+		for k, f := range val {
+			var m interface{}
+			if hasMin {
+				var ok bool
+				m, ok = min[k]
+				n.optional[k] = !ok
+			}
+
+			n.fields[k] = infer(f, m)
+		}
+
+		return n
+	}
+
+	return &node{kind: "any"}
+}
+
+// decode returns the decoded json encoding of a sample
+func decode(sample interface{}) (interface{}, error) {
+	if sample == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(sample)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	err = json.Unmarshal(b, &v)
+	return v, err
+}
+
+func inferType(t Type) (*node, error) {
+	sample, err := decode(t.Sample)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", t.Name, err)
+	}
+
+	minimal := sample
+	if t.Minimal != nil {
+		minimal, err = decode(t.Minimal)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", t.Name, err)
+		}
+	}
+
+	return infer(sample, minimal), nil
+}
+
+// parseExpr returns the type of a data expression (eg. "Trade[]")
+func parseExpr(expr string) *node {
+	if strings.HasSuffix(expr, "[]") {
+		return &node{kind: "array", items: parseExpr(strings.TrimSuffix(expr, "[]"))}
+	}
+
+	switch expr {
+	case "string", "number", "boolean", "any":
+		return &node{kind: expr}
+	}
+
+	return &node{kind: "ref", ref: expr}
+}
+
+// checkRefs returns an error if a type references an unknown type
+func checkRefs(n *node, names map[string]bool, context string) error {
+	switch n.kind {
+	case "ref":
+		if !names[n.ref] {
+			return fmt.Errorf("%s: unknown type %s", context, n.ref)
+		}
+	case "array":
+		return checkRefs(n.items, names, context)
+	case "object":
+		for _, f := range n.fields {
+			if err := checkRefs(f, names, context); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// compile infers the named types and checks the references of the types and messages
+func compile(types []Type, messages []Message) (map[string]*node, error) {
+	names := map[string]bool{}
+	for _, t := range types {
+		names[t.Name] = true
+	}
+
+	nodes := map[string]*node{}
+	for _, t := range types {
+		n, err := inferType(t)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkRefs(n, names, t.Name); err != nil {
+			return nil, err
+		}
+
+		nodes[t.Name] = n
+	}
+
+	for _, m := range messages {
+		context := m.Channel + "/" + m.Type
+		if m.Payload != "" {
+			if err := checkRefs(parseExpr(m.Payload), names, context); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if err := checkRefs(parseExpr(m.Data), names, context); err != nil {
+			return nil, err
+		}
+	}
+
+	return nodes, nil
+}
+
+func sortedKeys(m map[string]*node) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// typescript returns the typescript representation of a type
+func (n *node) typescript(indent string) string {
+	switch n.kind {
+	case "ref":
+		return n.ref
+	case "array":
+		items := n.items.typescript(indent)
+		if n.items.kind == "object" {
+			return "Array<" + items + ">"
+		}
+
+		return items + "[]"
+	case "object":
+		if len(n.fields) == 0 {
+			return "{}"
+		}
+
+		b := &bytes.Buffer{}
+		b.WriteString("{\n")
+		for _, k := range sortedKeys(n.fields) {
+			opt := ""
+			if n.optional[k] {
+				opt = "?"
+			}
+
+			fmt.Fprintf(b, "%s  %s%s: %s;\n", indent, k, opt, n.fields[k].typescript(indent+"  "))
+		}
+
+		b.WriteString(indent + "}")
+		return b.String()
+	}
+
+	return n.kind
+}
+
+// jsonSchema returns the json schema of a type
+func (n *node) jsonSchema() map[string]interface{} {
+	switch n.kind {
+	case "ref":
+		return map[string]interface{}{"$ref": "#/definitions/" + n.ref}
+	case "array":
+		return map[string]interface{}{"type": "array", "items": n.items.jsonSchema()}
+	case "object":
+		properties := map[string]interface{}{}
+		required := []string{}
+		for _, k := range sortedKeys(n.fields) {
+			properties[k] = n.fields[k].jsonSchema()
+			if !n.optional[k] {
+				required = append(required, k)
+			}
+		}
+
+		s := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+
+		return s
+	case "any":
+		return map[string]interface{}{}
+	}
+
+	return map[string]interface{}{"type": n.kind}
+}
+
+// TypeScript returns the typescript declarations of the types and messages
+func TypeScript(types []Type, messages []Message) ([]byte, error) {
+	nodes, err := compile(types, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString("// Code generated by cmd/wsschema. DO NOT EDIT.\n")
+
+	for _, t := range types {
+		n := nodes[t.Name]
+		if n.kind == "object" {
+			fmt.Fprintf(b, "\nexport interface %s %s\n", t.Name, n.typescript(""))
+		} else {
+			fmt.Fprintf(b, "\nexport type %s = %s;\n", t.Name, n.typescript(""))
+		}
+	}
+
+	channels := map[string]bool{}
+	for _, m := range messages {
+		channels[m.Channel] = true
+	}
+
+	quoted := []string{}
+	for c := range channels {
+		quoted = append(quoted, fmt.Sprintf("%q", c))
+	}
+
+	sort.Strings(quoted)
+	fmt.Fprintf(b, "\nexport type Channel = %s;\n", strings.Join(quoted, " | "))
+
+	b.WriteString(`
+export interface Payload<T extends string, D> {
+  type: T;
+  hash?: string;
+  data: D;
+}
+
+export interface Message<C extends Channel, P> {
+  channel: C;
+  payload: P;
+}
+`)
+
+	for _, direction := range []string{ServerToClient, ClientToServer} {
+		name := "ServerMessage"
+		if direction == ClientToServer {
+			name = "ClientMessage"
+		}
+
+		fmt.Fprintf(b, "\nexport type %s =\n", name)
+		for _, m := range messages {
+			if m.Direction != direction {
+				continue
+			}
+
+			payload := m.Payload
+			if payload == "" {
+				payload = fmt.Sprintf("Payload<%q, %s>", m.Type, parseExpr(m.Data).typescript(""))
+			}
+
+			fmt.Fprintf(b, "  | Message<%q, %s>\n", m.Channel, payload)
+		}
+
+		b.Truncate(b.Len() - 1)
+		b.WriteString(";\n")
+	}
+
+	return b.Bytes(), nil
+}
+
+// JSONSchema returns the json schema of the types and messages. The messages are
+// defined by the ServerMessage and ClientMessage definitions.
+func JSONSchema(types []Type, messages []Message) ([]byte, error) {
+	nodes, err := compile(types, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := map[string]interface{}{}
+	for name, n := range nodes {
+		definitions[name] = n.jsonSchema()
+	}
+
+	server := []interface{}{}
+	client := []interface{}{}
+	for _, m := range messages {
+		var payload map[string]interface{}
+		if m.Payload != "" {
+			payload = parseExpr(m.Payload).jsonSchema()
+		} else {
+			payload = map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"type": map[string]interface{}{"const": m.Type},
+					"hash": map[string]interface{}{"type": "string"},
+					"data": parseExpr(m.Data).jsonSchema(),
+				},
+				"required": []string{"type", "data"},
+			}
+		}
+
+		s := map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"channel": map[string]interface{}{"const": m.Channel},
+				"payload": payload,
+			},
+			"required": []string{"channel", "payload"},
+		}
+
+		if m.Direction == ClientToServer {
+			client = append(client, s)
+		} else {
+			server = append(server, s)
+		}
+	}
+
+	definitions["ServerMessage"] = map[string]interface{}{"oneOf": server}
+	definitions["ClientMessage"] = map[string]interface{}{"oneOf": client}
+
+	schema := map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       "AMP websocket messages",
+		"definitions": definitions,
+	}
+
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypeScript(t *testing.T) {
+	types := []Type{
+		{
+			Name:    "Item",
+			Sample:  map[string]interface{}{"id": "1", "amount": 1, "note": "x", "tags": []string{"a"}},
+			Minimal: map[string]interface{}{"id": "1", "amount": 1, "tags": []string{}},
+		},
+		{Name: "Page", Sample: map[string]interface{}{"items": []Ref{"Item"}}},
+	}
+
+	messages := []Message{
+		{Channel: "items", Direction: ServerToClient, Type: "INIT", Data: "Page"},
+		{Channel: "items", Direction: ClientToServer, Payload: "Item"},
+	}
+
+	ts, err := TypeScript(types, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"export interface Item {\n  amount: number;\n  id: string;\n  note?: string;\n  tags: string[];\n}",
+		"export interface Page {\n  items: Item[];\n}",
+		`export type Channel = "items";`,
+		`  | Message<"items", Payload<"INIT", Page>>;`,
+		`  | Message<"items", Item>;`,
+	} {
+		if !strings.Contains(string(ts), expected) {
+			t.Errorf("Expected %q in:\n%s", expected, ts)
+		}
+	}
+}
+
+func TestUnknownType(t *testing.T) {
+	messages := []Message{{Channel: "items", Direction: ServerToClient, Type: "INIT", Data: "Page[]"}}
+
+	_, err := TypeScript(nil, messages)
+	if err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+
+	_, err = JSONSchema(nil, messages)
+	if err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+}
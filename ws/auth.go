@@ -0,0 +1,119 @@
+package ws
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync/atomic"
+
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+)
+
+// authRequired is 1 when the connections must be authenticated to send orders and
+// cancels on the orders channel
+var authRequired int32
+
+// The challenges sent to the connections and the addresses the connections are
+// authenticated as are protected by connectionsMutex
+var connectionChallenges = make(map[*websocket.Conn]common.Hash)
+var connectionAddresses = make(map[*websocket.Conn]common.Address)
+
+// AuthError is the error sent when a connection is not allowed to act for a maker
+type AuthError struct {
+	Code    string `json:"Code"`
+	Message string `json:"Message"`
+}
+
+func (e *AuthError) Error() string {
+	return e.Message
+}
+
+// SetAuthRequired sets whether the connections must be authenticated to send orders
+// and cancels. The authenticated connections can only act for their own address either way.
+func SetAuthRequired(required bool) {
+	var v int32
+	if required {
+		v = 1
+	}
+
+	atomic.StoreInt32(&authRequired, v)
+}
+
+// isAuthRequired returns true if the connections must be authenticated to send orders
+func isAuthRequired() bool {
+	return atomic.LoadInt32(&authRequired) == 1
+}
+
+// NewChallenge returns a random challenge to be signed by the client to authenticate a
+// connection. It replaces the previous challenge of the connection.
+func NewChallenge(conn *websocket.Conn) (common.Hash, error) {
+	var challenge common.Hash
+	if _, err := rand.Read(challenge[:]); err != nil {
+		return common.Hash{}, err
+	}
+
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+
+	connectionChallenges[conn] = challenge
+	return challenge, nil
+}
+
+// TakeChallenge returns the challenge of a connection and forgets it, so that a signed
+// challenge can only be used once
+func TakeChallenge(conn *websocket.Conn) (common.Hash, bool) {
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+
+	challenge, ok := connectionChallenges[conn]
+	delete(connectionChallenges, conn)
+	return challenge, ok
+}
+
+// Authenticate records the address a connection is authenticated as
+func Authenticate(conn *websocket.Conn, addr common.Address) {
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+
+	connectionAddresses[conn] = addr
+}
+
+// AuthenticatedAddress returns the address a connection is authenticated as, if any
+func AuthenticatedAddress(conn *websocket.Conn) (common.Address, bool) {
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+
+	addr, ok := connectionAddresses[conn]
+	return addr, ok
+}
+
+// CheckMaker returns an error if a connection can not send the orders or the cancels of
+// a maker: the connection is authenticated as another address, or it is not
+// authenticated while authentication is required
+func CheckMaker(conn *websocket.Conn, maker common.Address) error {
+	addr, ok := AuthenticatedAddress(conn)
+	if !ok {
+		if isAuthRequired() {
+			return &AuthError{Code: aerrors.AuthenticationRequired.Code, Message: "The connection must be authenticated on the auth channel"}
+		}
+
+		return nil
+	}
+
+	if addr != maker {
+		return &AuthError{Code: aerrors.MakerMismatch.Code, Message: fmt.Sprintf("The connection is authenticated as %s, not as the maker %s", addr.Hex(), maker.Hex())}
+	}
+
+	return nil
+}
+
+// SendAuthMessage sends a websocket message on the auth channel
+func SendAuthMessage(conn *websocket.Conn, msgType string, p interface{}) {
+	SendMessage(conn, AuthChannel, msgType, p)
+}
+
+// SendAuthErrorMessage sends an error message on the auth channel
+func SendAuthErrorMessage(conn *websocket.Conn, p interface{}) {
+	SendAuthMessage(conn, "ERROR", p)
+}
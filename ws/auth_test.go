@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMaker(t *testing.T) {
+	conn, _ := newTestConnection(t)
+	initConnection(conn)
+	maker := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	other := common.HexToAddress("0xae55690d4b079460e6ac28aaa58c9ec7b73a7485")
+
+	// the connections are not required to authenticate by default
+	assert.Nil(t, CheckMaker(conn, maker))
+
+	SetAuthRequired(true)
+	defer SetAuthRequired(false)
+
+	err := CheckMaker(conn, maker)
+	if assert.IsType(t, &AuthError{}, err) {
+		assert.Equal(t, "AUTHENTICATION_REQUIRED", err.(*AuthError).Code)
+	}
+
+	Authenticate(conn, maker)
+	assert.Nil(t, CheckMaker(conn, maker))
+
+	err = CheckMaker(conn, other)
+	if assert.IsType(t, &AuthError{}, err) {
+		assert.Equal(t, "MAKER_MISMATCH", err.(*AuthError).Code)
+	}
+
+	// the authentication is forgotten with the connection
+	forgetConnection(conn)
+	_, ok := AuthenticatedAddress(conn)
+	assert.False(t, ok)
+}
+
+func TestTakeChallenge(t *testing.T) {
+	conn, _ := newTestConnection(t)
+	initConnection(conn)
+
+	_, ok := TakeChallenge(conn)
+	assert.False(t, ok)
+
+	first, err := NewChallenge(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := NewChallenge(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotEqual(t, first, second)
+
+	// a challenge replaces the previous one and is only used once
+	challenge, ok := TakeChallenge(conn)
+	assert.True(t, ok)
+	assert.Equal(t, second, challenge)
+
+	_, ok = TakeChallenge(conn)
+	assert.False(t, ok)
+}
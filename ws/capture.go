@@ -0,0 +1,237 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Directions of the captured websocket payloads
+const (
+	CaptureInbound  = "IN"
+	CaptureOutbound = "OUT"
+)
+
+// redactedValue replaces the signatures of the captured payloads
+const redactedValue = "REDACTED"
+
+// captureAddressFields are the fields of the payloads holding the address of an account
+var captureAddressFields = map[string]bool{
+	"userAddress": true,
+	"maker":       true,
+	"taker":       true,
+	"address":     true,
+}
+
+var captures = &payloadCaptures{
+	addresses: make(map[common.Address]time.Time),
+	pairs:     make(map[string]time.Time),
+}
+
+// payloadCaptures holds the addresses and the pairs whose websocket payloads are logged
+// in full, with their expiry. The captures are kept in memory: they only apply to the
+// connections served by the process that received the admin request. mutex protects
+// the maps.
+type payloadCaptures struct {
+	addresses map[common.Address]time.Time
+	pairs     map[string]time.Time
+	mutex     sync.RWMutex
+}
+
+// Capture is an address or a pair whose websocket payloads are logged until Expires
+type Capture struct {
+	Address *common.Address `json:"address,omitempty"`
+	Pair    string          `json:"pair,omitempty"`
+	Expires time.Time       `json:"expires"`
+}
+
+// CaptureAddress logs the websocket payloads sent by or to an address for a duration.
+// The payloads mentioning the address (eg. the trades it is the maker of) are logged too.
+func CaptureAddress(addr common.Address, d time.Duration) *Capture {
+	captures.mutex.Lock()
+	defer captures.mutex.Unlock()
+
+	captures.addresses[addr] = time.Now().Add(d)
+	return &Capture{Address: &addr, Expires: captures.addresses[addr]}
+}
+
+// CapturePair logs the websocket payloads of the orders, trades and subscriptions of a
+// pair for a duration
+func CapturePair(baseToken, quoteToken common.Address, d time.Duration) *Capture {
+	captures.mutex.Lock()
+	defer captures.mutex.Unlock()
+
+	pair := utils.GetPairKey(baseToken, quoteToken)
+	captures.pairs[pair] = time.Now().Add(d)
+	return &Capture{Pair: pair, Expires: captures.pairs[pair]}
+}
+
+// StopAddressCapture stops logging the payloads of an address. It returns false if the
+// address was not captured.
+func StopAddressCapture(addr common.Address) bool {
+	captures.mutex.Lock()
+	defer captures.mutex.Unlock()
+
+	_, ok := captures.addresses[addr]
+	delete(captures.addresses, addr)
+	return ok
+}
+
+// StopPairCapture stops logging the payloads of a pair. It returns false if the pair was
+// not captured.
+func StopPairCapture(baseToken, quoteToken common.Address) bool {
+	captures.mutex.Lock()
+	defer captures.mutex.Unlock()
+
+	pair := utils.GetPairKey(baseToken, quoteToken)
+	_, ok := captures.pairs[pair]
+	delete(captures.pairs, pair)
+	return ok
+}
+
+// GetCaptures returns the addresses and the pairs whose payloads are currently logged
+func GetCaptures() []*Capture {
+	captures.mutex.Lock()
+	defer captures.mutex.Unlock()
+
+	captures.expire(time.Now())
+	res := []*Capture{}
+	for addr, expires := range captures.addresses {
+		addr := addr
+		res = append(res, &Capture{Address: &addr, Expires: expires})
+	}
+
+	for pair, expires := range captures.pairs {
+		res = append(res, &Capture{Pair: pair, Expires: expires})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Expires.Before(res[j].Expires) })
+	return res
+}
+
+// expire removes the expired captures. The lock must be held by the caller.
+func (c *payloadCaptures) expire(now time.Time) {
+	for addr, expires := range c.addresses {
+		if !now.Before(expires) {
+			delete(c.addresses, addr)
+		}
+	}
+
+	for pair, expires := range c.pairs {
+		if !now.Before(expires) {
+			delete(c.pairs, pair)
+		}
+	}
+}
+
+// empty returns true if no address nor pair is captured, so that the payloads are only
+// encoded for the captures when needed
+func (c *payloadCaptures) empty() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return len(c.addresses) == 0 && len(c.pairs) == 0
+}
+
+// matches returns true if an address or a pair of a payload is captured at now
+func (c *payloadCaptures) matches(addresses []common.Address, pairs []string, now time.Time) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for _, addr := range addresses {
+		if expires, ok := c.addresses[addr]; ok && now.Before(expires) {
+			return true
+		}
+	}
+
+	for _, pair := range pairs {
+		if expires, ok := c.pairs[pair]; ok && now.Before(expires) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// capturePayload logs a websocket payload if one of its addresses or pairs is captured
+func capturePayload(direction, channel string, payload interface{}) {
+	if captures.empty() {
+		return
+	}
+
+	b, ok := capturedPayload(payload, time.Now())
+	if ok {
+		log.Printf("CAPTURE %s %s: %s", direction, channel, b)
+	}
+}
+
+// capturedPayload returns the json encoding of a payload with its signatures redacted if
+// one of its addresses or pairs is captured at now
+func capturedPayload(payload interface{}, now time.Time) ([]byte, bool) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, false
+	}
+
+	var decoded interface{}
+	err = json.Unmarshal(b, &decoded)
+	if err != nil {
+		return nil, false
+	}
+
+	s := &payloadScan{}
+	s.scan(decoded)
+	if !captures.matches(s.addresses, s.pairs, now) {
+		return nil, false
+	}
+
+	b, err = json.Marshal(decoded)
+	if err != nil {
+		return nil, false
+	}
+
+	return b, true
+}
+
+// payloadScan collects the addresses and the pairs of a decoded payload and redacts its
+// signatures
+type payloadScan struct {
+	addresses []common.Address
+	pairs     []string
+}
+
+func (s *payloadScan) scan(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if strings.HasSuffix(strings.ToLower(k), "signature") {
+				v[k] = redactedValue
+				continue
+			}
+
+			if str, ok := value.(string); ok && captureAddressFields[k] && common.IsHexAddress(str) {
+				s.addresses = append(s.addresses, common.HexToAddress(str))
+				continue
+			}
+
+			s.scan(value)
+		}
+
+		base, _ := v["baseToken"].(string)
+		quote, _ := v["quoteToken"].(string)
+		if common.IsHexAddress(base) && common.IsHexAddress(quote) {
+			s.pairs = append(s.pairs, utils.GetPairKey(common.HexToAddress(base), common.HexToAddress(quote)))
+		}
+
+	case []interface{}:
+		for _, value := range v {
+			s.scan(value)
+		}
+	}
+}
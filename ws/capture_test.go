@@ -0,0 +1,62 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapturedPayload(t *testing.T) {
+	defer func() {
+		captures.addresses = make(map[common.Address]time.Time)
+		captures.pairs = make(map[string]time.Time)
+	}()
+
+	maker := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	baseToken := common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498")
+	quoteToken := common.HexToAddress("0x12459c951127e0c374ff9105dda097662a027093")
+	payload := types.WebSocketPayload{
+		Type: "NEW_ORDER",
+		Data: map[string]interface{}{
+			"userAddress": maker.Hex(),
+			"baseToken":   baseToken.Hex(),
+			"quoteToken":  quoteToken.Hex(),
+			"signature":   map[string]interface{}{"V": 28, "R": "0x1", "S": "0x2"},
+		},
+	}
+
+	now := time.Now()
+	_, ok := capturedPayload(payload, now)
+	assert.False(t, ok)
+
+	CaptureAddress(maker, time.Minute)
+	b, ok := capturedPayload(payload, now)
+	assert.True(t, ok)
+
+	decoded := map[string]interface{}{}
+	err := json.Unmarshal(b, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := decoded["data"].(map[string]interface{})
+	assert.Equal(t, "REDACTED", data["signature"])
+	assert.Equal(t, maker.Hex(), data["userAddress"])
+
+	// the capture expires
+	_, ok = capturedPayload(payload, now.Add(2*time.Minute))
+	assert.False(t, ok)
+
+	assert.True(t, StopAddressCapture(maker))
+	assert.False(t, StopAddressCapture(maker))
+	assert.True(t, captures.empty())
+
+	CapturePair(baseToken, quoteToken, time.Minute)
+	_, ok = capturedPayload(payload, now)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(GetCaptures()))
+}
@@ -1,12 +1,15 @@
 package ws
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/websocket"
@@ -14,8 +17,14 @@ import (
 
 const TradeChannel = "trades"
 const OrderBookChannel = "order_book"
+const RawOrderBookChannel = "raw_order_book"
 const OrderChannel = "orders"
 const OHLCVChannel = "ohlcv"
+const UserChannel = "user"
+const BalanceChannel = "balances"
+const MarketChannel = "markets"
+const TickerChannel = "tickers"
+const AuthChannel = "auth"
 
 // gorilla websocket upgrader instance with configuration
 var upgrader = websocket.Upgrader{
@@ -26,66 +35,107 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-var connectionUnsubscribtions map[*websocket.Conn][]func(*websocket.Conn)
-var socketChannels map[string]func(interface{}, *websocket.Conn)
+// connectionsMutex protects connectionUnsubscribtions, connectionWriteLocks and the
+// authentication and rate limiting state of the connections.
+// channelsMutex protects socketChannels.
+var connectionsMutex sync.Mutex
+var channelsMutex sync.RWMutex
+
+var connectionUnsubscribtions = make(map[*websocket.Conn][]func(*websocket.Conn))
+var connectionWriteLocks = make(map[*websocket.Conn]*sync.Mutex)
+var socketChannels = make(map[string]func(interface{}, *websocket.Conn))
 
 // ConnectionEndpoint is the the handleFunc function for websocket connections
 // It handles incoming websocket messages and routes the message according to
 // channel parameter in channelMessage
 func ConnectionEndpoint(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	version, codec, err := negotiateVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	header := http.Header{}
+	header.Set(ProtocolVersionHeader, strconv.Itoa(version))
+
+	conn, err := upgrader.Upgrade(w, r, header)
 	if err != nil {
 		log.Println("==>" + err.Error())
 		return
 	}
 
+	// the connection is closed with the close code 1009 when a message exceeds the limit
+	conn.SetReadLimit(getMaxMessageSize())
 	initConnection(conn)
-	go func() {
-		// Recover in case of any panic in websocket. So that the app doesn't crash ===
-		defer func() {
-			if r := recover(); r != nil {
-				var ok bool
-				err, ok = r.(error)
-				if !ok {
-					err = fmt.Errorf("Panic in websocket: %v", r)
-				}
-				log.Fatal(err)
-			}
-		}()
+	setCodec(conn, codec)
+	startHeartbeat(conn)
+	go readMessages(conn)
+}
 
-		for {
-			messageType, p, err := conn.ReadMessage()
-			if err != nil {
-				conn.Close()
+// readMessages routes the messages read from a connection to the channel handlers until
+// the connection fails, eg. when it is closed by the client or misses the pongs
+func readMessages(conn *websocket.Conn) {
+	// Recover in case of any panic in websocket. So that the app doesn't crash ===
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(error)
+			if !ok {
+				err = fmt.Errorf("Panic in websocket: %v", r)
 			}
+			log.Fatal(err)
+		}
+	}()
 
-			if messageType != 1 {
-				return
+	for {
+		messageType, p, err := conn.ReadMessage()
+		if err != nil {
+			closeConnection(conn)
+			return
+		}
+
+		msg, err := getCodec(conn).Decode(messageType, p)
+		if err == errUnsupportedMessageType {
+			return
+		}
+
+		if err != nil {
+			log.Println("unmarshal to channelMessage <==>" + err.Error())
+			channel := ""
+			if msg != nil {
+				channel = msg.Channel
 			}
 
-			msg := types.WebSocketMessage{}
-			if err := json.Unmarshal(p, &msg); err != nil {
-				log.Println("unmarshal to channelMessage <==>" + err.Error())
-				SendMessage(conn, msg.Channel, "ERROR", err.Error())
+			SendMessage(conn, channel, "ERROR", aerrors.NewWSError(aerrors.InvalidMessage.New(nil)))
+			return
+		}
+
+		conn.SetCloseHandler(wsCloseHandler(conn))
+		capturePayload(CaptureInbound, msg.Channel, msg.Payload)
+
+		// the messages over the rate limits are not handled, the clients that keep
+		// flooding the connection are disconnected
+		if limitErr, abusive := allowMessage(conn, time.Now()); limitErr != nil {
+			SendMessage(conn, msg.Channel, "ERROR", limitErr)
+			if abusive {
+				closeAbusiveConnection(conn)
 				return
 			}
 
-			conn.SetCloseHandler(wsCloseHandler(conn))
+			continue
+		}
 
-			if socketChannels[msg.Channel] != nil {
-				go socketChannels[msg.Channel](msg.Payload, conn)
-			} else {
-				SendMessage(conn, msg.Channel, "ERROR", "INVALID_CHANNEL")
-			}
+		if fn := getChannelHandler(msg.Channel); fn != nil {
+			go handleChannelMessage(fn, msg.Channel, msg.Payload, conn)
+		} else {
+			SendMessage(conn, msg.Channel, "ERROR", aerrors.NewWSError(aerrors.InvalidChannel.New(aerrors.Params{"channel": msg.Channel})))
 		}
-	}()
+	}
 }
 
 // initConnection initializes connection in connectionUnsubscribtions map
 func initConnection(conn *websocket.Conn) {
-	if connectionUnsubscribtions == nil {
-		connectionUnsubscribtions = make(map[*websocket.Conn][]func(*websocket.Conn))
-	}
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
 
 	if connectionUnsubscribtions[conn] == nil {
 		connectionUnsubscribtions[conn] = make([]func(*websocket.Conn), 0)
@@ -105,21 +155,23 @@ func RegisterChannel(channel string, fn func(interface{}, *websocket.Conn)) erro
 		return errors.New("fn can not be nil")
 	}
 
-	ch := getChannelMap()
-	if ch[channel] != nil {
+	channelsMutex.Lock()
+	defer channelsMutex.Unlock()
+
+	if socketChannels[channel] != nil {
 		return fmt.Errorf("channel %s already registered", channel)
 	}
 
-	ch[channel] = fn
+	socketChannels[channel] = fn
 	return nil
 }
 
-// getChannelMap returns singleton map of channels with there handler functions
-func getChannelMap() map[string]func(interface{}, *websocket.Conn) {
-	if socketChannels == nil {
-		socketChannels = make(map[string]func(interface{}, *websocket.Conn))
-	}
-	return socketChannels
+// getChannelHandler returns the handler function registered for a channel
+func getChannelHandler(channel string) func(interface{}, *websocket.Conn) {
+	channelsMutex.RLock()
+	defer channelsMutex.RUnlock()
+
+	return socketChannels[channel]
 }
 
 // RegisterConnectionUnsubscribeHandler needs to be called whenever a connection subscribes to
@@ -127,23 +179,75 @@ func getChannelMap() map[string]func(interface{}, *websocket.Conn) {
 // At the time of connection closing the ConnectionUnsubscribeHandler handlers associated with
 // that connection are triggered.
 func RegisterConnectionUnsubscribeHandler(conn *websocket.Conn, fn func(*websocket.Conn)) {
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+
 	connectionUnsubscribtions[conn] = append(connectionUnsubscribtions[conn], fn)
 }
 
 // wsCloseHandler handles the closing of connection.
 // it triggers all the UnsubscribeHandler associated with the closing
-// connection in a separate go routine
+// connection in a separate go routine and forgets the connection
 func wsCloseHandler(conn *websocket.Conn) func(code int, text string) error {
 	return func(code int, text string) error {
-		for _, unsub := range connectionUnsubscribtions[conn] {
-			go unsub(conn)
-		}
+		forgetConnection(conn)
 		return nil
 	}
 }
 
+// closeConnection unsubscribes and closes a connection that failed without a close
+// message, eg. a dead client that stopped answering the pings
+func closeConnection(conn *websocket.Conn) {
+	forgetConnection(conn)
+	conn.Close()
+}
+
+// forgetConnection triggers the UnsubscribeHandlers of a connection in separate go
+// routines and forgets the connection. The handlers are only triggered once.
+func forgetConnection(conn *websocket.Conn) {
+	connectionsMutex.Lock()
+	unsubs := connectionUnsubscribtions[conn]
+	delete(connectionUnsubscribtions, conn)
+	delete(connectionWriteLocks, conn)
+	delete(connectionCodecs, conn)
+	delete(connectionChallenges, conn)
+	delete(connectionBuckets, conn)
+	delete(connectionViolations, conn)
+	if addr, ok := connectionAddresses[conn]; ok {
+		delete(connectionAddresses, conn)
+		forgetAddressBucket(addr)
+	}
+	connectionsMutex.Unlock()
+
+	for _, unsub := range unsubs {
+		go unsub(conn)
+	}
+}
+
+// getWriteLock returns the mutex preventing concurrent writes on a connection
+func getWriteLock(conn *websocket.Conn) *sync.Mutex {
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+
+	if connectionWriteLocks[conn] == nil {
+		connectionWriteLocks[conn] = &sync.Mutex{}
+	}
+
+	return connectionWriteLocks[conn]
+}
+
 // SendMessage constructs the message with proper structure to be sent over websocket
 func SendMessage(conn *websocket.Conn, channel string, msgType string, data interface{}, hash ...common.Hash) {
+	writeMessage(conn, channel, msgType, data, hash...)
+}
+
+// writeMessage sends a message and returns once it is written to the connection. The
+// connection is closed if the message can not be written.
+func writeMessage(conn *websocket.Conn, channel string, msgType string, data interface{}, hash ...common.Hash) error {
+	if conn == nil {
+		return errors.New("Empty connection object")
+	}
+
 	payload := types.WebSocketPayload{
 		Type: msgType,
 		Data: data,
@@ -158,10 +262,26 @@ func SendMessage(conn *websocket.Conn, channel string, msgType string, data inte
 		Payload: payload,
 	}
 
-	err := conn.WriteJSON(message)
+	metrics.sentOnChannel(channel)
+	capturePayload(CaptureOutbound, channel, message.Payload)
+
+	mt, b, err := getCodec(conn).Encode(&message)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	// gorilla connections support one concurrent writer only
+	lock := getWriteLock(conn)
+	lock.Lock()
+	err = conn.WriteMessage(mt, b)
+	lock.Unlock()
+
 	if err != nil {
 		conn.Close()
 	}
+
+	return err
 }
 
 // message := types.Message{
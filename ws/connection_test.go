@@ -0,0 +1,235 @@
+package ws
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+	"github.com/posener/wstest"
+	"github.com/stretchr/testify/assert"
+)
+
+// These tests are meant to be run with the race detector (go test -race ./ws).
+
+// newDrainedConnections returns the server side of n websocket connections. The
+// messages received by the client side are counted and discarded.
+func newDrainedConnections(t *testing.T, n int, received *int64) []*websocket.Conn {
+	conns := []*websocket.Conn{}
+
+	for i := 0; i < n; i++ {
+		server, client := newTestConnection(t)
+		initConnection(server)
+
+		go func() {
+			for {
+				_, _, err := client.ReadMessage()
+				if err != nil {
+					return
+				}
+
+				atomic.AddInt64(received, 1)
+			}
+		}()
+
+		conns = append(conns, server)
+	}
+
+	return conns
+}
+
+func TestConcurrentSubscriptions(t *testing.T) {
+	var received int64
+	conns := newDrainedConnections(t, 8, &received)
+
+	wg := sync.WaitGroup{}
+	for i, conn := range conns {
+		wg.Add(1)
+
+		go func(i int, conn *websocket.Conn) {
+			defer wg.Done()
+
+			id := fmt.Sprintf("race::%d", i%3)
+			for j := 0; j < 50; j++ {
+				GetOrderBookSocket().Subscribe(id, conn)
+				RegisterConnectionUnsubscribeHandler(conn, GetOrderBookSocket().UnsubscribeHandler(id))
+				GetOrderBookSocket().BroadcastMessage(id, "UPDATE", &types.WebSocketPayload{})
+
+				GetTradeSocket().Subscribe(id, conn)
+				RegisterConnectionUnsubscribeHandler(conn, GetTradeSocket().UnsubscribeHandler(id))
+				GetTradeSocket().BroadcastMessage(id, "UPDATE", &types.WebSocketPayload{})
+
+				GetOHLCVSocket().Subscribe(id, conn)
+				RegisterConnectionUnsubscribeHandler(conn, GetOHLCVSocket().UnsubscribeHandler(id))
+				GetOHLCVSocket().BroadcastOHLCV(id, &types.Tick{})
+
+				if j%2 == 0 {
+					GetOrderBookSocket().Unsubscribe(id, conn)
+					GetTradeSocket().Unsubscribe(id, conn)
+					GetOHLCVSocket().Unsubscribe(id, conn)
+				}
+			}
+		}(i, conn)
+	}
+
+	wg.Wait()
+
+	for _, conn := range conns {
+		wg.Add(1)
+
+		go func(conn *websocket.Conn) {
+			defer wg.Done()
+			wsCloseHandler(conn)(websocket.CloseNormalClosure, "")
+			conn.Close()
+		}(conn)
+	}
+
+	wg.Wait()
+
+	// the unsubscribe handlers run in their own goroutines
+	assert.True(t, waitUntil(time.Second, func() bool {
+		for i := 0; i < 3; i++ {
+			id := fmt.Sprintf("race::%d", i)
+			if len(GetOrderBookSocket().connections(id)) != 0 ||
+				len(GetTradeSocket().connections(id)) != 0 ||
+				len(GetOHLCVSocket().connections(id)) != 0 {
+				return false
+			}
+		}
+
+		return true
+	}), "connections should be unsubscribed when they are closed")
+
+	assert.True(t, atomic.LoadInt64(&received) > 0)
+}
+
+func TestConcurrentOrderConnections(t *testing.T) {
+	var received int64
+	conns := newDrainedConnections(t, 4, &received)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			conn := conns[i%len(conns)]
+			hash := common.BigToHash(big.NewInt(int64(i)))
+			ch := make(chan *types.WebSocketPayload)
+
+			RegisterOrderConnection(hash, &OrderConnection{Conn: conn, ReadChannel: ch})
+			RegisterConnectionUnsubscribeHandler(conn, OrderSocketUnsubscribeHandler(hash))
+			SendOrderMessage(GetOrderConnection(hash), "ORDER_ADDED", nil, hash)
+			assert.NotNil(t, GetOrderChannel(hash))
+
+			// the read channel can be closed concurrently several times
+			go CloseOrderReadChannel(hash)
+			CloseOrderReadChannel(hash)
+			assert.Nil(t, GetOrderChannel(hash))
+
+			OrderSocketUnsubscribeHandler(hash)(conn)
+			assert.Nil(t, GetOrderConnection(hash))
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, conn := range conns {
+		wsCloseHandler(conn)(websocket.CloseNormalClosure, "")
+		conn.Close()
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	var received int64
+	conns := newDrainedConnections(t, 1, &received)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			SendOrderBookUpdateMessage(conns[0], map[string]interface{}{})
+		}()
+	}
+
+	wg.Wait()
+
+	assert.True(t, waitUntil(time.Second, func() bool {
+		return atomic.LoadInt64(&received) == 100
+	}), "all messages should be received")
+
+	conns[0].Close()
+}
+
+func TestConcurrentChannelMessages(t *testing.T) {
+	var handled int64
+	err := RegisterChannel("race_test", func(p interface{}, conn *websocket.Conn) {
+		atomic.AddInt64(&handled, 1)
+		SendMessage(conn, "race_test", "ACK", p)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dialer := wstest.NewDialer(http.HandlerFunc(ConnectionEndpoint))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			client, _, err := dialer.Dial("ws://localhost/socket", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer client.Close()
+
+			for j := 0; j < 10; j++ {
+				msg := &types.WebSocketMessage{Channel: "race_test", Payload: types.WebSocketPayload{Type: "PING"}}
+				err := client.WriteJSON(msg)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+
+			for j := 0; j < 10; j++ {
+				_, _, err := client.ReadMessage()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int64(40), atomic.LoadInt64(&handled))
+}
+
+// waitUntil polls cond until it returns true or until the timeout expires
+func waitUntil(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return cond()
+}
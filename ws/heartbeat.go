@@ -0,0 +1,64 @@
+package ws
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultPingInterval is the interval between the pings sent to a connection and
+// DefaultPongDeadline the time a client has to answer a ping when they are not configured
+const (
+	DefaultPingInterval = 30 * time.Second
+	DefaultPongDeadline = 10 * time.Second
+)
+
+var pingInterval = int64(DefaultPingInterval)
+var pongDeadline = int64(DefaultPongDeadline)
+
+// SetHeartbeat sets the interval between the pings sent to the connections opened from
+// then on and the time a client has to answer a ping. A connection that misses a pong is
+// unsubscribed from its channels and closed.
+func SetHeartbeat(interval, deadline time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPingInterval
+	}
+
+	if deadline <= 0 {
+		deadline = DefaultPongDeadline
+	}
+
+	atomic.StoreInt64(&pingInterval, int64(interval))
+	atomic.StoreInt64(&pongDeadline, int64(deadline))
+}
+
+// getHeartbeat returns the interval between the pings and the deadline of the pongs
+func getHeartbeat() (time.Duration, time.Duration) {
+	return time.Duration(atomic.LoadInt64(&pingInterval)), time.Duration(atomic.LoadInt64(&pongDeadline))
+}
+
+// startHeartbeat pings a connection every interval until it is closed. The reads of the
+// connection fail if no pong is received within the deadline of a ping, the connection is
+// then closed by its read loop.
+func startHeartbeat(conn *websocket.Conn) {
+	interval, deadline := getHeartbeat()
+
+	conn.SetReadDeadline(time.Now().Add(interval + deadline))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(interval + deadline))
+	})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			// control messages can be written concurrently with the other messages
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(deadline))
+			if err != nil {
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,56 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestHeartbeat(t *testing.T) {
+	SetHeartbeat(20*time.Millisecond, 20*time.Millisecond)
+	defer SetHeartbeat(0, 0)
+
+	// the pings are answered while the client reads its connection
+	alive, client := newTestConnection(t)
+	aliveClosed := make(chan struct{})
+	initConnection(alive)
+	RegisterConnectionUnsubscribeHandler(alive, func(*websocket.Conn) { close(aliveClosed) })
+	startHeartbeat(alive)
+	go readMessages(alive)
+
+	go func() {
+		for {
+			if _, _, err := client.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// the client of a dead connection does not answer the pings
+	dead, _ := newTestConnection(t)
+	deadClosed := make(chan struct{})
+	initConnection(dead)
+	RegisterConnectionUnsubscribeHandler(dead, func(*websocket.Conn) { close(deadClosed) })
+	startHeartbeat(dead)
+	go readMessages(dead)
+
+	select {
+	case <-deadClosed:
+	case <-time.After(time.Second):
+		t.Error("Expected the dead connection to be unsubscribed")
+	}
+
+	select {
+	case <-aliveClosed:
+		t.Error("Expected the connection answering the pings to stay open")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	client.Close()
+	select {
+	case <-aliveClosed:
+	case <-time.After(time.Second):
+		t.Error("Expected the closed connection to be unsubscribed")
+	}
+}
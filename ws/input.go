@@ -0,0 +1,213 @@
+package ws
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultMaxMessageSize is the maximum size in bytes of the messages read from a
+// connection when it is not configured
+const DefaultMaxMessageSize = 256 * 1024
+
+// maxNumericLength is the number of digits of the largest uint256
+const maxNumericLength = 78
+
+// maxStringLength is the maximum length of the string fields of the payloads
+const maxStringLength = 256
+
+var maxMessageSize int64 = DefaultMaxMessageSize
+
+// numericRegexp matches the numeric strings of the payloads: unsigned decimal integers
+var numericRegexp = regexp.MustCompile(`^[0-9]+$`)
+
+// SetMaxMessageSize sets the maximum size in bytes of the messages read from the
+// connections opened from then on. The connection is closed when a message exceeds it.
+func SetMaxMessageSize(n int64) {
+	if n <= 0 {
+		n = DefaultMaxMessageSize
+	}
+
+	atomic.StoreInt64(&maxMessageSize, n)
+}
+
+// getMaxMessageSize returns the maximum size of the messages read from a connection
+func getMaxMessageSize() int64 {
+	return atomic.LoadInt64(&maxMessageSize)
+}
+
+// InputError is the error sent on a channel when the payload of a message is rejected.
+// Field is the path of the invalid field in the payload (eg. "bids.3.amount") and Max the
+// exceeded limit, if any.
+type InputError struct {
+	Code    string `json:"Code"`
+	Message string `json:"Message"`
+	Field   string `json:"Field,omitempty"`
+	Max     int    `json:"Max,omitempty"`
+}
+
+func (e *InputError) Error() string {
+	return e.Message
+}
+
+func invalidField(field, message string) *InputError {
+	return &InputError{Code: aerrors.InvalidField.Code, Message: fmt.Sprintf("Invalid %s: %s", fieldName(field), message), Field: field}
+}
+
+// fieldName returns the name of a field in the error messages
+func fieldName(field string) string {
+	if field == "" {
+		return "payload"
+	}
+
+	return field
+}
+
+// fieldPath returns the path of a field of an object or of an item of an array
+func fieldPath(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+
+	return parent + "." + field
+}
+
+// ItemPath returns the path of the item of an array field
+func ItemPath(field string, i int) string {
+	return fieldPath(field, strconv.Itoa(i))
+}
+
+// CheckObject returns the fields of a payload object, an InputError if the value is not
+// an object
+func CheckObject(data interface{}, field string) (map[string]interface{}, error) {
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, invalidField(field, "expected an object")
+	}
+
+	return obj, nil
+}
+
+// CheckArray returns the items of a payload array. It returns an InputError if the value
+// is not an array or if it holds more than max items. A missing array is empty.
+func CheckArray(data interface{}, field string, max int) ([]interface{}, error) {
+	if data == nil {
+		return []interface{}{}, nil
+	}
+
+	items, ok := data.([]interface{})
+	if !ok {
+		return nil, invalidField(field, "expected an array")
+	}
+
+	if len(items) > max {
+		return nil, &InputError{
+			Code:    aerrors.InvalidBatchSize.Code,
+			Message: fmt.Sprintf("Invalid %s: at most %d items are accepted", fieldName(field), max),
+			Field:   field,
+			Max:     max,
+		}
+	}
+
+	return items, nil
+}
+
+// CheckNumericStrings returns an InputError if one of the fields of an object is set and
+// is not an unsigned decimal integer in a string of at most 78 digits (eg. "1000")
+func CheckNumericStrings(obj map[string]interface{}, parent string, fields ...string) error {
+	for _, f := range fields {
+		v, ok := obj[f]
+		if !ok || v == nil {
+			continue
+		}
+
+		s, ok := v.(string)
+		if !ok || len(s) > maxNumericLength || !numericRegexp.MatchString(s) {
+			return invalidField(fieldPath(parent, f), "expected an unsigned integer string")
+		}
+	}
+
+	return nil
+}
+
+// CheckStrings returns an InputError if one of the fields of an object is set and is not
+// a string of at most 256 characters
+func CheckStrings(obj map[string]interface{}, parent string, fields ...string) error {
+	for _, f := range fields {
+		v, ok := obj[f]
+		if !ok || v == nil {
+			continue
+		}
+
+		s, ok := v.(string)
+		if !ok || len(s) > maxStringLength {
+			return invalidField(fieldPath(parent, f), "expected a string")
+		}
+	}
+
+	return nil
+}
+
+// CheckAddresses returns an InputError if one of the fields of an object is set and is
+// not an hex encoded address
+func CheckAddresses(obj map[string]interface{}, parent string, fields ...string) error {
+	for _, f := range fields {
+		v, ok := obj[f]
+		if !ok || v == nil {
+			continue
+		}
+
+		s, ok := v.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return invalidField(fieldPath(parent, f), "expected an address")
+		}
+	}
+
+	return nil
+}
+
+// CheckSignature returns an InputError if the signature field of an object is set and is
+// not an object with a numeric V and string R and S
+func CheckSignature(obj map[string]interface{}, parent string) error {
+	v, ok := obj["signature"]
+	if !ok || v == nil {
+		return nil
+	}
+
+	field := fieldPath(parent, "signature")
+	sig, err := CheckObject(v, field)
+	if err != nil {
+		return err
+	}
+
+	if n, ok := sig["V"].(float64); !ok || n < 0 || n > 255 {
+		return invalidField(fieldPath(field, "V"), "expected a number between 0 and 255")
+	}
+
+	for _, f := range []string{"R", "S"} {
+		if _, ok := sig[f].(string); !ok {
+			return invalidField(fieldPath(field, f), "expected a string")
+		}
+	}
+
+	return CheckStrings(sig, field, "R", "S")
+}
+
+// handleChannelMessage runs the handler of the channel of a message. A handler panicking
+// on an unexpected payload does not bring down the process, an error is sent instead.
+func handleChannelMessage(fn func(interface{}, *websocket.Conn), channel string, payload interface{}, conn *websocket.Conn) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic in %s channel handler: %v", channel, r)
+			SendMessage(conn, channel, "ERROR", &InputError{Code: aerrors.InvalidPayload.Code, Message: "Invalid payload"})
+		}
+	}()
+
+	fn(payload, conn)
+}
@@ -0,0 +1,94 @@
+package ws
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeInput(t *testing.T, s string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	return v
+}
+
+func TestCheckNumericStrings(t *testing.T) {
+	obj, err := CheckObject(decodeInput(t, `{"amount": "1000", "nonce": "0"}`), "")
+	assert.Nil(t, err)
+	assert.Nil(t, CheckNumericStrings(obj, "", "amount", "nonce", "price"))
+
+	invalid := []string{`1000`, `"-5"`, `"+5"`, `"1e18"`, `"0x10"`, `" 1"`, `""`, `"` + strings.Repeat("9", 79) + `"`}
+	for _, v := range invalid {
+		obj, _ := CheckObject(decodeInput(t, `{"amount": `+v+`}`), "")
+		err := CheckNumericStrings(obj, "bids.2", "amount")
+		if assert.NotNil(t, err, v) {
+			assert.Equal(t, "INVALID_FIELD", err.(*InputError).Code)
+			assert.Equal(t, "bids.2.amount", err.(*InputError).Field)
+		}
+	}
+}
+
+func TestCheckInputs(t *testing.T) {
+	_, err := CheckObject(decodeInput(t, `[]`), "")
+	assert.NotNil(t, err)
+
+	items, err := CheckArray(nil, "bids", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(items))
+
+	_, err = CheckArray(decodeInput(t, `[{}, {}, {}]`), "bids", 2)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, &InputError{
+			Code:    "INVALID_BATCH_SIZE",
+			Message: "Invalid bids: at most 2 items are accepted",
+			Field:   "bids",
+			Max:     2,
+		}, err)
+	}
+
+	obj, _ := CheckObject(decodeInput(t, `{"side": 1, "baseToken": "0x1"}`), "")
+	assert.NotNil(t, CheckStrings(obj, "", "side"))
+	assert.NotNil(t, CheckAddresses(obj, "", "baseToken"))
+
+	obj, _ = CheckObject(decodeInput(t, `{"signature": {"V": 28, "R": "0x1", "S": "0x2"}}`), "")
+	assert.Nil(t, CheckSignature(obj, ""))
+
+	obj, _ = CheckObject(decodeInput(t, `{"signature": {"V": "28", "R": "0x1", "S": "0x2"}}`), "")
+	err = CheckSignature(obj, "3")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "3.signature.V", err.(*InputError).Field)
+	}
+}
+
+func TestHandleChannelMessageRecovers(t *testing.T) {
+	server, client := newTestConnection(t)
+	defer client.Close()
+	defer server.Close()
+
+	handleChannelMessage(func(p interface{}, conn *websocket.Conn) {
+		_ = p.(map[string]interface{})["amount"].(string)
+	}, OrderChannel, decodeInput(t, `{"amount": 1}`), server)
+
+	_, p, err := client.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, string(p), "INVALID_PAYLOAD")
+}
+
+func TestSetMaxMessageSize(t *testing.T) {
+	defer SetMaxMessageSize(DefaultMaxMessageSize)
+
+	SetMaxMessageSize(1024)
+	assert.Equal(t, int64(1024), getMaxMessageSize())
+
+	SetMaxMessageSize(0)
+	assert.Equal(t, int64(DefaultMaxMessageSize), getMaxMessageSize())
+}
@@ -0,0 +1,91 @@
+package ws
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+var marketSocket = &MarketSocket{subscriptions: make(map[*websocket.Conn]bool)}
+
+// MarketSocket holds the connections subscribed to the catalog of the listed tokens and
+// pairs (listings, rule changes and delistings).
+// mutex protects the subscriptions map
+type MarketSocket struct {
+	subscriptions map[*websocket.Conn]bool
+	mutex         sync.RWMutex
+}
+
+// GetMarketSocket returns the socket of the markets channel
+func GetMarketSocket() *MarketSocket {
+	return marketSocket
+}
+
+// Subscribe registers a websocket connection to the catalog changes
+func (s *MarketSocket) Subscribe(conn *websocket.Conn) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.subscriptions[conn] {
+		metrics.subscribed(MarketChannel, "", 1)
+	}
+
+	s.subscriptions[conn] = true
+	return nil
+}
+
+// Unsubscribe removes a websocket connection from the catalog changes
+func (s *MarketSocket) Unsubscribe(conn *websocket.Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.subscriptions[conn] {
+		delete(s.subscriptions, conn)
+		metrics.subscribed(MarketChannel, "", -1)
+	}
+}
+
+// UnsubscribeHandler unsubscribes a connection from the catalog changes
+func (s *MarketSocket) UnsubscribeHandler() func(conn *websocket.Conn) {
+	return func(conn *websocket.Conn) {
+		s.Unsubscribe(conn)
+	}
+}
+
+// BroadcastMessage sends a message to all the connections subscribed to the catalog
+// changes
+func (s *MarketSocket) BroadcastMessage(msgType string, p interface{}) {
+	conns := s.connections()
+	metrics.sent(MarketChannel, "", len(conns))
+
+	go func() {
+		for _, conn := range conns {
+			SendMarketMessage(conn, msgType, p)
+		}
+	}()
+}
+
+// connections returns the connections subscribed to the catalog changes
+func (s *MarketSocket) connections() []*websocket.Conn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	conns := []*websocket.Conn{}
+	for conn, active := range s.subscriptions {
+		if active {
+			conns = append(conns, conn)
+		}
+	}
+
+	return conns
+}
+
+// SendMarketMessage sends a websocket message on the markets channel
+func SendMarketMessage(conn *websocket.Conn, msgType string, p interface{}) {
+	SendMessage(conn, MarketChannel, msgType, p)
+}
+
+// SendMarketErrorMessage sends an error message on the markets channel
+func SendMarketErrorMessage(conn *websocket.Conn, p interface{}) {
+	SendMarketMessage(conn, "ERROR", p)
+}
@@ -0,0 +1,45 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarketSocket(t *testing.T) {
+	server, client := newTestConnection(t)
+	defer client.Close()
+	defer server.Close()
+
+	socket := &MarketSocket{subscriptions: make(map[*websocket.Conn]bool)}
+	socket.Subscribe(server)
+	socket.BroadcastMessage("PAIR_LISTED", testPair().Market(testTime()))
+
+	_, p, err := client.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &struct {
+		Channel string `json:"channel"`
+		Payload struct {
+			Type string           `json:"type"`
+			Data types.MarketPair `json:"data"`
+		} `json:"payload"`
+	}{}
+
+	err = json.Unmarshal(p, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, MarketChannel, msg.Channel)
+	assert.Equal(t, "PAIR_LISTED", msg.Payload.Type)
+	assert.Equal(t, testPair().Reference(), msg.Payload.Data.Pair)
+
+	socket.Unsubscribe(server)
+	assert.Equal(t, 0, len(socket.connections()))
+}
@@ -0,0 +1,244 @@
+package ws
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsPeriod is the period over which the message rates are computed
+const metricsPeriod = time.Minute
+
+var metrics = &socketMetrics{
+	channels: make(map[string]*rateCounter),
+	streams:  make(map[streamKey]*streamMetrics),
+}
+
+// socketMetrics tracks the number of subscribers and the messages sent per channel
+// and per channel id (eg. the trades of a pair). mutex protects the maps.
+type socketMetrics struct {
+	channels map[string]*rateCounter
+	streams  map[streamKey]*streamMetrics
+	mutex    sync.Mutex
+}
+
+type streamKey struct {
+	channel string
+	id      string
+}
+
+type streamMetrics struct {
+	subscribers int
+	messages    rateCounter
+}
+
+// rateCounter counts messages and computes the message rate of the last complete period
+type rateCounter struct {
+	total       uint64
+	current     uint64
+	periodStart time.Time
+	rate        float64
+}
+
+func (c *rateCounter) add(n int, now time.Time) {
+	c.roll(now)
+	c.total += uint64(n)
+	c.current += uint64(n)
+}
+
+// roll starts a new period if the current one is over
+func (c *rateCounter) roll(now time.Time) {
+	if c.periodStart.IsZero() {
+		c.periodStart = now
+		return
+	}
+
+	elapsed := now.Sub(c.periodStart)
+	if elapsed < metricsPeriod {
+		return
+	}
+
+	// no message was counted during the last complete period
+	if elapsed >= 2*metricsPeriod {
+		c.current = 0
+	}
+
+	c.rate = float64(c.current) / metricsPeriod.Seconds()
+	c.current = 0
+	c.periodStart = now
+}
+
+// ChannelStats holds the metrics of a channel or of a channel id
+type ChannelStats struct {
+	Channel     string  `json:"channel"`
+	ID          string  `json:"id,omitempty"`
+	Pair        string  `json:"pair,omitempty"`
+	Subscribers int     `json:"subscribers"`
+	Messages    uint64  `json:"messages"`
+	MessageRate float64 `json:"messageRate"`
+}
+
+// PairStats holds the metrics of all the channels of a pair. Name is the name of the
+// pair, it is not known by the ws package and is set by the caller.
+type PairStats struct {
+	Pair        string  `json:"pair"`
+	Name        string  `json:"name,omitempty"`
+	Subscribers int     `json:"subscribers"`
+	Messages    uint64  `json:"messages"`
+	MessageRate float64 `json:"messageRate"`
+}
+
+// subscribed updates the number of subscribers of a channel id by delta
+func (m *socketMetrics) subscribed(channel, id string, delta int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s := m.stream(channel, id)
+	s.subscribers += delta
+}
+
+// sent records the messages sent to the subscribers of a channel id
+func (m *socketMetrics) sent(channel, id string, n int) {
+	if n == 0 {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.stream(channel, id).messages.add(n, time.Now())
+}
+
+// sentOnChannel records a message sent on a channel, including the messages sent to a
+// single connection (eg. INIT and ERROR messages)
+func (m *socketMetrics) sentOnChannel(channel string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.channels[channel] == nil {
+		m.channels[channel] = &rateCounter{}
+	}
+
+	m.channels[channel].add(1, time.Now())
+}
+
+func (m *socketMetrics) stream(channel, id string) *streamMetrics {
+	key := streamKey{channel, id}
+	if m.streams[key] == nil {
+		m.streams[key] = &streamMetrics{}
+	}
+
+	return m.streams[key]
+}
+
+// GetChannelStats returns the subscribers and messages of each channel
+func GetChannelStats() []*ChannelStats {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	now := time.Now()
+	channels := map[string]*ChannelStats{}
+	for channel, c := range metrics.channels {
+		c.roll(now)
+		channels[channel] = &ChannelStats{
+			Channel:     channel,
+			Messages:    c.total,
+			MessageRate: c.rate,
+		}
+	}
+
+	for key, s := range metrics.streams {
+		if channels[key.channel] == nil {
+			channels[key.channel] = &ChannelStats{Channel: key.channel}
+		}
+
+		channels[key.channel].Subscribers += s.subscribers
+	}
+
+	stats := []*ChannelStats{}
+	for _, s := range channels {
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Channel < stats[j].Channel })
+	return stats
+}
+
+// GetStreamStats returns the subscribers and broadcasted messages of each channel id
+func GetStreamStats() []*ChannelStats {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	now := time.Now()
+	stats := []*ChannelStats{}
+	for key, s := range metrics.streams {
+		s.messages.roll(now)
+		stats = append(stats, &ChannelStats{
+			Channel:     key.channel,
+			ID:          key.id,
+			Pair:        pairKey(key.id),
+			Subscribers: s.subscribers,
+			Messages:    s.messages.total,
+			MessageRate: s.messages.rate,
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Channel != stats[j].Channel {
+			return stats[i].Channel < stats[j].Channel
+		}
+
+		return stats[i].ID < stats[j].ID
+	})
+
+	return stats
+}
+
+// GetTopPairs returns the limit pairs with the highest message rate, all channels included
+func GetTopPairs(limit int) []*PairStats {
+	pairs := map[string]*PairStats{}
+	for _, s := range GetStreamStats() {
+		if s.Pair == "" {
+			continue
+		}
+
+		if pairs[s.Pair] == nil {
+			pairs[s.Pair] = &PairStats{Pair: s.Pair}
+		}
+
+		pairs[s.Pair].Subscribers += s.Subscribers
+		pairs[s.Pair].Messages += s.Messages
+		pairs[s.Pair].MessageRate += s.MessageRate
+	}
+
+	stats := []*PairStats{}
+	for _, p := range pairs {
+		stats = append(stats, p)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].MessageRate != stats[j].MessageRate {
+			return stats[i].MessageRate > stats[j].MessageRate
+		}
+
+		return stats[i].Subscribers > stats[j].Subscribers
+	})
+
+	if limit > 0 && len(stats) > limit {
+		stats = stats[:limit]
+	}
+
+	return stats
+}
+
+// pairKey returns the pair key (baseToken::quoteToken) of a channel id. The ohlcv
+// channel ids are suffixed with the tick duration and units.
+func pairKey(id string) string {
+	parts := strings.Split(id, "::")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	return parts[0] + "::" + parts[1]
+}
@@ -0,0 +1,72 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateCounter(t *testing.T) {
+	start := time.Date(2018, 9, 1, 0, 0, 0, 0, time.UTC)
+	c := &rateCounter{}
+
+	c.add(30, start)
+	c.add(30, start.Add(30*time.Second))
+	assert.Equal(t, uint64(60), c.total)
+	assert.Equal(t, float64(0), c.rate)
+
+	// the rate is computed at the end of the period
+	c.roll(start.Add(metricsPeriod))
+	assert.Equal(t, float64(1), c.rate)
+
+	// no message was sent during the last period
+	c.add(1, start.Add(3*metricsPeriod))
+	assert.Equal(t, float64(0), c.rate)
+	assert.Equal(t, uint64(61), c.total)
+}
+
+func TestSubscriptionMetrics(t *testing.T) {
+	id := "0xmetrics::0xtest::60::sec"
+	socket := GetOHLCVSocket()
+
+	var received int64
+	server := newDrainedConnections(t, 1, &received)[0]
+	socket.Subscribe(id, server)
+	// subscribing twice does not count the connection twice
+	socket.Subscribe(id, server)
+
+	stats := findStreamStats(OHLCVChannel, id)
+	if assert.NotNil(t, stats) {
+		assert.Equal(t, 1, stats.Subscribers)
+		assert.Equal(t, "0xmetrics::0xtest", stats.Pair)
+	}
+
+	socket.BroadcastOHLCV(id, nil)
+	assert.Equal(t, uint64(1), findStreamStats(OHLCVChannel, id).Messages)
+
+	top := GetTopPairs(0)
+	found := false
+	for _, p := range top {
+		if p.Pair == "0xmetrics::0xtest" {
+			found = true
+			assert.Equal(t, 1, p.Subscribers)
+		}
+	}
+
+	assert.True(t, found)
+
+	socket.Unsubscribe(id, server)
+	socket.Unsubscribe(id, server)
+	assert.Equal(t, 0, findStreamStats(OHLCVChannel, id).Subscribers)
+}
+
+func findStreamStats(channel, id string) *ChannelStats {
+	for _, s := range GetStreamStats() {
+		if s.Channel == channel && s.ID == id {
+			return s
+		}
+	}
+
+	return nil
+}
@@ -2,24 +2,24 @@ package ws
 
 import (
 	"errors"
+	"sync"
 
+	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/gorilla/websocket"
 )
 
-var ohlcvSocket *OHLCVSocket
+var ohlcvSocket = &OHLCVSocket{subscriptions: make(map[string]map[*websocket.Conn]bool)}
 
 // OHLCVSocket holds the map of subscribtions subscribed to pair channels
 // corresponding to the key/event they have subscribed to.
+// mutex protects the subscriptions map
 type OHLCVSocket struct {
 	subscriptions map[string]map[*websocket.Conn]bool
+	mutex         sync.RWMutex
 }
 
 // GetOHLCVSocket return singleton instance of PairSockets type struct
 func GetOHLCVSocket() *OHLCVSocket {
-	if ohlcvSocket == nil {
-		ohlcvSocket = &OHLCVSocket{make(map[string]map[*websocket.Conn]bool)}
-	}
-
 	return ohlcvSocket
 }
 
@@ -30,10 +30,17 @@ func (s *OHLCVSocket) Subscribe(channelId string, conn *websocket.Conn) error {
 		return errors.New("Empty connection object")
 	}
 
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	if s.subscriptions[channelId] == nil {
 		s.subscriptions[channelId] = make(map[*websocket.Conn]bool)
 	}
 
+	if !s.subscriptions[channelId][conn] {
+		metrics.subscribed(OHLCVChannel, channelId, 1)
+	}
+
 	s.subscriptions[channelId][conn] = true
 	return nil
 }
@@ -50,21 +57,41 @@ func (s *OHLCVSocket) UnsubscribeHandler(channelId string) func(conn *websocket.
 // subscribed to. It can be called on unsubscription message from user or due to some other reason by
 // system
 func (s *OHLCVSocket) Unsubscribe(channelId string, conn *websocket.Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	if s.subscriptions[channelId][conn] {
 		s.subscriptions[channelId][conn] = false
 		delete(s.subscriptions[channelId], conn)
+		metrics.subscribed(OHLCVChannel, channelId, -1)
 	}
 }
 
 // Broadcast Message streams message to all the subscribtions subscribed to the pair
 func (s *OHLCVSocket) BroadcastOHLCV(channelId string, p interface{}) error {
+	conns := s.connections(channelId)
+	metrics.sent(OHLCVChannel, channelId, len(conns))
+
+	for _, conn := range conns {
+		SendOHLCVMessage(conn, "UPDATE", p)
+	}
+
+	return nil
+}
+
+// connections returns the connections subscribed to a channel
+func (s *OHLCVSocket) connections(channelId string) []*websocket.Conn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	conns := []*websocket.Conn{}
 	for conn, status := range s.subscriptions[channelId] {
 		if status {
-			SendOHLCVMessage(conn, "UPDATE", p)
+			conns = append(conns, conn)
 		}
 	}
 
-	return nil
+	return conns
 }
 
 // SendMessage sends a message on the orderbook channel
@@ -85,6 +112,94 @@ func SendOHLCVUpdateMessage(conn *websocket.Conn, p interface{}) {
 	SendOHLCVMessage(conn, "UPDATE", p)
 }
 
+// SendOHLCVInitChunks sends the ticks of an ohlcv subscription in INIT messages of at
+// most chunkSize ticks followed by an END message. Each chunk is sent once the previous
+// one is written, so a slow client does not force the whole history to be buffered.
+// The chunks are numbered from 0, the END message holds the number of chunks and ticks.
+func SendOHLCVInitChunks(conn *websocket.Conn, ticks []*types.Tick, chunkSize int) error {
+	if chunkSize <= 0 {
+		return errors.New("Chunk size should be positive")
+	}
+
+	sequence, err := writeOHLCVChunks(conn, "", ticks, chunkSize, 0)
+	if err != nil {
+		return err
+	}
+
+	end := map[string]interface{}{
+		"chunks": sequence,
+		"ticks":  len(ticks),
+	}
+
+	return writeMessage(conn, OHLCVChannel, "END", end)
+}
+
+// OHLCVIntervalTicks is the ticks history of an interval of a bundled ohlcv subscription
+type OHLCVIntervalTicks struct {
+	Interval string
+	Ticks    []*types.Tick
+}
+
+// SendOHLCVBundleInitChunks sends the ticks of a subscription to several intervals of a
+// pair like SendOHLCVInitChunks: the chunks of each interval are tagged with the interval
+// and numbered in a single sequence, followed by one END message for the bundle.
+func SendOHLCVBundleInitChunks(conn *websocket.Conn, bundle []OHLCVIntervalTicks, chunkSize int) error {
+	if chunkSize <= 0 {
+		return errors.New("Chunk size should be positive")
+	}
+
+	sequence := 0
+	count := 0
+	intervals := []string{}
+	for _, b := range bundle {
+		var err error
+		sequence, err = writeOHLCVChunks(conn, b.Interval, b.Ticks, chunkSize, sequence)
+		if err != nil {
+			return err
+		}
+
+		count += len(b.Ticks)
+		intervals = append(intervals, b.Interval)
+	}
+
+	end := map[string]interface{}{
+		"chunks":    sequence,
+		"ticks":     count,
+		"intervals": intervals,
+	}
+
+	return writeMessage(conn, OHLCVChannel, "END", end)
+}
+
+// writeOHLCVChunks writes the ticks in INIT messages of at most chunkSize ticks numbered
+// from sequence, tagged with the interval if it is set. It returns the next sequence.
+func writeOHLCVChunks(conn *websocket.Conn, interval string, ticks []*types.Tick, chunkSize int, sequence int) (int, error) {
+	for start := 0; start < len(ticks); start += chunkSize {
+		end := start + chunkSize
+		if end > len(ticks) {
+			end = len(ticks)
+		}
+
+		chunk := map[string]interface{}{
+			"sequence": sequence,
+			"ticks":    ticks[start:end],
+		}
+
+		if interval != "" {
+			chunk["interval"] = interval
+		}
+
+		err := writeMessage(conn, OHLCVChannel, "INIT", chunk)
+		if err != nil {
+			return sequence, err
+		}
+
+		sequence++
+	}
+
+	return sequence, nil
+}
+
 // // SendErrorMessage is responsible for sending error messages on orderbook channel
 // func (ps *PairSockets) SendErrorMessage(conn *websocket.Conn, msg interface{}) {
 // 	ps.SendMessage(conn, "ERROR", msg)
@@ -0,0 +1,111 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendOHLCVInitChunks(t *testing.T) {
+	ticks := []*types.Tick{}
+	for i := 0; i < 5; i++ {
+		ticks = append(ticks, testTick())
+	}
+
+	server, client := newTestConnection(t)
+	initConnection(server)
+
+	done := make(chan []*types.WebSocketMessage)
+	go func() {
+		messages := []*types.WebSocketMessage{}
+		for {
+			_, raw, err := client.ReadMessage()
+			if err != nil {
+				t.Error(err)
+				break
+			}
+
+			msg := &types.WebSocketMessage{}
+			json.Unmarshal(raw, msg)
+			messages = append(messages, msg)
+			if msg.Payload.Type == "END" {
+				break
+			}
+		}
+
+		done <- messages
+	}()
+
+	err := SendOHLCVInitChunks(server, ticks, 2)
+	assert.Nil(t, err)
+
+	messages := <-done
+	if assert.Len(t, messages, 4) {
+		for i, msg := range messages[:3] {
+			assert.Equal(t, OHLCVChannel, msg.Channel)
+			assert.Equal(t, "INIT", msg.Payload.Type)
+
+			data := msg.Payload.Data.(map[string]interface{})
+			assert.Equal(t, float64(i), data["sequence"])
+		}
+
+		assert.Len(t, messages[2].Payload.Data.(map[string]interface{})["ticks"], 1)
+
+		end := messages[3].Payload.Data.(map[string]interface{})
+		assert.Equal(t, float64(3), end["chunks"])
+		assert.Equal(t, float64(5), end["ticks"])
+	}
+}
+
+func TestSendOHLCVBundleInitChunks(t *testing.T) {
+	bundle := []OHLCVIntervalTicks{
+		{Interval: "1min", Ticks: []*types.Tick{testTick(), testTick(), testTick()}},
+		{Interval: "1hour", Ticks: []*types.Tick{testTick()}},
+	}
+
+	server, client := newTestConnection(t)
+	initConnection(server)
+
+	done := make(chan []*types.WebSocketMessage)
+	go func() {
+		messages := []*types.WebSocketMessage{}
+		for {
+			_, raw, err := client.ReadMessage()
+			if err != nil {
+				t.Error(err)
+				break
+			}
+
+			msg := &types.WebSocketMessage{}
+			json.Unmarshal(raw, msg)
+			messages = append(messages, msg)
+			if msg.Payload.Type == "END" {
+				break
+			}
+		}
+
+		done <- messages
+	}()
+
+	err := SendOHLCVBundleInitChunks(server, bundle, 2)
+	assert.Nil(t, err)
+
+	messages := <-done
+	if assert.Len(t, messages, 4) {
+		intervals := []string{"1min", "1min", "1hour"}
+		for i, msg := range messages[:3] {
+			assert.Equal(t, "INIT", msg.Payload.Type)
+
+			data := msg.Payload.Data.(map[string]interface{})
+			assert.Equal(t, float64(i), data["sequence"])
+			assert.Equal(t, intervals[i], data["interval"])
+		}
+
+		end := messages[3].Payload.Data.(map[string]interface{})
+		assert.Equal(t, float64(3), end["chunks"])
+		assert.Equal(t, float64(4), end["ticks"])
+		assert.Equal(t, []interface{}{"1min", "1hour"}, end["intervals"])
+	}
+}
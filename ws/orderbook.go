@@ -2,28 +2,52 @@ package ws
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/Proofsuite/amp-matching-engine/types"
 	"github.com/gorilla/websocket"
 )
 
-var orderBookSocket *OrderBookSocket
+var orderBookSocket = newOrderBookSocket(OrderBookChannel)
+var rawOrderBookSocket = newOrderBookSocket(RawOrderBookChannel)
 
 // OrderBookSocket holds the map of subscribtions subscribed to pair channels
 // corresponding to the key/event they have subscribed to.
+// mutex protects the subscriptions and streams maps
 type OrderBookSocket struct {
+	channel       string
 	subscriptions map[string]map[*websocket.Conn]bool
+	streams       map[string]*orderBookStream
+	mutex         sync.RWMutex
 }
 
-// GetPairSockets return singleton instance of PairSockets type struct
-func GetOrderBookSocket() *OrderBookSocket {
-	if orderBookSocket == nil {
-		orderBookSocket = &OrderBookSocket{make(map[string]map[*websocket.Conn]bool)}
+// orderBookStream sequences the snapshots and updates of an orderbook channel. The
+// lock is held while a snapshot or an update is built and sent so that a subscriber
+// receives its snapshot before any update with a greater sequence number, and no
+// update with a smaller one.
+type orderBookStream struct {
+	sequence uint64
+	lock     sync.Mutex
+}
+
+func newOrderBookSocket(channel string) *OrderBookSocket {
+	return &OrderBookSocket{
+		channel:       channel,
+		subscriptions: make(map[string]map[*websocket.Conn]bool),
+		streams:       make(map[string]*orderBookStream),
 	}
+}
 
+// GetPairSockets return singleton instance of PairSockets type struct
+func GetOrderBookSocket() *OrderBookSocket {
 	return orderBookSocket
 }
 
+// GetRawOrderBookSocket returns the socket of the full orderbook channel
+func GetRawOrderBookSocket() *OrderBookSocket {
+	return rawOrderBookSocket
+}
+
 // Register handles the registration of connection to get
 // streaming data over the socker for any pair.
 // pair := utils.GetPairKey(bt, qt)
@@ -32,10 +56,17 @@ func (s *OrderBookSocket) Subscribe(channelId string, conn *websocket.Conn) erro
 		return errors.New("Empty connection object")
 	}
 
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	if s.subscriptions[channelId] == nil {
 		s.subscriptions[channelId] = make(map[*websocket.Conn]bool)
 	}
 
+	if !s.subscriptions[channelId][conn] {
+		metrics.subscribed(s.channel, channelId, 1)
+	}
+
 	s.subscriptions[channelId][conn] = true
 	return nil
 }
@@ -52,21 +83,112 @@ func (s *OrderBookSocket) UnsubscribeHandler(channelId string) func(conn *websoc
 // subscribed to. It can be called on unsubscription message from user or due to some other reason by
 // system
 func (s *OrderBookSocket) Unsubscribe(channelId string, conn *websocket.Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	if s.subscriptions[channelId][conn] {
 		s.subscriptions[channelId][conn] = false
 		delete(s.subscriptions[channelId], conn)
+		metrics.subscribed(s.channel, channelId, -1)
 	}
 }
 
 // Broadcast Message streams message to all the subscribtions subscribed to the pair
 func (s *OrderBookSocket) BroadcastMessage(channelId string, msgType string, p *types.WebSocketPayload) error {
+	conns := s.connections(channelId)
+	metrics.sent(s.channel, channelId, len(conns))
+
+	for _, conn := range conns {
+		SendMessage(conn, s.channel, msgType, p)
+	}
+
+	return nil
+}
+
+// SubscribeWithSnapshot subscribes a connection to a channel and sends it the INIT
+// message built by snapshot. The snapshot carries the sequence number of the last
+// update of the channel, the client applies the updates with a greater sequence.
+func (s *OrderBookSocket) SubscribeWithSnapshot(channelId string, conn *websocket.Conn, snapshot func() (map[string]interface{}, error)) error {
+	if conn == nil {
+		return errors.New("Empty connection object")
+	}
+
+	stream := s.stream(channelId)
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+
+	data, err := snapshot()
+	if err != nil {
+		return err
+	}
+
+	err = s.Subscribe(channelId, conn)
+	if err != nil {
+		return err
+	}
+
+	data["sequence"] = stream.sequence
+	SendMessage(conn, s.channel, "INIT", data)
+	return nil
+}
+
+// PublishUpdate builds an update of a channel with the given function and sends
+// it to the subscribers with the next sequence number of the channel. Nothing is sent
+// if the update is nil.
+func (s *OrderBookSocket) PublishUpdate(channelId string, update func() (map[string]interface{}, error)) error {
+	stream := s.stream(channelId)
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+
+	data, err := update()
+	if err != nil || data == nil {
+		return err
+	}
+
+	stream.sequence++
+	data["sequence"] = stream.sequence
+
+	conns := s.connections(channelId)
+	metrics.sent(s.channel, channelId, len(conns))
+
+	for _, conn := range conns {
+		SendMessage(conn, s.channel, "UPDATE", data)
+	}
+
+	return nil
+}
+
+// stream returns the stream of a channel
+func (s *OrderBookSocket) stream(channelId string) *orderBookStream {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.streams[channelId] == nil {
+		s.streams[channelId] = &orderBookStream{}
+	}
+
+	return s.streams[channelId]
+}
+
+// Subscribed returns true if a connection is subscribed to a channel
+func (s *OrderBookSocket) Subscribed(channelId string) bool {
+	return len(s.connections(channelId)) > 0
+}
+
+// connections returns the connections subscribed to a channel. The messages are
+// sent without holding the lock so that a slow connection does not block subscriptions.
+func (s *OrderBookSocket) connections(channelId string) []*websocket.Conn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	conns := []*websocket.Conn{}
 	for conn, status := range s.subscriptions[channelId] {
 		if status {
-			SendOrderBookMessage(conn, msgType, p)
+			conns = append(conns, conn)
 		}
 	}
 
-	return nil
+	return conns
 }
 
 // SendMessage sends a message on the orderbook channel
@@ -74,6 +196,11 @@ func SendOrderBookMessage(conn *websocket.Conn, msgType string, data interface{}
 	SendMessage(conn, OrderBookChannel, msgType, data)
 }
 
+// SendRawOrderBookErrorMessage sends an error message on the full orderbook channel
+func SendRawOrderBookErrorMessage(conn *websocket.Conn, data interface{}) {
+	SendMessage(conn, RawOrderBookChannel, "ERROR", data)
+}
+
 // SendErrorMessage sends
 func SendOrderBookErrorMessage(conn *websocket.Conn, data interface{}) {
 	SendOrderBookMessage(conn, "ERROR", data)
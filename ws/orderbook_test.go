@@ -0,0 +1,90 @@
+package ws
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderBookSnapshotSequence(t *testing.T) {
+	id := "sequence::test"
+	socket := GetOrderBookSocket()
+
+	// the book is the number of updates published so far
+	book := 0
+	mutex := sync.Mutex{}
+	read := func() (map[string]interface{}, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return map[string]interface{}{"book": book}, nil
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			socket.PublishUpdate(id, func() (map[string]interface{}, error) {
+				mutex.Lock()
+				book++
+				mutex.Unlock()
+				return read()
+			})
+		}
+	}()
+
+	server, client := newTestConnection(t)
+	initConnection(server)
+
+	// the connection does not buffer messages, they are read while being sent
+	messages := [][]byte{}
+	done := make(chan bool)
+	go func() {
+		for {
+			_, msg, err := client.ReadMessage()
+			if err != nil {
+				close(done)
+				return
+			}
+
+			messages = append(messages, msg)
+		}
+	}()
+
+	err := socket.SubscribeWithSnapshot(id, server, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wg.Wait()
+	socket.Unsubscribe(id, server)
+	server.Close()
+	<-done
+
+	var last float64 = -1
+	for _, msg := range messages {
+		m := &types.WebSocketMessage{}
+		if err := json.Unmarshal(msg, m); err != nil {
+			t.Fatal(err)
+		}
+
+		data := m.Payload.Data.(map[string]interface{})
+		seq := data["sequence"].(float64)
+
+		if last == -1 {
+			assert.Equal(t, "INIT", m.Payload.Type)
+		} else {
+			assert.Equal(t, "UPDATE", m.Payload.Type)
+			assert.Equal(t, last+1, seq, "updates should follow the snapshot without gaps")
+		}
+
+		// the snapshot and each update contain exactly the updates up to their sequence
+		assert.Equal(t, seq, data["book"])
+		last = seq
+	}
+
+	assert.NotEqual(t, float64(-1), last)
+}
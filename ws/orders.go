@@ -17,10 +17,19 @@ type OrderConnection struct {
 	Once        sync.Once
 }
 
-var orderConnections map[string]*OrderConnection
+// orderConnectionsMutex protects orderConnections and the Active flag of the order connections
+var orderConnectionsMutex sync.RWMutex
+var orderConnections = make(map[string]*OrderConnection)
 
 // GetOrderConn returns the connection associated with an order ID
 func GetOrderConnection(hash common.Hash) (conn *websocket.Conn) {
+	orderConnectionsMutex.RLock()
+	defer orderConnectionsMutex.RUnlock()
+
+	if orderConnections[hash.Hex()] == nil {
+		return nil
+	}
+
 	return orderConnections[hash.Hex()].Conn
 }
 
@@ -28,6 +37,9 @@ func GetOrderConnection(hash common.Hash) (conn *websocket.Conn) {
 func GetOrderChannel(h common.Hash) chan *types.WebSocketPayload {
 	hash := h.Hex()
 
+	orderConnectionsMutex.RLock()
+	defer orderConnectionsMutex.RUnlock()
+
 	if orderConnections[hash] == nil {
 		return nil
 	} else if !orderConnections[hash].Active {
@@ -41,6 +53,9 @@ func OrderSocketUnsubscribeHandler(h common.Hash) func(conn *websocket.Conn) {
 	hash := h.Hex()
 
 	return func(conn *websocket.Conn) {
+		orderConnectionsMutex.Lock()
+		defer orderConnectionsMutex.Unlock()
+
 		if orderConnections[hash] != nil {
 			orderConnections[hash] = nil
 			delete(orderConnections, hash)
@@ -53,9 +68,9 @@ func OrderSocketUnsubscribeHandler(h common.Hash) func(conn *websocket.Conn) {
 func RegisterOrderConnection(h common.Hash, conn *OrderConnection) {
 	hash := h.Hex()
 
-	if orderConnections == nil {
-		orderConnections = make(map[string]*OrderConnection)
-	}
+	orderConnectionsMutex.Lock()
+	defer orderConnectionsMutex.Unlock()
+
 	if orderConnections[hash] == nil {
 		conn.Active = true
 		orderConnections[hash] = conn
@@ -67,9 +82,20 @@ func RegisterOrderConnection(h common.Hash, conn *OrderConnection) {
 func CloseOrderReadChannel(h common.Hash) error {
 	hash := h.Hex()
 
-	orderConnections[hash].Once.Do(func() {
-		close(orderConnections[hash].ReadChannel)
-		orderConnections[hash].Active = false
+	orderConnectionsMutex.Lock()
+	defer orderConnectionsMutex.Unlock()
+
+	c := orderConnections[hash]
+	if c == nil {
+		return nil
+	}
+
+	c.Once.Do(func() {
+		if c.ReadChannel != nil {
+			close(c.ReadChannel)
+		}
+
+		c.Active = false
 	})
 
 	return nil
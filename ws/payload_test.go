@@ -0,0 +1,256 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Proofsuite/amp-matching-engine/engine"
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+	"github.com/posener/wstest"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The golden files in testdata hold the JSON messages received by clients for each
+// payload type. Run the tests with -update to regenerate them after an intended change.
+var update = flag.Bool("update", false, "update the golden files")
+
+// newTestConnection returns the server side and the client side of a websocket connection
+func newTestConnection(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	ch := make(chan *websocket.Conn, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+		}
+
+		ch <- conn
+	})
+
+	client, _, err := wstest.NewDialer(handler).Dial("ws://localhost/socket", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return <-ch, client
+}
+
+func testTime() time.Time {
+	return time.Date(2018, 9, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func testPair() *types.Pair {
+	return &types.Pair{
+		Name:              "ZRX/WETH",
+		Symbol:            "ZRX-WETH",
+		BaseTokenAddress:  common.HexToAddress("0x3"),
+		QuoteTokenAddress: common.HexToAddress("0x4"),
+	}
+}
+
+func testOrder() *types.Order {
+	return &types.Order{
+		ID:              bson.ObjectIdHex("5b8a3b1f2e3c4d5e6f708192"),
+		UserAddress:     common.HexToAddress("0x1"),
+		ExchangeAddress: common.HexToAddress("0x2"),
+		BuyToken:        common.HexToAddress("0x3"),
+		SellToken:       common.HexToAddress("0x4"),
+		BaseToken:       common.HexToAddress("0x3"),
+		QuoteToken:      common.HexToAddress("0x4"),
+		BuyAmount:       big.NewInt(1000),
+		SellAmount:      big.NewInt(100),
+		Status:          "OPEN",
+		Side:            "BUY",
+		Hash:            common.HexToHash("0xa"),
+		Signature:       &types.Signature{V: 28, R: common.HexToHash("0xb"), S: common.HexToHash("0xc")},
+		Price:           big.NewInt(10000000),
+		PricePoint:      big.NewInt(10000000),
+		Amount:          big.NewInt(1000),
+		FilledAmount:    big.NewInt(0),
+		Nonce:           big.NewInt(1),
+		Expires:         big.NewInt(10000),
+		MakeFee:         big.NewInt(0),
+		TakeFee:         big.NewInt(0),
+		PairID:          bson.ObjectIdHex("5b8a3b1f2e3c4d5e6f708193"),
+		PairName:        "ZRX/WETH",
+		CreatedAt:       testTime(),
+		UpdatedAt:       testTime(),
+	}
+}
+
+func testTrade() *types.Trade {
+	return &types.Trade{
+		ID:           bson.ObjectIdHex("5b8a3b1f2e3c4d5e6f708194"),
+		TakerOrderID: bson.ObjectIdHex("5b8a3b1f2e3c4d5e6f708195"),
+		MakerOrderID: bson.ObjectIdHex("5b8a3b1f2e3c4d5e6f708192"),
+		Taker:        common.HexToAddress("0x5"),
+		Maker:        common.HexToAddress("0x1"),
+		BaseToken:    common.HexToAddress("0x3"),
+		QuoteToken:   common.HexToAddress("0x4"),
+		OrderHash:    common.HexToHash("0xa"),
+		Hash:         common.HexToHash("0xd"),
+		PairName:     "ZRX/WETH",
+		TradeNonce:   big.NewInt(1),
+		Signature:    &types.Signature{V: 27, R: common.HexToHash("0xe"), S: common.HexToHash("0xf")},
+		Status:       "SUCCESS",
+		CreatedAt:    testTime(),
+		UpdatedAt:    testTime(),
+		Price:        big.NewInt(10000000),
+		PricePoint:   big.NewInt(10000000),
+		Side:         "BUY",
+		Amount:       big.NewInt(1000),
+	}
+}
+
+func testTick() *types.Tick {
+	return &types.Tick{
+		ID: types.TickID{
+			Pair:       "ZRX/WETH",
+			BaseToken:  common.HexToAddress("0x3").Hex(),
+			QuoteToken: common.HexToAddress("0x4").Hex(),
+		},
+		O:     10000000,
+		H:     12000000,
+		L:     9000000,
+		C:     11000000,
+		V:     5000,
+		Count: 3,
+		Ts:    testTime().Unix() * 1000,
+	}
+}
+
+func testOrderBook() map[string]interface{} {
+	return map[string]interface{}{
+		"pair": testPair().Reference(),
+		"asks": []*map[string]float64{{"price": 0.11, "volume": 10}},
+		"bids": []*map[string]float64{{"price": 0.1, "volume": 5}},
+	}
+}
+
+func testOrderBookUpdate() map[string]interface{} {
+	return map[string]interface{}{
+		"pair": testPair().Reference(),
+		"changes": []*types.OrderBookChange{
+			{Side: "SELL", Price: 0.12, Amount: 0},
+			{Side: "BUY", Price: 0.1, Amount: 7},
+		},
+		"sequence": 2,
+	}
+}
+
+func testFailedTrade() *types.Trade {
+	tr := testTrade()
+	tr.Status = "ERROR"
+	tr.ErrorCode = aerrors.MakerInsufficientBalance
+	tr.FailureReason = aerrors.GetExchangeError(tr.ErrorCode).Cause
+	return tr
+}
+
+func TestPayloadGoldenFiles(t *testing.T) {
+	o := testOrder()
+
+	tests := []struct {
+		golden string
+		send   func(conn *websocket.Conn)
+	}{
+		{"orders_order_added", func(conn *websocket.Conn) {
+			SendOrderMessage(conn, "ORDER_ADDED", o, o.Hash)
+		}},
+		{"orders_order_cancelled", func(conn *websocket.Conn) {
+			SendOrderMessage(conn, "ORDER_CANCELLED", o, o.Hash)
+		}},
+		{"orders_request_signature", func(conn *websocket.Conn) {
+			res := &engine.Response{
+				Order:          o,
+				Trades:         []*types.Trade{testTrade()},
+				FillStatus:     engine.FULL,
+				MatchingOrders: []*engine.FillOrder{{Amount: big.NewInt(1000), Order: testOrder()}},
+			}
+
+			SendOrderMessage(conn, "REQUEST_SIGNATURE", res, o.Hash)
+		}},
+		{"orders_trade_tx_error", func(conn *websocket.Conn) {
+			tr := testFailedTrade()
+			payload := map[string]interface{}{
+				"trade":     tr,
+				"errorCode": tr.ErrorCode,
+				"reason":    tr.FailureReason,
+				"error":     aerrors.GetExchangeError(tr.ErrorCode),
+			}
+
+			SendOrderMessage(conn, "TRADE_TX_ERROR", payload, o.Hash)
+		}},
+		{"orders_error", func(conn *websocket.Conn) {
+			SendOrderErrorMessage(conn, "UNKNOWN_MESSAGE", o.Hash)
+		}},
+		{"order_book_init", func(conn *websocket.Conn) {
+			SendOrderBookInitMessage(conn, testOrderBook())
+		}},
+		{"order_book_update", func(conn *websocket.Conn) {
+			SendOrderBookUpdateMessage(conn, testOrderBookUpdate())
+		}},
+		{"order_book_error", func(conn *websocket.Conn) {
+			SendOrderBookErrorMessage(conn, &aerrors.WSError{
+				Code:    "UNABLE_TO_REGISTER",
+				Message: "UNABLE_TO_REGISTER Empty connection object",
+			})
+		}},
+		{"trades_init", func(conn *websocket.Conn) {
+			SendTradeInitMessage(conn, []*types.Trade{testTrade()})
+		}},
+		{"trades_update", func(conn *websocket.Conn) {
+			SendTradeUpdateMessage(conn, testTrade())
+		}},
+		{"ohlcv_init", func(conn *websocket.Conn) {
+			SendOHLCVInitMesssage(conn, []*types.Tick{testTick()})
+		}},
+		{"ohlcv_update", func(conn *websocket.Conn) {
+			SendOHLCVUpdateMessage(conn, testTick())
+		}},
+		{"invalid_channel_error", func(conn *websocket.Conn) {
+			SendMessage(conn, "unknown", "ERROR", "INVALID_CHANNEL")
+		}},
+	}
+
+	for _, test := range tests {
+		server, client := newTestConnection(t)
+		test.send(server)
+
+		_, msg, err := client.ReadMessage()
+		if err != nil {
+			t.Fatalf("%s: could not read message: %v", test.golden, err)
+		}
+
+		path := filepath.Join("testdata", test.golden+".golden")
+		if *update {
+			indented := &bytes.Buffer{}
+			json.Indent(indented, msg, "", "  ")
+			indented.WriteString("\n")
+
+			err := ioutil.WriteFile(path, indented.Bytes(), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		expected, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: could not read golden file: %v", test.golden, err)
+		}
+
+		assert.JSONEq(t, string(expected), string(msg), test.golden)
+
+		client.Close()
+		server.Close()
+	}
+}
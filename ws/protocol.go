@@ -0,0 +1,116 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/gorilla/websocket"
+)
+
+// ProtocolVersionParam is the query parameter of the websocket handshake setting the
+// protocol version of the connection (eg. /socket?version=1). The connections that do
+// not set it use the legacy version.
+const ProtocolVersionParam = "version"
+
+// ProtocolVersionHeader is the handshake response header holding the version used
+const ProtocolVersionHeader = "X-Protocol-Version"
+
+// LegacyProtocolVersion is the version of the json messages with string encoded amounts
+const LegacyProtocolVersion = 1
+
+var errUnsupportedMessageType = errors.New("Unsupported websocket message type")
+
+// Codec encodes and decodes the websocket messages of a protocol version. Encode returns
+// the websocket message type (text or binary) and the encoded message.
+type Codec interface {
+	Encode(m *types.WebSocketMessage) (int, []byte, error)
+	Decode(messageType int, data []byte) (*types.WebSocketMessage, error)
+}
+
+// codecsMutex protects codecs. The codecs of the connections are protected by connectionsMutex.
+var codecsMutex sync.RWMutex
+var codecs = map[int]Codec{LegacyProtocolVersion: jsonCodec{}}
+var connectionCodecs = make(map[*websocket.Conn]Codec)
+
+// RegisterCodec adds a protocol version. Clients can then select it in the handshake,
+// so new payload encodings can be served next to the previous ones during migrations.
+func RegisterCodec(version int, c Codec) error {
+	if c == nil {
+		return errors.New("Codec can not be nil")
+	}
+
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+
+	if codecs[version] != nil {
+		return fmt.Errorf("protocol version %d already registered", version)
+	}
+
+	codecs[version] = c
+	return nil
+}
+
+// negotiateVersion returns the protocol version requested in the handshake and its codec
+func negotiateVersion(r *http.Request) (int, Codec, error) {
+	version := LegacyProtocolVersion
+	if v := r.URL.Query().Get(ProtocolVersionParam); v != "" {
+		var err error
+		version, err = strconv.Atoi(v)
+		if err != nil {
+			return 0, nil, fmt.Errorf("Invalid protocol version %q", v)
+		}
+	}
+
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	c := codecs[version]
+	if c == nil {
+		return 0, nil, fmt.Errorf("Unsupported protocol version %d", version)
+	}
+
+	return version, c, nil
+}
+
+// setCodec sets the codec used by a connection
+func setCodec(conn *websocket.Conn, c Codec) {
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+
+	connectionCodecs[conn] = c
+}
+
+// getCodec returns the codec of a connection, the legacy codec by default
+func getCodec(conn *websocket.Conn) Codec {
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+
+	if c := connectionCodecs[conn]; c != nil {
+		return c
+	}
+
+	return jsonCodec{}
+}
+
+// jsonCodec is the codec of the legacy protocol version: json text messages
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(m *types.WebSocketMessage) (int, []byte, error) {
+	b, err := json.Marshal(m)
+	return websocket.TextMessage, b, err
+}
+
+func (jsonCodec) Decode(messageType int, data []byte) (*types.WebSocketMessage, error) {
+	if messageType != websocket.TextMessage {
+		return nil, errUnsupportedMessageType
+	}
+
+	m := &types.WebSocketMessage{}
+	err := json.Unmarshal(data, m)
+	return m, err
+}
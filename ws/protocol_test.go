@@ -0,0 +1,60 @@
+package ws
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// upperCodec is a test protocol version sending binary messages
+type upperCodec struct {
+	jsonCodec
+}
+
+func (upperCodec) Encode(m *types.WebSocketMessage) (int, []byte, error) {
+	_, b, err := jsonCodec{}.Encode(m)
+	return websocket.BinaryMessage, b, err
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	version, codec, err := negotiateVersion(httptest.NewRequest("GET", "/socket", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, LegacyProtocolVersion, version)
+	assert.Equal(t, jsonCodec{}, codec)
+
+	_, _, err = negotiateVersion(httptest.NewRequest("GET", "/socket?version=99", nil))
+	assert.NotNil(t, err)
+
+	_, _, err = negotiateVersion(httptest.NewRequest("GET", "/socket?version=abc", nil))
+	assert.NotNil(t, err)
+
+	assert.Nil(t, RegisterCodec(99, upperCodec{}))
+	assert.NotNil(t, RegisterCodec(99, upperCodec{}))
+
+	version, codec, err = negotiateVersion(httptest.NewRequest("GET", "/socket?version=99", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 99, version)
+	assert.Equal(t, upperCodec{}, codec)
+}
+
+func TestConnectionCodec(t *testing.T) {
+	server, client := newTestConnection(t)
+	initConnection(server)
+	setCodec(server, upperCodec{})
+
+	done := make(chan int)
+	go func() {
+		mt, _, err := client.ReadMessage()
+		assert.Nil(t, err)
+		done <- mt
+	}()
+
+	SendMessage(server, TradeChannel, "UPDATE", nil)
+	assert.Equal(t, websocket.BinaryMessage, <-done)
+
+	wsCloseHandler(server)(websocket.CloseNormalClosure, "")
+	assert.Equal(t, jsonCodec{}, getCodec(server))
+}
@@ -0,0 +1,171 @@
+package ws
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	aerrors "github.com/Proofsuite/amp-matching-engine/errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+)
+
+// RateLimits are the token buckets applied to the messages sent by the clients. A
+// connection can send ConnectionRate messages per second with bursts of ConnectionBurst
+// messages, and the connections authenticated as an address share the AddressRate and
+// AddressBurst of the address. A rate of 0 is not limited. A connection is closed after MaxViolations
+// consecutive rejected messages, 0 never closes it.
+type RateLimits struct {
+	ConnectionRate  float64
+	ConnectionBurst int
+	AddressRate     float64
+	AddressBurst    int
+	MaxViolations   int
+}
+
+// RateLimitError is the error sent on the channel of a message rejected by the rate limits
+type RateLimitError struct {
+	Code    string  `json:"Code"`
+	Message string  `json:"Message"`
+	Rate    float64 `json:"Rate"`
+}
+
+func (e *RateLimitError) Error() string {
+	return e.Message
+}
+
+var rateLimits atomic.Value
+
+// The buckets and the rejected messages of the connections are protected by
+// connectionsMutex. The buckets of the addresses are shared by their connections.
+var connectionBuckets = make(map[*websocket.Conn]*tokenBucket)
+var connectionViolations = make(map[*websocket.Conn]int)
+var addressBuckets = make(map[common.Address]*tokenBucket)
+
+func init() {
+	rateLimits.Store(RateLimits{})
+}
+
+// SetRateLimits sets the rate limits of the messages sent by the clients
+func SetRateLimits(l RateLimits) {
+	rateLimits.Store(l)
+}
+
+// getRateLimits returns the rate limits of the messages sent by the clients
+func getRateLimits() RateLimits {
+	return rateLimits.Load().(RateLimits)
+}
+
+// tokenBucket holds the messages a client can send right away, refilled at the rate of
+// the limit up to its burst
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	mutex  sync.Mutex
+}
+
+// take removes a token from the bucket if there is one
+func (b *tokenBucket) take(rate float64, burst int, now time.Time) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	capacity := float64(burstOf(rate, burst))
+	if b.last.IsZero() {
+		b.tokens = capacity
+	} else if now.After(b.last) {
+		b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// burstOf returns the burst of a limit, at least one message and one second of messages
+// when it is not set
+func burstOf(rate float64, burst int) int {
+	if burst > 0 {
+		return burst
+	}
+
+	return int(math.Max(1, math.Ceil(rate)))
+}
+
+// allowMessage returns nil if a message of a connection is within the rate limits of the
+// connection and of its address. Otherwise it returns the error to send and whether the
+// connection is abusive and must be closed.
+func allowMessage(conn *websocket.Conn, now time.Time) (*RateLimitError, bool) {
+	l := getRateLimits()
+	if l.ConnectionRate <= 0 && l.AddressRate <= 0 {
+		return nil, false
+	}
+
+	connectionsMutex.Lock()
+	connBucket := connectionBuckets[conn]
+	if connBucket == nil {
+		connBucket = &tokenBucket{}
+		connectionBuckets[conn] = connBucket
+	}
+
+	var addrBucket *tokenBucket
+	if addr, ok := connectionAddresses[conn]; ok && l.AddressRate > 0 {
+		addrBucket = addressBuckets[addr]
+		if addrBucket == nil {
+			addrBucket = &tokenBucket{}
+			addressBuckets[addr] = addrBucket
+		}
+	}
+	connectionsMutex.Unlock()
+
+	var err *RateLimitError
+	if l.ConnectionRate > 0 && !connBucket.take(l.ConnectionRate, l.ConnectionBurst, now) {
+		err = &RateLimitError{
+			Code:    aerrors.RateLimitExceeded.Code,
+			Message: fmt.Sprintf("The connection can send %g messages per second", l.ConnectionRate),
+			Rate:    l.ConnectionRate,
+		}
+	} else if addrBucket != nil && !addrBucket.take(l.AddressRate, l.AddressBurst, now) {
+		err = &RateLimitError{
+			Code:    aerrors.RateLimitExceeded.Code,
+			Message: fmt.Sprintf("The connections of the address can send %g messages per second", l.AddressRate),
+			Rate:    l.AddressRate,
+		}
+	}
+
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+
+	if err == nil {
+		delete(connectionViolations, conn)
+		return nil, false
+	}
+
+	connectionViolations[conn]++
+	return err, l.MaxViolations > 0 && connectionViolations[conn] >= l.MaxViolations
+}
+
+// closeAbusiveConnection closes a connection that kept sending messages over the rate
+// limits with the policy violation close code (1008)
+func closeAbusiveConnection(conn *websocket.Conn) {
+	msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "RATE_LIMIT_EXCEEDED")
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	closeConnection(conn)
+}
+
+// forgetAddressBucket removes the bucket of an address once none of its connections is
+// left. It is called with connectionsMutex held.
+func forgetAddressBucket(addr common.Address) {
+	for _, a := range connectionAddresses {
+		if a == addr {
+			return
+		}
+	}
+
+	delete(addressBuckets, addr)
+}
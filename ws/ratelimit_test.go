@@ -0,0 +1,90 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := &tokenBucket{}
+	now := testTime()
+
+	// the bucket starts full
+	assert.True(t, b.take(2, 3, now))
+	assert.True(t, b.take(2, 3, now))
+	assert.True(t, b.take(2, 3, now))
+	assert.False(t, b.take(2, 3, now))
+
+	// it is refilled at the rate of the limit, up to the burst
+	assert.True(t, b.take(2, 3, now.Add(500*time.Millisecond)))
+	assert.False(t, b.take(2, 3, now.Add(500*time.Millisecond)))
+
+	later := now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, b.take(2, 3, later))
+	}
+
+	assert.False(t, b.take(2, 3, later))
+}
+
+func TestAllowMessage(t *testing.T) {
+	SetRateLimits(RateLimits{ConnectionRate: 1, ConnectionBurst: 2, AddressRate: 1, AddressBurst: 3, MaxViolations: 2})
+	defer SetRateLimits(RateLimits{})
+
+	conn, _ := newTestConnection(t)
+	initConnection(conn)
+	defer forgetConnection(conn)
+	now := testTime()
+
+	err, _ := allowMessage(conn, now)
+	assert.Nil(t, err)
+	err, _ = allowMessage(conn, now)
+	assert.Nil(t, err)
+
+	err, abusive := allowMessage(conn, now)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "RATE_LIMIT_EXCEEDED", err.Code)
+	}
+
+	assert.False(t, abusive)
+
+	// the connection is abusive after MaxViolations consecutive rejected messages
+	_, abusive = allowMessage(conn, now)
+	assert.True(t, abusive)
+
+	// an allowed message resets the rejected messages
+	err, _ = allowMessage(conn, now.Add(time.Second))
+	assert.Nil(t, err)
+	_, abusive = allowMessage(conn, now.Add(time.Second))
+	assert.False(t, abusive)
+
+	// the connections of an address share the bucket of the address
+	addr := common.HexToAddress("0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa")
+	first, _ := newTestConnection(t)
+	second, _ := newTestConnection(t)
+	initConnection(first)
+	initConnection(second)
+	Authenticate(first, addr)
+	Authenticate(second, addr)
+
+	err, _ = allowMessage(first, now)
+	assert.Nil(t, err)
+	err, _ = allowMessage(first, now)
+	assert.Nil(t, err)
+	err, _ = allowMessage(second, now)
+	assert.Nil(t, err)
+
+	err, _ = allowMessage(second, now)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Message, "address")
+	}
+
+	// the bucket of the address is forgotten with its last connection
+	forgetConnection(first)
+	assert.NotNil(t, addressBuckets[addr])
+	forgetConnection(second)
+	assert.Nil(t, addressBuckets[addr])
+}
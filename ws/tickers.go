@@ -0,0 +1,94 @@
+package ws
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+var tickerSocket = &TickerSocket{subscriptions: make(map[*websocket.Conn]bool)}
+
+// TickerSocket holds the connections subscribed to the 24 hour tickers of the pairs.
+// mutex protects the subscriptions map
+type TickerSocket struct {
+	subscriptions map[*websocket.Conn]bool
+	mutex         sync.RWMutex
+}
+
+// GetTickerSocket returns the socket of the tickers channel
+func GetTickerSocket() *TickerSocket {
+	return tickerSocket
+}
+
+// Subscribe registers a websocket connection to the tickers
+func (s *TickerSocket) Subscribe(conn *websocket.Conn) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.subscriptions[conn] {
+		metrics.subscribed(TickerChannel, "", 1)
+	}
+
+	s.subscriptions[conn] = true
+	return nil
+}
+
+// Unsubscribe removes a websocket connection from the tickers
+func (s *TickerSocket) Unsubscribe(conn *websocket.Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.subscriptions[conn] {
+		delete(s.subscriptions, conn)
+		metrics.subscribed(TickerChannel, "", -1)
+	}
+}
+
+// UnsubscribeHandler unsubscribes a connection from the tickers
+func (s *TickerSocket) UnsubscribeHandler() func(conn *websocket.Conn) {
+	return func(conn *websocket.Conn) {
+		s.Unsubscribe(conn)
+	}
+}
+
+// BroadcastMessage sends a message to all the connections subscribed to the tickers
+func (s *TickerSocket) BroadcastMessage(msgType string, p interface{}) {
+	conns := s.connections()
+	metrics.sent(TickerChannel, "", len(conns))
+
+	go func() {
+		for _, conn := range conns {
+			SendTickerMessage(conn, msgType, p)
+		}
+	}()
+}
+
+// HasSubscribers returns true if connections are subscribed to the tickers
+func (s *TickerSocket) HasSubscribers() bool {
+	return len(s.connections()) > 0
+}
+
+// connections returns the connections subscribed to the tickers
+func (s *TickerSocket) connections() []*websocket.Conn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	conns := []*websocket.Conn{}
+	for conn, active := range s.subscriptions {
+		if active {
+			conns = append(conns, conn)
+		}
+	}
+
+	return conns
+}
+
+// SendTickerMessage sends a websocket message on the tickers channel
+func SendTickerMessage(conn *websocket.Conn, msgType string, p interface{}) {
+	SendMessage(conn, TickerChannel, msgType, p)
+}
+
+// SendTickerErrorMessage sends an error message on the tickers channel
+func SendTickerErrorMessage(conn *websocket.Conn, p interface{}) {
+	SendTickerMessage(conn, "ERROR", p)
+}
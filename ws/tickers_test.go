@@ -0,0 +1,51 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Proofsuite/amp-matching-engine/types"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTickerSocket(t *testing.T) {
+	server, client := newTestConnection(t)
+	defer client.Close()
+	defer server.Close()
+
+	socket := &TickerSocket{subscriptions: make(map[*websocket.Conn]bool)}
+	assert.False(t, socket.HasSubscribers())
+
+	socket.Subscribe(server)
+	assert.True(t, socket.HasSubscribers())
+
+	tickers := []*types.Ticker{types.NewTicker(testPair(), nil, nil, nil, testTime())}
+	socket.BroadcastMessage("UPDATE", tickers)
+
+	_, p, err := client.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &struct {
+		Channel string `json:"channel"`
+		Payload struct {
+			Type string         `json:"type"`
+			Data []types.Ticker `json:"data"`
+		} `json:"payload"`
+	}{}
+
+	err = json.Unmarshal(p, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, TickerChannel, msg.Channel)
+	assert.Equal(t, "UPDATE", msg.Payload.Type)
+	assert.Equal(t, 1, len(msg.Payload.Data))
+	assert.Equal(t, testPair().Reference(), msg.Payload.Data[0].Pair)
+
+	socket.Unsubscribe(server)
+	assert.False(t, socket.HasSubscribers())
+}
@@ -1,41 +1,51 @@
 package ws
 
 import (
-	"github.com/Proofsuite/amp-matching-engine/types"
+	"sync"
+
 	"github.com/gorilla/websocket"
 )
 
-var tradeSocket *TradeSocket
+var tradeSocket = &TradeSocket{subscriptions: make(map[string]map[*websocket.Conn]bool)}
 
 // TradeSocket holds the map of connections subscribed to pair channels
 // corresponding to the key/event they have subscribed to.
+// mutex protects the subscriptions map
 type TradeSocket struct {
 	subscriptions map[string]map[*websocket.Conn]bool
+	mutex         sync.RWMutex
 }
 
 func GetTradeSocket() *TradeSocket {
-	if tradeSocket == nil {
-		tradeSocket = &TradeSocket{make(map[string]map[*websocket.Conn]bool)}
-	}
-
 	return tradeSocket
 }
 
 // Subscribe registers a new websocket connections to the trade channel updates
 func (s *TradeSocket) Subscribe(channelId string, conn *websocket.Conn) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	if s.subscriptions[channelId] == nil {
 		s.subscriptions[channelId] = make(map[*websocket.Conn]bool)
 	}
 
+	if !s.subscriptions[channelId][conn] {
+		metrics.subscribed(TradeChannel, channelId, 1)
+	}
+
 	s.subscriptions[channelId][conn] = true
 	return nil
 }
 
 // Unsubscribe removes a websocket connection from the trade channel updates
 func (s *TradeSocket) Unsubscribe(channelId string, conn *websocket.Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	if s.subscriptions[channelId][conn] {
 		s.subscriptions[channelId][conn] = false
 		delete(s.subscriptions[channelId], conn)
+		metrics.subscribed(TradeChannel, channelId, -1)
 	}
 }
 
@@ -46,16 +56,33 @@ func (s *TradeSocket) UnsubscribeHandler(channelId string) func(conn *websocket.
 	}
 }
 
-func (s *TradeSocket) BroadcastMessage(channelId string, msgType string, p *types.WebSocketPayload) {
+// BroadcastMessage sends a message to all the connections subscribed to a trade channel
+func (s *TradeSocket) BroadcastMessage(channelId string, msgType string, p interface{}) {
+	conns := s.connections(channelId)
+	metrics.sent(TradeChannel, channelId, len(conns))
+
 	go func() {
-		for conn, active := range tradeSocket.subscriptions[channelId] {
-			if active {
-				SendTradeMessage(conn, msgType, p)
-			}
+		for _, conn := range conns {
+			SendTradeMessage(conn, msgType, p)
 		}
 	}()
 }
 
+// connections returns the connections subscribed to a channel
+func (s *TradeSocket) connections(channelId string) []*websocket.Conn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	conns := []*websocket.Conn{}
+	for conn, active := range s.subscriptions[channelId] {
+		if active {
+			conns = append(conns, conn)
+		}
+	}
+
+	return conns
+}
+
 // SendTradeMesage sends a websocket message on the trade channel
 func SendTradeMessage(conn *websocket.Conn, msgType string, p interface{}) {
 	SendMessage(conn, TradeChannel, msgType, p)
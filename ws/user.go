@@ -0,0 +1,131 @@
+package ws
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+)
+
+var userSocket = newUserSocket(UserChannel)
+var balanceSocket = newUserSocket(BalanceChannel)
+
+// UserSocket holds the map of connections subscribed to the private messages of an
+// account on a channel (the fills on the user channel, the token balances on the
+// balances channel). Subscriptions are authenticated so the messages are sent with full
+// detail.
+// mutex protects the subscriptions map
+type UserSocket struct {
+	channel       string
+	subscriptions map[common.Address]map[*websocket.Conn]bool
+	mutex         sync.RWMutex
+}
+
+func newUserSocket(channel string) *UserSocket {
+	return &UserSocket{channel: channel, subscriptions: make(map[common.Address]map[*websocket.Conn]bool)}
+}
+
+// GetUserSocket returns the socket of the user channel
+func GetUserSocket() *UserSocket {
+	return userSocket
+}
+
+// GetBalanceSocket returns the socket of the balances channel
+func GetBalanceSocket() *UserSocket {
+	return balanceSocket
+}
+
+// Subscribe registers a websocket connection to the messages of an account
+func (s *UserSocket) Subscribe(addr common.Address, conn *websocket.Conn) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.subscriptions[addr] == nil {
+		s.subscriptions[addr] = make(map[*websocket.Conn]bool)
+	}
+
+	// the accounts are not tracked individually in the metrics
+	if !s.subscriptions[addr][conn] {
+		metrics.subscribed(s.channel, "", 1)
+	}
+
+	s.subscriptions[addr][conn] = true
+	return nil
+}
+
+// Unsubscribe removes a websocket connection from the messages of an account
+func (s *UserSocket) Unsubscribe(addr common.Address, conn *websocket.Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.subscriptions[addr][conn] {
+		delete(s.subscriptions[addr], conn)
+		metrics.subscribed(s.channel, "", -1)
+	}
+
+	if len(s.subscriptions[addr]) == 0 {
+		delete(s.subscriptions, addr)
+	}
+}
+
+// UnsubscribeHandler unsubscribes a connection from the messages of an account
+func (s *UserSocket) UnsubscribeHandler(addr common.Address) func(conn *websocket.Conn) {
+	return func(conn *websocket.Conn) {
+		s.Unsubscribe(addr, conn)
+	}
+}
+
+// BroadcastMessage sends a message to all the connections subscribed to an account
+func (s *UserSocket) BroadcastMessage(addr common.Address, msgType string, p interface{}) {
+	conns := s.connections(addr)
+	metrics.sent(s.channel, "", len(conns))
+
+	go func() {
+		for _, conn := range conns {
+			SendMessage(conn, s.channel, msgType, p)
+		}
+	}()
+}
+
+// IsSubscribed returns true if a connection is subscribed to the messages of an account
+func (s *UserSocket) IsSubscribed(addr common.Address) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.subscriptions[addr]) > 0
+}
+
+// connections returns the connections subscribed to an account
+func (s *UserSocket) connections(addr common.Address) []*websocket.Conn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	conns := []*websocket.Conn{}
+	for conn, active := range s.subscriptions[addr] {
+		if active {
+			conns = append(conns, conn)
+		}
+	}
+
+	return conns
+}
+
+// SendUserMessage sends a websocket message on the user channel
+func SendUserMessage(conn *websocket.Conn, msgType string, p interface{}) {
+	SendMessage(conn, UserChannel, msgType, p)
+}
+
+// SendUserErrorMessage sends an error message on the user channel
+func SendUserErrorMessage(conn *websocket.Conn, p interface{}) {
+	SendUserMessage(conn, "ERROR", p)
+}
+
+// SendBalanceMessage sends a websocket message on the balances channel
+func SendBalanceMessage(conn *websocket.Conn, msgType string, p interface{}) {
+	SendMessage(conn, BalanceChannel, msgType, p)
+}
+
+// SendBalanceErrorMessage sends an error message on the balances channel
+func SendBalanceErrorMessage(conn *websocket.Conn, p interface{}) {
+	SendBalanceMessage(conn, "ERROR", p)
+}